@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// BlobCache defines raw binary cache operations that bypass the JSON codec,
+// for payloads such as rendered PDFs, images or pre-compressed blobs.
+// The Redis and Mongo caches returned by this package implement it:
+//
+//	blobs := cache.NewRedisCache(client, time.Hour, cache.WithCompression(1024)).(cache.BlobCache)
+type BlobCache interface {
+	// SetBytes stores data under key. If ttl <= 0, the default TTL is used.
+	SetBytes(ctx context.Context, key string, data []byte, ttl time.Duration) error
+
+	// GetBytes returns the data stored under key.
+	// It returns (nil, false, nil) if the key is not found.
+	GetBytes(ctx context.Context, key string) ([]byte, bool, error)
+
+	// SetStream stores everything read from r under key. Compression, when
+	// enabled, is applied while reading.
+	SetStream(ctx context.Context, key string, r io.Reader, ttl time.Duration) error
+
+	// GetStream writes the data stored under key to w.
+	// It returns (false, nil) if the key is not found.
+	GetStream(ctx context.Context, key string, w io.Writer) (bool, error)
+}
+
+// Option customizes a cache created by this package.
+type Option func(*cacheOptions)
+
+type cacheOptions struct {
+	// compressMin is the minimum payload size that gets gzip-compressed.
+	// Zero disables compression.
+	compressMin int
+}
+
+func applyOptions(opts []Option) cacheOptions {
+	var o cacheOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithCompression gzip-compresses binary payloads of at least minSize bytes
+// stored via BlobCache. Smaller payloads are stored as-is.
+func WithCompression(minSize int) Option {
+	return func(o *cacheOptions) {
+		if minSize < 1 {
+			minSize = 1
+		}
+		o.compressMin = minSize
+	}
+}
+
+// Blob payloads are prefixed with a single header byte describing the encoding.
+const (
+	blobRaw  byte = 0x00
+	blobGzip byte = 0x01
+)
+
+// encodeBlob prepends the encoding header and compresses data when enabled.
+func (o cacheOptions) encodeBlob(data []byte) ([]byte, error) {
+	if o.compressMin == 0 || len(data) < o.compressMin {
+		return append([]byte{blobRaw}, data...), nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(blobGzip)
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeStream reads r and encodes it like encodeBlob. When compression is
+// enabled, data is compressed as it is read rather than buffered twice.
+func (o cacheOptions) encodeStream(r io.Reader) ([]byte, error) {
+	if o.compressMin == 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return o.encodeBlob(data)
+	}
+
+	// Read just enough to decide whether the payload reaches the threshold.
+	head := make([]byte, o.compressMin)
+	n, err := io.ReadFull(r, head)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return append([]byte{blobRaw}, head[:n]...), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(blobGzip)
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(head); err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(zw, r); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeBlob writes the payload in stored to w, decompressing if needed.
+func decodeBlob(stored []byte, w io.Writer) error {
+	if len(stored) == 0 {
+		return fmt.Errorf("invalid blob: missing header")
+	}
+	switch stored[0] {
+	case blobRaw:
+		_, err := w.Write(stored[1:])
+		return err
+	case blobGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(stored[1:]))
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		_, err = io.Copy(w, zr)
+		return err
+	default:
+		return fmt.Errorf("invalid blob: unknown encoding %#x", stored[0])
+	}
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestBlobCaches(t *testing.T, opts ...Option) map[string]BlobCache {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return map[string]BlobCache{
+		"redis": NewRedisCache(client, time.Minute, opts...).(BlobCache),
+		"mongo": NewMongoCache(newFakeCollection(), time.Minute, opts...).(BlobCache),
+	}
+}
+
+func TestBlobCache_SetAndGetBytes(t *testing.T) {
+	for name, c := range newTestBlobCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			data := []byte{0x00, 0xff, 0x10, 'p', 'd', 'f'}
+
+			if err := c.SetBytes(ctx, "blob", data, 0); err != nil {
+				t.Fatalf("SetBytes failed: %v", err)
+			}
+			got, found, err := c.GetBytes(ctx, "blob")
+			if err != nil || !found {
+				t.Fatalf("GetBytes failed: found=%v err=%v", found, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("expected %v, got %v", data, got)
+			}
+
+			if _, found, _ := c.GetBytes(ctx, "missing"); found {
+				t.Error("expected missing key to return found=false")
+			}
+		})
+	}
+}
+
+func TestBlobCache_StreamWithCompression(t *testing.T) {
+	for name, c := range newTestBlobCaches(t, WithCompression(16)) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			payload := strings.Repeat("compressible ", 1000)
+
+			if err := c.SetStream(ctx, "big", strings.NewReader(payload), 0); err != nil {
+				t.Fatalf("SetStream failed: %v", err)
+			}
+			var out bytes.Buffer
+			found, err := c.GetStream(ctx, "big", &out)
+			if err != nil || !found {
+				t.Fatalf("GetStream failed: found=%v err=%v", found, err)
+			}
+			if out.String() != payload {
+				t.Error("expected streamed payload to round-trip")
+			}
+
+			// Payloads below the threshold are stored uncompressed.
+			if err := c.SetStream(ctx, "small", strings.NewReader("tiny"), 0); err != nil {
+				t.Fatalf("SetStream failed: %v", err)
+			}
+			got, _, _ := c.GetBytes(ctx, "small")
+			if string(got) != "tiny" {
+				t.Errorf("expected tiny, got %q", got)
+			}
+		})
+	}
+}
+
+func TestEncodeBlob_Compresses(t *testing.T) {
+	o := applyOptions([]Option{WithCompression(1)})
+	data := []byte(strings.Repeat("a", 4096))
+
+	stored, err := o.encodeBlob(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored[0] != blobGzip || len(stored) >= len(data) {
+		t.Errorf("expected gzip-compressed blob, got header %#x and %d bytes", stored[0], len(stored))
+	}
+}
+
+func TestDecodeBlob_Invalid(t *testing.T) {
+	var out bytes.Buffer
+	if err := decodeBlob(nil, &out); err == nil {
+		t.Error("expected error for empty blob")
+	}
+	if err := decodeBlob([]byte{0x7f, 1}, &out); err == nil {
+		t.Error("expected error for unknown encoding")
+	}
+}
@@ -1,11 +1,13 @@
 // Package cache provides a lightweight interface for caching structured
-// data using Redis. It supports JSON serialization for convenience and
-// defines a generic Cache interface that can be implemented by other backends.
+// data using Redis or MongoDB. It supports JSON serialization for convenience
+// and defines a generic Cache interface that can be implemented by other backends.
 package cache
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -33,6 +35,7 @@ type Cache interface {
 type redisCache struct {
 	client *redis.Client
 	ttl    time.Duration
+	opts   cacheOptions
 }
 
 // NewRedisCache returns a new Redis-backed Cache instance.
@@ -43,8 +46,10 @@ type redisCache struct {
 //	    Addr: "localhost:6379",
 //	})
 //	cache := cache.NewRedisCache(client, 10*time.Minute)
-func NewRedisCache(client *redis.Client, defaultTTL time.Duration) Cache {
-	return &redisCache{client: client, ttl: defaultTTL}
+//
+// The returned value also implements BlobCache.
+func NewRedisCache(client *redis.Client, defaultTTL time.Duration, opts ...Option) Cache {
+	return &redisCache{client: client, ttl: defaultTTL, opts: applyOptions(opts)}
 }
 
 // DefaultTTL implements Cache.DefaultTTL.
@@ -78,3 +83,50 @@ func (c *redisCache) SetJSON(ctx context.Context, key string, v any, ttl time.Du
 func (c *redisCache) Delete(ctx context.Context, key string) error {
 	return c.client.Del(ctx, key).Err()
 }
+
+// SetBytes implements BlobCache.SetBytes.
+func (c *redisCache) SetBytes(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	stored, err := c.opts.encodeBlob(data)
+	if err != nil {
+		return err
+	}
+	return c.setRaw(ctx, key, stored, ttl)
+}
+
+// GetBytes implements BlobCache.GetBytes.
+func (c *redisCache) GetBytes(ctx context.Context, key string) ([]byte, bool, error) {
+	var buf bytes.Buffer
+	found, err := c.GetStream(ctx, key, &buf)
+	if !found || err != nil {
+		return nil, found, err
+	}
+	return buf.Bytes(), true, nil
+}
+
+// SetStream implements BlobCache.SetStream.
+func (c *redisCache) SetStream(ctx context.Context, key string, r io.Reader, ttl time.Duration) error {
+	stored, err := c.opts.encodeStream(r)
+	if err != nil {
+		return err
+	}
+	return c.setRaw(ctx, key, stored, ttl)
+}
+
+// GetStream implements BlobCache.GetStream.
+func (c *redisCache) GetStream(ctx context.Context, key string, w io.Writer) (bool, error) {
+	val, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, decodeBlob(val, w)
+}
+
+func (c *redisCache) setRaw(ctx context.Context, key string, stored []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	return c.client.Set(ctx, key, stored, ttl).Err()
+}
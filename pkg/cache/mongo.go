@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoCollection defines the subset of *mongo.Collection used by the Mongo
+// cache. This makes it mockable in tests.
+type MongoCollection interface {
+	FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
+	ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error)
+	DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+}
+
+// mongoEntry is the document stored for each cached key. ExpiresAt is nil
+// for entries that never expire, which the TTL index then ignores.
+type mongoEntry struct {
+	Key       string     `bson:"_id"`
+	Value     string     `bson:"value,omitempty"`
+	Data      []byte     `bson:"data,omitempty"`
+	ExpiresAt *time.Time `bson:"expiresAt,omitempty"`
+}
+
+// mongoCache implements Cache using a MongoDB collection as the backend.
+type mongoCache struct {
+	coll MongoCollection
+	ttl  time.Duration
+	opts cacheOptions
+	now  func() time.Time
+}
+
+// NewMongoCache returns a Cache backed by a MongoDB collection. Expired
+// documents are removed by MongoDB's TTL monitor once EnsureMongoTTLIndex has
+// been called; until then they are ignored on read. The returned value also
+// implements BlobCache.
+//
+// Example:
+//
+//	coll := client.Database("appdb").Collection("cache")
+//	_ = cache.EnsureMongoTTLIndex(ctx, coll)
+//	c := cache.NewMongoCache(coll, 10*time.Minute)
+func NewMongoCache(coll MongoCollection, defaultTTL time.Duration, opts ...Option) Cache {
+	return &mongoCache{coll: coll, ttl: defaultTTL, opts: applyOptions(opts), now: time.Now}
+}
+
+// EnsureMongoTTLIndex creates the TTL index that lets MongoDB expire cached
+// documents automatically. It is safe to call on every startup.
+func EnsureMongoTTLIndex(ctx context.Context, coll *mongo.Collection) error {
+	_, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// DefaultTTL implements Cache.DefaultTTL.
+func (c *mongoCache) DefaultTTL() time.Duration { return c.ttl }
+
+// GetJSON implements Cache.GetJSON.
+func (c *mongoCache) GetJSON(ctx context.Context, key string, out any) (bool, error) {
+	doc, err := c.find(ctx, key)
+	if doc == nil || err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal([]byte(doc.Value), out)
+}
+
+// SetJSON implements Cache.SetJSON.
+func (c *mongoCache) SetJSON(ctx context.Context, key string, v any, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.replace(ctx, mongoEntry{Key: key, Value: string(data)}, ttl)
+}
+
+// Delete implements Cache.Delete.
+func (c *mongoCache) Delete(ctx context.Context, key string) error {
+	_, err := c.coll.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+// SetBytes implements BlobCache.SetBytes.
+func (c *mongoCache) SetBytes(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	stored, err := c.opts.encodeBlob(data)
+	if err != nil {
+		return err
+	}
+	return c.replace(ctx, mongoEntry{Key: key, Data: stored}, ttl)
+}
+
+// GetBytes implements BlobCache.GetBytes.
+func (c *mongoCache) GetBytes(ctx context.Context, key string) ([]byte, bool, error) {
+	var buf bytes.Buffer
+	found, err := c.GetStream(ctx, key, &buf)
+	if !found || err != nil {
+		return nil, found, err
+	}
+	return buf.Bytes(), true, nil
+}
+
+// SetStream implements BlobCache.SetStream.
+func (c *mongoCache) SetStream(ctx context.Context, key string, r io.Reader, ttl time.Duration) error {
+	stored, err := c.opts.encodeStream(r)
+	if err != nil {
+		return err
+	}
+	return c.replace(ctx, mongoEntry{Key: key, Data: stored}, ttl)
+}
+
+// GetStream implements BlobCache.GetStream.
+func (c *mongoCache) GetStream(ctx context.Context, key string, w io.Writer) (bool, error) {
+	doc, err := c.find(ctx, key)
+	if doc == nil || err != nil {
+		return false, err
+	}
+	return true, decodeBlob(doc.Data, w)
+}
+
+// find returns the live document for key, or nil if it is missing or expired.
+func (c *mongoCache) find(ctx context.Context, key string) (*mongoEntry, error) {
+	var doc mongoEntry
+	err := c.coll.FindOne(ctx, bson.M{"_id": key}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The TTL monitor runs periodically, so expired documents may linger.
+	if doc.ExpiresAt != nil && !doc.ExpiresAt.After(c.now()) {
+		return nil, nil
+	}
+	return &doc, nil
+}
+
+// replace upserts doc with an expiry derived from ttl. Like Redis, a TTL of
+// 0 after applying the default means the entry never expires.
+func (c *mongoCache) replace(ctx context.Context, doc mongoEntry, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	if ttl > 0 {
+		expiresAt := c.now().Add(ttl)
+		doc.ExpiresAt = &expiresAt
+	}
+	_, err := c.coll.ReplaceOne(ctx, bson.M{"_id": doc.Key}, doc, options.Replace().SetUpsert(true))
+	return err
+}
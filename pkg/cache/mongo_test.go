@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fakeCollection is an in-memory MongoCollection keyed by _id.
+type fakeCollection struct {
+	docs map[string]mongoEntry
+}
+
+func newFakeCollection() *fakeCollection {
+	return &fakeCollection{docs: make(map[string]mongoEntry)}
+}
+
+func idFrom(filter interface{}) string {
+	return filter.(bson.M)["_id"].(string)
+}
+
+func (f *fakeCollection) FindOne(ctx context.Context, filter interface{}, _ ...*options.FindOneOptions) *mongo.SingleResult {
+	doc, ok := f.docs[idFrom(filter)]
+	if !ok {
+		return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+	}
+	return mongo.NewSingleResultFromDocument(doc, nil, nil)
+}
+
+func (f *fakeCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, _ ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	f.docs[idFrom(filter)] = replacement.(mongoEntry)
+	return &mongo.UpdateResult{MatchedCount: 1}, nil
+}
+
+func (f *fakeCollection) DeleteOne(ctx context.Context, filter interface{}, _ ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	delete(f.docs, idFrom(filter))
+	return &mongo.DeleteResult{DeletedCount: 1}, nil
+}
+
+func TestMongoCache_SetAndGetJSON(t *testing.T) {
+	coll := newFakeCollection()
+	c := NewMongoCache(coll, time.Minute)
+	ctx := context.Background()
+
+	type user struct {
+		Name string
+		Age  int
+	}
+	u := user{"Alice", 30}
+
+	if err := c.SetJSON(ctx, "user:1", u, 0); err != nil {
+		t.Fatalf("SetJSON failed: %v", err)
+	}
+
+	var out user
+	found, err := c.GetJSON(ctx, "user:1", &out)
+	if err != nil || !found {
+		t.Fatalf("GetJSON failed: found=%v err=%v", found, err)
+	}
+	if out != u {
+		t.Errorf("expected %+v, got %+v", u, out)
+	}
+	if c.DefaultTTL() != time.Minute {
+		t.Errorf("expected default TTL of 1m, got %v", c.DefaultTTL())
+	}
+}
+
+func TestMongoCache_MissingAndExpired(t *testing.T) {
+	coll := newFakeCollection()
+	c := NewMongoCache(coll, time.Minute).(*mongoCache)
+	ctx := context.Background()
+
+	var out string
+	if found, err := c.GetJSON(ctx, "missing", &out); found || err != nil {
+		t.Fatalf("expected miss, got found=%v err=%v", found, err)
+	}
+
+	_ = c.SetJSON(ctx, "k", "v", time.Second)
+	c.now = func() time.Time { return time.Now().Add(2 * time.Second) }
+
+	if found, _ := c.GetJSON(ctx, "k", &out); found {
+		t.Error("expected expired entry to be treated as a miss")
+	}
+}
+
+func TestMongoCache_NoDefaultTTLNeverExpires(t *testing.T) {
+	coll := newFakeCollection()
+	c := NewMongoCache(coll, 0).(*mongoCache)
+	ctx := context.Background()
+
+	_ = c.SetJSON(ctx, "k", "v", 0)
+	if coll.docs["k"].ExpiresAt != nil {
+		t.Fatalf("expected no expiry, got %v", coll.docs["k"].ExpiresAt)
+	}
+
+	c.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+	var out string
+	if found, _ := c.GetJSON(ctx, "k", &out); !found || out != "v" {
+		t.Errorf("expected entry without TTL to be kept, got found=%v out=%q", found, out)
+	}
+}
+
+func TestMongoCache_Delete(t *testing.T) {
+	coll := newFakeCollection()
+	c := NewMongoCache(coll, time.Minute)
+	ctx := context.Background()
+
+	_ = c.SetJSON(ctx, "k", "v", 0)
+	if err := c.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	var out string
+	if found, _ := c.GetJSON(ctx, "k", &out); found {
+		t.Error("expected key to be deleted")
+	}
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisAddr is used when RedisConfig.Addr is empty.
+const DefaultRedisAddr = "localhost:6379"
+
+// RedisConfig holds Redis connection settings shared by the Redis cache and
+// other Redis-backed packages, so a service configures its connection once.
+type RedisConfig struct {
+	Addr     string
+	Username string
+	Password string
+	DB       int
+
+	// TLS enables TLS with the system root certificates.
+	TLS bool
+}
+
+// NewRedisConfigFromEnv builds configuration from environment variables:
+//
+//	REDIS_ADDR      host:port, default localhost:6379
+//	REDIS_USERNAME  ACL user
+//	REDIS_PASSWORD  password
+//	REDIS_DB        integer, database number
+//	REDIS_TLS       bool
+func NewRedisConfigFromEnv() (*RedisConfig, error) {
+	cfg := &RedisConfig{
+		Addr:     os.Getenv("REDIS_ADDR"),
+		Username: os.Getenv("REDIS_USERNAME"),
+		Password: os.Getenv("REDIS_PASSWORD"),
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = DefaultRedisAddr
+	}
+
+	var err error
+	if v := os.Getenv("REDIS_DB"); v != "" {
+		if cfg.DB, err = strconv.Atoi(v); err != nil || cfg.DB < 0 {
+			return nil, fmt.Errorf("invalid REDIS_DB %q", v)
+		}
+	}
+	if v := os.Getenv("REDIS_TLS"); v != "" {
+		if cfg.TLS, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid REDIS_TLS: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// Options returns the go-redis client options for cfg.
+func (c *RedisConfig) Options() *redis.Options {
+	opts := &redis.Options{
+		Addr:     c.Addr,
+		Username: c.Username,
+		Password: c.Password,
+		DB:       c.DB,
+	}
+	if opts.Addr == "" {
+		opts.Addr = DefaultRedisAddr
+	}
+	if c.TLS {
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return opts
+}
+
+// NewClient returns a client for cfg. Share it between the packages using
+// Redis; it maintains its own connection pool.
+//
+// Example:
+//
+//	cfg, err := cache.NewRedisConfigFromEnv()
+//	if err != nil {
+//	    return err
+//	}
+//	client := cfg.NewClient()
+//	c := cache.NewRedisCache(client, 10*time.Minute)
+func (c *RedisConfig) NewClient() *redis.Client {
+	return redis.NewClient(c.Options())
+}
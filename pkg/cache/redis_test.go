@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestNewRedisConfigFromEnv(t *testing.T) {
+	t.Setenv("REDIS_ADDR", "")
+	cfg, err := NewRedisConfigFromEnv()
+	if err != nil {
+		t.Fatalf("NewRedisConfigFromEnv failed: %v", err)
+	}
+	if cfg.Addr != DefaultRedisAddr || cfg.DB != 0 || cfg.TLS {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+
+	t.Setenv("REDIS_ADDR", "redis:6380")
+	t.Setenv("REDIS_USERNAME", "svc")
+	t.Setenv("REDIS_PASSWORD", "secret")
+	t.Setenv("REDIS_DB", "2")
+	t.Setenv("REDIS_TLS", "true")
+	cfg, err = NewRedisConfigFromEnv()
+	if err != nil {
+		t.Fatalf("NewRedisConfigFromEnv failed: %v", err)
+	}
+	want := RedisConfig{Addr: "redis:6380", Username: "svc", Password: "secret", DB: 2, TLS: true}
+	if *cfg != want {
+		t.Errorf("expected %+v, got %+v", want, *cfg)
+	}
+
+	opts := cfg.Options()
+	if opts.Addr != "redis:6380" || opts.Username != "svc" || opts.Password != "secret" || opts.DB != 2 || opts.TLSConfig == nil {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+}
+
+func TestNewRedisConfigFromEnv_Invalid(t *testing.T) {
+	for key, value := range map[string]string{"REDIS_DB": "-1", "REDIS_TLS": "sometimes"} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			if _, err := NewRedisConfigFromEnv(); err == nil {
+				t.Errorf("expected error for %s=%s", key, value)
+			}
+		})
+	}
+}
+
+func TestRedisConfig_NewClient(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	client := (&RedisConfig{Addr: mr.Addr()}).NewClient()
+	defer client.Close()
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+}
@@ -114,6 +114,10 @@ func (r *realCollection) Indexes() IndexViewAdapter {
 	return &realIndexView{idx: r.col.Indexes()}
 }
 
+func (r *realCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	return r.col.UpdateOne(ctx, filter, update, opts...)
+}
+
 type realIndexView struct {
 	idx mongo.IndexView
 }
@@ -141,13 +145,14 @@ type MongoDB struct {
 }
 
 // New creates a new MongoDB client and connects to the database at the given URI.
-// It applies a 10-second timeout for establishing the connection.
+// It applies a 10-second timeout for establishing the connection. Optional
+// Option values customize the underlying client options.
 //
 // Example:
 //
 //	db, err := mongo.New("appdb", "mongodb://localhost:27017")
 //	if err != nil { ... }
-func New(name, uri string) (*MongoDB, error) {
+func New(name, uri string, opts ...Option) (*MongoDB, error) {
 	if uri == "" {
 		return nil, fmt.Errorf("MongoDB connection URI cannot be empty")
 	}
@@ -155,7 +160,12 @@ func New(name, uri string) (*MongoDB, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	clientOpts := options.Client().ApplyURI(uri)
+	for _, opt := range opts {
+		opt(clientOpts)
+	}
+
+	client, err := mongo.Connect(ctx, clientOpts)
 	if err != nil {
 		return nil, err
 	}
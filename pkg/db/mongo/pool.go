@@ -0,0 +1,129 @@
+package mongo
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//
+// --- Client Options ---
+//
+
+// Option customizes the mongo.Client options used by New.
+type Option func(*options.ClientOptions)
+
+//
+// --- Connection Pool Metrics ---
+//
+
+// PoolMetrics receives connection pool measurements emitted by the driver.
+// Implementations typically forward these values to a metrics backend
+// (Prometheus gauges, StatsD timers, ...) to alert on pool saturation.
+type PoolMetrics interface {
+	// ConnectionCheckedOut is called when a connection is handed to the
+	// application. inUse is the number of connections currently checked out
+	// from the server's pool and wait is the time spent waiting for it.
+	ConnectionCheckedOut(address string, inUse int64, wait time.Duration)
+
+	// ConnectionCheckedIn is called when a connection is returned to the pool.
+	ConnectionCheckedIn(address string, inUse int64)
+
+	// ConnectionCheckOutFailed is called when a connection could not be
+	// obtained, e.g. because the wait queue timed out.
+	ConnectionCheckOutFailed(address, reason string, wait time.Duration)
+
+	// PoolCleared is called when the driver clears a server's pool, usually
+	// after a network error or failover.
+	PoolCleared(address string)
+}
+
+// WithPoolMetrics installs a PoolMonitor that reports pool activity to m.
+//
+// Example:
+//
+//	db, err := mongo.New("appdb", uri, mongo.WithPoolMetrics(myMetrics))
+func WithPoolMetrics(m PoolMetrics) Option {
+	return func(o *options.ClientOptions) {
+		o.SetPoolMonitor(NewPoolMonitor(m))
+	}
+}
+
+// NewPoolMonitor returns an event.PoolMonitor that translates driver pool
+// events into PoolMetrics calls.
+//
+// Check-out wait times are measured per server address from the oldest
+// pending check-out, which is exact for serialized access and a close
+// approximation under concurrency.
+func NewPoolMonitor(m PoolMetrics) *event.PoolMonitor {
+	t := &poolTracker{
+		metrics: m,
+		inUse:   make(map[string]int64),
+		pending: make(map[string][]time.Time),
+		now:     time.Now,
+	}
+	return &event.PoolMonitor{Event: t.handle}
+}
+
+// poolTracker keeps the per-address state needed to derive gauges and
+// wait durations from the raw event stream.
+type poolTracker struct {
+	mu      sync.Mutex
+	metrics PoolMetrics
+	inUse   map[string]int64
+	pending map[string][]time.Time
+	now     func() time.Time
+}
+
+func (t *poolTracker) handle(e *event.PoolEvent) {
+	if e == nil || t.metrics == nil {
+		return
+	}
+
+	t.mu.Lock()
+	switch e.Type {
+	case event.GetStarted:
+		t.pending[e.Address] = append(t.pending[e.Address], t.now())
+		t.mu.Unlock()
+	case event.GetSucceeded:
+		wait := t.popWait(e.Address)
+		t.inUse[e.Address]++
+		inUse := t.inUse[e.Address]
+		t.mu.Unlock()
+		t.metrics.ConnectionCheckedOut(e.Address, inUse, wait)
+	case event.GetFailed:
+		wait := t.popWait(e.Address)
+		t.mu.Unlock()
+		t.metrics.ConnectionCheckOutFailed(e.Address, e.Reason, wait)
+	case event.ConnectionReturned:
+		if t.inUse[e.Address] > 0 {
+			t.inUse[e.Address]--
+		}
+		inUse := t.inUse[e.Address]
+		t.mu.Unlock()
+		t.metrics.ConnectionCheckedIn(e.Address, inUse)
+	case event.PoolCleared:
+		t.mu.Unlock()
+		t.metrics.PoolCleared(e.Address)
+	default:
+		t.mu.Unlock()
+	}
+}
+
+// popWait removes the oldest pending check-out for address and returns how
+// long it has been waiting. The caller must hold t.mu.
+func (t *poolTracker) popWait(address string) time.Duration {
+	queue := t.pending[address]
+	if len(queue) == 0 {
+		return 0
+	}
+	started := queue[0]
+	if len(queue) == 1 {
+		delete(t.pending, address)
+	} else {
+		t.pending[address] = queue[1:]
+	}
+	return t.now().Sub(started)
+}
@@ -0,0 +1,112 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//
+// --- Mocks ---
+//
+
+type recordedCheckout struct {
+	address string
+	inUse   int64
+	wait    time.Duration
+}
+
+type mockPoolMetrics struct {
+	checkedOut []recordedCheckout
+	checkedIn  []int64
+	failed     []string
+	cleared    []string
+}
+
+func (m *mockPoolMetrics) ConnectionCheckedOut(address string, inUse int64, wait time.Duration) {
+	m.checkedOut = append(m.checkedOut, recordedCheckout{address, inUse, wait})
+}
+
+func (m *mockPoolMetrics) ConnectionCheckedIn(address string, inUse int64) {
+	m.checkedIn = append(m.checkedIn, inUse)
+}
+
+func (m *mockPoolMetrics) ConnectionCheckOutFailed(address, reason string, wait time.Duration) {
+	m.failed = append(m.failed, reason)
+}
+
+func (m *mockPoolMetrics) PoolCleared(address string) {
+	m.cleared = append(m.cleared, address)
+}
+
+//
+// --- NewPoolMonitor() tests ---
+//
+
+func TestPoolMonitor_CheckoutLifecycle(t *testing.T) {
+	m := &mockPoolMetrics{}
+	monitor := NewPoolMonitor(m)
+
+	const addr = "localhost:27017"
+	monitor.Event(&event.PoolEvent{Type: event.GetStarted, Address: addr})
+	monitor.Event(&event.PoolEvent{Type: event.GetSucceeded, Address: addr})
+	monitor.Event(&event.PoolEvent{Type: event.GetStarted, Address: addr})
+	monitor.Event(&event.PoolEvent{Type: event.GetSucceeded, Address: addr})
+	monitor.Event(&event.PoolEvent{Type: event.ConnectionReturned, Address: addr})
+
+	if len(m.checkedOut) != 2 {
+		t.Fatalf("expected 2 checkouts, got %d", len(m.checkedOut))
+	}
+	if m.checkedOut[1].inUse != 2 {
+		t.Errorf("expected 2 connections in use, got %d", m.checkedOut[1].inUse)
+	}
+	if len(m.checkedIn) != 1 || m.checkedIn[0] != 1 {
+		t.Errorf("expected 1 connection in use after check-in, got %v", m.checkedIn)
+	}
+}
+
+func TestPoolMonitor_WaitTime(t *testing.T) {
+	m := &mockPoolMetrics{}
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := &poolTracker{
+		metrics: m,
+		inUse:   make(map[string]int64),
+		pending: make(map[string][]time.Time),
+		now:     func() time.Time { return clock },
+	}
+
+	tracker.handle(&event.PoolEvent{Type: event.GetStarted, Address: "a"})
+	clock = clock.Add(250 * time.Millisecond)
+	tracker.handle(&event.PoolEvent{Type: event.GetSucceeded, Address: "a"})
+
+	if got := m.checkedOut[0].wait; got != 250*time.Millisecond {
+		t.Errorf("expected 250ms wait, got %v", got)
+	}
+}
+
+func TestPoolMonitor_FailuresAndClears(t *testing.T) {
+	m := &mockPoolMetrics{}
+	monitor := NewPoolMonitor(m)
+
+	monitor.Event(&event.PoolEvent{Type: event.GetStarted, Address: "a"})
+	monitor.Event(&event.PoolEvent{Type: event.GetFailed, Address: "a", Reason: event.ReasonTimedOut})
+	monitor.Event(&event.PoolEvent{Type: event.PoolCleared, Address: "a"})
+
+	if len(m.failed) != 1 || m.failed[0] != event.ReasonTimedOut {
+		t.Errorf("expected timeout failure, got %v", m.failed)
+	}
+	if len(m.cleared) != 1 {
+		t.Errorf("expected pool cleared event, got %v", m.cleared)
+	}
+}
+
+func TestWithPoolMetrics_SetsMonitor(t *testing.T) {
+	opts := options.Client()
+	WithPoolMetrics(&mockPoolMetrics{})(opts)
+
+	if opts.PoolMonitor == nil {
+		t.Fatal("expected PoolMonitor to be installed")
+	}
+}
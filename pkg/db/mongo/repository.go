@@ -0,0 +1,222 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ranorsolutions/http-common-go/pkg/db/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//
+// --- Repository ---
+//
+
+// RepositoryCollection defines the subset of *mongo.Collection used by
+// Repository. This makes it mockable in tests.
+type RepositoryCollection interface {
+	FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
+	Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error)
+	InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error)
+	ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error)
+	DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+}
+
+// Repository implements repository.Repository on a MongoDB collection.
+// Entities are mapped with their bson tags and identified by "_id". Query
+// filters and sorts must name a mapped field, optionally followed by a
+// dotted path into it.
+type Repository[T any, ID comparable] struct {
+	coll   RepositoryCollection
+	fields map[string]bool
+}
+
+// NewRepository returns a Repository backed by coll.
+//
+// Example:
+//
+//	users := mongo.NewRepository[User, string](client.Database("appdb").Collection("users"))
+//	var repo repository.Repository[User, string] = users
+func NewRepository[T any, ID comparable](coll RepositoryCollection) *Repository[T, ID] {
+	var fields map[string]bool
+	if t := reflect.TypeOf(*new(T)); t != nil && t.Kind() == reflect.Struct {
+		fields = map[string]bool{}
+		mapFields(t, fields)
+	}
+	return &Repository[T, ID]{coll: coll, fields: fields}
+}
+
+// mapFields collects the bson field names of t, with the default mapping
+// of the driver for untagged fields.
+func mapFields(t reflect.Type, fields map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("bson"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") && f.Type.Kind() == reflect.Struct {
+			mapFields(f.Type, fields)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = true
+	}
+}
+
+// checkField rejects field paths that are not mapped by T or that contain
+// an operator, which would otherwise be injected into the query.
+func (r *Repository[T, ID]) checkField(kind, field string) error {
+	first, _, _ := strings.Cut(field, ".")
+	for _, part := range strings.Split(field, ".") {
+		if part == "" || strings.HasPrefix(part, "$") {
+			return fmt.Errorf("invalid %s field %q", kind, field)
+		}
+	}
+	if r.fields != nil && !r.fields[first] {
+		return fmt.Errorf("unknown %s field %q", kind, field)
+	}
+	return nil
+}
+
+// Get implements repository.Repository.
+func (r *Repository[T, ID]) Get(ctx context.Context, id ID) (*T, error) {
+	var out T
+	err := r.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&out)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// List implements repository.Repository.
+func (r *Repository[T, ID]) List(ctx context.Context, q *repository.Query) ([]T, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	filter, opts, err := r.translateQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []T{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Create implements repository.Repository.
+func (r *Repository[T, ID]) Create(ctx context.Context, entity *T) error {
+	_, err := r.coll.InsertOne(ctx, entity)
+	return err
+}
+
+// Update implements repository.Repository.
+func (r *Repository[T, ID]) Update(ctx context.Context, id ID, entity *T) error {
+	res, err := r.coll.ReplaceOne(ctx, bson.M{"_id": id}, entity)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// Delete implements repository.Repository.
+func (r *Repository[T, ID]) Delete(ctx context.Context, id ID) error {
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// mongoOperators maps repository operators to MongoDB query operators.
+var mongoOperators = map[repository.Op]string{
+	repository.Ne:  "$ne",
+	repository.Gt:  "$gt",
+	repository.Gte: "$gte",
+	repository.Lt:  "$lt",
+	repository.Lte: "$lte",
+	repository.In:  "$in",
+}
+
+// translateQuery converts a repository.Query into a filter and find options.
+// Operators on the same field are merged into a range; combining them with
+// an equality is rejected, as one would silently replace the other.
+func (r *Repository[T, ID]) translateQuery(q *repository.Query) (bson.M, *options.FindOptions, error) {
+	filter := bson.M{}
+	opts := options.Find()
+	if q == nil {
+		return filter, opts, nil
+	}
+
+	for _, f := range q.Filters {
+		if err := r.checkField("filter", f.Field); err != nil {
+			return nil, nil, err
+		}
+		existing, seen := filter[f.Field]
+		if f.Op == repository.Eq {
+			if seen {
+				return nil, nil, fmt.Errorf("conflicting filters on field %q", f.Field)
+			}
+			filter[f.Field] = f.Value
+			continue
+		}
+		if !seen {
+			filter[f.Field] = bson.M{mongoOperators[f.Op]: f.Value}
+			continue
+		}
+		ops, ok := existing.(bson.M)
+		if !ok {
+			return nil, nil, fmt.Errorf("conflicting filters on field %q", f.Field)
+		}
+		ops[mongoOperators[f.Op]] = f.Value
+	}
+
+	if len(q.Sorts) > 0 {
+		sort := bson.D{}
+		for _, s := range q.Sorts {
+			if err := r.checkField("sort", s.Field); err != nil {
+				return nil, nil, err
+			}
+			dir := 1
+			if s.Desc {
+				dir = -1
+			}
+			sort = append(sort, bson.E{Key: s.Field, Value: dir})
+		}
+		opts.SetSort(sort)
+	}
+	if q.Limit > 0 {
+		opts.SetLimit(int64(q.Limit))
+	}
+	if q.Offset > 0 {
+		opts.SetSkip(int64(q.Offset))
+	}
+	return filter, opts, nil
+}
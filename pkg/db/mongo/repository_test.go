@@ -0,0 +1,171 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ranorsolutions/http-common-go/pkg/db/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type repoUser struct {
+	ID   string `bson:"_id"`
+	Name string `bson:"name"`
+	Age  int    `bson:"age,omitempty"`
+}
+
+// fakeRepoCollection is an in-memory RepositoryCollection keyed by _id.
+type fakeRepoCollection struct {
+	docs     map[interface{}]repoUser
+	lastFind bson.M
+	lastOpts *options.FindOptions
+}
+
+func newFakeRepoCollection() *fakeRepoCollection {
+	return &fakeRepoCollection{docs: map[interface{}]repoUser{}}
+}
+
+func (f *fakeRepoCollection) FindOne(_ context.Context, filter interface{}, _ ...*options.FindOneOptions) *mongo.SingleResult {
+	doc, ok := f.docs[filter.(bson.M)["_id"]]
+	if !ok {
+		return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+	}
+	return mongo.NewSingleResultFromDocument(doc, nil, nil)
+}
+
+func (f *fakeRepoCollection) Find(_ context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	f.lastFind = filter.(bson.M)
+	f.lastOpts = opts[0]
+	docs := []interface{}{}
+	for _, d := range f.docs {
+		docs = append(docs, d)
+	}
+	return mongo.NewCursorFromDocuments(docs, nil, nil)
+}
+
+func (f *fakeRepoCollection) InsertOne(_ context.Context, doc interface{}, _ ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	u := *doc.(*repoUser)
+	f.docs[u.ID] = u
+	return &mongo.InsertOneResult{InsertedID: u.ID}, nil
+}
+
+func (f *fakeRepoCollection) ReplaceOne(_ context.Context, filter, doc interface{}, _ ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	id := filter.(bson.M)["_id"]
+	if _, ok := f.docs[id]; !ok {
+		return &mongo.UpdateResult{}, nil
+	}
+	f.docs[id] = *doc.(*repoUser)
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func (f *fakeRepoCollection) DeleteOne(_ context.Context, filter interface{}, _ ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	id := filter.(bson.M)["_id"]
+	if _, ok := f.docs[id]; !ok {
+		return &mongo.DeleteResult{}, nil
+	}
+	delete(f.docs, id)
+	return &mongo.DeleteResult{DeletedCount: 1}, nil
+}
+
+// --- Repository tests ---
+
+func TestRepository_CRUD(t *testing.T) {
+	ctx := context.Background()
+	coll := newFakeRepoCollection()
+	var repo repository.Repository[repoUser, string] = NewRepository[repoUser, string](coll)
+
+	if err := repo.Create(ctx, &repoUser{ID: "u1", Name: "Ada"}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	got, err := repo.Get(ctx, "u1")
+	if err != nil || got.Name != "Ada" {
+		t.Fatalf("unexpected get result %+v, %v", got, err)
+	}
+	if err := repo.Update(ctx, "u1", &repoUser{ID: "u1", Name: "Grace"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	list, err := repo.List(ctx, nil)
+	if err != nil || len(list) != 1 || list[0].Name != "Grace" {
+		t.Fatalf("unexpected list result %+v, %v", list, err)
+	}
+	if err := repo.Delete(ctx, "u1"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+}
+
+func TestRepository_NotFound(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository[repoUser, string](newFakeRepoCollection())
+
+	if _, err := repo.Get(ctx, "missing"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Get, got %v", err)
+	}
+	if err := repo.Update(ctx, "missing", &repoUser{}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Update, got %v", err)
+	}
+	if err := repo.Delete(ctx, "missing"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Delete, got %v", err)
+	}
+}
+
+func TestRepository_ListTranslatesQuery(t *testing.T) {
+	coll := newFakeRepoCollection()
+	repo := NewRepository[repoUser, string](coll)
+
+	q := repository.NewQuery().
+		Where("name", repository.Eq, "Ada").
+		Where("age", repository.Gte, 18).
+		Where("age", repository.Lt, 65).
+		OrderBy("name", true).
+		Page(5, 10)
+	if _, err := repo.List(context.Background(), q); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if coll.lastFind["name"] != "Ada" {
+		t.Errorf("expected equality filter on name, got %v", coll.lastFind["name"])
+	}
+	age, ok := coll.lastFind["age"].(bson.M)
+	if !ok || age["$gte"] != 18 || age["$lt"] != 65 {
+		t.Errorf("expected merged range filter on age, got %v", coll.lastFind["age"])
+	}
+	if *coll.lastOpts.Limit != 5 || *coll.lastOpts.Skip != 10 {
+		t.Errorf("unexpected paging options %+v", coll.lastOpts)
+	}
+	if sort := coll.lastOpts.Sort.(bson.D); sort[0].Key != "name" || sort[0].Value != -1 {
+		t.Errorf("unexpected sort %v", coll.lastOpts.Sort)
+	}
+}
+
+func TestRepository_ListRejectsInvalidFields(t *testing.T) {
+	repo := NewRepository[repoUser, string](newFakeRepoCollection())
+
+	for _, q := range []*repository.Query{
+		repository.NewQuery().Where("$where", repository.Eq, "sleep(1000)"),
+		repository.NewQuery().Where("$or", repository.Eq, bson.A{}),
+		repository.NewQuery().Where("name.$ne", repository.Eq, "x"),
+		repository.NewQuery().Where("password", repository.Eq, "x"),
+		repository.NewQuery().OrderBy("$natural", false),
+	} {
+		if _, err := repo.List(context.Background(), q); err == nil {
+			t.Errorf("expected an error for %+v", q)
+		}
+	}
+}
+
+func TestRepository_ListRejectsConflictingFilters(t *testing.T) {
+	repo := NewRepository[repoUser, string](newFakeRepoCollection())
+
+	for _, q := range []*repository.Query{
+		repository.NewQuery().Where("age", repository.Eq, 30).Where("age", repository.Gte, 18),
+		repository.NewQuery().Where("age", repository.Gte, 18).Where("age", repository.Eq, 30),
+		repository.NewQuery().Where("age", repository.Eq, 18).Where("age", repository.Eq, 30),
+	} {
+		if _, err := repo.List(context.Background(), q); err == nil {
+			t.Errorf("expected an error for %+v", q)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//
+// --- Optimistic Concurrency ---
+//
+
+// VersionField is the document field holding the optimistic-lock version.
+const VersionField = "version"
+
+// ErrVersionConflict is matched (via errors.Is) by every VersionConflictError.
+var ErrVersionConflict = errors.New("version conflict")
+
+// VersionConflictError is returned when a versioned update matches no
+// document, meaning the document was modified (or removed) by another writer
+// since it was read.
+type VersionConflictError struct {
+	Collection string
+	ID         interface{}
+	Expected   int64
+}
+
+// Error implements error.
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("version conflict on %s/%v: expected version %d", e.Collection, e.ID, e.Expected)
+}
+
+// Is reports whether target is ErrVersionConflict.
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// CollectionUpdater is implemented by collections that support single
+// document updates. The collections returned by New implement it;
+// adapters used in tests only need it to exercise UpdateWithVersion.
+type CollectionUpdater interface {
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+}
+
+// UpdateWithVersion applies update to the document identified by id only if
+// its VersionField still equals version, atomically incrementing the version.
+// It returns the new version, or a *VersionConflictError if another writer
+// got there first.
+//
+// The update must be expressed with update operators, e.g.:
+//
+//	newVersion, err := db.UpdateWithVersion(ctx, "orders", id, order.Version,
+//	    bson.M{"$set": bson.M{"status": "shipped"}})
+//	if errors.Is(err, mongo.ErrVersionConflict) { ... reload and retry ... }
+func (db *MongoDB) UpdateWithVersion(ctx context.Context, collection string, id interface{}, version int64, update bson.M) (int64, error) {
+	col, ok := db.Connection.Collection(collection).(CollectionUpdater)
+	if !ok {
+		return 0, fmt.Errorf("collection %s does not support updates", collection)
+	}
+	versioned, err := withVersionIncrement(update)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.M{"_id": id, VersionField: version}
+	res, err := col.UpdateOne(ctx, filter, versioned)
+	if err != nil {
+		return 0, err
+	}
+	if res.MatchedCount == 0 {
+		return 0, &VersionConflictError{Collection: collection, ID: id, Expected: version}
+	}
+	return version + 1, nil
+}
+
+// withVersionIncrement returns a copy of update with {$inc: {version: 1}}
+// merged in, preserving any existing $inc fields. An $inc that is not a
+// document, or that already changes the version, is rejected.
+func withVersionIncrement(update bson.M) (bson.M, error) {
+	out := make(bson.M, len(update)+1)
+	for k, v := range update {
+		out[k] = v
+	}
+
+	inc := bson.D{}
+	switch existing := update["$inc"].(type) {
+	case nil:
+	case bson.M:
+		for k, v := range existing {
+			inc = append(inc, bson.E{Key: k, Value: v})
+		}
+	case map[string]interface{}:
+		for k, v := range existing {
+			inc = append(inc, bson.E{Key: k, Value: v})
+		}
+	case bson.D:
+		inc = append(inc, existing...)
+	default:
+		return nil, fmt.Errorf("invalid $inc %T: must be a document", existing)
+	}
+	for _, e := range inc {
+		if e.Key == VersionField {
+			return nil, fmt.Errorf("invalid $inc: must not change %s", VersionField)
+		}
+	}
+	out["$inc"] = append(inc, bson.E{Key: VersionField, Value: 1})
+	return out, nil
+}
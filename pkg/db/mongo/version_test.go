@@ -0,0 +1,146 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//
+// --- UpdateWithVersion() tests ---
+//
+
+type mockUpdateCollection struct {
+	mockCollection
+	matched    int64
+	updateErr  error
+	lastFilter interface{}
+	lastUpdate interface{}
+}
+
+func (m *mockUpdateCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	m.lastFilter, m.lastUpdate = filter, update
+	if m.updateErr != nil {
+		return nil, m.updateErr
+	}
+	return &mongo.UpdateResult{MatchedCount: m.matched, ModifiedCount: m.matched}, nil
+}
+
+// incValue returns the value of key in the $inc of update.
+func incValue(update bson.M, key string) (interface{}, bool) {
+	for _, e := range update["$inc"].(bson.D) {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+func TestUpdateWithVersion_Success(t *testing.T) {
+	col := &mockUpdateCollection{matched: 1}
+	db := &MongoDB{Name: "testdb", Connection: &mockDatabase{col: col}}
+
+	got, err := db.UpdateWithVersion(context.Background(), "orders", "o1", 3, bson.M{"$set": bson.M{"status": "shipped"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got != 4 {
+		t.Errorf("expected new version 4, got %d", got)
+	}
+
+	filter := col.lastFilter.(bson.M)
+	if filter["_id"] != "o1" || filter[VersionField] != int64(3) {
+		t.Errorf("unexpected filter %v", filter)
+	}
+	update := col.lastUpdate.(bson.M)
+	if v, _ := incValue(update, VersionField); v != 1 {
+		t.Errorf("expected version increment in update, got %v", update)
+	}
+	if _, ok := update["$set"]; !ok {
+		t.Errorf("expected $set to be preserved, got %v", update)
+	}
+}
+
+func TestUpdateWithVersion_Conflict(t *testing.T) {
+	col := &mockUpdateCollection{matched: 0}
+	db := &MongoDB{Name: "testdb", Connection: &mockDatabase{col: col}}
+
+	_, err := db.UpdateWithVersion(context.Background(), "orders", "o1", 3, bson.M{"$set": bson.M{"a": 1}})
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+
+	var conflict *VersionConflictError
+	if !errors.As(err, &conflict) || conflict.Expected != 3 || conflict.Collection != "orders" {
+		t.Errorf("expected typed conflict error, got %#v", err)
+	}
+}
+
+func TestUpdateWithVersion_DriverError(t *testing.T) {
+	col := &mockUpdateCollection{updateErr: errors.New("network down")}
+	db := &MongoDB{Name: "testdb", Connection: &mockDatabase{col: col}}
+
+	_, err := db.UpdateWithVersion(context.Background(), "orders", "o1", 1, bson.M{})
+	if err == nil || errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected driver error, got %v", err)
+	}
+}
+
+func TestUpdateWithVersion_UnsupportedCollection(t *testing.T) {
+	db := &MongoDB{Name: "testdb", Connection: &mockDatabase{col: &mockCollection{}}}
+
+	_, err := db.UpdateWithVersion(context.Background(), "orders", "o1", 1, bson.M{})
+	if err == nil {
+		t.Fatal("expected error for a collection without UpdateOne")
+	}
+}
+
+func TestWithVersionIncrement_MergesExistingInc(t *testing.T) {
+	for name, inc := range map[string]interface{}{
+		"bson.M": bson.M{"count": 2},
+		"map":    map[string]interface{}{"count": 2},
+		"bson.D": bson.D{{Key: "count", Value: 2}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			update := bson.M{"$inc": inc}
+			out, err := withVersionIncrement(update)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			count, _ := incValue(out, "count")
+			version, _ := incValue(out, VersionField)
+			if count != 2 || version != 1 {
+				t.Errorf("expected merged $inc, got %v", out["$inc"])
+			}
+			if len(out["$inc"].(bson.D)) != 2 {
+				t.Errorf("expected two increments, got %v", out["$inc"])
+			}
+		})
+	}
+}
+
+func TestWithVersionIncrement_LeavesOriginalUntouched(t *testing.T) {
+	update := bson.M{"$inc": bson.D{{Key: "count", Value: 2}}}
+	if _, err := withVersionIncrement(update); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(update["$inc"].(bson.D)) != 1 {
+		t.Errorf("expected original update to be left untouched, got %v", update)
+	}
+}
+
+func TestWithVersionIncrement_Invalid(t *testing.T) {
+	for name, inc := range map[string]interface{}{
+		"scalar":  5,
+		"version": bson.M{VersionField: 5},
+	} {
+		if _, err := withVersionIncrement(bson.M{"$inc": inc}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// DefaultBulkBatchSize is the number of rows sent per COPY batch when
+// BulkOptions.BatchSize is not set.
+const DefaultBulkBatchSize = 5000
+
+// RowIterator yields rows for BulkInsert. It follows the sql.Rows pattern:
+// call Next until it returns false, then check Err.
+type RowIterator interface {
+	Next() bool
+	Values() ([]interface{}, error)
+	Err() error
+}
+
+// sliceRows is a RowIterator over an in-memory slice.
+type sliceRows struct {
+	rows [][]interface{}
+	pos  int
+}
+
+// RowsFromSlice returns a RowIterator over rows.
+func RowsFromSlice(rows [][]interface{}) RowIterator {
+	return &sliceRows{rows: rows, pos: -1}
+}
+
+func (s *sliceRows) Next() bool                     { s.pos++; return s.pos < len(s.rows) }
+func (s *sliceRows) Values() ([]interface{}, error) { return s.rows[s.pos], nil }
+func (s *sliceRows) Err() error                     { return nil }
+
+// RowError describes a row rejected by PostgreSQL during a COPY.
+type RowError struct {
+	// Row is the zero-based index of the row in the input.
+	Row int64
+
+	// Values are the values of the rejected row.
+	Values []interface{}
+
+	// Err is the error reported by the server.
+	Err error
+}
+
+// Error implements error.
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d rejected: %v", e.Row, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RowError) Unwrap() error { return e.Err }
+
+// BulkOptions controls BulkInsert.
+type BulkOptions struct {
+	// Schema qualifies the table name. Optional.
+	Schema string
+
+	// BatchSize is the number of rows per COPY transaction.
+	BatchSize int
+
+	// OnProgress is called after each committed batch with the running total
+	// of inserted rows.
+	OnProgress func(inserted int64)
+
+	// SkipInvalidRows drops rows the server rejects (reporting them through
+	// OnRowError) and retries the rest of the batch, instead of aborting.
+	SkipInvalidRows bool
+
+	// OnRowError is called for every row rejected by the server.
+	OnRowError func(*RowError)
+}
+
+// BulkInsert loads rows into table using the COPY protocol, committing one
+// transaction per batch. It returns the number of rows inserted.
+//
+// When a batch fails, the offending row is identified from the server error
+// and returned as a *RowError (or skipped, with SkipInvalidRows).
+//
+// Example:
+//
+//	n, err := postgres.BulkInsert(ctx, db, "users", []string{"id", "email"},
+//	    postgres.RowsFromSlice(rows), &postgres.BulkOptions{
+//	        BatchSize:  10000,
+//	        OnProgress: func(n int64) { log.Info("loaded %d rows", n) },
+//	    })
+func BulkInsert(ctx context.Context, db *sql.DB, table string, columns []string, rows RowIterator, opts *BulkOptions) (int64, error) {
+	if opts == nil {
+		opts = &BulkOptions{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBulkBatchSize
+	}
+
+	query := pq.CopyIn(table, columns...)
+	if opts.Schema != "" {
+		query = pq.CopyInSchema(opts.Schema, table, columns...)
+	}
+
+	var inserted, offset int64
+	batch := make([][]interface{}, 0, batchSize)
+
+	flush := func() error {
+		n, err := copyBatch(ctx, db, query, batch, offset, opts)
+		inserted += n
+		offset += int64(len(batch))
+		batch = batch[:0]
+		if err != nil {
+			return err
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(inserted)
+		}
+		return nil
+	}
+
+	for rows.Next() {
+		vals, err := rows.Values()
+		if err != nil {
+			return inserted, fmt.Errorf("failed to read row %d: %w", offset+int64(len(batch)), err)
+		}
+		if len(vals) != len(columns) {
+			return inserted, fmt.Errorf("row %d has %d values, expected %d", offset+int64(len(batch)), len(vals), len(columns))
+		}
+		batch = append(batch, vals)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return inserted, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return inserted, err
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return inserted, err
+		}
+	}
+	return inserted, nil
+}
+
+// copyBatch copies a batch, retrying without rejected rows when
+// SkipInvalidRows is set. offset is the input index of batch[0].
+func copyBatch(ctx context.Context, db *sql.DB, query string, batch [][]interface{}, offset int64, opts *BulkOptions) (int64, error) {
+	// index maps positions in pending back to input row indexes.
+	pending := batch
+	index := make([]int64, len(batch))
+	for i := range index {
+		index[i] = offset + int64(i)
+	}
+
+	for len(pending) > 0 {
+		err := copyRows(ctx, db, query, pending)
+		if err == nil {
+			return int64(len(pending)), nil
+		}
+
+		line, ok := copyErrorLine(err)
+		if !ok || line < 1 || line > len(pending) {
+			return 0, fmt.Errorf("bulk insert of rows %d-%d failed: %w", index[0], index[len(index)-1], err)
+		}
+
+		rowErr := &RowError{Row: index[line-1], Values: pending[line-1], Err: err}
+		if opts.OnRowError != nil {
+			opts.OnRowError(rowErr)
+		}
+		if !opts.SkipInvalidRows {
+			return 0, rowErr
+		}
+
+		pending = append(append([][]interface{}{}, pending[:line-1]...), pending[line:]...)
+		index = append(append([]int64{}, index[:line-1]...), index[line:]...)
+	}
+	return 0, nil
+}
+
+// copyRows runs a single COPY transaction.
+func copyRows(ctx context.Context, db *sql.DB, query string, rows [][]interface{}) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	for _, vals := range rows {
+		if _, err = stmt.ExecContext(ctx, vals...); err != nil {
+			_ = stmt.Close()
+			return err
+		}
+	}
+	// An Exec without arguments flushes the buffered COPY data.
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		_ = stmt.Close()
+		return err
+	}
+	if err = stmt.Close(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// copyLinePattern extracts the line number from the context PostgreSQL
+// attaches to COPY errors, e.g. `COPY users, line 3, column email: "..."`.
+var copyLinePattern = regexp.MustCompile(`COPY [^,]+, line (\d+)`)
+
+// copyErrorLine returns the one-based line of a COPY error, if present.
+func copyErrorLine(err error) (int, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return 0, false
+	}
+	m := copyLinePattern.FindStringSubmatch(pqErr.Where)
+	if m == nil {
+		return 0, false
+	}
+	line, convErr := strconv.Atoi(m[1])
+	return line, convErr == nil
+}
@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// --- Fake COPY driver ---
+
+// copyDriver is a minimal database/sql driver that emulates COPY FROM STDIN:
+// rows are buffered per statement and validated when flushed. Any row whose
+// first value is "bad" is rejected with a pq.Error pointing at its line.
+type copyDriver struct {
+	mu        sync.Mutex
+	queries   []string
+	committed [][]driver.Value
+}
+
+type copyConn struct{ d *copyDriver }
+type copyTx struct{}
+type copyStmt struct {
+	d    *copyDriver
+	rows [][]driver.Value
+}
+
+var fakeDriverSeq int
+
+func newCopyDB(t *testing.T) (*sql.DB, *copyDriver) {
+	t.Helper()
+	fakeDriverSeq++
+	name := fmt.Sprintf("copyfake-%d", fakeDriverSeq)
+
+	d := &copyDriver{}
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("failed to open fake db: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db, d
+}
+
+func (d *copyDriver) Open(string) (driver.Conn, error) { return &copyConn{d: d}, nil }
+
+func (c *copyConn) Close() error              { return nil }
+func (c *copyConn) Begin() (driver.Tx, error) { return copyTx{}, nil }
+func (c *copyConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.mu.Lock()
+	c.d.queries = append(c.d.queries, query)
+	c.d.mu.Unlock()
+	return &copyStmt{d: c.d}, nil
+}
+
+func (copyTx) Commit() error   { return nil }
+func (copyTx) Rollback() error { return nil }
+
+func (s *copyStmt) Close() error  { return nil }
+func (s *copyStmt) NumInput() int { return -1 }
+func (s *copyStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+func (s *copyStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if len(args) > 0 {
+		s.rows = append(s.rows, args)
+		return driver.RowsAffected(0), nil
+	}
+	for i, row := range s.rows {
+		if row[0] == "bad" {
+			return nil, &pq.Error{Message: "invalid input", Where: fmt.Sprintf("COPY items, line %d, column name", i+1)}
+		}
+	}
+	s.d.mu.Lock()
+	s.d.committed = append(s.d.committed, s.rows...)
+	s.d.mu.Unlock()
+	return driver.RowsAffected(len(s.rows)), nil
+}
+
+// --- BulkInsert() tests ---
+
+func TestBulkInsert_BatchesAndReportsProgress(t *testing.T) {
+	db, d := newCopyDB(t)
+
+	rows := make([][]interface{}, 0, 5)
+	for i := 0; i < 5; i++ {
+		rows = append(rows, []interface{}{fmt.Sprintf("item-%d", i), int64(i)})
+	}
+
+	var progress []int64
+	n, err := BulkInsert(context.Background(), db, "items", []string{"name", "qty"}, RowsFromSlice(rows), &BulkOptions{
+		BatchSize:  2,
+		OnProgress: func(n int64) { progress = append(progress, n) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 5 || len(d.committed) != 5 {
+		t.Errorf("expected 5 inserted rows, got %d (committed %d)", n, len(d.committed))
+	}
+	if fmt.Sprint(progress) != "[2 4 5]" {
+		t.Errorf("unexpected progress callbacks %v", progress)
+	}
+	if !strings.HasPrefix(d.queries[0], `COPY "items" ("name", "qty") FROM STDIN`) {
+		t.Errorf("unexpected COPY statement %q", d.queries[0])
+	}
+}
+
+func TestBulkInsert_ReportsRejectedRow(t *testing.T) {
+	db, _ := newCopyDB(t)
+
+	rows := [][]interface{}{{"ok", int64(1)}, {"ok", int64(2)}, {"ok", int64(3)}, {"bad", int64(4)}}
+	n, err := BulkInsert(context.Background(), db, "items", []string{"name", "qty"}, RowsFromSlice(rows), &BulkOptions{BatchSize: 2})
+
+	var rowErr *RowError
+	if !errors.As(err, &rowErr) {
+		t.Fatalf("expected *RowError, got %v", err)
+	}
+	if rowErr.Row != 3 {
+		t.Errorf("expected row 3 to be rejected, got %d", rowErr.Row)
+	}
+	if n != 2 {
+		t.Errorf("expected first batch to be inserted, got %d", n)
+	}
+}
+
+func TestBulkInsert_SkipInvalidRows(t *testing.T) {
+	db, _ := newCopyDB(t)
+
+	rows := [][]interface{}{{"ok", int64(1)}, {"bad", int64(2)}, {"ok", int64(3)}, {"bad", int64(4)}}
+	var rejected []int64
+	n, err := BulkInsert(context.Background(), db, "items", []string{"name", "qty"}, RowsFromSlice(rows), &BulkOptions{
+		SkipInvalidRows: true,
+		OnRowError:      func(e *RowError) { rejected = append(rejected, e.Row) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 inserted rows, got %d", n)
+	}
+	if fmt.Sprint(rejected) != "[1 3]" {
+		t.Errorf("expected rows 1 and 3 to be rejected, got %v", rejected)
+	}
+}
+
+func TestBulkInsert_ColumnMismatch(t *testing.T) {
+	db, _ := newCopyDB(t)
+	_, err := BulkInsert(context.Background(), db, "items", []string{"name", "qty"}, RowsFromSlice([][]interface{}{{"only-one"}}), nil)
+	if err == nil {
+		t.Fatal("expected error for column count mismatch")
+	}
+}
+
+func TestCopyErrorLine(t *testing.T) {
+	if line, ok := copyErrorLine(&pq.Error{Where: "COPY users, line 12, column email"}); !ok || line != 12 {
+		t.Errorf("expected line 12, got %d (%v)", line, ok)
+	}
+	if _, ok := copyErrorLine(errors.New("plain")); ok {
+		t.Error("expected non-pq error to have no line")
+	}
+}
@@ -0,0 +1,197 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+)
+
+//
+// --- Transactional outbox ---
+//
+
+// DefaultOutboxTable is the table used when NewOutbox is given no name.
+const DefaultOutboxTable = "outbox"
+
+// Execer is satisfied by *sql.DB, *sql.Tx and *sql.Conn. Outbox.Write takes
+// one so events are written in the caller's transaction.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// OutboxEvent is a message waiting in the outbox.
+type OutboxEvent struct {
+	ID      int64
+	Topic   string
+	Key     string
+	Headers map[string]string
+
+	// Payload is the encoded message. Nil marks a tombstone: a message with
+	// a null value that deletes Key from a compacted topic.
+	Payload []byte
+
+	CreatedAt time.Time
+}
+
+// Outbox stores messages in a table so they can be written atomically with
+// business data and published afterwards by a relay. This removes the
+// dual-write problem of committing to the database and then publishing:
+// either both the data and the event are committed, or neither is.
+type Outbox struct {
+	db    *sql.DB
+	table string
+}
+
+// NewOutbox returns an Outbox backed by table (DefaultOutboxTable if empty).
+//
+// Example:
+//
+//	outbox := postgres.NewOutbox(db, "")
+//
+//	tx, err := db.BeginTx(ctx, nil)
+//	// ... insert the order with tx ...
+//	if err := outbox.Write(ctx, tx, "orders", order.ID, OrderCreated{ID: order.ID}, nil); err != nil {
+//	    _ = tx.Rollback()
+//	    return err
+//	}
+//	return tx.Commit()
+func NewOutbox(db *sql.DB, table string) *Outbox {
+	if table == "" {
+		table = DefaultOutboxTable
+	}
+	return &Outbox{db: db, table: table}
+}
+
+// CreateTable creates the outbox table if it does not exist.
+func (o *Outbox) CreateTable(ctx context.Context) error {
+	_, err := o.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id         BIGSERIAL PRIMARY KEY,
+	topic      TEXT NOT NULL,
+	key        TEXT NOT NULL DEFAULT '',
+	headers    JSONB NOT NULL DEFAULT '{}',
+	payload    BYTEA,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`, pq.QuoteIdentifier(o.table)))
+	if err != nil {
+		return fmt.Errorf("failed to create outbox table: %w", err)
+	}
+	return nil
+}
+
+// Write adds a JSON-encoded message to the outbox using tx, typically the
+// transaction that also writes the business data. The request metadata of
+// ctx is stored with the headers, so the relay publishes the event with
+// the correlation IDs of the request that wrote it.
+func (o *Outbox) Write(ctx context.Context, tx Execer, topic, key string, value interface{}, headers map[string]string) error {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+	return o.insert(ctx, tx, topic, key, payload, headers)
+}
+
+// WriteTombstone adds a tombstone for key, deleting it from a compacted
+// topic once relayed.
+func (o *Outbox) WriteTombstone(ctx context.Context, tx Execer, topic, key string) error {
+	return o.insert(ctx, tx, topic, key, nil, nil)
+}
+
+func (o *Outbox) insert(ctx context.Context, tx Execer, topic, key string, payload []byte, headers map[string]string) error {
+	encodedHeaders, err := json.Marshal(correlation.Merge(ctx, headers))
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox headers: %w", err)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (topic, key, headers, payload) VALUES ($1, $2, $3, $4)`, pq.QuoteIdentifier(o.table))
+	if _, err := tx.ExecContext(ctx, query, topic, key, encodedHeaders, payload); err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+	return nil
+}
+
+// Relay publishes up to limit pending events, oldest first, and deletes the
+// ones that were published. It stops at the first failed publish so events
+// are never reordered, and returns the number of events published.
+//
+// Rows are locked with FOR UPDATE SKIP LOCKED, so concurrent relays never
+// publish the same event; per-key ordering is only guaranteed with a single
+// relay, though. Delivery is at-least-once: if the final commit fails,
+// already published events are published again on the next call.
+//
+// Events are relayed in id order, which is the order they were written,
+// not the order their transactions committed. A relay running while an
+// earlier-written transaction is still open publishes the later events
+// first, since the uncommitted rows are not visible yet. Writers that need
+// per-key ordering must serialize their transactions for a key, e.g. by
+// locking the business row the event describes.
+func (o *Outbox) Relay(ctx context.Context, limit int, publish func(context.Context, OutboxEvent) error) (n int, err error) {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin outbox transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	events, err := o.pending(ctx, tx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var published []int64
+	var publishErr error
+	for _, e := range events {
+		if publishErr = publish(ctx, e); publishErr != nil {
+			break
+		}
+		published = append(published, e.ID)
+	}
+
+	if len(published) > 0 {
+		query := fmt.Sprintf(`DELETE FROM %s WHERE id = ANY($1)`, pq.QuoteIdentifier(o.table))
+		if _, err := tx.ExecContext(ctx, query, pq.Array(published)); err != nil {
+			return 0, fmt.Errorf("failed to delete published outbox events: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox transaction: %w", err)
+	}
+	if publishErr != nil {
+		return len(published), fmt.Errorf("failed to publish outbox event: %w", publishErr)
+	}
+	return len(published), nil
+}
+
+// pending locks and returns up to limit committed events in id order.
+func (o *Outbox) pending(ctx context.Context, tx *sql.Tx, limit int) ([]OutboxEvent, error) {
+	query := fmt.Sprintf(`SELECT id, topic, key, headers, payload, created_at FROM %s ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`,
+		pq.QuoteIdentifier(o.table))
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var events []OutboxEvent
+	for rows.Next() {
+		var e OutboxEvent
+		var headers []byte
+		if err := rows.Scan(&e.ID, &e.Topic, &e.Key, &headers, &e.Payload, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		if len(headers) > 0 {
+			if err := json.Unmarshal(headers, &e.Headers); err != nil {
+				return nil, fmt.Errorf("invalid headers on outbox event %d: %w", e.ID, err)
+			}
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
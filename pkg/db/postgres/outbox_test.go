@@ -0,0 +1,261 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+)
+
+// --- Fake outbox driver ---
+
+// outboxDriver is a minimal database/sql driver that keeps outbox rows in
+// memory and understands the INSERT, SELECT and DELETE statements issued by
+// Outbox. Changes made in a transaction are discarded on rollback.
+type outboxDriver struct {
+	mu      sync.Mutex
+	nextID  int64
+	rows    []driver.Value // each entry is a []driver.Value row
+	queries []string
+}
+
+type outboxConn struct {
+	d      *outboxDriver
+	backup []driver.Value
+}
+
+type outboxTx struct{ c *outboxConn }
+
+type outboxStmt struct {
+	c     *outboxConn
+	query string
+}
+
+type outboxRows struct {
+	rows [][]driver.Value
+	pos  int
+}
+
+func newOutboxDB(t *testing.T) (*sql.DB, *outboxDriver) {
+	t.Helper()
+	fakeDriverSeq++
+	name := fmt.Sprintf("outboxfake-%d", fakeDriverSeq)
+
+	d := &outboxDriver{}
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("failed to open fake db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, d
+}
+
+func (d *outboxDriver) Open(string) (driver.Conn, error) { return &outboxConn{d: d}, nil }
+
+func (c *outboxConn) Close() error { return nil }
+func (c *outboxConn) Begin() (driver.Tx, error) {
+	c.d.mu.Lock()
+	c.backup = append([]driver.Value(nil), c.d.rows...)
+	c.d.mu.Unlock()
+	return outboxTx{c}, nil
+}
+func (c *outboxConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.mu.Lock()
+	c.d.queries = append(c.d.queries, query)
+	c.d.mu.Unlock()
+	return &outboxStmt{c: c, query: query}, nil
+}
+
+func (tx outboxTx) Commit() error { return nil }
+func (tx outboxTx) Rollback() error {
+	tx.c.d.mu.Lock()
+	tx.c.d.rows = tx.c.backup
+	tx.c.d.mu.Unlock()
+	return nil
+}
+
+func (s *outboxStmt) Close() error  { return nil }
+func (s *outboxStmt) NumInput() int { return -1 }
+
+func (s *outboxStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(s.query, "INSERT"):
+		d.nextID++
+		row := []driver.Value{d.nextID, args[0], args[1], args[2], args[3], time.Now()}
+		d.rows = append(d.rows, row)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "DELETE"):
+		ids := strings.Split(strings.Trim(args[0].(string), "{}"), ",")
+		deleted := map[string]bool{}
+		for _, id := range ids {
+			deleted[id] = true
+		}
+		var kept []driver.Value
+		for _, r := range d.rows {
+			if !deleted[fmt.Sprint(r.([]driver.Value)[0])] {
+				kept = append(kept, r)
+			}
+		}
+		d.rows = kept
+		return driver.RowsAffected(len(ids)), nil
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (s *outboxStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	limit := int(args[0].(int64))
+	out := &outboxRows{pos: -1}
+	for _, r := range d.rows {
+		if len(out.rows) == limit {
+			break
+		}
+		out.rows = append(out.rows, r.([]driver.Value))
+	}
+	return out, nil
+}
+
+func (r *outboxRows) Columns() []string {
+	return []string{"id", "topic", "key", "headers", "payload", "created_at"}
+}
+func (r *outboxRows) Close() error { return nil }
+func (r *outboxRows) Next(dest []driver.Value) error {
+	r.pos++
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	return nil
+}
+
+func writeEvents(t *testing.T, db *sql.DB, o *Outbox, keys ...string) {
+	t.Helper()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	for _, k := range keys {
+		if err := o.Write(context.Background(), tx, "orders", k, map[string]string{"id": k}, map[string]string{"source": "test"}); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+}
+
+// --- Outbox tests ---
+
+func TestOutbox_WriteAndRelay(t *testing.T) {
+	db, d := newOutboxDB(t)
+	o := NewOutbox(db, "")
+	writeEvents(t, db, o, "a", "b")
+
+	var got []OutboxEvent
+	n, err := o.Relay(context.Background(), 10, func(_ context.Context, e OutboxEvent) error {
+		got = append(got, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 || len(got) != 2 {
+		t.Fatalf("expected 2 relayed events, got %d", n)
+	}
+	if got[0].Key != "a" || got[0].Topic != "orders" || string(got[0].Payload) != `{"id":"a"}` {
+		t.Errorf("unexpected event %+v", got[0])
+	}
+	if got[0].Headers["source"] != "test" {
+		t.Errorf("expected headers to round-trip, got %v", got[0].Headers)
+	}
+	if len(d.rows) != 0 {
+		t.Errorf("expected published events to be deleted, %d left", len(d.rows))
+	}
+	if !strings.Contains(d.queries[0], `INSERT INTO "outbox"`) {
+		t.Errorf("unexpected insert %q", d.queries[0])
+	}
+}
+
+func TestOutbox_WriteStoresRequestMetadata(t *testing.T) {
+	db, _ := newOutboxDB(t)
+	o := NewOutbox(db, "")
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{
+		RequestID:   "req-1",
+		TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+	if err := o.Write(ctx, db, "orders", "a", map[string]string{"id": "a"}, map[string]string{"source": "test"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var got OutboxEvent
+	if _, err := o.Relay(context.Background(), 1, func(_ context.Context, e OutboxEvent) error {
+		got = e
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Headers["request_id"] != "req-1" || got.Headers["traceparent"] == "" || got.Headers["source"] != "test" {
+		t.Errorf("expected request metadata in headers, got %v", got.Headers)
+	}
+	if _, ok := got.Headers["span_id"]; ok {
+		t.Errorf("expected empty metadata to be omitted, got %v", got.Headers)
+	}
+}
+
+func TestOutbox_RelayStopsAtFirstFailure(t *testing.T) {
+	db, d := newOutboxDB(t)
+	o := NewOutbox(db, "events_outbox")
+	writeEvents(t, db, o, "a", "b", "c")
+
+	n, err := o.Relay(context.Background(), 10, func(_ context.Context, e OutboxEvent) error {
+		if e.Key == "b" {
+			return errors.New("broker down")
+		}
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "broker down") {
+		t.Fatalf("expected publish error, got %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 published event, got %d", n)
+	}
+	if len(d.rows) != 2 {
+		t.Errorf("expected b and c to remain pending, got %d rows", len(d.rows))
+	}
+}
+
+func TestOutbox_Tombstone(t *testing.T) {
+	db, _ := newOutboxDB(t)
+	o := NewOutbox(db, "")
+	if err := o.WriteTombstone(context.Background(), db, "users", "u-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got OutboxEvent
+	if _, err := o.Relay(context.Background(), 1, func(_ context.Context, e OutboxEvent) error {
+		got = e
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Key != "u-1" || got.Payload != nil {
+		t.Errorf("expected tombstone for u-1, got %+v", got)
+	}
+}
@@ -0,0 +1,286 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/ranorsolutions/http-common-go/pkg/db/repository"
+)
+
+//
+// --- Repository ---
+//
+
+// Repository implements repository.Repository on a PostgreSQL table.
+//
+// Entity fields are mapped to columns with `db:"column"` tags; untagged
+// fields and fields tagged `db:"-"` are ignored. A column tagged with the
+// `auto` option (e.g. `db:"id,auto"`) is generated by the database: it is
+// omitted on insert and populated from RETURNING.
+type Repository[T any, ID comparable] struct {
+	db       *sql.DB
+	table    string
+	idColumn string
+	columns  []column
+	byName   map[string]column
+}
+
+// column describes a mapped struct field.
+type column struct {
+	name  string
+	index []int
+	auto  bool
+}
+
+// NewRepository returns a Repository for table, keyed by idColumn.
+// It panics if T is not a struct or does not map idColumn.
+//
+// Example:
+//
+//	type User struct {
+//	    ID    int64  `db:"id,auto"`
+//	    Email string `db:"email"`
+//	}
+//
+//	users := postgres.NewRepository[User, int64](db, "users", "id")
+func NewRepository[T any, ID comparable](db *sql.DB, table, idColumn string) *Repository[T, ID] {
+	cols := mapColumns(reflect.TypeOf((*T)(nil)).Elem())
+	byName := make(map[string]column, len(cols))
+	for _, c := range cols {
+		byName[c.name] = c
+	}
+	if _, ok := byName[idColumn]; !ok {
+		panic(fmt.Sprintf("postgres: id column %q is not mapped by %T", idColumn, *new(T)))
+	}
+	return &Repository[T, ID]{db: db, table: table, idColumn: idColumn, columns: cols, byName: byName}
+}
+
+// mapColumns collects the db-tagged fields of t.
+func mapColumns(t reflect.Type) []column {
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("postgres: repository entity must be a struct, got %s", t))
+	}
+	var cols []column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("db")
+		if !ok || tag == "-" || !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		cols = append(cols, column{name: name, index: f.Index, auto: opts == "auto"})
+	}
+	return cols
+}
+
+// Get implements repository.Repository.
+func (r *Repository[T, ID]) Get(ctx context.Context, id ID) (*T, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1",
+		r.selectList(), pq.QuoteIdentifier(r.table), pq.QuoteIdentifier(r.idColumn))
+
+	var out T
+	err := r.db.QueryRowContext(ctx, query, id).Scan(r.fieldPtrs(&out)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// List implements repository.Repository.
+func (r *Repository[T, ID]) List(ctx context.Context, q *repository.Query) ([]T, error) {
+	query, args, err := r.buildList(q)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []T{}
+	for rows.Next() {
+		var item T
+		if err := rows.Scan(r.fieldPtrs(&item)...); err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, rows.Err()
+}
+
+// Create implements repository.Repository. Auto columns are filled in from
+// the inserted row.
+func (r *Repository[T, ID]) Create(ctx context.Context, entity *T) error {
+	v := reflect.ValueOf(entity).Elem()
+
+	var names, placeholders, returning []string
+	var args, dest []interface{}
+	for _, c := range r.columns {
+		if c.auto {
+			returning = append(returning, pq.QuoteIdentifier(c.name))
+			dest = append(dest, v.FieldByIndex(c.index).Addr().Interface())
+			continue
+		}
+		names = append(names, pq.QuoteIdentifier(c.name))
+		args = append(args, v.FieldByIndex(c.index).Interface())
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		pq.QuoteIdentifier(r.table), strings.Join(names, ", "), strings.Join(placeholders, ", "))
+	if len(returning) == 0 {
+		_, err := r.db.ExecContext(ctx, query, args...)
+		return err
+	}
+	query += " RETURNING " + strings.Join(returning, ", ")
+	return r.db.QueryRowContext(ctx, query, args...).Scan(dest...)
+}
+
+// Update implements repository.Repository. Every non-auto column except the
+// ID is overwritten.
+func (r *Repository[T, ID]) Update(ctx context.Context, id ID, entity *T) error {
+	v := reflect.ValueOf(entity).Elem()
+
+	var sets []string
+	var args []interface{}
+	for _, c := range r.columns {
+		if c.auto || c.name == r.idColumn {
+			continue
+		}
+		args = append(args, v.FieldByIndex(c.index).Interface())
+		sets = append(sets, fmt.Sprintf("%s = $%d", pq.QuoteIdentifier(c.name), len(args)))
+	}
+	if len(sets) == 0 {
+		return fmt.Errorf("no updatable columns mapped for table %s", r.table)
+	}
+	args = append(args, id)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = $%d",
+		pq.QuoteIdentifier(r.table), strings.Join(sets, ", "), pq.QuoteIdentifier(r.idColumn), len(args))
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res)
+}
+
+// Delete implements repository.Repository.
+func (r *Repository[T, ID]) Delete(ctx context.Context, id ID) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1", pq.QuoteIdentifier(r.table), pq.QuoteIdentifier(r.idColumn))
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res)
+}
+
+// requireAffected maps a zero-row result to repository.ErrNotFound.
+func requireAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// sqlOperators maps repository operators to SQL comparison operators. In is
+// rendered separately as "= ANY($n)".
+var sqlOperators = map[repository.Op]string{
+	repository.Eq:  "=",
+	repository.Ne:  "<>",
+	repository.Gt:  ">",
+	repository.Gte: ">=",
+	repository.Lt:  "<",
+	repository.Lte: "<=",
+}
+
+// buildList renders q as a SELECT statement. Field names are checked against
+// the mapped columns so user input can never reach the SQL text.
+func (r *Repository[T, ID]) buildList(q *repository.Query) (string, []interface{}, error) {
+	if err := q.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT %s FROM %s", r.selectList(), pq.QuoteIdentifier(r.table))
+	if q == nil {
+		return sb.String(), nil, nil
+	}
+
+	var args []interface{}
+	for i, f := range q.Filters {
+		if _, ok := r.byName[f.Field]; !ok {
+			return "", nil, fmt.Errorf("unknown filter field %q", f.Field)
+		}
+		if i == 0 {
+			sb.WriteString(" WHERE ")
+		} else {
+			sb.WriteString(" AND ")
+		}
+		value := f.Value
+		if f.Op == repository.In {
+			value = pq.Array(f.Value)
+			fmt.Fprintf(&sb, "%s = ANY($%d)", pq.QuoteIdentifier(f.Field), len(args)+1)
+		} else {
+			fmt.Fprintf(&sb, "%s %s $%d", pq.QuoteIdentifier(f.Field), sqlOperators[f.Op], len(args)+1)
+		}
+		args = append(args, value)
+	}
+
+	for i, s := range q.Sorts {
+		if _, ok := r.byName[s.Field]; !ok {
+			return "", nil, fmt.Errorf("unknown sort field %q", s.Field)
+		}
+		if i == 0 {
+			sb.WriteString(" ORDER BY ")
+		} else {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(pq.QuoteIdentifier(s.Field))
+		if s.Desc {
+			sb.WriteString(" DESC")
+		}
+	}
+
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		fmt.Fprintf(&sb, " LIMIT $%d", len(args))
+	}
+	if q.Offset > 0 {
+		args = append(args, q.Offset)
+		fmt.Fprintf(&sb, " OFFSET $%d", len(args))
+	}
+	return sb.String(), args, nil
+}
+
+// selectList returns the quoted, comma-separated list of mapped columns.
+func (r *Repository[T, ID]) selectList() string {
+	names := make([]string, len(r.columns))
+	for i, c := range r.columns {
+		names[i] = pq.QuoteIdentifier(c.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// fieldPtrs returns pointers to the mapped fields of entity, in column order.
+func (r *Repository[T, ID]) fieldPtrs(entity *T) []interface{} {
+	v := reflect.ValueOf(entity).Elem()
+	ptrs := make([]interface{}, len(r.columns))
+	for i, c := range r.columns {
+		ptrs[i] = v.FieldByIndex(c.index).Addr().Interface()
+	}
+	return ptrs
+}
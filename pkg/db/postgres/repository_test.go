@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/ranorsolutions/http-common-go/pkg/db/repository"
+)
+
+type repoUser struct {
+	ID     int64  `db:"id,auto"`
+	Email  string `db:"email"`
+	Age    int    `db:"age"`
+	Secret string `db:"-"`
+	Note   string
+}
+
+// --- NewRepository() tests ---
+
+func TestNewRepository_MapsColumns(t *testing.T) {
+	r := NewRepository[repoUser, int64](nil, "users", "id")
+	if len(r.columns) != 3 {
+		t.Fatalf("expected 3 mapped columns, got %+v", r.columns)
+	}
+	if !r.byName["id"].auto || r.byName["email"].auto {
+		t.Errorf("unexpected auto flags %+v", r.columns)
+	}
+	if got := r.selectList(); got != `"id", "email", "age"` {
+		t.Errorf("unexpected select list %s", got)
+	}
+}
+
+func TestNewRepository_PanicsOnUnmappedID(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for unmapped id column")
+		}
+	}()
+	NewRepository[repoUser, int64](nil, "users", "uuid")
+}
+
+// --- buildList() tests ---
+
+func TestBuildList(t *testing.T) {
+	r := NewRepository[repoUser, int64](nil, "users", "id")
+
+	q := repository.NewQuery().
+		Where("age", repository.Gte, 18).
+		Where("id", repository.In, []int64{1, 2}).
+		OrderBy("email", false).
+		OrderBy("age", true).
+		Page(10, 20)
+
+	query, args, err := r.buildList(q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `SELECT "id", "email", "age" FROM "users" WHERE "age" >= $1 AND "id" = ANY($2) ORDER BY "email", "age" DESC LIMIT $3 OFFSET $4`
+	if query != want {
+		t.Errorf("unexpected query\n got: %s\nwant: %s", query, want)
+	}
+	if len(args) != 4 || args[2] != 10 || args[3] != 20 {
+		t.Errorf("unexpected args %v", args)
+	}
+}
+
+func TestBuildList_RejectsUnknownFields(t *testing.T) {
+	r := NewRepository[repoUser, int64](nil, "users", "id")
+
+	if _, _, err := r.buildList(repository.NewQuery().Where("email; DROP TABLE users", repository.Eq, "x")); err == nil {
+		t.Error("expected error for unknown filter field")
+	}
+	if _, _, err := r.buildList(repository.NewQuery().OrderBy("Secret", false)); err == nil {
+		t.Error("expected error for unknown sort field")
+	}
+}
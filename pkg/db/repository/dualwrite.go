@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// Logger is the minimal logging contract used by DualWrite.
+// pkg/log/logger.Logger satisfies it.
+type Logger interface {
+	Warn(msg string, args ...interface{})
+}
+
+// DualWriteOptions controls a DualWrite repository.
+type DualWriteOptions[T any] struct {
+	// Logger receives shadow failures and read discrepancies. Optional.
+	Logger Logger
+
+	// CompareReads also reads from the shadow on Get and List and compares
+	// the results with the primary. Lists are compared item by item when the
+	// query sorts them, and regardless of order otherwise; pages of unsorted
+	// lists are not compared, as the backends may return different items.
+	// Discrepancies are logged with the names of the differing fields, never
+	// their values.
+	CompareReads bool
+
+	// StrictShadowWrites fails writes when the shadow write fails. By default
+	// shadow failures are logged and counted but the primary result wins.
+	StrictShadowWrites bool
+
+	// Equal compares a primary and a shadow entity. Defaults to
+	// reflect.DeepEqual.
+	Equal func(primary, shadow *T) bool
+}
+
+// DualWriteStats are running counters kept by a DualWrite repository.
+type DualWriteStats struct {
+	Writes       int64
+	ShadowErrors int64
+	Compared     int64
+	Mismatches   int64
+}
+
+// MatchRate returns the fraction of compared reads where the shadow agreed
+// with the primary, or 1 if nothing has been compared yet.
+func (s DualWriteStats) MatchRate() float64 {
+	if s.Compared == 0 {
+		return 1
+	}
+	return float64(s.Compared-s.Mismatches) / float64(s.Compared)
+}
+
+// DualWrite is a migration shim that serves every call from a primary
+// repository while mirroring writes to a shadow, optionally comparing reads.
+// Its stats give a confidence measure before cutting over to the shadow.
+type DualWrite[T any, ID comparable] struct {
+	primary Repository[T, ID]
+	shadow  Repository[T, ID]
+	opts    DualWriteOptions[T]
+
+	writes       atomic.Int64
+	shadowErrors atomic.Int64
+	compared     atomic.Int64
+	mismatches   atomic.Int64
+}
+
+// NewDualWrite returns a DualWrite repository.
+//
+// Example:
+//
+//	repo := repository.NewDualWrite[User, string](
+//	    mongo.NewRepository[User, string](coll),
+//	    postgres.NewRepository[User, string](db, "users", "id"),
+//	    repository.DualWriteOptions[User]{Logger: log, CompareReads: true},
+//	)
+func NewDualWrite[T any, ID comparable](primary, shadow Repository[T, ID], opts DualWriteOptions[T]) *DualWrite[T, ID] {
+	if opts.Equal == nil {
+		opts.Equal = func(a, b *T) bool { return reflect.DeepEqual(a, b) }
+	}
+	return &DualWrite[T, ID]{primary: primary, shadow: shadow, opts: opts}
+}
+
+// Stats returns a snapshot of the dual-write counters.
+func (d *DualWrite[T, ID]) Stats() DualWriteStats {
+	return DualWriteStats{
+		Writes:       d.writes.Load(),
+		ShadowErrors: d.shadowErrors.Load(),
+		Compared:     d.compared.Load(),
+		Mismatches:   d.mismatches.Load(),
+	}
+}
+
+// Get implements Repository.
+func (d *DualWrite[T, ID]) Get(ctx context.Context, id ID) (*T, error) {
+	got, err := d.primary.Get(ctx, id)
+	if !d.opts.CompareReads {
+		return got, err
+	}
+
+	shadow, shadowErr := d.shadow.Get(ctx, id)
+	switch {
+	case err != nil && shadowErr != nil:
+		// Both missing counts as agreement; other failures are not compared.
+		if errors.Is(err, ErrNotFound) && errors.Is(shadowErr, ErrNotFound) {
+			d.compared.Add(1)
+		}
+	case errors.Is(err, ErrNotFound) && shadowErr == nil:
+		d.mismatch(fmt.Sprintf("get %v", id), "unexpected in shadow")
+	case err != nil:
+		// Primary failure is returned below; nothing to compare.
+	case errors.Is(shadowErr, ErrNotFound):
+		d.mismatch(fmt.Sprintf("get %v", id), "missing in shadow")
+	case shadowErr != nil:
+		d.shadowFailure("get", shadowErr)
+	case !d.opts.Equal(got, shadow):
+		d.mismatch(fmt.Sprintf("get %v", id), differingFields(got, shadow))
+	default:
+		d.compared.Add(1)
+	}
+	return got, err
+}
+
+// List implements Repository.
+func (d *DualWrite[T, ID]) List(ctx context.Context, q *Query) ([]T, error) {
+	got, err := d.primary.List(ctx, q)
+	if err != nil || !d.opts.CompareReads {
+		return got, err
+	}
+
+	shadow, shadowErr := d.shadow.List(ctx, q)
+	if shadowErr != nil {
+		d.shadowFailure("list", shadowErr)
+		return got, nil
+	}
+	if len(got) != len(shadow) {
+		d.mismatch("list", fmt.Sprintf("primary returned %d items, shadow %d", len(got), len(shadow)))
+		return got, nil
+	}
+	if q == nil || len(q.Sorts) == 0 {
+		if q != nil && (q.Limit > 0 || q.Offset > 0) {
+			return got, nil
+		}
+		if i := d.unmatched(got, shadow); i >= 0 {
+			d.mismatch("list", fmt.Sprintf("item %d has no equal item in shadow", i))
+			return got, nil
+		}
+		d.compared.Add(1)
+		return got, nil
+	}
+	for i := range got {
+		if !d.opts.Equal(&got[i], &shadow[i]) {
+			d.mismatch("list", fmt.Sprintf("item %d: %s", i, differingFields(&got[i], &shadow[i])))
+			return got, nil
+		}
+	}
+	d.compared.Add(1)
+	return got, nil
+}
+
+// unmatched returns the index of the first primary item without an equal
+// shadow item, each shadow item matching once, or -1 if there is none.
+func (d *DualWrite[T, ID]) unmatched(primary, shadow []T) int {
+	used := make([]bool, len(shadow))
+	for i := range primary {
+		found := false
+		for j := range shadow {
+			if !used[j] && d.opts.Equal(&primary[i], &shadow[j]) {
+				used[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return i
+		}
+	}
+	return -1
+}
+
+// differingFields describes how a and b differ by naming the top-level
+// struct fields that differ, so values (which may be personal data) are
+// never logged.
+func differingFields[T any](a, b *T) string {
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	if va.Kind() != reflect.Struct {
+		return "values differ"
+	}
+	var names []string
+	for i := 0; i < va.NumField(); i++ {
+		f := va.Type().Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			names = append(names, f.Name)
+		}
+	}
+	if len(names) == 0 {
+		return "values differ"
+	}
+	return "fields differ: " + strings.Join(names, ", ")
+}
+
+// Create implements Repository. The shadow receives the entity after the
+// primary has filled in any generated fields.
+func (d *DualWrite[T, ID]) Create(ctx context.Context, entity *T) error {
+	if err := d.primary.Create(ctx, entity); err != nil {
+		return err
+	}
+	return d.mirror("create", d.shadow.Create(ctx, entity))
+}
+
+// Update implements Repository.
+func (d *DualWrite[T, ID]) Update(ctx context.Context, id ID, entity *T) error {
+	if err := d.primary.Update(ctx, id, entity); err != nil {
+		return err
+	}
+	return d.mirror("update", d.shadow.Update(ctx, id, entity))
+}
+
+// Delete implements Repository.
+func (d *DualWrite[T, ID]) Delete(ctx context.Context, id ID) error {
+	if err := d.primary.Delete(ctx, id); err != nil {
+		return err
+	}
+	return d.mirror("delete", d.shadow.Delete(ctx, id))
+}
+
+// mirror records the outcome of a shadow write.
+func (d *DualWrite[T, ID]) mirror(op string, err error) error {
+	d.writes.Add(1)
+	if err == nil {
+		return nil
+	}
+	d.shadowFailure(op, err)
+	if d.opts.StrictShadowWrites {
+		return fmt.Errorf("shadow %s failed: %w", op, err)
+	}
+	return nil
+}
+
+func (d *DualWrite[T, ID]) shadowFailure(op string, err error) {
+	d.shadowErrors.Add(1)
+	d.warn("dual-write shadow %s failed: %v", op, err)
+}
+
+func (d *DualWrite[T, ID]) mismatch(op, detail string) {
+	d.compared.Add(1)
+	d.mismatches.Add(1)
+	d.warn("dual-write discrepancy on %s: %s", op, detail)
+}
+
+func (d *DualWrite[T, ID]) warn(msg string, args ...interface{}) {
+	if d.opts.Logger != nil {
+		d.opts.Logger.Warn(msg, args...)
+	}
+}
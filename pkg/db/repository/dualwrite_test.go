@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	ID    string
+	Name  string
+	Email string
+}
+
+// memRepo is an in-memory Repository used to exercise DualWrite. Lists are
+// sorted by ID, or in reverse when reverse is set.
+type memRepo struct {
+	items   map[string]item
+	err     error
+	reverse bool
+}
+
+func newMemRepo() *memRepo { return &memRepo{items: map[string]item{}} }
+
+func (m *memRepo) Get(_ context.Context, id string) (*item, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	it, ok := m.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &it, nil
+}
+
+func (m *memRepo) List(_ context.Context, _ *Query) ([]item, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	out := []item{}
+	for _, it := range m.items {
+		out = append(out, it)
+	}
+	sort.Slice(out, func(i, j int) bool { return (out[i].ID < out[j].ID) != m.reverse })
+	return out, nil
+}
+
+func (m *memRepo) Create(_ context.Context, it *item) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.items[it.ID] = *it
+	return nil
+}
+
+func (m *memRepo) Update(_ context.Context, id string, it *item) error {
+	if m.err != nil {
+		return m.err
+	}
+	if _, ok := m.items[id]; !ok {
+		return ErrNotFound
+	}
+	m.items[id] = *it
+	return nil
+}
+
+func (m *memRepo) Delete(_ context.Context, id string) error {
+	if m.err != nil {
+		return m.err
+	}
+	delete(m.items, id)
+	return nil
+}
+
+type recordingLogger struct{ lines []string }
+
+func (l *recordingLogger) Warn(msg string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(msg, args...))
+}
+
+func TestDualWrite_MirrorsWrites(t *testing.T) {
+	ctx := context.Background()
+	primary, shadow := newMemRepo(), newMemRepo()
+	repo := NewDualWrite[item, string](primary, shadow, DualWriteOptions[item]{})
+
+	_ = repo.Create(ctx, &item{ID: "a", Name: "one"})
+	_ = repo.Update(ctx, "a", &item{ID: "a", Name: "two"})
+	_ = repo.Create(ctx, &item{ID: "b", Name: "three"})
+	_ = repo.Delete(ctx, "b")
+
+	if shadow.items["a"].Name != "two" || len(shadow.items) != 1 {
+		t.Errorf("shadow out of sync: %+v", shadow.items)
+	}
+	if s := repo.Stats(); s.Writes != 4 || s.ShadowErrors != 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
+
+func TestDualWrite_ShadowFailure(t *testing.T) {
+	ctx := context.Background()
+	shadow := newMemRepo()
+	shadow.err = errors.New("connection refused")
+	log := &recordingLogger{}
+
+	lenient := NewDualWrite[item, string](newMemRepo(), shadow, DualWriteOptions[item]{Logger: log})
+	if err := lenient.Create(ctx, &item{ID: "a"}); err != nil {
+		t.Errorf("expected shadow failure to be tolerated, got %v", err)
+	}
+	if lenient.Stats().ShadowErrors != 1 || len(log.lines) != 1 {
+		t.Errorf("expected shadow failure to be counted and logged, got %+v %v", lenient.Stats(), log.lines)
+	}
+
+	strict := NewDualWrite[item, string](newMemRepo(), shadow, DualWriteOptions[item]{StrictShadowWrites: true})
+	if err := strict.Create(ctx, &item{ID: "a"}); err == nil {
+		t.Error("expected strict mode to surface shadow failure")
+	}
+}
+
+func TestDualWrite_CompareReads(t *testing.T) {
+	ctx := context.Background()
+	primary, shadow := newMemRepo(), newMemRepo()
+	log := &recordingLogger{}
+	repo := NewDualWrite[item, string](primary, shadow, DualWriteOptions[item]{Logger: log, CompareReads: true})
+
+	_ = repo.Create(ctx, &item{ID: "a", Name: "one"})
+	if _, err := repo.Get(ctx, "a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repo.List(ctx, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Drift the shadow and read again.
+	shadow.items["a"] = item{ID: "a", Name: "stale"}
+	got, _ := repo.Get(ctx, "a")
+	if got.Name != "one" {
+		t.Errorf("expected primary result, got %+v", got)
+	}
+	delete(shadow.items, "a")
+	_, _ = repo.Get(ctx, "a")
+
+	s := repo.Stats()
+	if s.Compared != 4 || s.Mismatches != 2 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if s.MatchRate() != 0.5 {
+		t.Errorf("expected match rate 0.5, got %v", s.MatchRate())
+	}
+	if len(log.lines) != 2 {
+		t.Errorf("expected 2 discrepancy logs, got %v", log.lines)
+	}
+}
+
+func TestDualWrite_UnexpectedInShadow(t *testing.T) {
+	primary, shadow := newMemRepo(), newMemRepo()
+	shadow.items["a"] = item{ID: "a", Name: "orphan"}
+	repo := NewDualWrite[item, string](primary, shadow, DualWriteOptions[item]{CompareReads: true})
+
+	if _, err := repo.Get(context.Background(), "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected the primary ErrNotFound, got %v", err)
+	}
+	if s := repo.Stats(); s.Compared != 1 || s.Mismatches != 1 {
+		t.Errorf("expected a mismatch, got %+v", s)
+	}
+}
+
+func TestDualWrite_LogsFieldNamesOnly(t *testing.T) {
+	ctx := context.Background()
+	primary, shadow := newMemRepo(), newMemRepo()
+	log := &recordingLogger{}
+	repo := NewDualWrite[item, string](primary, shadow, DualWriteOptions[item]{Logger: log, CompareReads: true})
+
+	primary.items["a"] = item{ID: "a", Name: "Ada", Email: "ada@example.com"}
+	shadow.items["a"] = item{ID: "a", Name: "Ada", Email: "old@example.com"}
+	_, _ = repo.Get(ctx, "a")
+	_, _ = repo.List(ctx, NewQuery().OrderBy("ID", false))
+
+	if len(log.lines) != 2 {
+		t.Fatalf("expected 2 discrepancy logs, got %v", log.lines)
+	}
+	for _, line := range log.lines {
+		if !strings.Contains(line, "fields differ: Email") || strings.Contains(line, "example.com") {
+			t.Errorf("expected only field names to be logged, got %q", line)
+		}
+	}
+}
+
+func TestDualWrite_ListOrder(t *testing.T) {
+	ctx := context.Background()
+	primary, shadow := newMemRepo(), newMemRepo()
+	shadow.reverse = true
+	repo := NewDualWrite[item, string](primary, shadow, DualWriteOptions[item]{CompareReads: true})
+	_ = repo.Create(ctx, &item{ID: "a"})
+	_ = repo.Create(ctx, &item{ID: "b"})
+
+	// Unsorted lists match regardless of order and pages are not compared
+	_, _ = repo.List(ctx, nil)
+	_, _ = repo.List(ctx, NewQuery().Page(1, 0))
+	if s := repo.Stats(); s.Compared != 1 || s.Mismatches != 0 {
+		t.Errorf("unexpected stats for unsorted lists %+v", s)
+	}
+
+	// Sorted lists must match in order
+	_, _ = repo.List(ctx, NewQuery().OrderBy("ID", false))
+	if s := repo.Stats(); s.Compared != 2 || s.Mismatches != 1 {
+		t.Errorf("unexpected stats for a sorted list %+v", s)
+	}
+}
+
+func TestDualWriteStats_MatchRateWithoutReads(t *testing.T) {
+	if r := (DualWriteStats{}).MatchRate(); r != 1 {
+		t.Errorf("expected 1, got %v", r)
+	}
+}
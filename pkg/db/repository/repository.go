@@ -0,0 +1,118 @@
+// Package repository defines a minimal, database-agnostic repository
+// abstraction. Concrete implementations live next to their drivers in
+// pkg/db/mongo and pkg/db/postgres, so services can switch datastores per
+// deployment (or run both during a migration) behind one interface.
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned when no entity matches the requested ID.
+var ErrNotFound = errors.New("entity not found")
+
+// Repository is the generic CRUD contract implemented by every backend.
+type Repository[T any, ID comparable] interface {
+	// Get returns the entity with the given ID, or ErrNotFound.
+	Get(ctx context.Context, id ID) (*T, error)
+
+	// List returns the entities matching q. A nil query lists everything.
+	List(ctx context.Context, q *Query) ([]T, error)
+
+	// Create stores a new entity.
+	Create(ctx context.Context, entity *T) error
+
+	// Update replaces the entity with the given ID, or returns ErrNotFound.
+	Update(ctx context.Context, id ID, entity *T) error
+
+	// Delete removes the entity with the given ID, or returns ErrNotFound.
+	Delete(ctx context.Context, id ID) error
+}
+
+// Op is a comparison operator used in a Filter.
+type Op string
+
+// Supported filter operators.
+const (
+	Eq  Op = "eq"
+	Ne  Op = "ne"
+	Gt  Op = "gt"
+	Gte Op = "gte"
+	Lt  Op = "lt"
+	Lte Op = "lte"
+	In  Op = "in"
+)
+
+// Filter restricts a query to entities whose Field compares to Value.
+// For In, Value must be a slice.
+type Filter struct {
+	Field string
+	Op    Op
+	Value interface{}
+}
+
+// Sort orders query results by Field.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Query describes a backend-neutral List request. Field names refer to the
+// storage names (bson or db tags) of the entity.
+type Query struct {
+	Filters []Filter
+	Sorts   []Sort
+	Limit   int
+	Offset  int
+}
+
+// NewQuery returns an empty Query.
+//
+// Example:
+//
+//	q := repository.NewQuery().
+//	    Where("status", repository.Eq, "active").
+//	    OrderBy("created_at", true).
+//	    Page(50, 0)
+func NewQuery() *Query {
+	return &Query{}
+}
+
+// Where adds a filter and returns q for chaining.
+func (q *Query) Where(field string, op Op, value interface{}) *Query {
+	q.Filters = append(q.Filters, Filter{Field: field, Op: op, Value: value})
+	return q
+}
+
+// OrderBy adds a sort key and returns q for chaining.
+func (q *Query) OrderBy(field string, desc bool) *Query {
+	q.Sorts = append(q.Sorts, Sort{Field: field, Desc: desc})
+	return q
+}
+
+// Page sets the limit and offset and returns q for chaining.
+func (q *Query) Page(limit, offset int) *Query {
+	q.Limit, q.Offset = limit, offset
+	return q
+}
+
+// Validate checks that every filter uses a supported operator and that
+// limit and offset are not negative.
+func (q *Query) Validate() error {
+	if q == nil {
+		return nil
+	}
+	for _, f := range q.Filters {
+		switch f.Op {
+		case Eq, Ne, Gt, Gte, Lt, Lte, In:
+		default:
+			return fmt.Errorf("unsupported operator %q on field %s", f.Op, f.Field)
+		}
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return fmt.Errorf("limit and offset must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import "testing"
+
+func TestQueryBuilder(t *testing.T) {
+	q := NewQuery().
+		Where("status", Eq, "active").
+		Where("age", Gte, 18).
+		OrderBy("created_at", true).
+		Page(10, 20)
+
+	if len(q.Filters) != 2 || q.Filters[1].Op != Gte {
+		t.Errorf("unexpected filters %+v", q.Filters)
+	}
+	if len(q.Sorts) != 1 || !q.Sorts[0].Desc {
+		t.Errorf("unexpected sorts %+v", q.Sorts)
+	}
+	if q.Limit != 10 || q.Offset != 20 {
+		t.Errorf("unexpected paging %d/%d", q.Limit, q.Offset)
+	}
+	if err := q.Validate(); err != nil {
+		t.Errorf("expected valid query, got %v", err)
+	}
+}
+
+func TestQueryValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		q    *Query
+		ok   bool
+	}{
+		{"nil", nil, true},
+		{"bad operator", NewQuery().Where("a", Op("like"), "x"), false},
+		{"negative limit", NewQuery().Page(-1, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.q.Validate(); (err == nil) != tt.ok {
+				t.Errorf("expected ok=%v, got %v", tt.ok, err)
+			}
+		})
+	}
+}
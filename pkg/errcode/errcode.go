@@ -0,0 +1,268 @@
+// Package errcode defines a central catalog of stable error codes and how
+// each maps onto HTTP statuses, gRPC codes and messaging outcomes, so that
+// "is this retryable?" gets the same answer on every transport.
+//
+// Errors carry a code by wrapping them in an *AppError:
+//
+//	if user == nil {
+//	    return errcode.New(errcode.NotFound, "user %s not found", id)
+//	}
+//
+// and callers classify any error, wrapped or not, with the helpers:
+//
+//	c.JSON(errcode.HTTPStatus(err), gin.H{"code": errcode.Of(err).Code})
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Category groups codes by who is expected to act on them.
+type Category string
+
+// Error categories.
+const (
+	CategoryClient    Category = "client"
+	CategoryAuth      Category = "auth"
+	CategoryTransient Category = "transient"
+	CategoryServer    Category = "server"
+)
+
+// GRPCCode mirrors google.golang.org/grpc/codes.Code without importing gRPC.
+type GRPCCode uint32
+
+// gRPC status codes used by the catalog.
+const (
+	GRPCCanceled           GRPCCode = 1
+	GRPCUnknown            GRPCCode = 2
+	GRPCInvalidArgument    GRPCCode = 3
+	GRPCDeadlineExceeded   GRPCCode = 4
+	GRPCNotFound           GRPCCode = 5
+	GRPCAlreadyExists      GRPCCode = 6
+	GRPCPermissionDenied   GRPCCode = 7
+	GRPCResourceExhausted  GRPCCode = 8
+	GRPCFailedPrecondition GRPCCode = 9
+	GRPCUnimplemented      GRPCCode = 12
+	GRPCInternal           GRPCCode = 13
+	GRPCUnavailable        GRPCCode = 14
+	GRPCUnauthenticated    GRPCCode = 16
+)
+
+// Outcome is what a message consumer should do with a failed message.
+type Outcome int
+
+// Messaging outcomes.
+const (
+	// OutcomeRetry redelivers the message (or sends it to a retry topic).
+	OutcomeRetry Outcome = iota
+
+	// OutcomeDeadLetter parks the message on the dead-letter queue.
+	OutcomeDeadLetter
+)
+
+// String implements fmt.Stringer.
+func (o Outcome) String() string {
+	if o == OutcomeRetry {
+		return "retry"
+	}
+	return "dead_letter"
+}
+
+// Code describes one entry of the catalog.
+type Code struct {
+	// Code is the stable, machine-readable identifier, e.g. "not_found".
+	Code       string
+	Category   Category
+	Retryable  bool
+	HTTPStatus int
+	GRPCCode   GRPCCode
+}
+
+// Outcome returns the messaging outcome for the code.
+func (c Code) Outcome() Outcome {
+	if c.Retryable {
+		return OutcomeRetry
+	}
+	return OutcomeDeadLetter
+}
+
+// Built-in codes.
+var (
+	InvalidArgument    = Code{"invalid_argument", CategoryClient, false, http.StatusBadRequest, GRPCInvalidArgument}
+	Unauthenticated    = Code{"unauthenticated", CategoryAuth, false, http.StatusUnauthorized, GRPCUnauthenticated}
+	PermissionDenied   = Code{"permission_denied", CategoryAuth, false, http.StatusForbidden, GRPCPermissionDenied}
+	NotFound           = Code{"not_found", CategoryClient, false, http.StatusNotFound, GRPCNotFound}
+	Conflict           = Code{"conflict", CategoryClient, false, http.StatusConflict, GRPCAlreadyExists}
+	PreconditionFailed = Code{"precondition_failed", CategoryClient, false, http.StatusPreconditionFailed, GRPCFailedPrecondition}
+	PayloadTooLarge    = Code{"payload_too_large", CategoryClient, false, http.StatusRequestEntityTooLarge, GRPCInvalidArgument}
+	RateLimited        = Code{"rate_limited", CategoryTransient, true, http.StatusTooManyRequests, GRPCResourceExhausted}
+	Canceled           = Code{"canceled", CategoryClient, false, 499, GRPCCanceled}
+	Timeout            = Code{"timeout", CategoryTransient, true, http.StatusGatewayTimeout, GRPCDeadlineExceeded}
+	Unavailable        = Code{"unavailable", CategoryTransient, true, http.StatusServiceUnavailable, GRPCUnavailable}
+	Unimplemented      = Code{"unimplemented", CategoryServer, false, http.StatusNotImplemented, GRPCUnimplemented}
+	Internal           = Code{"internal", CategoryServer, false, http.StatusInternalServerError, GRPCInternal}
+)
+
+var (
+	catalogMu sync.RWMutex
+	catalog   = map[string]Code{}
+)
+
+func init() {
+	for _, c := range []Code{
+		InvalidArgument, Unauthenticated, PermissionDenied, NotFound, Conflict,
+		PreconditionFailed, PayloadTooLarge, RateLimited, Canceled, Timeout,
+		Unavailable, Unimplemented, Internal,
+	} {
+		catalog[c.Code] = c
+	}
+}
+
+// Register adds a service-specific code to the catalog. It returns an error
+// if the code is empty or already registered with different properties.
+func Register(c Code) error {
+	if c.Code == "" {
+		return fmt.Errorf("error code must not be empty")
+	}
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	if existing, ok := catalog[c.Code]; ok && existing != c {
+		return fmt.Errorf("error code %q is already registered", c.Code)
+	}
+	catalog[c.Code] = c
+	return nil
+}
+
+// Lookup returns the registered code with the given identifier.
+func Lookup(code string) (Code, bool) {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	c, ok := catalog[code]
+	return c, ok
+}
+
+// AppError is an error annotated with a catalog code.
+type AppError struct {
+	Code    Code
+	Message string
+	Err     error
+}
+
+// New returns an *AppError with a formatted message.
+func New(code Code, format string, args ...interface{}) *AppError {
+	return &AppError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap annotates err with code. The message defaults to err's text.
+func Wrap(err error, code Code, message string) *AppError {
+	if message == "" && err != nil {
+		message = err.Error()
+	}
+	return &AppError{Code: code, Message: message, Err: err}
+}
+
+// Error implements error.
+func (e *AppError) Error() string {
+	if e.Err != nil && e.Err.Error() != e.Message {
+		return fmt.Sprintf("%s: %s: %v", e.Code.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code.Code, e.Message)
+}
+
+// Unwrap returns the wrapped error.
+func (e *AppError) Unwrap() error { return e.Err }
+
+// Is reports whether target is an *AppError with the same code, so
+// errors.Is(err, errcode.New(errcode.NotFound, "")) matches by code.
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code.Code == e.Code.Code
+}
+
+// Of returns the code carried by err. Context cancellation and deadline
+// errors map to Canceled and Timeout; anything else without a code is
+// Internal. A nil error returns the zero Code.
+func Of(err error) Code {
+	if err == nil {
+		return Code{}
+	}
+	var app *AppError
+	if errors.As(err, &app) {
+		return app.Code
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	}
+	return Internal
+}
+
+// IsRetryable reports whether the operation that produced err may succeed
+// if retried.
+func IsRetryable(err error) bool {
+	return err != nil && Of(err).Retryable
+}
+
+// HTTPStatus returns the HTTP status for err (200 for nil).
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	return Of(err).HTTPStatus
+}
+
+// GRPCStatus returns the gRPC code for err (0, OK, for nil).
+func GRPCStatus(err error) GRPCCode {
+	if err == nil {
+		return 0
+	}
+	return Of(err).GRPCCode
+}
+
+// MessageOutcome returns what a consumer should do with a message whose
+// handler failed with err.
+func MessageOutcome(err error) Outcome {
+	return Of(err).Outcome()
+}
+
+// FromHTTPStatus classifies an upstream HTTP response status, for outbound
+// clients deciding whether to retry (see httpclient.Classify). Statuses
+// below 400 return the zero Code.
+func FromHTTPStatus(status int) Code {
+	switch {
+	case status < 400:
+		return Code{}
+	case status == http.StatusBadRequest, status == http.StatusUnprocessableEntity:
+		return InvalidArgument
+	case status == http.StatusUnauthorized:
+		return Unauthenticated
+	case status == http.StatusForbidden:
+		return PermissionDenied
+	case status == http.StatusNotFound, status == http.StatusGone:
+		return NotFound
+	case status == http.StatusConflict:
+		return Conflict
+	case status == http.StatusPreconditionFailed:
+		return PreconditionFailed
+	case status == http.StatusRequestEntityTooLarge:
+		return PayloadTooLarge
+	case status == http.StatusTooManyRequests:
+		return RateLimited
+	case status == http.StatusRequestTimeout, status == http.StatusGatewayTimeout:
+		return Timeout
+	case status == http.StatusBadGateway, status == http.StatusServiceUnavailable:
+		return Unavailable
+	case status == http.StatusNotImplemented:
+		return Unimplemented
+	case status < 500:
+		return InvalidArgument
+	default:
+		return Internal
+	}
+}
@@ -0,0 +1,114 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	base := errors.New("connection reset")
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"nil", nil, Code{}},
+		{"app error", New(NotFound, "user %s", "u1"), NotFound},
+		{"wrapped app error", fmt.Errorf("lookup: %w", Wrap(base, Unavailable, "")), Unavailable},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), Timeout},
+		{"canceled", context.Canceled, Canceled},
+		{"plain error", base, Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Of(tt.err); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want.Code, got.Code)
+			}
+		})
+	}
+}
+
+func TestTransportMappings(t *testing.T) {
+	err := Wrap(errors.New("too many requests"), RateLimited, "slow down")
+
+	if !IsRetryable(err) {
+		t.Error("expected rate limited error to be retryable")
+	}
+	if HTTPStatus(err) != http.StatusTooManyRequests {
+		t.Errorf("unexpected HTTP status %d", HTTPStatus(err))
+	}
+	if GRPCStatus(err) != GRPCResourceExhausted {
+		t.Errorf("unexpected gRPC code %d", GRPCStatus(err))
+	}
+	if MessageOutcome(err) != OutcomeRetry {
+		t.Errorf("unexpected outcome %s", MessageOutcome(err))
+	}
+
+	bad := New(InvalidArgument, "missing email")
+	if IsRetryable(bad) || MessageOutcome(bad) != OutcomeDeadLetter {
+		t.Error("expected invalid argument to go to the dead-letter queue")
+	}
+	if HTTPStatus(nil) != http.StatusOK || IsRetryable(nil) {
+		t.Error("unexpected classification of nil error")
+	}
+}
+
+func TestAppError_ErrorAndIs(t *testing.T) {
+	err := Wrap(errors.New("dial tcp: refused"), Unavailable, "payments down")
+	if got := err.Error(); got != "unavailable: payments down: dial tcp: refused" {
+		t.Errorf("unexpected message %q", got)
+	}
+	if got := New(NotFound, "gone").Error(); got != "not_found: gone" {
+		t.Errorf("unexpected message %q", got)
+	}
+	if !errors.Is(fmt.Errorf("ctx: %w", err), New(Unavailable, "")) {
+		t.Error("expected errors.Is to match by code")
+	}
+	if errors.Is(err, New(NotFound, "")) {
+		t.Error("expected different codes not to match")
+	}
+}
+
+func TestRegisterAndLookup(t *testing.T) {
+	quota := Code{Code: "quota_exceeded", Category: CategoryClient, HTTPStatus: http.StatusPaymentRequired, GRPCCode: GRPCResourceExhausted}
+	if err := Register(quota); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := Register(quota); err != nil {
+		t.Errorf("expected re-registering an identical code to succeed, got %v", err)
+	}
+	if err := Register(Code{Code: "not_found", HTTPStatus: 410}); err == nil {
+		t.Error("expected conflicting registration to fail")
+	}
+	if got, ok := Lookup("quota_exceeded"); !ok || got != quota {
+		t.Errorf("unexpected lookup result %+v, %v", got, ok)
+	}
+	if _, ok := Lookup("nope"); ok {
+		t.Error("expected unknown code lookup to fail")
+	}
+}
+
+func TestFromHTTPStatus(t *testing.T) {
+	tests := map[int]Code{
+		200: {},
+		400: InvalidArgument,
+		404: NotFound,
+		418: InvalidArgument,
+		429: RateLimited,
+		502: Unavailable,
+		503: Unavailable,
+		504: Timeout,
+		500: Internal,
+	}
+	for status, want := range tests {
+		if got := FromHTTPStatus(status); got != want {
+			t.Errorf("status %d: expected %q, got %q", status, want.Code, got.Code)
+		}
+	}
+	if !FromHTTPStatus(503).Retryable || FromHTTPStatus(500).Retryable {
+		t.Error("unexpected retryability for 5xx statuses")
+	}
+}
@@ -0,0 +1,126 @@
+// Package errtrace creates errors that record the stack trace of where they
+// were created or wrapped, so logs and error reporters can show where a
+// failure originated rather than only where it was logged:
+//
+//	if err := row.Scan(&u); err != nil {
+//	    return errtrace.Wrap(err, "scan user")
+//	}
+//	...
+//	log.WithError(err).Error("request failed") // error, error_chain and error_stack fields
+//
+// Errors keep working with errors.Is and errors.As. The recorded program
+// counters are exposed through a StackTrace method, which Sentry and
+// similar reporters read as well.
+package errtrace
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// maxDepth bounds the number of frames recorded per error.
+const maxDepth = 32
+
+// Error is an error annotated with the stack trace of its creation.
+type Error struct {
+	msg   string
+	err   error
+	stack []uintptr
+}
+
+// New returns an error with msg and the current stack trace.
+func New(msg string) error {
+	return &Error{msg: msg, stack: callers()}
+}
+
+// Errorf formats an error like fmt.Errorf, including %w wrapping, and
+// records the current stack trace.
+func Errorf(format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	var wrapped error
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		wrapped = u.Unwrap()
+	case interface{ Unwrap() []error }:
+		wrapped = errors.Join(u.Unwrap()...)
+	}
+	return &Error{msg: err.Error(), err: wrapped, stack: callers()}
+}
+
+// Wrap returns err prefixed with msg, as "msg: err", and records the
+// current stack trace. It returns nil if err is nil.
+func Wrap(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{msg: msg + ": " + err.Error(), err: err, stack: callers()}
+}
+
+// Error implements error.
+func (e *Error) Error() string { return e.msg }
+
+// Unwrap returns the wrapped error, if any.
+func (e *Error) Unwrap() error { return e.err }
+
+// StackTrace returns the program counters recorded when e was created.
+func (e *Error) StackTrace() []uintptr { return e.stack }
+
+// Stack returns the frames of the deepest stack trace recorded in the chain
+// of err, which points closest to where the failure originated, or nil if
+// no error in the chain has one.
+func Stack(err error) []runtime.Frame {
+	var stack []uintptr
+	for _, e := range Chain(err) {
+		if t, ok := e.(*Error); ok && len(t.stack) > 0 {
+			stack = t.stack
+		}
+	}
+	if stack == nil {
+		return nil
+	}
+
+	frames := runtime.CallersFrames(stack)
+	var out []runtime.Frame
+	for {
+		frame, more := frames.Next()
+		out = append(out, frame)
+		if !more {
+			break
+		}
+	}
+	return out
+}
+
+// Chain returns err followed by the errors it wraps, depth first. Errors
+// joining several others (errors.Join) contribute each branch in order.
+func Chain(err error) []error {
+	var chain []error
+	var walk func(error)
+	walk = func(err error) {
+		for err != nil {
+			chain = append(chain, err)
+			switch u := err.(type) {
+			case interface{ Unwrap() []error }:
+				for _, e := range u.Unwrap() {
+					walk(e)
+				}
+				return
+			case interface{ Unwrap() error }:
+				err = u.Unwrap()
+			default:
+				return
+			}
+		}
+	}
+	walk(err)
+	return chain
+}
+
+// callers returns the program counters of the caller of the exported
+// function calling it.
+func callers() []uintptr {
+	pcs := make([]uintptr, maxDepth)
+	n := runtime.Callers(3, pcs)
+	return pcs[:n]
+}
@@ -0,0 +1,87 @@
+package errtrace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func loadUser() error {
+	return New("user not found")
+}
+
+func TestNew_RecordsCaller(t *testing.T) {
+	err := loadUser()
+	if err.Error() != "user not found" {
+		t.Errorf("unexpected message %q", err)
+	}
+	frames := Stack(err)
+	if len(frames) == 0 || !strings.HasSuffix(frames[0].Function, "errtrace.loadUser") {
+		t.Fatalf("expected the stack to start at loadUser, got %+v", frames)
+	}
+	if !strings.HasSuffix(frames[0].File, "errtrace_test.go") {
+		t.Errorf("unexpected file %q", frames[0].File)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if Wrap(nil, "ignored") != nil {
+		t.Error("expected Wrap(nil) to return nil")
+	}
+
+	err := Wrap(io.ErrUnexpectedEOF, "read config")
+	if err.Error() != "read config: unexpected EOF" {
+		t.Errorf("unexpected message %q", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Error("expected errors.Is to match the wrapped error")
+	}
+	var traced *Error
+	if !errors.As(err, &traced) || len(traced.StackTrace()) == 0 {
+		t.Error("expected a stack trace")
+	}
+}
+
+func TestErrorf(t *testing.T) {
+	err := Errorf("load %s: %w", "u1", io.EOF)
+	if err.Error() != "load u1: EOF" || !errors.Is(err, io.EOF) {
+		t.Errorf("unexpected error %q", err)
+	}
+
+	multi := Errorf("both: %w, %w", io.EOF, io.ErrClosedPipe)
+	if !errors.Is(multi, io.EOF) || !errors.Is(multi, io.ErrClosedPipe) {
+		t.Error("expected every %w operand to be wrapped")
+	}
+}
+
+func TestStack_Deepest(t *testing.T) {
+	inner := loadUser()
+	outer := Wrap(fmt.Errorf("handler: %w", inner), "request")
+
+	frames := Stack(outer)
+	if len(frames) == 0 || !strings.HasSuffix(frames[0].Function, "errtrace.loadUser") {
+		t.Errorf("expected the stack of the innermost error, got %+v", frames)
+	}
+	if Stack(errors.New("plain")) != nil {
+		t.Error("expected no stack for a plain error")
+	}
+}
+
+func TestChain(t *testing.T) {
+	a, b := errors.New("a"), errors.New("b")
+	err := fmt.Errorf("top: %w", errors.Join(a, fmt.Errorf("mid: %w", b)))
+
+	var got []string
+	for _, e := range Chain(err) {
+		got = append(got, e.Error())
+	}
+	want := []string{"top: a\nmid: b", "a\nmid: b", "a", "mid: b", "b"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Chain = %q, want %q", got, want)
+	}
+	if Chain(nil) != nil {
+		t.Error("expected an empty chain for nil")
+	}
+}
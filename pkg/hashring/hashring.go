@@ -0,0 +1,279 @@
+// Package hashring provides a consistent-hash ring with virtual nodes and
+// weighted members for client-side sharding of keys across replicas (cache
+// nodes, Kafka partitions, workers). Adding or removing a member only moves
+// the keys adjacent to its points, and every change reports how much of the
+// keyspace was reassigned.
+package hashring
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// DefaultVirtualNodes is the number of points placed on the ring per unit of
+// member weight when Options.VirtualNodes is not set.
+const DefaultVirtualNodes = 160
+
+// RebalanceStats describes the impact of a membership change.
+type RebalanceStats struct {
+	// Op is "add", "remove" or "update".
+	Op string
+
+	// Member is the member that was added, removed or re-weighted.
+	Member string
+
+	// MovedFraction is the fraction (0..1) of the keyspace whose owner changed.
+	MovedFraction float64
+
+	// Members is the number of members after the change.
+	Members int
+}
+
+// Options configures a Ring.
+type Options struct {
+	// VirtualNodes is the number of points per unit of weight.
+	VirtualNodes int
+
+	// Hash maps keys and virtual node labels onto the ring. Defaults to xxHash64.
+	Hash func(data []byte) uint64
+
+	// OnRebalance, if set, is called after every membership change. Use it to
+	// export rebalancing metrics.
+	OnRebalance func(RebalanceStats)
+}
+
+// point is a single virtual node on the ring.
+type point struct {
+	hash   uint64
+	member string
+}
+
+// Ring is a consistent-hash ring. It is safe for concurrent use.
+type Ring struct {
+	mu          sync.RWMutex
+	vnodes      int
+	hash        func([]byte) uint64
+	onRebalance func(RebalanceStats)
+	weights     map[string]int
+	points      []point
+}
+
+// New creates an empty Ring. A nil opts uses the defaults.
+//
+// Example:
+//
+//	ring := hashring.New(nil)
+//	ring.Add("cache-a:6379", 1)
+//	ring.Add("cache-b:6379", 2) // receives roughly twice as many keys
+//	node, _ := ring.Get("user:42")
+func New(opts *Options) *Ring {
+	if opts == nil {
+		opts = &Options{}
+	}
+	r := &Ring{
+		vnodes:      opts.VirtualNodes,
+		hash:        opts.Hash,
+		onRebalance: opts.OnRebalance,
+		weights:     make(map[string]int),
+	}
+	if r.vnodes <= 0 {
+		r.vnodes = DefaultVirtualNodes
+	}
+	if r.hash == nil {
+		r.hash = xxhash.Sum64
+	}
+	return r
+}
+
+// Add inserts member with the given weight, or updates its weight if it is
+// already present. Weights <= 0 are treated as 1.
+func (r *Ring) Add(member string, weight int) {
+	if weight <= 0 {
+		weight = 1
+	}
+
+	r.mu.Lock()
+	op := "add"
+	if current, ok := r.weights[member]; ok {
+		if current == weight {
+			r.mu.Unlock()
+			return
+		}
+		op = "update"
+	}
+	old := r.points
+	r.weights[member] = weight
+	r.rebuild()
+	stats := r.stats(op, member, old)
+	r.mu.Unlock()
+
+	r.report(stats)
+}
+
+// Remove deletes member from the ring. Removing an unknown member is a no-op.
+func (r *Ring) Remove(member string) {
+	r.mu.Lock()
+	if _, ok := r.weights[member]; !ok {
+		r.mu.Unlock()
+		return
+	}
+	old := r.points
+	delete(r.weights, member)
+	r.rebuild()
+	stats := r.stats("remove", member, old)
+	r.mu.Unlock()
+
+	r.report(stats)
+}
+
+// Get returns the member owning key. It returns false if the ring is empty.
+func (r *Ring) Get(key string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.points) == 0 {
+		return "", false
+	}
+	return r.points[r.search(r.hash([]byte(key)))].member, true
+}
+
+// GetN returns up to n distinct members for key, in ring order starting with
+// the owner. This is useful for replication or fallback targets.
+func (r *Ring) GetN(key string, n int) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if n > len(r.weights) {
+		n = len(r.weights)
+	}
+	if n <= 0 || len(r.points) == 0 {
+		return nil
+	}
+
+	out := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	start := r.search(r.hash([]byte(key)))
+	for i := 0; len(out) < n && i < len(r.points); i++ {
+		m := r.points[(start+i)%len(r.points)].member
+		if !seen[m] {
+			seen[m] = true
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Members returns the current members sorted by name.
+func (r *Ring) Members() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]string, 0, len(r.weights))
+	for m := range r.weights {
+		out = append(out, m)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// search returns the index of the first point at or after h, wrapping around.
+// The caller must hold r.mu.
+func (r *Ring) search(h uint64) int {
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		return 0
+	}
+	return i
+}
+
+// rebuild recomputes all points from r.weights. The caller must hold r.mu.
+func (r *Ring) rebuild() {
+	total := 0
+	for _, w := range r.weights {
+		total += w * r.vnodes
+	}
+
+	points := make([]point, 0, total)
+	for member, w := range r.weights {
+		for i := 0; i < w*r.vnodes; i++ {
+			label := member + "#" + strconv.Itoa(i)
+			points = append(points, point{hash: r.hash([]byte(label)), member: member})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash == points[j].hash {
+			return points[i].member < points[j].member
+		}
+		return points[i].hash < points[j].hash
+	})
+	r.points = points
+}
+
+// stats computes the RebalanceStats for a change from old to r.points.
+// The caller must hold r.mu.
+func (r *Ring) stats(op, member string, old []point) RebalanceStats {
+	return RebalanceStats{
+		Op:            op,
+		Member:        member,
+		MovedFraction: movedFraction(old, r.points),
+		Members:       len(r.weights),
+	}
+}
+
+func (r *Ring) report(stats RebalanceStats) {
+	if r.onRebalance != nil {
+		r.onRebalance(stats)
+	}
+}
+
+// movedFraction returns the fraction of the hash space whose owner differs
+// between two rings. Each arc (prev, p] is owned by the member at p.
+func movedFraction(a, b []point) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		if len(a) == 0 && len(b) == 0 {
+			return 0
+		}
+		return 1
+	}
+
+	// Walk the union of boundaries; within each segment both owners are fixed.
+	bounds := make([]uint64, 0, len(a)+len(b))
+	for _, p := range a {
+		bounds = append(bounds, p.hash)
+	}
+	for _, p := range b {
+		bounds = append(bounds, p.hash)
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	owner := func(ring []point, h uint64) string {
+		i := sort.Search(len(ring), func(i int) bool { return ring[i].hash >= h })
+		if i == len(ring) {
+			i = 0
+		}
+		return ring[i].member
+	}
+
+	if bounds[0] == bounds[len(bounds)-1] {
+		// A single boundary: one arc covering the whole ring.
+		if owner(a, bounds[0]) != owner(b, bounds[0]) {
+			return 1
+		}
+		return 0
+	}
+
+	var moved float64
+	prev := bounds[len(bounds)-1]
+	for _, h := range bounds {
+		// Segment (prev, h]; the first one wraps around zero.
+		if h != prev && owner(a, h) != owner(b, h) {
+			moved += float64(h - prev)
+		}
+		prev = h
+	}
+	return moved / math.Exp2(64)
+}
@@ -0,0 +1,123 @@
+package hashring
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestRing_Empty(t *testing.T) {
+	r := New(nil)
+	if _, ok := r.Get("k"); ok {
+		t.Error("expected empty ring to return false")
+	}
+	if got := r.GetN("k", 2); got != nil {
+		t.Errorf("expected no members, got %v", got)
+	}
+}
+
+func TestRing_GetIsStable(t *testing.T) {
+	r := New(nil)
+	r.Add("a", 1)
+	r.Add("b", 1)
+	r.Add("c", 1)
+
+	first, _ := r.Get("user:42")
+	for i := 0; i < 10; i++ {
+		if got, _ := r.Get("user:42"); got != first {
+			t.Fatalf("expected stable owner %q, got %q", first, got)
+		}
+	}
+}
+
+func TestRing_WeightedDistribution(t *testing.T) {
+	r := New(nil)
+	r.Add("small", 1)
+	r.Add("large", 3)
+
+	counts := map[string]int{}
+	for i := 0; i < 20000; i++ {
+		m, _ := r.Get(fmt.Sprintf("key-%d", i))
+		counts[m]++
+	}
+
+	ratio := float64(counts["large"]) / float64(counts["small"])
+	if ratio < 2.4 || ratio > 3.6 {
+		t.Errorf("expected roughly 3x keys on weighted member, got ratio %.2f (%v)", ratio, counts)
+	}
+}
+
+func TestRing_RemoveOnlyMovesOwnedKeys(t *testing.T) {
+	r := New(nil)
+	for _, m := range []string{"a", "b", "c", "d"} {
+		r.Add(m, 1)
+	}
+
+	before := map[string]string{}
+	for i := 0; i < 2000; i++ {
+		k := fmt.Sprintf("key-%d", i)
+		before[k], _ = r.Get(k)
+	}
+
+	r.Remove("c")
+	for k, owner := range before {
+		after, _ := r.Get(k)
+		if owner != "c" && after != owner {
+			t.Fatalf("key %s moved from %s to %s although its owner stayed", k, owner, after)
+		}
+		if after == "c" {
+			t.Fatalf("key %s still owned by removed member", k)
+		}
+	}
+}
+
+func TestRing_GetN(t *testing.T) {
+	r := New(nil)
+	r.Add("a", 1)
+	r.Add("b", 1)
+	r.Add("c", 1)
+
+	got := r.GetN("order:7", 5)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 distinct members, got %v", got)
+	}
+	owner, _ := r.Get("order:7")
+	if got[0] != owner {
+		t.Errorf("expected owner first, got %v (owner %s)", got, owner)
+	}
+}
+
+func TestRing_RebalanceStats(t *testing.T) {
+	var events []RebalanceStats
+	r := New(&Options{OnRebalance: func(s RebalanceStats) { events = append(events, s) }})
+
+	r.Add("a", 1)
+	r.Add("b", 1)
+	r.Add("b", 1) // unchanged weight: no event
+	r.Add("c", 1)
+	r.Remove("missing")
+	r.Remove("a")
+
+	if len(events) != 4 {
+		t.Fatalf("expected 4 rebalance events, got %d", len(events))
+	}
+	if events[0].MovedFraction != 1 {
+		t.Errorf("expected first member to take the whole keyspace, got %v", events[0].MovedFraction)
+	}
+	// Adding a third member should move roughly a third of the keyspace.
+	if f := events[2].MovedFraction; math.Abs(f-1.0/3) > 0.1 {
+		t.Errorf("expected ~0.33 moved when adding third member, got %.3f", f)
+	}
+	if events[3].Op != "remove" || events[3].Members != 2 {
+		t.Errorf("unexpected remove event %+v", events[3])
+	}
+}
+
+func TestRing_Members(t *testing.T) {
+	r := New(&Options{VirtualNodes: 4})
+	r.Add("b", 1)
+	r.Add("a", 2)
+	if got := r.Members(); len(got) != 2 || got[0] != "a" {
+		t.Errorf("expected sorted members, got %v", got)
+	}
+}
@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestBearerAuth(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://svc.internal/x", nil)
+	if err := BearerAuth(StaticToken("abc", "")).Authenticate(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer abc" {
+		t.Errorf("expected bearer header, got %q", got)
+	}
+}
+
+func TestBearerAuth_TokenError(t *testing.T) {
+	ts := TokenSourceFunc(func(context.Context) (*Token, error) { return nil, errors.New("down") })
+	req, _ := http.NewRequest("GET", "http://svc.internal/x", nil)
+	if err := BearerAuth(ts).Authenticate(req); err == nil {
+		t.Fatal("expected error when token source fails")
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://svc.internal/x", nil)
+	_ = APIKeyAuth("X-API-Key", "k1").Authenticate(req)
+	if got := req.Header.Get("X-API-Key"); got != "k1" {
+		t.Errorf("expected API key header, got %q", got)
+	}
+}
+
+func TestSigV4Auth_SignsAndPreservesBody(t *testing.T) {
+	creds := credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")
+	auth := SigV4Auth(creds, "execute-api", "us-east-1")
+
+	req, _ := http.NewRequest("POST", "https://api.example.com/items", strings.NewReader(`{"a":1}`))
+	if err := auth.Authenticate(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := req.Header.Get("Authorization"); !strings.HasPrefix(got, "AWS4-HMAC-SHA256 Credential=AKID/") {
+		t.Errorf("expected SigV4 authorization header, got %q", got)
+	}
+	if req.Header.Get("X-Amz-Date") == "" {
+		t.Error("expected X-Amz-Date header")
+	}
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != `{"a":1}` {
+		t.Errorf("expected body to be preserved, got %q", body)
+	}
+}
+
+func TestSigV4Auth_CredentialsError(t *testing.T) {
+	creds := aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{}, errors.New("no creds")
+	})
+	req, _ := http.NewRequest("GET", "https://api.example.com/items", nil)
+	if err := SigV4Auth(creds, "execute-api", "us-east-1").Authenticate(req); err == nil {
+		t.Fatal("expected error when credentials cannot be retrieved")
+	}
+}
@@ -0,0 +1,51 @@
+package httpclient
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+)
+
+// Classify maps the outcome of an outbound request onto the errcode
+// catalog. Errors that carry a code (including context cancellation and
+// deadlines) keep it, other network timeouts are Timeout and remaining
+// transport errors, such as refused or reset connections, are Unavailable.
+// Without an error the response status is classified with
+// errcode.FromHTTPStatus, so successful responses return the zero Code.
+func Classify(resp *http.Response, err error) errcode.Code {
+	if err != nil {
+		var app *errcode.AppError
+		if errors.As(err, &app) {
+			return app.Code
+		}
+		if code := errcode.Of(err); code != errcode.Internal {
+			return code
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return errcode.Timeout
+		}
+		return errcode.Unavailable
+	}
+	if resp == nil {
+		return errcode.Code{}
+	}
+	return errcode.FromHTTPStatus(resp.StatusCode)
+}
+
+// ShouldRetry reports whether a request that ended with resp and err may
+// succeed if retried, according to the Retryable flag of its Classify code.
+// It does not check whether the request is safe to repeat; callers retrying
+// non-idempotent methods must ensure that themselves.
+//
+// Example:
+//
+//	resp, err := client.Do(req)
+//	if httpclient.ShouldRetry(resp, err) {
+//	    // back off and try again
+//	}
+func ShouldRetry(resp *http.Response, err error) bool {
+	return Classify(resp, err).Retryable
+}
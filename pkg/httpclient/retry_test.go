@@ -0,0 +1,61 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassify(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	tests := []struct {
+		name   string
+		status int
+		err    error
+		want   errcode.Code
+	}{
+		{"ok", http.StatusOK, nil, errcode.Code{}},
+		{"not found", http.StatusNotFound, nil, errcode.NotFound},
+		{"rate limited", http.StatusTooManyRequests, nil, errcode.RateLimited},
+		{"unavailable", http.StatusServiceUnavailable, nil, errcode.Unavailable},
+		{"server error", http.StatusInternalServerError, nil, errcode.Internal},
+		{"canceled", 0, context.Canceled, errcode.Canceled},
+		{"network timeout", 0, timeoutError{}, errcode.Timeout},
+		{"connection refused", 0, refused, errcode.Unavailable},
+		{"app error", 0, errcode.New(errcode.Unauthenticated, "no token"), errcode.Unauthenticated},
+	}
+	for _, tt := range tests {
+		var resp *http.Response
+		if tt.err == nil {
+			resp = &http.Response{StatusCode: tt.status}
+		}
+		if got := Classify(resp, tt.err); got.Code != tt.want.Code {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want.Code, got.Code)
+		}
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	if !ShouldRetry(&http.Response{StatusCode: http.StatusServiceUnavailable}, nil) {
+		t.Error("expected 503 to be retried")
+	}
+	if ShouldRetry(&http.Response{StatusCode: http.StatusBadRequest}, nil) {
+		t.Error("expected 400 not to be retried")
+	}
+	if !ShouldRetry(nil, &net.OpError{Op: "read", Err: errors.New("connection reset")}) {
+		t.Error("expected a reset connection to be retried")
+	}
+	if ShouldRetry(nil, context.Canceled) {
+		t.Error("expected a canceled request not to be retried")
+	}
+}
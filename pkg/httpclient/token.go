@@ -0,0 +1,177 @@
+// Package httpclient provides helpers for authenticated outbound HTTP calls
+// to internal services. It defines pluggable token sources (OAuth2 client
+// credentials, static API keys) with automatic caching and refresh, request
+// authenticators (bearer, API key, AWS SigV4) and a transport that binds an
+// authenticator to each destination host.
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token is a credential presented to a remote service.
+type Token struct {
+	// Value is the raw token or key.
+	Value string
+
+	// Type is the authorization scheme (e.g. "Bearer"). Defaults to "Bearer".
+	Type string
+
+	// Expiry is when the token stops being valid. Zero means it never expires.
+	Expiry time.Time
+}
+
+// Valid reports whether the token is usable for at least leeway longer.
+func (t *Token) Valid(now time.Time, leeway time.Duration) bool {
+	if t == nil || t.Value == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || now.Add(leeway).Before(t.Expiry)
+}
+
+// TokenSource supplies tokens for outbound requests.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc adapts a function to the TokenSource interface.
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+// Token implements TokenSource.
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) { return f(ctx) }
+
+// StaticToken returns a TokenSource that always yields the same token, such
+// as a long-lived API key.
+func StaticToken(value, tokenType string) TokenSource {
+	tok := &Token{Value: value, Type: tokenType}
+	return TokenSourceFunc(func(context.Context) (*Token, error) { return tok, nil })
+}
+
+// DefaultRefreshLeeway is how long before expiry a cached token is refreshed.
+const DefaultRefreshLeeway = 30 * time.Second
+
+// cachingTokenSource reuses a token until shortly before it expires.
+type cachingTokenSource struct {
+	mu     sync.Mutex
+	src    TokenSource
+	leeway time.Duration
+	tok    *Token
+	now    func() time.Time
+}
+
+// CachingTokenSource wraps src so that tokens are fetched once and reused
+// until they are within leeway of expiring. Concurrent callers share a
+// single refresh. If leeway <= 0, DefaultRefreshLeeway is used.
+func CachingTokenSource(src TokenSource, leeway time.Duration) TokenSource {
+	if leeway <= 0 {
+		leeway = DefaultRefreshLeeway
+	}
+	return &cachingTokenSource{src: src, leeway: leeway, now: time.Now}
+}
+
+// Token implements TokenSource.
+func (c *cachingTokenSource) Token(ctx context.Context) (*Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tok.Valid(c.now(), c.leeway) {
+		return c.tok, nil
+	}
+	tok, err := c.src.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.tok = tok
+	return tok, nil
+}
+
+// ClientCredentialsConfig configures an OAuth2 client-credentials grant.
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// Audience is sent as the "audience" parameter when set, as required by
+	// some identity providers.
+	Audience string
+
+	// HTTPClient is used to call the token endpoint. Defaults to a client
+	// with a 10-second timeout.
+	HTTPClient *http.Client
+}
+
+// tokenResponse is the token endpoint response body (RFC 6749 §5.1).
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// ClientCredentials returns a cached, auto-refreshing TokenSource that
+// obtains tokens using the OAuth2 client-credentials grant.
+//
+// Example:
+//
+//	ts := httpclient.ClientCredentials(&httpclient.ClientCredentialsConfig{
+//	    TokenURL:     "https://auth.internal/oauth/token",
+//	    ClientID:     os.Getenv("CLIENT_ID"),
+//	    ClientSecret: os.Getenv("CLIENT_SECRET"),
+//	})
+func ClientCredentials(cfg *ClientCredentialsConfig) TokenSource {
+	return CachingTokenSource(TokenSourceFunc(cfg.fetch), 0)
+}
+
+func (cfg *ClientCredentialsConfig) fetch(ctx context.Context) (*Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+	if cfg.Audience != "" {
+		form.Set("audience", cfg.Audience)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var body tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if body.AccessToken == "" {
+		return nil, fmt.Errorf("token response missing access_token")
+	}
+
+	tok := &Token{Value: body.AccessToken, Type: body.TokenType}
+	if body.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
@@ -0,0 +1,123 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// --- Token.Valid() tests ---
+
+func TestTokenValid(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		tok  *Token
+		want bool
+	}{
+		{"nil", nil, false},
+		{"empty value", &Token{}, false},
+		{"no expiry", &Token{Value: "x"}, true},
+		{"fresh", &Token{Value: "x", Expiry: now.Add(time.Hour)}, true},
+		{"inside leeway", &Token{Value: "x", Expiry: now.Add(10 * time.Second)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tok.Valid(now, 30*time.Second); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// --- CachingTokenSource() tests ---
+
+func TestCachingTokenSource_ReusesUntilExpiry(t *testing.T) {
+	calls := 0
+	src := TokenSourceFunc(func(context.Context) (*Token, error) {
+		calls++
+		return &Token{Value: "t", Expiry: time.Now().Add(time.Minute)}, nil
+	})
+
+	ts := CachingTokenSource(src, 10*time.Second).(*cachingTokenSource)
+	for i := 0; i < 3; i++ {
+		if _, err := ts.Token(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 fetch, got %d", calls)
+	}
+
+	ts.now = func() time.Time { return time.Now().Add(55 * time.Second) }
+	_, _ = ts.Token(context.Background())
+	if calls != 2 {
+		t.Errorf("expected refresh near expiry, got %d fetches", calls)
+	}
+}
+
+func TestCachingTokenSource_PropagatesError(t *testing.T) {
+	ts := CachingTokenSource(TokenSourceFunc(func(context.Context) (*Token, error) {
+		return nil, errors.New("boom")
+	}), 0)
+	if _, err := ts.Token(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// --- ClientCredentials() tests ---
+
+func TestClientCredentials_FetchesAndCaches(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "id" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = r.ParseForm()
+		if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("scope") != "read write" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"abc","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	ts := ClientCredentials(&ClientCredentialsConfig{
+		TokenURL:     srv.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+
+	for i := 0; i < 2; i++ {
+		tok, err := ts.Token(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tok.Value != "abc" || tok.Expiry.IsZero() {
+			t.Fatalf("unexpected token: %+v", tok)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("expected token endpoint to be called once, got %d", hits)
+	}
+}
+
+func TestClientCredentials_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	ts := ClientCredentials(&ClientCredentialsConfig{TokenURL: srv.URL})
+	if _, err := ts.Token(context.Background()); err == nil {
+		t.Fatal("expected error for non-200 token response")
+	}
+}
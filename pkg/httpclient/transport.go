@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transport is an http.RoundTripper that authenticates requests according
+// to the destination host. Requests to hosts without a binding are sent
+// unmodified, so credentials never leak to unexpected destinations.
+type Transport struct {
+	// Base is the underlying RoundTripper. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+
+	mu    sync.RWMutex
+	hosts map[string]Authenticator
+}
+
+// NewTransport returns a Transport wrapping base.
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base, hosts: make(map[string]Authenticator)}
+}
+
+// Bind associates an Authenticator with a host. The host is matched against
+// the request URL's hostname (without port); a leading "*." matches any
+// subdomain (but not the parent domain itself). An exact binding wins over
+// wildcards, and the most specific (longest) matching wildcard wins over the
+// others. Bind panics if host contains "*" anywhere else.
+//
+// Example:
+//
+//	t := httpclient.NewTransport(nil)
+//	t.Bind("billing.internal", httpclient.BearerAuth(ts))
+//	t.Bind("*.execute-api.us-east-1.amazonaws.com", httpclient.SigV4Auth(creds, "execute-api", "us-east-1"))
+//	client := t.Client(10 * time.Second)
+func (t *Transport) Bind(host string, auth Authenticator) {
+	host = strings.ToLower(host)
+	if strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+		panic(fmt.Sprintf("httpclient: invalid host pattern %q", host))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.hosts == nil {
+		t.hosts = make(map[string]Authenticator)
+	}
+	t.hosts[host] = auth
+}
+
+// Client returns an *http.Client using this Transport.
+func (t *Transport) Client(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: t, Timeout: timeout}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	auth := t.lookup(req.URL.Hostname())
+	if auth == nil {
+		return base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the caller's request.
+	clone := req.Clone(req.Context())
+	if err := auth.Authenticate(clone); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	return base.RoundTrip(clone)
+}
+
+func (t *Transport) lookup(host string) Authenticator {
+	host = strings.ToLower(host)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if auth, ok := t.hosts[host]; ok {
+		return auth
+	}
+	var best Authenticator
+	bestLen := -1
+	for pattern, auth := range t.hosts {
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok && strings.HasSuffix(host, "."+suffix) && len(suffix) > bestLen {
+			best, bestLen = auth, len(suffix)
+		}
+	}
+	return best
+}
@@ -0,0 +1,135 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// roundTripFunc records requests instead of sending them.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func recordingBase(seen *[]*http.Request) http.RoundTripper {
+	return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		*seen = append(*seen, r)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+	})
+}
+
+func TestTransport_PerHostBinding(t *testing.T) {
+	var seen []*http.Request
+	tr := NewTransport(recordingBase(&seen))
+	tr.Bind("billing.internal", APIKeyAuth("X-API-Key", "billing"))
+	tr.Bind("*.svc.internal", BearerAuth(StaticToken("wild", "")))
+
+	client := tr.Client(time.Second)
+	for _, u := range []string{
+		"http://billing.internal:8080/a",
+		"http://users.svc.internal/b",
+		"http://public.example.com/c",
+	} {
+		resp, err := client.Get(u)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if got := seen[0].Header.Get("X-API-Key"); got != "billing" {
+		t.Errorf("expected billing API key, got %q", got)
+	}
+	if got := seen[1].Header.Get("Authorization"); got != "Bearer wild" {
+		t.Errorf("expected wildcard bearer token, got %q", got)
+	}
+	if seen[2].Header.Get("Authorization") != "" || seen[2].Header.Get("X-API-Key") != "" {
+		t.Error("expected unbound host to receive no credentials")
+	}
+}
+
+// apiKeyOf returns the X-API-Key header auth sets.
+func apiKeyOf(t *testing.T, auth Authenticator) string {
+	t.Helper()
+	if auth == nil {
+		t.Fatal("expected a binding")
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	if err := auth.Authenticate(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return req.Header.Get("X-API-Key")
+}
+
+func TestTransport_LongestWildcardWins(t *testing.T) {
+	tr := NewTransport(nil)
+	tr.Bind("*.internal", APIKeyAuth("X-API-Key", "broad"))
+	tr.Bind("*.billing.internal", APIKeyAuth("X-API-Key", "narrow"))
+	tr.Bind("*.svc.billing.internal", APIKeyAuth("X-API-Key", "svc"))
+
+	// Map iteration order varies, so look up repeatedly
+	for i := 0; i < 50; i++ {
+		if got := apiKeyOf(t, tr.lookup("api.billing.internal")); got != "narrow" {
+			t.Fatalf("expected the most specific wildcard, got %q", got)
+		}
+	}
+	if got := apiKeyOf(t, tr.lookup("users.internal")); got != "broad" {
+		t.Errorf("expected the broad wildcard, got %q", got)
+	}
+}
+
+func TestTransport_WildcardMatchesWholeLabels(t *testing.T) {
+	tr := NewTransport(nil)
+	tr.Bind("*.example.com", APIKeyAuth("X-API-Key", "k"))
+
+	if tr.lookup("api.example.com") == nil {
+		t.Error("expected a subdomain to match")
+	}
+	for _, host := range []string{"evilexample.com", "example.com", "example.com.evil"} {
+		if tr.lookup(host) != nil {
+			t.Errorf("expected %q not to match", host)
+		}
+	}
+}
+
+func TestTransport_BindRejectsInvalidPatterns(t *testing.T) {
+	for _, pattern := range []string{"*example.com", "api.*.example.com", "*"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected Bind(%q) to panic", pattern)
+				}
+			}()
+			NewTransport(nil).Bind(pattern, APIKeyAuth("X-API-Key", "k"))
+		}()
+	}
+}
+
+func TestTransport_DoesNotMutateOriginalRequest(t *testing.T) {
+	var seen []*http.Request
+	tr := NewTransport(recordingBase(&seen))
+	tr.Bind("svc.internal", APIKeyAuth("X-API-Key", "k"))
+
+	req := httptest.NewRequest("GET", "http://svc.internal/", nil)
+	_, _ = tr.RoundTrip(req)
+
+	if req.Header.Get("X-API-Key") != "" {
+		t.Error("expected original request headers to be untouched")
+	}
+}
+
+func TestTransport_AuthError(t *testing.T) {
+	var seen []*http.Request
+	tr := NewTransport(recordingBase(&seen))
+	tr.Bind("svc.internal", AuthenticatorFunc(func(*http.Request) error { return errors.New("denied") }))
+
+	req := httptest.NewRequest("GET", "http://svc.internal/", nil)
+	if _, err := tr.RoundTrip(req); err == nil {
+		t.Fatal("expected authentication error")
+	}
+	if len(seen) != 0 {
+		t.Error("expected request not to be sent")
+	}
+}
@@ -0,0 +1,49 @@
+// Package awsconfig loads the AWS configuration shared by the AWS messaging
+// clients, applying their region, profile and credential overrides.
+package awsconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Options are the overrides applied on top of the default configuration.
+// Zero-valued fields are left to the SDK's default resolution.
+type Options struct {
+	Region          string
+	Profile         string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	RoleARN         string
+}
+
+// Load loads the default AWS configuration with the overrides of o.
+func Load(ctx context.Context, o Options) (aws.Config, error) {
+	opts := []func(*config.LoadOptions) error{config.WithRegion(o.Region)}
+	if o.Profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(o.Profile))
+	}
+	if o.AccessKeyID != "" || o.SecretAccessKey != "" {
+		if o.AccessKeyID == "" || o.SecretAccessKey == "" {
+			return aws.Config{}, fmt.Errorf("static credentials require both an access key ID and a secret access key")
+		}
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(o.AccessKeyID, o.SecretAccessKey, o.SessionToken)))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if o.RoleARN != "" {
+		awsCfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(awsCfg), o.RoleARN))
+	}
+	return awsCfg, nil
+}
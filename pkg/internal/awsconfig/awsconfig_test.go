@@ -0,0 +1,26 @@
+package awsconfig
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLoad_StaticCredentials(t *testing.T) {
+	cfg, err := Load(context.Background(), Options{Region: "eu-west-1", AccessKeyID: "id", SecretAccessKey: "secret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Region != "eu-west-1" {
+		t.Errorf("unexpected region %q", cfg.Region)
+	}
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil || creds.AccessKeyID != "id" {
+		t.Errorf("expected the static credentials, got %+v, %v", creds, err)
+	}
+}
+
+func TestLoad_IncompleteCredentials(t *testing.T) {
+	if _, err := Load(context.Background(), Options{Region: "eu-west-1", AccessKeyID: "id"}); err == nil {
+		t.Fatal("expected an error for a missing secret access key")
+	}
+}
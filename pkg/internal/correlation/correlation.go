@@ -0,0 +1,57 @@
+// Package correlation adds the request correlation IDs of a context to the
+// headers or attributes of outgoing messages. The names are shared by every
+// transport, so a message can be traced across them.
+package correlation
+
+import (
+	"context"
+
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+)
+
+// Names of the correlation headers.
+const (
+	RequestID   = "request_id"
+	TraceID     = "trace_id"
+	SpanID      = "span_id"
+	TraceParent = "traceparent"
+	TraceState  = "tracestate"
+)
+
+// Merge returns the correlation IDs from ctx overridden by headers. It
+// always returns a new, non-nil map.
+func Merge(ctx context.Context, headers map[string]string) map[string]string {
+	merged := make(map[string]string, len(headers)+5)
+	md := reqctx.RequestMetadataFromContext(ctx)
+	for k, v := range map[string]string{
+		RequestID:   md.RequestID,
+		TraceID:     md.TraceID,
+		SpanID:      md.SpanID,
+		TraceParent: md.TraceParent,
+		TraceState:  md.TraceState,
+	} {
+		if v != "" {
+			merged[k] = v
+		}
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Context returns parent carrying the correlation IDs found in headers, or
+// parent itself when there are none.
+func Context(parent context.Context, headers map[string]string) context.Context {
+	md := reqctx.RequestMetadata{
+		RequestID:   headers[RequestID],
+		TraceID:     headers[TraceID],
+		SpanID:      headers[SpanID],
+		TraceParent: headers[TraceParent],
+		TraceState:  headers[TraceState],
+	}
+	if md.IsZero() {
+		return parent
+	}
+	return reqctx.WithRequestMetadata(parent, md)
+}
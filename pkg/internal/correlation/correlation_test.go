@@ -0,0 +1,42 @@
+package correlation
+
+import (
+	"context"
+	"testing"
+
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+)
+
+func TestMerge(t *testing.T) {
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1", TraceID: "trace-1"})
+	got := Merge(ctx, map[string]string{TraceID: "explicit", "tenant": "acme"})
+
+	want := map[string]string{RequestID: "req-1", TraceID: "explicit", "tenant": "acme"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("got %s=%q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestMerge_Empty(t *testing.T) {
+	if got := Merge(context.Background(), nil); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty map, got %v", got)
+	}
+}
+
+func TestContext(t *testing.T) {
+	parent := context.Background()
+	if got := Context(parent, map[string]string{"tenant": "acme"}); got != parent {
+		t.Error("expected the parent without correlation IDs")
+	}
+
+	ctx := Context(parent, map[string]string{RequestID: "req-1", TraceParent: "00-abc-def-01"})
+	md := reqctx.RequestMetadataFromContext(ctx)
+	if md.RequestID != "req-1" || md.TraceParent != "00-abc-def-01" {
+		t.Errorf("unexpected metadata %+v", md)
+	}
+}
@@ -0,0 +1,52 @@
+package env
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	if d, err := Duration("ENV_TEST_UNSET"); err != nil || d != 0 {
+		t.Errorf("expected 0 for an unset variable, got %v, %v", d, err)
+	}
+	t.Setenv("ENV_TEST_DURATION", "1.5s")
+	if d, err := Duration("ENV_TEST_DURATION"); err != nil || d != 1500*time.Millisecond {
+		t.Errorf("unexpected result %v, %v", d, err)
+	}
+	t.Setenv("ENV_TEST_DURATION", "soon")
+	if _, err := Duration("ENV_TEST_DURATION"); err == nil || !strings.Contains(err.Error(), "ENV_TEST_DURATION") {
+		t.Errorf("expected an error naming the variable, got %v", err)
+	}
+}
+
+func TestInt(t *testing.T) {
+	t.Setenv("ENV_TEST_INT", "42")
+	if n, err := Int("ENV_TEST_INT"); err != nil || n != 42 {
+		t.Errorf("unexpected result %v, %v", n, err)
+	}
+	if n, err := Int64("ENV_TEST_INT"); err != nil || n != 42 {
+		t.Errorf("unexpected result %v, %v", n, err)
+	}
+	t.Setenv("ENV_TEST_INT", "many")
+	if _, err := Int("ENV_TEST_INT"); err == nil {
+		t.Error("expected an error")
+	}
+	if _, err := Int64("ENV_TEST_INT"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestBool(t *testing.T) {
+	if b, err := Bool("ENV_TEST_UNSET"); err != nil || b {
+		t.Errorf("expected false for an unset variable, got %v, %v", b, err)
+	}
+	t.Setenv("ENV_TEST_BOOL", "true")
+	if b, err := Bool("ENV_TEST_BOOL"); err != nil || !b {
+		t.Errorf("unexpected result %v, %v", b, err)
+	}
+	t.Setenv("ENV_TEST_BOOL", "maybe")
+	if _, err := Bool("ENV_TEST_BOOL"); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,23 @@
+// Package etag compares entity tags for conditional requests. It is shared
+// by the response cache and the file downloads of the response package.
+package etag
+
+import "strings"
+
+// Matches reports whether an If-None-Match header value matches tag,
+// using the weak comparison required for GET requests.
+func Matches(header, tag string) bool {
+	if header == "" || tag == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	want := strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
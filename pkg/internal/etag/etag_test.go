@@ -0,0 +1,23 @@
+package etag
+
+import "testing"
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		header, tag string
+		want        bool
+	}{
+		{`"abc"`, `"abc"`, true},
+		{`W/"abc"`, `"abc"`, true},
+		{`"x", "abc"`, `"abc"`, true},
+		{`*`, `"abc"`, true},
+		{`"x"`, `"abc"`, false},
+		{``, `"abc"`, false},
+		{`"abc"`, ``, false},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.header, tt.tag); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.header, tt.tag, got, tt.want)
+		}
+	}
+}
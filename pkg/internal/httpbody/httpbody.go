@@ -0,0 +1,72 @@
+// Package httpbody captures the start of HTTP request bodies without
+// consuming them, and redacts captured bodies for logging. It is shared by
+// the body logging of the logger middleware and the request dumps of the
+// recovery middleware.
+package httpbody
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ranorsolutions/http-common-go/pkg/log/formatter"
+)
+
+// ReadCloser joins the reader of a restored body with the original closer.
+type ReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Capture reads up to limit+1 bytes of the request body and puts them back
+// in front of the rest, so the handler sees the body unchanged. A result
+// longer than limit means the body was cut. It returns nil for requests
+// without a body.
+func Capture(req *http.Request, limit int) ([]byte, error) {
+	body := req.Body
+	if body == nil || body == http.NoBody {
+		return nil, nil
+	}
+	head, err := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	req.Body = ReadCloser{Reader: io.MultiReader(bytes.NewReader(head), body), Closer: body}
+	return head, err
+}
+
+// jsonStringPair matches "key": "value" pairs, for masking truncated JSON
+// that cannot be decoded.
+var jsonStringPair = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"(\s*:\s*)"((?:[^"\\]|\\.)*)"?`)
+
+// Redact returns body as a string with the sensitive values of r masked:
+// form and JSON bodies by key, JSON bodies cut by truncation by their
+// string pairs, and other bodies by the patterns of r.
+func Redact(r *formatter.Redactor, body []byte, contentType string, truncated bool) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		// Pairs that fail to decode, such as one cut at the limit, are dropped
+		values, _ := url.ParseQuery(string(body))
+		return url.Values(r.Value("", map[string][]string(values)).(map[string][]string)).Encode()
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var doc interface{}
+		if !truncated && json.Unmarshal(body, &doc) == nil {
+			if out, err := json.Marshal(r.Value("", doc)); err == nil {
+				return string(out)
+			}
+		}
+		masked := jsonStringPair.ReplaceAllStringFunc(string(body), func(pair string) string {
+			m := jsonStringPair.FindStringSubmatch(pair)
+			if !r.IsSensitive(m[1]) {
+				return pair
+			}
+			return `"` + m[1] + `"` + m[2] + `"` + r.Value(m[1], m[3]).(string) + `"`
+		})
+		return r.String(masked)
+	}
+	return r.String(string(body))
+}
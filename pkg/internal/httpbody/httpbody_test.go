@@ -0,0 +1,50 @@
+package httpbody
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ranorsolutions/http-common-go/pkg/log/formatter"
+)
+
+func TestCapture(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789"))
+	head, err := Capture(req, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(head) != "01234" {
+		t.Errorf("expected limit+1 bytes, got %q", head)
+	}
+	if rest, _ := io.ReadAll(req.Body); string(rest) != "0123456789" {
+		t.Errorf("expected the body restored, got %q", rest)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	if head, err := Capture(req, 4); head != nil || err != nil {
+		t.Errorf("expected nothing for a request without a body, got %q %v", head, err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	r := formatter.NewRedactor()
+	cases := []struct {
+		name, body, contentType string
+		truncated               bool
+		want                    string
+	}{
+		{"json", `{"password":"p","item":"book"}`, "application/json", false, `{"item":"book","password":"[REDACTED]"}`},
+		{"truncated json", `{"password":"hunter2","note":"xx`, "application/json; charset=utf-8", true, `{"password":"[REDACTED]","note":"xx`},
+		{"problem json", `{"token":"t"}`, "application/problem+json", false, `{"token":"[REDACTED]"}`},
+		{"form", "user=ana&password=p", "application/x-www-form-urlencoded", false, "password=%5BREDACTED%5D&user=ana"},
+		{"text", "password=p", "text/plain", false, "password=p"},
+	}
+	for _, tc := range cases {
+		if got := Redact(r, []byte(tc.body), tc.contentType, tc.truncated); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
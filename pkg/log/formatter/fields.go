@@ -0,0 +1,67 @@
+package formatter
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fieldKeys returns the keys of data to print: those allowed by
+// IncludeFields and ExcludeFields, the ones of FieldOrder first.
+func (f *Formatter) fieldKeys(data logrus.Fields) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		if len(f.IncludeFields) > 0 && !slices.Contains(f.IncludeFields, k) {
+			continue
+		}
+		if slices.Contains(f.ExcludeFields, k) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+
+	// Sort the keys by name
+	if !f.DisableSorting {
+		sort.Strings(keys)
+	}
+	if len(f.FieldOrder) == 0 {
+		return keys
+	}
+
+	ordered := make([]string, 0, len(keys))
+	for _, k := range f.FieldOrder {
+		if slices.Contains(keys, k) && !slices.Contains(ordered, k) {
+			ordered = append(ordered, k)
+		}
+	}
+	for _, k := range keys {
+		if !slices.Contains(f.FieldOrder, k) {
+			ordered = append(ordered, k)
+		}
+	}
+	return ordered
+}
+
+// truncate shortens the text of v to MaxValueLength characters. Values
+// within the limit are returned unchanged.
+func (f *Formatter) truncate(v interface{}) interface{} {
+	if f.MaxValueLength <= 0 {
+		return v
+	}
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if utf8.RuneCountInString(s) <= f.MaxValueLength {
+		return v
+	}
+	return string([]rune(s)[:f.MaxValueLength]) + "…"
+}
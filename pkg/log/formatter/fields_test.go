@@ -0,0 +1,63 @@
+package formatter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFormat_FieldOrder(t *testing.T) {
+	f := &Formatter{DisableTimestamp: true, FieldOrder: []string{"status", "missing", "a"}}
+	entry := newEntryWithFields(logrus.Fields{"c": 3, "a": 1, "status": 200, "b": 2})
+	entry.Message = "done"
+
+	b, _ := f.Format(entry)
+	if got, want := string(b), "level:info msg:done status:200 a:1 b:2 c:3\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestFormat_IncludeExcludeFields(t *testing.T) {
+	entry := newEntryWithFields(logrus.Fields{"a": 1, "agent": "curl", "b": 2})
+
+	f := &Formatter{DisableTimestamp: true, ExcludeFields: []string{"agent"}}
+	b, _ := f.Format(entry)
+	if got := string(b); strings.Contains(got, "agent") || !strings.Contains(got, "a:1 b:2") {
+		t.Errorf("expected agent excluded, got %q", got)
+	}
+
+	f = &Formatter{DisableTimestamp: true, IncludeFields: []string{"a", "agent"}, ExcludeFields: []string{"agent"}}
+	b, _ = f.Format(entry)
+	if got, want := string(b), "level:info a:1\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	// The formatted layout filters the same way
+	f = &Formatter{ForceFormatting: true, DisableTimestamp: true, IncludeFields: []string{"b"}}
+	b, _ = f.Format(entry)
+	if got := string(b); strings.Contains(got, "curl") || !strings.Contains(got, " 2 --") {
+		t.Errorf("expected only b in the formatted layout, got %q", got)
+	}
+}
+
+func TestFormat_MaxValueLength(t *testing.T) {
+	f := &Formatter{DisableTimestamp: true, MaxValueLength: 5}
+	entry := newEntryWithFields(logrus.Fields{
+		"body":  "0123456789",
+		"err":   errors.New("connection refused"),
+		"n":     1234567,
+		"short": "abc",
+		"utf8":  "ééééééé",
+	})
+	entry.Message = "a message longer than five characters"
+
+	b, _ := f.Format(entry)
+	got := string(b)
+	for _, want := range []string{`body:"01234…"`, `err:"conne…"`, `n:"12345…"`, "short:abc", `utf8:"ééééé…"`, `msg:"a message longer than five characters"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %s in %q", want, got)
+		}
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"io"
 	"os"
 	"regexp"
-	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -73,9 +72,12 @@ type compiledColorScheme struct {
 // Formatter implements logrus.Formatter interface.
 type Formatter struct {
 	// Set to true to bypass checking for a TTY before outputting colors.
+	// CLICOLOR_FORCE set to a value other than 0 has the same effect, and
+	// also forces the formatted layout.
 	ForceColors bool
 
-	// Force disabling colors. For a TTY colors are enabled by default.
+	// Force disabling colors. For a TTY colors are enabled by default,
+	// unless NO_COLOR is set.
 	DisableColors bool
 
 	// Force formatted layout, even for non-TTY output.
@@ -107,16 +109,53 @@ type Formatter struct {
 	// with something else. For example: ', or `.
 	QuoteCharacter string
 
+	// Quote every string and error value, not only those with characters
+	// other than letters, digits, '-' and '.'.
+	ForceQuote bool
+
+	// Separator between keys and values in the plain layout. Defaults to
+	// ":"; set "=" for key=value output.
+	KeyValueSeparator string
+
+	// Write logfmt (key=value pairs) that logfmt parsers such as Loki,
+	// Heroku or the Go logfmt package read as is: keys lose spaces, '=' and
+	// quotes, and values with spaces, '=', quotes or control characters are
+	// quoted with Go escapes. It implies the plain layout and overrides
+	// KeyValueSeparator and QuoteCharacter.
+	Logfmt bool
+
 	// Pad msg field with spaces on the right for display.
 	// The value for this parameter will be the size of padding.
 	// Its default value is zero, which means no padding will be applied for msg.
 	SpacePadding int
 
+	// Mask sensitive fields and message fragments. Nil disables redaction.
+	Redactor *Redactor
+
+	// Fields printed first, in this order, e.g. request_id and status. The
+	// other fields follow, sorted unless DisableSorting is set.
+	FieldOrder []string
+
+	// Print only these fields. Empty prints every field.
+	IncludeFields []string
+
+	// Never print these fields, e.g. noisy ones such as agent. It applies
+	// after IncludeFields.
+	ExcludeFields []string
+
+	// Truncate field values longer than this many characters, marking the
+	// cut with "…". Zero disables truncation.
+	MaxValueLength int
+
 	// Color scheme to use.
 	colorScheme *compiledColorScheme
 
-	// Whether the Formatter's out is to a terminal.
-	isTerminal bool
+	// Whether the outputs the Formatter wrote to are terminals, by
+	// *os.File.
+	terminals sync.Map
+
+	// Colors disabled by NO_COLOR, or forced by CLICOLOR_FORCE.
+	noColor, colorForced bool
 
 	sync.Once
 	// Available standard keys: time, msg, lvl
@@ -149,20 +188,33 @@ func compileColorScheme(s *ColorScheme) *compiledColorScheme {
 	}
 }
 
-// init -- Initialize the Formatter for terminals
-func (f *Formatter) init(entry *logrus.Entry) {
+// init -- Initialize the quoting character and the color conventions of
+// the environment
+func (f *Formatter) init() {
 	if len(f.QuoteCharacter) == 0 {
 		f.QuoteCharacter = "\""
 	}
-	if entry.Logger != nil {
-		f.isTerminal = f.checkIfTerminal(entry.Logger.Out)
+	// See https://no-color.org and https://bixense.com/clicolors
+	f.noColor = os.Getenv("NO_COLOR") != ""
+	if v := os.Getenv("CLICOLOR_FORCE"); v != "" && v != "0" {
+		f.colorForced = true
 	}
 }
 
+// checkIfTerminal -- Reports whether w is a terminal. Files are checked
+// once; writers such as writer.MultiWriter report it with an IsTerminal
+// method.
 func (f *Formatter) checkIfTerminal(w io.Writer) bool {
 	switch v := w.(type) {
 	case *os.File:
-		return terminal.IsTerminal(int(v.Fd()))
+		if cached, ok := f.terminals.Load(v); ok {
+			return cached.(bool)
+		}
+		isTerminal := terminal.IsTerminal(int(v.Fd()))
+		f.terminals.Store(v, isTerminal)
+		return isTerminal
+	case interface{ IsTerminal() bool }:
+		return v.IsTerminal()
 	default:
 		return false
 	}
@@ -175,25 +227,18 @@ func (f *Formatter) SetColorScheme(colorScheme *ColorScheme) {
 
 // Format -- Logrus Formatter, sets the output of the logs
 func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	// Mask sensitive values on a copy of the entry
+	entry = redactEntry(f.Redactor, entry)
+
 	// Create a byte buffer pointer to store the output
 	var b *bytes.Buffer
 
-	// Create an array of strings from the logrus keys
-	var keys []string = make([]string, 0, len(entry.Data))
-
-	// Map the logrus keys into the keys array
-	for k := range entry.Data {
-		keys = append(keys, k)
-	}
+	// Select and order the keys of the fields to print
+	keys := f.fieldKeys(entry.Data)
 
 	// Retrieve the last index to get the message
 	lastKeyIdx := len(keys) - 1
 
-	// Sort the keys by name
-	if !f.DisableSorting {
-		sort.Strings(keys)
-	}
-
 	// Either copy the existing bytes or create a new byte buffer
 	if entry.Buffer != nil {
 		b = entry.Buffer
@@ -205,10 +250,14 @@ func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
 	prefixFieldClashes(entry.Data)
 
 	// Initialize the Formatter
-	f.Do(func() { f.init(entry) })
+	f.Do(f.init)
+
+	// Check the output of this entry, as loggers sharing the Formatter may
+	// write to a terminal and a file
+	isTerminal := entry.Logger != nil && f.checkIfTerminal(entry.Logger.Out)
 
 	// Determine whether to apply formatting
-	isFormatted := f.ForceFormatting || f.isTerminal
+	isFormatted := (f.ForceFormatting || isTerminal || f.colorForced) && !f.Logfmt
 
 	// Set the format of the timestamp
 	timestampFormat := f.TimestampFormat
@@ -221,7 +270,7 @@ func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
 	// Detemine whether to format the output
 	if isFormatted {
 		// Check if the output should be colored
-		isColored := (f.ForceColors || f.isTerminal) && !f.DisableColors
+		isColored := (f.ForceColors || isTerminal || f.colorForced) && !f.DisableColors && !f.noColor
 
 		// Use the existing color scheme
 		var colorScheme *compiledColorScheme
@@ -256,7 +305,7 @@ func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
 
 		// Map over the remaining keys to log them
 		for i, key := range keys {
-			f.appendKeyValue(b, key, entry.Data[key], lastKeyIdx != i)
+			f.appendKeyValue(b, key, f.truncate(entry.Data[key]), lastKeyIdx != i)
 		}
 	}
 
@@ -352,7 +401,7 @@ func (f *Formatter) printColored(b *bytes.Buffer, entry *logrus.Entry, keys []st
 	for _, k := range keys {
 		if k != "prefix" && k != "service" && k != "version" {
 			v := entry.Data[k]
-			fmt.Fprintf(b, " %v", v)
+			fmt.Fprintf(b, " %v", f.truncate(v))
 		}
 	}
 
@@ -386,9 +435,19 @@ func extractPrefix(msg string) (string, string) {
 }
 
 func (f *Formatter) appendKeyValue(b *bytes.Buffer, key string, value interface{}, appendSpace bool) {
-	b.WriteString(key)
-	b.WriteByte(':')
-	f.appendValue(b, value)
+	if f.Logfmt {
+		b.WriteString(logfmtKey(key))
+		b.WriteByte('=')
+		appendLogfmtValue(b, value, f.ForceQuote)
+	} else {
+		b.WriteString(key)
+		if f.KeyValueSeparator != "" {
+			b.WriteString(f.KeyValueSeparator)
+		} else {
+			b.WriteByte(':')
+		}
+		f.appendValue(b, value)
+	}
 
 	if appendSpace {
 		b.WriteByte(' ')
@@ -398,14 +457,14 @@ func (f *Formatter) appendKeyValue(b *bytes.Buffer, key string, value interface{
 func (f *Formatter) appendValue(b *bytes.Buffer, value interface{}) {
 	switch value := value.(type) {
 	case string:
-		if !f.needsQuoting(value) {
+		if !f.ForceQuote && !f.needsQuoting(value) {
 			b.WriteString(value)
 		} else {
 			fmt.Fprintf(b, "%s%v%s", f.QuoteCharacter, value, f.QuoteCharacter)
 		}
 	case error:
 		errmsg := value.Error()
-		if !f.needsQuoting(errmsg) {
+		if !f.ForceQuote && !f.needsQuoting(errmsg) {
 			b.WriteString(errmsg)
 		} else {
 			fmt.Fprintf(b, "%s%v%s", f.QuoteCharacter, errmsg, f.QuoteCharacter)
@@ -3,6 +3,7 @@ package formatter
 import (
 	"bytes"
 	"errors"
+	"io"
 	"strings"
 	"testing"
 	"time"
@@ -246,3 +247,58 @@ func TestMiniTSIncreases(t *testing.T) {
 		t.Error("expected miniTS to increase over time")
 	}
 }
+
+// --- Terminal detection and color conventions ---
+
+// fakeTerminal is a writer reporting itself as a terminal.
+type fakeTerminal struct {
+	bytes.Buffer
+}
+
+func (*fakeTerminal) IsTerminal() bool { return true }
+
+func formatTo(f *Formatter, out io.Writer) string {
+	l := logrus.New()
+	l.Out = out
+	entry := logrus.NewEntry(l).WithField("k", "v")
+	entry.Level = logrus.InfoLevel
+	entry.Message = "hello"
+	b, _ := f.Format(entry)
+	return string(b)
+}
+
+func TestFormat_TerminalPerOutput(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "")
+	f := &Formatter{}
+
+	if got := formatTo(f, &fakeTerminal{}); !strings.Contains(got, "\x1b[") {
+		t.Errorf("expected colors for a terminal, got %q", got)
+	}
+	if got := formatTo(f, &bytes.Buffer{}); strings.Contains(got, "\x1b[") || !strings.Contains(got, "k:v") {
+		t.Errorf("expected the plain layout for a non-terminal sharing the formatter, got %q", got)
+	}
+}
+
+func TestFormat_NoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	t.Setenv("CLICOLOR_FORCE", "")
+
+	got := formatTo(&Formatter{ForceColors: true}, &fakeTerminal{})
+	if strings.Contains(got, "\x1b[") || !strings.Contains(got, "-- hello") {
+		t.Errorf("expected the formatted layout without colors, got %q", got)
+	}
+}
+
+func TestFormat_CliColorForce(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "1")
+	if got := formatTo(&Formatter{}, &bytes.Buffer{}); !strings.Contains(got, "\x1b[") {
+		t.Errorf("expected colors with CLICOLOR_FORCE, got %q", got)
+	}
+
+	t.Setenv("CLICOLOR_FORCE", "0")
+	if got := formatTo(&Formatter{}, &bytes.Buffer{}); strings.Contains(got, "\x1b[") {
+		t.Errorf("expected CLICOLOR_FORCE=0 to be ignored, got %q", got)
+	}
+}
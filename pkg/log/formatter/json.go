@@ -0,0 +1,92 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// JSONFormatter implements logrus.Formatter, writing each entry as a single
+// JSON object followed by a newline. The object holds time, level and msg
+// next to every entry field (service, version, trace_id, ...) at the top
+// level, which log pipelines such as Loki or CloudWatch parse natively.
+//
+// Example output:
+//
+//	{"level":"info","msg":"request completed","service":"user-service","status":200,"time":"2024-11-10T12:00:00Z","trace_id":"4bf9...","version":"1.0.0"}
+type JSONFormatter struct {
+	// Timestamp format to use for the time key. Defaults to RFC3339.
+	TimestampFormat string
+
+	// Disable the time key, for collectors that add their own timestamp.
+	DisableTimestamp bool
+
+	// Indent the output, for local debugging only.
+	PrettyPrint bool
+
+	// Mask sensitive fields and message fragments. Nil disables redaction.
+	Redactor *Redactor
+
+	// Rename fields to the schema of a log backend, e.g. ECSPreset. Nil
+	// keeps the time, level and msg keys and the field names as logged.
+	Preset *FieldPreset
+}
+
+// Format -- Logrus Formatter, renders the entry as a JSON line
+func (f *JSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	entry = redactEntry(f.Redactor, entry)
+
+	data := make(logrus.Fields, len(entry.Data)+3)
+	for k, v := range entry.Data {
+		// Errors have no exported fields and would marshal as {}
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		data[k] = v
+	}
+
+	timeKey, levelKey, msgKey := "time", "level", "msg"
+	level := entry.Level.String()
+	timestampFormat := f.TimestampFormat
+	if p := f.Preset; p != nil {
+		data = p.apply(data)
+		timeKey, levelKey, msgKey = p.TimeKey, p.LevelKey, p.MessageKey
+		level = p.level(entry.Level)
+		if timestampFormat == "" {
+			timestampFormat = p.TimestampFormat
+		}
+	} else {
+		// Ensure we dont overwrite important keys
+		prefixFieldClashes(data)
+	}
+
+	if !f.DisableTimestamp {
+		if timestampFormat == "" {
+			timestampFormat = defaultTimestampFormat
+		}
+		data[timeKey] = entry.Time.Format(timestampFormat)
+	}
+	data[levelKey] = level
+	data[msgKey] = entry.Message
+
+	var b *bytes.Buffer
+	if entry.Buffer != nil {
+		b = entry.Buffer
+	} else {
+		b = &bytes.Buffer{}
+	}
+
+	encoder := json.NewEncoder(b)
+	encoder.SetEscapeHTML(false)
+	if f.PrettyPrint {
+		encoder.SetIndent("", "  ")
+	}
+
+	// Encode appends the newline terminating the entry
+	if err := encoder.Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON: %w", err)
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,82 @@
+package formatter
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestJSONFormatter(t *testing.T) {
+	f := &JSONFormatter{}
+	entry := newEntryWithFields(logrus.Fields{
+		"service":  "svc",
+		"version":  "v1",
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"status":   200,
+		"error":    errors.New("boom"),
+		"level":    "user level",
+	})
+	entry.Message = "request <completed>"
+
+	b, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(string(b), "}\n") || strings.Count(string(b), "\n") != 1 {
+		t.Fatalf("expected a single JSON line, got %q", b)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"time":         "2024-11-10T12:00:00Z",
+		"level":        "info",
+		"msg":          "request <completed>",
+		"service":      "svc",
+		"version":      "v1",
+		"trace_id":     "4bf92f3577b34da6a3ce929d0e0e4736",
+		"status":       float64(200),
+		"error":        "boom",
+		"fields.level": "user level",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+	if !strings.Contains(string(b), `"request <completed>"`) {
+		t.Errorf("expected HTML characters to be left unescaped, got %s", b)
+	}
+}
+
+func TestJSONFormatter_Options(t *testing.T) {
+	f := &JSONFormatter{DisableTimestamp: true, PrettyPrint: true}
+	entry := newEntryWithFields(logrus.Fields{})
+	entry.Message = "hi"
+
+	b, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(b), `"time"`) {
+		t.Errorf("expected no time key, got %s", b)
+	}
+	if !strings.Contains(string(b), "\n  \"msg\": \"hi\"") {
+		t.Errorf("expected indented output, got %s", b)
+	}
+}
+
+func TestJSONFormatter_UnsupportedValue(t *testing.T) {
+	f := &JSONFormatter{}
+	entry := newEntryWithFields(logrus.Fields{"ch": make(chan int)})
+
+	if _, err := f.Format(entry); err == nil {
+		t.Error("expected an error for a value JSON cannot encode")
+	}
+}
@@ -0,0 +1,57 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// logfmtKey returns key without the characters logfmt does not allow in
+// keys, replaced with '_'.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == unicode.ReplacementChar {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// appendLogfmtValue writes value as a logfmt value, quoted when needed or
+// when force is set for strings and errors.
+func appendLogfmtValue(b *bytes.Buffer, value interface{}, force bool) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	default:
+		force = false
+		s = fmt.Sprint(v)
+	}
+	if force || logfmtNeedsQuoting(s) {
+		b.WriteString(strconv.Quote(s))
+		return
+	}
+	b.WriteString(s)
+}
+
+// logfmtNeedsQuoting reports whether s must be quoted to parse as a single
+// logfmt value.
+func logfmtNeedsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package formatter
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFormat_Logfmt(t *testing.T) {
+	f := &Formatter{Logfmt: true, ForceFormatting: true}
+	entry := newEntryWithFields(logrus.Fields{
+		"empty":    "",
+		"err":      errors.New(`bad "input"`),
+		"list":     []string{"a", "b"},
+		"path":     "/users/42",
+		"query":    "a=1&b=2",
+		"status":   200,
+		"multi\nk": "line1\nline2",
+		"user_id":  "u_1",
+	})
+	entry.Message = "request completed"
+
+	b, err := f.Format(entry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `time=2024-11-10T12:00:00Z level=info msg="request completed" ` +
+		`empty="" err="bad \"input\"" list="[a b]" multi_k="line1\nline2" path=/users/42 query="a=1&b=2" status=200 user_id=u_1` + "\n"
+	if got := string(b); got != want {
+		t.Errorf("unexpected logfmt output\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestFormat_LogfmtForceQuote(t *testing.T) {
+	f := &Formatter{Logfmt: true, ForceQuote: true, DisableTimestamp: true}
+	entry := newEntryWithFields(logrus.Fields{"path": "/users", "status": 200})
+	entry.Message = "ok"
+
+	b, _ := f.Format(entry)
+	if got, want := string(b), `level="info" msg="ok" path="/users" status=200`+"\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestFormat_Separator(t *testing.T) {
+	f := &Formatter{KeyValueSeparator: "=", QuoteCharacter: "'", DisableTimestamp: true}
+	entry := newEntryWithFields(logrus.Fields{"k": "has space", "n": 1})
+	entry.Message = "hi"
+
+	b, _ := f.Format(entry)
+	if got, want := string(b), "level=info msg=hi k='has space' n=1\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	var buf bytes.Buffer
+	(&Formatter{ForceQuote: true, QuoteCharacter: `"`}).appendKeyValue(&buf, "k", "plain", false)
+	if got := buf.String(); got != `k:"plain"` {
+		t.Errorf("expected ForceQuote to quote plain values, got %q", got)
+	}
+}
+
+func TestLogfmtNeedsQuoting(t *testing.T) {
+	for s, want := range map[string]bool{
+		"plain":     false,
+		"u_1":       false,
+		"/a/b?c":    false,
+		"é":         false,
+		"":          true,
+		"a b":       true,
+		"a=b":       true,
+		`a"b`:       true,
+		`a\b`:       true,
+		"tab\there": true,
+	} {
+		if got := logfmtNeedsQuoting(s); got != want {
+			t.Errorf("logfmtNeedsQuoting(%q) = %v, want %v", s, got, want)
+		}
+	}
+	if got := logfmtKey("a b=c\"d"); got != "a_b_c_d" {
+		t.Errorf("unexpected logfmt key %q", got)
+	}
+}
@@ -0,0 +1,284 @@
+package formatter
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FieldPreset maps the standard fields of entries (time, level, msg,
+// trace_id, the request fields of the logger middleware, ...) onto the
+// schema of a log backend, so that backend correlates logs with traces and
+// parses HTTP fields without custom pipelines. Set it as
+// JSONFormatter.Preset.
+//
+// ECSPreset, DatadogPreset and GCPPreset cover the common backends; a
+// custom preset can be declared the same way.
+type FieldPreset struct {
+	// Name of the preset, e.g. "ecs".
+	Name string
+
+	// Keys of the timestamp, level and message.
+	TimeKey, LevelKey, MessageKey string
+
+	// TimestampFormat used unless JSONFormatter.TimestampFormat is set.
+	TimestampFormat string
+
+	// Level renders the level value. Nil uses the Logrus name, e.g. "warning".
+	Level func(logrus.Level) string
+
+	// Fields renames entry fields; fields not listed keep their name.
+	Fields map[string]string
+
+	// Static fields added to every entry, e.g. the schema version.
+	Static map[string]interface{}
+
+	// Transform adjusts the renamed fields before they are encoded, e.g. to
+	// convert IDs or nest objects. It must not retain data.
+	Transform func(data logrus.Fields)
+}
+
+// ECSPreset follows the Elastic Common Schema 8.x: @timestamp, log.level,
+// message, trace.id, span.id, service.*, http.*, url.path and client.ip.
+var ECSPreset = &FieldPreset{
+	Name:            "ecs",
+	TimeKey:         "@timestamp",
+	LevelKey:        "log.level",
+	MessageKey:      "message",
+	TimestampFormat: "2006-01-02T15:04:05.000Z07:00",
+	Fields: map[string]string{
+		"service":       "service.name",
+		"version":       "service.version",
+		"tenant":        "organization.id",
+		"trace_id":      "trace.id",
+		"span_id":       "span.id",
+		"request_id":    "http.request.id",
+		"method":        "http.request.method",
+		"status":        "http.response.status_code",
+		"size":          "http.request.body.bytes",
+		"request_size":  "http.request.body.bytes",
+		"response_size": "http.response.body.bytes",
+		"path":          "url.path",
+		"resource":      "url.path",
+		"clientIP":      "client.ip",
+		"origin":        "source.address",
+		"agent":         "user_agent.original",
+		"latency":       "event.duration",
+		"error":         "error.message",
+		"error_stack":   "error.stack_trace",
+	},
+	Static: map[string]interface{}{"ecs.version": "8.11.0"},
+	Transform: func(data logrus.Fields) {
+		durationNanos(data, "event.duration")
+		joinLines(data, "error.stack_trace")
+	},
+}
+
+// DatadogPreset follows the Datadog reserved and standard attributes:
+// status, message, service, dd.trace_id and dd.span_id (converted to the
+// decimal 64-bit form Datadog correlates on), http.*, network.client.ip and
+// duration.
+var DatadogPreset = &FieldPreset{
+	Name:            "datadog",
+	TimeKey:         "timestamp",
+	LevelKey:        "status",
+	MessageKey:      "message",
+	TimestampFormat: "2006-01-02T15:04:05.000Z07:00",
+	Level:           syslogLevel,
+	Fields: map[string]string{
+		"trace_id":      "dd.trace_id",
+		"span_id":       "dd.span_id",
+		"request_id":    "http.request_id",
+		"method":        "http.method",
+		"status":        "http.status_code",
+		"path":          "http.url_details.path",
+		"resource":      "http.url_details.path",
+		"clientIP":      "network.client.ip",
+		"origin":        "network.client.ip",
+		"agent":         "http.useragent",
+		"request_size":  "network.bytes_read",
+		"response_size": "network.bytes_written",
+		"latency":       "duration",
+		"error":         "error.message",
+		"error_stack":   "error.stack",
+	},
+	Transform: func(data logrus.Fields) {
+		datadogID(data, "dd.trace_id")
+		datadogID(data, "dd.span_id")
+		durationNanos(data, "duration")
+		joinLines(data, "error.stack")
+	},
+}
+
+// GCPPreset follows the Google Cloud Logging structured log format:
+// severity, message, the logging.googleapis.com/trace and spanId fields,
+// httpRequest and serviceContext. Trace IDs are qualified with projectID,
+// as Cloud Trace requires; an empty projectID leaves them bare.
+func GCPPreset(projectID string) *FieldPreset {
+	return &FieldPreset{
+		Name:            "gcp",
+		TimeKey:         "timestamp",
+		LevelKey:        "severity",
+		MessageKey:      "message",
+		TimestampFormat: time.RFC3339Nano,
+		Level:           gcpSeverity,
+		Fields: map[string]string{
+			"service":       "serviceContext.service",
+			"version":       "serviceContext.version",
+			"trace_id":      "logging.googleapis.com/trace",
+			"span_id":       "logging.googleapis.com/spanId",
+			"method":        "httpRequest.requestMethod",
+			"status":        "httpRequest.status",
+			"path":          "httpRequest.requestUrl",
+			"resource":      "httpRequest.requestUrl",
+			"clientIP":      "httpRequest.remoteIp",
+			"agent":         "httpRequest.userAgent",
+			"size":          "httpRequest.requestSize",
+			"request_size":  "httpRequest.requestSize",
+			"response_size": "httpRequest.responseSize",
+			"latency":       "httpRequest.latency",
+			"error_stack":   "stack_trace",
+		},
+		Transform: func(data logrus.Fields) {
+			if id, ok := data["logging.googleapis.com/trace"].(string); ok && projectID != "" {
+				data["logging.googleapis.com/trace"] = "projects/" + projectID + "/traces/" + id
+			}
+			if v, ok := data["httpRequest.latency"].(string); ok {
+				if d, err := time.ParseDuration(v); err == nil {
+					data["httpRequest.latency"] = strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+				}
+			}
+			joinLines(data, "stack_trace")
+			nest(data, "httpRequest")
+			nest(data, "serviceContext")
+		},
+	}
+}
+
+// apply returns data with fields renamed and the preset's static fields and
+// transform applied. A field whose new name is already taken, e.g. a second
+// field mapped to url.path, keeps its name. Fields clashing with the time,
+// level or message key are prefixed with "fields.".
+func (p *FieldPreset) apply(data logrus.Fields) logrus.Fields {
+	out := make(logrus.Fields, len(data)+len(p.Static)+3)
+	for k, v := range p.Static {
+		out[k] = v
+	}
+	var renamed []string
+	for k, v := range data {
+		if _, ok := p.Fields[k]; ok {
+			renamed = append(renamed, k)
+			continue
+		}
+		out[k] = v
+	}
+	sort.Strings(renamed)
+	for _, k := range renamed {
+		name := p.Fields[k]
+		if _, taken := out[name]; taken {
+			name = k
+		}
+		out[name] = data[k]
+	}
+
+	if p.Transform != nil {
+		p.Transform(out)
+	}
+	for _, key := range []string{p.TimeKey, p.LevelKey, p.MessageKey} {
+		if v, ok := out[key]; ok {
+			out["fields."+key] = v
+			delete(out, key)
+		}
+	}
+	return out
+}
+
+// level renders l.
+func (p *FieldPreset) level(l logrus.Level) string {
+	if p.Level != nil {
+		return p.Level(l)
+	}
+	return l.String()
+}
+
+// durationNanos converts the duration string at key, e.g. "1.5ms", to
+// nanoseconds.
+func durationNanos(data logrus.Fields, key string) {
+	if v, ok := data[key].(string); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			data[key] = d.Nanoseconds()
+		}
+	}
+}
+
+// joinLines converts the string slice at key to one newline-separated
+// string.
+func joinLines(data logrus.Fields, key string) {
+	if v, ok := data[key].([]string); ok {
+		data[key] = strings.Join(v, "\n")
+	}
+}
+
+// datadogID converts the hex W3C trace or span ID at key to the decimal
+// form of its lower 64 bits.
+func datadogID(data logrus.Fields, key string) {
+	id, ok := data[key].(string)
+	if !ok || id == "" {
+		return
+	}
+	if len(id) > 16 {
+		id = id[len(id)-16:]
+	}
+	if n, err := strconv.ParseUint(id, 16, 64); err == nil {
+		data[key] = strconv.FormatUint(n, 10)
+	}
+}
+
+// nest moves the fields named prefix.<name> into an object at prefix.
+func nest(data logrus.Fields, prefix string) {
+	obj := map[string]interface{}{}
+	for k, v := range data {
+		if name, ok := strings.CutPrefix(k, prefix+"."); ok {
+			obj[name] = v
+			delete(data, k)
+		}
+	}
+	if len(obj) > 0 {
+		data[prefix] = obj
+	}
+}
+
+// syslogLevel renders l with the syslog severity names Datadog recognizes.
+func syslogLevel(l logrus.Level) string {
+	switch l {
+	case logrus.PanicLevel:
+		return "emergency"
+	case logrus.FatalLevel:
+		return "critical"
+	case logrus.TraceLevel:
+		return "debug"
+	default:
+		return l.String()
+	}
+}
+
+// gcpSeverity renders l as a Cloud Logging LogSeverity.
+func gcpSeverity(l logrus.Level) string {
+	switch l {
+	case logrus.PanicLevel:
+		return "ALERT"
+	case logrus.FatalLevel:
+		return "CRITICAL"
+	case logrus.ErrorLevel:
+		return "ERROR"
+	case logrus.WarnLevel:
+		return "WARNING"
+	case logrus.InfoLevel:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
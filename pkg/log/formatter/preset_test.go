@@ -0,0 +1,156 @@
+package formatter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// requestFields are the fields of a completion log of the logger middleware.
+func requestFields() logrus.Fields {
+	return logrus.Fields{
+		"service":    "user-service",
+		"version":    "1.0.0",
+		"request_id": "req-1",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":    "00f067aa0ba902b7",
+		"method":     "GET",
+		"path":       "/users",
+		"status":     200,
+		"clientIP":   "10.0.0.1",
+		"latency":    "1.5ms",
+		"user_id":    "u1",
+
+		"request_size":  int64(64),
+		"response_size": 512,
+	}
+}
+
+// formatPreset formats an entry with fields through p and decodes it.
+func formatPreset(t *testing.T, p *FieldPreset, level logrus.Level, fields logrus.Fields) map[string]interface{} {
+	t.Helper()
+	entry := newEntryWithFields(fields)
+	entry.Level = level
+	entry.Message = "request completed"
+
+	b, err := (&JSONFormatter{Preset: p}).Format(entry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	return got
+}
+
+func assertFields(t *testing.T, got, want map[string]interface{}) {
+	t.Helper()
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v (%T), want %v", k, got[k], got[k], v)
+		}
+	}
+}
+
+func TestECSPreset(t *testing.T) {
+	fields := requestFields()
+	fields["error_stack"] = []string{"main.load (main.go:10)", "main.main (main.go:3)"}
+	got := formatPreset(t, ECSPreset, logrus.WarnLevel, fields)
+
+	assertFields(t, got, map[string]interface{}{
+		"@timestamp":                "2024-11-10T12:00:00.000Z",
+		"log.level":                 "warning",
+		"message":                   "request completed",
+		"ecs.version":               "8.11.0",
+		"service.name":              "user-service",
+		"service.version":           "1.0.0",
+		"trace.id":                  "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span.id":                   "00f067aa0ba902b7",
+		"http.request.id":           "req-1",
+		"http.request.method":       "GET",
+		"http.response.status_code": float64(200),
+		"url.path":                  "/users",
+		"client.ip":                 "10.0.0.1",
+		"event.duration":            float64(1500000),
+		"http.request.body.bytes":   float64(64),
+		"http.response.body.bytes":  float64(512),
+		"error.stack_trace":         "main.load (main.go:10)\nmain.main (main.go:3)",
+		"user_id":                   "u1",
+	})
+	for _, k := range []string{"time", "level", "msg", "trace_id", "status"} {
+		if _, ok := got[k]; ok {
+			t.Errorf("expected %s to be renamed", k)
+		}
+	}
+}
+
+func TestDatadogPreset(t *testing.T) {
+	got := formatPreset(t, DatadogPreset, logrus.FatalLevel, requestFields())
+
+	assertFields(t, got, map[string]interface{}{
+		"timestamp":             "2024-11-10T12:00:00.000Z",
+		"status":                "critical",
+		"message":               "request completed",
+		"service":               "user-service",
+		"version":               "1.0.0",
+		"dd.trace_id":           "11803532876627986230",
+		"dd.span_id":            "67667974448284343",
+		"http.method":           "GET",
+		"http.status_code":      float64(200),
+		"http.url_details.path": "/users",
+		"network.client.ip":     "10.0.0.1",
+		"duration":              float64(1500000),
+		"network.bytes_read":    float64(64),
+		"network.bytes_written": float64(512),
+	})
+}
+
+func TestGCPPreset(t *testing.T) {
+	got := formatPreset(t, GCPPreset("my-project"), logrus.ErrorLevel, requestFields())
+
+	assertFields(t, got, map[string]interface{}{
+		"timestamp":                     "2024-11-10T12:00:00Z",
+		"severity":                      "ERROR",
+		"message":                       "request completed",
+		"logging.googleapis.com/trace":  "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+		"logging.googleapis.com/spanId": "00f067aa0ba902b7",
+		"request_id":                    "req-1",
+	})
+	http, ok := got["httpRequest"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected an httpRequest object, got %v", got["httpRequest"])
+	}
+	assertFields(t, http, map[string]interface{}{
+		"requestMethod": "GET",
+		"requestUrl":    "/users",
+		"status":        float64(200),
+		"remoteIp":      "10.0.0.1",
+		"latency":       "0.0015s",
+		"requestSize":   float64(64),
+		"responseSize":  float64(512),
+	})
+	svc, _ := got["serviceContext"].(map[string]interface{})
+	assertFields(t, svc, map[string]interface{}{"service": "user-service", "version": "1.0.0"})
+
+	got = formatPreset(t, GCPPreset(""), logrus.TraceLevel, logrus.Fields{"trace_id": "abc"})
+	assertFields(t, got, map[string]interface{}{
+		"severity":                     "DEBUG",
+		"logging.googleapis.com/trace": "abc",
+	})
+}
+
+func TestPreset_Clashes(t *testing.T) {
+	got := formatPreset(t, ECSPreset, logrus.InfoLevel, logrus.Fields{
+		"message":  "user message",
+		"path":     "/a",
+		"resource": "/b",
+	})
+	assertFields(t, got, map[string]interface{}{
+		"message":        "request completed",
+		"fields.message": "user message",
+		"url.path":       "/a",
+		"resource":       "/b",
+	})
+}
@@ -0,0 +1,157 @@
+package formatter
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultRedactMask replaces redacted values.
+const DefaultRedactMask = "[REDACTED]"
+
+// DefaultRedactKeys are the field names masked by NewRedactor.
+var DefaultRedactKeys = []string{"authorization", "cookie", "password", "passwd", "secret", "token", "api_key", "apikey"}
+
+// Redactor masks sensitive values before entries are written.
+//
+// A field is masked entirely when its name contains one of Keys, compared
+// case-insensitively, so "token" also covers "access_token" and
+// "X-Auth-Token". Matches of Patterns are masked inside string values and
+// messages, e.g. bearer tokens or card numbers embedded in free text. Maps
+// (including http.Header) are redacted key by key, and slices element by
+// element, so decoded JSON documents are redacted throughout.
+type Redactor struct {
+	// Field name fragments whose values are masked, in lower case.
+	Keys []string
+
+	// Expressions masked wherever they match in string values.
+	Patterns []*regexp.Regexp
+
+	// Replacement for redacted values. Defaults to DefaultRedactMask.
+	Mask string
+}
+
+// NewRedactor returns a Redactor masking DefaultRedactKeys and the given
+// extra keys.
+//
+// Example:
+//
+//	r := formatter.NewRedactor("ssn")
+//	r.Patterns = append(r.Patterns, regexp.MustCompile(`\b\d{16}\b`))
+func NewRedactor(keys ...string) *Redactor {
+	all := append([]string{}, DefaultRedactKeys...)
+	for _, k := range keys {
+		all = append(all, strings.ToLower(k))
+	}
+	return &Redactor{Keys: all}
+}
+
+// Fields returns a copy of data with sensitive values masked.
+func (r *Redactor) Fields(data logrus.Fields) logrus.Fields {
+	out := make(logrus.Fields, len(data))
+	for k, v := range data {
+		out[k] = r.Value(k, v)
+	}
+	return out
+}
+
+// Value returns v masked if key is sensitive, and otherwise with Patterns
+// masked in strings, errors and maps.
+func (r *Redactor) Value(key string, v interface{}) interface{} {
+	if r.IsSensitive(key) {
+		return r.mask()
+	}
+	switch v := v.(type) {
+	case string:
+		return r.String(v)
+	case error:
+		if msg := v.Error(); len(r.Patterns) > 0 && r.String(msg) != msg {
+			return r.String(msg)
+		}
+		return v
+	case map[string]interface{}:
+		return map[string]interface{}(r.Fields(v))
+	case logrus.Fields:
+		return r.Fields(v)
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for k, s := range v {
+			out[k] = r.Value(k, s).(string)
+		}
+		return out
+	case http.Header:
+		return http.Header(r.multi(v))
+	case map[string][]string:
+		return r.multi(v)
+	case []string:
+		out := make([]string, len(v))
+		for i, e := range v {
+			out[i] = r.String(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = r.Value("", e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// String returns s with every match of Patterns masked.
+func (r *Redactor) String(s string) string {
+	for _, p := range r.Patterns {
+		s = p.ReplaceAllString(s, r.mask())
+	}
+	return s
+}
+
+// IsSensitive reports whether values of the field key are masked.
+func (r *Redactor) IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.Keys {
+		if k != "" && strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) multi(v map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(v))
+	for k, values := range v {
+		if r.IsSensitive(k) {
+			out[k] = []string{r.mask()}
+			continue
+		}
+		redacted := make([]string, len(values))
+		for i, s := range values {
+			redacted[i] = r.String(s)
+		}
+		out[k] = redacted
+	}
+	return out
+}
+
+func (r *Redactor) mask() string {
+	if r.Mask == "" {
+		return DefaultRedactMask
+	}
+	return r.Mask
+}
+
+// redactEntry returns a copy of entry with its fields and message redacted,
+// or entry itself when r is nil.
+func redactEntry(r *Redactor, entry *logrus.Entry) *logrus.Entry {
+	if r == nil {
+		return entry
+	}
+	redacted := *entry
+	redacted.Data = r.Fields(entry.Data)
+	redacted.Message = r.String(entry.Message)
+	return &redacted
+}
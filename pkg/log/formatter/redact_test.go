@@ -0,0 +1,109 @@
+package formatter
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRedactor_Fields(t *testing.T) {
+	r := NewRedactor("SSN")
+	r.Patterns = []*regexp.Regexp{regexp.MustCompile(`Bearer \S+`)}
+
+	in := logrus.Fields{
+		"Authorization": "Bearer abc",
+		"access_token":  "t1",
+		"user_ssn":      "123-45-6789",
+		"note":          "sent Bearer xyz to upstream",
+		"status":        200,
+		"error":         errors.New("rejected Bearer xyz"),
+		"cause":         errors.New("plain"),
+		"headers":       http.Header{"Cookie": {"a=b"}, "Accept": {"Bearer q"}},
+		"params":        map[string]string{"password": "p", "q": "go"},
+		"nested":        map[string]interface{}{"secret": "s", "ok": 1},
+		"items":         []interface{}{map[string]interface{}{"token": "t2"}, "Bearer z"},
+		"chain":         []string{"auth: Bearer y", "plain"},
+	}
+	out := r.Fields(in)
+
+	want := map[string]interface{}{
+		"Authorization": DefaultRedactMask,
+		"access_token":  DefaultRedactMask,
+		"user_ssn":      DefaultRedactMask,
+		"note":          "sent [REDACTED] to upstream",
+		"status":        200,
+		"error":         "rejected [REDACTED]",
+	}
+	for k, v := range want {
+		if out[k] != v {
+			t.Errorf("%s = %v, want %v", k, out[k], v)
+		}
+	}
+	if err, ok := out["cause"].(error); !ok || err.Error() != "plain" {
+		t.Errorf("expected unmatched errors to be kept, got %v", out["cause"])
+	}
+	h := out["headers"].(http.Header)
+	if h.Get("Cookie") != DefaultRedactMask || h.Get("Accept") != DefaultRedactMask {
+		t.Errorf("unexpected headers %v", h)
+	}
+	if p := out["params"].(map[string]string); p["password"] != DefaultRedactMask || p["q"] != "go" {
+		t.Errorf("unexpected params %v", p)
+	}
+	if n := out["nested"].(map[string]interface{}); n["secret"] != DefaultRedactMask || n["ok"] != 1 {
+		t.Errorf("unexpected nested map %v", n)
+	}
+	items := out["items"].([]interface{})
+	if items[0].(map[string]interface{})["token"] != DefaultRedactMask || items[1] != DefaultRedactMask {
+		t.Errorf("unexpected slice %v", items)
+	}
+	if c := out["chain"].([]string); c[0] != "auth: [REDACTED]" || c[1] != "plain" {
+		t.Errorf("unexpected string slice %v", c)
+	}
+	if in["Authorization"] != "Bearer abc" {
+		t.Error("expected the input fields to be left unchanged")
+	}
+}
+
+func TestRedactor_CustomMask(t *testing.T) {
+	r := &Redactor{Keys: []string{"pin"}, Mask: "***"}
+	if got := r.Value("PIN", "1234"); got != "***" {
+		t.Errorf("expected custom mask, got %v", got)
+	}
+	if r.IsSensitive("") || r.IsSensitive("name") {
+		t.Error("expected only matching keys to be sensitive")
+	}
+}
+
+func TestFormatters_Redact(t *testing.T) {
+	r := NewRedactor()
+	r.Patterns = []*regexp.Regexp{regexp.MustCompile(`pw=\S+`)}
+
+	for name, f := range map[string]logrus.Formatter{
+		"text": &Formatter{Redactor: r},
+		"json": &JSONFormatter{Redactor: r},
+	} {
+		t.Run(name, func(t *testing.T) {
+			entry := newEntryWithFields(logrus.Fields{"password": "hunter2", "user": "bob"})
+			entry.Message = "login pw=hunter2"
+
+			b, err := f.Format(entry)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			out := string(b)
+			if strings.Contains(out, "hunter2") {
+				t.Errorf("expected the secret to be masked, got %s", out)
+			}
+			if !strings.Contains(out, "bob") || !strings.Contains(out, DefaultRedactMask) {
+				t.Errorf("expected masked and plain values, got %s", out)
+			}
+			if entry.Data["password"] != "hunter2" || entry.Message != "login pw=hunter2" {
+				t.Error("expected the entry to be left unchanged")
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Access log formats for WithAccessLog, as Apache LogFormat strings.
+const (
+	// AccessLogCommon is the Apache Common Log Format.
+	AccessLogCommon = `%h %l %u %t "%r" %>s %b`
+
+	// AccessLogCombined is the Apache Combined Log Format: the common format
+	// plus the referer and user agent.
+	AccessLogCombined = AccessLogCommon + ` "%{Referer}i" "%{User-Agent}i"`
+)
+
+// accessLogTimeFormat is the layout of %t.
+const accessLogTimeFormat = "[02/Jan/2006:15:04:05 -0700]"
+
+// WithAccessLog also writes one line per request to w in format, an Apache
+// LogFormat string such as AccessLogCombined, for tools that parse access
+// logs. The line is written for every request, whatever the skip options,
+// which apply to the structured logs only.
+//
+// Supported directives:
+//
+//	%h  client IP            %r  request line      %s, %>s  status
+//	%l  "-"                  %m  method            %b  response bytes, "-" for none
+//	%u  basic auth user      %U  URL path          %B  response bytes
+//	%t  start time           %q  query string      %D  latency in microseconds
+//	%H  protocol             %{Name}i  request header   %T  latency in seconds
+//	%%  literal %            %{Name}o  response header
+//
+// Other directives are written as is. Values are escaped as Apache does, so
+// quotes in headers cannot break the fields apart.
+//
+// Example:
+//
+//	f, _ := os.OpenFile("/var/log/app/access.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+//	r.Use(log.Middleware(logger.WithAccessLog(f, logger.AccessLogCombined)))
+func WithAccessLog(w io.Writer, format string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.access = &accessLog{w: w, directives: parseAccessLogFormat(format)}
+	}
+}
+
+// accessLog writes access log lines to a writer shared by all requests.
+type accessLog struct {
+	mu         sync.Mutex
+	w          io.Writer
+	directives []accessLogDirective
+}
+
+// accessLogRequest is a completed request rendered by the directives.
+type accessLogRequest struct {
+	c       *gin.Context
+	start   time.Time
+	latency time.Duration
+	status  int
+	size    int
+}
+
+// accessLogDirective appends one part of the line.
+type accessLogDirective func(b *bytes.Buffer, r *accessLogRequest)
+
+// write renders r and writes it as a single line.
+func (a *accessLog) write(r *accessLogRequest) {
+	var b bytes.Buffer
+	for _, d := range a.directives {
+		d(&b, r)
+	}
+	b.WriteByte('\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.w.Write(b.Bytes()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write access log, %v\n", err)
+	}
+}
+
+// parseAccessLogFormat compiles an Apache LogFormat string.
+func parseAccessLogFormat(format string) []accessLogDirective {
+	var directives []accessLogDirective
+	literal := func(s string) {
+		if s != "" {
+			directives = append(directives, func(b *bytes.Buffer, _ *accessLogRequest) { b.WriteString(s) })
+		}
+	}
+
+	for {
+		i := strings.IndexByte(format, '%')
+		if i < 0 || i == len(format)-1 {
+			literal(format)
+			return directives
+		}
+		literal(format[:i])
+		rest := format[i+1:]
+
+		// Parse %{arg}X and the final-status modifier of %>s
+		var arg string
+		if rest[0] == '{' {
+			end := strings.IndexByte(rest, '}')
+			if end < 0 || end == len(rest)-1 {
+				literal(format[i:])
+				return directives
+			}
+			arg, rest = rest[1:end], rest[end+1:]
+		} else if rest[0] == '>' && len(rest) > 1 {
+			rest = rest[1:]
+		}
+
+		if d := accessLogVerb(rest[0], arg); d != nil {
+			directives = append(directives, d)
+		} else {
+			literal(format[i : len(format)-len(rest)+1])
+		}
+		format = rest[1:]
+	}
+}
+
+// accessLogVerb returns the directive of verb, or nil if it is unknown.
+func accessLogVerb(verb byte, arg string) accessLogDirective {
+	switch verb {
+	case '%':
+		return func(b *bytes.Buffer, _ *accessLogRequest) { b.WriteByte('%') }
+	case 'h':
+		return func(b *bytes.Buffer, r *accessLogRequest) { writeAccessLogValue(b, r.c.ClientIP()) }
+	case 'l':
+		return func(b *bytes.Buffer, _ *accessLogRequest) { b.WriteByte('-') }
+	case 'u':
+		return func(b *bytes.Buffer, r *accessLogRequest) {
+			user, _, _ := r.c.Request.BasicAuth()
+			writeAccessLogValue(b, user)
+		}
+	case 't':
+		return func(b *bytes.Buffer, r *accessLogRequest) { b.WriteString(r.start.Format(accessLogTimeFormat)) }
+	case 'r':
+		return func(b *bytes.Buffer, r *accessLogRequest) {
+			req := r.c.Request
+			writeAccessLogValue(b, req.Method+" "+req.URL.RequestURI()+" "+req.Proto)
+		}
+	case 'm':
+		return func(b *bytes.Buffer, r *accessLogRequest) { writeAccessLogValue(b, r.c.Request.Method) }
+	case 'U':
+		return func(b *bytes.Buffer, r *accessLogRequest) { writeAccessLogValue(b, r.c.Request.URL.Path) }
+	case 'q':
+		return func(b *bytes.Buffer, r *accessLogRequest) {
+			if q := r.c.Request.URL.RawQuery; q != "" {
+				writeAccessLogValue(b, "?"+q)
+			}
+		}
+	case 'H':
+		return func(b *bytes.Buffer, r *accessLogRequest) { writeAccessLogValue(b, r.c.Request.Proto) }
+	case 's':
+		return func(b *bytes.Buffer, r *accessLogRequest) { b.WriteString(strconv.Itoa(r.status)) }
+	case 'b':
+		return func(b *bytes.Buffer, r *accessLogRequest) {
+			if r.size <= 0 {
+				b.WriteByte('-')
+				return
+			}
+			b.WriteString(strconv.Itoa(r.size))
+		}
+	case 'B':
+		return func(b *bytes.Buffer, r *accessLogRequest) { b.WriteString(strconv.Itoa(max(r.size, 0))) }
+	case 'D':
+		return func(b *bytes.Buffer, r *accessLogRequest) {
+			b.WriteString(strconv.FormatInt(r.latency.Microseconds(), 10))
+		}
+	case 'T':
+		return func(b *bytes.Buffer, r *accessLogRequest) {
+			b.WriteString(strconv.FormatInt(int64(r.latency/time.Second), 10))
+		}
+	case 'i':
+		if arg == "" {
+			return nil
+		}
+		return func(b *bytes.Buffer, r *accessLogRequest) { writeAccessLogValue(b, r.c.Request.Header.Get(arg)) }
+	case 'o':
+		if arg == "" {
+			return nil
+		}
+		return func(b *bytes.Buffer, r *accessLogRequest) { writeAccessLogValue(b, r.c.Writer.Header().Get(arg)) }
+	}
+	return nil
+}
+
+// writeAccessLogValue writes s escaped as Apache does: quotes, backslashes
+// and control characters as \", \\ and \xhh. An empty s is written as "-".
+func writeAccessLogValue(b *bytes.Buffer, s string) {
+	if s == "" {
+		b.WriteByte('-')
+		return
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c == 0x7f:
+			fmt.Fprintf(b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveAccessLog(t *testing.T, format string, req *http.Request, opts ...MiddlewareOption) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	appLogger, _ := newTestLogger()
+
+	var buf bytes.Buffer
+	r := gin.New()
+	r.Use(appLogger.Middleware(append(opts, WithAccessLog(&buf, format))...))
+	r.GET("/users/:id", func(c *gin.Context) {
+		c.Header("X-Cache", "HIT")
+		c.String(http.StatusOK, "hello")
+	})
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return buf.String()
+}
+
+func TestWithAccessLog_Combined(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/42?full=true", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.SetBasicAuth("alice", "secret")
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set("User-Agent", `curl/8.0 "quoted"`)
+
+	line := serveAccessLog(t, AccessLogCombined, req)
+	want := regexp.MustCompile(`^203\.0\.113\.7 - alice \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] ` +
+		`"GET /users/42\?full=true HTTP/1\.1" 200 5 "https://example\.com/" "curl/8\.0 \\"quoted\\""\n$`)
+	if !want.MatchString(line) {
+		t.Errorf("unexpected combined log line %q", line)
+	}
+}
+
+func TestWithAccessLog_CustomFormat(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/42?x=1", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+
+	line := serveAccessLog(t, `%m %U%q %>s %B %{X-Request-ID}i %{X-Cache}o %Dus %% %z %{x}`, req)
+	fields := strings.Fields(line)
+	if len(fields) != 10 {
+		t.Fatalf("unexpected custom log line %q", line)
+	}
+	if got := strings.Join(fields[:5], " "); got != "GET /users/42?x=1 200 5 req-1" {
+		t.Errorf("unexpected directives %q", got)
+	}
+	if fields[5] != "HIT" || !strings.HasSuffix(fields[6], "us") || fields[7] != "%" {
+		t.Errorf("unexpected directives %q", line)
+	}
+	if fields[8] != "%z" || fields[9] != "%{x}" {
+		t.Errorf("expected unknown directives written as is, got %q", line)
+	}
+}
+
+func TestWithAccessLog_IgnoresSkipOptions(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	line := serveAccessLog(t, AccessLogCommon, req, WithSkipPaths("/users/1"))
+	if !strings.Contains(line, `"GET /users/1 HTTP/1.1" 200 5`) {
+		t.Errorf("expected skipped requests in the access log, got %q", line)
+	}
+}
+
+func TestWriteAccessLogValue(t *testing.T) {
+	var b bytes.Buffer
+	writeAccessLogValue(&b, "")
+	b.WriteByte(' ')
+	writeAccessLogValue(&b, "a\"b\\c\nd")
+	if got := b.String(); got != `- a\"b\\c\x0ad` {
+		t.Errorf("unexpected escaping %q", got)
+	}
+}
@@ -0,0 +1,201 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Record is a single log entry handed to a Backend. Fields hold the fields
+// of the Logger (service, version, request_id, ...) merged with the fields
+// of the call.
+type Record struct {
+	Time    time.Time
+	Level   logrus.Level
+	Message string
+	Fields  logrus.Fields
+
+	// PC is the program counter of the call site, or zero if unknown. It is
+	// set when caller reporting is enabled, see WithCaller.
+	PC uintptr
+}
+
+// Backend writes the entries of a Logger. The default backend formats with
+// Logrus; NewSlogBackend writes through any slog.Handler instead, e.g.
+// slog.NewJSONHandler or a zap core wrapped by zapslog, for services where
+// Logrus formatting dominates the hot path.
+//
+// The Logger filters entries below its level before calling the Backend.
+type Backend interface {
+	// Enabled reports whether entries at level are written.
+	Enabled(ctx context.Context, level logrus.Level) bool
+
+	// Log writes r.
+	Log(ctx context.Context, r Record) error
+}
+
+// Backends accepted by the LOG_BACKEND environment variable.
+const (
+	BackendLogrus = "logrus"
+	BackendSlog   = "slog"
+)
+
+// logrusBackend writes entries through a Logrus entry, with the formatter,
+// hooks and output of its logger. Logrus merges the entry fields itself.
+type logrusBackend struct {
+	entry *logrus.Entry
+}
+
+// Enabled implements Backend.
+func (b logrusBackend) Enabled(_ context.Context, level logrus.Level) bool {
+	return b.entry.Logger.IsLevelEnabled(level)
+}
+
+// Log implements Backend. Write failures are reported by Logrus.
+func (b logrusBackend) Log(ctx context.Context, r Record) error {
+	entry := b.entry.WithContext(ctx).WithFields(r.Fields)
+	if !r.Time.IsZero() {
+		entry = entry.WithTime(r.Time)
+	}
+	entry.Log(r.Level, r.Message)
+	return nil
+}
+
+// slogBackend writes entries through a slog.Handler.
+type slogBackend struct {
+	handler slog.Handler
+}
+
+// NewSlogBackend returns a Backend writing to h. Use it with WithBackend.
+//
+// Example:
+//
+//	log, _ := logger.New("user-service", "1.0.0", false,
+//		logger.WithBackend(logger.NewSlogBackend(slog.NewJSONHandler(os.Stderr, nil))))
+func NewSlogBackend(h slog.Handler) Backend {
+	return slogBackend{handler: h}
+}
+
+// Enabled implements Backend.
+func (b slogBackend) Enabled(ctx context.Context, level logrus.Level) bool {
+	return b.handler.Enabled(ctx, slogLevel(level))
+}
+
+// Log implements Backend. Fields are added in key order for a stable
+// output.
+func (b slogBackend) Log(ctx context.Context, r Record) error {
+	rec := slog.NewRecord(r.Time, slogLevel(r.Level), r.Message, r.PC)
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		rec.AddAttrs(slog.Any(k, r.Fields[k]))
+	}
+	return b.handler.Handle(ctx, rec)
+}
+
+// newEnvSlogBackend returns the slog backend selected by LOG_BACKEND=slog,
+// writing text or JSON to out. Every level is enabled; the Logger level
+// applies.
+func newEnvSlogBackend(format string, out io.Writer) Backend {
+	opts := &slog.HandlerOptions{Level: slogLevel(logrus.TraceLevel)}
+	if format == FormatJSON {
+		return NewSlogBackend(slog.NewJSONHandler(out, opts))
+	}
+	return NewSlogBackend(slog.NewTextHandler(out, opts))
+}
+
+// slogLevel maps a Logrus level to slog: trace is below slog.LevelDebug,
+// fatal and panic above slog.LevelError.
+func slogLevel(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.TraceLevel:
+		return slog.LevelDebug - 4
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	case logrus.ErrorLevel:
+		return slog.LevelError
+	case logrus.FatalLevel:
+		return slog.LevelError + 4
+	default:
+		return slog.LevelError + 8
+	}
+}
+
+// log writes r with the fields of l through its backend; a nil backend
+// means Logrus. Hooks of the Logrus logger fire for every backend.
+//
+// With caller reporting, an r without PC is attributed to the caller of the
+// function calling log, i.e. the code calling Info, Error, etc.
+func (l *Logger) log(ctx context.Context, r Record) {
+	if !l.Entry.Logger.IsLevelEnabled(r.Level) {
+		return
+	}
+	if l.caller {
+		if r.PC == 0 {
+			r.PC = callerPC(2)
+		}
+		addCaller(&r)
+	}
+	if l.backend == nil {
+		_ = logrusBackend{entry: l.Entry}.Log(ctx, r)
+		return
+	}
+	if !l.backend.Enabled(ctx, r.Level) {
+		return
+	}
+
+	fields := make(logrus.Fields, len(l.Entry.Data)+len(r.Fields))
+	for k, v := range l.Entry.Data {
+		fields[k] = v
+	}
+	for k, v := range r.Fields {
+		fields[k] = v
+	}
+	r.Fields = fields
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	l.fireHooks(ctx, &r)
+	if err := l.backend.Log(ctx, r); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	}
+}
+
+// fireHooks fires the Logrus hooks registered for r.Level on an entry built
+// from r, as Logrus does before formatting. Changes hooks make to the entry
+// fields and message, such as redaction, are kept in r. Logrus never
+// formats the entry, so sampling decisions made for it are dropped.
+func (l *Logger) fireHooks(ctx context.Context, r *Record) {
+	hooks := l.Entry.Logger.Hooks[r.Level]
+	if len(hooks) == 0 {
+		return
+	}
+	entry := &logrus.Entry{
+		Logger:  l.Entry.Logger,
+		Data:    r.Fields,
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Context: ctx,
+	}
+	for _, hook := range hooks {
+		if err := hook.Fire(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to fire hook: %v\n", err)
+		}
+	}
+	forgetSampled(l.Entry.Logger.Formatter, entry)
+	r.Fields, r.Message = entry.Data, entry.Message
+}
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// newSlogLogger returns a Logger writing JSON through the slog backend to
+// the returned buffer.
+func newSlogLogger(t *testing.T, opts ...Option) (*Logger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug - 4})
+	l, err := New("svc", "v1", false, append([]Option{WithBackend(NewSlogBackend(h))}, opts...)...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return l, &buf
+}
+
+// jsonLines decodes one JSON object per line.
+func jsonLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestSlogBackend(t *testing.T) {
+	l, buf := newSlogLogger(t, WithLevel("debug"))
+
+	l.Trace("dropped")
+	l.Info("hello %s", "world")
+	l.ForTenant("acme").Warn("tenant")
+
+	lines := jsonLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %s", len(lines), buf)
+	}
+	if lines[0]["msg"] != "hello world" || lines[0]["level"] != "INFO" || lines[0]["service"] != "svc" || lines[0]["version"] != "v1" {
+		t.Errorf("unexpected first line %v", lines[0])
+	}
+	if lines[1]["tenant"] != "acme" || lines[1]["level"] != "WARN" {
+		t.Errorf("unexpected second line %v", lines[1])
+	}
+}
+
+func TestSlogBackend_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l, buf := newSlogLogger(t)
+
+	r := gin.New()
+	r.Use(l.Middleware())
+	r.GET("/test", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := jsonLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %s", len(lines), buf)
+	}
+	last := lines[1]
+	if last["msg"] != "request completed" || last["request_id"] != "abc-123" || last["status"] != float64(200) || last["path"] != "/test" {
+		t.Errorf("unexpected completion line %v", last)
+	}
+}
+
+func TestSlogBackend_SlogHandler(t *testing.T) {
+	l, buf := newSlogLogger(t)
+	l.Slog().Info("bridged", "k", "v")
+
+	lines := jsonLines(t, buf)
+	if len(lines) != 1 || lines[0]["msg"] != "bridged" || lines[0]["k"] != "v" || lines[0]["service"] != "svc" {
+		t.Errorf("unexpected output %s", buf)
+	}
+}
+
+// failingHandler is a slog.Handler whose writes fail.
+type failingHandler struct{ slog.Handler }
+
+func (failingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (failingHandler) Handle(context.Context, slog.Record) error { return errors.New("disk full") }
+
+func TestSlogBackend_Fatal(t *testing.T) {
+	l, buf := newSlogLogger(t)
+	code := 0
+	l.Entry.Logger.ExitFunc = func(c int) { code = c }
+
+	l.Fatal("bye")
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if lines := jsonLines(t, buf); len(lines) != 1 || lines[0]["level"] != "ERROR+4" {
+		t.Errorf("expected a fatal line, got %s", buf)
+	}
+
+	// Write failures must not panic
+	l.backend = NewSlogBackend(failingHandler{})
+	l.Info("lost")
+}
+
+func TestNew_BackendFromEnv(t *testing.T) {
+	t.Setenv("LOG_BACKEND", "slog")
+	l, err := New("svc", "v1", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := l.backend.(slogBackend); !ok {
+		t.Errorf("expected LOG_BACKEND=slog to select the slog backend, got %T", l.backend)
+	}
+
+	t.Setenv("LOG_BACKEND", "zap")
+	if _, err := New("svc", "v1", false); err == nil {
+		t.Error("expected an error for an unknown LOG_BACKEND")
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	for _, lvl := range logrus.AllLevels {
+		if got := logrusLevel(slogLevel(lvl)); got != lvl && lvl > logrus.ErrorLevel {
+			t.Errorf("level %v does not round-trip, got %v", lvl, got)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package logger
+
+import (
+	"bytes"
+	"mime"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/httpbody"
+	"github.com/ranorsolutions/http-common-go/pkg/log/formatter"
+)
+
+// DefaultBodyLogLimit is the number of body bytes logged unless
+// BodyLogConfig.MaxBytes is set.
+const DefaultBodyLogLimit = 4 << 10
+
+// DefaultBodyLogContentTypes are the media types whose bodies are logged
+// unless BodyLogConfig.ContentTypes is set.
+var DefaultBodyLogContentTypes = []string{
+	"application/json",
+	"application/*+json",
+	"application/x-www-form-urlencoded",
+	"application/xml",
+	"text/*",
+}
+
+// BodyLogConfig configures the body logging of WithBodyLogging.
+type BodyLogConfig struct {
+	// Bytes of each body logged; longer bodies are cut and flagged with
+	// request_body_truncated or response_body_truncated. Defaults to
+	// DefaultBodyLogLimit.
+	MaxBytes int
+
+	// Media types whose bodies are logged, as path.Match patterns such as
+	// "text/*". Defaults to DefaultBodyLogContentTypes; other bodies, such as
+	// uploads and images, are not captured.
+	ContentTypes []string
+
+	// Masks sensitive values: JSON and form bodies are redacted by key,
+	// other bodies by pattern. Defaults to formatter.NewRedactor().
+	Redactor *formatter.Redactor
+}
+
+// WithBodyLogging adds the request and response bodies to the completion
+// log as request_body and response_body. Only the first MaxBytes of a body
+// are held in memory; the handler still reads the full request body and the
+// client receives the full response.
+//
+// Bodies may carry personal data: enable it per environment or route group
+// while debugging an integration.
+//
+// Example:
+//
+//	r.Use(log.Middleware(logger.WithBodyLogging(logger.BodyLogConfig{MaxBytes: 1024})))
+func WithBodyLogging(cfg BodyLogConfig) MiddlewareOption {
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultBodyLogLimit
+	}
+	if len(cfg.ContentTypes) == 0 {
+		cfg.ContentTypes = DefaultBodyLogContentTypes
+	}
+	if cfg.Redactor == nil {
+		cfg.Redactor = formatter.NewRedactor()
+	}
+	return func(o *middlewareOptions) { o.body = &cfg }
+}
+
+// loggable reports whether bodies of contentType are logged.
+func (cfg *BodyLogConfig) loggable(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range cfg.ContentTypes {
+		if ok, _ := path.Match(pattern, mediaType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// captureRequest reads the first MaxBytes of the request body and puts them
+// back in front of the rest, so the handler sees the body unchanged.
+func (cfg *BodyLogConfig) captureRequest(c *gin.Context) *capturedBody {
+	if !cfg.loggable(c.Request.Header.Get("Content-Type")) {
+		return nil
+	}
+	head, err := httpbody.Capture(c.Request, cfg.MaxBytes)
+	if err != nil || head == nil {
+		return nil
+	}
+
+	captured := &capturedBody{}
+	captured.add(head, cfg.MaxBytes)
+	return captured
+}
+
+// fields returns the log fields of the captured bodies.
+func (cfg *BodyLogConfig) fields(req *capturedBody, resp *bodyWriter, contentType string) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if req != nil && req.buf.Len() > 0 {
+		fields["request_body"] = cfg.redact(req.buf.Bytes(), contentType, req.truncated)
+		if req.truncated {
+			fields["request_body_truncated"] = true
+		}
+	}
+	if resp != nil && resp.captured.buf.Len() > 0 {
+		fields["response_body"] = cfg.redact(resp.captured.buf.Bytes(), resp.Header().Get("Content-Type"), resp.captured.truncated)
+		if resp.captured.truncated {
+			fields["response_body_truncated"] = true
+		}
+	}
+	return fields
+}
+
+// redact returns body as a string with sensitive values masked.
+func (cfg *BodyLogConfig) redact(body []byte, contentType string, truncated bool) string {
+	return httpbody.Redact(cfg.Redactor, body, contentType, truncated)
+}
+
+// capturedBody holds the logged prefix of a body.
+type capturedBody struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+// add appends p up to limit bytes in total.
+func (b *capturedBody) add(p []byte, limit int) {
+	if room := limit - b.buf.Len(); len(p) > room {
+		p, b.truncated = p[:room], true
+	}
+	b.buf.Write(p)
+}
+
+// bodyWriter is a gin.ResponseWriter capturing the first bytes of a
+// loggable response body.
+type bodyWriter struct {
+	gin.ResponseWriter
+	cfg      *BodyLogConfig
+	captured capturedBody
+	checked  bool
+	loggable bool
+}
+
+// Write implements io.Writer.
+func (w *bodyWriter) Write(p []byte) (int, error) {
+	w.capture(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// WriteString implements io.StringWriter.
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyWriter) capture(p []byte) {
+	if !w.checked {
+		// Headers are final once the body is written
+		w.checked = true
+		w.loggable = w.cfg.loggable(w.Header().Get("Content-Type"))
+	}
+	if w.loggable {
+		w.captured.add(p, w.cfg.MaxBytes)
+	}
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// serveBody serves a request with body through Middleware with body logging
+// and returns the completion entry and the body the handler read.
+func serveBody(t *testing.T, cfg BodyLogConfig, contentType, body string, respond func(c *gin.Context)) (*logrus.Entry, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	appLogger, hook := newTestLogger()
+
+	var read string
+	r := gin.New()
+	r.Use(appLogger.Middleware(WithBodyLogging(cfg)))
+	r.POST("/orders", func(c *gin.Context) {
+		b, _ := io.ReadAll(c.Request.Body)
+		read = string(b)
+		respond(c)
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return hook.entries[len(hook.entries)-1], read
+}
+
+func TestBodyLogging_JSON(t *testing.T) {
+	entry, read := serveBody(t, BodyLogConfig{}, "application/json",
+		`{"item":"book","password":"hunter2","cards":[{"token":"t1"}]}`,
+		func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"id": 7, "api_key": "k"}) })
+
+	if read != `{"item":"book","password":"hunter2","cards":[{"token":"t1"}]}` {
+		t.Errorf("expected the handler to read the full body, got %q", read)
+	}
+	if got := entry.Data["request_body"]; got != `{"cards":[{"token":"[REDACTED]"}],"item":"book","password":"[REDACTED]"}` {
+		t.Errorf("unexpected request_body %v", got)
+	}
+	if got := entry.Data["response_body"]; got != `{"api_key":"[REDACTED]","id":7}` {
+		t.Errorf("unexpected response_body %v", got)
+	}
+	if _, ok := entry.Data["request_body_truncated"]; ok {
+		t.Error("expected no truncation flag for a short body")
+	}
+}
+
+func TestBodyLogging_Truncated(t *testing.T) {
+	body := `{"password":"hunter2","note":"` + strings.Repeat("x", 100) + `"}`
+	entry, read := serveBody(t, BodyLogConfig{MaxBytes: 40}, "application/json", body,
+		func(c *gin.Context) { c.String(http.StatusOK, strings.Repeat("y", 50)) })
+
+	if read != body {
+		t.Error("expected the handler to read the full body past the limit")
+	}
+	got, _ := entry.Data["request_body"].(string)
+	if strings.Contains(got, "hunter2") || !strings.HasPrefix(got, `{"password":"[REDACTED]","note":"xxx`) {
+		t.Errorf("unexpected truncated request_body %q", got)
+	}
+	if entry.Data["request_body_truncated"] != true {
+		t.Error("expected request_body_truncated")
+	}
+	if got := entry.Data["response_body"]; got != strings.Repeat("y", 40) || entry.Data["response_body_truncated"] != true {
+		t.Errorf("unexpected response_body %v", got)
+	}
+}
+
+func TestBodyLogging_Form(t *testing.T) {
+	entry, _ := serveBody(t, BodyLogConfig{}, "application/x-www-form-urlencoded", "user=ana&password=p",
+		func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	if got := entry.Data["request_body"]; got != "password=%5BREDACTED%5D&user=ana" {
+		t.Errorf("unexpected request_body %v", got)
+	}
+	if _, ok := entry.Data["response_body"]; ok {
+		t.Error("expected no response_body for an empty response")
+	}
+}
+
+func TestBodyLogging_ContentTypes(t *testing.T) {
+	entry, read := serveBody(t, BodyLogConfig{}, "application/octet-stream", "\x00\x01binary",
+		func(c *gin.Context) { c.Data(http.StatusOK, "image/png", []byte("\x89PNG")) })
+
+	if read != "\x00\x01binary" {
+		t.Errorf("expected the handler to read the body, got %q", read)
+	}
+	if _, ok := entry.Data["request_body"]; ok {
+		t.Error("expected no request_body for a binary content type")
+	}
+	if _, ok := entry.Data["response_body"]; ok {
+		t.Error("expected no response_body for a binary content type")
+	}
+
+	entry, _ = serveBody(t, BodyLogConfig{ContentTypes: []string{"application/*+json"}}, "application/json", `{"a":1}`,
+		func(c *gin.Context) {
+			c.Data(http.StatusBadRequest, "application/problem+json", []byte(`{"title":"bad"}`))
+		})
+	if _, ok := entry.Data["request_body"]; ok {
+		t.Error("expected only allowlisted content types to be logged")
+	}
+	if got := entry.Data["response_body"]; got != `{"title":"bad"}` {
+		t.Errorf("unexpected response_body %v", got)
+	}
+}
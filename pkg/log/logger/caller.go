@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Fields added to every entry when caller reporting is enabled with
+// WithCaller or LOG_CALLER.
+const (
+	// CallerKey holds the file and line of the call, relative to the
+	// package directory, e.g. "service/charge.go:42".
+	CallerKey = "caller"
+
+	// FunctionKey holds the calling function qualified with its package
+	// name, e.g. "service.(*Service).Charge".
+	FunctionKey = "function"
+)
+
+// callerPC returns the program counter of the function skip frames above
+// the caller of callerPC: 0 is the function calling callerPC.
+func callerPC(skip int) uintptr {
+	var pcs [1]uintptr
+	// Skip runtime.Callers and callerPC itself
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return 0
+	}
+	return pcs[0]
+}
+
+// pc returns the program counter of its caller when caller reporting is
+// enabled, for entries this package logs on its own behalf, such as the
+// request logs of Middleware.
+func (l *Logger) pc() uintptr {
+	if !l.caller {
+		return 0
+	}
+	return callerPC(1)
+}
+
+// addCaller sets the CallerKey and FunctionKey fields of r from r.PC.
+func addCaller(r *Record) {
+	if r.PC == 0 {
+		return
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+	if frame.File == "" {
+		return
+	}
+
+	fields := make(logrus.Fields, len(r.Fields)+2)
+	for k, v := range r.Fields {
+		fields[k] = v
+	}
+	dir, file := path.Split(frame.File)
+	fields[CallerKey] = path.Base(dir) + "/" + file + ":" + strconv.Itoa(frame.Line)
+	fn := frame.Function
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	fields[FunctionKey] = fn
+	r.Fields = fields
+}
@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// nextLine returns the caller position of the line after the call.
+func nextLine() string {
+	_, _, line, _ := runtime.Caller(1)
+	return "logger/caller_test.go:" + strconv.Itoa(line+1)
+}
+
+func newCallerLogger(t *testing.T, opts ...Option) (*Logger, *testHook) {
+	t.Helper()
+	l, err := New("svc", "v1", false, append([]Option{WithOutput(io.Discard), WithCaller()}, opts...)...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := &testHook{}
+	l.Entry.Logger.AddHook(h)
+	return l, h
+}
+
+func TestWithCaller(t *testing.T) {
+	l, h := newCallerLogger(t)
+
+	want := nextLine()
+	l.Info("direct")
+	child := l.WithFields(map[string]interface{}{"job": 1}).ForTenant("acme")
+	wantChild := nextLine()
+	child.Warn("derived")
+	wantSlog := nextLine()
+	l.Slog().Error("via slog")
+
+	if len(h.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(h.entries))
+	}
+	for i, w := range []string{want, wantChild, wantSlog} {
+		if got := h.entries[i].Data[CallerKey]; got != w {
+			t.Errorf("entry %d: expected caller %q, got %v", i, w, got)
+		}
+		if got := h.entries[i].Data[FunctionKey]; got != "logger.TestWithCaller" {
+			t.Errorf("entry %d: expected function logger.TestWithCaller, got %v", i, got)
+		}
+	}
+}
+
+func TestWithCaller_Disabled(t *testing.T) {
+	l, _ := New("svc", "v1", false, WithOutput(io.Discard))
+	h := &testHook{}
+	l.Entry.Logger.AddHook(h)
+
+	l.Info("no caller")
+	if _, ok := h.entries[0].Data[CallerKey]; ok {
+		t.Error("expected no caller field by default")
+	}
+
+	t.Setenv("LOG_CALLER", "true")
+	l, _ = New("svc", "v1", false, WithOutput(io.Discard))
+	l.Entry.Logger.AddHook(h)
+	l.Info("caller from env")
+	if _, ok := h.entries[1].Data[CallerKey]; !ok {
+		t.Error("expected the caller field with LOG_CALLER=true")
+	}
+}
+
+func TestWithCaller_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l, h := newCallerLogger(t)
+
+	r := gin.New()
+	r.Use(l.Middleware())
+	r.GET("/", func(c *gin.Context) {
+		FromGin(c).Info("handler")
+		c.Status(http.StatusOK)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if len(h.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(h.entries))
+	}
+	if fn, _ := h.entries[1].Data[FunctionKey].(string); !strings.HasPrefix(fn, "logger.TestWithCaller_Middleware") {
+		t.Errorf("expected the handler entry attributed to the handler, got %q", fn)
+	}
+	for _, i := range []int{0, 2} {
+		caller, _ := h.entries[i].Data[CallerKey].(string)
+		fn, _ := h.entries[i].Data[FunctionKey].(string)
+		if !strings.HasPrefix(caller, "logger/logger.go:") || !strings.HasPrefix(fn, "logger.(*Logger).Middleware") {
+			t.Errorf("expected the request logs attributed to the middleware, got %q %q", caller, fn)
+		}
+	}
+}
+
+func TestWithCaller_SlogBackend(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true})
+	l, err := New("svc", "v1", false, WithBackend(NewSlogBackend(handler)), WithCaller())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := nextLine()
+	l.Info("through slog")
+
+	var got struct {
+		Caller string `json:"caller"`
+		Source struct {
+			File string `json:"file"`
+			Line int    `json:"line"`
+		} `json:"source"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if got.Caller != want {
+		t.Errorf("expected caller %q, got %q", want, got.Caller)
+	}
+	if !strings.HasSuffix(want, "caller_test.go:"+strconv.Itoa(got.Source.Line)) {
+		t.Errorf("expected the slog source to match the caller, got %+v", got.Source)
+	}
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// EntryKey is the Gin context key under which Middleware stores the
+// request-scoped *logrus.Entry. Prefer FromGin over reading it directly.
+const EntryKey = "logger_entry"
+
+type loggerKey struct{}
+
+var defaultLogger atomic.Pointer[Logger]
+
+// standardLogger is returned by Default when no Logger was set.
+var standardLogger = &Logger{Entry: logrus.NewEntry(logrus.StandardLogger()), tenants: newTenantRegistry()}
+
+// SetDefault sets the Logger returned by Default, and by FromContext and
+// FromGin outside of a request handled by Middleware.
+func SetDefault(l *Logger) {
+	defaultLogger.Store(l)
+}
+
+// Default returns the Logger set with SetDefault, or a Logger writing to
+// the Logrus standard logger when none was set.
+func Default() *Logger {
+	if l := defaultLogger.Load(); l != nil {
+		return l
+	}
+	return standardLogger
+}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the Logger stored in ctx by NewContext or Middleware,
+// which carries the request_id, trace_id and span_id fields of the request,
+// or Default when there is none.
+//
+// Example:
+//
+//	func (s *Service) Charge(ctx context.Context, id string) error {
+//		logger.FromContext(ctx).Info("charging %s", id)
+//		...
+//	}
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(*Logger); ok {
+			return l
+		}
+	}
+	return Default()
+}
+
+// FromGin returns the request-scoped Logger Middleware stored for c, or
+// Default when the middleware did not run.
+//
+// Example:
+//
+//	r.GET("/users/:id", func(c *gin.Context) {
+//		logger.FromGin(c).Info("loading user %s", c.Param("id"))
+//	})
+func FromGin(c *gin.Context) *Logger {
+	if c.Request != nil {
+		if l, ok := c.Request.Context().Value(loggerKey{}).(*Logger); ok {
+			return l
+		}
+	}
+	if v, ok := c.Get(EntryKey); ok {
+		if entry, ok := v.(*logrus.Entry); ok {
+			return &Logger{Entry: entry}
+		}
+	}
+	return Default()
+}
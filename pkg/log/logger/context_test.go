@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func TestFromContextAndFromGin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appLogger, hook := newTestLogger()
+
+	r := gin.New()
+	r.Use(appLogger.Middleware())
+	r.GET("/test", func(c *gin.Context) {
+		FromGin(c).Info("from gin")
+		FromContext(c.Request.Context()).Info("from context")
+		c.String(http.StatusOK, "ok")
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, msg := range []string{"from gin", "from context"} {
+		found := false
+		for _, e := range hook.entries {
+			if e.Message == msg {
+				found = true
+				if e.Data["request_id"] != "abc-123" || e.Data["service"] != "test-service" {
+					t.Errorf("%s: expected request fields, got %v", msg, e.Data)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("expected entry %q", msg)
+		}
+	}
+}
+
+func TestFromGin_EntryOnly(t *testing.T) {
+	entry := logrus.NewEntry(logrus.New()).WithField("request_id", "r1")
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(EntryKey, entry)
+
+	if got := FromGin(c); got.Entry != entry {
+		t.Errorf("expected the stored entry, got %v", got.Entry)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	t.Cleanup(func() { defaultLogger.Store(nil) })
+
+	if Default().Entry.Logger != logrus.StandardLogger() {
+		t.Error("expected the Logrus standard logger without SetDefault")
+	}
+
+	l, _ := New("svc", "v1", false)
+	SetDefault(l)
+	if FromContext(context.Background()) != l {
+		t.Error("expected FromContext to fall back to the default logger")
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if FromGin(c) != l {
+		t.Error("expected FromGin to fall back to the default logger")
+	}
+
+	other, _ := New("other", "v1", false)
+	if FromContext(NewContext(context.Background(), other)) != other {
+		t.Error("expected the logger stored with NewContext")
+	}
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LevelHandlerOption configures the handler returned by LevelHandler.
+type LevelHandlerOption func(*levelHandler)
+
+// WithLevelToken requires requests to carry "Authorization: Bearer token".
+// An empty token, e.g. from an unset environment variable, denies every
+// request rather than accepting an empty bearer token.
+func WithLevelToken(token string) LevelHandlerOption {
+	return func(h *levelHandler) {
+		h.authorize = func(r *http.Request) bool {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			return ok && token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+		}
+	}
+}
+
+// WithLevelAuthorizer requires authorize to accept requests, e.g. to check
+// a client certificate or an admin session.
+func WithLevelAuthorizer(authorize func(r *http.Request) bool) LevelHandlerOption {
+	return func(h *levelHandler) { h.authorize = authorize }
+}
+
+// levelHandler serves the level of a Logger.
+type levelHandler struct {
+	logger    *Logger
+	authorize func(r *http.Request) bool
+}
+
+// levelBody is the request and response body of the level handler.
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler returns an http.Handler reading and changing the level of l
+// at runtime, so debug logging can be turned on in production without a
+// redeploy:
+//
+//	GET  returns {"level":"info"}
+//	PUT  sets the level from {"level":"debug"} or ?level=debug
+//
+// The handler is open unless WithLevelToken or WithLevelAuthorizer is
+// given; mount it on an internal port or protect it.
+//
+// Example:
+//
+//	r.Any("/admin/log-level", gin.WrapH(log.LevelHandler(logger.WithLevelToken(os.Getenv("ADMIN_TOKEN")))))
+//
+//	curl -X PUT -H "Authorization: Bearer $ADMIN_TOKEN" -d '{"level":"debug"}' localhost:8080/admin/log-level
+func (l *Logger) LevelHandler(opts ...LevelHandlerOption) http.Handler {
+	h := &levelHandler{logger: l}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.authorize != nil && !h.authorize(r) {
+		writeLevelError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut:
+		level := r.URL.Query().Get("level")
+		if level == "" {
+			var body levelBody
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&body); err != nil {
+				writeLevelError(w, http.StatusBadRequest, "invalid request body")
+				return
+			}
+			level = body.Level
+		}
+
+		previous := h.logger.Level()
+		if err := h.logger.SetLevel(level); err != nil {
+			writeLevelError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.log(r.Context(), Record{
+			PC:      h.logger.pc(),
+			Level:   logrus.WarnLevel,
+			Message: "log level changed",
+			Fields:  logrus.Fields{"previous_level": previous, "level": h.logger.Level()},
+		})
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		writeLevelError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(levelBody{Level: h.logger.Level()})
+}
+
+// writeLevelError writes {"error": msg} with status.
+func writeLevelError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
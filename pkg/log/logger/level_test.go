@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveLevel(h http.Handler, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestLevelHandler_GetAndPut(t *testing.T) {
+	l, hook := newTestLogger()
+	if err := l.SetLevel("info"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := l.LevelHandler()
+
+	w := serveLevel(h, http.MethodGet, "/", "", nil)
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"level":"info"}` {
+		t.Fatalf("unexpected GET response %d %s", w.Code, w.Body)
+	}
+
+	w = serveLevel(h, http.MethodPut, "/", `{"level":"debug"}`, nil)
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"level":"debug"}` {
+		t.Fatalf("unexpected PUT response %d %s", w.Code, w.Body)
+	}
+	if l.Level() != "debug" {
+		t.Errorf("expected level debug, got %s", l.Level())
+	}
+	last := hook.entries[len(hook.entries)-1]
+	if last.Message != "log level changed" || last.Data["previous_level"] != "info" || last.Data["level"] != "debug" {
+		t.Errorf("expected the change to be logged, got %q %v", last.Message, last.Data)
+	}
+
+	w = serveLevel(h, http.MethodPut, "/?level=warn", "", nil)
+	if w.Code != http.StatusOK || l.Level() != "warning" {
+		t.Errorf("expected level from the query, got %d %s", w.Code, l.Level())
+	}
+}
+
+func TestLevelHandler_Errors(t *testing.T) {
+	l, _ := newTestLogger()
+	h := l.LevelHandler()
+
+	if w := serveLevel(h, http.MethodPut, "/", `{"level":"loud"}`, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid level, got %d", w.Code)
+	}
+	if w := serveLevel(h, http.MethodPut, "/", `not json`, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid body, got %d", w.Code)
+	}
+	w := serveLevel(h, http.MethodPost, "/", "", nil)
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD, PUT" {
+		t.Errorf("expected 405 with Allow, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+	if l.Level() != "trace" {
+		t.Errorf("expected the level to be unchanged, got %s", l.Level())
+	}
+}
+
+func TestLevelHandler_Token(t *testing.T) {
+	l, _ := newTestLogger()
+	h := l.LevelHandler(WithLevelToken("s3cret"))
+
+	if w := serveLevel(h, http.MethodGet, "/", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", w.Code)
+	}
+	bad := http.Header{"Authorization": {"Bearer wrong"}}
+	if w := serveLevel(h, http.MethodPut, "/?level=error", "", bad); w.Code != http.StatusUnauthorized || l.Level() != "trace" {
+		t.Errorf("expected 401 with a wrong token, got %d", w.Code)
+	}
+	good := http.Header{"Authorization": {"Bearer s3cret"}}
+	if w := serveLevel(h, http.MethodPut, "/?level=error", "", good); w.Code != http.StatusOK || l.Level() != "error" {
+		t.Errorf("expected the level to change with the token, got %d %s", w.Code, l.Level())
+	}
+}
+
+func TestLevelHandler_EmptyTokenDeniesAll(t *testing.T) {
+	l, _ := newTestLogger()
+	h := l.LevelHandler(WithLevelToken(""))
+
+	for _, header := range []http.Header{nil, {"Authorization": {"Bearer "}}, {"Authorization": {"Bearer"}}} {
+		if w := serveLevel(h, http.MethodPut, "/?level=error", "", header); w.Code != http.StatusUnauthorized || l.Level() != "trace" {
+			t.Errorf("expected 401 with %v, got %d", header, w.Code)
+		}
+	}
+}
+
+func TestLevelHandler_Authorizer(t *testing.T) {
+	l, _ := newTestLogger()
+	h := l.LevelHandler(WithLevelAuthorizer(func(r *http.Request) bool {
+		return r.Header.Get("X-Admin") == "yes"
+	}))
+
+	if w := serveLevel(h, http.MethodGet, "/", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+	if w := serveLevel(h, http.MethodGet, "/", "", http.Header{"X-Admin": {"yes"}}); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
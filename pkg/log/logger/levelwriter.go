@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LevelWriter routes the entries of some levels to a writer, for
+// WithLevelWriter.
+type LevelWriter struct {
+	// Writer receives the formatted entries.
+	Writer io.Writer
+
+	// Levels are the levels written to Writer, all levels when empty. See
+	// AtLeast.
+	Levels []logrus.Level
+}
+
+// AtLeast returns level and the more severe levels, e.g.
+// AtLeast(logrus.WarnLevel) returns warn, error, fatal and panic.
+func AtLeast(level logrus.Level) []logrus.Level {
+	var levels []logrus.Level
+	for _, l := range logrus.AllLevels {
+		if l <= level {
+			levels = append(levels, l)
+		}
+	}
+	return levels
+}
+
+// WithLevelWriter routes entries to writers by level. Each entry is written
+// to every LevelWriter listing its level, formatted by the Logrus formatter
+// whatever the backend. Unless WithOutput is also given, entries are no
+// longer written to stderr; WithFile still receives every entry.
+//
+// Example:
+//
+//	// Warn and above to stderr, errors to a socket, everything to a file
+//	log, _ := logger.New("user-service", "1.0.0", false,
+//		logger.WithLevelWriter(
+//			logger.LevelWriter{Writer: os.Stderr, Levels: logger.AtLeast(logrus.WarnLevel)},
+//			logger.LevelWriter{Writer: conn, Levels: logger.AtLeast(logrus.ErrorLevel)},
+//		),
+//		logger.WithFile(writer.FileConfig{Filename: "/var/log/user-service/app.log"}))
+func WithLevelWriter(lw ...LevelWriter) Option {
+	return func(o *options) { o.levelWriters = append(o.levelWriters, lw...) }
+}
+
+// levelWriterHook writes the entries of its levels to a writer. Hooks fire
+// concurrently, so writes are serialized.
+type levelWriterHook struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	levels []logrus.Level
+}
+
+// newLevelWriterHook returns the hook of lw.
+func newLevelWriterHook(lw LevelWriter) levelWriterHook {
+	levels := lw.Levels
+	if len(levels) == 0 {
+		levels = logrus.AllLevels
+	}
+	return levelWriterHook{mu: &sync.Mutex{}, w: lw.Writer, levels: levels}
+}
+
+// Levels implements logrus.Hook.
+func (h levelWriterHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire implements logrus.Hook. The entry is formatted as if the logger
+// wrote to h.w, so colors follow whether h.w is a terminal. Entries the
+// logger samples out are dropped here too.
+func (h levelWriterHook) Fire(entry *logrus.Entry) error {
+	f := entry.Logger.Formatter
+	for {
+		s, ok := f.(sampler)
+		if !ok {
+			break
+		}
+		if !s.sampled(entry) {
+			return nil
+		}
+		f = s.unwrap()
+	}
+	e := *entry
+	e.Logger = &logrus.Logger{Out: h.w, Formatter: f, Level: entry.Logger.GetLevel()}
+	b, err := f.Format(&e)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, err := h.w.Write(b); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestAtLeast(t *testing.T) {
+	want := []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel}
+	if got := AtLeast(logrus.WarnLevel); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestWithLevelWriter(t *testing.T) {
+	var warn, errs, all bytes.Buffer
+	l, err := New("svc", "1.0.0", false, WithFormat(FormatJSON),
+		WithLevelWriter(
+			LevelWriter{Writer: &warn, Levels: AtLeast(logrus.WarnLevel)},
+			LevelWriter{Writer: &errs, Levels: []logrus.Level{logrus.ErrorLevel}},
+			LevelWriter{Writer: &all},
+		))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	l.Info("info entry")
+	l.Warn("warn entry")
+	l.Error("error entry")
+
+	if got := strings.Count(all.String(), "\n"); got != 3 {
+		t.Errorf("expected 3 entries for all levels, got %q", all.String())
+	}
+	if got := warn.String(); strings.Contains(got, "info entry") || !strings.Contains(got, "warn entry") || !strings.Contains(got, "error entry") {
+		t.Errorf("expected warn and error entries, got %q", got)
+	}
+	if got := errs.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, `"error entry"`) {
+		t.Errorf("expected the error entry only, got %q", got)
+	}
+	if l.Entry.Logger.Out != io.Discard {
+		t.Errorf("expected the default stderr output to be dropped")
+	}
+}
+
+func TestWithLevelWriter_KeepsOutput(t *testing.T) {
+	var out, errs bytes.Buffer
+	l, err := New("svc", "1.0.0", false, WithOutput(&out),
+		WithLevelWriter(LevelWriter{Writer: &errs, Levels: AtLeast(logrus.ErrorLevel)}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	l.Info("info entry")
+	l.Error("error entry")
+
+	if got := strings.Count(out.String(), "\n"); got != 2 {
+		t.Errorf("expected every entry in the output, got %q", out.String())
+	}
+	if got := errs.String(); strings.Contains(got, "info entry") || !strings.Contains(got, "error entry") {
+		t.Errorf("expected the error entry only, got %q", got)
+	}
+}
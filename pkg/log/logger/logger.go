@@ -3,15 +3,18 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/ranorsolutions/http-common-go/pkg/log/formatter"
+	"github.com/ranorsolutions/http-common-go/pkg/errtrace"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
 	"github.com/ranorsolutions/http-common-go/pkg/middleware/response"
 	"github.com/sirupsen/logrus"
 )
@@ -20,25 +23,54 @@ import (
 // application-wide logging and request-scoped logging via Gin middleware.
 type Logger struct {
 	Entry *logrus.Entry
+
+	// tenants holds per-tenant overrides shared with derived loggers.
+	tenants *tenantRegistry
+
+	// backend writes entries; nil writes through Entry with Logrus.
+	backend Backend
+
+	// caller adds the CallerKey and FunctionKey fields to entries.
+	caller bool
+
+	// closers release the outputs opened by New.
+	closers []io.Closer
 }
 
 // New initializes a new Logger instance configured with the provided service
-// name and version. It sets up a Logrus instance with a custom formatter.
+// name and version. It sets up a Logrus instance with a custom formatter;
+// pass WithJSON (or set LOG_FORMAT=json) to emit one JSON object per line
+// for log pipelines that cannot parse the colored layout.
 //
 // Example:
 //
 //	log, _ := logger.New("user-service", "1.0.0", true)
 //	log.Info("service started")
-func New(name, version string, forceColors bool) (*Logger, error) {
+func New(name, version string, forceColors bool, opts ...Option) (*Logger, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	out, closers, err := o.openOutput()
+	if err != nil {
+		return nil, err
+	}
+	hooks, hookClosers, err := o.newHooks()
+	if err != nil {
+		closeAll(closers)
+		return nil, err
+	}
+	closers = append(closers, hookClosers...)
+
 	log := &logrus.Logger{
-		Out:   os.Stderr,
-		Level: logrus.TraceLevel,
-		Hooks: make(logrus.LevelHooks), // ✅ prevents nil map panic
-		Formatter: &formatter.Formatter{
-			ForceColors:     forceColors,
-			TimestampFormat: "2006-01-02 15:04:05",
-			FullTimestamp:   true,
-		},
+		Out:       out,
+		Level:     o.logLevel(),
+		Hooks:     make(logrus.LevelHooks), // ✅ prevents nil map panic
+		Formatter: o.newFormatter(forceColors),
+	}
+	for _, hook := range hooks {
+		log.AddHook(hook)
 	}
 
 	return &Logger{
@@ -46,23 +78,168 @@ func New(name, version string, forceColors bool) (*Logger, error) {
 			"service": name,
 			"version": version,
 		}),
+		tenants: newTenantRegistry(),
+		backend: o.newBackend(out),
+		caller:  o.caller,
+		closers: closers,
 	}, nil
 }
 
+// ProductionSampling is the sampling of NewProduction: per second, the first
+// 100 identical info, debug or trace entries, then one in 100.
+var ProductionSampling = SamplingConfig{Tick: time.Second, First: 100, Thereafter: 100}
+
+// NewDevelopment returns a Logger for local development: colored text with
+// caller information, at trace level. LOG_FORMAT and LOG_LEVEL still take
+// precedence over these defaults, and opts over both.
+//
+// Example:
+//
+//	log, _ := logger.NewDevelopment("user-service", "dev")
+func NewDevelopment(name, version string, opts ...Option) (*Logger, error) {
+	defaults := []Option{WithCaller()}
+	if os.Getenv("LOG_FORMAT") == "" {
+		defaults = append(defaults, WithFormat(FormatText))
+	}
+	if os.Getenv("LOG_LEVEL") == "" {
+		defaults = append(defaults, WithLevel(logrus.TraceLevel.String()))
+	}
+	return New(name, version, true, append(defaults, opts...)...)
+}
+
+// NewProduction returns a Logger for deployed services: JSON at info level,
+// with ProductionSampling. LOG_FORMAT and LOG_LEVEL still take precedence
+// over these defaults, and opts over both.
+//
+// Example:
+//
+//	log, _ := logger.NewProduction("user-service", "1.0.0",
+//		logger.WithFieldPreset(formatter.DatadogPreset))
+func NewProduction(name, version string, opts ...Option) (*Logger, error) {
+	defaults := []Option{WithSampling(ProductionSampling)}
+	if os.Getenv("LOG_FORMAT") == "" {
+		defaults = append(defaults, WithFormat(FormatJSON))
+	}
+	if os.Getenv("LOG_LEVEL") == "" {
+		defaults = append(defaults, WithLevel(logrus.InfoLevel.String()))
+	}
+	return New(name, version, false, append(defaults, opts...)...)
+}
+
+// Close closes the outputs and hooks New opened, such as the file of
+// WithFile. The Logger must not be used afterwards.
+func (l *Logger) Close() error {
+	return closeAll(l.closers)
+}
+
+// Flush waits until the entries queued by WithAsync are written. It is a
+// no-op for synchronous outputs.
+func (l *Logger) Flush() error {
+	var errs []error
+	for _, c := range l.closers {
+		if f, ok := c.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeAll closes every closer and joins their errors.
+func closeAll(closers []io.Closer) error {
+	var errs []error
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithFields returns a child Logger adding fields to every entry. l is not
+// modified, so the child can be used per request or goroutine while l is
+// shared.
+//
+// Example:
+//
+//	jobLog := log.WithFields(map[string]interface{}{"job_id": id})
+//	jobLog.Info("job started")
+func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
+	child := *l
+	child.Entry = l.Entry.WithFields(fields)
+	return &child
+}
+
+// WithRequest returns a child Logger carrying the standard HTTP request
+// fields: method, origin, agent, size and resource. l is not modified.
+func (l *Logger) WithRequest(r *http.Request) *Logger {
+	if r == nil {
+		return l
+	}
+	return l.WithFields(requestFields(r))
+}
+
+// WithError returns a child Logger recording err as structured fields
+// rather than text in the message:
+//
+//   - error: err itself
+//   - error_chain: the messages of the errors err wraps, outermost first
+//   - error_stack: the frames where the failure originated, for errors
+//     created with the errtrace package
+//
+// A nil err returns l.
+//
+// Example:
+//
+//	log.WithError(errtrace.Wrap(err, "load user")).Error("request failed")
+func (l *Logger) WithError(err error) *Logger {
+	if err == nil {
+		return l
+	}
+	fields := logrus.Fields{logrus.ErrorKey: err}
+
+	var chain []string
+	for _, e := range errtrace.Chain(err)[1:] {
+		chain = append(chain, e.Error())
+	}
+	if len(chain) > 0 {
+		fields["error_chain"] = chain
+	}
+
+	var stack []string
+	for _, f := range errtrace.Stack(err) {
+		stack = append(stack, fmt.Sprintf("%s (%s:%d)", f.Function, f.File, f.Line))
+	}
+	if len(stack) > 0 {
+		fields["error_stack"] = stack
+	}
+	return l.WithFields(fields)
+}
+
 // Format attaches standard HTTP request fields to the logger entry for
 // contextual logging of incoming requests.
+//
+// Deprecated: Format modifies l, so the fields of one request leak into
+// every other user of the Logger. Use WithRequest, which returns a child
+// Logger.
 func (l *Logger) Format(r *http.Request) {
 	if r == nil {
 		return
 	}
 
-	l.Entry = l.Entry.WithFields(logrus.Fields{
+	l.Entry = l.Entry.WithFields(requestFields(r))
+}
+
+// requestFields returns the standard HTTP request fields of r.
+func requestFields(r *http.Request) logrus.Fields {
+	return logrus.Fields{
 		"method":   r.Method,
 		"origin":   r.RemoteAddr,
 		"agent":    r.Header.Get("User-Agent"),
 		"size":     r.ContentLength,
 		"resource": r.URL.Path,
-	})
+	}
 }
 
 // Middleware returns a Gin middleware that wraps requests with
@@ -72,9 +249,20 @@ func (l *Logger) Format(r *http.Request) {
 //   - request_id
 //   - method, path, client IP
 //   - status code and latency
-func (log *Logger) Middleware() gin.HandlerFunc {
+//   - response_size, the response body bytes, and request_size, the request
+//     Content-Length when known
+//
+// If a tenant is found in the request context, or in the header set with
+// WithTrustedTenantHeader, any override registered via SetTenantOverride is
+// applied. Options such as
+// WithSkipPaths reduce the noise of health checks and similar requests, and
+// WithStatusLevel and WithRouteLevel change the levels of the request logs,
+// debug and info by default.
+func (log *Logger) Middleware(opts ...MiddlewareOption) gin.HandlerFunc {
+	o := newMiddlewareOptions(opts)
 	return func(c *gin.Context) {
 		start := time.Now()
+		skip := o.skipRequest(c)
 
 		// -------------------------------------------------------------------
 		// 1. Handle request ID
@@ -85,34 +273,21 @@ func (log *Logger) Middleware() gin.HandlerFunc {
 		c.Writer.Header().Set("X-Request-ID", reqID)
 
 		// -------------------------------------------------------------------
-		// 2. Handle W3C Trace Context (traceparent)
-		traceParent := c.Request.Header.Get("traceparent")
-		var traceID, spanID string
-
-		if traceParent == "" {
-			// Generate new trace/span IDs
-			traceID = strings.ReplaceAll(uuid.New().String(), "-", "")
-			traceID = traceID[:32] // 16 bytes, hex-encoded
-			spanID = strings.ReplaceAll(uuid.New().String(), "-", "")
-			spanID = spanID[:16]
-			traceParent = "00-" + traceID + "-" + spanID + "-01"
+		// 2. Handle W3C Trace Context (traceparent). A missing or malformed
+		// traceparent starts a new sampled trace, and its tracestate is
+		// dropped; a valid one is continued with its trace flags
+		tc, err := reqctx.ParseTraceParent(c.Request.Header.Get("traceparent"))
+		if err != nil {
+			tc = reqctx.NewTraceContext()
 		} else {
-			// Parse traceparent header
-			parts := strings.Split(traceParent, "-")
-			if len(parts) >= 4 {
-				traceID = parts[1]
-				spanID = parts[2]
-			} else {
-				traceID = strings.ReplaceAll(uuid.New().String(), "-", "")[:32]
-				spanID = strings.ReplaceAll(uuid.New().String(), "-", "")[:16]
-				traceParent = "00-" + traceID + "-" + spanID + "-01"
-			}
+			tc.State = c.Request.Header.Get("tracestate")
 		}
+		traceID, spanID, traceParent, traceState := tc.TraceID, tc.SpanID, tc.String(), tc.State
 
 		// Always include trace headers in response for propagation
 		c.Writer.Header().Set("traceparent", traceParent)
-		if state := c.Request.Header.Get("tracestate"); state != "" {
-			c.Writer.Header().Set("tracestate", state)
+		if traceState != "" {
+			c.Writer.Header().Set("tracestate", traceState)
 		}
 
 		// -------------------------------------------------------------------
@@ -120,25 +295,55 @@ func (log *Logger) Middleware() gin.HandlerFunc {
 		rw := response.NewWriter(c.Writer)
 		c.Writer = rw
 
-		reqLogger := log.Entry.WithFields(map[string]interface{}{
+		var reqBody *capturedBody
+		var respBody *bodyWriter
+		if o.body != nil && !skip {
+			reqBody = o.body.captureRequest(c)
+			respBody = &bodyWriter{ResponseWriter: rw, cfg: o.body}
+			c.Writer = respBody
+		}
+
+		reqLogger := log.ForTenant(tenantFromRequest(c.Request, o.tenantHeader)).WithFields(map[string]interface{}{
 			"request_id": reqID,
 			"trace_id":   traceID,
 			"span_id":    spanID,
 		})
 
-		// Save to Gin context
-		c.Set("logger", log)
+		// Save to Gin context. The request logger carries the correlation IDs,
+		// so panics logged by the recovery middleware are tagged with them
+		c.Set("logger", reqLogger)
 		c.Set("request_id", reqID)
 		c.Set("trace_id", traceID)
 		c.Set("span_id", spanID)
-		c.Set("logger_entry", reqLogger)
+		c.Set("traceparent", traceParent)
+		c.Set("tracestate", traceState)
+		c.Set(EntryKey, reqLogger.Entry)
+
+		// Expose correlation IDs and the request logger to code that only sees
+		// the request context
+		ctx := reqctx.WithRequestMetadata(c.Request.Context(), reqctx.RequestMetadata{
+			RequestID:   reqID,
+			TraceID:     traceID,
+			SpanID:      spanID,
+			TraceParent: traceParent,
+			TraceState:  traceState,
+		})
+		ctx = reqctx.WithTraceContext(ctx, tc)
+		c.Request = c.Request.WithContext(NewContext(ctx, reqLogger))
 
 		// -------------------------------------------------------------------
 		// 4. Log start of request
-		reqLogger.WithFields(map[string]interface{}{
-			"method": c.Request.Method,
-			"path":   c.Request.URL.Path,
-		}).Debug("Request Received")
+		if !skip {
+			reqLogger.log(c.Request.Context(), Record{
+				PC:      reqLogger.pc(),
+				Level:   o.startLevel(c.Request.URL.Path),
+				Message: "Request Received",
+				Fields: logrus.Fields{
+					"method": c.Request.Method,
+					"path":   c.Request.URL.Path,
+				},
+			})
+		}
 
 		// Process the request
 		c.Next()
@@ -147,43 +352,117 @@ func (log *Logger) Middleware() gin.HandlerFunc {
 		// 5. Log completion
 		duration := time.Since(start)
 		status := rw.Status()
+		if o.access != nil {
+			o.access.write(&accessLogRequest{c: c, start: start, latency: duration, status: status, size: rw.BytesWritten()})
+		}
+		if skip || o.skipCompletion(c, status) {
+			return
+		}
+
+		fields := map[string]interface{}{}
+		if extra, ok := c.Get(FieldsKey); ok {
+			if m, ok := extra.(map[string]interface{}); ok {
+				for k, v := range m {
+					fields[k] = v
+				}
+			}
+		}
+		if o.body != nil {
+			for k, v := range o.body.fields(reqBody, respBody, c.Request.Header.Get("Content-Type")) {
+				fields[k] = v
+			}
+		}
+		fields["status"] = status
+		fields["response_size"] = rw.BytesWritten()
+		if c.Request.ContentLength >= 0 {
+			fields["request_size"] = c.Request.ContentLength
+		}
+		fields["method"] = c.Request.Method
+		fields["path"] = c.Request.URL.Path
+		fields["clientIP"] = c.ClientIP()
+		fields["latency"] = duration.String()
 
-		reqLogger.WithFields(map[string]interface{}{
-			"status":   status,
-			"method":   c.Request.Method,
-			"path":     c.Request.URL.Path,
-			"clientIP": c.ClientIP(),
-			"latency":  duration.String(),
-		}).Info("request completed")
+		reqLogger.log(c.Request.Context(), Record{
+			PC:      reqLogger.pc(),
+			Level:   o.completionLevel(c.Request.URL.Path, status),
+			Message: "request completed",
+			Fields:  fields,
+		})
 	}
 }
 
+// FieldsKey is the Gin context key holding extra fields for the
+// "request completed" log line. Use AddFields to populate it.
+const FieldsKey = "log_fields"
+
+// AddFields attaches fields to the completion log written by Middleware.
+// Handlers and downstream middleware can call it at any point during the
+// request; later calls overwrite earlier values for the same key.
+func AddFields(c *gin.Context, fields map[string]interface{}) {
+	merged := map[string]interface{}{}
+	if existing, ok := c.Get(FieldsKey); ok {
+		if m, ok := existing.(map[string]interface{}); ok {
+			merged = m
+		}
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	c.Set(FieldsKey, merged)
+}
+
+// SetLevel changes the minimum level logged, e.g. "info" or "debug", at
+// runtime. The change applies to every Logger derived from the same New call,
+// including tenant loggers without a level override of their own.
+func (l *Logger) SetLevel(level string) error {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+	l.Entry.Logger.SetLevel(lvl)
+
+	// Tenant loggers copy the base level when built; rebuild them lazily
+	if l.tenants != nil {
+		l.tenants.mu.Lock()
+		clear(l.tenants.loggers)
+		l.tenants.mu.Unlock()
+	}
+	return nil
+}
+
+// Level returns the current minimum level logged.
+func (l *Logger) Level() string {
+	return l.Entry.Logger.GetLevel().String()
+}
+
 // Info logs a message at info level.
 func (l *Logger) Info(msg string, args ...interface{}) {
-	l.Entry.Info(fmt.Sprintf(msg, args...))
+	l.log(context.Background(), Record{Level: logrus.InfoLevel, Message: fmt.Sprintf(msg, args...)})
 }
 
 // Error logs a message at error level.
 func (l *Logger) Error(msg string, args ...interface{}) {
-	l.Entry.Error(fmt.Sprintf(msg, args...))
+	l.log(context.Background(), Record{Level: logrus.ErrorLevel, Message: fmt.Sprintf(msg, args...)})
 }
 
 // Warn logs a message at warning level.
 func (l *Logger) Warn(msg string, args ...interface{}) {
-	l.Entry.Warn(fmt.Sprintf(msg, args...))
+	l.log(context.Background(), Record{Level: logrus.WarnLevel, Message: fmt.Sprintf(msg, args...)})
 }
 
 // Fatal logs a message at fatal level and exits the program.
 func (l *Logger) Fatal(msg string, args ...interface{}) {
-	l.Entry.Fatal(fmt.Sprintf(msg, args...))
+	l.log(context.Background(), Record{Level: logrus.FatalLevel, Message: fmt.Sprintf(msg, args...)})
+	_ = l.Flush()
+	l.Entry.Logger.Exit(1)
 }
 
 // Trace logs a message at trace level.
 func (l *Logger) Trace(msg string, args ...interface{}) {
-	l.Entry.Trace(fmt.Sprintf(msg, args...))
+	l.log(context.Background(), Record{Level: logrus.TraceLevel, Message: fmt.Sprintf(msg, args...)})
 }
 
 // Debug logs a message at debug level.
 func (l *Logger) Debug(msg string, args ...interface{}) {
-	l.Entry.Debug(fmt.Sprintf(msg, args...))
+	l.log(context.Background(), Record{Level: logrus.DebugLevel, Message: fmt.Sprintf(msg, args...)})
 }
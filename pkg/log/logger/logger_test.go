@@ -1,13 +1,18 @@
 package logger
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ranorsolutions/http-common-go/pkg/errtrace"
 	"github.com/ranorsolutions/http-common-go/pkg/log/formatter"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
 	"github.com/sirupsen/logrus"
 )
 
@@ -130,6 +135,51 @@ func TestRequestIDPropagation(t *testing.T) {
 	}
 }
 
+func TestMiddleware_StoresRequestMetadataInContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appLogger, _ := New("svc", "v1", true)
+
+	var md reqctx.RequestMetadata
+	r := gin.New()
+	r.Use(appLogger.Middleware())
+	r.GET("/test", func(c *gin.Context) {
+		md = reqctx.RequestMetadataFromContext(c.Request.Context())
+		c.String(http.StatusOK, "ok")
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if md.RequestID != "abc-123" || md.TraceID == "" || md.TraceParent == "" {
+		t.Errorf("expected request metadata in request context, got %+v", md)
+	}
+}
+
+func TestMiddleware_AddFieldsToCompletionLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appLogger, hook := newTestLogger()
+
+	r := gin.New()
+	r.Use(appLogger.Middleware())
+	r.GET("/test", func(c *gin.Context) {
+		AddFields(c, map[string]interface{}{"user_id": "u1"})
+		AddFields(c, map[string]interface{}{"cache": "hit", "status": "ignored"})
+		c.String(http.StatusOK, "ok")
+	})
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	last := hook.entries[len(hook.entries)-1]
+	if last.Data["user_id"] != "u1" || last.Data["cache"] != "hit" {
+		t.Errorf("expected extra fields on completion log, got %v", last.Data)
+	}
+	if last.Data["status"] != http.StatusOK {
+		t.Errorf("expected standard fields to win, got status %v", last.Data["status"])
+	}
+}
+
 // --- Log level method tests ---
 
 func TestLogLevelMethods(t *testing.T) {
@@ -215,6 +265,55 @@ func TestNewSetsFormatterAndLevel(t *testing.T) {
 	}
 }
 
+func TestNewDevelopment(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "")
+	t.Setenv("LOG_LEVEL", "")
+	l, err := NewDevelopment("svc", "dev")
+	if err != nil {
+		t.Fatalf("NewDevelopment: %v", err)
+	}
+	if l.Entry.Logger.Level != logrus.TraceLevel || !l.caller {
+		t.Errorf("expected trace level with caller info, got %v, caller %v", l.Entry.Logger.Level, l.caller)
+	}
+	if f, ok := l.Entry.Logger.Formatter.(*formatter.Formatter); !ok || !f.ForceColors {
+		t.Errorf("expected the colored text formatter, got %T", l.Entry.Logger.Formatter)
+	}
+}
+
+func TestNewProduction(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "")
+	t.Setenv("LOG_LEVEL", "")
+	l, err := NewProduction("svc", "1.0.0")
+	if err != nil {
+		t.Fatalf("NewProduction: %v", err)
+	}
+	if l.Entry.Logger.Level != logrus.InfoLevel {
+		t.Errorf("expected info level, got %v", l.Entry.Logger.Level)
+	}
+	s, ok := l.Entry.Logger.Formatter.(*burstSamplingFormatter)
+	if !ok {
+		t.Fatalf("expected sampling, got %T", l.Entry.Logger.Formatter)
+	}
+	if _, ok := s.inner.(*formatter.JSONFormatter); !ok {
+		t.Errorf("expected the JSON formatter, got %T", s.inner)
+	}
+}
+
+func TestNewProduction_EnvAndOptions(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "")
+	t.Setenv("LOG_LEVEL", "debug")
+	l, err := NewProduction("svc", "1.0.0", WithFormat(FormatLogfmt))
+	if err != nil {
+		t.Fatalf("NewProduction: %v", err)
+	}
+	if l.Entry.Logger.Level != logrus.DebugLevel {
+		t.Errorf("expected LOG_LEVEL to take precedence, got %v", l.Entry.Logger.Level)
+	}
+	if f, ok := l.Entry.Logger.Formatter.(*burstSamplingFormatter).inner.(*formatter.Formatter); !ok || !f.Logfmt {
+		t.Errorf("expected options to take precedence")
+	}
+}
+
 func TestFormatAddsAllExpectedKeys(t *testing.T) {
 	logger, _ := New("svc", "v1", true)
 	req, _ := http.NewRequest("GET", "http://localhost:8080/foo", nil)
@@ -239,3 +338,107 @@ func TestDefaultKeysPresent(t *testing.T) {
 		}
 	}
 }
+
+func TestSetLevel(t *testing.T) {
+	logger, hook := newTestLogger()
+	if err := logger.SetTenantOverride("acme", TenantOverride{SampleRate: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.ForTenant("acme").Debug("before")
+
+	if err := logger.SetLevel("info"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.Debug("dropped")
+	logger.ForTenant("acme").Debug("dropped")
+	logger.Info("kept")
+
+	var messages []string
+	for _, e := range hook.entries {
+		messages = append(messages, e.Message)
+	}
+	if len(messages) != 2 || messages[0] != "before" || messages[1] != "kept" {
+		t.Errorf("expected debug entries to be dropped after SetLevel, got %v", messages)
+	}
+
+	if err := logger.SetLevel("loud"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+	if logger.Level() != "info" {
+		t.Errorf("expected an invalid level to be ignored, got %s", logger.Level())
+	}
+}
+
+// --- WithFields() and WithRequest() tests ---
+
+func TestWithFieldsDoesNotModifyParent(t *testing.T) {
+	logger, hook := newTestLogger()
+
+	child := logger.WithFields(map[string]interface{}{"job_id": "j1"})
+	child.Info("child")
+	logger.Info("parent")
+
+	if _, ok := logger.Entry.Data["job_id"]; ok {
+		t.Error("expected the parent logger to be unchanged")
+	}
+	if hook.entries[0].Data["job_id"] != "j1" || hook.entries[0].Data["service"] != "test-service" {
+		t.Errorf("expected child fields, got %v", hook.entries[0].Data)
+	}
+	if _, ok := hook.entries[1].Data["job_id"]; ok {
+		t.Errorf("expected no child fields on the parent entry, got %v", hook.entries[1].Data)
+	}
+}
+
+func TestWithRequest(t *testing.T) {
+	logger, _ := newTestLogger()
+	req, _ := http.NewRequest("GET", "http://localhost:8080/foo", nil)
+	req.Header.Set("User-Agent", "test-agent")
+
+	child := logger.WithRequest(req)
+	for _, k := range []string{"method", "origin", "agent", "size", "resource"} {
+		if _, ok := child.Entry.Data[k]; !ok {
+			t.Errorf("expected key %s on the child logger", k)
+		}
+		if _, ok := logger.Entry.Data[k]; ok {
+			t.Errorf("expected key %s to stay off the parent logger", k)
+		}
+	}
+	if logger.WithRequest(nil) != logger {
+		t.Error("expected a nil request to return the logger itself")
+	}
+}
+
+func TestWithError(t *testing.T) {
+	logger, hook := newTestLogger()
+
+	cause := errors.New("connection refused")
+	err := errtrace.Wrap(fmt.Errorf("query users: %w", cause), "load user")
+	logger.WithError(err).Error("request failed")
+
+	data := hook.entries[0].Data
+	if data[logrus.ErrorKey] != err {
+		t.Errorf("expected the error itself, got %v", data[logrus.ErrorKey])
+	}
+	chain, _ := data["error_chain"].([]string)
+	if len(chain) != 2 || chain[0] != "query users: connection refused" || chain[1] != "connection refused" {
+		t.Errorf("unexpected error_chain %v", chain)
+	}
+	stack, _ := data["error_stack"].([]string)
+	if len(stack) == 0 || !strings.Contains(stack[0], "logger.TestWithError") || !strings.Contains(stack[0], "logger_test.go:") {
+		t.Errorf("unexpected error_stack %v", stack)
+	}
+	if hook.entries[0].Message != "request failed" {
+		t.Errorf("expected the message to stay unchanged, got %q", hook.entries[0].Message)
+	}
+
+	logger.WithError(cause).Warn("plain")
+	if _, ok := hook.entries[1].Data["error_chain"]; ok {
+		t.Error("expected no error_chain for an unwrapped error")
+	}
+	if _, ok := hook.entries[1].Data["error_stack"]; ok {
+		t.Error("expected no error_stack without errtrace")
+	}
+	if logger.WithError(nil) != logger {
+		t.Error("expected a nil error to return the logger itself")
+	}
+}
@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LevelMetrics receives a call per entry logged, so alerts such as "error
+// log rate" can use a metrics backend instead of parsing log streams.
+// Implementations typically increment a counter labeled by logger and
+// level (a Prometheus CounterVec, a StatsD counter, ...); LevelCounter
+// counts in memory.
+type LevelMetrics interface {
+	// EntryLogged is called for each entry at or above the Logger level.
+	// logger is the service name given to New, or the value of the logger
+	// field when set.
+	EntryLogged(logger string, level logrus.Level)
+}
+
+// WithLevelMetrics reports every entry to m, whatever the backend.
+// Entries dropped by tenant sampling are counted as well.
+//
+// Example:
+//
+//	counter := logger.NewLevelCounter()
+//	expvar.Publish("log_entries", counter)
+//	log, _ := logger.New("user-service", "1.0.0", false, logger.WithLevelMetrics(counter))
+func WithLevelMetrics(m LevelMetrics) Option {
+	return func(o *options) { o.metrics = m }
+}
+
+// levelMetricsHook reports entries to a LevelMetrics.
+type levelMetricsHook struct {
+	metrics LevelMetrics
+}
+
+// Levels implements logrus.Hook.
+func (h levelMetricsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook.
+func (h levelMetricsHook) Fire(entry *logrus.Entry) error {
+	name, ok := entry.Data["logger"]
+	if !ok {
+		name = entry.Data["service"]
+	}
+	logger, _ := name.(string)
+	h.metrics.EntryLogged(logger, entry.Level)
+	return nil
+}
+
+// levelCounterKey identifies a counter of a LevelCounter.
+type levelCounterKey struct {
+	logger string
+	level  logrus.Level
+}
+
+// LevelCounter is a LevelMetrics counting entries per logger and level in
+// memory. It implements expvar.Var, so it can be published as is. It is
+// safe for concurrent use.
+type LevelCounter struct {
+	mu     sync.Mutex
+	counts map[levelCounterKey]uint64
+}
+
+// NewLevelCounter returns an empty LevelCounter.
+func NewLevelCounter() *LevelCounter {
+	return &LevelCounter{counts: map[levelCounterKey]uint64{}}
+}
+
+// EntryLogged implements LevelMetrics.
+func (c *LevelCounter) EntryLogged(logger string, level logrus.Level) {
+	c.mu.Lock()
+	c.counts[levelCounterKey{logger: logger, level: level}]++
+	c.mu.Unlock()
+}
+
+// Count returns the number of entries logged by logger at level.
+func (c *LevelCounter) Count(logger string, level logrus.Level) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[levelCounterKey{logger: logger, level: level}]
+}
+
+// Snapshot returns the counts by logger, then by level name, e.g.
+// {"user-service": {"error": 3, "info": 120}}.
+func (c *LevelCounter) Snapshot() map[string]map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snapshot := map[string]map[string]uint64{}
+	for k, n := range c.counts {
+		if snapshot[k.logger] == nil {
+			snapshot[k.logger] = map[string]uint64{}
+		}
+		snapshot[k.logger][k.level.String()] = n
+	}
+	return snapshot
+}
+
+// String implements expvar.Var, returning the Snapshot as JSON.
+func (c *LevelCounter) String() string {
+	b, err := json.Marshal(c.Snapshot())
+	if err != nil {
+		return fmt.Sprintf("%q", err.Error())
+	}
+	return string(b)
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithLevelMetrics(t *testing.T) {
+	counter := NewLevelCounter()
+	l, err := New("metrics-service", "1.0.0", false, WithLevelMetrics(counter), WithLevel("info"), WithOutput(io.Discard))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	l.Info("one")
+	l.Info("two")
+	l.Error("three")
+	l.Debug("below the level")
+	l.WithFields(map[string]interface{}{"logger": "worker"}).Warn("four")
+
+	if n := counter.Count("metrics-service", logrus.InfoLevel); n != 2 {
+		t.Errorf("expected 2 info entries, got %d", n)
+	}
+	if n := counter.Count("metrics-service", logrus.ErrorLevel); n != 1 {
+		t.Errorf("expected 1 error entry, got %d", n)
+	}
+	if n := counter.Count("metrics-service", logrus.DebugLevel); n != 0 {
+		t.Errorf("expected disabled levels not counted, got %d", n)
+	}
+	if n := counter.Count("worker", logrus.WarnLevel); n != 1 {
+		t.Errorf("expected the logger field to name the logger, got %d", n)
+	}
+}
+
+func TestLevelCounter_String(t *testing.T) {
+	counter := NewLevelCounter()
+	counter.EntryLogged("svc", logrus.ErrorLevel)
+	counter.EntryLogged("svc", logrus.ErrorLevel)
+	counter.EntryLogged("svc", logrus.InfoLevel)
+
+	var got map[string]map[string]uint64
+	if err := json.Unmarshal([]byte(counter.String()), &got); err != nil {
+		t.Fatalf("expected JSON, got %q: %v", counter.String(), err)
+	}
+	if got["svc"]["error"] != 2 || got["svc"]["info"] != 1 {
+		t.Errorf("unexpected snapshot %v", got)
+	}
+}
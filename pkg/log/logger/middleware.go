@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// MiddlewareOption configures the request logs written by Middleware.
+type MiddlewareOption func(*middlewareOptions)
+
+// middlewareOptions holds the settings applied by MiddlewareOption.
+type middlewareOptions struct {
+	skipPaths    map[string]bool
+	skipStatuses [6]bool
+	skip         []func(c *gin.Context) bool
+	body         *BodyLogConfig
+	access       *accessLog
+	statusLevels map[int]logrus.Level
+	routeLevels  []routeLevel
+	tenantHeader string
+}
+
+// routeLevel is a rule of WithRouteLevel.
+type routeLevel struct {
+	pattern string
+	level   logrus.Level
+}
+
+// newMiddlewareOptions applies opts.
+func newMiddlewareOptions(opts []MiddlewareOption) *middlewareOptions {
+	o := &middlewareOptions{skipPaths: map[string]bool{}, statusLevels: map[int]logrus.Level{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithSkipPaths disables the request logs of the given URL paths, such as
+// /healthz or /metrics. Paths match exactly; the request ID, trace headers
+// and request logger are still set up, so handlers log as usual.
+//
+// Example:
+//
+//	r.Use(log.Middleware(logger.WithSkipPaths("/healthz", "/metrics")))
+func WithSkipPaths(paths ...string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		for _, p := range paths {
+			o.skipPaths[p] = true
+		}
+	}
+}
+
+// WithSkipStatusClasses disables the completion log of responses whose
+// status is in one of the given classes, e.g. 2 for 2xx and 3 for 3xx.
+func WithSkipStatusClasses(classes ...int) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		for _, class := range classes {
+			if class >= 1 && class <= 5 {
+				o.skipStatuses[class] = true
+			}
+		}
+	}
+}
+
+// WithSkip disables the completion log of requests for which skip returns
+// true. It is called after the handler, so it can inspect the response
+// status with c.Writer.Status().
+//
+// Example:
+//
+//	// Log only failed or mutating requests
+//	logger.WithSkip(func(c *gin.Context) bool {
+//		return c.Request.Method == http.MethodGet && c.Writer.Status() < 400
+//	})
+func WithSkip(skip func(c *gin.Context) bool) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		if skip != nil {
+			o.skip = append(o.skip, skip)
+		}
+	}
+}
+
+// WithStatusLevel logs the completion of responses whose status is in
+// class, e.g. 5 for 5xx, at level instead of info.
+//
+// Example:
+//
+//	r.Use(log.Middleware(
+//		logger.WithStatusLevel(2, logrus.DebugLevel),
+//		logger.WithStatusLevel(4, logrus.WarnLevel),
+//		logger.WithStatusLevel(5, logrus.ErrorLevel),
+//	))
+func WithStatusLevel(class int, level logrus.Level) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		if class >= 1 && class <= 5 {
+			o.statusLevels[class] = level
+		}
+	}
+}
+
+// WithRouteLevel logs both request logs of the URL paths matching pattern
+// at level, whatever the status. Patterns use path.Match syntax, and a
+// trailing "/*" matches any depth, e.g. "/internal/*" matches
+// /internal/jobs/1. The first matching pattern wins; route levels take
+// precedence over WithStatusLevel.
+func WithRouteLevel(pattern string, level logrus.Level) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.routeLevels = append(o.routeLevels, routeLevel{pattern: pattern, level: level})
+	}
+}
+
+// WithTrustedTenantHeader resolves the tenant from header, e.g.
+// TenantHeader, when none was stored in the request context. Clients
+// control request headers, so only use it behind a gateway that sets or
+// strips header; otherwise any caller could route its logs to the sink and
+// level of another tenant.
+func WithTrustedTenantHeader(header string) MiddlewareOption {
+	return func(o *middlewareOptions) { o.tenantHeader = header }
+}
+
+// routeLevel returns the level of the first WithRouteLevel rule matching
+// urlPath.
+func (o *middlewareOptions) routeLevel(urlPath string) (logrus.Level, bool) {
+	for _, r := range o.routeLevels {
+		if prefix, ok := strings.CutSuffix(r.pattern, "/*"); ok && strings.HasPrefix(urlPath, prefix+"/") {
+			return r.level, true
+		}
+		if ok, _ := path.Match(r.pattern, urlPath); ok {
+			return r.level, true
+		}
+	}
+	return 0, false
+}
+
+// startLevel returns the level of the "Request Received" log.
+func (o *middlewareOptions) startLevel(urlPath string) logrus.Level {
+	if level, ok := o.routeLevel(urlPath); ok {
+		return level
+	}
+	return logrus.DebugLevel
+}
+
+// completionLevel returns the level of the "request completed" log.
+func (o *middlewareOptions) completionLevel(urlPath string, status int) logrus.Level {
+	if level, ok := o.routeLevel(urlPath); ok {
+		return level
+	}
+	if level, ok := o.statusLevels[status/100]; ok {
+		return level
+	}
+	return logrus.InfoLevel
+}
+
+// skipRequest reports whether all logs of the request are disabled. It is
+// decided before the handler runs.
+func (o *middlewareOptions) skipRequest(c *gin.Context) bool {
+	return o.skipPaths[c.Request.URL.Path]
+}
+
+// skipCompletion reports whether the completion log of the request with
+// the given status is disabled.
+func (o *middlewareOptions) skipCompletion(c *gin.Context, status int) bool {
+	if class := status / 100; class >= 1 && class <= 5 && o.skipStatuses[class] {
+		return true
+	}
+	for _, skip := range o.skip {
+		if skip(c) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/sirupsen/logrus"
+)
+
+// serveMiddleware serves a request for path through Middleware with opts
+// and returns the messages logged.
+func serveMiddleware(t *testing.T, method, path string, status int, opts ...MiddlewareOption) []string {
+	t.Helper()
+	var msgs []string
+	for _, e := range serveMiddlewareEntries(t, method, path, status, opts...) {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+// serveMiddlewareEntries is serveMiddleware returning the entries logged.
+func serveMiddlewareEntries(t *testing.T, method, path string, status int, opts ...MiddlewareOption) []*logrus.Entry {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	appLogger, hook := newTestLogger()
+
+	r := gin.New()
+	r.Use(appLogger.Middleware(opts...))
+	r.Handle(method, path, func(c *gin.Context) {
+		if c.GetString("request_id") == "" {
+			t.Error("expected request_id to be set for skipped requests too")
+		}
+		c.Status(status)
+	})
+
+	req, _ := http.NewRequest(method, path, nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return hook.entries
+}
+
+func TestMiddleware_NoOptionsLogsBoth(t *testing.T) {
+	msgs := serveMiddleware(t, http.MethodGet, "/users", http.StatusOK)
+	if len(msgs) != 2 || msgs[0] != "Request Received" || msgs[1] != "request completed" {
+		t.Errorf("expected start and completion logs, got %v", msgs)
+	}
+}
+
+func TestMiddleware_SkipPaths(t *testing.T) {
+	opt := WithSkipPaths("/healthz", "/metrics")
+
+	if msgs := serveMiddleware(t, http.MethodGet, "/healthz", http.StatusOK, opt); len(msgs) != 0 {
+		t.Errorf("expected no logs for a skipped path, got %v", msgs)
+	}
+	if msgs := serveMiddleware(t, http.MethodGet, "/healthz/deep", http.StatusOK, opt); len(msgs) != 2 {
+		t.Errorf("expected paths to match exactly, got %v", msgs)
+	}
+}
+
+func TestMiddleware_SkipStatusClasses(t *testing.T) {
+	opt := WithSkipStatusClasses(2, 3)
+
+	if msgs := serveMiddleware(t, http.MethodGet, "/users", http.StatusNoContent, opt); len(msgs) != 1 {
+		t.Errorf("expected only the start log for a 2xx, got %v", msgs)
+	}
+	if msgs := serveMiddleware(t, http.MethodGet, "/users", http.StatusFound, opt); len(msgs) != 1 {
+		t.Errorf("expected only the start log for a 3xx, got %v", msgs)
+	}
+	if msgs := serveMiddleware(t, http.MethodGet, "/users", http.StatusInternalServerError, opt); len(msgs) != 2 {
+		t.Errorf("expected the completion log for a 5xx, got %v", msgs)
+	}
+}
+
+func TestMiddleware_Skip(t *testing.T) {
+	opt := WithSkip(func(c *gin.Context) bool {
+		return c.Request.Method == http.MethodGet && c.Writer.Status() < 400
+	})
+
+	if msgs := serveMiddleware(t, http.MethodGet, "/users", http.StatusOK, opt); len(msgs) != 1 {
+		t.Errorf("expected the completion log to be skipped, got %v", msgs)
+	}
+	if msgs := serveMiddleware(t, http.MethodGet, "/users", http.StatusNotFound, opt); len(msgs) != 2 {
+		t.Errorf("expected the completion log of a 404, got %v", msgs)
+	}
+	if msgs := serveMiddleware(t, http.MethodPost, "/users", http.StatusCreated, opt); len(msgs) != 2 {
+		t.Errorf("expected the completion log of a POST, got %v", msgs)
+	}
+
+	if msgs := serveMiddleware(t, http.MethodGet, "/users", http.StatusOK, WithSkip(nil)); len(msgs) != 2 {
+		t.Errorf("expected a nil predicate to be ignored, got %v", msgs)
+	}
+}
+
+func TestMiddleware_Levels(t *testing.T) {
+	opts := []MiddlewareOption{
+		WithStatusLevel(2, logrus.DebugLevel),
+		WithStatusLevel(4, logrus.InfoLevel),
+		WithStatusLevel(5, logrus.ErrorLevel),
+		WithRouteLevel("/internal/*", logrus.TraceLevel),
+		WithRouteLevel("/jobs/?", logrus.WarnLevel),
+	}
+
+	tests := []struct {
+		path          string
+		status        int
+		start, finish logrus.Level
+	}{
+		{"/users", http.StatusOK, logrus.DebugLevel, logrus.DebugLevel},
+		{"/users", http.StatusFound, logrus.DebugLevel, logrus.InfoLevel},
+		{"/users", http.StatusNotFound, logrus.DebugLevel, logrus.InfoLevel},
+		{"/users", http.StatusBadGateway, logrus.DebugLevel, logrus.ErrorLevel},
+		{"/internal/jobs/1", http.StatusInternalServerError, logrus.TraceLevel, logrus.TraceLevel},
+		{"/internal", http.StatusOK, logrus.DebugLevel, logrus.DebugLevel},
+		{"/jobs/1", http.StatusOK, logrus.WarnLevel, logrus.WarnLevel},
+	}
+	for _, tc := range tests {
+		entries := serveMiddlewareEntries(t, http.MethodGet, tc.path, tc.status, opts...)
+		if len(entries) != 2 {
+			t.Fatalf("%s %d: expected 2 entries, got %d", tc.path, tc.status, len(entries))
+		}
+		if entries[0].Level != tc.start || entries[1].Level != tc.finish {
+			t.Errorf("%s %d: expected levels %s/%s, got %s/%s",
+				tc.path, tc.status, tc.start, tc.finish, entries[0].Level, entries[1].Level)
+		}
+	}
+
+	entries := serveMiddlewareEntries(t, http.MethodGet, "/users", http.StatusInternalServerError)
+	if entries[0].Level != logrus.DebugLevel || entries[1].Level != logrus.InfoLevel {
+		t.Errorf("expected debug/info without rules, got %s/%s", entries[0].Level, entries[1].Level)
+	}
+}
+
+func TestMiddleware_Sizes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appLogger, hook := newTestLogger()
+
+	r := gin.New()
+	r.Use(appLogger.Middleware())
+	r.POST("/echo", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, "text/plain", append(body, body...))
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("hello")))
+
+	completed := hook.entries[len(hook.entries)-1]
+	if completed.Data["request_size"] != int64(5) || completed.Data["response_size"] != 10 {
+		t.Errorf("expected request_size 5 and response_size 10, got %v and %v",
+			completed.Data["request_size"], completed.Data["response_size"])
+	}
+}
+
+func TestMiddleware_TraceParent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appLogger, _ := newTestLogger()
+
+	var got reqctx.TraceContext
+	r := gin.New()
+	r.Use(appLogger.Middleware())
+	r.GET("/", func(c *gin.Context) {
+		got, _ = reqctx.TraceContextFromContext(c.Request.Context())
+	})
+
+	serve := func(traceParent, traceState string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("traceparent", traceParent)
+		req.Header.Set("tracestate", traceState)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	incoming := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"
+	rec := serve(incoming, "vendor=1")
+	if rec.Header().Get("traceparent") != incoming || rec.Header().Get("tracestate") != "vendor=1" {
+		t.Errorf("expected the incoming trace and flags to be kept, got %q %q",
+			rec.Header().Get("traceparent"), rec.Header().Get("tracestate"))
+	}
+	if got.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || got.Sampled() || got.State != "vendor=1" {
+		t.Errorf("unexpected trace context %+v", got)
+	}
+
+	rec = serve("00-not-valid-01", "vendor=1")
+	if got.TraceID == "" || got.TraceID == "not" || !got.Sampled() || rec.Header().Get("tracestate") != "" {
+		t.Errorf("expected a new sampled trace without tracestate, got %+v", got)
+	}
+	if rec.Header().Get("traceparent") != got.String() {
+		t.Errorf("expected the new traceparent in the response, got %q", rec.Header().Get("traceparent"))
+	}
+}
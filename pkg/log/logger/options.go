@@ -0,0 +1,390 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ranorsolutions/http-common-go/pkg/log/formatter"
+	"github.com/ranorsolutions/http-common-go/pkg/log/sentryhook"
+	"github.com/ranorsolutions/http-common-go/pkg/log/ship"
+	"github.com/ranorsolutions/http-common-go/pkg/log/syslog"
+	"github.com/ranorsolutions/http-common-go/pkg/log/writer"
+	"github.com/sirupsen/logrus"
+)
+
+// Output formats accepted by WithFormat and the LOG_FORMAT environment
+// variable.
+const (
+	// FormatText is the colored key:value layout of formatter.Formatter.
+	FormatText = "text"
+
+	// FormatJSON writes one JSON object per line via formatter.JSONFormatter.
+	FormatJSON = "json"
+
+	// FormatLogfmt writes logfmt key=value lines via formatter.Formatter.
+	FormatLogfmt = "logfmt"
+)
+
+// Option configures a Logger built by New.
+type Option func(*options)
+
+type options struct {
+	format      string
+	level       string
+	backendName string
+	backend     Backend
+	redactor    *formatter.Redactor
+	outputs     []io.Writer
+	file        *writer.FileConfig
+	hooks       []logrus.Hook
+	preset      *formatter.FieldPreset
+	async       *writer.AsyncConfig
+	caller      bool
+	metrics     LevelMetrics
+
+	levelWriters []LevelWriter
+	sampling     *SamplingConfig
+}
+
+// WithFormat selects the output format, FormatText, FormatJSON or
+// FormatLogfmt. It takes precedence over LOG_FORMAT.
+func WithFormat(format string) Option {
+	return func(o *options) { o.format = format }
+}
+
+// WithJSON is shorthand for WithFormat(FormatJSON).
+func WithJSON() Option {
+	return WithFormat(FormatJSON)
+}
+
+// WithLevel sets the minimum level logged, e.g. "info" or "debug". It takes
+// precedence over LOG_LEVEL. The default is "trace".
+func WithLevel(level string) Option {
+	return func(o *options) { o.level = level }
+}
+
+// WithBackend writes entries through b instead of Logrus, e.g. a
+// NewSlogBackend. Formatter options and tenant overrides other than the
+// level apply to the Logrus backend only. It takes precedence over
+// LOG_BACKEND.
+func WithBackend(b Backend) Option {
+	return func(o *options) { o.backend = b }
+}
+
+// WithOutput writes entries to the given writers instead of stderr, e.g.
+// WithOutput(os.Stdout) or WithOutput(os.Stderr, socket).
+func WithOutput(w ...io.Writer) Option {
+	return func(o *options) { o.outputs = w }
+}
+
+// WithFile also writes entries to a file rotated according to cfg, next to
+// stderr or the writers of WithOutput. It takes precedence over LOG_FILE.
+// Call Logger.Close on shutdown to close the file.
+//
+// Example:
+//
+//	log, _ := logger.New("user-service", "1.0.0", false, logger.WithFile(writer.FileConfig{
+//		Filename:   "/var/log/user-service/app.log",
+//		MaxSize:    50 << 20,
+//		MaxBackups: 7,
+//		Compress:   true,
+//	}))
+//	defer log.Close()
+func WithFile(cfg writer.FileConfig) Option {
+	return func(o *options) { o.file = &cfg }
+}
+
+// WithAsync writes entries from a background goroutine through a
+// writer.AsyncWriter, so logging calls do not wait on the outputs. It takes
+// precedence over LOG_ASYNC. Call Logger.Close on shutdown, or Logger.Flush
+// before exiting, so queued entries are written; Fatal flushes itself.
+func WithAsync(cfg writer.AsyncConfig) Option {
+	return func(o *options) { o.async = &cfg }
+}
+
+// WithCaller adds the file, line and function of the call site to every
+// entry, as the CallerKey and FunctionKey fields. Entries written through
+// Logger methods, FromContext and Slog point at the calling code rather than
+// this package. LOG_CALLER=true has the same effect.
+func WithCaller() Option {
+	return func(o *options) { o.caller = true }
+}
+
+// WithRedactor masks sensitive field values and message fragments with r
+// before entries are written or passed to hooks, whatever the backend. Use
+// formatter.NewRedactor for the default list: authorization, cookie,
+// password, secret, token and API keys.
+//
+// Example:
+//
+//	log, _ := logger.New("user-service", "1.0.0", false,
+//		logger.WithRedactor(formatter.NewRedactor("ssn")))
+func WithRedactor(r *formatter.Redactor) Option {
+	return func(o *options) { o.redactor = r }
+}
+
+// WithHook adds Logrus hooks, such as syslog.NewHook. Hooks fire for every
+// backend, after redaction.
+func WithHook(hooks ...logrus.Hook) Option {
+	return func(o *options) { o.hooks = append(o.hooks, hooks...) }
+}
+
+// WithFieldPreset renames the JSON output fields to the schema of a log
+// backend: formatter.ECSPreset, formatter.DatadogPreset or
+// formatter.GCPPreset. It implies FormatJSON and takes precedence over
+// LOG_FIELD_PRESET.
+//
+// Example:
+//
+//	log, _ := logger.New("user-service", "1.0.0", false,
+//		logger.WithFieldPreset(formatter.GCPPreset(os.Getenv("GOOGLE_CLOUD_PROJECT"))))
+func WithFieldPreset(p *formatter.FieldPreset) Option {
+	return func(o *options) { o.preset = p }
+}
+
+// newOptions reads the defaults from the environment and applies opts on top.
+//
+// Environment variables:
+//
+//	LOG_FORMAT           text (default), json or logfmt
+//	LOG_LEVEL            minimum level: trace (default), debug, info, warn, error, fatal or panic
+//	LOG_BACKEND          logrus (default) or slog, writing LOG_FORMAT through log/slog
+//	LOG_FIELD_PRESET     ecs, datadog or gcp field names, implying json; gcp reads GOOGLE_CLOUD_PROJECT
+//	LOG_CALLER           add the caller and function fields (default false)
+//	LOG_FILE             also write to this file; see writer.NewFileConfigFromEnv for rotation
+//	LOG_ASYNC            write from a background goroutine; see writer.NewAsyncConfigFromEnv
+//	LOG_SYSLOG_ADDR      also send to syslog; see syslog.NewConfigFromEnv
+//	LOG_JOURNALD         also send to systemd-journald (default false)
+//	LOG_JOURNALD_SOCKET  journal socket (default /run/systemd/journal/socket)
+//	SENTRY_DSN           also report errors to Sentry; see sentryhook.NewConfigFromEnv
+//	LOG_LOKI_URL         also ship entries to Loki; see ship.NewLokiConfigFromEnv
+func newOptions(opts []Option) (*options, error) {
+	caller, _ := strconv.ParseBool(os.Getenv("LOG_CALLER"))
+	o := &options{
+		caller:      caller,
+		format:      strings.ToLower(os.Getenv("LOG_FORMAT")),
+		level:       os.Getenv("LOG_LEVEL"),
+		backendName: strings.ToLower(os.Getenv("LOG_BACKEND")),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.level == "" {
+		o.level = logrus.TraceLevel.String()
+	}
+	if _, err := logrus.ParseLevel(o.level); err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+	if o.preset == nil {
+		switch preset := strings.ToLower(os.Getenv("LOG_FIELD_PRESET")); preset {
+		case "":
+		case formatter.ECSPreset.Name:
+			o.preset = formatter.ECSPreset
+		case formatter.DatadogPreset.Name:
+			o.preset = formatter.DatadogPreset
+		case "gcp":
+			o.preset = formatter.GCPPreset(os.Getenv("GOOGLE_CLOUD_PROJECT"))
+		default:
+			return nil, fmt.Errorf("invalid LOG_FIELD_PRESET %q: must be ecs, datadog or gcp", preset)
+		}
+	}
+	if o.preset != nil {
+		if o.format != "" && o.format != FormatJSON {
+			return nil, fmt.Errorf("field preset %q requires the %s format", o.preset.Name, FormatJSON)
+		}
+		o.format = FormatJSON
+	}
+	if o.format == "" {
+		o.format = FormatText
+	}
+	if o.format != FormatText && o.format != FormatJSON && o.format != FormatLogfmt {
+		return nil, fmt.Errorf("invalid log format %q: must be %s, %s or %s", o.format, FormatText, FormatJSON, FormatLogfmt)
+	}
+	if o.backendName != "" && o.backendName != BackendLogrus && o.backendName != BackendSlog {
+		return nil, fmt.Errorf("invalid log backend %q: must be %s or %s", o.backendName, BackendLogrus, BackendSlog)
+	}
+	if o.sampling != nil {
+		if err := o.sampling.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if async, _ := strconv.ParseBool(os.Getenv("LOG_ASYNC")); o.async == nil && async {
+		cfg, err := writer.NewAsyncConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		o.async = cfg
+	}
+	if o.file == nil && os.Getenv("LOG_FILE") != "" {
+		cfg, err := writer.NewFileConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		o.file = cfg
+	}
+	return o, nil
+}
+
+// openOutput returns the writer entries go to: the configured outputs, or
+// stderr, plus the log file if any, behind an async writer if configured. The returned closers release what was
+// opened here.
+func (o *options) openOutput() (io.Writer, []io.Closer, error) {
+	outputs := o.outputs
+	if len(outputs) == 0 && len(o.levelWriters) == 0 {
+		outputs = []io.Writer{os.Stderr}
+	}
+	var closers []io.Closer
+	if o.file != nil {
+		f, err := writer.NewRotatingFile(*o.file)
+		if err != nil {
+			return nil, nil, err
+		}
+		outputs = append(outputs[:len(outputs):len(outputs)], f)
+		closers = append(closers, f)
+	}
+	if len(outputs) == 0 {
+		// Entries only go to the level writers
+		return io.Discard, closers, nil
+	}
+	out := outputs[0]
+	if len(outputs) > 1 {
+		out = writer.NewMultiWriter(outputs...)
+	}
+	if o.async != nil {
+		// Close the async writer first so its queue drains into the file
+		a := writer.NewAsyncWriter(out, *o.async)
+		out, closers = a, append([]io.Closer{a}, closers...)
+	}
+	return out, closers, nil
+}
+
+// newBackend returns the configured backend writing to out, or nil for
+// Logrus.
+func (o *options) newBackend(out io.Writer) Backend {
+	if o.backend == nil && o.backendName == BackendSlog {
+		return newEnvSlogBackend(o.format, out)
+	}
+	return o.backend
+}
+
+// newHooks returns the hooks to install, in order: redaction first so that
+// no other hook sees sensitive values, level metrics, those of WithHook, the
+// level writers, then the syslog, journal, Sentry and Loki hooks configured
+// in the environment. The returned closers release the hooks opened here.
+func (o *options) newHooks() ([]logrus.Hook, []io.Closer, error) {
+	var hooks []logrus.Hook
+	if o.redactor != nil {
+		hooks = append(hooks, redactHook{redactor: o.redactor})
+	}
+	if o.metrics != nil {
+		hooks = append(hooks, levelMetricsHook{metrics: o.metrics})
+	}
+	hooks = append(hooks, o.hooks...)
+	for _, lw := range o.levelWriters {
+		hooks = append(hooks, newLevelWriterHook(lw))
+	}
+
+	var closers []io.Closer
+	if os.Getenv("LOG_SYSLOG_ADDR") != "" {
+		cfg, err := syslog.NewConfigFromEnv()
+		if err != nil {
+			return nil, nil, err
+		}
+		hook, err := syslog.NewHook(*cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		hooks, closers = append(hooks, hook), append(closers, hook)
+	}
+	if journald, _ := strconv.ParseBool(os.Getenv("LOG_JOURNALD")); journald {
+		hook, err := syslog.NewJournalHook(os.Getenv("LOG_JOURNALD_SOCKET"), "")
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, err
+		}
+		hooks, closers = append(hooks, hook), append(closers, hook)
+	}
+	if os.Getenv("SENTRY_DSN") != "" {
+		cfg, err := sentryhook.NewConfigFromEnv()
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, err
+		}
+		hook, err := sentryhook.New(*cfg)
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, err
+		}
+		hooks, closers = append(hooks, hook), append(closers, hook)
+	}
+	if os.Getenv("LOG_LOKI_URL") != "" {
+		cfg, err := ship.NewLokiConfigFromEnv()
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, err
+		}
+		sink, err := ship.NewLokiSink(*cfg)
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, err
+		}
+		hook := ship.NewHook(sink, ship.Config{})
+		hooks, closers = append(hooks, hook), append(closers, hook)
+	}
+	return hooks, closers, nil
+}
+
+// redactHook masks sensitive values in entries before they are formatted or
+// passed to later hooks.
+type redactHook struct {
+	redactor *formatter.Redactor
+}
+
+// Levels implements logrus.Hook.
+func (h redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook. Logrus hands hooks a copy of the entry, so
+// the fields are masked in place.
+func (h redactHook) Fire(entry *logrus.Entry) error {
+	for k, v := range entry.Data {
+		entry.Data[k] = h.redactor.Value(k, v)
+	}
+	entry.Message = h.redactor.String(entry.Message)
+	return nil
+}
+
+// logLevel returns the validated minimum level.
+func (o *options) logLevel() logrus.Level {
+	lvl, _ := logrus.ParseLevel(o.level)
+	return lvl
+}
+
+// newFormatter returns the Logrus formatter for the configured format,
+// sampling entries if configured.
+func (o *options) newFormatter(forceColors bool) logrus.Formatter {
+	f := o.newFormatFormatter(forceColors)
+	if o.sampling != nil {
+		return newBurstSamplingFormatter(f, *o.sampling)
+	}
+	return f
+}
+
+// newFormatFormatter returns the Logrus formatter for the configured format.
+func (o *options) newFormatFormatter(forceColors bool) logrus.Formatter {
+	if o.format == FormatJSON {
+		return &formatter.JSONFormatter{Preset: o.preset}
+	}
+	if o.format == FormatLogfmt {
+		return &formatter.Formatter{Logfmt: true}
+	}
+	return &formatter.Formatter{
+		ForceColors:     forceColors,
+		TimestampFormat: "2006-01-02 15:04:05",
+		FullTimestamp:   true,
+	}
+}
@@ -0,0 +1,360 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ranorsolutions/http-common-go/pkg/log/formatter"
+	"github.com/ranorsolutions/http-common-go/pkg/log/writer"
+	"github.com/sirupsen/logrus"
+)
+
+func TestNew_JSONFormat(t *testing.T) {
+	logger, err := New("svc", "v1", true, WithJSON())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := logger.Entry.Logger.Formatter.(*formatter.JSONFormatter); !ok {
+		t.Fatalf("expected JSON formatter, got %T", logger.Entry.Logger.Formatter)
+	}
+
+	var buf bytes.Buffer
+	logger.Entry.Logger.Out = &buf
+	logger.Entry.WithField("trace_id", "abc").Info("hello")
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", buf.String(), err)
+	}
+	for k, v := range map[string]interface{}{"service": "svc", "version": "v1", "trace_id": "abc", "level": "info", "msg": "hello"} {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestNew_LogfmtFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New("svc", "v1", true, WithFormat(FormatLogfmt), WithOutput(&buf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.Info("hello world")
+	if got := buf.String(); !strings.Contains(got, `level=info msg="hello world" service=svc version=v1`) {
+		t.Errorf("expected logfmt output, got %q", got)
+	}
+
+	if _, err := New("svc", "v1", false, WithFormat(FormatLogfmt), WithFieldPreset(formatter.ECSPreset)); err == nil {
+		t.Error("expected an error combining logfmt with a field preset")
+	}
+}
+
+func TestNew_FormatFromEnv(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "JSON")
+	logger, err := New("svc", "v1", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := logger.Entry.Logger.Formatter.(*formatter.JSONFormatter); !ok {
+		t.Errorf("expected LOG_FORMAT to select the JSON formatter, got %T", logger.Entry.Logger.Formatter)
+	}
+
+	logger, err = New("svc", "v1", false, WithFormat(FormatText))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := logger.Entry.Logger.Formatter.(*formatter.Formatter); !ok {
+		t.Errorf("expected the option to take precedence over LOG_FORMAT, got %T", logger.Entry.Logger.Formatter)
+	}
+}
+
+func TestNew_InvalidFormat(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "xml")
+	if _, err := New("svc", "v1", false); err == nil {
+		t.Error("expected an error for an unknown LOG_FORMAT")
+	}
+}
+
+func TestNew_Level(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warn")
+	logger, err := New("svc", "v1", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if logger.Level() != "warning" {
+		t.Errorf("expected LOG_LEVEL to set the level, got %s", logger.Level())
+	}
+
+	logger, err = New("svc", "v1", false, WithLevel("info"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if logger.Level() != "info" {
+		t.Errorf("expected the option to take precedence over LOG_LEVEL, got %s", logger.Level())
+	}
+
+	if _, err := New("svc", "v1", false, WithLevel("verbose")); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
+
+func TestNew_Redactor(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New("svc", "v1", false, WithJSON(), WithRedactor(formatter.NewRedactor()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.Entry.Logger.Out = &buf
+	logger.WithFields(map[string]interface{}{"authorization": "Bearer abc"}).Info("hello")
+	if bytes.Contains(buf.Bytes(), []byte("Bearer abc")) {
+		t.Errorf("expected the logrus backend to redact, got %s", buf.String())
+	}
+
+	slogLogger, out := newSlogLogger(t, WithRedactor(formatter.NewRedactor()))
+	slogLogger.WithFields(map[string]interface{}{"cookie": "session=1"}).Info("hello")
+	if bytes.Contains(out.Bytes(), []byte("session=1")) || !bytes.Contains(out.Bytes(), []byte(formatter.DefaultRedactMask)) {
+		t.Errorf("expected the slog backend to redact, got %s", out.String())
+	}
+}
+
+func TestNew_OutputAndFile(t *testing.T) {
+	var buf bytes.Buffer
+	name := filepath.Join(t.TempDir(), "app.log")
+	logger, err := New("svc", "v1", false, WithOutput(&buf), WithFile(writer.FileConfig{Filename: name}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.Info("to both")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, _ := os.ReadFile(name)
+	if !strings.Contains(buf.String(), "to both") || !strings.Contains(string(data), "to both") {
+		t.Errorf("expected the entry in both outputs, got %q and %q", buf.String(), data)
+	}
+}
+
+func TestNew_FileFromEnv(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv("LOG_FILE", name)
+	t.Setenv("LOG_BACKEND", "slog")
+	logger, err := New("svc", "v1", false, WithOutput(io.Discard))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.Info("to file")
+	logger.Close()
+
+	data, _ := os.ReadFile(name)
+	if !strings.Contains(string(data), "to file") {
+		t.Errorf("expected the slog backend to write to LOG_FILE, got %q", data)
+	}
+
+	t.Setenv("LOG_FILE_MAX_BACKUPS", "many")
+	if _, err := New("svc", "v1", false); err == nil {
+		t.Error("expected an error for an invalid file configuration")
+	}
+}
+
+func TestNew_HooksAfterRedaction(t *testing.T) {
+	for _, backend := range []string{BackendLogrus, BackendSlog} {
+		t.Run(backend, func(t *testing.T) {
+			t.Setenv("LOG_BACKEND", backend)
+			hook := &testHook{}
+			logger, err := New("svc", "v1", false, WithOutput(io.Discard), WithRedactor(formatter.NewRedactor()), WithHook(hook))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			logger.WithFields(map[string]interface{}{"token": "t1"}).Info("hello")
+
+			if len(hook.entries) != 1 {
+				t.Fatalf("expected the hook to fire once, got %d", len(hook.entries))
+			}
+			if e := hook.entries[0]; e.Data["token"] != formatter.DefaultRedactMask || e.Data["service"] != "svc" || e.Message != "hello" {
+				t.Errorf("expected a redacted entry with logger fields, got %v %q", e.Data, e.Message)
+			}
+		})
+	}
+}
+
+func TestNew_SyslogFromEnv(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pc.Close()
+	t.Setenv("LOG_SYSLOG_ADDR", "udp://"+pc.LocalAddr().String())
+
+	logger, err := New("svc", "v1", false, WithOutput(io.Discard))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.Warn("to syslog")
+
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg := string(buf[:n]); !strings.HasPrefix(msg, "<12>1 ") || !strings.HasSuffix(msg, "to syslog") || !strings.Contains(msg, `service="svc"`) {
+		t.Errorf("unexpected syslog message %q", msg)
+	}
+	if err := logger.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	t.Setenv("LOG_SYSLOG_ADDR", "ftp://logs")
+	if _, err := New("svc", "v1", false); err == nil {
+		t.Error("expected an error for an invalid LOG_SYSLOG_ADDR")
+	}
+}
+
+func TestNew_SentryFromEnv(t *testing.T) {
+	t.Setenv("SENTRY_DSN", "https://key@o0.ingest.sentry.io/1")
+	logger, err := New("svc", "v1", false, WithOutput(io.Discard))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(logger.Entry.Logger.Hooks[logrus.ErrorLevel]); n != 1 {
+		t.Errorf("expected the Sentry hook on error entries, got %d hooks", n)
+	}
+	if n := len(logger.Entry.Logger.Hooks[logrus.InfoLevel]); n != 0 {
+		t.Errorf("expected no hook on info entries, got %d", n)
+	}
+	if err := logger.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	t.Setenv("SENTRY_DSN", "not a dsn")
+	if _, err := New("svc", "v1", false); err == nil {
+		t.Error("expected an error for an invalid SENTRY_DSN")
+	}
+}
+
+func TestNew_LokiFromEnv(t *testing.T) {
+	pushed := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		pushed <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	t.Setenv("LOG_LOKI_URL", srv.URL)
+	logger, err := New("svc", "v1", false, WithOutput(io.Discard))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.Info("shipped")
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body := <-pushed; !strings.Contains(body, `\"msg\":\"shipped\"`) || !strings.Contains(body, `"service":"svc"`) {
+		t.Errorf("unexpected push body %s", body)
+	}
+	if err := logger.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	t.Setenv("LOG_LOKI_LABELS", "invalid")
+	if _, err := New("svc", "v1", false); err == nil {
+		t.Error("expected an error for an invalid LOG_LOKI_LABELS")
+	}
+}
+
+func TestNew_FieldPreset(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New("svc", "v1", false, WithOutput(&buf), WithFieldPreset(formatter.ECSPreset))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.Entry.WithField("trace_id", "abc").Info("hello")
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("expected the preset to imply JSON, got %q: %v", buf.String(), err)
+	}
+	for k, v := range map[string]interface{}{"service.name": "svc", "trace.id": "abc", "log.level": "info", "message": "hello"} {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+
+	if _, err := New("svc", "v1", false, WithFormat(FormatText), WithFieldPreset(formatter.ECSPreset)); err == nil {
+		t.Error("expected an error for a preset with the text format")
+	}
+}
+
+func TestNew_FieldPresetFromEnv(t *testing.T) {
+	t.Setenv("LOG_FIELD_PRESET", "GCP")
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "my-project")
+	logger, err := New("svc", "v1", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, ok := logger.Entry.Logger.Formatter.(*formatter.JSONFormatter)
+	if !ok || f.Preset == nil || f.Preset.Name != "gcp" {
+		t.Fatalf("expected the gcp preset, got %#v", logger.Entry.Logger.Formatter)
+	}
+
+	t.Setenv("LOG_FIELD_PRESET", "splunk")
+	if _, err := New("svc", "v1", false); err == nil {
+		t.Error("expected an error for an unknown LOG_FIELD_PRESET")
+	}
+}
+
+func TestNew_Async(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New("svc", "v1", false, WithOutput(&buf), WithJSON(), WithAsync(writer.AsyncConfig{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := logger.Entry.Logger.Out.(*writer.AsyncWriter); !ok {
+		t.Fatalf("expected an async output, got %T", logger.Entry.Logger.Out)
+	}
+
+	logger.Info("queued")
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"msg":"queued"`) {
+		t.Errorf("expected the entry after Flush, got %q", buf.String())
+	}
+
+	logger.Info("on close")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"msg":"on close"`) {
+		t.Errorf("expected Close to write queued entries, got %q", buf.String())
+	}
+}
+
+func TestNew_AsyncFromEnv(t *testing.T) {
+	t.Setenv("LOG_ASYNC", "true")
+	t.Setenv("LOG_ASYNC_BUFFER", "8")
+	logger, err := New("svc", "v1", false, WithOutput(io.Discard))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer logger.Close()
+	if _, ok := logger.Entry.Logger.Out.(*writer.AsyncWriter); !ok {
+		t.Errorf("expected LOG_ASYNC to select an async output, got %T", logger.Entry.Logger.Out)
+	}
+
+	t.Setenv("LOG_ASYNC_BUFFER", "lots")
+	if _, err := New("svc", "v1", false); err == nil {
+		t.Error("expected an error for an invalid LOG_ASYNC_BUFFER")
+	}
+}
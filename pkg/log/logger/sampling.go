@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SamplingConfig thins out bursts of identical entries. Within each Tick,
+// the first First entries with a given level and message are written, then
+// every Thereafter-th one. Warnings and errors are never sampled.
+type SamplingConfig struct {
+	// Tick is the sampling window, one second when zero.
+	Tick time.Duration
+
+	// First is the number of identical entries written per Tick before
+	// sampling starts.
+	First int
+
+	// Thereafter keeps one in Thereafter identical entries past First; 0
+	// drops them all.
+	Thereafter int
+}
+
+// WithSampling samples info, debug and trace entries according to cfg, so
+// hot loops cannot flood the outputs. It applies to the Logrus backend; the
+// level writers of WithLevelWriter keep the same entries, other hooks still
+// see every entry.
+func WithSampling(cfg SamplingConfig) Option {
+	return func(o *options) { o.sampling = &cfg }
+}
+
+// validate reports invalid settings.
+func (cfg SamplingConfig) validate() error {
+	if cfg.Tick < 0 || cfg.First < 0 || cfg.Thereafter < 0 {
+		return fmt.Errorf("invalid sampling config %+v: values must not be negative", cfg)
+	}
+	return nil
+}
+
+// samplingKey identifies identical entries.
+type samplingKey struct {
+	level   logrus.Level
+	message string
+}
+
+// sampler is a formatter that drops some entries by producing no output
+// for them. Level writers ask it before formatting an entry themselves, so
+// they keep the same entries as the logger.
+type sampler interface {
+	logrus.Formatter
+
+	// sampled reports whether entry is kept. The decision is remembered
+	// until Format is called with the same entry.
+	sampled(entry *logrus.Entry) bool
+
+	// unwrap returns the formatter of the kept entries.
+	unwrap() logrus.Formatter
+
+	// forget drops the decision remembered for entry, for entries Logrus
+	// never formats.
+	forget(entry *logrus.Entry)
+}
+
+// forgetSampled drops the decisions the samplers of f remember for entry.
+func forgetSampled(f logrus.Formatter, entry *logrus.Entry) {
+	for {
+		s, ok := f.(sampler)
+		if !ok {
+			return
+		}
+		s.forget(entry)
+		f = s.unwrap()
+	}
+}
+
+// samplingDecisions remembers the decisions made for hooks until the logger
+// formats the entry, which happens after the hooks fired. Entries are
+// sampled once, whatever the number of outputs.
+type samplingDecisions struct {
+	mu   sync.Mutex
+	kept map[*logrus.Entry]bool
+}
+
+// decide returns the decision for entry, calling keep if there is none
+// yet. The decision is forgotten when final is set.
+func (d *samplingDecisions) decide(entry *logrus.Entry, final bool, keep func() bool) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	kept, ok := d.kept[entry]
+	if !ok {
+		kept = keep()
+	}
+	switch {
+	case final:
+		delete(d.kept, entry)
+	case !ok:
+		if d.kept == nil {
+			d.kept = map[*logrus.Entry]bool{}
+		}
+		d.kept[entry] = kept
+	}
+	return kept
+}
+
+// forget drops the decision for entry.
+func (d *samplingDecisions) forget(entry *logrus.Entry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.kept, entry)
+}
+
+// burstSamplingFormatter drops the entries SamplingConfig samples out.
+type burstSamplingFormatter struct {
+	inner     logrus.Formatter
+	cfg       SamplingConfig
+	decisions samplingDecisions
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[samplingKey]int
+}
+
+// newBurstSamplingFormatter wraps inner.
+func newBurstSamplingFormatter(inner logrus.Formatter, cfg SamplingConfig) *burstSamplingFormatter {
+	if cfg.Tick == 0 {
+		cfg.Tick = time.Second
+	}
+	return &burstSamplingFormatter{inner: inner, cfg: cfg, counts: map[samplingKey]int{}}
+}
+
+// Format implements logrus.Formatter.
+func (f *burstSamplingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if f.decisions.decide(entry, true, func() bool { return f.keep(entry) }) {
+		return f.inner.Format(entry)
+	}
+	return nil, nil
+}
+
+// sampled implements sampler.
+func (f *burstSamplingFormatter) sampled(entry *logrus.Entry) bool {
+	return f.decisions.decide(entry, false, func() bool { return f.keep(entry) })
+}
+
+// unwrap implements sampler.
+func (f *burstSamplingFormatter) unwrap() logrus.Formatter { return f.inner }
+
+// forget implements sampler.
+func (f *burstSamplingFormatter) forget(entry *logrus.Entry) { f.decisions.forget(entry) }
+
+// keep counts entry in the current window and reports whether it is kept.
+func (f *burstSamplingFormatter) keep(entry *logrus.Entry) bool {
+	if entry.Level < logrus.InfoLevel {
+		return true
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if now := time.Now(); now.Sub(f.start) >= f.cfg.Tick {
+		f.start = now
+		clear(f.counts)
+	}
+
+	key := samplingKey{level: entry.Level, message: entry.Message}
+	f.counts[key]++
+	n := f.counts[key]
+	if n <= f.cfg.First {
+		return true
+	}
+	return f.cfg.Thereafter > 0 && (n-f.cfg.First)%f.cfg.Thereafter == 0
+}
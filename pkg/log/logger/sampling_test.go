@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithSampling(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New("svc", "1.0.0", false, WithOutput(&buf), WithFormat(FormatJSON),
+		WithSampling(SamplingConfig{Tick: time.Hour, First: 2, Thereafter: 3}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for i := 0; i < 8; i++ {
+		l.Info("hot loop")
+		l.Error("failure")
+	}
+	l.Info("other message")
+
+	// Entries 1, 2, 5 and 8 of the hot loop are kept
+	if got := strings.Count(buf.String(), `"hot loop"`); got != 4 {
+		t.Errorf("expected 4 sampled entries, got %d", got)
+	}
+	if got := strings.Count(buf.String(), `"failure"`); got != 8 {
+		t.Errorf("expected errors never sampled, got %d", got)
+	}
+	if !strings.Contains(buf.String(), `"other message"`) {
+		t.Errorf("expected messages sampled separately")
+	}
+}
+
+func TestWithSampling_LevelWriter(t *testing.T) {
+	var all bytes.Buffer
+	l, err := New("svc", "1.0.0", false, WithFormat(FormatJSON),
+		WithSampling(SamplingConfig{Tick: time.Hour, First: 2, Thereafter: 3}),
+		WithLevelWriter(LevelWriter{Writer: &all}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for i := 0; i < 8; i++ {
+		l.Info("hot loop")
+	}
+
+	// The level writer keeps the entries the logger keeps, counted once
+	if got := strings.Count(all.String(), `"hot loop"`); got != 4 {
+		t.Errorf("expected 4 sampled entries, got %d", got)
+	}
+	if s := l.Entry.Logger.Formatter.(*burstSamplingFormatter); len(s.decisions.kept) != 0 {
+		t.Errorf("expected decisions to be forgotten, got %d", len(s.decisions.kept))
+	}
+}
+
+func TestWithSampling_SlogBackendForgetsDecisions(t *testing.T) {
+	var all bytes.Buffer
+	l, _ := newSlogLogger(t,
+		WithSampling(SamplingConfig{Tick: time.Hour, First: 2, Thereafter: 3}),
+		WithLevelWriter(LevelWriter{Writer: &all}))
+	if err := l.SetTenantOverride("acme", TenantOverride{SampleRate: 0.5}); err != nil {
+		t.Fatalf("SetTenantOverride: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		l.Info("hot loop")
+		l.ForTenant("acme").Info("tenant loop")
+	}
+
+	if s := l.Entry.Logger.Formatter.(*burstSamplingFormatter); len(s.decisions.kept) != 0 {
+		t.Errorf("expected decisions to be forgotten, got %d", len(s.decisions.kept))
+	}
+	tf := l.ForTenant("acme").Entry.Logger.Formatter.(*samplingFormatter)
+	if len(tf.decisions.kept) != 0 {
+		t.Errorf("expected tenant decisions to be forgotten, got %d", len(tf.decisions.kept))
+	}
+}
+
+func TestWithSampling_NewTick(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New("svc", "1.0.0", false, WithOutput(&buf), WithFormat(FormatJSON),
+		WithSampling(SamplingConfig{Tick: time.Millisecond, First: 1}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	l.Info("tick")
+	l.Info("tick")
+	time.Sleep(2 * time.Millisecond)
+	l.Info("tick")
+
+	if got := strings.Count(buf.String(), `"tick"`); got != 2 {
+		t.Errorf("expected one entry per tick, got %d", got)
+	}
+}
+
+func TestWithSampling_Invalid(t *testing.T) {
+	if _, err := New("svc", "1.0.0", false, WithSampling(SamplingConfig{First: -1})); err == nil {
+		t.Error("expected an error for a negative First")
+	}
+}
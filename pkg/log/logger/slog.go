@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/sirupsen/logrus"
+)
+
+// SlogHandler implements slog.Handler on top of a Logger, so code that takes
+// a *slog.Logger writes through the same backend and level as the rest of
+// the service. Entries keep the fields of the Logger (service, version,
+// tenant, ...); request_id, trace_id and span_id are added from the request
+// metadata of the context passed to the slog call.
+type SlogHandler struct {
+	logger *Logger
+	fields logrus.Fields
+	group  string
+}
+
+// NewSlogHandler returns a slog.Handler writing to l.
+func NewSlogHandler(l *Logger) *SlogHandler {
+	return &SlogHandler{logger: l, fields: logrus.Fields{}}
+}
+
+// Slog returns a *slog.Logger writing to l.
+//
+// Example:
+//
+//	log, _ := logger.New("user-service", "1.0.0", false)
+//	client := thirdparty.New(thirdparty.WithLogger(log.Slog()))
+func (l *Logger) Slog() *slog.Logger {
+	return slog.New(NewSlogHandler(l))
+}
+
+// Enabled implements slog.Handler.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	lvl := logrusLevel(level)
+	if !h.logger.Entry.Logger.IsLevelEnabled(lvl) {
+		return false
+	}
+	return h.logger.backend == nil || h.logger.backend.Enabled(ctx, lvl)
+}
+
+// Handle implements slog.Handler.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make(logrus.Fields, len(h.fields)+r.NumAttrs()+3)
+
+	md := reqctx.RequestMetadataFromContext(ctx)
+	for k, v := range map[string]string{"request_id": md.RequestID, "trace_id": md.TraceID, "span_id": md.SpanID} {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, h.group, a)
+		return true
+	})
+
+	// slog records the call site of the *slog.Logger method
+	h.logger.log(ctx, Record{Time: r.Time, Level: logrusLevel(r.Level), Message: r.Message, Fields: fields, PC: r.PC})
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(logrus.Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		addAttr(fields, h.group, a)
+	}
+	return &SlogHandler{logger: h.logger, fields: fields, group: h.group}
+}
+
+// WithGroup implements slog.Handler. Attributes added afterwards are
+// prefixed with the group name, e.g. "http.status".
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{logger: h.logger, fields: h.fields, group: h.group + name + "."}
+}
+
+// addAttr stores a in fields under prefix, flattening groups into dotted
+// keys.
+func addAttr(fields logrus.Fields, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		group := v.Group()
+		if len(group) == 0 {
+			return
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range group {
+			addAttr(fields, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	fields[prefix+a.Key] = v.Any()
+}
+
+// logrusLevel maps a slog level to the closest Logrus level; levels below
+// slog.LevelDebug map to trace.
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level >= slog.LevelError:
+		return logrus.ErrorLevel
+	case level >= slog.LevelWarn:
+		return logrus.WarnLevel
+	case level >= slog.LevelInfo:
+		return logrus.InfoLevel
+	case level >= slog.LevelDebug:
+		return logrus.DebugLevel
+	default:
+		return logrus.TraceLevel
+	}
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/sirupsen/logrus"
+)
+
+func TestSlog_Fields(t *testing.T) {
+	logger, hook := newTestLogger()
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1", TraceID: "trace-1", SpanID: "span-1"})
+
+	log := logger.Slog().With("component", "billing").WithGroup("http")
+	log.InfoContext(ctx, "charged", "status", 200, slog.Group("client", "ip", "10.0.0.1"), "err", errors.New("none"))
+
+	if len(hook.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(hook.entries))
+	}
+	e := hook.entries[0]
+	if e.Level != logrus.InfoLevel || e.Message != "charged" {
+		t.Errorf("unexpected entry %v %q", e.Level, e.Message)
+	}
+	want := map[string]interface{}{
+		"service":        "test-service",
+		"version":        "1.0.0",
+		"request_id":     "req-1",
+		"trace_id":       "trace-1",
+		"span_id":        "span-1",
+		"component":      "billing",
+		"http.status":    int64(200),
+		"http.client.ip": "10.0.0.1",
+	}
+	for k, v := range want {
+		if e.Data[k] != v {
+			t.Errorf("%s = %v (%T), want %v", k, e.Data[k], e.Data[k], v)
+		}
+	}
+	if err, ok := e.Data["http.err"].(error); !ok || err.Error() != "none" {
+		t.Errorf("expected errors to be kept as error values, got %v", e.Data["http.err"])
+	}
+}
+
+func TestSlog_Levels(t *testing.T) {
+	logger, hook := newTestLogger()
+	if err := logger.SetLevel("info"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	log := logger.Slog()
+
+	log.Debug("dropped")
+	log.Info("info")
+	log.Warn("warn")
+	log.Error("error")
+	log.Log(context.Background(), slog.LevelError+4, "critical")
+
+	want := []logrus.Level{logrus.InfoLevel, logrus.WarnLevel, logrus.ErrorLevel, logrus.ErrorLevel}
+	if len(hook.entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(hook.entries))
+	}
+	for i, lvl := range want {
+		if hook.entries[i].Level != lvl {
+			t.Errorf("entry %d: level %v, want %v", i, hook.entries[i].Level, lvl)
+		}
+	}
+	if log.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("expected debug to be disabled at info level")
+	}
+}
+
+func TestLogrusLevel(t *testing.T) {
+	tests := map[slog.Level]logrus.Level{
+		slog.LevelDebug - 4: logrus.TraceLevel,
+		slog.LevelDebug:     logrus.DebugLevel,
+		slog.LevelInfo:      logrus.InfoLevel,
+		slog.LevelInfo + 2:  logrus.InfoLevel,
+		slog.LevelWarn:      logrus.WarnLevel,
+		slog.LevelError:     logrus.ErrorLevel,
+	}
+	for in, want := range tests {
+		if got := logrusLevel(in); got != want {
+			t.Errorf("logrusLevel(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
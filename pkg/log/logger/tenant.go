@@ -0,0 +1,219 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TenantHeader is the conventional tenant header, for use with
+// WithTrustedTenantHeader.
+const TenantHeader = "X-Tenant-ID"
+
+// TenantOverride describes per-tenant logging behavior. Any zero-valued field
+// inherits the setting of the base logger.
+type TenantOverride struct {
+	// Level is the minimum level logged for the tenant (e.g. "debug").
+	Level string
+
+	// Out is a dedicated sink for the tenant's log entries.
+	Out io.Writer
+
+	// SampleRate is the fraction (0 < rate < 1) of info, debug and trace
+	// entries that are kept. Warnings and errors are never sampled.
+	SampleRate float64
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the given tenant identifier.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant identifier stored in ctx, or an empty
+// string if none is present.
+func TenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// tenantFromRequest resolves the tenant from the request context, falling
+// back to header when set.
+func tenantFromRequest(r *http.Request, header string) string {
+	if tenant := TenantFromContext(r.Context()); tenant != "" {
+		return tenant
+	}
+	if header == "" {
+		return ""
+	}
+	return r.Header.Get(header)
+}
+
+// tenantRegistry stores overrides and the derived Logrus loggers so that
+// per-request lookups do not rebuild loggers.
+type tenantRegistry struct {
+	mu        sync.RWMutex
+	overrides map[string]TenantOverride
+	loggers   map[string]*logrus.Logger
+}
+
+func newTenantRegistry() *tenantRegistry {
+	return &tenantRegistry{
+		overrides: make(map[string]TenantOverride),
+		loggers:   make(map[string]*logrus.Logger),
+	}
+}
+
+// SetTenantOverride installs or replaces the logging override for a tenant.
+// Overrides are shared by the Loggers derived from a Logger created by New,
+// and by Default.
+//
+// Example:
+//
+//	log.SetTenantOverride("acme", logger.TenantOverride{Level: "trace"})
+func (l *Logger) SetTenantOverride(tenant string, o TenantOverride) error {
+	if tenant == "" {
+		return fmt.Errorf("tenant is required")
+	}
+	if o.Level != "" {
+		if _, err := logrus.ParseLevel(o.Level); err != nil {
+			return fmt.Errorf("invalid level for tenant %s: %w", tenant, err)
+		}
+	}
+	if o.SampleRate < 0 || o.SampleRate > 1 {
+		return fmt.Errorf("invalid sample rate for tenant %s: %v", tenant, o.SampleRate)
+	}
+	if l.tenants == nil {
+		return fmt.Errorf("tenant overrides require a Logger created by New")
+	}
+
+	l.tenants.mu.Lock()
+	defer l.tenants.mu.Unlock()
+	l.tenants.overrides[tenant] = o
+	delete(l.tenants.loggers, tenant)
+	return nil
+}
+
+// ClearTenantOverride removes the logging override for a tenant.
+func (l *Logger) ClearTenantOverride(tenant string) {
+	if l.tenants == nil {
+		return
+	}
+	l.tenants.mu.Lock()
+	defer l.tenants.mu.Unlock()
+	delete(l.tenants.overrides, tenant)
+	delete(l.tenants.loggers, tenant)
+}
+
+// ForTenant returns a Logger tagged with the tenant. If an override is
+// registered for the tenant, the returned Logger uses its level, sink and
+// sampling rate; otherwise it shares the base Logrus logger.
+func (l *Logger) ForTenant(tenant string) *Logger {
+	if tenant == "" {
+		return l
+	}
+
+	base := l.Entry.Logger
+	if out := l.tenantLogger(tenant); out != nil {
+		base = out
+	}
+
+	entry := base.WithFields(l.Entry.Data).WithField("tenant", tenant)
+	child := *l
+	child.Entry = entry
+	return &child
+}
+
+func (l *Logger) tenantLogger(tenant string) *logrus.Logger {
+	if l.tenants == nil {
+		return nil
+	}
+
+	l.tenants.mu.RLock()
+	cached, ok := l.tenants.loggers[tenant]
+	_, hasOverride := l.tenants.overrides[tenant]
+	l.tenants.mu.RUnlock()
+	if ok {
+		return cached
+	}
+	if !hasOverride {
+		return nil
+	}
+
+	// Build under the write lock, so a logger built from an override that
+	// was replaced or cleared in the meantime is never cached.
+	l.tenants.mu.Lock()
+	defer l.tenants.mu.Unlock()
+	if cached, ok := l.tenants.loggers[tenant]; ok {
+		return cached
+	}
+	override, hasOverride := l.tenants.overrides[tenant]
+	if !hasOverride {
+		return nil
+	}
+	built := buildTenantLogger(l.Entry.Logger, override)
+	l.tenants.loggers[tenant] = built
+	return built
+}
+
+func buildTenantLogger(base *logrus.Logger, o TenantOverride) *logrus.Logger {
+	child := &logrus.Logger{
+		Out:          base.Out,
+		Level:        base.GetLevel(),
+		Hooks:        base.Hooks,
+		Formatter:    base.Formatter,
+		ReportCaller: base.ReportCaller,
+		ExitFunc:     base.ExitFunc,
+	}
+	if o.Out != nil {
+		child.Out = o.Out
+	}
+	if lvl, err := logrus.ParseLevel(o.Level); err == nil {
+		child.Level = lvl
+	}
+	if o.SampleRate > 0 && o.SampleRate < 1 {
+		child.Formatter = &samplingFormatter{inner: base.Formatter, rate: o.SampleRate}
+	}
+	return child
+}
+
+// samplingFormatter drops a fraction of low-severity entries by producing
+// no output for them.
+type samplingFormatter struct {
+	inner     logrus.Formatter
+	rate      float64
+	decisions samplingDecisions
+}
+
+// Format implements logrus.Formatter.
+func (f *samplingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if !f.decisions.decide(entry, true, func() bool { return f.keep(entry) }) {
+		return nil, nil
+	}
+	return f.inner.Format(entry)
+}
+
+// sampled implements sampler.
+func (f *samplingFormatter) sampled(entry *logrus.Entry) bool {
+	return f.decisions.decide(entry, false, func() bool { return f.keep(entry) })
+}
+
+// unwrap implements sampler.
+func (f *samplingFormatter) unwrap() logrus.Formatter { return f.inner }
+
+// forget implements sampler.
+func (f *samplingFormatter) forget(entry *logrus.Entry) { f.decisions.forget(entry) }
+
+// keep reports whether entry is kept.
+func (f *samplingFormatter) keep(entry *logrus.Entry) bool {
+	return entry.Level < logrus.InfoLevel || rand.Float64() < f.rate
+}
@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func TestTenantContextRoundTrip(t *testing.T) {
+	ctx := WithTenant(context.Background(), "acme")
+	if got := TenantFromContext(ctx); got != "acme" {
+		t.Errorf("expected tenant acme, got %q", got)
+	}
+	if got := TenantFromContext(context.Background()); got != "" {
+		t.Errorf("expected empty tenant, got %q", got)
+	}
+}
+
+func TestSetTenantOverride_Validation(t *testing.T) {
+	l, _ := New("svc", "v1", false)
+
+	if err := l.SetTenantOverride("", TenantOverride{}); err == nil {
+		t.Error("expected error for empty tenant")
+	}
+	if err := l.SetTenantOverride("acme", TenantOverride{Level: "loud"}); err == nil {
+		t.Error("expected error for invalid level")
+	}
+	if err := l.SetTenantOverride("acme", TenantOverride{SampleRate: 2}); err == nil {
+		t.Error("expected error for invalid sample rate")
+	}
+}
+
+func TestForTenant_AppliesLevelAndSink(t *testing.T) {
+	l, _ := New("svc", "v1", false)
+	l.Entry.Logger.SetLevel(logrus.InfoLevel)
+
+	var sink bytes.Buffer
+	if err := l.SetTenantOverride("acme", TenantOverride{Level: "debug", Out: &sink}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tl := l.ForTenant("acme")
+	tl.Debug("verbose details")
+
+	if !strings.Contains(sink.String(), "verbose details") {
+		t.Errorf("expected debug entry in tenant sink, got %q", sink.String())
+	}
+	if tl.Entry.Data["tenant"] != "acme" {
+		t.Errorf("expected tenant field, got %v", tl.Entry.Data["tenant"])
+	}
+	if l.Entry.Logger.GetLevel() != logrus.InfoLevel {
+		t.Error("expected base logger level to be unchanged")
+	}
+
+	l.ClearTenantOverride("acme")
+	if l.ForTenant("acme").Entry.Logger != l.Entry.Logger {
+		t.Error("expected base logger after clearing override")
+	}
+}
+
+func TestForTenant_SamplingKeepsErrors(t *testing.T) {
+	l, _ := New("svc", "v1", false)
+
+	var sink bytes.Buffer
+	_ = l.SetTenantOverride("noisy", TenantOverride{Out: &sink, SampleRate: 0.0001})
+
+	tl := l.ForTenant("noisy")
+	for i := 0; i < 50; i++ {
+		tl.Info("sampled")
+	}
+	tl.Error("always kept")
+
+	out := sink.String()
+	if strings.Count(out, "sampled") > 5 {
+		t.Errorf("expected most info entries to be sampled out, got %q", out)
+	}
+	if !strings.Contains(out, "always kept") {
+		t.Errorf("expected error entry to bypass sampling, got %q", out)
+	}
+}
+
+func TestForTenant_ConcurrentOverrideChange(t *testing.T) {
+	var base, sink bytes.Buffer
+	l, _ := New("svc", "1.0.0", false, WithOutput(&base))
+	_ = l.SetTenantOverride("acme", TenantOverride{Level: "trace"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = l.ForTenant("acme")
+			}
+		}()
+	}
+	_ = l.SetTenantOverride("acme", TenantOverride{Out: &sink})
+	wg.Wait()
+
+	// The logger cached last must follow the override set last
+	if out := l.tenantLogger("acme").Out; out != &sink {
+		t.Errorf("expected the latest override to be used, got %v", out)
+	}
+}
+
+// serveTenantRequest serves a request with the tenant header set through
+// the middleware of l and returns the output of the tenant sink.
+func serveTenantRequest(t *testing.T, opts ...MiddlewareOption) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	l, _ := New("svc", "v1", false, WithOutput(io.Discard))
+
+	var sink bytes.Buffer
+	if err := l.SetTenantOverride("acme", TenantOverride{Out: &sink}); err != nil {
+		t.Fatalf("SetTenantOverride: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(l.Middleware(opts...))
+	r.GET("/test", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set(TenantHeader, "acme")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return sink.String()
+}
+
+func TestMiddleware_IgnoresTenantHeader(t *testing.T) {
+	if got := serveTenantRequest(t); got != "" {
+		t.Errorf("expected the untrusted tenant header to be ignored, got %q", got)
+	}
+}
+
+func TestMiddleware_TrustedTenantHeader(t *testing.T) {
+	if got := serveTenantRequest(t, WithTrustedTenantHeader(TenantHeader)); !strings.Contains(got, "request completed") {
+		t.Errorf("expected request log in tenant sink, got %q", got)
+	}
+}
+
+func TestSetTenantOverride_RequiresRegistry(t *testing.T) {
+	l := &Logger{Entry: logrus.NewEntry(logrus.New())}
+	if err := l.SetTenantOverride("acme", TenantOverride{Level: "debug"}); err == nil {
+		t.Error("expected an error for a Logger not created by New")
+	}
+
+	if err := Default().SetTenantOverride("acme", TenantOverride{Level: "debug"}); err != nil {
+		t.Fatalf("SetTenantOverride: %v", err)
+	}
+	t.Cleanup(func() { Default().ClearTenantOverride("acme") })
+	if Default().ForTenant("acme").Entry.Logger.GetLevel() != logrus.DebugLevel {
+		t.Error("expected the override of Default to be kept")
+	}
+}
@@ -0,0 +1,207 @@
+// Package sentryhook provides a Logrus hook forwarding error, fatal and panic
+// entries to Sentry, tagged with the correlation IDs of the request.
+package sentryhook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultLevels are the levels forwarded unless Config.Levels is set.
+var DefaultLevels = []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+
+// DefaultFlushTimeout bounds how long Close and fatal entries wait for
+// events to be delivered.
+const DefaultFlushTimeout = 2 * time.Second
+
+// tagFields are entry fields sent as searchable Sentry tags; other fields
+// are sent as extra data.
+var tagFields = []string{"request_id", "trace_id", "span_id", "service", "version", "tenant", "method", "path", "status"}
+
+// Config configures a Hook.
+type Config struct {
+	// DSN of the Sentry project.
+	DSN string
+
+	// Environment reported with events, e.g. production.
+	Environment string
+
+	// Release reported with events. Defaults to service@version from the
+	// entry fields.
+	Release string
+
+	// Levels forwarded. Defaults to DefaultLevels.
+	Levels []logrus.Level
+
+	// FlushTimeout bounds Close and the flush after fatal and panic entries.
+	// Defaults to DefaultFlushTimeout.
+	FlushTimeout time.Duration
+}
+
+// NewConfigFromEnv returns a Config from environment variables.
+//
+// Environment variables:
+//
+//	SENTRY_DSN          project DSN (required)
+//	SENTRY_ENVIRONMENT  environment name
+//	SENTRY_RELEASE      release name (default service@version)
+//	SENTRY_LEVEL        minimum level forwarded: warn, error (default), fatal or panic
+func NewConfigFromEnv() (*Config, error) {
+	cfg := &Config{
+		DSN:         os.Getenv("SENTRY_DSN"),
+		Environment: os.Getenv("SENTRY_ENVIRONMENT"),
+		Release:     os.Getenv("SENTRY_RELEASE"),
+	}
+	if cfg.DSN == "" {
+		return nil, errors.New("SENTRY_DSN is required")
+	}
+	if v := os.Getenv("SENTRY_LEVEL"); v != "" {
+		lvl, err := logrus.ParseLevel(v)
+		if err != nil || lvl > logrus.WarnLevel {
+			return nil, fmt.Errorf("invalid SENTRY_LEVEL %q", v)
+		}
+		for _, l := range logrus.AllLevels {
+			if l <= lvl {
+				cfg.Levels = append(cfg.Levels, l)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// Hook is a logrus.Hook capturing entries as Sentry events. The error
+// field (logrus.ErrorKey) becomes the exception, with its wrapped chain and
+// stack trace; entries without one get the stack of the logging call, which
+// for the recovery middleware is the panicking goroutine.
+type Hook struct {
+	hub          *sentry.Hub
+	release      string
+	levels       []logrus.Level
+	flushTimeout time.Duration
+}
+
+// New returns a Hook sending to the project of cfg.DSN.
+//
+// Example:
+//
+//	hook, err := sentryhook.New(sentryhook.Config{DSN: dsn, Environment: "production"})
+//	log, _ := logger.New("user-service", "1.0.0", false, logger.WithHook(hook))
+//	defer log.Close()
+func New(cfg Config) (*Hook, error) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         cfg.DSN,
+		Environment: cfg.Environment,
+		Release:     cfg.Release,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Sentry client: %w", err)
+	}
+	return NewWithHub(sentry.NewHub(client, sentry.NewScope()), cfg), nil
+}
+
+// NewWithHub returns a Hook capturing through hub, e.g. one shared with
+// other Sentry integrations. DSN and Environment of cfg are ignored.
+func NewWithHub(hub *sentry.Hub, cfg Config) *Hook {
+	if len(cfg.Levels) == 0 {
+		cfg.Levels = DefaultLevels
+	}
+	if cfg.FlushTimeout <= 0 {
+		cfg.FlushTimeout = DefaultFlushTimeout
+	}
+	return &Hook{hub: hub, release: cfg.Release, levels: cfg.Levels, flushTimeout: cfg.FlushTimeout}
+}
+
+// Levels implements logrus.Hook.
+func (h *Hook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire implements logrus.Hook. Fatal and panic entries are flushed before
+// returning, since the process is about to stop.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	h.hub.CaptureEvent(h.event(entry))
+	if entry.Level <= logrus.FatalLevel {
+		h.hub.Flush(h.flushTimeout)
+	}
+	return nil
+}
+
+// Close waits for buffered events to be delivered.
+func (h *Hook) Close() error {
+	if !h.hub.Flush(h.flushTimeout) {
+		return errors.New("timed out flushing Sentry events")
+	}
+	return nil
+}
+
+// event converts entry to a Sentry event.
+func (h *Hook) event(entry *logrus.Entry) *sentry.Event {
+	event := sentry.NewEvent()
+	event.Level = level(entry.Level)
+	event.Message = entry.Message
+	event.Timestamp = entry.Time
+	event.Logger = "logrus"
+	event.Release = h.release
+
+	extra := make(map[string]interface{}, len(entry.Data))
+	for k, v := range entry.Data {
+		extra[k] = v
+	}
+
+	// Request metadata of the context fills in IDs the entry lacks
+	md := reqctx.RequestMetadataFromContext(entry.Context)
+	for k, v := range map[string]string{"request_id": md.RequestID, "trace_id": md.TraceID, "span_id": md.SpanID} {
+		if _, ok := extra[k]; !ok && v != "" {
+			extra[k] = v
+		}
+	}
+	for _, k := range tagFields {
+		if v, ok := extra[k]; ok {
+			event.Tags[k] = fmt.Sprint(v)
+			delete(extra, k)
+		}
+	}
+	if event.Release == "" && event.Tags["service"] != "" && event.Tags["version"] != "" {
+		event.Release = event.Tags["service"] + "@" + event.Tags["version"]
+	}
+
+	if err, ok := extra[logrus.ErrorKey].(error); ok {
+		delete(extra, logrus.ErrorKey)
+		event.SetException(err, -1)
+	} else {
+		event.Exception = []sentry.Exception{{
+			Type:       entry.Level.String(),
+			Value:      entry.Message,
+			Stacktrace: sentry.NewStacktrace(),
+		}}
+	}
+	for k, v := range extra {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		event.Extra[k] = v
+	}
+	return event
+}
+
+// level maps a Logrus level to a Sentry level.
+func level(l logrus.Level) sentry.Level {
+	switch l {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return sentry.LevelFatal
+	case logrus.ErrorLevel:
+		return sentry.LevelError
+	case logrus.WarnLevel:
+		return sentry.LevelWarning
+	case logrus.InfoLevel:
+		return sentry.LevelInfo
+	default:
+		return sentry.LevelDebug
+	}
+}
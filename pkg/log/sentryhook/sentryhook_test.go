@@ -0,0 +1,217 @@
+package sentryhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/sirupsen/logrus"
+)
+
+// recordingTransport is a sentry.Transport keeping the events it is sent.
+type recordingTransport struct {
+	mu      sync.Mutex
+	events  []*sentry.Event
+	flushes int
+}
+
+func (t *recordingTransport) Configure(sentry.ClientOptions) {}
+func (t *recordingTransport) Close()                         {}
+
+func (t *recordingTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *recordingTransport) Flush(time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.flushes++
+	return true
+}
+
+func (t *recordingTransport) FlushWithContext(context.Context) bool {
+	return t.Flush(0)
+}
+
+func (t *recordingTransport) Events() []*sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*sentry.Event(nil), t.events...)
+}
+
+func newTestLogger(t *testing.T, cfg Config) (*logrus.Logger, *recordingTransport) {
+	t.Helper()
+	transport := &recordingTransport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.AddHook(NewWithHub(sentry.NewHub(client, sentry.NewScope()), cfg))
+	return l, transport
+}
+
+func TestHook_ErrorWithTags(t *testing.T) {
+	l, transport := newTestLogger(t, Config{})
+
+	cause := errors.New("connection refused")
+	l.WithFields(logrus.Fields{
+		"service":    "user-service",
+		"version":    "1.0.0",
+		"request_id": "req-1",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"user":       "42",
+	}).WithError(fmt.Errorf("load user: %w", cause)).Error("request failed")
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	e := events[0]
+	if e.Level != sentry.LevelError || e.Message != "request failed" {
+		t.Errorf("unexpected level/message: %q %q", e.Level, e.Message)
+	}
+	if e.Tags["request_id"] != "req-1" || e.Tags["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("missing correlation tags: %v", e.Tags)
+	}
+	if e.Release != "user-service@1.0.0" {
+		t.Errorf("expected release from service@version, got %q", e.Release)
+	}
+	if e.Extra["user"] != "42" {
+		t.Errorf("expected extra user field, got %v", e.Extra)
+	}
+	if _, ok := e.Extra[logrus.ErrorKey]; ok {
+		t.Error("error field should be sent as the exception, not extra")
+	}
+	if len(e.Exception) != 2 {
+		t.Fatalf("expected the wrapped chain as 2 exceptions, got %d", len(e.Exception))
+	}
+	var values []string
+	for _, ex := range e.Exception {
+		values = append(values, ex.Value)
+	}
+	if values[0] != "connection refused" && values[1] != "connection refused" {
+		t.Errorf("expected the cause in the chain, got %v", values)
+	}
+}
+
+func TestHook_StackWithoutError(t *testing.T) {
+	l, transport := newTestLogger(t, Config{})
+
+	l.Error("panic recovered: boom")
+
+	events := transport.Events()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	ex := events[0].Exception
+	if len(ex) != 1 || ex[0].Value != "panic recovered: boom" {
+		t.Fatalf("unexpected exception: %+v", ex)
+	}
+	if ex[0].Stacktrace == nil || len(ex[0].Stacktrace.Frames) == 0 {
+		t.Error("expected the stack of the logging call")
+	}
+}
+
+func TestHook_ContextMetadata(t *testing.T) {
+	l, transport := newTestLogger(t, Config{Release: "v2"})
+
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-ctx", TraceID: "trace-ctx", SpanID: "span-ctx"})
+	l.WithContext(ctx).WithField("request_id", "req-field").Error("failed")
+
+	e := transport.Events()[0]
+	if e.Tags["request_id"] != "req-field" {
+		t.Errorf("entry field should win over context, got %q", e.Tags["request_id"])
+	}
+	if e.Tags["trace_id"] != "trace-ctx" || e.Tags["span_id"] != "span-ctx" {
+		t.Errorf("expected IDs from the context, got %v", e.Tags)
+	}
+	if e.Release != "v2" {
+		t.Errorf("expected configured release, got %q", e.Release)
+	}
+}
+
+func TestHook_Levels(t *testing.T) {
+	l, transport := newTestLogger(t, Config{})
+
+	l.Warn("ignored")
+	l.Info("ignored")
+	if n := len(transport.Events()); n != 0 {
+		t.Fatalf("expected no events below error, got %d", n)
+	}
+
+	l, transport = newTestLogger(t, Config{Levels: []logrus.Level{logrus.WarnLevel}})
+	l.Warn("sent")
+	l.Error("ignored")
+	events := transport.Events()
+	if len(events) != 1 || events[0].Level != sentry.LevelWarning {
+		t.Fatalf("expected one warning event, got %+v", events)
+	}
+}
+
+func TestHook_PanicFlushes(t *testing.T) {
+	l, transport := newTestLogger(t, Config{})
+
+	func() {
+		defer func() { _ = recover() }()
+		l.Panic("boom")
+	}()
+
+	events := transport.Events()
+	if len(events) != 1 || events[0].Level != sentry.LevelFatal {
+		t.Fatalf("expected one fatal event, got %+v", events)
+	}
+	if transport.flushes == 0 {
+		t.Error("expected a flush after a panic entry")
+	}
+}
+
+func TestNewConfigFromEnv(t *testing.T) {
+	t.Setenv("SENTRY_DSN", "")
+	if _, err := NewConfigFromEnv(); err == nil {
+		t.Error("expected error without SENTRY_DSN")
+	}
+
+	t.Setenv("SENTRY_DSN", "https://key@o0.ingest.sentry.io/1")
+	t.Setenv("SENTRY_ENVIRONMENT", "production")
+	t.Setenv("SENTRY_RELEASE", "user-service@1.2.3")
+	t.Setenv("SENTRY_LEVEL", "warn")
+	cfg, err := NewConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Environment != "production" || cfg.Release != "user-service@1.2.3" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if len(cfg.Levels) != 4 || cfg.Levels[3] != logrus.WarnLevel {
+		t.Errorf("expected panic through warn, got %v", cfg.Levels)
+	}
+
+	t.Setenv("SENTRY_LEVEL", "info")
+	if _, err := NewConfigFromEnv(); err == nil {
+		t.Error("expected error for SENTRY_LEVEL below warn")
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{DSN: "not a dsn"}); err == nil {
+		t.Error("expected error for an invalid DSN")
+	}
+
+	hook, err := New(Config{DSN: "https://key@o0.ingest.sentry.io/1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hook.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
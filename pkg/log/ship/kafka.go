@@ -0,0 +1,47 @@
+package ship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// KafkaProducerAPI is the part of *kafka.Producer (pkg/messaging/kafka)
+// used by KafkaSink.
+type KafkaProducerAPI interface {
+	SendBytes(ctx context.Context, topic string, key string, value []byte, headers map[string]string) error
+}
+
+// KafkaSink is a Sink publishing each record as a message to a Kafka topic,
+// keyed by the service label so the entries of a service stay in order.
+type KafkaSink struct {
+	producer KafkaProducerAPI
+	topic    string
+}
+
+// NewKafkaSink returns a KafkaSink publishing to topic through producer.
+//
+// Example:
+//
+//	cfg, _ := kafka.NewConfigFromEnv()
+//	producer, _ := kafka.NewProducer(cfg)
+//	sink, _ := ship.NewKafkaSink(producer, "logs")
+//	hook := ship.NewHook(sink, ship.Config{})
+func NewKafkaSink(producer KafkaProducerAPI, topic string) (*KafkaSink, error) {
+	if producer == nil || topic == "" {
+		return nil, errors.New("kafka producer and topic are required")
+	}
+	return &KafkaSink{producer: producer, topic: topic}, nil
+}
+
+// Send implements Sink. A batch failing part way is retried from its first
+// record, so consumers may see duplicates.
+func (s *KafkaSink) Send(ctx context.Context, batch []Record) error {
+	headers := map[string]string{"content-type": "application/json"}
+	for _, r := range batch {
+		if err := s.producer.SendBytes(ctx, s.topic, r.Labels["service"], r.Line, headers); err != nil {
+			return fmt.Errorf("failed to publish log entry to Kafka: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package ship
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type sentMessage struct {
+	topic, key string
+	value      []byte
+	headers    map[string]string
+}
+
+type fakeProducer struct {
+	sent []sentMessage
+	err  error
+}
+
+func (p *fakeProducer) SendBytes(_ context.Context, topic, key string, value []byte, headers map[string]string) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.sent = append(p.sent, sentMessage{topic: topic, key: key, value: value, headers: headers})
+	return nil
+}
+
+func TestKafkaSink_Send(t *testing.T) {
+	producer := &fakeProducer{}
+	sink, err := NewKafkaSink(producer, "logs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	batch := []Record{
+		{Line: []byte(`{"msg":"a"}`), Labels: map[string]string{"service": "svc"}},
+		{Line: []byte(`{"msg":"b"}`), Labels: map[string]string{"level": "info"}},
+	}
+	if err := sink.Send(context.Background(), batch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(producer.sent) != 2 {
+		t.Fatalf("expected one message per record, got %d", len(producer.sent))
+	}
+	m := producer.sent[0]
+	if m.topic != "logs" || m.key != "svc" || string(m.value) != `{"msg":"a"}` || m.headers["content-type"] != "application/json" {
+		t.Errorf("unexpected message %+v", m)
+	}
+	if producer.sent[1].key != "" {
+		t.Errorf("expected an empty key without service label, got %q", producer.sent[1].key)
+	}
+
+	producer.err = errors.New("broker down")
+	if err := sink.Send(context.Background(), batch); !errors.Is(err, producer.err) {
+		t.Errorf("expected the producer error, got %v", err)
+	}
+}
+
+func TestNewKafkaSink_Validation(t *testing.T) {
+	if _, err := NewKafkaSink(nil, "logs"); err == nil {
+		t.Error("expected an error without producer")
+	}
+	if _, err := NewKafkaSink(&fakeProducer{}, ""); err == nil {
+		t.Error("expected an error without topic")
+	}
+}
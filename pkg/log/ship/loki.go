@@ -0,0 +1,172 @@
+package ship
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LokiConfig configures a LokiSink.
+type LokiConfig struct {
+	// URL of the push endpoint, e.g. http://loki:3100/loki/api/v1/push.
+	URL string
+
+	// Labels added to every stream, e.g. {"env": "production"}. The labels
+	// of the records (level, service) are added per stream.
+	Labels map[string]string
+
+	// TenantID is sent as X-Scope-OrgID for multi-tenant Loki.
+	TenantID string
+
+	// Basic auth credentials, e.g. for Grafana Cloud.
+	Username string
+	Password string
+
+	// Client sends the requests. Defaults to an http.Client without timeout;
+	// the hook bounds each push with Config.SendTimeout.
+	Client *http.Client
+}
+
+// NewLokiConfigFromEnv returns a LokiConfig from environment variables.
+//
+// Environment variables:
+//
+//	LOG_LOKI_URL       push endpoint URL (required)
+//	LOG_LOKI_LABELS    extra stream labels, e.g. env=production,region=eu
+//	LOG_LOKI_TENANT    X-Scope-OrgID tenant
+//	LOG_LOKI_USERNAME  basic auth user
+//	LOG_LOKI_PASSWORD  basic auth password
+func NewLokiConfigFromEnv() (*LokiConfig, error) {
+	cfg := &LokiConfig{
+		URL:      os.Getenv("LOG_LOKI_URL"),
+		TenantID: os.Getenv("LOG_LOKI_TENANT"),
+		Username: os.Getenv("LOG_LOKI_USERNAME"),
+		Password: os.Getenv("LOG_LOKI_PASSWORD"),
+	}
+	if cfg.URL == "" {
+		return nil, errors.New("LOG_LOKI_URL is required")
+	}
+	if v := os.Getenv("LOG_LOKI_LABELS"); v != "" {
+		cfg.Labels = map[string]string{}
+		for _, pair := range strings.Split(v, ",") {
+			k, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || k == "" {
+				return nil, fmt.Errorf("invalid LOG_LOKI_LABELS %q: want name=value pairs", v)
+			}
+			cfg.Labels[k] = val
+		}
+	}
+	return cfg, nil
+}
+
+// LokiSink is a Sink pushing batches to the Loki HTTP push API, one stream
+// per distinct label set.
+type LokiSink struct {
+	cfg LokiConfig
+}
+
+// NewLokiSink returns a LokiSink for cfg.
+func NewLokiSink(cfg LokiConfig) (*LokiSink, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("loki push URL is required")
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{}
+	}
+	return &LokiSink{cfg: cfg}, nil
+}
+
+// lokiStream is a stream of the push API.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// Send implements Sink. Responses with status 4xx other than 429 wrap
+// ErrRejected, as retrying the same batch cannot succeed.
+func (s *LokiSink) Send(ctx context.Context, batch []Record) error {
+	streams := map[string]*lokiStream{}
+	var keys []string
+	for _, r := range batch {
+		labels := make(map[string]string, len(s.cfg.Labels)+len(r.Labels))
+		for k, v := range s.cfg.Labels {
+			labels[k] = v
+		}
+		for k, v := range r.Labels {
+			labels[k] = v
+		}
+		key := labelKey(labels)
+		stream, ok := streams[key]
+		if !ok {
+			stream = &lokiStream{Stream: labels}
+			streams[key] = stream
+			keys = append(keys, key)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(r.Time.UnixNano(), 10), string(r.Line)})
+	}
+
+	body := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, k := range keys {
+		body.Streams = append(body.Streams, streams[k])
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode Loki push: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create Loki push request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", s.cfg.TenantID)
+	}
+	if s.cfg.Username != "" || s.cfg.Password != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push to Loki: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("loki push returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", ErrRejected, err)
+	}
+	return err
+}
+
+// labelKey returns a canonical key of labels.
+func labelKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, k := range names {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
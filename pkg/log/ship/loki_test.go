@@ -0,0 +1,111 @@
+package ship
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLokiSink_Send(t *testing.T) {
+	var (
+		got     map[string][]lokiStream
+		headers http.Header
+		user    string
+		pass    string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		user, pass, _ = r.BasicAuth()
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid push body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink, err := NewLokiSink(LokiConfig{
+		URL:      srv.URL,
+		Labels:   map[string]string{"env": "test"},
+		TenantID: "team-a",
+		Username: "user",
+		Password: "secret",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ts := time.Unix(1700000000, 5)
+	batch := []Record{
+		{Time: ts, Line: []byte("a"), Labels: map[string]string{"level": "info", "service": "svc"}},
+		{Time: ts, Line: []byte("b"), Labels: map[string]string{"level": "error", "service": "svc"}},
+		{Time: ts, Line: []byte("c"), Labels: map[string]string{"level": "info", "service": "svc"}},
+	}
+	if err := sink.Send(context.Background(), batch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	streams := got["streams"]
+	if len(streams) != 2 {
+		t.Fatalf("expected one stream per label set, got %+v", streams)
+	}
+	info := streams[0]
+	if info.Stream["level"] != "info" || info.Stream["env"] != "test" || info.Stream["service"] != "svc" {
+		t.Errorf("unexpected stream labels %v", info.Stream)
+	}
+	if len(info.Values) != 2 || info.Values[0] != [2]string{"1700000000000000005", "a"} || info.Values[1][1] != "c" {
+		t.Errorf("unexpected stream values %v", info.Values)
+	}
+	if headers.Get("X-Scope-OrgID") != "team-a" || headers.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers %v", headers)
+	}
+	if user != "user" || pass != "secret" {
+		t.Errorf("expected basic auth, got %q:%q", user, pass)
+	}
+}
+
+func TestLokiSink_Errors(t *testing.T) {
+	status := http.StatusBadRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "entry out of order", status)
+	}))
+	defer srv.Close()
+
+	sink, _ := NewLokiSink(LokiConfig{URL: srv.URL})
+	batch := []Record{{Time: time.Now(), Line: []byte("x")}}
+
+	if err := sink.Send(context.Background(), batch); !errors.Is(err, ErrRejected) {
+		t.Errorf("expected ErrRejected for a 400, got %v", err)
+	}
+	for _, status = range []int{http.StatusTooManyRequests, http.StatusInternalServerError} {
+		if err := sink.Send(context.Background(), batch); err == nil || errors.Is(err, ErrRejected) {
+			t.Errorf("expected a retryable error for a %d, got %v", status, err)
+		}
+	}
+}
+
+func TestNewLokiConfigFromEnv(t *testing.T) {
+	t.Setenv("LOG_LOKI_URL", "")
+	if _, err := NewLokiConfigFromEnv(); err == nil {
+		t.Error("expected an error without LOG_LOKI_URL")
+	}
+
+	t.Setenv("LOG_LOKI_URL", "http://loki:3100/loki/api/v1/push")
+	t.Setenv("LOG_LOKI_LABELS", "env=production, region=eu")
+	t.Setenv("LOG_LOKI_TENANT", "team-a")
+	cfg, err := NewLokiConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Labels["env"] != "production" || cfg.Labels["region"] != "eu" || cfg.TenantID != "team-a" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	t.Setenv("LOG_LOKI_LABELS", "env")
+	if _, err := NewLokiConfigFromEnv(); err == nil {
+		t.Error("expected an error for an invalid LOG_LOKI_LABELS")
+	}
+}
@@ -0,0 +1,293 @@
+// Package ship provides a Logrus hook shipping entries to a remote sink,
+// such as a Kafka topic or a Loki push endpoint, in batches with retries.
+// It is meant for environments without a node-level log collector; where a
+// collector tails stdout, prefer that.
+package ship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ranorsolutions/http-common-go/pkg/log/formatter"
+	"github.com/sirupsen/logrus"
+)
+
+// Defaults of Config.
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = time.Second
+	DefaultBufferSize    = 10000
+	DefaultMaxRetries    = 3
+	DefaultRetryBackoff  = 500 * time.Millisecond
+	DefaultSendTimeout   = 10 * time.Second
+)
+
+// ErrRejected marks errors of a sink that retrying cannot fix, such as a
+// malformed batch. Sinks wrap it; the hook drops the batch without retrying.
+var ErrRejected = errors.New("log batch rejected")
+
+// Record is a formatted entry handed to a Sink.
+type Record struct {
+	Time  time.Time
+	Level logrus.Level
+
+	// Line is the formatted entry, without a trailing newline.
+	Line []byte
+
+	// Labels are the values of Config.LabelFields found in the entry, plus
+	// the level, for sinks that index streams by label.
+	Labels map[string]string
+}
+
+// Sink delivers batches of records. Send is called from a single goroutine.
+type Sink interface {
+	Send(ctx context.Context, batch []Record) error
+}
+
+// Config configures a Hook.
+type Config struct {
+	// Records per batch. Defaults to DefaultBatchSize.
+	BatchSize int
+
+	// Maximum time a record waits for its batch to fill. Defaults to
+	// DefaultFlushInterval.
+	FlushInterval time.Duration
+
+	// Records queued before entries are dropped. Defaults to
+	// DefaultBufferSize.
+	BufferSize int
+
+	// Retries of a failed batch before it is dropped, with exponential
+	// backoff from RetryBackoff. Defaults to DefaultMaxRetries and
+	// DefaultRetryBackoff; a negative MaxRetries disables retries.
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	// Timeout of each Send. Defaults to DefaultSendTimeout.
+	SendTimeout time.Duration
+
+	// Levels shipped. Defaults to all levels.
+	Levels []logrus.Level
+
+	// Formatter of the lines. Defaults to a formatter.JSONFormatter.
+	Formatter logrus.Formatter
+
+	// Entry fields copied to Record.Labels. Defaults to service.
+	LabelFields []string
+}
+
+// Hook is a logrus.Hook queueing entries and shipping them to a Sink from
+// a background goroutine. Fire never blocks: when the queue is full, or a
+// batch still fails after the retries, entries are dropped and counted
+// (see Dropped) and the failure is reported on stderr.
+//
+// Call Close on shutdown so queued entries are shipped.
+type Hook struct {
+	sink Sink
+	cfg  Config
+
+	queue   chan Record
+	flushes chan chan struct{}
+	done    chan struct{}
+
+	// mu guards closed against Fire racing Close.
+	mu     sync.RWMutex
+	closed bool
+
+	dropped atomic.Uint64
+}
+
+// NewHook returns a Hook shipping to sink and starts its goroutine.
+//
+// Example:
+//
+//	sink, _ := ship.NewLokiSink(ship.LokiConfig{URL: "http://loki:3100/loki/api/v1/push"})
+//	hook := ship.NewHook(sink, ship.Config{})
+//	log, _ := logger.New("user-service", "1.0.0", false, logger.WithHook(hook))
+//	defer hook.Close()
+func NewHook(sink Sink, cfg Config) *Hook {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultBufferSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DefaultRetryBackoff
+	}
+	if cfg.SendTimeout <= 0 {
+		cfg.SendTimeout = DefaultSendTimeout
+	}
+	if len(cfg.Levels) == 0 {
+		cfg.Levels = logrus.AllLevels
+	}
+	if cfg.Formatter == nil {
+		cfg.Formatter = &formatter.JSONFormatter{}
+	}
+	if cfg.LabelFields == nil {
+		cfg.LabelFields = []string{"service"}
+	}
+
+	h := &Hook{
+		sink:    sink,
+		cfg:     cfg,
+		queue:   make(chan Record, cfg.BufferSize),
+		flushes: make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// Levels implements logrus.Hook.
+func (h *Hook) Levels() []logrus.Level {
+	return h.cfg.Levels
+}
+
+// Fire implements logrus.Hook.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	line, err := h.cfg.Formatter.Format(entry)
+	if err != nil {
+		return fmt.Errorf("failed to format log entry: %w", err)
+	}
+	labels := map[string]string{"level": entry.Level.String()}
+	for _, k := range h.cfg.LabelFields {
+		if v, ok := entry.Data[k]; ok {
+			labels[k] = fmt.Sprint(v)
+		}
+	}
+	// The formatter may write into the entry's buffer, which Logrus reuses
+	r := Record{
+		Time:   entry.Time,
+		Level:  entry.Level,
+		Line:   append([]byte(nil), trimNewline(line)...),
+		Labels: labels,
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return nil
+	}
+	select {
+	case h.queue <- r:
+	default:
+		h.dropped.Add(1)
+	}
+	return nil
+}
+
+// Flush ships the queued entries and waits for the batch to be sent or
+// dropped.
+func (h *Hook) Flush() error {
+	h.mu.RLock()
+	if h.closed {
+		h.mu.RUnlock()
+		return os.ErrClosed
+	}
+	flushed := make(chan struct{})
+	h.flushes <- flushed
+	h.mu.RUnlock()
+
+	<-flushed
+	return nil
+}
+
+// Close ships the queued entries and stops the goroutine.
+func (h *Hook) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	close(h.queue)
+	h.mu.Unlock()
+
+	<-h.done
+	return nil
+}
+
+// Dropped returns the number of entries dropped because the queue was full
+// or their batch could not be delivered.
+func (h *Hook) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
+func (h *Hook) run() {
+	defer close(h.done)
+	ticker := time.NewTicker(h.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, h.cfg.BatchSize)
+	ship := func() {
+		if len(batch) > 0 {
+			h.send(batch)
+			batch = make([]Record, 0, h.cfg.BatchSize)
+		}
+	}
+	for {
+		select {
+		case r, ok := <-h.queue:
+			if !ok {
+				ship()
+				return
+			}
+			batch = append(batch, r)
+			if len(batch) >= h.cfg.BatchSize {
+				ship()
+			}
+		case flushed := <-h.flushes:
+			// Take what was queued before the flush
+			for n := len(h.queue); n > 0; n-- {
+				batch = append(batch, <-h.queue)
+				if len(batch) >= h.cfg.BatchSize {
+					ship()
+				}
+			}
+			ship()
+			close(flushed)
+		case <-ticker.C:
+			ship()
+		}
+	}
+}
+
+// send delivers batch, retrying with exponential backoff.
+func (h *Hook) send(batch []Record) {
+	backoff := h.cfg.RetryBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), h.cfg.SendTimeout)
+		err = h.sink.Send(ctx, batch)
+		cancel()
+		if err == nil || errors.Is(err, ErrRejected) || attempt >= h.cfg.MaxRetries {
+			break
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	if err != nil {
+		h.dropped.Add(uint64(len(batch)))
+		// Logging through Logrus would feed the failure back into the hook
+		fmt.Fprintf(os.Stderr, "Failed to ship %d log entries, %v\n", len(batch), err)
+	}
+}
+
+func trimNewline(b []byte) []byte {
+	if n := len(b); n > 0 && b[n-1] == '\n' {
+		return b[:n-1]
+	}
+	return b
+}
@@ -0,0 +1,181 @@
+package ship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeSink records batches and fails the first failures sends.
+type fakeSink struct {
+	mu       sync.Mutex
+	batches  [][]Record
+	calls    int
+	failures int
+	err      error
+	gate     chan struct{}
+}
+
+func (s *fakeSink) Send(_ context.Context, batch []Record) error {
+	if s.gate != nil {
+		<-s.gate
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.calls <= s.failures {
+		return s.err
+	}
+	s.batches = append(s.batches, batch)
+	return nil
+}
+
+func (s *fakeSink) lines() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []string
+	for _, b := range s.batches {
+		for _, r := range b {
+			out = append(out, string(r.Line))
+		}
+	}
+	return out
+}
+
+func newShipLogger(sink Sink, cfg Config) (*logrus.Logger, *Hook) {
+	hook := NewHook(sink, cfg)
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.AddHook(hook)
+	return l, hook
+}
+
+func TestHook_BatchesBySize(t *testing.T) {
+	sink := &fakeSink{}
+	l, hook := newShipLogger(sink, Config{BatchSize: 2, FlushInterval: time.Hour})
+
+	entry := l.WithField("service", "svc")
+	for i := 0; i < 5; i++ {
+		entry.Infof("entry %d", i)
+	}
+	if err := hook.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(sink.batches) != 3 || len(sink.batches[0]) != 2 || len(sink.batches[2]) != 1 {
+		t.Fatalf("expected batches of 2, 2 and 1, got %d batches", len(sink.batches))
+	}
+	r := sink.batches[0][0]
+	if !strings.Contains(string(r.Line), `"msg":"entry 0"`) || strings.HasSuffix(string(r.Line), "\n") {
+		t.Errorf("expected a JSON line without newline, got %q", r.Line)
+	}
+	if r.Labels["service"] != "svc" || r.Labels["level"] != "info" {
+		t.Errorf("unexpected labels %v", r.Labels)
+	}
+}
+
+func TestHook_FlushInterval(t *testing.T) {
+	sink := &fakeSink{}
+	l, hook := newShipLogger(sink, Config{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer hook.Close()
+
+	l.Info("waits for the ticker")
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sink.lines()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(sink.lines()) != 1 {
+		t.Fatal("expected the partial batch to ship after the flush interval")
+	}
+}
+
+func TestHook_Flush(t *testing.T) {
+	sink := &fakeSink{}
+	l, hook := newShipLogger(sink, Config{FlushInterval: time.Hour})
+
+	l.Info("one")
+	l.Warn("two")
+	if err := hook.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := sink.lines(); len(got) != 2 {
+		t.Fatalf("expected both entries after Flush, got %v", got)
+	}
+
+	hook.Close()
+	if err := hook.Flush(); err == nil {
+		t.Error("expected an error from Flush after Close")
+	}
+	l.Info("after close")
+	if got := sink.lines(); len(got) != 2 {
+		t.Errorf("expected entries after Close to be ignored, got %v", got)
+	}
+}
+
+func TestHook_Retries(t *testing.T) {
+	sink := &fakeSink{failures: 2, err: errors.New("connection refused")}
+	l, hook := newShipLogger(sink, Config{MaxRetries: 2, RetryBackoff: time.Millisecond})
+
+	l.Error("eventually shipped")
+	hook.Close()
+	if sink.calls != 3 || len(sink.lines()) != 1 || hook.Dropped() != 0 {
+		t.Errorf("expected success on the third attempt, got %d calls, %d dropped", sink.calls, hook.Dropped())
+	}
+
+	sink = &fakeSink{failures: 10, err: errors.New("connection refused")}
+	l, hook = newShipLogger(sink, Config{MaxRetries: 1, RetryBackoff: time.Millisecond})
+	l.Error("a")
+	l.Error("b")
+	hook.Close()
+	if sink.calls != 2 || hook.Dropped() != 2 {
+		t.Errorf("expected the batch dropped after one retry, got %d calls, %d dropped", sink.calls, hook.Dropped())
+	}
+}
+
+func TestHook_RejectedNotRetried(t *testing.T) {
+	sink := &fakeSink{failures: 1, err: fmt.Errorf("%w: bad labels", ErrRejected)}
+	l, hook := newShipLogger(sink, Config{RetryBackoff: time.Millisecond})
+
+	l.Info("rejected")
+	hook.Close()
+	if sink.calls != 1 || hook.Dropped() != 1 {
+		t.Errorf("expected no retry for a rejected batch, got %d calls, %d dropped", sink.calls, hook.Dropped())
+	}
+}
+
+func TestHook_DropsWhenFull(t *testing.T) {
+	sink := &fakeSink{gate: make(chan struct{})}
+	l, hook := newShipLogger(sink, Config{BatchSize: 1, BufferSize: 1})
+
+	// The goroutine blocks sending the first entry; one more fits the queue
+	for i := 0; i < 10; i++ {
+		l.Info("x")
+	}
+	close(sink.gate)
+	hook.Close()
+
+	if shipped := uint64(len(sink.lines())); shipped+hook.Dropped() != 10 || hook.Dropped() < 7 {
+		t.Errorf("expected most entries dropped, got %d shipped and %d dropped", shipped, hook.Dropped())
+	}
+}
+
+func TestHook_Levels(t *testing.T) {
+	hook := NewHook(&fakeSink{}, Config{Levels: []logrus.Level{logrus.ErrorLevel}})
+	defer hook.Close()
+	if levels := hook.Levels(); len(levels) != 1 || levels[0] != logrus.ErrorLevel {
+		t.Errorf("unexpected levels %v", levels)
+	}
+
+	all := NewHook(&fakeSink{}, Config{})
+	defer all.Close()
+	if levels := all.Levels(); len(levels) != len(logrus.AllLevels) {
+		t.Errorf("expected all levels by default, got %v", levels)
+	}
+}
@@ -0,0 +1,89 @@
+package syslog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// parseJournal decodes a native protocol datagram.
+func parseJournal(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for len(data) > 0 {
+		nl := bytes.IndexByte(data, '\n')
+		line := data[:nl]
+		if eq := bytes.IndexByte(line, '='); eq >= 0 {
+			fields[string(line[:eq])] = string(line[eq+1:])
+			data = data[nl+1:]
+			continue
+		}
+		size := binary.LittleEndian.Uint64(data[nl+1 : nl+9])
+		fields[string(line)] = string(data[nl+9 : nl+9+int(size)])
+		data = data[nl+9+int(size)+1:]
+	}
+	return fields
+}
+
+func TestJournalHook(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	server, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer server.Close()
+
+	hook, err := NewJournalHook(socket, "user-service")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer hook.Close()
+
+	entry := testEntry()
+	entry.Level = logrus.WarnLevel
+	entry.Message = "line one\nline two"
+	entry.Data = logrus.Fields{"request_id": "abc", "2xx": 1, "priority": "spoofed", "http.status": 200}
+	if err := hook.Fire(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := server.Read(buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := parseJournal(t, buf[:n])
+	want := map[string]string{
+		"MESSAGE":           "line one\nline two",
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": "user-service",
+		"REQUEST_ID":        "abc",
+		"XX":                "1",
+		"HTTP_STATUS":       "200",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("unexpected fields %v", got)
+	}
+}
+
+func TestJournalHook_NoServer(t *testing.T) {
+	hook, err := NewJournalHook(filepath.Join(t.TempDir(), "missing.sock"), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer hook.Close()
+	if err := hook.Fire(testEntry()); err == nil {
+		t.Error("expected an error without a journal")
+	}
+}
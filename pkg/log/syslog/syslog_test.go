@@ -0,0 +1,184 @@
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func testEntry() *logrus.Entry {
+	return &logrus.Entry{
+		Logger:  logrus.New(),
+		Time:    time.Date(2024, 11, 10, 12, 0, 0, 0, time.UTC),
+		Level:   logrus.InfoLevel,
+		Message: "request completed",
+		Data:    logrus.Fields{"request_id": "abc", "status": 200, "path": `/a"b]`},
+	}
+}
+
+func TestHook_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pc.Close()
+
+	hook, err := NewHook(Config{Network: "udp", Address: pc.LocalAddr().String(), Facility: FacilityLocal0, AppName: "user-service", Hostname: "host"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer hook.Close()
+	if err := hook.Fire(testEntry()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := regexp.MustCompile(`^<134>1 2024-11-10T12:00:00Z host user-service \d+ - \[fields@32473 path="/a\\"b\\]" request_id="abc" status="200"\] request completed$`)
+	if got := string(buf[:n]); !want.MatchString(got) {
+		t.Errorf("unexpected message %q", got)
+	}
+}
+
+func TestHook_TCPFramingAndReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ln.Close()
+
+	messages := make(chan string, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				r := bufio.NewReader(conn)
+				for {
+					size, err := r.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(size))
+					msg := make([]byte, n)
+					if _, err := r.Read(msg); err != nil {
+						return
+					}
+					messages <- string(msg)
+				}
+			}()
+		}
+	}()
+
+	hook, err := NewHook(Config{Network: "tcp", Address: ln.Addr().String(), Hostname: "host", AppName: "svc"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer hook.Close()
+
+	entry := testEntry()
+	entry.Level = logrus.ErrorLevel
+	if err := hook.Fire(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg := <-messages; !strings.HasPrefix(msg, "<11>1 ") || !strings.HasSuffix(msg, " request completed") {
+		t.Errorf("unexpected message %q", msg)
+	}
+
+	// A closed connection is re-established on the next entry
+	hook.conn.Close()
+	if err := hook.Fire(entry); err != nil {
+		t.Fatalf("expected reconnect, got %v", err)
+	}
+	select {
+	case <-messages:
+	case <-time.After(time.Second):
+		t.Fatal("expected the message after reconnecting")
+	}
+}
+
+func TestHook_DialError(t *testing.T) {
+	if _, err := NewHook(Config{}); err == nil {
+		t.Error("expected an error without an address")
+	}
+	if _, err := NewHook(Config{Network: "udp", Address: "x", Facility: 24}); err == nil {
+		t.Error("expected an error for an invalid facility")
+	}
+
+	hook := &Hook{cfg: Config{Network: "tcp", Address: "x", SDID: DefaultSDID}, dial: func(string, string) (net.Conn, error) {
+		return nil, errors.New("refused")
+	}}
+	if err := hook.Fire(testEntry()); err == nil || !strings.Contains(err.Error(), "refused") {
+		t.Errorf("expected the dial error, got %v", err)
+	}
+}
+
+func TestStructuredData(t *testing.T) {
+	if got := StructuredData("id", nil); got != "-" {
+		t.Errorf("expected nil SD for no fields, got %q", got)
+	}
+	got := StructuredData("id", logrus.Fields{"a key=x": 1, "===": 2, strings.Repeat("k", 40): `\`})
+	want := `[id a_key_x="1" ` + strings.Repeat("k", 32) + `="\\"]`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	want := map[logrus.Level]int{
+		logrus.PanicLevel: 2, logrus.FatalLevel: 2, logrus.ErrorLevel: 3,
+		logrus.WarnLevel: 4, logrus.InfoLevel: 6, logrus.DebugLevel: 7, logrus.TraceLevel: 7,
+	}
+	for lvl, sev := range want {
+		if got := Severity(lvl); got != sev {
+			t.Errorf("Severity(%v) = %d, want %d", lvl, got, sev)
+		}
+	}
+}
+
+func TestNewConfigFromEnv(t *testing.T) {
+	t.Setenv("LOG_SYSLOG_ADDR", "tcp://logs.internal:601")
+	t.Setenv("LOG_SYSLOG_FACILITY", "LOCAL3")
+	t.Setenv("LOG_SYSLOG_APP_NAME", "billing")
+
+	cfg, err := NewConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Config{Network: "tcp", Address: "logs.internal:601", Facility: FacilityLocal3, AppName: "billing"}
+	if *cfg != want {
+		t.Errorf("got %+v, want %+v", *cfg, want)
+	}
+
+	t.Setenv("LOG_SYSLOG_ADDR", "unix:///dev/log")
+	t.Setenv("LOG_SYSLOG_FACILITY", "")
+	cfg, err = NewConfigFromEnv()
+	if err != nil || cfg.Network != "unix" || cfg.Address != "/dev/log" || cfg.Facility != FacilityUser {
+		t.Errorf("unexpected config %+v, %v", cfg, err)
+	}
+
+	for _, addr := range []string{"", "http://logs:514", "udp://"} {
+		t.Setenv("LOG_SYSLOG_ADDR", addr)
+		if _, err := NewConfigFromEnv(); err == nil {
+			t.Errorf("expected an error for LOG_SYSLOG_ADDR=%q", addr)
+		}
+	}
+	t.Setenv("LOG_SYSLOG_ADDR", "udp://logs:514")
+	t.Setenv("LOG_SYSLOG_FACILITY", "mail2")
+	if _, err := NewConfigFromEnv(); err == nil {
+		t.Error("expected an error for an unknown facility")
+	}
+}
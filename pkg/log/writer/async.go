@@ -0,0 +1,192 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultAsyncBufferSize is the number of entries an AsyncWriter queues
+// unless AsyncConfig.BufferSize is set.
+const DefaultAsyncBufferSize = 1024
+
+// AsyncConfig configures an AsyncWriter.
+type AsyncConfig struct {
+	// Number of entries queued before writes drop or block. Defaults to
+	// DefaultAsyncBufferSize.
+	BufferSize int
+
+	// Block writers while the queue is full instead of dropping entries.
+	Block bool
+}
+
+// NewAsyncConfigFromEnv returns an AsyncConfig from environment variables.
+//
+// Environment variables:
+//
+//	LOG_ASYNC_BUFFER  number of entries queued (default 1024)
+//	LOG_ASYNC_BLOCK   block instead of dropping when full (default false)
+func NewAsyncConfigFromEnv() (*AsyncConfig, error) {
+	cfg := &AsyncConfig{}
+	if v := os.Getenv("LOG_ASYNC_BUFFER"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid LOG_ASYNC_BUFFER %q", v)
+		}
+		cfg.BufferSize = n
+	}
+	if v := os.Getenv("LOG_ASYNC_BLOCK"); v != "" {
+		block, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LOG_ASYNC_BLOCK %q", v)
+		}
+		cfg.Block = block
+	}
+	return cfg, nil
+}
+
+// asyncItem is an entry to write, or a flush marker when flushed is set.
+type asyncItem struct {
+	p       []byte
+	flushed chan struct{}
+}
+
+// AsyncWriter is an io.WriteCloser queueing writes in a bounded buffer and
+// writing them to an underlying writer from a background goroutine, so
+// callers do not wait on slow outputs such as a terminal or pipe. When the
+// buffer is full, entries are dropped and counted (see Dropped) unless
+// AsyncConfig.Block is set.
+//
+// Call Close on shutdown, or Flush before exiting, or queued entries are
+// lost. It is safe for concurrent use.
+type AsyncWriter struct {
+	w     io.Writer
+	block bool
+	queue chan asyncItem
+	done  chan struct{}
+
+	// mu guards closed against writes racing Close.
+	mu     sync.RWMutex
+	closed bool
+
+	dropped atomic.Uint64
+	errMu   sync.Mutex
+	err     error
+}
+
+// NewAsyncWriter returns an AsyncWriter writing to w and starts its
+// goroutine.
+//
+// Example:
+//
+//	out := writer.NewAsyncWriter(os.Stderr, writer.AsyncConfig{BufferSize: 4096})
+//	defer out.Close()
+func NewAsyncWriter(w io.Writer, cfg AsyncConfig) *AsyncWriter {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultAsyncBufferSize
+	}
+	a := &AsyncWriter{
+		w:     w,
+		block: cfg.Block,
+		queue: make(chan asyncItem, cfg.BufferSize),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// Write implements io.Writer. It copies p, as Logrus reuses its buffers,
+// and queues it; it returns os.ErrClosed after Close. Errors of the
+// underlying writer are reported by Flush and Close.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return 0, os.ErrClosed
+	}
+
+	item := asyncItem{p: append([]byte(nil), p...)}
+	if a.block {
+		a.queue <- item
+		return len(p), nil
+	}
+	select {
+	case a.queue <- item:
+	default:
+		a.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Flush waits until the entries queued before the call are written, and
+// returns the first error of the underlying writer since the last Flush.
+func (a *AsyncWriter) Flush() error {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return os.ErrClosed
+	}
+	flushed := make(chan struct{})
+	a.queue <- asyncItem{flushed: flushed}
+	a.mu.RUnlock()
+
+	<-flushed
+	return a.takeErr()
+}
+
+// Close writes the queued entries and stops the goroutine. The underlying
+// writer is not closed.
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+
+	<-a.done
+	return a.takeErr()
+}
+
+// IsTerminal reports whether the underlying writer is a terminal, see
+// IsTerminal.
+func (a *AsyncWriter) IsTerminal() bool {
+	return IsTerminal(a.w)
+}
+
+// Dropped returns the number of entries dropped because the buffer was
+// full.
+func (a *AsyncWriter) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+	for item := range a.queue {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		if _, err := a.w.Write(item.p); err != nil {
+			a.errMu.Lock()
+			if a.err == nil {
+				a.err = fmt.Errorf("failed to write log entry: %w", err)
+			}
+			a.errMu.Unlock()
+		}
+	}
+}
+
+// takeErr returns and clears the first write error.
+func (a *AsyncWriter) takeErr() error {
+	a.errMu.Lock()
+	defer a.errMu.Unlock()
+	err := a.err
+	a.err = nil
+	return err
+}
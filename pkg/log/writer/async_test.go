@@ -0,0 +1,143 @@
+package writer
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// gatedWriter records writes, blocking each one until gate is closed.
+type gatedWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	err  error
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	if w.gate != nil {
+		<-w.gate
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter_FlushAndClose(t *testing.T) {
+	out := &gatedWriter{}
+	a := NewAsyncWriter(out, AsyncConfig{})
+
+	buf := []byte("first\n")
+	a.Write(buf)
+	copy(buf, "reused")
+	a.Write([]byte("second\n"))
+
+	if err := a.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.String(); got != "first\nsecond\n" {
+		t.Errorf("expected queued entries copied and written in order, got %q", got)
+	}
+
+	a.Write([]byte("third\n"))
+	if err := a.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "third\n") {
+		t.Errorf("expected Close to drain the queue, got %q", out.String())
+	}
+	if _, err := a.Write([]byte("late\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected os.ErrClosed after Close, got %v", err)
+	}
+	if err := a.Flush(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected os.ErrClosed from Flush after Close, got %v", err)
+	}
+	if err := a.Close(); err != nil {
+		t.Errorf("expected a second Close to be a no-op, got %v", err)
+	}
+}
+
+func TestAsyncWriter_DropsWhenFull(t *testing.T) {
+	out := &gatedWriter{gate: make(chan struct{})}
+	a := NewAsyncWriter(out, AsyncConfig{BufferSize: 2})
+
+	// One entry may be taken by the goroutine and block on the gate; at
+	// most three fit, so at least two of five are dropped
+	for i := 0; i < 5; i++ {
+		if n, err := a.Write([]byte("x\n")); n != 2 || err != nil {
+			t.Fatalf("expected drops to look like successful writes, got %d %v", n, err)
+		}
+	}
+	if d := a.Dropped(); d < 2 || d > 3 {
+		t.Errorf("expected 2 or 3 dropped entries, got %d", d)
+	}
+
+	close(out.gate)
+	a.Close()
+	if got := strings.Count(out.String(), "x\n"); uint64(got)+a.Dropped() != 5 {
+		t.Errorf("expected written plus dropped to be 5, got %d + %d", got, a.Dropped())
+	}
+}
+
+func TestAsyncWriter_Block(t *testing.T) {
+	out := &gatedWriter{gate: make(chan struct{})}
+	a := NewAsyncWriter(out, AsyncConfig{BufferSize: 1, Block: true})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			a.Write([]byte("x\n"))
+		}
+		close(done)
+	}()
+	close(out.gate)
+	<-done
+	a.Close()
+
+	if got := strings.Count(out.String(), "x\n"); got != 5 || a.Dropped() != 0 {
+		t.Errorf("expected every entry written, got %d written and %d dropped", got, a.Dropped())
+	}
+}
+
+func TestAsyncWriter_WriteError(t *testing.T) {
+	out := &gatedWriter{err: errors.New("disk full")}
+	a := NewAsyncWriter(out, AsyncConfig{})
+
+	a.Write([]byte("x\n"))
+	if err := a.Flush(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("expected the write error from Flush, got %v", err)
+	}
+	if err := a.Flush(); err != nil {
+		t.Errorf("expected the error to be reported once, got %v", err)
+	}
+	a.Close()
+}
+
+func TestNewAsyncConfigFromEnv(t *testing.T) {
+	t.Setenv("LOG_ASYNC_BUFFER", "64")
+	t.Setenv("LOG_ASYNC_BLOCK", "true")
+	cfg, err := NewAsyncConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.BufferSize != 64 || !cfg.Block {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	t.Setenv("LOG_ASYNC_BUFFER", "0")
+	if _, err := NewAsyncConfigFromEnv(); err == nil {
+		t.Error("expected an error for a zero LOG_ASYNC_BUFFER")
+	}
+}
@@ -0,0 +1,322 @@
+// Package writer provides log outputs for services without a log collector
+// sidecar, such as rotating files.
+package writer
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSize is the size at which a RotatingFile rotates unless
+// FileConfig.MaxSize is set.
+const DefaultMaxSize = 100 << 20
+
+// backupTimeFormat is the timestamp inserted into backup file names.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// FileConfig configures a RotatingFile.
+type FileConfig struct {
+	// Path of the active log file. Backups are written next to it as
+	// <name>-<timestamp><ext>, e.g. app-2024-11-10T12-00-00.000.log.
+	Filename string
+
+	// Size in bytes at which the file is rotated. Defaults to DefaultMaxSize;
+	// negative disables size-based rotation.
+	MaxSize int64
+
+	// Rotate when this interval elapses, aligned to UTC (24h rotates at
+	// midnight). Zero disables time-based rotation.
+	Interval time.Duration
+
+	// Delete backups older than MaxAge. Zero keeps them regardless of age.
+	MaxAge time.Duration
+
+	// Keep at most MaxBackups backups, deleting the oldest. Zero keeps all.
+	MaxBackups int
+
+	// Gzip backups after rotation.
+	Compress bool
+}
+
+// NewFileConfigFromEnv returns a FileConfig from environment variables.
+//
+// Environment variables:
+//
+//	LOG_FILE                  path of the log file (required)
+//	LOG_FILE_MAX_SIZE_MB      rotate at this size in MiB (default 100)
+//	LOG_FILE_ROTATE_INTERVAL  rotate after this duration, e.g. 24h (default off)
+//	LOG_FILE_MAX_AGE          delete backups older than this, e.g. 168h (default off)
+//	LOG_FILE_MAX_BACKUPS      number of backups to keep (default all)
+//	LOG_FILE_COMPRESS         gzip backups (default false)
+func NewFileConfigFromEnv() (*FileConfig, error) {
+	cfg := &FileConfig{Filename: os.Getenv("LOG_FILE")}
+	if cfg.Filename == "" {
+		return nil, errors.New("LOG_FILE is required")
+	}
+
+	if v := os.Getenv("LOG_FILE_MAX_SIZE_MB"); v != "" {
+		mb, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || mb <= 0 {
+			return nil, fmt.Errorf("invalid LOG_FILE_MAX_SIZE_MB %q", v)
+		}
+		cfg.MaxSize = mb << 20
+	}
+	for name, dst := range map[string]*time.Duration{
+		"LOG_FILE_ROTATE_INTERVAL": &cfg.Interval,
+		"LOG_FILE_MAX_AGE":         &cfg.MaxAge,
+	} {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("invalid %s %q", name, v)
+			}
+			*dst = d
+		}
+	}
+	if v := os.Getenv("LOG_FILE_MAX_BACKUPS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid LOG_FILE_MAX_BACKUPS %q", v)
+		}
+		cfg.MaxBackups = n
+	}
+	if v := os.Getenv("LOG_FILE_COMPRESS"); v != "" {
+		compress, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LOG_FILE_COMPRESS %q", v)
+		}
+		cfg.Compress = compress
+	}
+	return cfg, nil
+}
+
+// RotatingFile is an io.WriteCloser appending to a file that is rotated by
+// size and/or time. After each rotation, backups are compressed and pruned
+// in the background. It is safe for concurrent use.
+type RotatingFile struct {
+	cfg FileConfig
+
+	mu         sync.Mutex
+	file       *os.File
+	size       int64
+	nextRotate time.Time
+
+	// now is replaced in tests.
+	now func() time.Time
+
+	// mill serializes background compression and pruning.
+	mill sync.Mutex
+	wg   sync.WaitGroup
+}
+
+// NewRotatingFile opens cfg.Filename for appending, creating it and its
+// directory if needed.
+func NewRotatingFile(cfg FileConfig) (*RotatingFile, error) {
+	if cfg.Filename == "" {
+		return nil, errors.New("log file name is required")
+	}
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = DefaultMaxSize
+	}
+	f := &RotatingFile{cfg: cfg, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write implements io.Writer, rotating first when p would exceed MaxSize or
+// the rotation interval elapsed. An entry larger than MaxSize is written to
+// a file of its own.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.due(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the active file, renames it to a backup and opens a new one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Close closes the file and waits for background compression and pruning.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+	return err
+}
+
+func (f *RotatingFile) due(n int64) bool {
+	if f.cfg.MaxSize > 0 && f.size > 0 && f.size+n > f.cfg.MaxSize {
+		return true
+	}
+	return !f.nextRotate.IsZero() && !f.now().Before(f.nextRotate)
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.cfg.Filename), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(f.cfg.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	if f.cfg.Interval > 0 {
+		f.nextRotate = f.now().UTC().Truncate(f.cfg.Interval).Add(f.cfg.Interval)
+	}
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	f.file = nil
+	if err := os.Rename(f.cfg.Filename, f.backupName(f.now())); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to rename log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.mill.Lock()
+		defer f.mill.Unlock()
+		f.millBackups()
+	}()
+	return nil
+}
+
+// backupName returns the backup path for a rotation at t.
+func (f *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := f.nameParts()
+	return filepath.Join(dir, prefix+t.UTC().Format(backupTimeFormat)+ext)
+}
+
+func (f *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(f.cfg.Filename)
+	base := filepath.Base(f.cfg.Filename)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+// backup is a rotated file found on disk.
+type backup struct {
+	path string
+	at   time.Time
+}
+
+// backups returns the rotated files, newest first.
+func (f *RotatingFile) backups() ([]backup, error) {
+	dir, prefix, ext := f.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var found []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimPrefix(name, prefix)
+		stamp = strings.TrimSuffix(strings.TrimSuffix(stamp, ".gz"), ext)
+		at, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		found = append(found, backup{path: filepath.Join(dir, name), at: at})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].at.After(found[j].at) })
+	return found, nil
+}
+
+// millBackups prunes backups beyond MaxBackups or MaxAge and compresses the
+// rest. Errors are ignored: the next rotation retries.
+func (f *RotatingFile) millBackups() {
+	found, err := f.backups()
+	if err != nil {
+		return
+	}
+	cutoff := time.Time{}
+	if f.cfg.MaxAge > 0 {
+		cutoff = f.now().Add(-f.cfg.MaxAge)
+	}
+	for i, b := range found {
+		if (f.cfg.MaxBackups > 0 && i >= f.cfg.MaxBackups) || (!cutoff.IsZero() && b.at.Before(cutoff)) {
+			_ = os.Remove(b.path)
+			continue
+		}
+		if f.cfg.Compress && !strings.HasSuffix(b.path, ".gz") {
+			_ = compressFile(b.path)
+		}
+	}
+}
+
+// compressFile gzips path to path.gz and removes path.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
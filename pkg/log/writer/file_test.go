@@ -0,0 +1,201 @@
+package writer
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock returns a controllable time source.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestFile(t *testing.T, cfg FileConfig) (*RotatingFile, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{t: time.Date(2024, 11, 10, 12, 0, 0, 0, time.UTC)}
+	cfg.Filename = filepath.Join(t.TempDir(), "logs", "app.log")
+	f, err := NewRotatingFile(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.now = clock.now
+	if cfg.Interval > 0 {
+		f.nextRotate = clock.t.Truncate(cfg.Interval).Add(cfg.Interval)
+	}
+	return f, clock
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func write(t *testing.T, f *RotatingFile, s string) {
+	t.Helper()
+	if _, err := f.Write([]byte(s)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRotatingFile_Size(t *testing.T) {
+	f, clock := newTestFile(t, FileConfig{MaxSize: 10})
+	dir := filepath.Dir(f.cfg.Filename)
+
+	write(t, f, "12345\n")
+	write(t, f, "123\n")
+	if names := listDir(t, dir); len(names) != 1 {
+		t.Fatalf("expected no rotation within MaxSize, got %v", names)
+	}
+
+	clock.advance(time.Second)
+	write(t, f, "abc\n")
+	if err := f.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	names := listDir(t, dir)
+	want := []string{"app-2024-11-10T12-00-01.000.log", "app.log"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+	backup, _ := os.ReadFile(filepath.Join(dir, want[0]))
+	active, _ := os.ReadFile(f.cfg.Filename)
+	if string(backup) != "12345\n123\n" || string(active) != "abc\n" {
+		t.Errorf("unexpected contents %q / %q", backup, active)
+	}
+}
+
+func TestRotatingFile_Interval(t *testing.T) {
+	f, clock := newTestFile(t, FileConfig{Interval: time.Hour, MaxSize: -1})
+	write(t, f, "a\n")
+	clock.advance(30 * time.Minute)
+	write(t, f, "b\n")
+	clock.advance(30 * time.Minute)
+	write(t, f, "c\n")
+	f.Close()
+
+	names := listDir(t, filepath.Dir(f.cfg.Filename))
+	if len(names) != 2 || names[0] != "app-2024-11-10T13-00-00.000.log" {
+		t.Errorf("expected one hourly rotation, got %v", names)
+	}
+}
+
+func TestRotatingFile_PruneAndCompress(t *testing.T) {
+	f, clock := newTestFile(t, FileConfig{MaxBackups: 2, MaxAge: 90 * time.Minute, Compress: true})
+	dir := filepath.Dir(f.cfg.Filename)
+
+	for i := 0; i < 4; i++ {
+		write(t, f, "entry\n")
+		clock.advance(time.Hour)
+		if err := f.Rotate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		f.wg.Wait()
+	}
+	f.Close()
+
+	names := listDir(t, dir)
+	want := []string{"app-2024-11-10T15-00-00.000.log.gz", "app-2024-11-10T16-00-00.000.log.gz", "app.log"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+
+	gz, err := os.Open(filepath.Join(dir, want[0]))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer gz.Close()
+	zr, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := io.ReadAll(zr)
+	if string(data) != "entry\n" {
+		t.Errorf("unexpected compressed contents %q", data)
+	}
+}
+
+func TestRotatingFile_AppendsAndClose(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(name, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewRotatingFile(FileConfig{Filename: name})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.size != 4 || f.cfg.MaxSize != DefaultMaxSize {
+		t.Errorf("expected existing size and default MaxSize, got %d / %d", f.size, f.cfg.MaxSize)
+	}
+	write(t, f, "new\n")
+	f.Close()
+
+	if _, err := f.Write([]byte("x")); err == nil {
+		t.Error("expected writes after Close to fail")
+	}
+	if err := f.Rotate(); err == nil {
+		t.Error("expected Rotate after Close to fail")
+	}
+	data, _ := os.ReadFile(name)
+	if string(data) != "old\nnew\n" {
+		t.Errorf("expected appended contents, got %q", data)
+	}
+
+	if _, err := NewRotatingFile(FileConfig{}); err == nil {
+		t.Error("expected an error without a file name")
+	}
+}
+
+func TestNewFileConfigFromEnv(t *testing.T) {
+	t.Setenv("LOG_FILE", "/var/log/app.log")
+	t.Setenv("LOG_FILE_MAX_SIZE_MB", "50")
+	t.Setenv("LOG_FILE_ROTATE_INTERVAL", "24h")
+	t.Setenv("LOG_FILE_MAX_AGE", "168h")
+	t.Setenv("LOG_FILE_MAX_BACKUPS", "7")
+	t.Setenv("LOG_FILE_COMPRESS", "true")
+
+	cfg, err := NewFileConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := FileConfig{Filename: "/var/log/app.log", MaxSize: 50 << 20, Interval: 24 * time.Hour, MaxAge: 168 * time.Hour, MaxBackups: 7, Compress: true}
+	if *cfg != want {
+		t.Errorf("got %+v, want %+v", *cfg, want)
+	}
+
+	for key, value := range map[string]string{
+		"LOG_FILE_MAX_SIZE_MB":     "0",
+		"LOG_FILE_ROTATE_INTERVAL": "daily",
+		"LOG_FILE_MAX_BACKUPS":     "-1",
+		"LOG_FILE_COMPRESS":        "maybe",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			if _, err := NewFileConfigFromEnv(); err == nil {
+				t.Errorf("expected an error for %s=%s", key, value)
+			}
+		})
+	}
+
+	t.Setenv("LOG_FILE", "")
+	if _, err := NewFileConfigFromEnv(); err == nil {
+		t.Error("expected an error without LOG_FILE")
+	}
+}
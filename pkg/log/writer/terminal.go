@@ -0,0 +1,47 @@
+package writer
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// IsTerminal reports whether w writes to a terminal: an *os.File attached
+// to one, or a writer of this package wrapping only terminals. Formatters
+// use it to decide whether to color their output.
+func IsTerminal(w io.Writer) bool {
+	switch v := w.(type) {
+	case *os.File:
+		return term.IsTerminal(int(v.Fd()))
+	case interface{ IsTerminal() bool }:
+		return v.IsTerminal()
+	default:
+		return false
+	}
+}
+
+// MultiWriter duplicates writes to several writers, like io.MultiWriter,
+// and reports itself as a terminal only if all of them are, so entries
+// written to a terminal and a file at once are never colored.
+type MultiWriter struct {
+	io.Writer
+	terminal bool
+}
+
+// NewMultiWriter returns a MultiWriter writing to ws.
+func NewMultiWriter(ws ...io.Writer) *MultiWriter {
+	terminal := len(ws) > 0
+	for _, w := range ws {
+		if !IsTerminal(w) {
+			terminal = false
+			break
+		}
+	}
+	return &MultiWriter{Writer: io.MultiWriter(ws...), terminal: terminal}
+}
+
+// IsTerminal reports whether every writer is a terminal.
+func (m *MultiWriter) IsTerminal() bool {
+	return m.terminal
+}
@@ -0,0 +1,54 @@
+package writer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeTerminal is a writer reporting itself as a terminal.
+type fakeTerminal struct {
+	bytes.Buffer
+}
+
+func (*fakeTerminal) IsTerminal() bool { return true }
+
+func TestIsTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "app.log"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	if IsTerminal(f) || IsTerminal(&bytes.Buffer{}) {
+		t.Error("expected files and buffers not to be terminals")
+	}
+	if !IsTerminal(&fakeTerminal{}) {
+		t.Error("expected writers with IsTerminal to be asked")
+	}
+
+	a := NewAsyncWriter(&fakeTerminal{}, AsyncConfig{})
+	defer a.Close()
+	if !IsTerminal(a) {
+		t.Error("expected an AsyncWriter to report its underlying writer")
+	}
+}
+
+func TestMultiWriter(t *testing.T) {
+	tty1, tty2, file := &fakeTerminal{}, &fakeTerminal{}, &bytes.Buffer{}
+
+	m := NewMultiWriter(tty1, tty2)
+	if !m.IsTerminal() {
+		t.Error("expected terminals only to be a terminal")
+	}
+	m = NewMultiWriter(tty1, file)
+	if m.IsTerminal() {
+		t.Error("expected a terminal and a file not to be a terminal")
+	}
+
+	m.Write([]byte("x\n"))
+	if tty1.String() != "x\n" || file.String() != "x\n" {
+		t.Errorf("expected writes to reach every writer, got %q and %q", tty1.String(), file.String())
+	}
+}
@@ -0,0 +1,229 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/sns"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/sqs"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+)
+
+// snsSender is the part of *sns.Client used by SNSPublisher.
+type snsSender interface {
+	PublishStringWithOptions(ctx context.Context, topicARN, message string, opts sns.PublishOptions) (string, error)
+}
+
+// SNSPublisher publishes through an sns.Client. Headers become string
+// message attributes; the key is used as message group of FIFO topics.
+type SNSPublisher struct {
+	client snsSender
+}
+
+// NewSNSPublisher adapts client to Publisher.
+func NewSNSPublisher(client *sns.Client) *SNSPublisher {
+	return &SNSPublisher{client: client}
+}
+
+func newSNSPublisherFromEnv() (Publisher, error) {
+	cfg, err := sns.NewConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	client, err := sns.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewSNSPublisher(client), nil
+}
+
+// Publish implements Publisher.
+func (p *SNSPublisher) Publish(ctx context.Context, msg *Message) error {
+	opts := sns.PublishOptions{Attributes: msg.Headers}
+	if strings.HasSuffix(msg.Topic, ".fifo") {
+		opts.GroupID = msg.Key
+	}
+	_, err := p.client.PublishStringWithOptions(ctx, msg.Topic, string(msg.Payload), opts)
+	return err
+}
+
+// Close implements Publisher. SNS clients hold no resources.
+func (p *SNSPublisher) Close() error {
+	return nil
+}
+
+// sqsSender is the part of *sqs.Producer used by SQSPublisher.
+type sqsSender interface {
+	SendStringWithOptions(ctx context.Context, queueURL, body string, opts sqs.SendOptions) (string, error)
+}
+
+// SQSPublisher sends to SQS queues through an sqs.Producer. Topics are queue
+// URLs, headers become string message attributes and the key is used as
+// message group of FIFO queues.
+type SQSPublisher struct {
+	producer sqsSender
+}
+
+// NewSQSPublisher adapts producer to Publisher.
+func NewSQSPublisher(producer *sqs.Producer) *SQSPublisher {
+	return &SQSPublisher{producer: producer}
+}
+
+func newSQSPublisherFromEnv() (Publisher, error) {
+	cfg, err := sqs.NewConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	producer, err := sqs.NewProducer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewSQSPublisher(producer), nil
+}
+
+// Publish implements Publisher.
+func (p *SQSPublisher) Publish(ctx context.Context, msg *Message) error {
+	return p.PublishAfter(ctx, 0, msg)
+}
+
+// PublishAfter implements DelayedPublisher with the SQS message delay, so
+// delay is at most sqs.MaxDelay. FIFO queues do not support per-message
+// delays.
+func (p *SQSPublisher) PublishAfter(ctx context.Context, delay time.Duration, msg *Message) error {
+	opts := sqs.SendOptions{Attributes: msg.Headers, Delay: delay}
+	if strings.HasSuffix(msg.Topic, ".fifo") {
+		opts.GroupID = msg.Key
+	}
+	_, err := p.producer.SendStringWithOptions(ctx, msg.Topic, string(msg.Payload), opts)
+	return err
+}
+
+// Close implements Publisher. SQS producers hold no resources.
+func (p *SQSPublisher) Close() error {
+	return nil
+}
+
+// SQSSubscriber receives from SQS queues, typically subscribed to SNS
+// topics with sns.Client.SubscribeQueue. The topics passed to Subscribe are
+// queue URLs. Messages delivered by SNS without raw message delivery are
+// unwrapped from the SNS envelope.
+type SQSSubscriber struct {
+	cfg  *sqs.Config
+	opts []sqs.ConsumerOption
+}
+
+// NewSQSSubscriber returns a Subscriber using cfg for every queue; its
+// QueueURL is replaced by the topics passed to Subscribe.
+func NewSQSSubscriber(cfg *sqs.Config, opts ...sqs.ConsumerOption) *SQSSubscriber {
+	return &SQSSubscriber{cfg: cfg, opts: opts}
+}
+
+func newSQSSubscriberFromEnv() (Subscriber, error) {
+	cfg, err := sqs.NewConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewSQSSubscriber(cfg), nil
+}
+
+// Subscribe implements Subscriber, running one consumer per queue.
+func (s *SQSSubscriber) Subscribe(ctx context.Context, topics []string, handler Handler) error {
+	consumers := make([]*sqs.Consumer, len(topics))
+	for i, queueURL := range topics {
+		cfg := *s.cfg
+		cfg.QueueURL = queueURL
+		c, err := sqs.NewConsumer(&cfg, sqsHandler{queueURL: queueURL, handler: handler}, s.opts...)
+		if err != nil {
+			return err
+		}
+		consumers[i] = c
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(consumers))
+	for i, c := range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.Run(ctx)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Close implements Subscriber. Consumers stop when the Subscribe context is
+// canceled.
+func (s *SQSSubscriber) Close() error {
+	return nil
+}
+
+// sqsHandler adapts a Handler to sqs.MessageHandler.
+type sqsHandler struct {
+	queueURL string
+	handler  Handler
+}
+
+func (h sqsHandler) HandleMessage(ctx context.Context, msg *types.Message) error {
+	m := &Message{
+		ID:      aws.ToString(msg.MessageId),
+		Topic:   h.queueURL,
+		Payload: []byte(aws.ToString(msg.Body)),
+		Headers: sqs.Attributes(msg),
+	}
+	if msg.Attributes != nil {
+		m.Key = msg.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]
+	}
+	if unwrapSNSEnvelope(m) && reqctx.RequestMetadataFromContext(ctx).IsZero() {
+		ctx = correlation.Context(ctx, m.Headers)
+	}
+	return h.handler.Handle(ctx, m)
+}
+
+// snsEnvelope is the JSON document SNS wraps messages in when raw message
+// delivery is disabled.
+type snsEnvelope struct {
+	Type              string
+	MessageId         string
+	TopicArn          string
+	Message           string
+	MessageAttributes map[string]sns.MessageAttribute
+}
+
+// unwrapSNSEnvelope replaces the payload of m with the message inside an
+// SNS notification envelope and merges its attributes into the headers. It
+// reports whether m was an envelope.
+func unwrapSNSEnvelope(m *Message) bool {
+	if len(m.Payload) == 0 || m.Payload[0] != '{' {
+		return false
+	}
+	var env snsEnvelope
+	if json.Unmarshal(m.Payload, &env) != nil || env.Type != sns.TypeNotification || env.TopicArn == "" {
+		return false
+	}
+
+	m.Topic = env.TopicArn
+	if env.MessageId != "" {
+		m.ID = env.MessageId
+	}
+	m.Payload = []byte(env.Message)
+	if m.Headers == nil {
+		m.Headers = map[string]string{}
+	}
+	for k, a := range env.MessageAttributes {
+		if a.Type != "String" && a.Type != "Number" {
+			continue
+		}
+		if _, ok := m.Headers[k]; !ok {
+			m.Headers[k] = a.Value
+		}
+	}
+	return true
+}
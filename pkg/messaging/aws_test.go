@@ -0,0 +1,132 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/sns"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/sqs"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSNSSender records PublishStringWithOptions calls.
+type fakeSNSSender struct {
+	topic   string
+	message string
+	opts    sns.PublishOptions
+}
+
+func (f *fakeSNSSender) PublishStringWithOptions(_ context.Context, topicARN, message string, opts sns.PublishOptions) (string, error) {
+	f.topic, f.message, f.opts = topicARN, message, opts
+	return "msg-1", nil
+}
+
+func TestSNSPublisher(t *testing.T) {
+	sender := &fakeSNSSender{}
+	p := &SNSPublisher{client: sender}
+
+	msg := &Message{Topic: "arn:aws:sns:us-east-1:123:orders", Key: "o-1", Payload: []byte("hi"), Headers: map[string]string{"tenant": "acme"}}
+	require.NoError(t, p.Publish(context.Background(), msg))
+	assert.Equal(t, "arn:aws:sns:us-east-1:123:orders", sender.topic)
+	assert.Equal(t, "hi", sender.message)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, sender.opts.Attributes)
+	assert.Empty(t, sender.opts.GroupID, "key is ignored for standard topics")
+
+	msg.Topic = "arn:aws:sns:us-east-1:123:orders.fifo"
+	require.NoError(t, p.Publish(context.Background(), msg))
+	assert.Equal(t, "o-1", sender.opts.GroupID)
+}
+
+// fakeSQSSender records SendStringWithOptions calls.
+type fakeSQSSender struct {
+	queueURL string
+	body     string
+	opts     sqs.SendOptions
+}
+
+func (f *fakeSQSSender) SendStringWithOptions(_ context.Context, queueURL, body string, opts sqs.SendOptions) (string, error) {
+	f.queueURL, f.body, f.opts = queueURL, body, opts
+	return "msg-1", nil
+}
+
+func TestSQSPublisher(t *testing.T) {
+	sender := &fakeSQSSender{}
+	p := &SQSPublisher{producer: sender}
+
+	msg := &Message{Topic: "https://queue/orders", Key: "o-1", Payload: []byte("hi"), Headers: map[string]string{"tenant": "acme"}}
+	require.NoError(t, p.Publish(context.Background(), msg))
+	assert.Equal(t, "https://queue/orders", sender.queueURL)
+	assert.Equal(t, "hi", sender.body)
+	assert.Equal(t, sqs.SendOptions{Attributes: map[string]string{"tenant": "acme"}}, sender.opts)
+
+	require.NoError(t, PublishAfter(context.Background(), p, time.Minute, msg))
+	assert.Equal(t, time.Minute, sender.opts.Delay)
+
+	msg.Topic = "https://queue/orders.fifo"
+	require.NoError(t, p.Publish(context.Background(), msg))
+	assert.Equal(t, "o-1", sender.opts.GroupID)
+}
+
+func TestSQSHandler_RawDelivery(t *testing.T) {
+	var got *Message
+	h := sqsHandler{queueURL: "https://queue/billing", handler: HandlerFunc(func(_ context.Context, msg *Message) error {
+		got = msg
+		return nil
+	})}
+
+	err := h.HandleMessage(context.Background(), &types.Message{
+		MessageId: aws.String("sqs-1"),
+		Body:      aws.String(`{"id":"o-1"}`),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"tenant": {DataType: aws.String("String"), StringValue: aws.String("acme")},
+		},
+		Attributes: map[string]string{"MessageGroupId": "o-1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "sqs-1", got.ID)
+	assert.Equal(t, "https://queue/billing", got.Topic)
+	assert.Equal(t, "o-1", got.Key)
+	assert.Equal(t, `{"id":"o-1"}`, string(got.Payload))
+	assert.Equal(t, "acme", got.Headers["tenant"])
+}
+
+func TestSQSHandler_UnwrapsSNSEnvelope(t *testing.T) {
+	envelope, err := json.Marshal(map[string]any{
+		"Type":      sns.TypeNotification,
+		"MessageId": "sns-1",
+		"TopicArn":  "arn:aws:sns:us-east-1:123:orders",
+		"Message":   `{"id":"o-1"}`,
+		"MessageAttributes": map[string]any{
+			"request_id": map[string]string{"Type": "String", "Value": "req-1"},
+			"blob":       map[string]string{"Type": "Binary", "Value": "AQ=="},
+		},
+	})
+	require.NoError(t, err)
+
+	var got *Message
+	var requestID string
+	h := sqsHandler{queueURL: "https://queue/billing", handler: HandlerFunc(func(ctx context.Context, msg *Message) error {
+		got = msg
+		requestID = reqctx.RequestMetadataFromContext(ctx).RequestID
+		return nil
+	})}
+
+	require.NoError(t, h.HandleMessage(context.Background(), &types.Message{MessageId: aws.String("sqs-1"), Body: aws.String(string(envelope))}))
+	assert.Equal(t, "sns-1", got.ID)
+	assert.Equal(t, "arn:aws:sns:us-east-1:123:orders", got.Topic)
+	assert.Equal(t, `{"id":"o-1"}`, string(got.Payload))
+	assert.Equal(t, map[string]string{"request_id": "req-1"}, got.Headers)
+	assert.Equal(t, "req-1", requestID)
+}
+
+func TestUnwrapSNSEnvelope_IgnoresOtherJSON(t *testing.T) {
+	m := &Message{Topic: "q", Payload: []byte(`{"Type":"Order","Message":"x"}`)}
+	assert.False(t, unwrapSNSEnvelope(m))
+	assert.Equal(t, "q", m.Topic)
+}
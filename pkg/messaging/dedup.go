@@ -0,0 +1,184 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ranorsolutions/http-common-go/pkg/cache"
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+)
+
+// DefaultDedupTTL is how long processed messages are remembered when neither
+// WithDedupTTL nor the cache sets a TTL.
+const DefaultDedupTTL = 24 * time.Hour
+
+// DefaultDedupPrefix prefixes the cache keys of processed messages.
+const DefaultDedupPrefix = "dedup:"
+
+// ErrDuplicateInFlight is returned by the dedup handler for a message whose
+// duplicate is being handled by the same process. It is an
+// *errcode.AppError (errcode.Unavailable), so the transport redelivers the
+// message, which is then skipped if the first delivery succeeded.
+var ErrDuplicateInFlight = errors.New("duplicate message is being processed")
+
+// DedupOption configures NewDedupHandler.
+type DedupOption func(*dedupHandler)
+
+// WithDedupTTL sets how long processed messages are remembered. It must
+// exceed the longest time after which the transport may redeliver a
+// message, e.g. the Kafka retention when consumers can rewind.
+func WithDedupTTL(ttl time.Duration) DedupOption {
+	return func(h *dedupHandler) {
+		if ttl > 0 {
+			h.ttl = ttl
+		}
+	}
+}
+
+// WithDedupKey replaces DedupKey. Messages for which fn returns "" are
+// handled without deduplication.
+func WithDedupKey(fn func(msg *Message) string) DedupOption {
+	return func(h *dedupHandler) { h.key = fn }
+}
+
+// WithDedupPrefix replaces DefaultDedupPrefix, e.g. to keep the records of
+// several consumer groups sharing a cache apart.
+func WithDedupPrefix(prefix string) DedupOption {
+	return func(h *dedupHandler) { h.prefix = prefix }
+}
+
+// WithDuplicateHandler sets a function called with every skipped duplicate,
+// e.g. to count them.
+func WithDuplicateHandler(fn func(ctx context.Context, msg *Message)) DedupOption {
+	return func(h *dedupHandler) { h.onDuplicate = fn }
+}
+
+// WithDedupErrorHandler sets a function called when a processed message
+// cannot be recorded. The message was handled, so the error is not returned
+// to the transport, but a redelivery would be handled again.
+func WithDedupErrorHandler(fn func(ctx context.Context, msg *Message, err error)) DedupOption {
+	return func(h *dedupHandler) { h.onError = fn }
+}
+
+// DedupKey identifies msg by its HeaderMessageID, so messages published
+// twice are recognized, or else by its transport ID, which only recognizes
+// redeliveries. The key is scoped by topic; it is "" when msg has neither
+// ID.
+func DedupKey(msg *Message) string {
+	id := msg.Headers[HeaderMessageID]
+	if id == "" {
+		id = msg.ID
+	}
+	if id == "" {
+		return ""
+	}
+	return msg.Topic + ":" + id
+}
+
+// dedupRecord is cached for every processed message.
+type dedupRecord struct {
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// dedupHandler implements NewDedupHandler.
+type dedupHandler struct {
+	store       cache.Cache
+	next        Handler
+	ttl         time.Duration
+	key         func(msg *Message) string
+	prefix      string
+	onDuplicate func(ctx context.Context, msg *Message)
+	onError     func(ctx context.Context, msg *Message, err error)
+
+	mu       sync.Mutex
+	inflight map[string]struct{}
+}
+
+// NewDedupHandler returns a Handler that records the messages next handled
+// successfully in store and skips their later deliveries, so at-least-once
+// transports process each message effectively once. A message is only
+// recorded once next succeeded, so failed messages are retried as usual.
+//
+// Duplicates delivered concurrently to different processes can both be
+// handled, as the check and the record are not atomic; within a process the
+// second one fails with ErrDuplicateInFlight. A cache lookup failure is
+// returned as errcode.Unavailable so the message is redelivered.
+//
+// Example:
+//
+//	store := cache.NewRedisCache(client, 0)
+//	handler := messaging.NewDedupHandler(store, orders, messaging.WithDedupTTL(7*24*time.Hour))
+//	err := sub.Subscribe(ctx, []string{"orders"}, handler)
+func NewDedupHandler(store cache.Cache, next Handler, opts ...DedupOption) Handler {
+	h := &dedupHandler{
+		store:    store,
+		next:     next,
+		ttl:      store.DefaultTTL(),
+		key:      DedupKey,
+		prefix:   DefaultDedupPrefix,
+		inflight: map[string]struct{}{},
+	}
+	if h.ttl <= 0 {
+		h.ttl = DefaultDedupTTL
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Handle implements Handler.
+func (h *dedupHandler) Handle(ctx context.Context, msg *Message) error {
+	id := h.key(msg)
+	if id == "" {
+		return h.next.Handle(ctx, msg)
+	}
+	key := h.prefix + id
+
+	if !h.acquire(key) {
+		return errcode.Wrap(ErrDuplicateInFlight, errcode.Unavailable, "")
+	}
+	defer h.release(key)
+
+	var rec dedupRecord
+	found, err := h.store.GetJSON(ctx, key, &rec)
+	if err != nil {
+		return errcode.Wrap(fmt.Errorf("dedup lookup failed: %w", err), errcode.Unavailable, "")
+	}
+	if found {
+		if h.onDuplicate != nil {
+			h.onDuplicate(ctx, msg)
+		}
+		return nil
+	}
+
+	if err := h.next.Handle(ctx, msg); err != nil {
+		return err
+	}
+	// Record even when the consumer is stopping: the message was handled.
+	err = h.store.SetJSON(context.WithoutCancel(ctx), key, dedupRecord{ProcessedAt: time.Now().UTC()}, h.ttl)
+	if err != nil && h.onError != nil {
+		h.onError(ctx, msg, fmt.Errorf("failed to record processed message: %w", err))
+	}
+	return nil
+}
+
+// acquire marks key as being handled, reporting false when it already is.
+func (h *dedupHandler) acquire(key string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.inflight[key]; ok {
+		return false
+	}
+	h.inflight[key] = struct{}{}
+	return true
+}
+
+func (h *dedupHandler) release(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.inflight, key)
+}
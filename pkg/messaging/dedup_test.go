@@ -0,0 +1,130 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ranorsolutions/http-common-go/pkg/cache"
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDedupCache(t *testing.T) (*miniredis.Miniredis, cache.Cache) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, cache.NewRedisCache(client, 0)
+}
+
+// failingCache fails every operation with err.
+type failingCache struct {
+	err error
+}
+
+func (c failingCache) GetJSON(context.Context, string, any) (bool, error)        { return false, c.err }
+func (c failingCache) SetJSON(context.Context, string, any, time.Duration) error { return c.err }
+func (c failingCache) Delete(context.Context, string) error                      { return c.err }
+func (c failingCache) DefaultTTL() time.Duration                                 { return 0 }
+
+func TestDedupKey(t *testing.T) {
+	assert.Equal(t, "orders:m-1", DedupKey(&Message{Topic: "orders", ID: "m-1"}))
+	assert.Equal(t, "orders:evt-1", DedupKey(&Message{Topic: "orders", ID: "m-1", Headers: map[string]string{HeaderMessageID: "evt-1"}}))
+	assert.Empty(t, DedupKey(&Message{Topic: "orders"}))
+}
+
+func TestDedupHandler_SkipsDuplicates(t *testing.T) {
+	mr, store := newDedupCache(t)
+	var handled, duplicates int
+	h := NewDedupHandler(store, HandlerFunc(func(context.Context, *Message) error {
+		handled++
+		return nil
+	}), WithDedupTTL(time.Hour), WithDuplicateHandler(func(context.Context, *Message) { duplicates++ }))
+
+	msg := &Message{Topic: "orders", ID: "m-1"}
+	require.NoError(t, h.Handle(context.Background(), msg))
+	require.NoError(t, h.Handle(context.Background(), msg))
+	assert.Equal(t, 1, handled)
+	assert.Equal(t, 1, duplicates)
+	assert.True(t, mr.Exists("dedup:orders:m-1"))
+	assert.Equal(t, time.Hour, mr.TTL("dedup:orders:m-1"))
+
+	require.NoError(t, h.Handle(context.Background(), &Message{Topic: "orders", ID: "m-2"}))
+	require.NoError(t, h.Handle(context.Background(), &Message{Topic: "orders"}), "messages without ID are handled")
+	require.NoError(t, h.Handle(context.Background(), &Message{Topic: "orders"}))
+	assert.Equal(t, 4, handled)
+}
+
+func TestDedupHandler_FailuresAreRetried(t *testing.T) {
+	_, store := newDedupCache(t)
+	calls := 0
+	h := NewDedupHandler(store, HandlerFunc(func(context.Context, *Message) error {
+		if calls++; calls == 1 {
+			return errors.New("boom")
+		}
+		return nil
+	}), WithDedupPrefix("billing:"))
+
+	msg := &Message{Topic: "orders", ID: "m-1"}
+	assert.ErrorContains(t, h.Handle(context.Background(), msg), "boom")
+	require.NoError(t, h.Handle(context.Background(), msg))
+	require.NoError(t, h.Handle(context.Background(), msg))
+	assert.Equal(t, 2, calls)
+}
+
+func TestDedupHandler_InFlight(t *testing.T) {
+	_, store := newDedupCache(t)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := NewDedupHandler(store, HandlerFunc(func(context.Context, *Message) error {
+		close(started)
+		<-release
+		return nil
+	}))
+
+	msg := &Message{Topic: "orders", ID: "m-1"}
+	done := make(chan error)
+	go func() { done <- h.Handle(context.Background(), msg) }()
+	<-started
+
+	err := h.Handle(context.Background(), msg)
+	assert.ErrorIs(t, err, ErrDuplicateInFlight)
+	assert.True(t, errcode.IsRetryable(err))
+	close(release)
+	require.NoError(t, <-done)
+}
+
+func TestDedupHandler_CacheErrors(t *testing.T) {
+	var handled atomic.Int32
+	next := HandlerFunc(func(context.Context, *Message) error {
+		handled.Add(1)
+		return nil
+	})
+	msg := &Message{Topic: "orders", ID: "m-1"}
+
+	err := NewDedupHandler(failingCache{err: errors.New("down")}, next).Handle(context.Background(), msg)
+	assert.Equal(t, errcode.Unavailable, errcode.Of(err))
+	assert.Zero(t, handled.Load(), "nothing is handled without the lookup")
+
+	// A store that reads but cannot write: the message is handled and the
+	// error reported.
+	mr, store := newDedupCache(t)
+	var reported error
+	h := NewDedupHandler(store, HandlerFunc(func(context.Context, *Message) error {
+		mr.SetError("READONLY")
+		return nil
+	}), WithDedupErrorHandler(func(_ context.Context, _ *Message, err error) { reported = err }))
+	require.NoError(t, h.Handle(context.Background(), msg))
+	assert.ErrorContains(t, reported, "failed to record processed message")
+}
+
+func TestDedupHandler_DefaultTTL(t *testing.T) {
+	h := NewDedupHandler(failingCache{}, HandlerFunc(func(context.Context, *Message) error { return nil }))
+	assert.Equal(t, DefaultDedupTTL, h.(*dedupHandler).ttl)
+}
@@ -0,0 +1,45 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrDelayUnsupported is returned by PublishAfter for publishers that cannot
+// defer delivery.
+var ErrDelayUnsupported = errors.New("delayed delivery not supported by publisher")
+
+// DelayedPublisher is a Publisher that can defer delivery. It is implemented
+// by SQSPublisher (up to sqs.MaxDelay), KafkaPublisher (with a delay
+// topology), RedisPublisher and MemoryBus.
+type DelayedPublisher interface {
+	Publisher
+
+	// PublishAfter publishes msg so that it is delivered no earlier than
+	// delay from now. Correlation IDs are read from ctx when it is called.
+	PublishAfter(ctx context.Context, delay time.Duration, msg *Message) error
+}
+
+// PublishAfter publishes msg through p to be delivered after delay. A delay
+// of zero or less publishes immediately. It returns ErrDelayUnsupported when
+// p does not implement DelayedPublisher.
+//
+// Example:
+//
+//	// Expire unpaid orders after 10 minutes.
+//	err := messaging.PublishAfter(ctx, pub, 10*time.Minute, &messaging.Message{
+//	    Topic:   "orders.expire",
+//	    Key:     order.ID,
+//	    Payload: payload,
+//	})
+func PublishAfter(ctx context.Context, p Publisher, delay time.Duration, msg *Message) error {
+	if delay <= 0 {
+		return p.Publish(ctx, msg)
+	}
+	dp, ok := p.(DelayedPublisher)
+	if !ok {
+		return ErrDelayUnsupported
+	}
+	return dp.PublishAfter(ctx, delay, msg)
+}
@@ -0,0 +1,21 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishAfter_Unsupported(t *testing.T) {
+	p := &recordingPublisher{}
+	msg := &Message{Topic: "orders", Payload: []byte("hi")}
+
+	assert.ErrorIs(t, PublishAfter(context.Background(), p, time.Minute, msg), ErrDelayUnsupported)
+	assert.Empty(t, p.published)
+
+	require.NoError(t, PublishAfter(context.Background(), p, 0, msg), "no delay publishes immediately")
+	assert.Len(t, p.published, 1)
+}
@@ -0,0 +1,335 @@
+package messaging
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+)
+
+// Headers set on encrypted messages. HeaderEncryption names the algorithm;
+// the data key that encrypted the payload travels, itself encrypted, in
+// HeaderEncryptedKey, next to the ID of the key that encrypted it.
+// HeaderEncryptionTopic is the topic the message was published to, since
+// transports such as SQS deliver messages under the queue instead.
+const (
+	HeaderEncryption      = "content-encryption"
+	HeaderEncryptionKeyID = "encryption-key-id"
+	HeaderEncryptedKey    = "encrypted-key"
+	HeaderEncryptionTopic = "encryption-topic"
+)
+
+// EncryptionAESGCM is the HeaderEncryption value of payloads encrypted with
+// AES-256-GCM. The payload is the 12-byte nonce followed by the ciphertext.
+// The algorithm, topic and key ID are authenticated as additional data, so
+// they cannot be changed without failing decryption.
+const EncryptionAESGCM = "aes256-gcm"
+
+// ErrDecrypt is returned by the decrypting handler for messages that cannot
+// be decrypted. Unless the key provider classified the failure (e.g. as
+// errcode.Unavailable when KMS cannot be reached), such errors are also
+// *errcode.AppError values (errcode.InvalidArgument), so consumers
+// dead-letter the message instead of retrying it.
+var ErrDecrypt = errors.New("failed to decrypt message")
+
+// KeyProvider supplies the data keys of envelope encryption: each message is
+// encrypted with a data key, and the data key is sent encrypted with a key
+// the provider manages.
+type KeyProvider interface {
+	// DataKey returns a 32-byte data key, the key encrypted and the ID of
+	// the key that encrypted it.
+	DataKey(ctx context.Context) (key, encrypted []byte, keyID string, err error)
+
+	// DecryptKey returns the data key that keyID encrypted as encrypted.
+	DecryptKey(ctx context.Context, keyID string, encrypted []byte) ([]byte, error)
+}
+
+// StaticKeyProvider encrypts data keys with AES-256-GCM keys held in memory.
+// Keep retired keys in it until no message encrypted with them can be
+// received.
+type StaticKeyProvider struct {
+	currentID string
+	keys      map[string]cipher.AEAD
+}
+
+// NewStaticKeyProvider returns a provider encrypting data keys with
+// keys[currentID]. Every key must be 32 bytes long.
+func NewStaticKeyProvider(currentID string, keys map[string][]byte) (*StaticKeyProvider, error) {
+	if _, ok := keys[currentID]; !ok {
+		return nil, fmt.Errorf("current key %q is not among the keys", currentID)
+	}
+	p := &StaticKeyProvider{currentID: currentID, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %q must be 32 bytes, got %d", id, len(key))
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		p.keys[id] = aead
+	}
+	return p, nil
+}
+
+// DataKey implements KeyProvider with a new random data key.
+func (p *StaticKeyProvider) DataKey(context.Context) ([]byte, []byte, string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	encrypted, err := seal(p.keys[p.currentID], key, nil)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return key, encrypted, p.currentID, nil
+}
+
+// DecryptKey implements KeyProvider.
+func (p *StaticKeyProvider) DecryptKey(_ context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	aead, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", keyID)
+	}
+	return open(aead, encrypted, nil)
+}
+
+// NewKeyProviderFromEnv creates a key provider from environment variables:
+//
+//	MESSAGING_ENCRYPTION_KMS_KEY_ID  KMS key ID, ARN or alias; AWS_REGION is then required
+//	MESSAGING_ENCRYPTION_KEY         base64 32-byte static key, when no KMS key is set
+//	MESSAGING_ENCRYPTION_KEY_ID      ID of the static key, default "static"
+func NewKeyProviderFromEnv() (KeyProvider, error) {
+	if keyID := os.Getenv("MESSAGING_ENCRYPTION_KMS_KEY_ID"); keyID != "" {
+		return newKMSKeyProviderFromEnv(keyID)
+	}
+
+	v := os.Getenv("MESSAGING_ENCRYPTION_KEY")
+	if v == "" {
+		return nil, fmt.Errorf("MESSAGING_ENCRYPTION_KMS_KEY_ID or MESSAGING_ENCRYPTION_KEY is required")
+	}
+	key, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MESSAGING_ENCRYPTION_KEY: %w", err)
+	}
+	id := os.Getenv("MESSAGING_ENCRYPTION_KEY_ID")
+	if id == "" {
+		id = "static"
+	}
+	return NewStaticKeyProvider(id, map[string][]byte{id: key})
+}
+
+// EncryptingPublisher encrypts payloads before passing messages to another
+// Publisher. Headers, including the correlation IDs, stay readable.
+type EncryptingPublisher struct {
+	next   Publisher
+	keys   KeyProvider
+	topics map[string]bool
+}
+
+// EncryptionOption configures an EncryptingPublisher.
+type EncryptionOption func(*EncryptingPublisher)
+
+// EncryptTopics restricts encryption to topics; messages to other topics
+// are published as they are. By default every message is encrypted.
+func EncryptTopics(topics ...string) EncryptionOption {
+	return func(p *EncryptingPublisher) {
+		p.topics = make(map[string]bool, len(topics))
+		for _, t := range topics {
+			p.topics[t] = true
+		}
+	}
+}
+
+// NewEncryptingPublisher wraps next so that payloads are encrypted with data
+// keys from keys. Consumers decrypt them with NewDecryptingHandler.
+//
+// Example:
+//
+//	keys, err := messaging.NewKeyProviderFromEnv()
+//	...
+//	pub = messaging.NewEncryptingPublisher(pub, keys, messaging.EncryptTopics("payments"))
+func NewEncryptingPublisher(next Publisher, keys KeyProvider, opts ...EncryptionOption) *EncryptingPublisher {
+	p := &EncryptingPublisher{next: next, keys: keys}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Publish implements Publisher.
+func (p *EncryptingPublisher) Publish(ctx context.Context, msg *Message) error {
+	if p.topics != nil && !p.topics[msg.Topic] {
+		return p.next.Publish(ctx, msg)
+	}
+
+	key, encryptedKey, keyID, err := p.keys.DataKey(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get data key: %w", err)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	payload, err := seal(aead, msg.Payload, additionalData(msg.Topic, keyID))
+	if err != nil {
+		return err
+	}
+
+	headers := make(map[string]string, len(msg.Headers)+4)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[HeaderEncryption] = EncryptionAESGCM
+	headers[HeaderEncryptionTopic] = msg.Topic
+	headers[HeaderEncryptionKeyID] = keyID
+	headers[HeaderEncryptedKey] = base64.StdEncoding.EncodeToString(encryptedKey)
+	return p.next.Publish(ctx, &Message{Topic: msg.Topic, Key: msg.Key, Payload: payload, Headers: headers})
+}
+
+// Close implements Publisher by closing the wrapped publisher.
+func (p *EncryptingPublisher) Close() error {
+	return p.next.Close()
+}
+
+// DecryptionOption configures the handler of NewDecryptingHandler.
+type DecryptionOption func(*decryptionOptions)
+
+type decryptionOptions struct {
+	required bool
+	topics   map[string]bool
+}
+
+// RequireEncryption rejects messages without HeaderEncryption, so a
+// publisher that forgot to encrypt, or anyone able to write to the topic,
+// cannot feed plain payloads to the handler.
+func RequireEncryption() DecryptionOption {
+	return func(o *decryptionOptions) { o.required = true }
+}
+
+// DecryptTopics rejects messages that were encrypted for other topics, so
+// a message copied from another encrypted topic is not handled as one of
+// these.
+func DecryptTopics(topics ...string) DecryptionOption {
+	return func(o *decryptionOptions) {
+		o.topics = make(map[string]bool, len(topics))
+		for _, t := range topics {
+			o.topics[t] = true
+		}
+	}
+}
+
+// NewDecryptingHandler returns a Handler that decrypts messages encrypted by
+// an EncryptingPublisher and passes them, without the encryption headers, to
+// next. Unless RequireEncryption is given, messages without
+// HeaderEncryption are passed unchanged, so a subscription may mix
+// encrypted and plain topics.
+//
+// Example:
+//
+//	handler = messaging.NewDecryptingHandler(keys, handler,
+//		messaging.RequireEncryption(), messaging.DecryptTopics("payments"))
+func NewDecryptingHandler(keys KeyProvider, next Handler, opts ...DecryptionOption) Handler {
+	var o decryptionOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return HandlerFunc(func(ctx context.Context, msg *Message) error {
+		alg, ok := msg.Headers[HeaderEncryption]
+		if !ok {
+			if o.required {
+				return decryptError(fmt.Errorf("message is not encrypted"))
+			}
+			return next.Handle(ctx, msg)
+		}
+		if alg != EncryptionAESGCM {
+			return decryptError(fmt.Errorf("unsupported encryption %q", alg))
+		}
+		topic := msg.Headers[HeaderEncryptionTopic]
+		if o.topics != nil && !o.topics[topic] {
+			return decryptError(fmt.Errorf("message was encrypted for topic %q", topic))
+		}
+
+		encryptedKey, err := base64.StdEncoding.DecodeString(msg.Headers[HeaderEncryptedKey])
+		if err != nil {
+			return decryptError(fmt.Errorf("invalid %s header: %w", HeaderEncryptedKey, err))
+		}
+		keyID := msg.Headers[HeaderEncryptionKeyID]
+		key, err := keys.DecryptKey(ctx, keyID, encryptedKey)
+		if err != nil {
+			if errcode.Of(err) != errcode.Internal {
+				// Classified by the provider, or the context ended.
+				return fmt.Errorf("%w: %w", ErrDecrypt, err)
+			}
+			return decryptError(err)
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return decryptError(err)
+		}
+		payload, err := open(aead, msg.Payload, additionalData(topic, keyID))
+		if err != nil {
+			return decryptError(err)
+		}
+
+		headers := make(map[string]string, len(msg.Headers))
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		delete(headers, HeaderEncryption)
+		delete(headers, HeaderEncryptionTopic)
+		delete(headers, HeaderEncryptionKeyID)
+		delete(headers, HeaderEncryptedKey)
+		return next.Handle(ctx, &Message{ID: msg.ID, Topic: msg.Topic, Key: msg.Key, Payload: payload, Headers: headers})
+	})
+}
+
+// additionalData returns the data authenticated along with a payload
+// encrypted for topic with a data key from keyID. Each part is
+// length-prefixed, so no two topic and key ID pairs share it.
+func additionalData(topic, keyID string) []byte {
+	return fmt.Appendf(nil, "%s\x00%d:%s%d:%s", EncryptionAESGCM, len(topic), topic, len(keyID), keyID)
+}
+
+// decryptError marks err as a permanent decryption failure.
+func decryptError(err error) error {
+	return errcode.Wrap(fmt.Errorf("%w: %w", ErrDecrypt, err), errcode.InvalidArgument, "")
+}
+
+// newGCM returns an AES-GCM cipher for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, prepended to the result,
+// authenticating additional along with it.
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+// open decrypts the output of seal.
+func open(aead cipher.AEAD, data, additional []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additional)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ciphertext: %w", err)
+	}
+	return plaintext, nil
+}
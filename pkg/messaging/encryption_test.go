@@ -0,0 +1,199 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyProvider(t *testing.T) *StaticKeyProvider {
+	t.Helper()
+	keys, err := NewStaticKeyProvider("k2", map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+		"k2": bytes.Repeat([]byte{2}, 32),
+	})
+	require.NoError(t, err)
+	return keys
+}
+
+func TestEncryptingPublisher_RoundTrip(t *testing.T) {
+	keys := newTestKeyProvider(t)
+	rec := &recordingPublisher{}
+	pub := NewEncryptingPublisher(rec, keys)
+
+	require.NoError(t, PublishJSON(context.Background(), pub, "payments", "p-1", map[string]string{"card": "4242"}, nil))
+	require.Len(t, rec.published, 1)
+	sent := rec.published[0]
+	assert.Equal(t, "p-1", sent.Key)
+	assert.NotContains(t, string(sent.Payload), "4242")
+	assert.Equal(t, EncryptionAESGCM, sent.Headers[HeaderEncryption])
+	assert.Equal(t, "k2", sent.Headers[HeaderEncryptionKeyID])
+	assert.Equal(t, ContentTypeJSON, sent.Headers[HeaderContentType])
+
+	var got *Message
+	handler := NewDecryptingHandler(keys, HandlerFunc(func(_ context.Context, msg *Message) error {
+		got = msg
+		return nil
+	}))
+	require.NoError(t, handler.Handle(context.Background(), sent))
+	assert.JSONEq(t, `{"card":"4242"}`, string(got.Payload))
+	assert.Equal(t, map[string]string{HeaderContentType: ContentTypeJSON}, got.Headers)
+	assert.Equal(t, EncryptionAESGCM, sent.Headers[HeaderEncryption], "received message is not modified")
+}
+
+func TestEncryptingPublisher_Topics(t *testing.T) {
+	rec := &recordingPublisher{}
+	pub := NewEncryptingPublisher(rec, newTestKeyProvider(t), EncryptTopics("payments"))
+
+	require.NoError(t, pub.Publish(context.Background(), &Message{Topic: "orders", Payload: []byte("plain")}))
+	require.NoError(t, pub.Publish(context.Background(), &Message{Topic: "payments", Payload: []byte("secret")}))
+	assert.Equal(t, "plain", string(rec.published[0].Payload))
+	assert.NotContains(t, rec.published[0].Headers, HeaderEncryption)
+	assert.Contains(t, rec.published[1].Headers, HeaderEncryption)
+	assert.NoError(t, pub.Close())
+}
+
+func TestDecryptingHandler_PassesPlainMessages(t *testing.T) {
+	msg := &Message{Topic: "orders", Payload: []byte("plain")}
+	var got *Message
+	handler := NewDecryptingHandler(newTestKeyProvider(t), HandlerFunc(func(_ context.Context, m *Message) error {
+		got = m
+		return nil
+	}))
+	require.NoError(t, handler.Handle(context.Background(), msg))
+	assert.Same(t, msg, got)
+}
+
+func TestDecryptingHandler_Failures(t *testing.T) {
+	keys := newTestKeyProvider(t)
+	rec := &recordingPublisher{}
+	require.NoError(t, NewEncryptingPublisher(rec, keys).Publish(context.Background(), &Message{Topic: "t", Payload: []byte("secret")}))
+	valid := rec.published[0]
+
+	with := func(header, value string) *Message {
+		headers := make(map[string]string, len(valid.Headers))
+		for k, v := range valid.Headers {
+			headers[k] = v
+		}
+		headers[header] = value
+		return &Message{Topic: "t", Payload: valid.Payload, Headers: headers}
+	}
+	tampered := with(HeaderEncryption, EncryptionAESGCM)
+	tampered.Payload = append([]byte(nil), valid.Payload...)
+	tampered.Payload[len(tampered.Payload)-1] ^= 1
+
+	handler := NewDecryptingHandler(keys, HandlerFunc(func(context.Context, *Message) error {
+		t.Fatal("handler must not be called")
+		return nil
+	}))
+	for name, msg := range map[string]*Message{
+		"algorithm":   with(HeaderEncryption, "rot13"),
+		"key header":  with(HeaderEncryptedKey, "%%%"),
+		"unknown key": with(HeaderEncryptionKeyID, "k9"),
+		"wrong key":   with(HeaderEncryptionKeyID, "k1"),
+		"topic":       with(HeaderEncryptionTopic, "other"),
+		"tampered":    tampered,
+		"short":       {Headers: valid.Headers, Payload: []byte("x")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := handler.Handle(context.Background(), msg)
+			assert.ErrorIs(t, err, ErrDecrypt)
+			assert.Equal(t, errcode.OutcomeDeadLetter, errcode.MessageOutcome(err))
+		})
+	}
+}
+
+func TestDecryptingHandler_RequireEncryption(t *testing.T) {
+	var handled int
+	handler := NewDecryptingHandler(newTestKeyProvider(t), HandlerFunc(func(context.Context, *Message) error {
+		handled++
+		return nil
+	}), RequireEncryption())
+
+	err := handler.Handle(context.Background(), &Message{Topic: "payments", Payload: []byte("plain")})
+	assert.ErrorIs(t, err, ErrDecrypt)
+	assert.Equal(t, errcode.OutcomeDeadLetter, errcode.MessageOutcome(err))
+	assert.Zero(t, handled)
+}
+
+func TestDecryptingHandler_DecryptTopics(t *testing.T) {
+	keys := newTestKeyProvider(t)
+	rec := &recordingPublisher{}
+	pub := NewEncryptingPublisher(rec, keys)
+	require.NoError(t, pub.Publish(context.Background(), &Message{Topic: "payments", Payload: []byte("secret")}))
+	require.NoError(t, pub.Publish(context.Background(), &Message{Topic: "audit", Payload: []byte("copied")}))
+
+	var got []string
+	handler := NewDecryptingHandler(keys, HandlerFunc(func(_ context.Context, msg *Message) error {
+		got = append(got, string(msg.Payload))
+		assert.NotContains(t, msg.Headers, HeaderEncryptionTopic)
+		return nil
+	}), DecryptTopics("payments"))
+
+	// Delivered under the queue name, as SQS does
+	for _, msg := range rec.published {
+		msg.Topic = "https://sqs/payments-queue"
+	}
+	require.NoError(t, handler.Handle(context.Background(), rec.published[0]))
+	assert.ErrorIs(t, handler.Handle(context.Background(), rec.published[1]), ErrDecrypt)
+	assert.Equal(t, []string{"secret"}, got)
+}
+
+// failingKeyProvider fails DecryptKey with err.
+type failingKeyProvider struct {
+	*StaticKeyProvider
+	err error
+}
+
+func (p failingKeyProvider) DecryptKey(context.Context, string, []byte) ([]byte, error) {
+	return nil, p.err
+}
+
+func TestDecryptingHandler_KeepsProviderClassification(t *testing.T) {
+	keys := newTestKeyProvider(t)
+	rec := &recordingPublisher{}
+	require.NoError(t, NewEncryptingPublisher(rec, keys).Publish(context.Background(), &Message{Topic: "t", Payload: []byte("secret")}))
+
+	unavailable := errcode.Wrap(errors.New("kms down"), errcode.Unavailable, "")
+	handler := NewDecryptingHandler(failingKeyProvider{keys, unavailable}, HandlerFunc(func(context.Context, *Message) error { return nil }))
+	err := handler.Handle(context.Background(), rec.published[0])
+	assert.ErrorIs(t, err, ErrDecrypt)
+	assert.True(t, errcode.IsRetryable(err))
+}
+
+func TestNewStaticKeyProvider_Invalid(t *testing.T) {
+	_, err := NewStaticKeyProvider("missing", map[string][]byte{"k1": make([]byte, 32)})
+	assert.ErrorContains(t, err, "not among the keys")
+	_, err = NewStaticKeyProvider("k1", map[string][]byte{"k1": make([]byte, 16)})
+	assert.ErrorContains(t, err, "must be 32 bytes")
+}
+
+func TestNewKeyProviderFromEnv(t *testing.T) {
+	t.Setenv("MESSAGING_ENCRYPTION_KMS_KEY_ID", "")
+	t.Setenv("MESSAGING_ENCRYPTION_KEY", "")
+	_, err := NewKeyProviderFromEnv()
+	assert.ErrorContains(t, err, "is required")
+
+	t.Setenv("MESSAGING_ENCRYPTION_KEY", "not base64!")
+	_, err = NewKeyProviderFromEnv()
+	assert.ErrorContains(t, err, "invalid MESSAGING_ENCRYPTION_KEY")
+
+	t.Setenv("MESSAGING_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	keys, err := NewKeyProviderFromEnv()
+	require.NoError(t, err)
+	_, _, id, err := keys.DataKey(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "static", id)
+
+	t.Setenv("MESSAGING_ENCRYPTION_KMS_KEY_ID", "alias/messaging")
+	t.Setenv("AWS_REGION", "us-east-1")
+	keys, err = NewKeyProviderFromEnv()
+	require.NoError(t, err)
+	assert.IsType(t, &KMSKeyProvider{}, keys)
+}
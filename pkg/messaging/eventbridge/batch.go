@@ -0,0 +1,132 @@
+package eventbridge
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+// MaxBatchSize is the maximum number of events EventBridge accepts in a
+// single PutEvents call.
+const MaxBatchSize = 10
+
+// BatchEntryError describes an event of a batch that was not put.
+type BatchEntryError struct {
+	// Index is the position of the event in the events passed to
+	// PutEvents.
+	Index int
+
+	// Code and Message are the error reported by EventBridge for the
+	// entry, or describe the failed request when the whole call failed.
+	Code    string
+	Message string
+
+	// Err is the error of the request when the whole call failed.
+	Err error
+}
+
+// BatchError is returned by PutEvents when some events could not be put.
+// The other events were put. It unwraps to the errors of failed calls.
+type BatchError struct {
+	Failed []BatchEntryError
+}
+
+func (e *BatchError) Error() string {
+	indexes := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		indexes[i] = strconv.Itoa(f.Index)
+	}
+	return fmt.Sprintf("put events failed for %d event(s): %s (first error: %s: %s)",
+		len(e.Failed), strings.Join(indexes, ", "), e.Failed[0].Code, e.Failed[0].Message)
+}
+
+// Unwrap returns the distinct call errors of the failed entries.
+func (e *BatchError) Unwrap() []error {
+	var errs []error
+	for _, f := range e.Failed {
+		if f.Err != nil && (len(errs) == 0 || errs[len(errs)-1] != f.Err) {
+			errs = append(errs, f.Err)
+		}
+	}
+	return errs
+}
+
+// PutEvents puts events on the client's bus, MaxBatchSize events per call.
+// The returned slice holds the ID of each event, in order, or "" for events
+// that failed. If any event failed the error is a *BatchError listing them;
+// a failed call does not stop the remaining chunks from being sent. Nothing
+// is sent if an event is invalid or its detail fails to marshal.
+//
+// EventBridge also limits a call to 256 KB of entries; callers sending
+// large details should pass fewer events at a time.
+//
+// Example:
+//
+//	ids, err := client.PutEvents(ctx, events)
+//	var batchErr *eventbridge.BatchError
+//	if errors.As(err, &batchErr) {
+//	    for _, f := range batchErr.Failed {
+//	        log.Printf("event %d not put: %s", f.Index, f.Message)
+//	    }
+//	}
+func (c *Client) PutEvents(ctx context.Context, events []Event) ([]string, error) {
+	entries := make([]types.PutEventsRequestEntry, len(events))
+	for i, e := range events {
+		entry, err := c.entry(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event at index %d: %w", i, err)
+		}
+		entries[i] = entry
+	}
+
+	ids := make([]string, len(events))
+	var failed []BatchEntryError
+	for start := 0; start < len(entries); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(entries))
+		failed = append(failed, c.putChunk(ctx, entries[start:end], start, ids)...)
+	}
+
+	if len(failed) > 0 {
+		return ids, &BatchError{Failed: failed}
+	}
+	return ids, nil
+}
+
+// putChunk sends up to MaxBatchSize entries starting at offset, records
+// their event IDs in ids and returns the entries that failed.
+func (c *Client) putChunk(ctx context.Context, entries []types.PutEventsRequestEntry, offset int, ids []string) []BatchEntryError {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	out, err := c.api.PutEvents(ctx, &eventbridge.PutEventsInput{Entries: entries})
+	if err != nil {
+		err = fmt.Errorf("put events failed: %w", err)
+		failed := make([]BatchEntryError, len(entries))
+		for i := range entries {
+			failed[i] = BatchEntryError{Index: offset + i, Code: "RequestFailed", Message: err.Error(), Err: err}
+		}
+		return failed
+	}
+
+	// Result entries are in the order of the request entries.
+	var failed []BatchEntryError
+	for i, r := range out.Entries {
+		if i >= len(entries) {
+			break
+		}
+		if r.ErrorCode != nil {
+			failed = append(failed, BatchEntryError{
+				Index:   offset + i,
+				Code:    aws.ToString(r.ErrorCode),
+				Message: aws.ToString(r.ErrorMessage),
+			})
+			continue
+		}
+		ids[offset+i] = aws.ToString(r.EventId)
+	}
+	return failed
+}
@@ -0,0 +1,92 @@
+package eventbridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutEvents_Chunks(t *testing.T) {
+	mock := &mockEventBridgeClient{}
+	c := NewWithAPI(mock, &Config{Source: "com.example.orders"})
+
+	events := make([]Event, 23)
+	for i := range events {
+		events[i] = Event{DetailType: "OrderPlaced", Detail: map[string]int{"n": i}}
+	}
+
+	ids, err := c.PutEvents(context.Background(), events)
+	require.NoError(t, err)
+	require.Len(t, ids, 23)
+	for i, id := range ids {
+		assert.Equal(t, fmt.Sprintf(`evt-{"n":%d}`, i), id)
+	}
+
+	require.Len(t, mock.calls, 3)
+	assert.Len(t, mock.calls[0].Entries, 10)
+	assert.Len(t, mock.calls[1].Entries, 10)
+	assert.Len(t, mock.calls[2].Entries, 3)
+	assert.JSONEq(t, `{"n":12}`, aws.ToString(mock.calls[1].Entries[2].Detail))
+}
+
+func TestPutEvents_PartialFailure(t *testing.T) {
+	mock := &mockEventBridgeClient{failTypes: map[string]bool{"Bad": true}}
+	c := NewWithAPI(mock, &Config{Source: "com.example.orders"})
+
+	events := make([]Event, 12)
+	for i := range events {
+		events[i] = Event{DetailType: "Good", Detail: map[string]int{"n": i}}
+	}
+	events[1].DetailType = "Bad"
+	events[11].DetailType = "Bad"
+
+	ids, err := c.PutEvents(context.Background(), events)
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	require.Len(t, batchErr.Failed, 2)
+	assert.Equal(t, 1, batchErr.Failed[0].Index)
+	assert.Equal(t, 11, batchErr.Failed[1].Index)
+	assert.Equal(t, "try again", batchErr.Failed[1].Message)
+	assert.Contains(t, err.Error(), "2 event(s): 1, 11")
+
+	assert.Empty(t, ids[1])
+	assert.Empty(t, ids[11])
+	assert.Equal(t, `evt-{"n":10}`, ids[10])
+}
+
+func TestPutEvents_CallFailureKeepsSending(t *testing.T) {
+	callErr := errors.New("throttled")
+	mock := &mockEventBridgeClient{callErr: callErr}
+	c := NewWithAPI(mock, &Config{Source: "com.example.orders"})
+
+	events := make([]Event, 15)
+	for i := range events {
+		events[i] = Event{DetailType: "OrderPlaced", Detail: map[string]int{"n": i}}
+	}
+
+	_, err := c.PutEvents(context.Background(), events)
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Failed, 15)
+	assert.Len(t, mock.calls, 2)
+	assert.Equal(t, "RequestFailed", batchErr.Failed[14].Code)
+	assert.ErrorIs(t, err, callErr)
+	assert.Len(t, batchErr.Unwrap(), 2, "one error per failed call")
+}
+
+func TestPutEvents_InvalidEventSendsNothing(t *testing.T) {
+	mock := &mockEventBridgeClient{}
+	c := NewWithAPI(mock, &Config{Source: "com.example.orders"})
+
+	_, err := c.PutEvents(context.Background(), []Event{
+		{DetailType: "OrderPlaced", Detail: struct{}{}},
+		{DetailType: "OrderPlaced", Detail: "not an object"},
+	})
+	assert.ErrorContains(t, err, "invalid event at index 1")
+	assert.Empty(t, mock.calls)
+}
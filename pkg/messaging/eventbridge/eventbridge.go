@@ -0,0 +1,211 @@
+package eventbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/awsconfig"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/env"
+)
+
+// DefaultEventBus is the account's default event bus, used when no bus is
+// configured.
+const DefaultEventBus = "default"
+
+// EventBridgeAPI defines the subset of eventbridge.Client methods we use.
+// This makes it mockable in tests.
+type EventBridgeAPI interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// Client wraps an AWS EventBridge client with helpers.
+type Client struct {
+	api     EventBridgeAPI
+	busName string
+	source  string
+	timeout time.Duration
+}
+
+// Config holds optional configuration for EventBridge setup.
+type Config struct {
+	Region string
+
+	// EventBusName is the name or ARN of the bus events are sent to
+	// (DefaultEventBus when empty).
+	EventBusName string
+
+	// Source is the default source of events, e.g. "com.example.billing".
+	Source string
+
+	// Endpoint overrides the EventBridge endpoint, e.g.
+	// "http://localhost:4566" for LocalStack.
+	Endpoint string
+
+	// Profile selects a profile of the shared config and credentials files
+	// instead of AWS_PROFILE.
+	Profile string
+
+	// AccessKeyID and SecretAccessKey (and optionally SessionToken) replace
+	// the default credential chain with static credentials.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// RoleARN is assumed with the credentials above, e.g. to put events on
+	// a bus in another account.
+	RoleARN string
+
+	// Timeout bounds each PutEvents call, including its retries, unless ctx
+	// has an earlier deadline. Zero leaves calls bounded by ctx only.
+	Timeout time.Duration
+}
+
+// NewConfigFromEnv builds configuration from environment variables:
+//
+//	AWS_REGION             required
+//	EVENTBRIDGE_BUS_NAME   bus name or ARN, default "default"
+//	EVENTBRIDGE_SOURCE     default event source
+//	EVENTBRIDGE_ENDPOINT   endpoint override
+//	EVENTBRIDGE_ROLE_ARN   role to assume
+//	EVENTBRIDGE_TIMEOUT    duration, e.g. 10s
+//
+// Credentials and profiles are read by the AWS SDK from its usual
+// environment variables and shared files.
+func NewConfigFromEnv() (*Config, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		return nil, fmt.Errorf("AWS_REGION is required")
+	}
+
+	cfg := &Config{
+		Region:       region,
+		EventBusName: os.Getenv("EVENTBRIDGE_BUS_NAME"),
+		Source:       os.Getenv("EVENTBRIDGE_SOURCE"),
+		Endpoint:     os.Getenv("EVENTBRIDGE_ENDPOINT"),
+		RoleARN:      os.Getenv("EVENTBRIDGE_ROLE_ARN"),
+	}
+
+	var err error
+	if cfg.Timeout, err = env.Duration("EVENTBRIDGE_TIMEOUT"); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// New creates a new EventBridge client from AWS credentials/config in the
+// environment, applying the overrides set in cfg.
+//
+// Example:
+//
+//	client, err := eventbridge.New(&eventbridge.Config{
+//	    Region:       "us-east-1",
+//	    EventBusName: "orders",
+//	    Source:       "com.example.orders",
+//	})
+//	id, err := client.PutJSON(ctx, "OrderPlaced", order)
+func New(cfg *Config) (*Client, error) {
+	awsCfg, err := awsconfig.Load(context.Background(), awsconfig.Options{
+		Region:          cfg.Region,
+		Profile:         cfg.Profile,
+		AccessKeyID:     cfg.AccessKeyID,
+		SecretAccessKey: cfg.SecretAccessKey,
+		SessionToken:    cfg.SessionToken,
+		RoleARN:         cfg.RoleARN,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewWithAPI(eventbridge.NewFromConfig(awsCfg, func(o *eventbridge.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	}), cfg), nil
+}
+
+// NewWithAPI returns a Client sending events through api with the bus,
+// source and timeout of cfg. The connection settings of cfg are ignored.
+func NewWithAPI(api EventBridgeAPI, cfg *Config) *Client {
+	bus := cfg.EventBusName
+	if bus == "" {
+		bus = DefaultEventBus
+	}
+	return &Client{api: api, busName: bus, source: cfg.Source, timeout: cfg.Timeout}
+}
+
+// callContext applies the configured per-call timeout to ctx.
+func (c *Client) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout > 0 {
+		return context.WithTimeout(ctx, c.timeout)
+	}
+	return ctx, func() {}
+}
+
+// Event is an event to put on the bus.
+type Event struct {
+	// DetailType identifies the kind of event, e.g. "OrderPlaced". Rules
+	// usually match on it. Required.
+	DetailType string
+
+	// Detail is marshaled as JSON and must encode to a JSON object. Use
+	// json.RawMessage for pre-encoded details.
+	Detail any
+
+	// Source overrides the client's default source.
+	Source string
+
+	// Resources lists the ARNs the event concerns.
+	Resources []string
+
+	// Time is the event time (the time of the call when zero).
+	Time time.Time
+}
+
+// PutJSON marshals detail as JSON and puts a single event of detailType with
+// the client's default source. It returns the event ID.
+func (c *Client) PutJSON(ctx context.Context, detailType string, detail any) (string, error) {
+	ids, err := c.PutEvents(ctx, []Event{{DetailType: detailType, Detail: detail}})
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+// entry converts e to a request entry for the client's bus.
+func (c *Client) entry(e Event) (types.PutEventsRequestEntry, error) {
+	source := e.Source
+	if source == "" {
+		source = c.source
+	}
+	if source == "" {
+		return types.PutEventsRequestEntry{}, fmt.Errorf("event source is required")
+	}
+	if e.DetailType == "" {
+		return types.PutEventsRequestEntry{}, fmt.Errorf("detail type is required")
+	}
+
+	detail, err := json.Marshal(e.Detail)
+	if err != nil {
+		return types.PutEventsRequestEntry{}, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if len(detail) == 0 || detail[0] != '{' {
+		return types.PutEventsRequestEntry{}, fmt.Errorf("detail must be a JSON object")
+	}
+
+	entry := types.PutEventsRequestEntry{
+		EventBusName: aws.String(c.busName),
+		Source:       aws.String(source),
+		DetailType:   aws.String(e.DetailType),
+		Detail:       aws.String(string(detail)),
+		Resources:    e.Resources,
+	}
+	if !e.Time.IsZero() {
+		entry.Time = aws.Time(e.Time)
+	}
+	return entry, nil
+}
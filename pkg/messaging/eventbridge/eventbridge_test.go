@@ -0,0 +1,168 @@
+package eventbridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockEventBridgeClient fakes EventBridgeAPI, failing the entries whose
+// detail type is in failTypes and every call once callErr is set.
+type mockEventBridgeClient struct {
+	calls     []*eventbridge.PutEventsInput
+	failTypes map[string]bool
+	callErr   error
+}
+
+func (m *mockEventBridgeClient) PutEvents(_ context.Context, input *eventbridge.PutEventsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	m.calls = append(m.calls, input)
+	if m.callErr != nil {
+		return nil, m.callErr
+	}
+
+	out := &eventbridge.PutEventsOutput{}
+	for _, e := range input.Entries {
+		if m.failTypes[aws.ToString(e.DetailType)] {
+			out.FailedEntryCount++
+			out.Entries = append(out.Entries, types.PutEventsResultEntry{
+				ErrorCode:    aws.String("InternalFailure"),
+				ErrorMessage: aws.String("try again"),
+			})
+			continue
+		}
+		out.Entries = append(out.Entries, types.PutEventsResultEntry{EventId: aws.String("evt-" + aws.ToString(e.Detail))})
+	}
+	return out, nil
+}
+
+func TestNewConfigFromEnv(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("EVENTBRIDGE_BUS_NAME", "orders")
+	t.Setenv("EVENTBRIDGE_SOURCE", "com.example.orders")
+	t.Setenv("EVENTBRIDGE_ENDPOINT", "http://localhost:4566")
+	t.Setenv("EVENTBRIDGE_ROLE_ARN", "arn:aws:iam::123456789012:role/events")
+	t.Setenv("EVENTBRIDGE_TIMEOUT", "5s")
+
+	cfg, err := NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		Region:       "eu-west-1",
+		EventBusName: "orders",
+		Source:       "com.example.orders",
+		Endpoint:     "http://localhost:4566",
+		RoleARN:      "arn:aws:iam::123456789012:role/events",
+		Timeout:      5 * time.Second,
+	}, cfg)
+}
+
+func TestNewConfigFromEnv_Invalid(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	_, err := NewConfigFromEnv()
+	assert.ErrorContains(t, err, "AWS_REGION is required")
+
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("EVENTBRIDGE_TIMEOUT", "soon")
+	_, err = NewConfigFromEnv()
+	assert.ErrorContains(t, err, "invalid EVENTBRIDGE_TIMEOUT")
+}
+
+func TestNew(t *testing.T) {
+	c, err := New(&Config{Region: "us-east-1", AccessKeyID: "test", SecretAccessKey: "test", Endpoint: "http://localhost:4566"})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultEventBus, c.busName)
+
+	_, err = New(&Config{Region: "us-east-1", AccessKeyID: "test"})
+	assert.ErrorContains(t, err, "static credentials")
+}
+
+func TestPutJSON(t *testing.T) {
+	mock := &mockEventBridgeClient{}
+	c := NewWithAPI(mock, &Config{EventBusName: "orders", Source: "com.example.orders"})
+
+	id, err := c.PutJSON(context.Background(), "OrderPlaced", map[string]string{"id": "o-1"})
+	require.NoError(t, err)
+	assert.Equal(t, `evt-{"id":"o-1"}`, id)
+
+	require.Len(t, mock.calls, 1)
+	entry := mock.calls[0].Entries[0]
+	assert.Equal(t, "orders", aws.ToString(entry.EventBusName))
+	assert.Equal(t, "com.example.orders", aws.ToString(entry.Source))
+	assert.Equal(t, "OrderPlaced", aws.ToString(entry.DetailType))
+	assert.Nil(t, entry.Time)
+}
+
+func TestPutJSON_Failure(t *testing.T) {
+	mock := &mockEventBridgeClient{failTypes: map[string]bool{"OrderPlaced": true}}
+	c := NewWithAPI(mock, &Config{Source: "com.example.orders"})
+
+	_, err := c.PutJSON(context.Background(), "OrderPlaced", map[string]string{"id": "o-1"})
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, "InternalFailure", batchErr.Failed[0].Code)
+}
+
+func TestEntry(t *testing.T) {
+	c := NewWithAPI(&mockEventBridgeClient{}, &Config{Source: "default.source"})
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	entry, err := c.entry(Event{
+		DetailType: "OrderPlaced",
+		Detail:     json.RawMessage(` { "id": "o-1" } `),
+		Source:     "custom.source",
+		Resources:  []string{"arn:aws:s3:::bucket"},
+		Time:       at,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultEventBus, aws.ToString(entry.EventBusName))
+	assert.Equal(t, "custom.source", aws.ToString(entry.Source))
+	assert.Equal(t, `{"id":"o-1"}`, aws.ToString(entry.Detail))
+	assert.Equal(t, []string{"arn:aws:s3:::bucket"}, entry.Resources)
+	assert.Equal(t, at, aws.ToTime(entry.Time))
+
+	for name, tc := range map[string]struct {
+		client *Client
+		event  Event
+		want   string
+	}{
+		"no source":      {NewWithAPI(nil, &Config{}), Event{DetailType: "X", Detail: struct{}{}}, "source is required"},
+		"no detail type": {c, Event{Detail: struct{}{}}, "detail type is required"},
+		"not an object":  {c, Event{DetailType: "X", Detail: []int{1}}, "must be a JSON object"},
+		"nil detail":     {c, Event{DetailType: "X"}, "must be a JSON object"},
+		"unmarshalable":  {c, Event{DetailType: "X", Detail: make(chan int)}, "marshal"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := tc.client.entry(tc.event)
+			assert.ErrorContains(t, err, tc.want)
+		})
+	}
+}
+
+func TestCallContext_Timeout(t *testing.T) {
+	c := NewWithAPI(&mockEventBridgeClient{}, &Config{Timeout: time.Second})
+	ctx, cancel := c.callContext(context.Background())
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.True(t, ok)
+
+	c = NewWithAPI(&mockEventBridgeClient{}, &Config{})
+	ctx, cancel = c.callContext(context.Background())
+	defer cancel()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+}
+
+func TestPutEvents_CallError(t *testing.T) {
+	callErr := errors.New("connection reset")
+	c := NewWithAPI(&mockEventBridgeClient{callErr: callErr}, &Config{Source: "s"})
+
+	_, err := c.PutJSON(context.Background(), "X", struct{}{})
+	assert.ErrorIs(t, err, callErr)
+}
@@ -0,0 +1,134 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	gpubsub "cloud.google.com/go/pubsub/v2"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/pubsub"
+)
+
+// pubsubSender is the part of *pubsub.Producer used by PubSubPublisher.
+type pubsubSender interface {
+	Publish(ctx context.Context, topic, orderingKey string, data []byte, attrs map[string]string) (string, error)
+	OrderingEnabled() bool
+	Close() error
+}
+
+// PubSubPublisher publishes through a pubsub.Producer. Headers become
+// attributes; the key is used as ordering key when ordering is enabled.
+type PubSubPublisher struct {
+	producer pubsubSender
+}
+
+// NewPubSubPublisher adapts producer to Publisher.
+func NewPubSubPublisher(producer *pubsub.Producer) *PubSubPublisher {
+	return &PubSubPublisher{producer: producer}
+}
+
+func newPubSubPublisherFromEnv() (Publisher, error) {
+	cfg, err := pubsub.NewConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	producer, err := pubsub.NewProducer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewPubSubPublisher(producer), nil
+}
+
+// Publish implements Publisher.
+func (p *PubSubPublisher) Publish(ctx context.Context, msg *Message) error {
+	var key string
+	if p.producer.OrderingEnabled() {
+		key = msg.Key
+	}
+	_, err := p.producer.Publish(ctx, msg.Topic, key, msg.Payload, msg.Headers)
+	return err
+}
+
+// Close closes the underlying producer.
+func (p *PubSubPublisher) Close() error {
+	return p.producer.Close()
+}
+
+// PubSubSubscriber receives from Pub/Sub subscriptions. The topics passed
+// to Subscribe are subscription IDs.
+type PubSubSubscriber struct {
+	cfg *pubsub.Config
+}
+
+// NewPubSubSubscriber returns a Subscriber using cfg for every subscription;
+// its Subscription is replaced by the topics passed to Subscribe.
+func NewPubSubSubscriber(cfg *pubsub.Config) *PubSubSubscriber {
+	return &PubSubSubscriber{cfg: cfg}
+}
+
+func newPubSubSubscriberFromEnv() (Subscriber, error) {
+	cfg, err := pubsub.NewConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewPubSubSubscriber(cfg), nil
+}
+
+// Subscribe implements Subscriber, running one consumer per subscription.
+// A consumer failing, e.g. because its subscription does not exist, stops
+// the others.
+func (s *PubSubSubscriber) Subscribe(ctx context.Context, topics []string, handler Handler) error {
+	consumers := make([]*pubsub.Consumer, 0, len(topics))
+	defer func() {
+		for _, c := range consumers {
+			_ = c.Close()
+		}
+	}()
+	for _, subscription := range topics {
+		cfg := *s.cfg
+		cfg.Subscription = subscription
+		c, err := pubsub.NewConsumer(&cfg, pubsubHandler{subscription: subscription, handler: handler})
+		if err != nil {
+			return err
+		}
+		consumers = append(consumers, c)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make([]error, len(consumers))
+	for i, c := range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[i] = c.Run(ctx); errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Close implements Subscriber. Consumers stop when the Subscribe context is
+// canceled.
+func (s *PubSubSubscriber) Close() error {
+	return nil
+}
+
+// pubsubHandler adapts a Handler to pubsub.MessageHandler.
+type pubsubHandler struct {
+	subscription string
+	handler      Handler
+}
+
+func (h pubsubHandler) HandleMessage(ctx context.Context, msg *gpubsub.Message) error {
+	return h.handler.Handle(ctx, &Message{
+		ID:      msg.ID,
+		Topic:   h.subscription,
+		Key:     msg.OrderingKey,
+		Payload: msg.Data,
+		Headers: msg.Attributes,
+	})
+}
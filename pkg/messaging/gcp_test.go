@@ -0,0 +1,66 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+
+	gpubsub "cloud.google.com/go/pubsub/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePubSubSender records Publish calls.
+type fakePubSubSender struct {
+	ordering    bool
+	topic       string
+	orderingKey string
+	data        []byte
+	attrs       map[string]string
+}
+
+func (f *fakePubSubSender) Publish(_ context.Context, topic, orderingKey string, data []byte, attrs map[string]string) (string, error) {
+	f.topic, f.orderingKey, f.data, f.attrs = topic, orderingKey, data, attrs
+	return "1", nil
+}
+
+func (f *fakePubSubSender) OrderingEnabled() bool { return f.ordering }
+
+func (f *fakePubSubSender) Close() error { return nil }
+
+func TestPubSubPublisher(t *testing.T) {
+	sender := &fakePubSubSender{}
+	p := &PubSubPublisher{producer: sender}
+
+	msg := &Message{Topic: "orders", Key: "customer-1", Payload: []byte("hi"), Headers: map[string]string{"tenant": "acme"}}
+	require.NoError(t, p.Publish(context.Background(), msg))
+	assert.Equal(t, "orders", sender.topic)
+	assert.Equal(t, "hi", string(sender.data))
+	assert.Equal(t, map[string]string{"tenant": "acme"}, sender.attrs)
+	assert.Empty(t, sender.orderingKey, "key is ignored without ordering")
+
+	sender.ordering = true
+	require.NoError(t, p.Publish(context.Background(), msg))
+	assert.Equal(t, "customer-1", sender.orderingKey)
+}
+
+func TestPubSubHandler(t *testing.T) {
+	var got *Message
+	h := pubsubHandler{subscription: "billing", handler: HandlerFunc(func(_ context.Context, msg *Message) error {
+		got = msg
+		return nil
+	})}
+
+	require.NoError(t, h.HandleMessage(context.Background(), &gpubsub.Message{
+		ID:          "m-1",
+		Data:        []byte("hi"),
+		Attributes:  map[string]string{"tenant": "acme"},
+		OrderingKey: "customer-1",
+	}))
+	assert.Equal(t, &Message{
+		ID:      "m-1",
+		Topic:   "billing",
+		Key:     "customer-1",
+		Payload: []byte("hi"),
+		Headers: map[string]string{"tenant": "acme"},
+	}, got)
+}
@@ -0,0 +1,159 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/kafka"
+)
+
+// kafkaSender is the part of *kafka.Producer used by KafkaPublisher.
+type kafkaSender interface {
+	SendBytes(ctx context.Context, topic string, key string, value []byte, headers map[string]string) error
+	Close() error
+}
+
+// KafkaPublisher publishes through a kafka.Producer.
+type KafkaPublisher struct {
+	producer kafkaSender
+
+	// scheduler and delays implement PublishAfter; delays is nil without a
+	// delay topology.
+	scheduler *kafka.Producer
+	delays    *kafka.DelayTopology
+}
+
+// KafkaPublisherOption configures a KafkaPublisher.
+type KafkaPublisherOption func(*KafkaPublisher)
+
+// WithDelayTopology implements PublishAfter with topo. Its tier topics must
+// be consumed by topo.Forwarder for the messages to be delivered.
+func WithDelayTopology(topo kafka.DelayTopology) KafkaPublisherOption {
+	return func(p *KafkaPublisher) {
+		if len(topo.Tiers) > 0 {
+			p.delays = &topo
+		}
+	}
+}
+
+// NewKafkaPublisher adapts producer to Publisher.
+func NewKafkaPublisher(producer *kafka.Producer, opts ...KafkaPublisherOption) *KafkaPublisher {
+	p := &KafkaPublisher{producer: producer, scheduler: producer}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// newKafkaPublisherFromEnv creates a publisher from kafka.NewConfigFromEnv,
+// with the delay topology of kafka.NewDelayTopologyFromEnv.
+func newKafkaPublisherFromEnv() (Publisher, error) {
+	cfg, err := kafka.NewConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	topo, err := kafka.NewDelayTopologyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	producer, err := kafka.NewProducer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewKafkaPublisher(producer, WithDelayTopology(topo)), nil
+}
+
+// Publish implements Publisher.
+func (p *KafkaPublisher) Publish(ctx context.Context, msg *Message) error {
+	return p.producer.SendBytes(ctx, msg.Topic, msg.Key, msg.Payload, msg.Headers)
+}
+
+// PublishAfter implements DelayedPublisher through the delay topology. It
+// returns ErrDelayUnsupported when the publisher has none.
+func (p *KafkaPublisher) PublishAfter(ctx context.Context, delay time.Duration, msg *Message) error {
+	if p.delays == nil {
+		return ErrDelayUnsupported
+	}
+	return p.delays.SendAfter(ctx, p.scheduler, delay, msg.Topic, msg.Key, msg.Payload, msg.Headers)
+}
+
+// Close closes the underlying producer.
+func (p *KafkaPublisher) Close() error {
+	return p.producer.Close()
+}
+
+// KafkaSubscriber consumes topics as a Kafka consumer group.
+type KafkaSubscriber struct {
+	cfg     *kafka.Config
+	groupID string
+	opts    []kafka.ConsumerOption
+
+	mu        sync.Mutex
+	consumers []*kafka.Consumer
+}
+
+// NewKafkaSubscriber returns a Subscriber consuming as groupID. opts apply
+// to every consumer it creates.
+func NewKafkaSubscriber(cfg *kafka.Config, groupID string, opts ...kafka.ConsumerOption) *KafkaSubscriber {
+	return &KafkaSubscriber{cfg: cfg, groupID: groupID, opts: opts}
+}
+
+func newKafkaSubscriberFromEnv(group string) (Subscriber, error) {
+	if group == "" {
+		return nil, fmt.Errorf("consumer group is required")
+	}
+	cfg, err := kafka.NewConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewKafkaSubscriber(cfg, group), nil
+}
+
+// Subscribe implements Subscriber.
+func (s *KafkaSubscriber) Subscribe(ctx context.Context, topics []string, handler Handler) error {
+	consumer, err := kafka.NewConsumer(s.cfg, s.groupID, topics, kafkaHandler{handler}, s.opts...)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.consumers = append(s.consumers, consumer)
+	s.mu.Unlock()
+
+	if err := consumer.Run(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// Close closes the consumers started by Subscribe.
+func (s *KafkaSubscriber) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for _, c := range s.consumers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.consumers = nil
+	return errors.Join(errs...)
+}
+
+// kafkaHandler adapts a Handler to kafka.MessageHandler.
+type kafkaHandler struct {
+	handler Handler
+}
+
+func (h kafkaHandler) HandleMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	return h.handler.Handle(ctx, &Message{
+		ID:      fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset),
+		Topic:   msg.Topic,
+		Key:     string(msg.Key),
+		Payload: msg.Value,
+		Headers: kafka.Headers(msg),
+	})
+}
@@ -0,0 +1,163 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/IBM/sarama"
+)
+
+// ErrTopicNotFound is returned by DescribeTopic for unknown topics.
+var ErrTopicNotFound = errors.New("kafka: topic not found")
+
+// TopicConfig describes a topic to create.
+type TopicConfig struct {
+	// Partitions defaults to 1.
+	Partitions int32
+
+	// ReplicationFactor defaults to 1. Production clusters usually want 3.
+	ReplicationFactor int16
+
+	// Configs holds topic-level settings such as "retention.ms" or
+	// "cleanup.policy".
+	Configs map[string]string
+}
+
+// TopicDescription is the current layout and configuration of a topic.
+type TopicDescription struct {
+	Name              string
+	Partitions        []int32
+	ReplicationFactor int16
+
+	// Configs holds the topic-level overrides of the broker settings.
+	Configs map[string]string
+}
+
+// Admin manages topics on a cluster.
+type Admin struct {
+	admin sarama.ClusterAdmin
+}
+
+// NewAdmin connects an admin client using the connection and security
+// settings of cfg.
+//
+// Example:
+//
+//	admin, err := kafka.NewAdmin(cfg)
+//	if err != nil {
+//	    return err
+//	}
+//	defer admin.Close()
+//	err = admin.EnsureTopic("orders", kafka.TopicConfig{
+//	    Partitions:        12,
+//	    ReplicationFactor: 3,
+//	    Configs:           map[string]string{"retention.ms": "604800000"},
+//	})
+func NewAdmin(cfg *Config) (*Admin, error) {
+	saramaCfg, err := newSaramaConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	admin, err := sarama.NewClusterAdmin(cfg.Brokers, saramaCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka admin client: %w", err)
+	}
+	return &Admin{admin: admin}, nil
+}
+
+// CreateTopic creates a topic. It fails with an error wrapping
+// sarama.ErrTopicAlreadyExists if the topic exists; see EnsureTopic.
+func (a *Admin) CreateTopic(name string, tc TopicConfig) error {
+	detail := &sarama.TopicDetail{
+		NumPartitions:     tc.Partitions,
+		ReplicationFactor: tc.ReplicationFactor,
+	}
+	if detail.NumPartitions <= 0 {
+		detail.NumPartitions = 1
+	}
+	if detail.ReplicationFactor <= 0 {
+		detail.ReplicationFactor = 1
+	}
+	if len(tc.Configs) > 0 {
+		detail.ConfigEntries = make(map[string]*string, len(tc.Configs))
+		for k, v := range tc.Configs {
+			detail.ConfigEntries[k] = &v
+		}
+	}
+
+	if err := a.admin.CreateTopic(name, detail, false); err != nil {
+		return fmt.Errorf("failed to create topic %s: %w", name, err)
+	}
+	return nil
+}
+
+// EnsureTopic creates a topic unless it already exists. An existing topic
+// is left unchanged even if its settings differ from tc.
+func (a *Admin) EnsureTopic(name string, tc TopicConfig) error {
+	err := a.CreateTopic(name, tc)
+	if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		return nil
+	}
+	return err
+}
+
+// TopicExists reports whether a topic exists.
+func (a *Admin) TopicExists(name string) (bool, error) {
+	topics, err := a.admin.ListTopics()
+	if err != nil {
+		return false, fmt.Errorf("failed to list topics: %w", err)
+	}
+	_, ok := topics[name]
+	return ok, nil
+}
+
+// DescribeTopic returns the partitions, replication factor and non-default
+// configuration of a topic, or ErrTopicNotFound.
+func (a *Admin) DescribeTopic(name string) (*TopicDescription, error) {
+	meta, err := a.admin.DescribeTopics([]string{name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe topic %s: %w", name, err)
+	}
+	if len(meta) == 0 || errors.Is(meta[0].Err, sarama.ErrUnknownTopicOrPartition) {
+		return nil, ErrTopicNotFound
+	}
+	if meta[0].Err != sarama.ErrNoError {
+		return nil, fmt.Errorf("failed to describe topic %s: %w", name, meta[0].Err)
+	}
+
+	desc := &TopicDescription{Name: name, Configs: map[string]string{}}
+	for _, p := range meta[0].Partitions {
+		desc.Partitions = append(desc.Partitions, p.ID)
+		if n := int16(len(p.Replicas)); n > desc.ReplicationFactor {
+			desc.ReplicationFactor = n
+		}
+	}
+	sort.Slice(desc.Partitions, func(i, j int) bool { return desc.Partitions[i] < desc.Partitions[j] })
+
+	entries, err := a.admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe config of topic %s: %w", name, err)
+	}
+	for _, e := range entries {
+		if e.Default || e.Source == sarama.SourceDefault || e.Source == sarama.SourceStaticBroker {
+			continue
+		}
+		desc.Configs[e.Name] = e.Value
+	}
+	return desc, nil
+}
+
+// DeleteTopic deletes a topic. Deletion is asynchronous on the broker side;
+// the topic may remain visible for a short while.
+func (a *Admin) DeleteTopic(name string) error {
+	if err := a.admin.DeleteTopic(name); err != nil {
+		return fmt.Errorf("failed to delete topic %s: %w", name, err)
+	}
+	return nil
+}
+
+// Close closes the admin client.
+func (a *Admin) Close() error {
+	return a.admin.Close()
+}
@@ -0,0 +1,139 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClusterAdmin keeps topics in memory. Methods not overridden panic
+// through the nil embedded interface.
+type fakeClusterAdmin struct {
+	sarama.ClusterAdmin
+	topics  map[string]*sarama.TopicDetail
+	configs []sarama.ConfigEntry
+}
+
+func (f *fakeClusterAdmin) CreateTopic(name string, detail *sarama.TopicDetail, _ bool) error {
+	if _, ok := f.topics[name]; ok {
+		return &sarama.TopicError{Err: sarama.ErrTopicAlreadyExists}
+	}
+	f.topics[name] = detail
+	return nil
+}
+
+func (f *fakeClusterAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
+	out := map[string]sarama.TopicDetail{}
+	for name, d := range f.topics {
+		out[name] = *d
+	}
+	return out, nil
+}
+
+func (f *fakeClusterAdmin) DescribeTopics(names []string) ([]*sarama.TopicMetadata, error) {
+	var out []*sarama.TopicMetadata
+	for _, name := range names {
+		d, ok := f.topics[name]
+		if !ok {
+			out = append(out, &sarama.TopicMetadata{Name: name, Err: sarama.ErrUnknownTopicOrPartition})
+			continue
+		}
+		meta := &sarama.TopicMetadata{Name: name}
+		for p := d.NumPartitions - 1; p >= 0; p-- {
+			meta.Partitions = append(meta.Partitions, &sarama.PartitionMetadata{
+				ID:       p,
+				Replicas: make([]int32, d.ReplicationFactor),
+			})
+		}
+		out = append(out, meta)
+	}
+	return out, nil
+}
+
+func (f *fakeClusterAdmin) DescribeConfig(sarama.ConfigResource) ([]sarama.ConfigEntry, error) {
+	return f.configs, nil
+}
+
+func (f *fakeClusterAdmin) DeleteTopic(name string) error {
+	if _, ok := f.topics[name]; !ok {
+		return sarama.ErrUnknownTopicOrPartition
+	}
+	delete(f.topics, name)
+	return nil
+}
+
+func newTestAdmin() (*Admin, *fakeClusterAdmin) {
+	fake := &fakeClusterAdmin{topics: map[string]*sarama.TopicDetail{}}
+	return &Admin{admin: fake}, fake
+}
+
+func TestAdmin_CreateTopic(t *testing.T) {
+	a, fake := newTestAdmin()
+
+	require.NoError(t, a.CreateTopic("orders", TopicConfig{
+		Partitions:        6,
+		ReplicationFactor: 3,
+		Configs:           map[string]string{"retention.ms": "1000"},
+	}))
+	d := fake.topics["orders"]
+	assert.Equal(t, int32(6), d.NumPartitions)
+	assert.Equal(t, int16(3), d.ReplicationFactor)
+	assert.Equal(t, "1000", *d.ConfigEntries["retention.ms"])
+
+	err := a.CreateTopic("orders", TopicConfig{})
+	assert.ErrorIs(t, err, sarama.ErrTopicAlreadyExists)
+}
+
+func TestAdmin_CreateTopicDefaults(t *testing.T) {
+	a, fake := newTestAdmin()
+
+	require.NoError(t, a.CreateTopic("events", TopicConfig{}))
+	assert.Equal(t, int32(1), fake.topics["events"].NumPartitions)
+	assert.Equal(t, int16(1), fake.topics["events"].ReplicationFactor)
+	assert.Nil(t, fake.topics["events"].ConfigEntries)
+}
+
+func TestAdmin_EnsureTopic(t *testing.T) {
+	a, fake := newTestAdmin()
+
+	require.NoError(t, a.EnsureTopic("orders", TopicConfig{Partitions: 3}))
+	require.NoError(t, a.EnsureTopic("orders", TopicConfig{Partitions: 9}))
+	assert.Equal(t, int32(3), fake.topics["orders"].NumPartitions)
+}
+
+func TestAdmin_TopicExistsAndDelete(t *testing.T) {
+	a, _ := newTestAdmin()
+	require.NoError(t, a.CreateTopic("orders", TopicConfig{}))
+
+	ok, err := a.TopicExists("orders")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, a.DeleteTopic("orders"))
+	ok, err = a.TopicExists("orders")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.ErrorIs(t, a.DeleteTopic("orders"), sarama.ErrUnknownTopicOrPartition)
+}
+
+func TestAdmin_DescribeTopic(t *testing.T) {
+	a, fake := newTestAdmin()
+	require.NoError(t, a.CreateTopic("orders", TopicConfig{Partitions: 3, ReplicationFactor: 2}))
+	fake.configs = []sarama.ConfigEntry{
+		{Name: "retention.ms", Value: "1000", Source: sarama.SourceTopic},
+		{Name: "cleanup.policy", Value: "delete", Source: sarama.SourceDefault},
+	}
+
+	d, err := a.DescribeTopic("orders")
+	require.NoError(t, err)
+	assert.Equal(t, "orders", d.Name)
+	assert.Equal(t, []int32{0, 1, 2}, d.Partitions)
+	assert.Equal(t, int16(2), d.ReplicationFactor)
+	assert.Equal(t, map[string]string{"retention.ms": "1000"}, d.Configs)
+
+	_, err = a.DescribeTopic("missing")
+	assert.ErrorIs(t, err, ErrTopicNotFound)
+}
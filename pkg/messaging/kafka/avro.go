@@ -0,0 +1,145 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"github.com/hamba/avro/v2"
+)
+
+// ErrInvalidAvroFrame is returned when a message is not in the Confluent
+// wire format (magic byte 0 followed by a 4-byte schema ID).
+var ErrInvalidAvroFrame = errors.New("invalid avro message framing")
+
+// avroMagicByte prefixes every Confluent-framed message.
+const avroMagicByte = 0
+
+// SetSchemaRegistry sets the registry used by SendAvro.
+func (p *Producer) SetSchemaRegistry(reg *SchemaRegistry) {
+	p.registry = reg
+}
+
+// SendAvro publishes value encoded with the latest schema registered under
+// the topic's value subject ("<topic>-value"). The payload is framed with
+// the schema ID so any Schema Registry aware consumer can decode it.
+// Correlation IDs found in ctx are added as message headers.
+//
+// Example:
+//
+//	type UserCreated struct {
+//	    ID    string `avro:"id"`
+//	    Email string `avro:"email"`
+//	}
+//
+//	err := producer.SendAvro(ctx, "users", user.ID, UserCreated{ID: user.ID, Email: user.Email})
+func (p *Producer) SendAvro(ctx context.Context, topic string, key string, value any) error {
+	if p.registry == nil {
+		return fmt.Errorf("schema registry is not configured")
+	}
+
+	data, err := p.registry.EncodeAvro(ctx, SubjectForTopic(topic), value)
+	if err != nil {
+		return err
+	}
+	return p.send(ctx, topic, p.keyFor(topic, key, value), data, ContentTypeAvro, nil)
+}
+
+// EncodeAvro encodes value with the latest schema of subject and returns it
+// in the Confluent wire format.
+func (r *SchemaRegistry) EncodeAvro(ctx context.Context, subject string, value any) ([]byte, error) {
+	s, err := r.Latest(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := r.parsedSchema(s.ID, s.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := avro.Marshal(schema, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode avro message: %w", err)
+	}
+
+	out := make([]byte, 5, 5+len(payload))
+	out[0] = avroMagicByte
+	binary.BigEndian.PutUint32(out[1:5], uint32(s.ID))
+	return append(out, payload...), nil
+}
+
+// DecodeAvro decodes a Confluent-framed message into out, using the writer
+// schema referenced by the embedded schema ID.
+func (r *SchemaRegistry) DecodeAvro(ctx context.Context, data []byte, out any) error {
+	if len(data) < 5 || data[0] != avroMagicByte {
+		return ErrInvalidAvroFrame
+	}
+	id := int(binary.BigEndian.Uint32(data[1:5]))
+
+	raw, err := r.SchemaByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	schema, err := r.parsedSchema(id, raw)
+	if err != nil {
+		return err
+	}
+
+	if err := avro.Unmarshal(schema, data[5:], out); err != nil {
+		return fmt.Errorf("failed to decode avro message: %w", err)
+	}
+	return nil
+}
+
+// parsedSchema returns the parsed form of schema, caching it by ID.
+func (r *SchemaRegistry) parsedSchema(id int, raw string) (avro.Schema, error) {
+	r.mu.RLock()
+	schema, ok := r.parsed[id]
+	r.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	schema, err := avro.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %d: %w", id, err)
+	}
+	r.mu.Lock()
+	r.parsed[id] = schema
+	r.mu.Unlock()
+	return schema, nil
+}
+
+// AvroHandlerFunc handles a decoded Avro message.
+type AvroHandlerFunc[T any] func(ctx context.Context, msg *sarama.ConsumerMessage, value *T) error
+
+// avroHandler adapts an AvroHandlerFunc to MessageHandler.
+type avroHandler[T any] struct {
+	registry *SchemaRegistry
+	fn       AvroHandlerFunc[T]
+}
+
+// ConsumeAvro returns a MessageHandler that decodes each message with reg
+// before passing it to fn. Messages that fail to decode are returned as
+// errors and therefore not marked as consumed.
+//
+// Example:
+//
+//	handler := kafka.ConsumeAvro(reg, func(ctx context.Context, msg *sarama.ConsumerMessage, ev *UserCreated) error {
+//	    return svc.OnUserCreated(ctx, ev)
+//	})
+//	consumer, err := kafka.NewConsumer(cfg, "billing", []string{"users"}, handler)
+func ConsumeAvro[T any](reg *SchemaRegistry, fn AvroHandlerFunc[T]) MessageHandler {
+	return &avroHandler[T]{registry: reg, fn: fn}
+}
+
+// HandleMessage implements MessageHandler.
+func (h *avroHandler[T]) HandleMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	var value T
+	if err := h.registry.DecodeAvro(ctx, msg.Value, &value); err != nil {
+		return err
+	}
+	return h.fn(ctx, msg, &value)
+}
@@ -0,0 +1,71 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testUser struct {
+	ID  string `avro:"id"`
+	Age int    `avro:"age"`
+}
+
+func TestSendAvro_FramesWithSchemaID(t *testing.T) {
+	srv, _ := newFakeRegistry(t)
+
+	mockProducer := mocks.NewSyncProducer(t, nil)
+	defer func() { _ = mockProducer.Close() }()
+
+	var sent []byte
+	mockProducer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(m *sarama.ProducerMessage) error {
+		sent, _ = m.Value.Encode()
+		return nil
+	})
+
+	p := &Producer{producer: mockProducer}
+	p.SetSchemaRegistry(NewSchemaRegistry(srv.URL))
+
+	err := p.SendAvro(context.Background(), "users", "u1", testUser{ID: "u1", Age: 42})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0, 7}, sent[:5])
+
+	var out testUser
+	require.NoError(t, NewSchemaRegistry(srv.URL).DecodeAvro(context.Background(), sent, &out))
+	assert.Equal(t, testUser{ID: "u1", Age: 42}, out)
+}
+
+func TestSendAvro_RequiresRegistry(t *testing.T) {
+	p := &Producer{producer: mocks.NewSyncProducer(t, nil)}
+	assert.Error(t, p.SendAvro(context.Background(), "users", "u1", testUser{}))
+}
+
+func TestDecodeAvro_InvalidFrame(t *testing.T) {
+	reg := NewSchemaRegistry("http://unused")
+	err := reg.DecodeAvro(context.Background(), []byte(`{"id":"u1"}`), &testUser{})
+	assert.True(t, errors.Is(err, ErrInvalidAvroFrame))
+}
+
+func TestConsumeAvro(t *testing.T) {
+	srv, _ := newFakeRegistry(t)
+	reg := NewSchemaRegistry(srv.URL)
+
+	data, err := reg.EncodeAvro(context.Background(), "users-value", testUser{ID: "u2", Age: 7})
+	require.NoError(t, err)
+
+	var got *testUser
+	h := ConsumeAvro(reg, func(ctx context.Context, msg *sarama.ConsumerMessage, v *testUser) error {
+		got = v
+		return nil
+	})
+
+	require.NoError(t, h.HandleMessage(context.Background(), &sarama.ConsumerMessage{Value: data}))
+	assert.Equal(t, &testUser{ID: "u2", Age: 7}, got)
+
+	assert.Error(t, h.HandleMessage(context.Background(), &sarama.ConsumerMessage{Value: []byte("junk")}))
+}
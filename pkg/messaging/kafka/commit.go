@@ -0,0 +1,111 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// CommitStrategy controls when consumed offsets are committed.
+type CommitStrategy int
+
+const (
+	// CommitAuto marks each message after its handler succeeds and lets
+	// Sarama commit marked offsets in the background (every second).
+	CommitAuto CommitStrategy = iota
+
+	// CommitInterval marks like CommitAuto but commits on the interval set
+	// with WithCommitInterval.
+	CommitInterval
+
+	// CommitManual never marks or commits on its own. Handlers use the
+	// Session from SessionFromContext to mark and commit explicitly, e.g.
+	// after the result has been written to a database.
+	CommitManual
+)
+
+// DefaultCommitInterval is used by CommitInterval when no interval is set.
+const DefaultCommitInterval = 5 * time.Second
+
+// WithCommitStrategy selects the commit strategy. The default is CommitAuto.
+func WithCommitStrategy(s CommitStrategy) ConsumerOption {
+	return func(o *consumerOptions) { o.commit = s }
+}
+
+// WithCommitInterval selects CommitInterval with the given interval.
+func WithCommitInterval(d time.Duration) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.commit = CommitInterval
+		o.commitInterval = d
+	}
+}
+
+// applyCommit configures Sarama offset commits for the strategy.
+func (o consumerOptions) applyCommit(sc *sarama.Config) {
+	switch o.commit {
+	case CommitInterval:
+		sc.Consumer.Offsets.AutoCommit.Enable = true
+		sc.Consumer.Offsets.AutoCommit.Interval = DefaultCommitInterval
+		if o.commitInterval > 0 {
+			sc.Consumer.Offsets.AutoCommit.Interval = o.commitInterval
+		}
+	case CommitManual:
+		sc.Consumer.Offsets.AutoCommit.Enable = false
+	}
+}
+
+// Session is the handle passed to handlers through the context. It gives
+// access to offset marking and committing for the message being handled.
+type Session struct {
+	sess sarama.ConsumerGroupSession
+	msg  *sarama.ConsumerMessage
+}
+
+type sessionKey struct{}
+
+// SessionFromContext returns the consumer session handle for the message
+// being handled, if ctx came from a Consumer.
+//
+// Example:
+//
+//	func (h *Handler) HandleMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+//	    if err := h.repo.Save(ctx, decode(msg)); err != nil {
+//	        return err
+//	    }
+//	    if s, ok := kafka.SessionFromContext(ctx); ok {
+//	        s.MarkAndCommit()
+//	    }
+//	    return nil
+//	}
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(sessionKey{}).(*Session)
+	return s, ok
+}
+
+// withSession attaches a Session for msg to ctx.
+func withSession(ctx context.Context, sess sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &Session{sess: sess, msg: msg})
+}
+
+// Mark marks the current message as consumed. With WithConcurrency, marking
+// a message also covers every earlier offset of its partition.
+func (s *Session) Mark() {
+	s.sess.MarkMessage(s.msg, "")
+}
+
+// Commit synchronously commits all marked offsets.
+func (s *Session) Commit() {
+	s.sess.Commit()
+}
+
+// MarkAndCommit marks the current message and commits it.
+func (s *Session) MarkAndCommit() {
+	s.Mark()
+	s.Commit()
+}
+
+// Message returns the message the session handle belongs to.
+func (s *Session) Message() *sarama.ConsumerMessage {
+	return s.msg
+}
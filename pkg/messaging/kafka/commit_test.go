@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// committingSession records marks and commits.
+type committingSession struct {
+	markingSession
+	commits int
+}
+
+func (s *committingSession) Commit() { s.commits++ }
+
+func TestCommitManual_HandlerControlsOffsets(t *testing.T) {
+	msgs := []*sarama.ConsumerMessage{
+		{Topic: "topic", Offset: 0, Value: []byte("skip")},
+		{Topic: "topic", Offset: 1, Value: []byte("commit")},
+	}
+
+	h := &consumerGroupHandler{
+		opts: consumerOptions{commit: CommitManual},
+		handler: MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+			s, ok := SessionFromContext(ctx)
+			require.True(t, ok)
+			assert.Same(t, msg, s.Message())
+			if string(msg.Value) == "commit" {
+				s.MarkAndCommit()
+			}
+			return nil
+		}),
+	}
+
+	sess := &committingSession{markingSession: markingSession{fakeSession: fakeSession{ctx: context.Background()}}}
+	assert.NoError(t, h.ConsumeClaim(sess, claimOf(msgs...)))
+	assert.Equal(t, []int64{1}, sess.marked)
+	assert.Equal(t, 1, sess.commits)
+}
+
+func TestCommitManual_ConcurrentDoesNotAutoMark(t *testing.T) {
+	h := &consumerGroupHandler{
+		opts: consumerOptions{commit: CommitManual, concurrency: 2},
+		handler: MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+			return nil
+		}),
+	}
+
+	sess := &markingSession{fakeSession: fakeSession{ctx: context.Background()}}
+	assert.NoError(t, h.ConsumeClaim(sess, claimOf(&sarama.ConsumerMessage{Offset: 0}, &sarama.ConsumerMessage{Offset: 1})))
+	assert.Empty(t, sess.marked)
+}
+
+func TestCommitAuto_MarksOnSuccess(t *testing.T) {
+	h := &consumerGroupHandler{handler: MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		return nil
+	})}
+
+	sess := &markingSession{fakeSession: fakeSession{ctx: context.Background()}}
+	assert.NoError(t, h.ConsumeClaim(sess, claimOf(&sarama.ConsumerMessage{Offset: 3})))
+	assert.Equal(t, []int64{3}, sess.marked)
+}
+
+func TestApplyCommit(t *testing.T) {
+	sc := sarama.NewConfig()
+	consumerOptions{}.applyCommit(sc)
+	assert.True(t, sc.Consumer.Offsets.AutoCommit.Enable)
+	assert.Equal(t, time.Second, sc.Consumer.Offsets.AutoCommit.Interval)
+
+	var o consumerOptions
+	WithCommitInterval(10 * time.Second)(&o)
+	sc = sarama.NewConfig()
+	o.applyCommit(sc)
+	assert.Equal(t, CommitInterval, o.commit)
+	assert.Equal(t, 10*time.Second, sc.Consumer.Offsets.AutoCommit.Interval)
+
+	o = consumerOptions{}
+	WithCommitStrategy(CommitManual)(&o)
+	sc = sarama.NewConfig()
+	o.applyCommit(sc)
+	assert.False(t, sc.Consumer.Offsets.AutoCommit.Enable)
+}
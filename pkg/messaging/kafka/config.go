@@ -0,0 +1,306 @@
+package kafka
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/env"
+)
+
+// Defaults applied when the corresponding tuning field is empty.
+const (
+	DefaultAcks              = "all"
+	DefaultMaxRetries        = 5
+	DefaultCompression       = "none"
+	DefaultPartitioner       = "hash"
+	DefaultInitialOffset     = "newest"
+	DefaultRebalanceStrategy = "roundrobin"
+)
+
+// ProducerConfig tunes the producer. Zero values select the defaults.
+type ProducerConfig struct {
+	// Acks is "all" (default), "leader" or "none".
+	Acks string
+
+	// MaxRetries is the number of send retries. Zero selects
+	// DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+
+	// RetryBackoff is the wait between retries (Sarama default when zero).
+	RetryBackoff time.Duration
+
+	// Compression is "none" (default), "gzip", "snappy", "lz4" or "zstd".
+	// Batches are compressed once by the producer and stay compressed on
+	// the broker, which saves both network and storage for JSON payloads.
+	Compression string
+
+	// CompressionLevel trades CPU for ratio: 1-9 for gzip, 1-22 for zstd.
+	// Zero selects the codec default; other codecs do not accept a level.
+	CompressionLevel int
+
+	// Partitioner is "hash" (default, FNV-1a of the key), "crc32" (CRC32 of
+	// the key, matching librdkafka's consistent partitioner), "ring" (a
+	// consistent-hash ring that moves few keys when partitions are added),
+	// "roundrobin", "random" or "manual" (the partition set with
+	// WithPartition). Keyless messages are spread randomly by the hash
+	// partitioners.
+	Partitioner string
+
+	// Idempotent makes the broker discard duplicates caused by producer
+	// retries. It requires Acks "all", retries and Kafka 0.11+.
+	Idempotent bool
+
+	// TransactionalID enables transactions (see WithinTransaction). It must
+	// be unique per producer instance and stable across restarts, e.g.
+	// "<service>-<pod ordinal>". It implies Idempotent.
+	TransactionalID string
+}
+
+// ConsumerConfig tunes the consumer group. Zero values select the defaults.
+type ConsumerConfig struct {
+	// InitialOffset is "newest" (default) or "oldest", used when the group
+	// has no committed offset.
+	InitialOffset string
+
+	// SessionTimeout is the group session timeout (Sarama default when zero).
+	SessionTimeout time.Duration
+
+	// RebalanceStrategy is "roundrobin" (default), "range" or "sticky".
+	RebalanceStrategy string
+
+	// ReadCommitted hides messages of aborted and open transactions. Enable
+	// it when consuming topics written with WithinTransaction.
+	ReadCommitted bool
+}
+
+// parseBrokers splits a comma-separated broker list, dropping blanks.
+func parseBrokers(s string) []string {
+	var out []string
+	for _, b := range strings.Split(s, ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// tuningFromEnv reads producer and consumer tuning from the environment:
+//
+//	KAFKA_PRODUCER_ACKS                all, leader, none
+//	KAFKA_PRODUCER_MAX_RETRIES         integer, negative disables retries
+//	KAFKA_PRODUCER_RETRY_BACKOFF       duration, e.g. 250ms
+//	KAFKA_PRODUCER_COMPRESSION         none, gzip, snappy, lz4, zstd
+//	KAFKA_PRODUCER_COMPRESSION_LEVEL   integer, codec specific
+//	KAFKA_PRODUCER_PARTITIONER         hash, crc32, roundrobin, random, manual
+//	KAFKA_PRODUCER_IDEMPOTENT          "true" for duplicate-free retries
+//	KAFKA_PRODUCER_TRANSACTIONAL_ID    enables transactions
+//	KAFKA_CONSUMER_INITIAL_OFFSET      newest, oldest
+//	KAFKA_CONSUMER_SESSION_TIMEOUT     duration, e.g. 30s
+//	KAFKA_CONSUMER_REBALANCE_STRATEGY  roundrobin, range, sticky
+//	KAFKA_CONSUMER_READ_COMMITTED      "true" to skip uncommitted transactional messages
+//
+// Values are validated here so misconfiguration fails at startup.
+func tuningFromEnv() (ProducerConfig, ConsumerConfig, error) {
+	prod := ProducerConfig{
+		Acks:            os.Getenv("KAFKA_PRODUCER_ACKS"),
+		Compression:     os.Getenv("KAFKA_PRODUCER_COMPRESSION"),
+		Partitioner:     os.Getenv("KAFKA_PRODUCER_PARTITIONER"),
+		TransactionalID: os.Getenv("KAFKA_PRODUCER_TRANSACTIONAL_ID"),
+	}
+	cons := ConsumerConfig{
+		InitialOffset:     os.Getenv("KAFKA_CONSUMER_INITIAL_OFFSET"),
+		RebalanceStrategy: os.Getenv("KAFKA_CONSUMER_REBALANCE_STRATEGY"),
+	}
+
+	var err error
+	if v := os.Getenv("KAFKA_PRODUCER_MAX_RETRIES"); v != "" {
+		if prod.MaxRetries, err = strconv.Atoi(v); err != nil {
+			return prod, cons, fmt.Errorf("invalid KAFKA_PRODUCER_MAX_RETRIES: %w", err)
+		}
+	}
+	if v := os.Getenv("KAFKA_PRODUCER_COMPRESSION_LEVEL"); v != "" {
+		if prod.CompressionLevel, err = strconv.Atoi(v); err != nil {
+			return prod, cons, fmt.Errorf("invalid KAFKA_PRODUCER_COMPRESSION_LEVEL: %w", err)
+		}
+	}
+	if prod.RetryBackoff, err = env.Duration("KAFKA_PRODUCER_RETRY_BACKOFF"); err != nil {
+		return prod, cons, err
+	}
+	if prod.Idempotent, err = env.Bool("KAFKA_PRODUCER_IDEMPOTENT"); err != nil {
+		return prod, cons, err
+	}
+	if cons.SessionTimeout, err = env.Duration("KAFKA_CONSUMER_SESSION_TIMEOUT"); err != nil {
+		return prod, cons, err
+	}
+	if cons.ReadCommitted, err = env.Bool("KAFKA_CONSUMER_READ_COMMITTED"); err != nil {
+		return prod, cons, err
+	}
+
+	if err := prod.apply(sarama.NewConfig()); err != nil {
+		return prod, cons, err
+	}
+	if err := cons.apply(sarama.NewConfig()); err != nil {
+		return prod, cons, err
+	}
+	return prod, cons, nil
+}
+
+// apply sets the producer tuning on a Sarama config.
+func (p ProducerConfig) apply(sc *sarama.Config) error {
+	switch strings.ToLower(valueOr(p.Acks, DefaultAcks)) {
+	case "all", "-1":
+		sc.Producer.RequiredAcks = sarama.WaitForAll
+	case "leader", "1":
+		sc.Producer.RequiredAcks = sarama.WaitForLocal
+	case "none", "0":
+		sc.Producer.RequiredAcks = sarama.NoResponse
+	default:
+		return fmt.Errorf("invalid producer acks %q", p.Acks)
+	}
+
+	switch {
+	case p.MaxRetries == 0:
+		sc.Producer.Retry.Max = DefaultMaxRetries
+	case p.MaxRetries < 0:
+		sc.Producer.Retry.Max = 0
+	default:
+		sc.Producer.Retry.Max = p.MaxRetries
+	}
+	if p.RetryBackoff > 0 {
+		sc.Producer.Retry.Backoff = p.RetryBackoff
+	}
+
+	codec, err := parseCompression(valueOr(p.Compression, DefaultCompression))
+	if err != nil {
+		return err
+	}
+	sc.Producer.Compression = codec
+	if sc.Producer.CompressionLevel, err = compressionLevel(codec, p.CompressionLevel); err != nil {
+		return err
+	}
+
+	partitioner, err := parsePartitioner(valueOr(p.Partitioner, DefaultPartitioner))
+	if err != nil {
+		return err
+	}
+	sc.Producer.Partitioner = partitioner
+
+	if p.Idempotent || p.TransactionalID != "" {
+		if err := enableIdempotence(sc); err != nil {
+			return err
+		}
+		sc.Producer.Transaction.ID = p.TransactionalID
+	}
+	return nil
+}
+
+// enableIdempotence turns on idempotent writes after checking the settings
+// they depend on: acks from all in-sync replicas, at least one retry and a
+// broker protocol of 0.11 or newer. Idempotence also limits the producer to
+// one in-flight request per broker, which keeps retried batches in order.
+func enableIdempotence(sc *sarama.Config) error {
+	if sc.Producer.RequiredAcks != sarama.WaitForAll {
+		return fmt.Errorf("idempotent producer requires acks %q", "all")
+	}
+	if sc.Producer.Retry.Max < 1 {
+		return fmt.Errorf("idempotent producer requires retries")
+	}
+	if !sc.Version.IsAtLeast(sarama.V0_11_0_0) {
+		return fmt.Errorf("idempotent producer requires Kafka version 0.11 or newer, got %s", sc.Version)
+	}
+	sc.Producer.Idempotent = true
+	sc.Net.MaxOpenRequests = 1
+	return nil
+}
+
+// parsePartitioner maps a partitioner name to its Sarama constructor.
+func parsePartitioner(name string) (sarama.PartitionerConstructor, error) {
+	switch strings.ToLower(name) {
+	case "hash":
+		return sarama.NewHashPartitioner, nil
+	case "crc32", "consistent":
+		return sarama.NewConsistentCRCHashPartitioner, nil
+	case "ring":
+		return NewRingPartitioner, nil
+	case "roundrobin":
+		return sarama.NewRoundRobinPartitioner, nil
+	case "random":
+		return sarama.NewRandomPartitioner, nil
+	case "manual":
+		return sarama.NewManualPartitioner, nil
+	default:
+		return nil, fmt.Errorf("invalid partitioner %q", name)
+	}
+}
+
+// parseCompression maps a codec name to its Sarama value.
+func parseCompression(name string) (sarama.CompressionCodec, error) {
+	var codec sarama.CompressionCodec
+	if err := codec.UnmarshalText([]byte(strings.ToLower(name))); err != nil {
+		return sarama.CompressionNone, fmt.Errorf("invalid compression %q", name)
+	}
+	return codec, nil
+}
+
+// compressionLevel validates level for codec and maps zero to the Sarama
+// default.
+func compressionLevel(codec sarama.CompressionCodec, level int) (int, error) {
+	if level == 0 {
+		return sarama.CompressionLevelDefault, nil
+	}
+	maxLevel := 0
+	switch codec {
+	case sarama.CompressionGZIP:
+		maxLevel = 9
+	case sarama.CompressionZSTD:
+		maxLevel = 22
+	}
+	if level < 1 || level > maxLevel {
+		return 0, fmt.Errorf("invalid compression level %d for %s", level, codec)
+	}
+	return level, nil
+}
+
+// apply sets the consumer tuning on a Sarama config.
+func (c ConsumerConfig) apply(sc *sarama.Config) error {
+	switch strings.ToLower(valueOr(c.InitialOffset, DefaultInitialOffset)) {
+	case "newest", "latest":
+		sc.Consumer.Offsets.Initial = sarama.OffsetNewest
+	case "oldest", "earliest":
+		sc.Consumer.Offsets.Initial = sarama.OffsetOldest
+	default:
+		return fmt.Errorf("invalid consumer initial offset %q", c.InitialOffset)
+	}
+
+	if c.SessionTimeout > 0 {
+		sc.Consumer.Group.Session.Timeout = c.SessionTimeout
+	}
+
+	switch strings.ToLower(valueOr(c.RebalanceStrategy, DefaultRebalanceStrategy)) {
+	case "roundrobin":
+		sc.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin()}
+	case "range":
+		sc.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRange()}
+	case "sticky":
+		sc.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky()}
+	default:
+		return fmt.Errorf("invalid consumer rebalance strategy %q", c.RebalanceStrategy)
+	}
+
+	if c.ReadCommitted {
+		sc.Consumer.IsolationLevel = sarama.ReadCommitted
+	}
+	return nil
+}
+
+// valueOr returns v, or def when v is empty.
+func valueOr(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
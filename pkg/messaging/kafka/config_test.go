@@ -0,0 +1,144 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfigFromEnv_MultipleBrokers(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "b1:9092, b2:9092,,b3:9092 ")
+
+	cfg, err := NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b1:9092", "b2:9092", "b3:9092"}, cfg.Brokers)
+}
+
+func TestNewConfigFromEnv_Tuning(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "localhost:9092")
+	t.Setenv("KAFKA_PRODUCER_ACKS", "leader")
+	t.Setenv("KAFKA_PRODUCER_MAX_RETRIES", "10")
+	t.Setenv("KAFKA_PRODUCER_RETRY_BACKOFF", "250ms")
+	t.Setenv("KAFKA_PRODUCER_COMPRESSION", "zstd")
+	t.Setenv("KAFKA_PRODUCER_PARTITIONER", "crc32")
+	t.Setenv("KAFKA_CONSUMER_INITIAL_OFFSET", "oldest")
+	t.Setenv("KAFKA_CONSUMER_SESSION_TIMEOUT", "30s")
+	t.Setenv("KAFKA_CONSUMER_REBALANCE_STRATEGY", "sticky")
+
+	cfg, err := NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, ProducerConfig{Acks: "leader", MaxRetries: 10, RetryBackoff: 250 * time.Millisecond, Compression: "zstd", Partitioner: "crc32"}, cfg.Producer)
+	assert.Equal(t, ConsumerConfig{InitialOffset: "oldest", SessionTimeout: 30 * time.Second, RebalanceStrategy: "sticky"}, cfg.Consumer)
+}
+
+func TestNewConfigFromEnv_InvalidTuning(t *testing.T) {
+	for key, value := range map[string]string{
+		"KAFKA_PRODUCER_ACKS":               "some",
+		"KAFKA_PRODUCER_MAX_RETRIES":        "many",
+		"KAFKA_PRODUCER_COMPRESSION":        "brotli",
+		"KAFKA_PRODUCER_PARTITIONER":        "sticky",
+		"KAFKA_CONSUMER_SESSION_TIMEOUT":    "soon",
+		"KAFKA_CONSUMER_REBALANCE_STRATEGY": "random",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv("KAFKA_BROKERS", "localhost:9092")
+			t.Setenv(key, value)
+			_, err := NewConfigFromEnv()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestProducerConfig_Apply(t *testing.T) {
+	sc := sarama.NewConfig()
+	require.NoError(t, ProducerConfig{}.apply(sc))
+	assert.Equal(t, sarama.WaitForAll, sc.Producer.RequiredAcks)
+	assert.Equal(t, DefaultMaxRetries, sc.Producer.Retry.Max)
+	assert.Equal(t, sarama.CompressionNone, sc.Producer.Compression)
+
+	sc = sarama.NewConfig()
+	require.NoError(t, ProducerConfig{Acks: "none", MaxRetries: -1, Compression: "lz4"}.apply(sc))
+	assert.Equal(t, sarama.NoResponse, sc.Producer.RequiredAcks)
+	assert.Equal(t, 0, sc.Producer.Retry.Max)
+	assert.Equal(t, sarama.CompressionLZ4, sc.Producer.Compression)
+}
+
+func TestConsumerConfig_Apply(t *testing.T) {
+	sc := sarama.NewConfig()
+	require.NoError(t, ConsumerConfig{}.apply(sc))
+	assert.Equal(t, sarama.OffsetNewest, sc.Consumer.Offsets.Initial)
+	assert.Equal(t, sarama.RoundRobinBalanceStrategyName, sc.Consumer.Group.Rebalance.GroupStrategies[0].Name())
+
+	sc = sarama.NewConfig()
+	require.NoError(t, ConsumerConfig{InitialOffset: "earliest", SessionTimeout: 45 * time.Second, RebalanceStrategy: "range"}.apply(sc))
+	assert.Equal(t, sarama.OffsetOldest, sc.Consumer.Offsets.Initial)
+	assert.Equal(t, 45*time.Second, sc.Consumer.Group.Session.Timeout)
+	assert.Equal(t, sarama.RangeBalanceStrategyName, sc.Consumer.Group.Rebalance.GroupStrategies[0].Name())
+}
+
+func TestProducerConfig_Idempotent(t *testing.T) {
+	sc := sarama.NewConfig()
+	require.NoError(t, ProducerConfig{Idempotent: true}.apply(sc))
+	assert.True(t, sc.Producer.Idempotent)
+	assert.Equal(t, 1, sc.Net.MaxOpenRequests)
+	assert.Empty(t, sc.Producer.Transaction.ID)
+	assert.NoError(t, sc.Validate())
+
+	assert.ErrorContains(t, ProducerConfig{Idempotent: true, Acks: "leader"}.apply(sarama.NewConfig()), "acks")
+	assert.ErrorContains(t, ProducerConfig{Idempotent: true, MaxRetries: -1}.apply(sarama.NewConfig()), "retries")
+
+	old := sarama.NewConfig()
+	old.Version = sarama.V0_10_2_0
+	assert.ErrorContains(t, ProducerConfig{Idempotent: true}.apply(old), "0.11")
+}
+
+func TestNewConfigFromEnv_Idempotent(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "localhost:9092")
+	t.Setenv("KAFKA_PRODUCER_IDEMPOTENT", "true")
+
+	cfg, err := NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.True(t, cfg.Producer.Idempotent)
+
+	t.Setenv("KAFKA_PRODUCER_ACKS", "none")
+	_, err = NewConfigFromEnv()
+	assert.Error(t, err)
+}
+
+func TestProducerConfig_CompressionLevel(t *testing.T) {
+	sc := sarama.NewConfig()
+	require.NoError(t, ProducerConfig{Compression: "zstd", CompressionLevel: 3}.apply(sc))
+	assert.Equal(t, sarama.CompressionZSTD, sc.Producer.Compression)
+	assert.Equal(t, 3, sc.Producer.CompressionLevel)
+
+	sc = sarama.NewConfig()
+	require.NoError(t, ProducerConfig{Compression: "gzip"}.apply(sc))
+	assert.Equal(t, sarama.CompressionLevelDefault, sc.Producer.CompressionLevel)
+	assert.NoError(t, sc.Validate())
+
+	for _, pc := range []ProducerConfig{
+		{Compression: "gzip", CompressionLevel: 10},
+		{Compression: "zstd", CompressionLevel: -1},
+		{Compression: "snappy", CompressionLevel: 1},
+		{CompressionLevel: 1},
+	} {
+		assert.Error(t, pc.apply(sarama.NewConfig()), "%+v", pc)
+	}
+}
+
+func TestNewConfigFromEnv_CompressionLevel(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "localhost:9092")
+	t.Setenv("KAFKA_PRODUCER_COMPRESSION", "gzip")
+	t.Setenv("KAFKA_PRODUCER_COMPRESSION_LEVEL", "6")
+
+	cfg, err := NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 6, cfg.Producer.CompressionLevel)
+
+	t.Setenv("KAFKA_PRODUCER_COMPRESSION_LEVEL", "max")
+	_, err = NewConfigFromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+// MessageHandlerFunc adapts a function to MessageHandler.
+type MessageHandlerFunc func(ctx context.Context, msg *sarama.ConsumerMessage) error
+
+// HandleMessage implements MessageHandler.
+func (f MessageHandlerFunc) HandleMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	return f(ctx, msg)
+}
+
+// ConsumerInterceptor wraps message handling, like Gin middleware wraps a
+// route. It must call next to continue the chain and may inspect or replace
+// the context, the message and the returned error.
+type ConsumerInterceptor func(ctx context.Context, msg *sarama.ConsumerMessage, next MessageHandlerFunc) error
+
+// SendFunc publishes a prepared message.
+type SendFunc func(ctx context.Context, msg *sarama.ProducerMessage) error
+
+// ProducerInterceptor wraps every send made through a Producer. It must call
+// next to publish the message and may modify headers or the payload first.
+type ProducerInterceptor func(ctx context.Context, msg *sarama.ProducerMessage, next SendFunc) error
+
+// Chain wraps handler with interceptors. The first interceptor is the
+// outermost, so it sees the message first and the result last.
+//
+// Example:
+//
+//	handler := kafka.Chain(orders, logMessages, validatePayload)
+func Chain(handler MessageHandler, interceptors ...ConsumerInterceptor) MessageHandler {
+	next := MessageHandlerFunc(handler.HandleMessage)
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		ic, inner := interceptors[i], next
+		next = func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+			return ic(ctx, msg, inner)
+		}
+	}
+	return next
+}
+
+// WithInterceptors wraps the consumer's handler with interceptors.
+func WithInterceptors(interceptors ...ConsumerInterceptor) ConsumerOption {
+	return func(o *consumerOptions) { o.interceptors = append(o.interceptors, interceptors...) }
+}
+
+// Use appends interceptors to the producer. They apply to every Send helper.
+//
+// Example:
+//
+//	producer.Use(func(ctx context.Context, msg *sarama.ProducerMessage, next kafka.SendFunc) error {
+//	    start := time.Now()
+//	    err := next(ctx, msg)
+//	    metrics.ObserveSend(msg.Topic, time.Since(start), err)
+//	    return err
+//	})
+func (p *Producer) Use(interceptors ...ProducerInterceptor) {
+	p.interceptors = append(p.interceptors, interceptors...)
+}
+
+// dispatch runs msg through the producer interceptors and sends it.
+func (p *Producer) dispatch(ctx context.Context, msg *sarama.ProducerMessage) error {
+	next := SendFunc(p.sendMessage)
+	for i := len(p.interceptors) - 1; i >= 0; i-- {
+		ic, inner := p.interceptors[i], next
+		next = func(ctx context.Context, m *sarama.ProducerMessage) error {
+			return ic(ctx, m, inner)
+		}
+	}
+	return next(ctx, msg)
+}
+
+// sendMessage sends m, giving up when ctx is done. Sarama cannot withdraw
+// a message once handed over, so an abandoned message may still be
+// delivered; the caller gets an error wrapping ctx.Err() either way, which
+// lets it tell a timeout apart from a broker error.
+func (p *Producer) sendMessage(ctx context.Context, m *sarama.ProducerMessage) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("kafka send to %s not attempted: %w", m.Topic, err)
+	}
+	if ctx.Done() == nil {
+		_, _, err := p.producer.SendMessage(m)
+		return err
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := p.producer.SendMessage(m)
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("kafka send to %s abandoned: %w", m.Topic, ctx.Err())
+	}
+}
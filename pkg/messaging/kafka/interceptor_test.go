@@ -0,0 +1,110 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain_OrderAndShortCircuit(t *testing.T) {
+	var calls []string
+	trace := func(name string) ConsumerInterceptor {
+		return func(ctx context.Context, msg *sarama.ConsumerMessage, next MessageHandlerFunc) error {
+			calls = append(calls, name+":before")
+			err := next(ctx, msg)
+			calls = append(calls, name+":after")
+			return err
+		}
+	}
+	handler := MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+
+	h := Chain(handler, trace("outer"), trace("inner"))
+	assert.NoError(t, h.HandleMessage(context.Background(), &sarama.ConsumerMessage{}))
+	assert.Equal(t, []string{"outer:before", "inner:before", "handler", "inner:after", "outer:after"}, calls)
+
+	reject := func(ctx context.Context, msg *sarama.ConsumerMessage, next MessageHandlerFunc) error {
+		return errors.New("invalid payload")
+	}
+	calls = nil
+	err := Chain(handler, reject).HandleMessage(context.Background(), &sarama.ConsumerMessage{})
+	assert.EqualError(t, err, "invalid payload")
+	assert.Empty(t, calls)
+}
+
+func TestWithInterceptors_WrapsConsumerHandler(t *testing.T) {
+	var o consumerOptions
+	WithInterceptors(func(ctx context.Context, msg *sarama.ConsumerMessage, next MessageHandlerFunc) error {
+		return next(ctx, msg)
+	})(&o)
+	assert.Len(t, o.interceptors, 1)
+}
+
+func TestProducerUse_InterceptsSends(t *testing.T) {
+	mockProducer := mocks.NewSyncProducer(t, nil)
+	defer func() { _ = mockProducer.Close() }()
+
+	var sent *sarama.ProducerMessage
+	mockProducer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(m *sarama.ProducerMessage) error {
+		sent = m
+		return nil
+	})
+
+	p := &Producer{producer: mockProducer}
+	var seenTopic string
+	p.Use(func(ctx context.Context, msg *sarama.ProducerMessage, next SendFunc) error {
+		seenTopic = msg.Topic
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte("x-intercepted"), Value: []byte("yes")})
+		return next(ctx, msg)
+	})
+
+	assert.NoError(t, p.SendJSON(context.Background(), "orders", "k", map[string]int{"n": 1}))
+	assert.Equal(t, "orders", seenTopic)
+	assert.Contains(t, sent.Headers, sarama.RecordHeader{Key: []byte("x-intercepted"), Value: []byte("yes")})
+
+	p.Use(func(ctx context.Context, msg *sarama.ProducerMessage, next SendFunc) error {
+		return errors.New("blocked")
+	})
+	assert.EqualError(t, p.SendJSON(context.Background(), "orders", "k", 1), "blocked")
+}
+
+// blockingProducer is a SyncProducer whose sends wait for release.
+type blockingProducer struct {
+	sarama.SyncProducer
+	release chan struct{}
+}
+
+func (b *blockingProducer) SendMessage(*sarama.ProducerMessage) (int32, int64, error) {
+	<-b.release
+	return 0, 0, nil
+}
+
+func TestSendJSON_HonorsContextDeadline(t *testing.T) {
+	bp := &blockingProducer{release: make(chan struct{})}
+	defer close(bp.release)
+	p := &Producer{producer: bp}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := p.SendJSON(ctx, "orders", "k", 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestSendJSON_CanceledBeforeSend(t *testing.T) {
+	mockProducer := mocks.NewSyncProducer(t, nil)
+	defer func() { _ = mockProducer.Close() }()
+	p := &Producer{producer: mockProducer}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, p.SendJSON(ctx, "orders", "k", 1), context.Canceled)
+}
@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+// JSONHandlerFunc handles a decoded JSON message.
+type JSONHandlerFunc[T any] func(ctx context.Context, key string, msg T, raw *sarama.ConsumerMessage) error
+
+// PoisonHandler is called for messages that cannot be decoded. Returning nil
+// marks the message as consumed; returning an error leaves it unmarked.
+type PoisonHandler func(ctx context.Context, raw *sarama.ConsumerMessage, err error) error
+
+// JSONOption configures a JSONHandler.
+type JSONOption func(*jsonOptions)
+
+// jsonOptions holds the settings applied by JSONOption.
+type jsonOptions struct {
+	poison                PoisonHandler
+	disallowUnknownFields bool
+}
+
+// WithPoisonHandler routes malformed payloads to fn instead of failing the
+// message, e.g. to publish them to a dead-letter topic.
+func WithPoisonHandler(fn PoisonHandler) JSONOption {
+	return func(o *jsonOptions) { o.poison = fn }
+}
+
+// WithStrictJSON rejects payloads containing fields unknown to T.
+func WithStrictJSON() JSONOption {
+	return func(o *jsonOptions) { o.disallowUnknownFields = true }
+}
+
+// jsonHandler adapts a JSONHandlerFunc to MessageHandler.
+type jsonHandler[T any] struct {
+	fn   JSONHandlerFunc[T]
+	opts jsonOptions
+}
+
+// JSONHandler returns a MessageHandler that decodes each message value into
+// T before calling fn. Messages that fail to decode, or that declare a
+// non-JSON content type, are passed to the poison handler if one is set and
+// otherwise returned as errors.
+//
+// Example:
+//
+//	handler := kafka.JSONHandler(func(ctx context.Context, key string, ev UserCreated, raw *sarama.ConsumerMessage) error {
+//	    return svc.OnUserCreated(ctx, ev)
+//	}, kafka.WithPoisonHandler(func(ctx context.Context, raw *sarama.ConsumerMessage, err error) error {
+//	    return dlq.SendBytes(ctx, "users.dlq", string(raw.Key), raw.Value, kafka.Headers(raw))
+//	}))
+func JSONHandler[T any](fn JSONHandlerFunc[T], opts ...JSONOption) MessageHandler {
+	h := &jsonHandler[T]{fn: fn}
+	for _, opt := range opts {
+		opt(&h.opts)
+	}
+	return h
+}
+
+// HandleMessage implements MessageHandler.
+func (h *jsonHandler[T]) HandleMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	value, err := h.decode(msg)
+	if err != nil {
+		if h.opts.poison != nil {
+			return h.opts.poison(ctx, msg, err)
+		}
+		return err
+	}
+	return h.fn(ctx, string(msg.Key), value, msg)
+}
+
+// decode unmarshals the message value into T.
+func (h *jsonHandler[T]) decode(msg *sarama.ConsumerMessage) (T, error) {
+	var value T
+	if ct := ContentType(msg); ct != "" && ct != ContentTypeJSON {
+		return value, fmt.Errorf("%w: %s", ErrUnexpectedContentType, ct)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(msg.Value))
+	if h.opts.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&value); err != nil {
+		return value, fmt.Errorf("failed to decode JSON message: %w", err)
+	}
+	return value, nil
+}
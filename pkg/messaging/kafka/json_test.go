@@ -0,0 +1,66 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderPlaced struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+}
+
+func TestJSONHandler_Decodes(t *testing.T) {
+	var gotKey string
+	var got orderPlaced
+	h := JSONHandler(func(ctx context.Context, key string, msg orderPlaced, raw *sarama.ConsumerMessage) error {
+		gotKey, got = key, msg
+		return nil
+	})
+
+	err := h.HandleMessage(context.Background(), &sarama.ConsumerMessage{
+		Key:   []byte("o1"),
+		Value: []byte(`{"id":"o1","total":42}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "o1", gotKey)
+	assert.Equal(t, orderPlaced{ID: "o1", Total: 42}, got)
+}
+
+func TestJSONHandler_MalformedWithoutPoisonHandler(t *testing.T) {
+	called := false
+	h := JSONHandler(func(ctx context.Context, key string, msg orderPlaced, raw *sarama.ConsumerMessage) error {
+		called = true
+		return nil
+	})
+
+	err := h.HandleMessage(context.Background(), &sarama.ConsumerMessage{Value: []byte(`{not json`)})
+	assert.Error(t, err)
+	assert.False(t, called)
+}
+
+func TestJSONHandler_PoisonHandler(t *testing.T) {
+	var poisoned []error
+	h := JSONHandler(func(ctx context.Context, key string, msg orderPlaced, raw *sarama.ConsumerMessage) error {
+		return nil
+	}, WithStrictJSON(), WithPoisonHandler(func(ctx context.Context, raw *sarama.ConsumerMessage, err error) error {
+		poisoned = append(poisoned, err)
+		return nil
+	}))
+
+	ctx := context.Background()
+	assert.NoError(t, h.HandleMessage(ctx, &sarama.ConsumerMessage{Value: []byte(`{"id":"o1","extra":true}`)}))
+	assert.NoError(t, h.HandleMessage(ctx, &sarama.ConsumerMessage{
+		Value:   []byte(`{"id":"o2"}`),
+		Headers: []*sarama.RecordHeader{{Key: []byte(HeaderContentType), Value: []byte(ContentTypeProto)}},
+	}))
+
+	require.Len(t, poisoned, 2)
+	assert.Contains(t, poisoned[0].Error(), "unknown field")
+	assert.True(t, errors.Is(poisoned[1], ErrUnexpectedContentType))
+}
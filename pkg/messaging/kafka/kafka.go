@@ -6,8 +6,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/IBM/sarama"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+)
+
+// Header keys used to propagate request correlation IDs between services.
+const (
+	HeaderRequestID   = correlation.RequestID
+	HeaderTraceID     = correlation.TraceID
+	HeaderSpanID      = correlation.SpanID
+	HeaderTraceParent = correlation.TraceParent
+	HeaderTraceState  = correlation.TraceState
+
+	// HeaderContentType identifies the payload encoding so consumers of
+	// mixed-format topics can pick a decoder.
+	HeaderContentType = "content-type"
+)
+
+// Content types set in HeaderContentType by the Send helpers.
+const (
+	ContentTypeJSON  = "application/json"
+	ContentTypeAvro  = "application/vnd.confluent.avro"
+	ContentTypeProto = "application/x-protobuf"
 )
 
 // Config defines Kafka connection and client options.
@@ -15,12 +37,26 @@ type Config struct {
 	Brokers  []string
 	ClientID string
 	Version  string
+
+	// SchemaRegistryURL enables SendAvro when set.
+	SchemaRegistryURL string
+
+	// Security configures SASL and TLS.
+	Security SecurityConfig
+
+	// Producer and Consumer tune the Sarama clients.
+	Producer ProducerConfig
+	Consumer ConsumerConfig
 }
 
 // Producer wraps a Sarama async producer for publishing messages.
 type Producer struct {
-	client   sarama.SyncProducer
-	producer sarama.SyncProducer
+	client       sarama.SyncProducer
+	producer     sarama.SyncProducer
+	registry     *SchemaRegistry
+	interceptors []ProducerInterceptor
+	keyFunc      KeyFunc
+	txMu         sync.Mutex
 }
 
 // Consumer wraps a Sarama consumer group for message processing.
@@ -28,17 +64,28 @@ type Consumer struct {
 	group   sarama.ConsumerGroup
 	topics  []string
 	handler MessageHandler
+	opts    consumerOptions
+	drain   *drainState
+
+	// Kept for standalone admin clients such as ResetOffsets.
+	brokers   []string
+	groupID   string
+	saramaCfg *sarama.Config
 }
 
 // MessageHandler defines the signature for handling consumed messages.
+// The context carries the correlation IDs found in the message headers; use
+// context.RequestMetadataFromContext or Headers(msg) to read them.
 type MessageHandler interface {
 	HandleMessage(ctx context.Context, msg *sarama.ConsumerMessage) error
 }
 
 // NewConfigFromEnv loads Kafka configuration from environment variables.
+// KAFKA_BROKERS is a comma-separated list of host:port pairs; producer and
+// consumer tuning is read as described on ProducerConfig and ConsumerConfig.
 func NewConfigFromEnv() (*Config, error) {
-	brokers := os.Getenv("KAFKA_BROKERS")
-	if brokers == "" {
+	brokers := parseBrokers(os.Getenv("KAFKA_BROKERS"))
+	if len(brokers) == 0 {
 		return nil, fmt.Errorf("KAFKA_BROKERS is required")
 	}
 	clientID := os.Getenv("KAFKA_CLIENT_ID")
@@ -49,50 +96,150 @@ func NewConfigFromEnv() (*Config, error) {
 	if version == "" {
 		version = "2.8.0"
 	}
+	security, err := securityFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	producer, consumer, err := tuningFromEnv()
+	if err != nil {
+		return nil, err
+	}
 	return &Config{
-		Brokers:  []string{brokers},
-		ClientID: clientID,
-		Version:  version,
+		Brokers:           brokers,
+		ClientID:          clientID,
+		Version:           version,
+		SchemaRegistryURL: os.Getenv("KAFKA_SCHEMA_REGISTRY_URL"),
+		Security:          security,
+		Producer:          producer,
+		Consumer:          consumer,
 	}, nil
 }
 
-// NewProducer initializes a new Kafka SyncProducer.
-func NewProducer(cfg *Config) (*Producer, error) {
+// newSaramaConfig returns a Sarama config with the version, client ID and
+// security settings shared by producers, consumers and admin clients.
+func newSaramaConfig(cfg *Config) (*sarama.Config, error) {
 	version, err := sarama.ParseKafkaVersion(cfg.Version)
 	if err != nil {
 		return nil, fmt.Errorf("invalid Kafka version: %w", err)
 	}
 
 	saramaCfg := sarama.NewConfig()
-	saramaCfg.Producer.Return.Successes = true
-	saramaCfg.Producer.RequiredAcks = sarama.WaitForAll
-	saramaCfg.Producer.Retry.Max = 5
-	saramaCfg.ClientID = cfg.ClientID
 	saramaCfg.Version = version
+	saramaCfg.ClientID = cfg.ClientID
+	if err := cfg.Security.apply(saramaCfg); err != nil {
+		return nil, fmt.Errorf("invalid Kafka security config: %w", err)
+	}
+	return saramaCfg, nil
+}
+
+// NewProducer initializes a new Kafka SyncProducer.
+func NewProducer(cfg *Config) (*Producer, error) {
+	saramaCfg, err := newSaramaConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	saramaCfg.Producer.Return.Successes = true
+	if err := cfg.Producer.apply(saramaCfg); err != nil {
+		return nil, fmt.Errorf("invalid Kafka producer config: %w", err)
+	}
 
 	prod, err := sarama.NewSyncProducer(cfg.Brokers, saramaCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
 	}
 
-	return &Producer{client: prod, producer: prod}, nil
+	p := &Producer{client: prod, producer: prod}
+	if cfg.SchemaRegistryURL != "" {
+		p.registry = NewSchemaRegistry(cfg.SchemaRegistryURL)
+	}
+	return p, nil
 }
 
-// SendJSON publishes a JSON-encoded message to a Kafka topic.
+// SendJSON publishes a JSON-encoded message to a Kafka topic. Correlation
+// IDs found in ctx are added as message headers. If ctx is canceled or its
+// deadline passes before the broker acknowledges the message, SendJSON
+// returns an error wrapping ctx.Err(). The message may still be delivered
+// afterwards, since a message handed to Sarama cannot be withdrawn.
 func (p *Producer) SendJSON(ctx context.Context, topic string, key string, value any) error {
+	return p.SendJSONWithHeaders(ctx, topic, key, value, nil)
+}
+
+// SendJSONWithHeaders publishes a JSON-encoded message with custom headers.
+// The request_id, trace_id, span_id, traceparent and tracestate set by the
+// logger middleware are read from ctx and injected automatically unless
+// headers already defines them.
+func (p *Producer) SendJSONWithHeaders(ctx context.Context, topic string, key string, value any, headers map[string]string) error {
 	data, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
+	return p.send(ctx, topic, p.keyFor(topic, key, value), data, ContentTypeJSON, headers)
+}
+
+// SendBytes publishes an already encoded payload with custom headers.
+// Correlation IDs are added as for SendJSONWithHeaders; set
+// HeaderContentType in headers to tag the encoding. A nil value is sent as
+// a tombstone.
+func (p *Producer) SendBytes(ctx context.Context, topic string, key string, value []byte, headers map[string]string) error {
+	return p.send(ctx, topic, key, value, "", headers)
+}
+
+// send publishes an encoded payload, tagging it with contentType unless
+// headers already set one. Nil data is sent as a tombstone. An empty key
+// is sent as no key, so the hash partitioners spread such messages instead
+// of pinning them to one partition.
+func (p *Producer) send(ctx context.Context, topic, key string, data []byte, contentType string, headers map[string]string) error {
+	if _, ok := headers[HeaderContentType]; !ok && data != nil && contentType != "" {
+		withType := make(map[string]string, len(headers)+1)
+		for k, v := range headers {
+			withType[k] = v
+		}
+		withType[HeaderContentType] = contentType
+		headers = withType
+	}
 
 	msg := &sarama.ProducerMessage{
-		Topic: topic,
-		Key:   sarama.StringEncoder(key),
-		Value: sarama.ByteEncoder(data),
+		Topic:   topic,
+		Headers: buildHeaders(ctx, headers),
+	}
+	if data != nil {
+		msg.Value = sarama.ByteEncoder(data)
+	}
+	if key != "" {
+		msg.Key = sarama.StringEncoder(key)
+	}
+	if partition, ok := partitionFromContext(ctx); ok {
+		msg.Partition = partition
 	}
+	return p.dispatch(ctx, msg)
+}
+
+// ContentType returns the content type header of msg, or "" if unset.
+func ContentType(msg *sarama.ConsumerMessage) string {
+	return Headers(msg)[HeaderContentType]
+}
+
+// buildHeaders merges explicit headers with correlation IDs from ctx.
+func buildHeaders(ctx context.Context, headers map[string]string) []sarama.RecordHeader {
+	merged := correlation.Merge(ctx, headers)
+
+	out := make([]sarama.RecordHeader, 0, len(merged))
+	for k, v := range merged {
+		out = append(out, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+	return out
+}
 
-	_, _, err = p.producer.SendMessage(msg)
-	return err
+// Headers returns the headers of a consumed message as a map. When a key is
+// repeated, the last value wins.
+func Headers(msg *sarama.ConsumerMessage) map[string]string {
+	out := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		if h != nil {
+			out[string(h.Key)] = string(h.Value)
+		}
+	}
+	return out
 }
 
 // Close shuts down the producer.
@@ -103,37 +250,58 @@ func (p *Producer) Close() error {
 	return nil
 }
 
-// NewConsumer creates a new Kafka consumer group.
-func NewConsumer(cfg *Config, groupID string, topics []string, handler MessageHandler) (*Consumer, error) {
-	version, err := sarama.ParseKafkaVersion(cfg.Version)
+// NewConsumer creates a new Kafka consumer group. By default messages of a
+// claim are handled one at a time; see WithConcurrency.
+func NewConsumer(cfg *Config, groupID string, topics []string, handler MessageHandler, opts ...ConsumerOption) (*Consumer, error) {
+	saramaCfg, err := newSaramaConfig(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("invalid Kafka version: %w", err)
+		return nil, err
+	}
+	if err := cfg.Consumer.apply(saramaCfg); err != nil {
+		return nil, fmt.Errorf("invalid Kafka consumer config: %w", err)
 	}
 
-	saramaCfg := sarama.NewConfig()
-	saramaCfg.Consumer.Offsets.Initial = sarama.OffsetNewest
-	saramaCfg.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
-	saramaCfg.Version = version
-	saramaCfg.ClientID = cfg.ClientID
+	c := &Consumer{
+		topics:    topics,
+		handler:   handler,
+		drain:     newDrainState(),
+		brokers:   cfg.Brokers,
+		groupID:   groupID,
+		saramaCfg: saramaCfg,
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	c.opts.applyCommit(saramaCfg)
 
-	group, err := sarama.NewConsumerGroup(cfg.Brokers, groupID, saramaCfg)
+	c.group, err = sarama.NewConsumerGroup(cfg.Brokers, groupID, saramaCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Kafka consumer group: %w", err)
 	}
-
-	return &Consumer{
-		group:   group,
-		topics:  topics,
-		handler: handler,
-	}, nil
+	if len(c.opts.interceptors) > 0 {
+		c.handler = Chain(c.handler, c.opts.interceptors...)
+	}
+	return c, nil
 }
 
-// Run starts consuming messages from configured topics until context is canceled.
+// Run starts consuming messages from configured topics until context is
+// canceled or Shutdown is called, in which case it returns nil.
 func (c *Consumer) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.drain.setCancel(cancel)
+
+	handler := &consumerGroupHandler{handler: c.handler, opts: c.opts, drain: c.drain}
 	for {
-		if err := c.group.Consume(ctx, c.topics, &consumerGroupHandler{handler: c.handler}); err != nil {
+		if err := c.group.Consume(ctx, c.topics, handler); err != nil {
+			if c.isShutdownErr(err) {
+				return nil
+			}
 			return fmt.Errorf("consume error: %w", err)
 		}
+		if c.drain.draining() {
+			return nil
+		}
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
@@ -148,16 +316,64 @@ func (c *Consumer) Close() error {
 // consumerGroupHandler bridges Sarama's interface to our MessageHandler.
 type consumerGroupHandler struct {
 	handler MessageHandler
+	opts    consumerOptions
+	drain   *drainState
 }
 
 func (h *consumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
 func (h *consumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
 func (h *consumerGroupHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for msg := range claim.Messages() {
-		ctx := context.Background()
-		if err := h.handler.HandleMessage(ctx, msg); err == nil {
+	if !h.drain.enter() {
+		return nil
+	}
+	defer h.drain.exit()
+
+	if h.opts.concurrency > 1 {
+		h.consumeConcurrently(sess, claim)
+	} else {
+		h.consumeSequentially(sess, claim)
+	}
+	if h.drain.draining() {
+		// Commit what the drained handlers marked before the group closes.
+		sess.Commit()
+	}
+	return nil
+}
+
+// consumeSequentially handles the messages of a claim one at a time until
+// the claim closes or draining starts.
+func (h *consumerGroupHandler) consumeSequentially(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) {
+	for {
+		var msg *sarama.ConsumerMessage
+		select {
+		case <-h.drain.done():
+			return
+		case m, ok := <-claim.Messages():
+			if !ok {
+				return
+			}
+			msg = m
+		}
+		if h.drain.draining() {
+			return
+		}
+		if h.handle(sess, msg) && h.opts.commit != CommitManual {
 			sess.MarkMessage(msg, "")
 		}
 	}
-	return nil
+}
+
+// handle runs the handler for msg and reports whether it succeeded.
+func (h *consumerGroupHandler) handle(sess sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) bool {
+	ctx := withSession(correlation.Context(sessionContext(sess), Headers(msg)), sess, msg)
+	return h.handler.HandleMessage(ctx, msg) == nil
+}
+
+// sessionContext returns the session's context, falling back to Background
+// for sessions that do not provide one.
+func sessionContext(sess sarama.ConsumerGroupSession) context.Context {
+	if ctx := sess.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
 }
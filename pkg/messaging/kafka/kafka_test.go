@@ -9,6 +9,7 @@ import (
 
 	"github.com/IBM/sarama"
 	"github.com/IBM/sarama/mocks"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -56,6 +57,60 @@ func TestSendJSON_SendFailure(t *testing.T) {
 	assert.Contains(t, err.Error(), "send failed")
 }
 
+func TestSendJSONWithHeaders_InjectsRequestMetadata(t *testing.T) {
+	mockProducer := mocks.NewSyncProducer(t, nil)
+	defer func() { _ = mockProducer.Close() }()
+
+	var sent *sarama.ProducerMessage
+	mockProducer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(m *sarama.ProducerMessage) error {
+		sent = m
+		return nil
+	})
+
+	p := &Producer{producer: mockProducer}
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{
+		RequestID:   "req-1",
+		TraceID:     "trace-1",
+		TraceParent: "00-trace-span-01",
+	})
+
+	err := p.SendJSONWithHeaders(ctx, "topic", "key", map[string]string{"a": "b"}, map[string]string{
+		"tenant":        "acme",
+		HeaderRequestID: "override",
+	})
+	assert.NoError(t, err)
+
+	headers := map[string]string{}
+	for _, h := range sent.Headers {
+		headers[string(h.Key)] = string(h.Value)
+	}
+	assert.Equal(t, "acme", headers["tenant"])
+	assert.Equal(t, "override", headers[HeaderRequestID])
+	assert.Equal(t, "trace-1", headers[HeaderTraceID])
+	assert.Equal(t, "00-trace-span-01", headers[HeaderTraceParent])
+	assert.NotContains(t, headers, HeaderSpanID)
+}
+
+func TestSendJSON_SetsContentType(t *testing.T) {
+	mockProducer := mocks.NewSyncProducer(t, nil)
+	defer func() { _ = mockProducer.Close() }()
+
+	var sent *sarama.ProducerMessage
+	mockProducer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(m *sarama.ProducerMessage) error {
+		sent = m
+		return nil
+	})
+
+	p := &Producer{producer: mockProducer}
+	assert.NoError(t, p.SendJSON(context.Background(), "topic", "key", map[string]string{"a": "b"}))
+
+	msg := &sarama.ConsumerMessage{}
+	for i := range sent.Headers {
+		msg.Headers = append(msg.Headers, &sent.Headers[i])
+	}
+	assert.Equal(t, ContentTypeJSON, ContentType(msg))
+}
+
 func TestProducer_Close(t *testing.T) {
 	mockProducer := mocks.NewSyncProducer(t, nil)
 	p := &Producer{producer: mockProducer}
@@ -148,6 +203,37 @@ func TestConsumerHandler_HandleMessage_Error(t *testing.T) {
 	assert.Len(t, h.handled, 1)
 }
 
+// ctxHandler records the request metadata seen by the handler.
+type ctxHandler struct {
+	md reqctx.RequestMetadata
+}
+
+func (h *ctxHandler) HandleMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	h.md = reqctx.RequestMetadataFromContext(ctx)
+	return nil
+}
+
+func TestConsumerHandler_PropagatesHeadersToContext(t *testing.T) {
+	h := &ctxHandler{}
+	msg := &sarama.ConsumerMessage{
+		Topic: "topic",
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte(HeaderRequestID), Value: []byte("req-9")},
+			{Key: []byte(HeaderTraceParent), Value: []byte("00-abc-def-01")},
+		},
+	}
+
+	claim := &fakeClaim{topic: "topic", messages: make(chan *sarama.ConsumerMessage, 1)}
+	claim.messages <- msg
+	close(claim.messages)
+
+	handler := &consumerGroupHandler{handler: h}
+	assert.NoError(t, handler.ConsumeClaim(&fakeSession{ctx: context.Background()}, claim))
+	assert.Equal(t, "req-9", h.md.RequestID)
+	assert.Equal(t, "00-abc-def-01", h.md.TraceParent)
+	assert.Equal(t, "req-9", Headers(msg)[HeaderRequestID])
+}
+
 /****************
  * CONFIG TESTS *
  ****************/
@@ -204,3 +290,27 @@ func TestConsumer_Run_ContextCancel_NoPanic(t *testing.T) {
 	// Integration tests would verify Run with a real cluster/mocks.
 	_ = time.Second
 }
+
+func TestSendBytes(t *testing.T) {
+	mockProducer := mocks.NewSyncProducer(t, nil)
+	defer func() { _ = mockProducer.Close() }()
+
+	var sent *sarama.ProducerMessage
+	mockProducer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(m *sarama.ProducerMessage) error {
+		sent = m
+		return nil
+	})
+
+	p := &Producer{producer: mockProducer}
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1"})
+	assert.NoError(t, p.SendBytes(ctx, "topic", "key", []byte("raw"), map[string]string{HeaderContentType: "text/plain"}))
+
+	value, _ := sent.Value.Encode()
+	assert.Equal(t, "raw", string(value))
+	headers := map[string]string{}
+	for _, h := range sent.Headers {
+		headers[string(h.Key)] = string(h.Value)
+	}
+	assert.Equal(t, "text/plain", headers[HeaderContentType])
+	assert.Equal(t, "req-1", headers[HeaderRequestID])
+}
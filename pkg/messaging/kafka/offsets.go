@@ -0,0 +1,140 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// ResetTarget selects where ResetOffsets moves a group's offsets.
+type ResetTarget struct {
+	// time is a Sarama offset query time (OffsetOldest, OffsetNewest or a
+	// timestamp in milliseconds), used when explicit is false.
+	time     int64
+	offset   int64
+	explicit bool
+}
+
+// Reset targets.
+var (
+	// ResetEarliest moves to the oldest retained message.
+	ResetEarliest = ResetTarget{time: sarama.OffsetOldest}
+
+	// ResetLatest moves past the newest message, skipping the backlog.
+	ResetLatest = ResetTarget{time: sarama.OffsetNewest}
+)
+
+// ResetToTime moves to the first message produced at or after t, or past
+// the newest message if none was.
+func ResetToTime(t time.Time) ResetTarget {
+	return ResetTarget{time: t.UnixMilli()}
+}
+
+// ResetToOffset moves every selected partition to offset.
+func ResetToOffset(offset int64) ResetTarget {
+	return ResetTarget{offset: offset, explicit: true}
+}
+
+// ResetOffsets commits new offsets for the consumer's group on topic, for
+// the given partitions or all partitions when none are given. It returns the
+// committed offset per partition.
+//
+// The group should have no active members while resetting; otherwise the
+// running consumers overwrite the new offsets with their own commits. Stop
+// the service (or call this before Run) when replaying.
+//
+// Example:
+//
+//	// Replay everything produced since the incident started.
+//	offsets, err := consumer.ResetOffsets(ctx, "orders", kafka.ResetToTime(incidentStart))
+func (c *Consumer) ResetOffsets(ctx context.Context, topic string, target ResetTarget, partitions ...int32) (map[int32]int64, error) {
+	client, err := c.newClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if len(partitions) == 0 {
+		if partitions, err = client.Partitions(topic); err != nil {
+			return nil, fmt.Errorf("failed to list partitions of %s: %w", topic, err)
+		}
+	}
+
+	om, err := sarama.NewOffsetManagerFromClient(c.groupID, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create offset manager: %w", err)
+	}
+	defer func() { _ = om.Close() }()
+
+	out := make(map[int32]int64, len(partitions))
+	poms := make([]sarama.PartitionOffsetManager, 0, len(partitions))
+	for _, p := range partitions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		offset := target.offset
+		if !target.explicit {
+			if offset, err = client.GetOffset(topic, p, target.time); err != nil {
+				return nil, fmt.Errorf("failed to resolve offset for %s/%d: %w", topic, p, err)
+			}
+			// A timestamp after the newest message resolves to -1.
+			if offset == -1 {
+				if offset, err = client.GetOffset(topic, p, sarama.OffsetNewest); err != nil {
+					return nil, fmt.Errorf("failed to resolve offset for %s/%d: %w", topic, p, err)
+				}
+			}
+		}
+
+		pom, err := om.ManagePartition(topic, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to manage %s/%d: %w", topic, p, err)
+		}
+		poms = append(poms, pom)
+		// ResetOffset only moves backwards and MarkOffset only forwards.
+		if next, _ := pom.NextOffset(); offset < next {
+			pom.ResetOffset(offset, "")
+		} else {
+			pom.MarkOffset(offset, "")
+		}
+		out[p] = offset
+	}
+
+	om.Commit()
+	var errs []error
+	for _, pom := range poms {
+		errs = append(errs, pendingErrors(pom)...)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to commit offsets for %s: %w", topic, errors.Join(errs...))
+	}
+	return out, nil
+}
+
+// pendingErrors returns the errors pom has already reported.
+func pendingErrors(pom sarama.PartitionOffsetManager) []error {
+	var errs []error
+	for {
+		select {
+		case err, ok := <-pom.Errors():
+			if !ok {
+				return errs
+			}
+			errs = append(errs, err)
+		default:
+			return errs
+		}
+	}
+}
+
+// newClient opens a standalone client with a copy of the consumer's
+// configuration. Consumer.Return.Errors is set, since the offset manager
+// only reports commit failures on its error channels then.
+func (c *Consumer) newClient() (sarama.Client, error) {
+	cfg := *c.saramaCfg
+	cfg.Consumer.Return.Errors = true
+	return sarama.NewClient(c.brokers, &cfg)
+}
@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOffsetTestConsumer returns a Consumer wired to a mock broker that hosts
+// topic "orders" with two partitions and acts as the group coordinator.
+func newOffsetTestConsumer(t *testing.T) (*Consumer, *sarama.MockBroker) {
+	t.Helper()
+	return newOffsetTestConsumerWith(t, nil)
+}
+
+// newOffsetTestConsumerWith is newOffsetTestConsumer with handlers replaced
+// or added from overrides.
+func newOffsetTestConsumerWith(t *testing.T, overrides map[string]sarama.MockResponse) (*Consumer, *sarama.MockBroker) {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	handlers := map[string]sarama.MockResponse{
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()).
+			SetLeader("orders", 1, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("orders", 0, sarama.OffsetOldest, 10).
+			SetOffset("orders", 0, sarama.OffsetNewest, 100).
+			SetOffset("orders", 1, sarama.OffsetOldest, 20).
+			SetOffset("orders", 1, sarama.OffsetNewest, 200),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "billing", broker),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("billing", "orders", 0, 50, "", sarama.ErrNoError).
+			SetOffset("billing", "orders", 1, 50, "", sarama.ErrNoError),
+		"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t),
+	}
+	for k, v := range overrides {
+		handlers[k] = v
+	}
+	broker.SetHandlerByMap(handlers)
+
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_8_0_0
+	cfg.Metadata.Retry.Max = 0
+	return &Consumer{brokers: []string{broker.Addr()}, groupID: "billing", saramaCfg: cfg}, broker
+}
+
+// committedOffsets extracts the offsets sent in OffsetCommit requests.
+func committedOffsets(broker *sarama.MockBroker) map[int32]int64 {
+	out := map[int32]int64{}
+	for _, rr := range broker.History() {
+		req, ok := rr.Request.(*sarama.OffsetCommitRequest)
+		if !ok {
+			continue
+		}
+		for _, p := range []int32{0, 1} {
+			if offset, _, err := req.Offset("orders", p); err == nil {
+				out[p] = offset
+			}
+		}
+	}
+	return out
+}
+
+func TestResetOffsets_EarliestAllPartitions(t *testing.T) {
+	c, broker := newOffsetTestConsumer(t)
+
+	offsets, err := c.ResetOffsets(context.Background(), "orders", ResetEarliest)
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]int64{0: 10, 1: 20}, offsets)
+	assert.Equal(t, map[int32]int64{0: 10, 1: 20}, committedOffsets(broker))
+}
+
+func TestResetOffsets_LatestSinglePartition(t *testing.T) {
+	c, broker := newOffsetTestConsumer(t)
+
+	offsets, err := c.ResetOffsets(context.Background(), "orders", ResetLatest, 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]int64{1: 200}, offsets)
+	assert.Equal(t, map[int32]int64{1: 200}, committedOffsets(broker))
+}
+
+func TestResetOffsets_ExplicitOffset(t *testing.T) {
+	c, _ := newOffsetTestConsumer(t)
+
+	offsets, err := c.ResetOffsets(context.Background(), "orders", ResetToOffset(42), 0)
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]int64{0: 42}, offsets)
+}
+
+func TestResetOffsets_CanceledContext(t *testing.T) {
+	c, _ := newOffsetTestConsumer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.ResetOffsets(ctx, "orders", ResetEarliest)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestResetOffsets_TimeAfterNewestMessage(t *testing.T) {
+	ts := time.Now()
+	c, _ := newOffsetTestConsumerWith(t, map[string]sarama.MockResponse{
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("orders", 0, ts.UnixMilli(), -1).
+			SetOffset("orders", 0, sarama.OffsetNewest, 100),
+	})
+
+	offsets, err := c.ResetOffsets(context.Background(), "orders", ResetToTime(ts), 0)
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]int64{0: 100}, offsets)
+}
+
+func TestResetOffsets_CommitError(t *testing.T) {
+	c, _ := newOffsetTestConsumerWith(t, map[string]sarama.MockResponse{
+		"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t).
+			SetError("billing", "orders", 0, sarama.ErrOffsetMetadataTooLarge),
+	})
+
+	_, err := c.ResetOffsets(context.Background(), "orders", ResetToOffset(42), 0)
+	assert.ErrorIs(t, err, sarama.ErrOffsetMetadataTooLarge)
+	assert.False(t, c.saramaCfg.Consumer.Return.Errors, "expected the consumer config to be left untouched")
+}
+
+func TestResetToTime(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	target := ResetToTime(ts)
+	assert.Equal(t, ts.UnixMilli(), target.time)
+	assert.False(t, target.explicit)
+}
@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/ranorsolutions/http-common-go/pkg/db/postgres"
+)
+
+// Defaults for OutboxRelay.
+const (
+	DefaultOutboxInterval  = time.Second
+	DefaultOutboxBatchSize = 100
+)
+
+// OutboxSource is the outbox an OutboxRelay drains, satisfied by
+// *postgres.Outbox.
+type OutboxSource interface {
+	Relay(ctx context.Context, limit int, publish func(context.Context, postgres.OutboxEvent) error) (int, error)
+}
+
+// OutboxRelayOption configures an OutboxRelay.
+type OutboxRelayOption func(*OutboxRelay)
+
+// WithOutboxInterval sets how long the relay waits after draining the
+// outbox before polling again.
+func WithOutboxInterval(d time.Duration) OutboxRelayOption {
+	return func(r *OutboxRelay) { r.interval = d }
+}
+
+// WithOutboxBatchSize sets the number of events published per transaction.
+func WithOutboxBatchSize(n int) OutboxRelayOption {
+	return func(r *OutboxRelay) { r.batchSize = n }
+}
+
+// WithOutboxErrorHandler is called with every relay error. The relay keeps
+// running and retries on the next poll.
+func WithOutboxErrorHandler(fn func(error)) OutboxRelayOption {
+	return func(r *OutboxRelay) { r.onError = fn }
+}
+
+// OutboxRelay publishes events written with postgres.Outbox to Kafka with
+// at-least-once delivery. Events keep their topic, key and headers; events
+// written with WriteTombstone are sent as tombstones (null values).
+type OutboxRelay struct {
+	source    OutboxSource
+	producer  *Producer
+	interval  time.Duration
+	batchSize int
+	onError   func(error)
+}
+
+// NewOutboxRelay returns a relay from source to producer.
+//
+// Example:
+//
+//	relay := kafka.NewOutboxRelay(postgres.NewOutbox(db, ""), producer,
+//	    kafka.WithOutboxErrorHandler(func(err error) { log.Warnf("outbox: %v", err) }))
+//	go func() { _ = relay.Run(ctx) }()
+func NewOutboxRelay(source OutboxSource, producer *Producer, opts ...OutboxRelayOption) *OutboxRelay {
+	r := &OutboxRelay{
+		source:    source,
+		producer:  producer,
+		interval:  DefaultOutboxInterval,
+		batchSize: DefaultOutboxBatchSize,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run relays events until ctx is canceled. Full batches are followed
+// immediately by the next one, so a backlog drains without waiting for the
+// poll interval.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	for {
+		n, err := r.source.Relay(ctx, r.batchSize, r.publish)
+		if err != nil && r.onError != nil && ctx.Err() == nil {
+			r.onError(err)
+		}
+		if err == nil && n == r.batchSize {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.interval):
+		}
+	}
+}
+
+// publish sends one outbox event.
+func (r *OutboxRelay) publish(ctx context.Context, e postgres.OutboxEvent) error {
+	return r.producer.send(ctx, e.Topic, e.Key, e.Payload, ContentTypeJSON, e.Headers)
+}
@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/ranorsolutions/http-common-go/pkg/db/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOutbox hands out its events in batches and records relay errors.
+type fakeOutbox struct {
+	mu     sync.Mutex
+	events []postgres.OutboxEvent
+	calls  int
+	fail   error
+}
+
+func (f *fakeOutbox) Relay(ctx context.Context, limit int, publish func(context.Context, postgres.OutboxEvent) error) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.fail != nil {
+		return 0, f.fail
+	}
+	n := 0
+	for n < limit && len(f.events) > 0 {
+		if err := publish(ctx, f.events[0]); err != nil {
+			return n, err
+		}
+		f.events = f.events[1:]
+		n++
+	}
+	return n, nil
+}
+
+func TestOutboxRelay_PublishesEventsAndTombstones(t *testing.T) {
+	var sent []*sarama.ProducerMessage
+	p := &Producer{}
+	p.Use(func(ctx context.Context, msg *sarama.ProducerMessage, next SendFunc) error {
+		sent = append(sent, msg)
+		return nil
+	})
+
+	src := &fakeOutbox{events: []postgres.OutboxEvent{
+		{ID: 1, Topic: "orders", Key: "o-1", Payload: []byte(`{"id":"o-1"}`), Headers: map[string]string{"event_type": "created"}},
+		{ID: 2, Topic: "orders", Key: "o-2", Payload: []byte(`{"id":"o-2"}`)},
+		{ID: 3, Topic: "orders", Key: "o-1"},
+	}}
+	relay := NewOutboxRelay(src, p, WithOutboxBatchSize(2), WithOutboxInterval(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- relay.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		src.mu.Lock()
+		defer src.mu.Unlock()
+		return len(src.events) == 0
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	require.Len(t, sent, 3)
+	assert.Equal(t, sarama.ByteEncoder(`{"id":"o-1"}`), sent[0].Value)
+	assert.Contains(t, sent[0].Headers, sarama.RecordHeader{Key: []byte("event_type"), Value: []byte("created")})
+	assert.Contains(t, sent[0].Headers, sarama.RecordHeader{Key: []byte(HeaderContentType), Value: []byte(ContentTypeJSON)})
+	assert.Nil(t, sent[2].Value)
+	assert.Equal(t, sarama.StringEncoder("o-1"), sent[2].Key)
+}
+
+func TestOutboxRelay_ReportsErrors(t *testing.T) {
+	src := &fakeOutbox{fail: errors.New("db down")}
+	errs := make(chan error, 10)
+	relay := NewOutboxRelay(src, &Producer{},
+		WithOutboxInterval(time.Millisecond),
+		WithOutboxErrorHandler(func(err error) { errs <- err }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = relay.Run(ctx) }()
+
+	select {
+	case err := <-errs:
+		assert.EqualError(t, err, "db down")
+	case <-time.After(time.Second):
+		t.Fatal("error handler not called")
+	}
+}
@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/IBM/sarama"
+
+	"github.com/ranorsolutions/http-common-go/pkg/hashring"
+)
+
+// KeyFunc derives a message key from the value being sent, e.g. the ID of
+// the entity the event is about, so all events of one entity land on the
+// same partition and stay ordered.
+type KeyFunc func(topic string, value any) string
+
+// SetKeyFunc sets the function used by the Send helpers to derive a key
+// when they are called with an empty key. An explicit key always wins.
+//
+// Example:
+//
+//	producer.SetKeyFunc(func(topic string, value any) string {
+//	    if e, ok := value.(interface{ EntityID() string }); ok {
+//	        return e.EntityID()
+//	    }
+//	    return ""
+//	})
+func (p *Producer) SetKeyFunc(fn KeyFunc) {
+	p.keyFunc = fn
+}
+
+// keyFor returns key, or the derived key when key is empty.
+func (p *Producer) keyFor(topic, key string, value any) string {
+	if key == "" && p.keyFunc != nil {
+		return p.keyFunc(topic, value)
+	}
+	return key
+}
+
+type partitionKey struct{}
+
+// WithPartition returns a context that makes the Send helpers target
+// partition. It is only honored by the "manual" partitioner; other
+// partitioners choose the partition themselves.
+//
+// Example:
+//
+//	err := producer.SendJSON(kafka.WithPartition(ctx, 3), "orders", id, order)
+func WithPartition(ctx context.Context, partition int32) context.Context {
+	return context.WithValue(ctx, partitionKey{}, partition)
+}
+
+// partitionFromContext returns the partition set with WithPartition.
+func partitionFromContext(ctx context.Context) (int32, bool) {
+	p, ok := ctx.Value(partitionKey{}).(int32)
+	return p, ok
+}
+
+// ringPartitioner places keys on a consistent-hash ring of the topic's
+// partitions, so growing a topic from n to n+1 partitions only moves about
+// 1/(n+1) of the keys instead of nearly all of them.
+type ringPartitioner struct {
+	random sarama.Partitioner
+
+	mu    sync.Mutex
+	count int32
+	ring  *hashring.Ring
+}
+
+// NewRingPartitioner returns a Sarama partitioner backed by a hashring.Ring
+// with one member per partition. Keyless messages are spread randomly. It
+// is selected with ProducerConfig.Partitioner "ring".
+func NewRingPartitioner(topic string) sarama.Partitioner {
+	return &ringPartitioner{random: sarama.NewRandomPartitioner(topic)}
+}
+
+// Partition implements sarama.Partitioner.
+func (p *ringPartitioner) Partition(msg *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	if msg.Key == nil {
+		return p.random.Partition(msg, numPartitions)
+	}
+	key, err := msg.Key.Encode()
+	if err != nil {
+		return -1, err
+	}
+	member, _ := p.ringFor(numPartitions).Get(string(key))
+	partition, err := strconv.ParseInt(member, 10, 32)
+	if err != nil {
+		return -1, err
+	}
+	return int32(partition), nil
+}
+
+// RequiresConsistency implements sarama.Partitioner.
+func (p *ringPartitioner) RequiresConsistency() bool {
+	return true
+}
+
+// ringFor returns the ring for numPartitions, adding or removing partition
+// members when the topic's partition count changed.
+func (p *ringPartitioner) ringFor(numPartitions int32) *hashring.Ring {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ring == nil {
+		p.ring = hashring.New(nil)
+	}
+	for ; p.count < numPartitions; p.count++ {
+		p.ring.Add(strconv.Itoa(int(p.count)), 1)
+	}
+	for ; p.count > numPartitions; p.count-- {
+		p.ring.Remove(strconv.Itoa(int(p.count - 1)))
+	}
+	return p.ring
+}
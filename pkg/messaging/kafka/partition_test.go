@@ -0,0 +1,116 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type order struct {
+	ID string `json:"id"`
+}
+
+// captureSends returns a producer backed by a mock that records n messages.
+func captureSends(t *testing.T, n int) (*Producer, *[]*sarama.ProducerMessage) {
+	t.Helper()
+	mockProducer := mocks.NewSyncProducer(t, nil)
+	t.Cleanup(func() { _ = mockProducer.Close() })
+
+	var sent []*sarama.ProducerMessage
+	for i := 0; i < n; i++ {
+		mockProducer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(m *sarama.ProducerMessage) error {
+			sent = append(sent, m)
+			return nil
+		})
+	}
+	return &Producer{producer: mockProducer}, &sent
+}
+
+func TestProducer_KeyFunc(t *testing.T) {
+	p, sent := captureSends(t, 3)
+	p.SetKeyFunc(func(topic string, value any) string {
+		if o, ok := value.(order); ok {
+			return topic + ":" + o.ID
+		}
+		return ""
+	})
+
+	ctx := context.Background()
+	require.NoError(t, p.SendJSON(ctx, "orders", "", order{ID: "42"}))
+	require.NoError(t, p.SendJSON(ctx, "orders", "explicit", order{ID: "42"}))
+	require.NoError(t, p.SendJSON(ctx, "orders", "", "no key"))
+
+	assert.Equal(t, sarama.StringEncoder("orders:42"), (*sent)[0].Key)
+	assert.Equal(t, sarama.StringEncoder("explicit"), (*sent)[1].Key)
+	assert.Nil(t, (*sent)[2].Key)
+}
+
+func TestWithPartition(t *testing.T) {
+	var partition int32
+	p := &Producer{}
+	p.Use(func(ctx context.Context, msg *sarama.ProducerMessage, next SendFunc) error {
+		partition = msg.Partition
+		return nil
+	})
+
+	require.NoError(t, p.SendJSON(WithPartition(context.Background(), 3), "orders", "k", 1))
+	assert.Equal(t, int32(3), partition)
+
+	_, ok := partitionFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestParsePartitioner(t *testing.T) {
+	for _, name := range []string{"hash", "CRC32", "consistent", "ring", "roundrobin", "random", "manual"} {
+		_, err := parsePartitioner(name)
+		assert.NoError(t, err, name)
+	}
+	_, err := parsePartitioner("sticky")
+	assert.Error(t, err)
+
+	sc := sarama.NewConfig()
+	require.NoError(t, ProducerConfig{Partitioner: "manual"}.apply(sc))
+	msg := &sarama.ProducerMessage{Partition: 2}
+	partition, err := sc.Producer.Partitioner("orders").Partition(msg, 4)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), partition)
+}
+
+func TestRingPartitioner(t *testing.T) {
+	p := NewRingPartitioner("orders")
+	assert.True(t, p.RequiresConsistency())
+
+	keys := make([]string, 1000)
+	before := make(map[string]int32, len(keys))
+	for i := range keys {
+		keys[i] = "order-" + strconv.Itoa(i)
+		partition, err := p.Partition(&sarama.ProducerMessage{Key: sarama.StringEncoder(keys[i])}, 8)
+		require.NoError(t, err)
+		require.True(t, partition >= 0 && partition < 8)
+		before[keys[i]] = partition
+	}
+
+	again, err := p.Partition(&sarama.ProducerMessage{Key: sarama.StringEncoder(keys[0])}, 8)
+	require.NoError(t, err)
+	assert.Equal(t, before[keys[0]], again)
+
+	moved := 0
+	for _, key := range keys {
+		partition, err := p.Partition(&sarama.ProducerMessage{Key: sarama.StringEncoder(key)}, 9)
+		require.NoError(t, err)
+		if partition != before[key] {
+			assert.Equal(t, int32(8), partition, "keys only move to the new partition")
+			moved++
+		}
+	}
+	assert.Less(t, moved, 250)
+
+	partition, err := p.Partition(&sarama.ProducerMessage{}, 9)
+	require.NoError(t, err)
+	assert.True(t, partition >= 0 && partition < 9)
+}
@@ -0,0 +1,30 @@
+package kafka
+
+// Pause stops fetching from the given partitions, keyed by topic, without
+// leaving the group. Messages already fetched are still delivered, and the
+// partitions stay assigned, so no rebalance is triggered. Use it to apply
+// backpressure while a downstream dependency is degraded.
+//
+// Example:
+//
+//	if breaker.Open() {
+//	    consumer.Pause(map[string][]int32{"orders": {0, 1}})
+//	}
+func (c *Consumer) Pause(partitions map[string][]int32) {
+	c.group.Pause(partitions)
+}
+
+// Resume restarts fetching from partitions paused with Pause.
+func (c *Consumer) Resume(partitions map[string][]int32) {
+	c.group.Resume(partitions)
+}
+
+// PauseAll stops fetching from every assigned partition.
+func (c *Consumer) PauseAll() {
+	c.group.PauseAll()
+}
+
+// ResumeAll restarts fetching from every paused partition.
+func (c *Consumer) ResumeAll() {
+	c.group.ResumeAll()
+}
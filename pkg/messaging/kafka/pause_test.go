@@ -0,0 +1,23 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumer_PauseResume(t *testing.T) {
+	g := &fakeGroup{}
+	c := &Consumer{group: g}
+
+	partitions := map[string][]int32{"orders": {0, 2}}
+	c.Pause(partitions)
+	assert.Equal(t, partitions, g.paused)
+	c.Resume(partitions)
+	assert.Nil(t, g.paused)
+
+	c.PauseAll()
+	assert.True(t, g.all)
+	c.ResumeAll()
+	assert.False(t, g.all)
+}
@@ -0,0 +1,154 @@
+package kafka
+
+import (
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/cespare/xxhash/v2"
+)
+
+// ConsumerOption configures a Consumer.
+type ConsumerOption func(*consumerOptions)
+
+// consumerOptions holds the settings applied by ConsumerOption.
+type consumerOptions struct {
+	concurrency    int
+	keyOrdering    bool
+	interceptors   []ConsumerInterceptor
+	commit         CommitStrategy
+	commitInterval time.Duration
+}
+
+// WithConcurrency dispatches the messages of each claim to a pool of n
+// workers. Offsets are still committed in order: a message is only marked
+// once every earlier message of its partition has been handled.
+//
+// Example:
+//
+//	consumer, err := kafka.NewConsumer(cfg, "billing", topics, handler,
+//	    kafka.WithConcurrency(16), kafka.WithKeyOrdering())
+func WithConcurrency(n int) ConsumerOption {
+	return func(o *consumerOptions) { o.concurrency = n }
+}
+
+// WithKeyOrdering routes messages with the same key to the same worker so
+// they are handled in order. Messages without a key are spread round-robin.
+// It only has an effect together with WithConcurrency.
+func WithKeyOrdering() ConsumerOption {
+	return func(o *consumerOptions) { o.keyOrdering = true }
+}
+
+// consumeConcurrently handles a claim with a bounded worker pool until the
+// claim closes or draining starts, then waits for the workers to finish.
+func (h *consumerGroupHandler) consumeConcurrently(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) {
+	n := h.opts.concurrency
+	queues := make([]chan *trackedMessage, n)
+	tracker := &offsetTracker{}
+
+	var wg sync.WaitGroup
+	for i := range queues {
+		// With key ordering each worker owns a queue; otherwise all workers
+		// share the first one.
+		if i == 0 || h.opts.keyOrdering {
+			queues[i] = make(chan *trackedMessage, 1)
+		} else {
+			queues[i] = queues[0]
+		}
+		wg.Add(1)
+		go func(q <-chan *trackedMessage) {
+			defer wg.Done()
+			for tm := range q {
+				tracker.complete(tm, h.handle(sess, tm.msg), func(m *sarama.ConsumerMessage) {
+					if h.opts.commit != CommitManual {
+						sess.MarkMessage(m, "")
+					}
+				})
+			}
+		}(queues[i])
+	}
+
+	var next int
+dispatch:
+	for {
+		var msg *sarama.ConsumerMessage
+		select {
+		case <-h.drain.done():
+			break dispatch
+		case m, ok := <-claim.Messages():
+			if !ok {
+				break dispatch
+			}
+			msg = m
+		}
+		if h.drain.draining() {
+			break
+		}
+
+		tm := tracker.add(msg)
+		q := queues[0]
+		if h.opts.keyOrdering {
+			if len(msg.Key) > 0 {
+				q = queues[xxhash.Sum64(msg.Key)%uint64(n)]
+			} else {
+				q = queues[next%n]
+				next++
+			}
+		}
+		q <- tm
+	}
+
+	if h.opts.keyOrdering {
+		for _, q := range queues {
+			close(q)
+		}
+	} else {
+		close(queues[0])
+	}
+	wg.Wait()
+}
+
+// trackedMessage is a message in flight in a worker pool.
+type trackedMessage struct {
+	msg  *sarama.ConsumerMessage
+	done bool
+	ok   bool
+}
+
+// offsetTracker releases messages for marking in arrival order, so offsets
+// are never committed past a message that is still being handled.
+type offsetTracker struct {
+	mu      sync.Mutex
+	pending []*trackedMessage
+}
+
+// add registers msg as in flight.
+func (t *offsetTracker) add(msg *sarama.ConsumerMessage) *trackedMessage {
+	tm := &trackedMessage{msg: msg}
+	t.mu.Lock()
+	t.pending = append(t.pending, tm)
+	t.mu.Unlock()
+	return tm
+}
+
+// complete records the outcome of tm and calls mark with the newest message
+// that can now be marked, if any. mark runs under the tracker lock so marks
+// are issued in offset order. As with sequential consumption, a failed
+// message is not marked itself but does not hold back later successes.
+func (t *offsetTracker) complete(tm *trackedMessage, ok bool, mark func(*sarama.ConsumerMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tm.done, tm.ok = true, ok
+	var last *sarama.ConsumerMessage
+	i := 0
+	for ; i < len(t.pending) && t.pending[i].done; i++ {
+		if t.pending[i].ok {
+			last = t.pending[i].msg
+		}
+	}
+	t.pending = t.pending[i:]
+	if last != nil {
+		mark(last)
+	}
+}
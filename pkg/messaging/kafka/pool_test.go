@@ -0,0 +1,126 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+// markingSession records the offsets marked by the handler.
+type markingSession struct {
+	fakeSession
+	mu     sync.Mutex
+	marked []int64
+}
+
+func (s *markingSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.mu.Lock()
+	s.marked = append(s.marked, msg.Offset)
+	s.mu.Unlock()
+}
+
+func claimOf(msgs ...*sarama.ConsumerMessage) *fakeClaim {
+	claim := &fakeClaim{topic: "topic", messages: make(chan *sarama.ConsumerMessage, len(msgs))}
+	for _, m := range msgs {
+		claim.messages <- m
+	}
+	close(claim.messages)
+	return claim
+}
+
+func TestConsumeConcurrently_MarksInOrder(t *testing.T) {
+	var msgs []*sarama.ConsumerMessage
+	for i := int64(0); i < 20; i++ {
+		msgs = append(msgs, &sarama.ConsumerMessage{Topic: "topic", Offset: i})
+	}
+
+	var inFlight, maxInFlight int32
+	h := &consumerGroupHandler{
+		opts: consumerOptions{concurrency: 4},
+		handler: MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				m := atomic.LoadInt32(&maxInFlight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+					break
+				}
+			}
+			// Earlier offsets take longer, so completions arrive out of order.
+			time.Sleep(time.Duration(20-msg.Offset) * time.Millisecond / 4)
+			atomic.AddInt32(&inFlight, -1)
+			return nil
+		}),
+	}
+
+	sess := &markingSession{fakeSession: fakeSession{ctx: context.Background()}}
+	assert.NoError(t, h.ConsumeClaim(sess, claimOf(msgs...)))
+
+	assert.Greater(t, atomic.LoadInt32(&maxInFlight), int32(1))
+	assert.NotEmpty(t, sess.marked)
+	assert.Equal(t, int64(19), sess.marked[len(sess.marked)-1])
+	for i := 1; i < len(sess.marked); i++ {
+		assert.Greater(t, sess.marked[i], sess.marked[i-1], "offsets must be marked in order")
+	}
+}
+
+func TestConsumeConcurrently_KeyOrdering(t *testing.T) {
+	var msgs []*sarama.ConsumerMessage
+	for i := int64(0); i < 30; i++ {
+		key := []byte{'a' + byte(i%3)}
+		msgs = append(msgs, &sarama.ConsumerMessage{Topic: "topic", Key: key, Offset: i})
+	}
+
+	var mu sync.Mutex
+	seen := map[string][]int64{}
+	h := &consumerGroupHandler{
+		opts: consumerOptions{concurrency: 3, keyOrdering: true},
+		handler: MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+			mu.Lock()
+			seen[string(msg.Key)] = append(seen[string(msg.Key)], msg.Offset)
+			mu.Unlock()
+			return nil
+		}),
+	}
+
+	sess := &markingSession{fakeSession: fakeSession{ctx: context.Background()}}
+	assert.NoError(t, h.ConsumeClaim(sess, claimOf(msgs...)))
+
+	for key, offsets := range seen {
+		for i := 1; i < len(offsets); i++ {
+			assert.Greater(t, offsets[i], offsets[i-1], "key %s handled out of order", key)
+		}
+	}
+	assert.Equal(t, int64(29), sess.marked[len(sess.marked)-1])
+}
+
+func TestOffsetTracker_FailedMessageDoesNotBlock(t *testing.T) {
+	tr := &offsetTracker{}
+	m0 := tr.add(&sarama.ConsumerMessage{Offset: 0})
+	m1 := tr.add(&sarama.ConsumerMessage{Offset: 1})
+	m2 := tr.add(&sarama.ConsumerMessage{Offset: 2})
+
+	var marked []int64
+	mark := func(m *sarama.ConsumerMessage) { marked = append(marked, m.Offset) }
+
+	tr.complete(m2, true, mark)
+	assert.Empty(t, marked, "must not mark past in-flight messages")
+
+	tr.complete(m1, false, mark)
+	assert.Empty(t, marked)
+
+	tr.complete(m0, false, mark)
+	assert.Equal(t, []int64{2}, marked)
+	assert.Empty(t, tr.pending)
+}
+
+func TestWithConcurrency_Options(t *testing.T) {
+	var o consumerOptions
+	WithConcurrency(8)(&o)
+	WithKeyOrdering()(&o)
+	assert.Equal(t, consumerOptions{concurrency: 8, keyOrdering: true}, o)
+}
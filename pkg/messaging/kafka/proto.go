@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrUnexpectedContentType is returned when a message's content-type header
+// does not match the decoder it is given to.
+var ErrUnexpectedContentType = errors.New("unexpected message content type")
+
+// SendProto publishes a protobuf-encoded message to a Kafka topic, tagged
+// with ContentTypeProto. Correlation IDs found in ctx are added as message
+// headers.
+func (p *Producer) SendProto(ctx context.Context, topic string, key string, value proto.Message) error {
+	return p.SendProtoWithHeaders(ctx, topic, key, value, nil)
+}
+
+// SendProtoWithHeaders publishes a protobuf-encoded message with custom
+// headers.
+func (p *Producer) SendProtoWithHeaders(ctx context.Context, topic string, key string, value proto.Message, headers map[string]string) error {
+	data, err := proto.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return p.send(ctx, topic, p.keyFor(topic, key, value), data, ContentTypeProto, headers)
+}
+
+// DecodeProto unmarshals msg into out. Messages that declare a content type
+// other than ContentTypeProto are rejected with ErrUnexpectedContentType;
+// messages without a content-type header are decoded as protobuf.
+func DecodeProto(msg *sarama.ConsumerMessage, out proto.Message) error {
+	if ct := ContentType(msg); ct != "" && ct != ContentTypeProto {
+		return fmt.Errorf("%w: %s", ErrUnexpectedContentType, ct)
+	}
+	if err := proto.Unmarshal(msg.Value, out); err != nil {
+		return fmt.Errorf("failed to decode protobuf message: %w", err)
+	}
+	return nil
+}
+
+// protoMessage constrains PT to a pointer to T implementing proto.Message,
+// so handlers can be written against generated message types directly.
+type protoMessage[T any] interface {
+	*T
+	proto.Message
+}
+
+// protoHandler adapts a typed protobuf handler to MessageHandler.
+type protoHandler[T any, PT protoMessage[T]] struct {
+	fn func(ctx context.Context, msg *sarama.ConsumerMessage, value PT) error
+}
+
+// ConsumeProto returns a MessageHandler that decodes each message with
+// DecodeProto before passing it to fn.
+//
+// Example:
+//
+//	handler := kafka.ConsumeProto(func(ctx context.Context, msg *sarama.ConsumerMessage, ev *userpb.UserCreated) error {
+//	    return svc.OnUserCreated(ctx, ev)
+//	})
+func ConsumeProto[T any, PT protoMessage[T]](fn func(ctx context.Context, msg *sarama.ConsumerMessage, value PT) error) MessageHandler {
+	return &protoHandler[T, PT]{fn: fn}
+}
+
+// HandleMessage implements MessageHandler.
+func (h *protoHandler[T, PT]) HandleMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	value := PT(new(T))
+	if err := DecodeProto(msg, value); err != nil {
+		return err
+	}
+	return h.fn(ctx, msg, value)
+}
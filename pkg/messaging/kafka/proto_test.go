@@ -0,0 +1,65 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestSendProto_SetsContentType(t *testing.T) {
+	mockProducer := mocks.NewSyncProducer(t, nil)
+	defer func() { _ = mockProducer.Close() }()
+
+	var sent *sarama.ProducerMessage
+	mockProducer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(m *sarama.ProducerMessage) error {
+		sent = m
+		return nil
+	})
+
+	p := &Producer{producer: mockProducer}
+	require.NoError(t, p.SendProto(context.Background(), "topic", "key", wrapperspb.String("hello")))
+
+	headers := map[string]string{}
+	for _, h := range sent.Headers {
+		headers[string(h.Key)] = string(h.Value)
+	}
+	assert.Equal(t, ContentTypeProto, headers[HeaderContentType])
+
+	data, _ := sent.Value.Encode()
+	var out wrapperspb.StringValue
+	require.NoError(t, proto.Unmarshal(data, &out))
+	assert.Equal(t, "hello", out.GetValue())
+}
+
+func TestDecodeProto_RejectsOtherContentTypes(t *testing.T) {
+	data, _ := proto.Marshal(wrapperspb.String("x"))
+	msg := &sarama.ConsumerMessage{
+		Value:   data,
+		Headers: []*sarama.RecordHeader{{Key: []byte(HeaderContentType), Value: []byte(ContentTypeJSON)}},
+	}
+
+	err := DecodeProto(msg, &wrapperspb.StringValue{})
+	assert.True(t, errors.Is(err, ErrUnexpectedContentType))
+}
+
+func TestConsumeProto(t *testing.T) {
+	data, _ := proto.Marshal(wrapperspb.String("payload"))
+
+	var got string
+	h := ConsumeProto(func(ctx context.Context, msg *sarama.ConsumerMessage, v *wrapperspb.StringValue) error {
+		got = v.GetValue()
+		return nil
+	})
+
+	require.NoError(t, h.HandleMessage(context.Background(), &sarama.ConsumerMessage{Value: data}))
+	assert.Equal(t, "payload", got)
+
+	assert.Error(t, h.HandleMessage(context.Background(), &sarama.ConsumerMessage{Value: []byte{0xff, 0xff}}))
+}
@@ -0,0 +1,203 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hamba/avro/v2"
+)
+
+// DefaultSubjectTTL is how long Latest caches the latest schema of a
+// subject by default.
+const DefaultSubjectTTL = 5 * time.Minute
+
+// SchemaRegistry is a minimal Confluent Schema Registry client. Schemas are
+// cached by ID for good, since IDs never change. The latest schema of a
+// subject is cached for the subject TTL, so producers pick up new schema
+// versions without a restart.
+type SchemaRegistry struct {
+	baseURL    string
+	client     *http.Client
+	username   string
+	password   string
+	subjectTTL time.Duration
+	now        func() time.Time
+
+	mu        sync.RWMutex
+	byID      map[int]string
+	bySubject map[string]cachedSubject
+	parsed    map[int]avro.Schema
+}
+
+// cachedSubject is the latest schema of a subject and when it was fetched.
+type cachedSubject struct {
+	schema    RegisteredSchema
+	fetchedAt time.Time
+}
+
+// RegisteredSchema is a schema together with the ID assigned by the registry.
+type RegisteredSchema struct {
+	ID      int    `json:"id"`
+	Subject string `json:"subject,omitempty"`
+	Version int    `json:"version,omitempty"`
+	Schema  string `json:"schema"`
+}
+
+// RegistryOption configures a SchemaRegistry.
+type RegistryOption func(*SchemaRegistry)
+
+// WithRegistryAuth sets HTTP basic auth credentials (e.g. a Confluent Cloud
+// API key and secret).
+func WithRegistryAuth(username, password string) RegistryOption {
+	return func(r *SchemaRegistry) { r.username, r.password = username, password }
+}
+
+// WithRegistryHTTPClient overrides the HTTP client used to reach the registry.
+func WithRegistryHTTPClient(c *http.Client) RegistryOption {
+	return func(r *SchemaRegistry) { r.client = c }
+}
+
+// WithRegistrySubjectTTL sets how long Latest caches the latest schema of a
+// subject (DefaultSubjectTTL by default).
+func WithRegistrySubjectTTL(ttl time.Duration) RegistryOption {
+	return func(r *SchemaRegistry) { r.subjectTTL = ttl }
+}
+
+// NewSchemaRegistry returns a client for the registry at baseURL.
+//
+// Example:
+//
+//	reg := kafka.NewSchemaRegistry("http://schema-registry:8081",
+//	    kafka.WithRegistryAuth(os.Getenv("SR_KEY"), os.Getenv("SR_SECRET")))
+func NewSchemaRegistry(baseURL string, opts ...RegistryOption) *SchemaRegistry {
+	r := &SchemaRegistry{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		client:     &http.Client{Timeout: 10 * time.Second},
+		subjectTTL: DefaultSubjectTTL,
+		now:        time.Now,
+		byID:       make(map[int]string),
+		bySubject:  make(map[string]cachedSubject),
+		parsed:     make(map[int]avro.Schema),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// SubjectForTopic returns the value subject for topic under the default
+// TopicNameStrategy ("<topic>-value").
+func SubjectForTopic(topic string) string {
+	return topic + "-value"
+}
+
+// Latest returns the latest schema registered under subject, cached for
+// the subject TTL.
+func (r *SchemaRegistry) Latest(ctx context.Context, subject string) (RegisteredSchema, error) {
+	r.mu.RLock()
+	cached, ok := r.bySubject[subject]
+	r.mu.RUnlock()
+	if ok && r.now().Sub(cached.fetchedAt) < r.subjectTTL {
+		return cached.schema, nil
+	}
+
+	var s RegisteredSchema
+	if err := r.do(ctx, http.MethodGet, "/subjects/"+url.PathEscape(subject)+"/versions/latest", nil, &s); err != nil {
+		return RegisteredSchema{}, fmt.Errorf("failed to fetch latest schema for %s: %w", subject, err)
+	}
+	r.store(subject, s)
+	return s, nil
+}
+
+// SchemaByID returns the schema registered with id.
+func (r *SchemaRegistry) SchemaByID(ctx context.Context, id int) (string, error) {
+	r.mu.RLock()
+	schema, ok := r.byID[id]
+	r.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	var s RegisteredSchema
+	if err := r.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &s); err != nil {
+		return "", fmt.Errorf("failed to fetch schema %d: %w", id, err)
+	}
+	s.ID = id
+	r.store("", s)
+	return s.Schema, nil
+}
+
+// Register registers schema under subject (a no-op on the registry side if
+// it already exists) and returns its ID.
+func (r *SchemaRegistry) Register(ctx context.Context, subject, schema string) (int, error) {
+	var s RegisteredSchema
+	body := map[string]string{"schema": schema}
+	if err := r.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", body, &s); err != nil {
+		return 0, fmt.Errorf("failed to register schema for %s: %w", subject, err)
+	}
+	s.Subject, s.Schema = subject, schema
+	r.store(subject, s)
+	return s.ID, nil
+}
+
+// Forget drops the cached latest schema of subject, so the next Latest call
+// asks the registry, e.g. after registering a new version elsewhere.
+func (r *SchemaRegistry) Forget(subject string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.bySubject, subject)
+}
+
+// store caches s by ID and, when subject is set, by subject.
+func (r *SchemaRegistry) store(subject string, s RegisteredSchema) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byID[s.ID] = s.Schema
+	if subject != "" {
+		r.bySubject[subject] = cachedSubject{schema: s, fetchedAt: r.now()}
+	}
+}
+
+// do performs a registry request and decodes the JSON response into out.
+func (r *SchemaRegistry) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	}
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("schema registry returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
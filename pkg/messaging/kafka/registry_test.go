@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUserSchema = `{"type":"record","name":"TestUser","fields":[{"name":"id","type":"string"},{"name":"age","type":"int"}]}`
+
+// newFakeRegistry serves a single schema with ID 7 under "users-value" and
+// counts the requests it receives.
+func newFakeRegistry(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if user, pass, ok := r.BasicAuth(); ok && (user != "key" || pass != "secret") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/subjects/users-value/versions/latest":
+			_ = json.NewEncoder(w).Encode(RegisteredSchema{ID: 7, Subject: "users-value", Version: 1, Schema: testUserSchema})
+		case r.Method == http.MethodGet && r.URL.Path == "/schemas/ids/7":
+			_ = json.NewEncoder(w).Encode(map[string]string{"schema": testUserSchema})
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/subjects/"):
+			_ = json.NewEncoder(w).Encode(map[string]int{"id": 9})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code":40401,"message":"Subject not found"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestSchemaRegistry_LatestIsCached(t *testing.T) {
+	srv, calls := newFakeRegistry(t)
+	reg := NewSchemaRegistry(srv.URL+"/", WithRegistryAuth("key", "secret"))
+
+	for i := 0; i < 3; i++ {
+		s, err := reg.Latest(context.Background(), "users-value")
+		require.NoError(t, err)
+		assert.Equal(t, 7, s.ID)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+	// The subject lookup also populates the ID cache.
+	schema, err := reg.SchemaByID(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, testUserSchema, schema)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestSchemaRegistry_LatestExpires(t *testing.T) {
+	srv, calls := newFakeRegistry(t)
+	reg := NewSchemaRegistry(srv.URL, WithRegistrySubjectTTL(time.Minute))
+	now := time.Now()
+	reg.now = func() time.Time { return now }
+
+	_, err := reg.Latest(context.Background(), "users-value")
+	require.NoError(t, err)
+	now = now.Add(30 * time.Second)
+	_, err = reg.Latest(context.Background(), "users-value")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+	now = now.Add(time.Minute)
+	_, err = reg.Latest(context.Background(), "users-value")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+
+	reg.Forget("users-value")
+	_, err = reg.Latest(context.Background(), "users-value")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestSchemaRegistry_SchemaByID(t *testing.T) {
+	srv, _ := newFakeRegistry(t)
+	reg := NewSchemaRegistry(srv.URL)
+
+	schema, err := reg.SchemaByID(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, testUserSchema, schema)
+}
+
+func TestSchemaRegistry_Register(t *testing.T) {
+	srv, _ := newFakeRegistry(t)
+	reg := NewSchemaRegistry(srv.URL)
+
+	id, err := reg.Register(context.Background(), "orders-value", testUserSchema)
+	require.NoError(t, err)
+	assert.Equal(t, 9, id)
+
+	s, err := reg.Latest(context.Background(), "orders-value")
+	require.NoError(t, err)
+	assert.Equal(t, 9, s.ID)
+}
+
+func TestSchemaRegistry_ErrorStatus(t *testing.T) {
+	srv, _ := newFakeRegistry(t)
+	reg := NewSchemaRegistry(srv.URL)
+
+	_, err := reg.Latest(context.Background(), "missing-value")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
+
+func TestSubjectForTopic(t *testing.T) {
+	assert.Equal(t, "users-value", SubjectForTopic("users"))
+}
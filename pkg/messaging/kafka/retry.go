@@ -0,0 +1,189 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+)
+
+// Headers set on messages re-published by a RetryTopology.
+const (
+	HeaderRetryAttempt   = "x-retry-attempt"
+	HeaderRetryNotBefore = "x-retry-not-before"
+	HeaderOriginalTopic  = "x-original-topic"
+	HeaderRetryError     = "x-retry-error"
+)
+
+// RetryTier is a delayed retry topic.
+type RetryTier struct {
+	Topic string
+	Delay time.Duration
+}
+
+// RetryTopology describes the common retry architecture: failed messages
+// of Main move through increasingly delayed retry topics and finally to a
+// dead-letter topic.
+type RetryTopology struct {
+	Main  string
+	Tiers []RetryTier
+	DLQ   string
+}
+
+// NewRetryTopology returns a topology for main with one retry tier per
+// delay, named "<main>.retry.<delay>", and the DLQ "<main>.dlq".
+//
+// Example:
+//
+//	topo := kafka.NewRetryTopology("orders", time.Minute, 10*time.Minute, time.Hour)
+//	// orders -> orders.retry.1m0s -> orders.retry.10m0s -> orders.retry.1h0m0s -> orders.dlq
+//	if err := topo.Ensure(admin, kafka.TopicConfig{Partitions: 6, ReplicationFactor: 3}); err != nil {
+//	    return err
+//	}
+//	consumer, err := kafka.NewConsumer(cfg, "billing", topo.Topics(), topo.Handler(producer, handler))
+func NewRetryTopology(main string, delays ...time.Duration) RetryTopology {
+	t := RetryTopology{Main: main, DLQ: main + ".dlq"}
+	for _, d := range delays {
+		t.Tiers = append(t.Tiers, RetryTier{Topic: main + ".retry." + d.String(), Delay: d})
+	}
+	return t
+}
+
+// Topics returns the topics a consumer of the topology subscribes to: the
+// main topic and every retry tier. The DLQ is left for manual inspection.
+func (t RetryTopology) Topics() []string {
+	topics := []string{t.Main}
+	for _, tier := range t.Tiers {
+		topics = append(topics, tier.Topic)
+	}
+	return topics
+}
+
+// Ensure creates every topic of the topology that does not exist yet, all
+// with the settings in tc.
+func (t RetryTopology) Ensure(admin *Admin, tc TopicConfig) error {
+	for _, topic := range append(t.Topics(), t.DLQ) {
+		if err := admin.EnsureTopic(topic, tc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler wraps handler so failed messages are re-published to the next
+// tier with an incremented HeaderRetryAttempt, and messages read from a
+// retry tier wait until their delay has elapsed before being handled.
+//
+// Failed messages move to the next tier unless the handler returns an
+// errcode.AppError whose code is not retryable, e.g. InvalidArgument: those
+// skip the remaining tiers and go to the DLQ, as do failures on the last
+// tier. Plain errors are retried. The original message is only marked once
+// the re-publish succeeded, so nothing is lost if the producer fails.
+func (t RetryTopology) Handler(producer *Producer, handler MessageHandler) MessageHandler {
+	return MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		if err := waitUntilDue(ctx, msg); err != nil {
+			return err
+		}
+
+		err := handler.HandleMessage(ctx, msg)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		next := t.next(msg.Topic)
+		if next == "" || !retryable(err) {
+			next = t.DLQ
+		}
+		return t.republish(ctx, producer, msg, next, err)
+	})
+}
+
+// next returns the topic after topic, or "" when topic is the last tier or
+// not part of the topology.
+func (t RetryTopology) next(topic string) string {
+	if topic == t.Main {
+		if len(t.Tiers) > 0 {
+			return t.Tiers[0].Topic
+		}
+		return ""
+	}
+	for i, tier := range t.Tiers {
+		if tier.Topic == topic && i+1 < len(t.Tiers) {
+			return t.Tiers[i+1].Topic
+		}
+	}
+	return ""
+}
+
+// delay returns the delay of the tier topic.
+func (t RetryTopology) delay(topic string) time.Duration {
+	for _, tier := range t.Tiers {
+		if tier.Topic == topic {
+			return tier.Delay
+		}
+	}
+	return 0
+}
+
+// republish sends msg to topic with updated retry headers.
+func (t RetryTopology) republish(ctx context.Context, producer *Producer, msg *sarama.ConsumerMessage, topic string, cause error) error {
+	headers := Headers(msg)
+	attempt, _ := strconv.Atoi(headers[HeaderRetryAttempt])
+	headers[HeaderRetryAttempt] = strconv.Itoa(attempt + 1)
+	headers[HeaderRetryError] = cause.Error()
+	if headers[HeaderOriginalTopic] == "" {
+		headers[HeaderOriginalTopic] = msg.Topic
+	}
+	if d := t.delay(topic); d > 0 {
+		headers[HeaderRetryNotBefore] = strconv.FormatInt(time.Now().Add(d).UnixMilli(), 10)
+	} else {
+		delete(headers, HeaderRetryNotBefore)
+	}
+
+	if err := producer.send(ctx, topic, string(msg.Key), msg.Value, "", headers); err != nil {
+		return fmt.Errorf("failed to route message to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// retryable reports whether err should go to the next retry tier: plain
+// errors do, AppErrors when their code does.
+func retryable(err error) bool {
+	var app *errcode.AppError
+	if !errors.As(err, &app) {
+		return true
+	}
+	return app.Code.Outcome() == errcode.OutcomeRetry
+}
+
+// waitUntilDue blocks until the message's HeaderRetryNotBefore time. Retry
+// tiers are consumed in order and every message of a tier has the same
+// delay, so waiting on the head of a partition never delays a message that
+// is already due.
+func waitUntilDue(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	v := Headers(msg)[HeaderRetryNotBefore]
+	if v == "" {
+		return nil
+	}
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil
+	}
+	wait := time.Until(time.UnixMilli(ms))
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,161 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingProducer returns a producer that records sent messages.
+func recordingProducer(sendErr error) (*Producer, *[]*sarama.ProducerMessage) {
+	var sent []*sarama.ProducerMessage
+	p := &Producer{}
+	p.Use(func(ctx context.Context, msg *sarama.ProducerMessage, next SendFunc) error {
+		sent = append(sent, msg)
+		return sendErr
+	})
+	return p, &sent
+}
+
+func sentHeaders(msg *sarama.ProducerMessage) map[string]string {
+	out := map[string]string{}
+	for _, h := range msg.Headers {
+		out[string(h.Key)] = string(h.Value)
+	}
+	return out
+}
+
+func TestNewRetryTopology(t *testing.T) {
+	topo := NewRetryTopology("orders", time.Minute, time.Hour)
+	assert.Equal(t, []string{"orders", "orders.retry.1m0s", "orders.retry.1h0m0s"}, topo.Topics())
+	assert.Equal(t, "orders.dlq", topo.DLQ)
+	assert.Equal(t, "orders.retry.1m0s", topo.next("orders"))
+	assert.Equal(t, "orders.retry.1h0m0s", topo.next("orders.retry.1m0s"))
+	assert.Equal(t, "", topo.next("orders.retry.1h0m0s"))
+}
+
+func TestRetryTopology_Ensure(t *testing.T) {
+	a, fake := newTestAdmin()
+	require.NoError(t, NewRetryTopology("orders", time.Minute).Ensure(a, TopicConfig{Partitions: 3}))
+	assert.Len(t, fake.topics, 3)
+	assert.Contains(t, fake.topics, "orders.dlq")
+}
+
+func TestRetryTopology_FailedMessageMovesToNextTier(t *testing.T) {
+	topo := NewRetryTopology("orders", time.Minute, time.Hour)
+	p, sent := recordingProducer(nil)
+	h := topo.Handler(p, MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		return errcode.New(errcode.Unavailable, "db unavailable")
+	}))
+
+	msg := &sarama.ConsumerMessage{
+		Topic:   "orders",
+		Key:     []byte("o-1"),
+		Value:   []byte(`{"id":"o-1"}`),
+		Headers: []*sarama.RecordHeader{{Key: []byte(HeaderContentType), Value: []byte(ContentTypeJSON)}},
+	}
+	require.NoError(t, h.HandleMessage(context.Background(), msg))
+	require.Len(t, *sent, 1)
+
+	out := (*sent)[0]
+	assert.Equal(t, "orders.retry.1m0s", out.Topic)
+	assert.Equal(t, sarama.StringEncoder("o-1"), out.Key)
+	assert.Equal(t, sarama.ByteEncoder(`{"id":"o-1"}`), out.Value)
+
+	headers := sentHeaders(out)
+	assert.Equal(t, "1", headers[HeaderRetryAttempt])
+	assert.Equal(t, "orders", headers[HeaderOriginalTopic])
+	assert.Equal(t, "unavailable: db unavailable", headers[HeaderRetryError])
+	assert.Equal(t, ContentTypeJSON, headers[HeaderContentType])
+	notBefore, err := strconv.ParseInt(headers[HeaderRetryNotBefore], 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Now().Add(time.Minute).UnixMilli(), notBefore, 1000)
+}
+
+func TestRetryTopology_LastTierAndNonRetryableGoToDLQ(t *testing.T) {
+	topo := NewRetryTopology("orders", time.Minute)
+	p, sent := recordingProducer(nil)
+
+	failing := topo.Handler(p, MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		return errcode.New(errcode.Timeout, "still failing")
+	}))
+	require.NoError(t, failing.HandleMessage(context.Background(), &sarama.ConsumerMessage{
+		Topic: "orders.retry.1m0s",
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte(HeaderRetryAttempt), Value: []byte("1")},
+			{Key: []byte(HeaderOriginalTopic), Value: []byte("orders")},
+		},
+	}))
+
+	invalid := topo.Handler(p, MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		return errcode.New(errcode.InvalidArgument, "missing customer")
+	}))
+	require.NoError(t, invalid.HandleMessage(context.Background(), &sarama.ConsumerMessage{Topic: "orders"}))
+
+	internal := topo.Handler(p, MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		return errcode.Wrap(errors.New("nil pointer"), errcode.Internal, "bug")
+	}))
+	require.NoError(t, internal.HandleMessage(context.Background(), &sarama.ConsumerMessage{Topic: "orders"}))
+
+	require.Len(t, *sent, 3)
+	assert.Equal(t, "orders.dlq", (*sent)[0].Topic)
+	assert.Equal(t, "2", sentHeaders((*sent)[0])[HeaderRetryAttempt])
+	assert.Equal(t, "orders", sentHeaders((*sent)[0])[HeaderOriginalTopic])
+	assert.NotContains(t, sentHeaders((*sent)[0]), HeaderRetryNotBefore)
+	assert.Equal(t, "orders.dlq", (*sent)[1].Topic)
+	assert.Equal(t, "orders.dlq", (*sent)[2].Topic)
+}
+
+func TestRetryTopology_PlainErrorsAreRetried(t *testing.T) {
+	topo := NewRetryTopology("orders", time.Minute)
+	p, sent := recordingProducer(nil)
+	h := topo.Handler(p, MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		return fmt.Errorf("update failed: %w", errors.New("connection reset"))
+	}))
+
+	require.NoError(t, h.HandleMessage(context.Background(), &sarama.ConsumerMessage{Topic: "orders"}))
+	require.Len(t, *sent, 1)
+	assert.Equal(t, "orders.retry.1m0s", (*sent)[0].Topic)
+}
+
+func TestRetryTopology_RepublishFailureLeavesMessageUnmarked(t *testing.T) {
+	topo := NewRetryTopology("orders", time.Minute)
+	p, _ := recordingProducer(errors.New("broker down"))
+	h := topo.Handler(p, MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		return errors.New("failed")
+	}))
+
+	err := h.HandleMessage(context.Background(), &sarama.ConsumerMessage{Topic: "orders"})
+	assert.ErrorContains(t, err, "broker down")
+}
+
+func TestRetryTopology_WaitsUntilDue(t *testing.T) {
+	topo := NewRetryTopology("orders", time.Minute)
+	p, _ := recordingProducer(nil)
+	var handledAt time.Time
+	h := topo.Handler(p, MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		handledAt = time.Now()
+		return nil
+	}))
+
+	due := time.Now().Add(50 * time.Millisecond)
+	msg := &sarama.ConsumerMessage{
+		Topic:   "orders.retry.1m0s",
+		Headers: []*sarama.RecordHeader{{Key: []byte(HeaderRetryNotBefore), Value: []byte(strconv.FormatInt(due.UnixMilli(), 10))}},
+	}
+	require.NoError(t, h.HandleMessage(context.Background(), msg))
+	assert.False(t, handledAt.Before(due.Truncate(time.Millisecond)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	msg.Headers[0].Value = []byte(strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10))
+	assert.ErrorIs(t, h.HandleMessage(ctx, msg), context.Canceled)
+}
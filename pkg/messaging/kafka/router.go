@@ -0,0 +1,106 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+// HeaderEventType names the event carried by a message, for routing mixed
+// topics with Router.
+const HeaderEventType = "event_type"
+
+// ErrNoRoute is returned by Router for messages no handler matches.
+var ErrNoRoute = errors.New("no handler registered for message")
+
+// routeKey identifies a route; empty fields match anything.
+type routeKey struct {
+	topic     string
+	eventType string
+}
+
+// Router is a MessageHandler that dispatches to handlers registered per
+// topic, per event type or both, so one Consumer can serve several topics
+// and event types without a switch on msg.Topic.
+//
+// The most specific route wins: topic and event type, then event type,
+// then topic, then the default handler.
+//
+// Example:
+//
+//	router := kafka.NewRouter()
+//	router.HandleTopic("payments", payments)
+//	router.HandleEvent("user.created", onUserCreated)
+//	router.Handle("orders", "order.cancelled", onOrderCancelled)
+//	router.HandleDefault(kafka.MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+//	    return nil // ignore unknown events
+//	}))
+//
+//	consumer, err := kafka.NewConsumer(cfg, "billing", []string{"payments", "users", "orders"}, router)
+type Router struct {
+	header   string
+	routes   map[routeKey]MessageHandler
+	fallback MessageHandler
+}
+
+// NewRouter returns an empty Router matching event types on
+// HeaderEventType.
+func NewRouter() *Router {
+	return &Router{header: HeaderEventType, routes: map[routeKey]MessageHandler{}}
+}
+
+// SetEventHeader changes the header that holds the event type.
+func (r *Router) SetEventHeader(name string) {
+	r.header = name
+}
+
+// Handle registers h for messages of eventType on topic. An empty topic or
+// event type matches any value.
+func (r *Router) Handle(topic, eventType string, h MessageHandler) {
+	r.routes[routeKey{topic: topic, eventType: eventType}] = h
+}
+
+// HandleTopic registers h for every message of topic.
+func (r *Router) HandleTopic(topic string, h MessageHandler) {
+	r.Handle(topic, "", h)
+}
+
+// HandleEvent registers h for messages of eventType on any topic.
+func (r *Router) HandleEvent(eventType string, h MessageHandler) {
+	r.Handle("", eventType, h)
+}
+
+// HandleDefault registers h for messages no other route matches. Without
+// a default handler such messages fail with ErrNoRoute.
+func (r *Router) HandleDefault(h MessageHandler) {
+	r.fallback = h
+}
+
+// HandleMessage implements MessageHandler.
+func (r *Router) HandleMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	if h := r.match(msg); h != nil {
+		return h.HandleMessage(ctx, msg)
+	}
+	return fmt.Errorf("%w (topic %s, %s %q)", ErrNoRoute, msg.Topic, r.header, Headers(msg)[r.header])
+}
+
+// match returns the most specific handler for msg.
+func (r *Router) match(msg *sarama.ConsumerMessage) MessageHandler {
+	eventType := Headers(msg)[r.header]
+	candidates := []routeKey{{topic: msg.Topic}}
+	if eventType != "" {
+		candidates = []routeKey{
+			{topic: msg.Topic, eventType: eventType},
+			{eventType: eventType},
+			{topic: msg.Topic},
+		}
+	}
+	for _, k := range candidates {
+		if h, ok := r.routes[k]; ok {
+			return h
+		}
+	}
+	return r.fallback
+}
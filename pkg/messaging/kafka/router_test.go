@@ -0,0 +1,75 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter_Precedence(t *testing.T) {
+	var got string
+	named := func(name string) MessageHandler {
+		return MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+			got = name
+			return nil
+		})
+	}
+
+	r := NewRouter()
+	r.HandleTopic("orders", named("orders"))
+	r.HandleEvent("user.created", named("user.created"))
+	r.Handle("orders", "order.cancelled", named("orders/order.cancelled"))
+
+	event := func(topic, eventType string) *sarama.ConsumerMessage {
+		msg := &sarama.ConsumerMessage{Topic: topic}
+		if eventType != "" {
+			msg.Headers = []*sarama.RecordHeader{{Key: []byte(HeaderEventType), Value: []byte(eventType)}}
+		}
+		return msg
+	}
+
+	for _, tc := range []struct {
+		msg  *sarama.ConsumerMessage
+		want string
+	}{
+		{event("orders", "order.cancelled"), "orders/order.cancelled"},
+		{event("orders", "order.created"), "orders"},
+		{event("orders", ""), "orders"},
+		{event("users", "user.created"), "user.created"},
+		{event("orders", "user.created"), "user.created"},
+	} {
+		got = ""
+		assert.NoError(t, r.HandleMessage(context.Background(), tc.msg))
+		assert.Equal(t, tc.want, got)
+	}
+}
+
+func TestRouter_NoRouteAndDefault(t *testing.T) {
+	r := NewRouter()
+	msg := &sarama.ConsumerMessage{Topic: "audit"}
+	assert.ErrorIs(t, r.HandleMessage(context.Background(), msg), ErrNoRoute)
+
+	called := false
+	r.HandleDefault(MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		called = true
+		return nil
+	}))
+	assert.NoError(t, r.HandleMessage(context.Background(), msg))
+	assert.True(t, called)
+}
+
+func TestRouter_CustomEventHeader(t *testing.T) {
+	r := NewRouter()
+	r.SetEventHeader("ce_type")
+	called := false
+	r.HandleEvent("com.example.created", MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		called = true
+		return nil
+	}))
+
+	msg := &sarama.ConsumerMessage{Topic: "events", Headers: []*sarama.RecordHeader{{Key: []byte("ce_type"), Value: []byte("com.example.created")}}}
+	assert.NoError(t, r.HandleMessage(context.Background(), msg))
+	assert.True(t, called)
+}
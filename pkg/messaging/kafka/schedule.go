@@ -0,0 +1,173 @@
+package kafka
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// HeaderDeliverAt is the Unix millisecond time a message scheduled through
+// a DelayTopology is due on its HeaderOriginalTopic.
+const HeaderDeliverAt = "x-deliver-at"
+
+// DefaultDelayTopicPrefix prefixes the tier topics of NewDelayTopologyFromEnv.
+const DefaultDelayTopicPrefix = "scheduled"
+
+// DelayTopology defers messages through fixed-delay topics, since Kafka has
+// no per-message delay. Every message of a tier waits the same delay, so a
+// tier consumer waiting on the head of a partition never delays a message
+// that is already due; longer delays hop through several tiers. Messages
+// are never delivered early, but can be late by up to the shortest delay,
+// so start with a short tier such as 1s.
+type DelayTopology struct {
+	// Tiers are ordered by ascending delay.
+	Tiers []RetryTier
+}
+
+// NewDelayTopology returns a topology with one tier per delay, named
+// "<prefix>.delay.<delay>".
+//
+// Example:
+//
+//	topo := kafka.NewDelayTopology("scheduled", time.Second, time.Minute, time.Hour)
+//	if err := topo.Ensure(admin, kafka.TopicConfig{Partitions: 6, ReplicationFactor: 3}); err != nil {
+//	    return err
+//	}
+//	// One forwarding consumer per deployment:
+//	forwarder, err := kafka.NewConsumer(cfg, "scheduler", topo.Topics(), topo.Forwarder(producer))
+//	// Anywhere:
+//	err = topo.SendAfter(ctx, producer, 90*time.Minute, "orders.expire", order.ID, payload, nil)
+func NewDelayTopology(prefix string, delays ...time.Duration) DelayTopology {
+	var t DelayTopology
+	for _, d := range delays {
+		if d > 0 {
+			t.Tiers = append(t.Tiers, RetryTier{Topic: prefix + ".delay." + d.String(), Delay: d})
+		}
+	}
+	slices.SortFunc(t.Tiers, func(a, b RetryTier) int { return cmp.Compare(a.Delay, b.Delay) })
+	return t
+}
+
+// NewDelayTopologyFromEnv builds a topology from environment variables:
+//
+//	KAFKA_DELAY_TIERS         comma-separated delays, e.g. 1s,1m,1h
+//	KAFKA_DELAY_TOPIC_PREFIX  tier topic prefix (default "scheduled")
+//
+// The topology has no tiers when KAFKA_DELAY_TIERS is unset.
+func NewDelayTopologyFromEnv() (DelayTopology, error) {
+	prefix := os.Getenv("KAFKA_DELAY_TOPIC_PREFIX")
+	if prefix == "" {
+		prefix = DefaultDelayTopicPrefix
+	}
+	var delays []time.Duration
+	for _, v := range strings.Split(os.Getenv("KAFKA_DELAY_TIERS"), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return DelayTopology{}, fmt.Errorf("invalid KAFKA_DELAY_TIERS delay %q", v)
+		}
+		delays = append(delays, d)
+	}
+	return NewDelayTopology(prefix, delays...), nil
+}
+
+// Topics returns the tier topics, consumed by Forwarder.
+func (t DelayTopology) Topics() []string {
+	topics := make([]string, len(t.Tiers))
+	for i, tier := range t.Tiers {
+		topics[i] = tier.Topic
+	}
+	return topics
+}
+
+// Ensure creates every tier topic that does not exist yet, all with the
+// settings in tc.
+func (t DelayTopology) Ensure(admin *Admin, tc TopicConfig) error {
+	for _, topic := range t.Topics() {
+		if err := admin.EnsureTopic(topic, tc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendAfter sends value to topic through the tiers so that it is delivered
+// no earlier than delay from now, with key and headers preserved. A delay
+// of zero or less sends to topic immediately. Correlation IDs are read from
+// ctx as by SendBytes.
+func (t DelayTopology) SendAfter(ctx context.Context, producer *Producer, delay time.Duration, topic, key string, value []byte, headers map[string]string) error {
+	if topic == "" {
+		return fmt.Errorf("topic is required")
+	}
+	if delay > 0 && len(t.Tiers) == 0 {
+		return fmt.Errorf("delay topology has no tiers")
+	}
+	return t.route(ctx, producer, time.Now().Add(delay), topic, key, value, headers)
+}
+
+// Forwarder returns the handler consuming Topics(): it waits until each
+// message is due on its tier, then moves it to the next tier or, once its
+// delivery time is reached, to its original topic without the scheduling
+// headers. The message is only marked once the send succeeded.
+func (t DelayTopology) Forwarder(producer *Producer) MessageHandler {
+	return MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		if err := waitUntilDue(ctx, msg); err != nil {
+			return err
+		}
+		headers := Headers(msg)
+		topic := headers[HeaderOriginalTopic]
+		if topic == "" {
+			return fmt.Errorf("scheduled message on %s has no %s header", msg.Topic, HeaderOriginalTopic)
+		}
+		deliverAt := time.Now()
+		if ms, err := strconv.ParseInt(headers[HeaderDeliverAt], 10, 64); err == nil {
+			deliverAt = time.UnixMilli(ms)
+		}
+		return t.route(ctx, producer, deliverAt, topic, string(msg.Key), msg.Value, headers)
+	})
+}
+
+// route sends value to the tier that brings it closest to deliverAt without
+// overshooting, or to topic when it is due.
+func (t DelayTopology) route(ctx context.Context, producer *Producer, deliverAt time.Time, topic, key string, value []byte, headers map[string]string) error {
+	out := make(map[string]string, len(headers)+3)
+	for k, v := range headers {
+		out[k] = v
+	}
+	delete(out, HeaderOriginalTopic)
+	delete(out, HeaderDeliverAt)
+	delete(out, HeaderRetryNotBefore)
+
+	remaining := time.Until(deliverAt)
+	if remaining <= 0 || len(t.Tiers) == 0 {
+		if err := producer.send(ctx, topic, key, value, "", out); err != nil {
+			return fmt.Errorf("failed to deliver scheduled message to %s: %w", topic, err)
+		}
+		return nil
+	}
+
+	// The largest tier not exceeding the remaining time; the shortest tier
+	// when every tier exceeds it, delivering up to that much late.
+	tier := t.Tiers[0]
+	for _, candidate := range t.Tiers[1:] {
+		if candidate.Delay <= remaining {
+			tier = candidate
+		}
+	}
+	out[HeaderOriginalTopic] = topic
+	out[HeaderDeliverAt] = strconv.FormatInt(deliverAt.UnixMilli(), 10)
+	out[HeaderRetryNotBefore] = strconv.FormatInt(time.Now().Add(min(tier.Delay, remaining)).UnixMilli(), 10)
+	if err := producer.send(ctx, tier.Topic, key, value, "", out); err != nil {
+		return fmt.Errorf("failed to schedule message on %s: %w", tier.Topic, err)
+	}
+	return nil
+}
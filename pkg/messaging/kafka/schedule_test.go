@@ -0,0 +1,128 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// consumed converts a sent message to the message a consumer would read.
+func consumed(msg *sarama.ProducerMessage) *sarama.ConsumerMessage {
+	out := &sarama.ConsumerMessage{Topic: msg.Topic}
+	if msg.Key != nil {
+		out.Key, _ = msg.Key.Encode()
+	}
+	if msg.Value != nil {
+		out.Value, _ = msg.Value.Encode()
+	}
+	for _, h := range msg.Headers {
+		out.Headers = append(out.Headers, &sarama.RecordHeader{Key: h.Key, Value: h.Value})
+	}
+	return out
+}
+
+func TestNewDelayTopology(t *testing.T) {
+	topo := NewDelayTopology("scheduled", time.Hour, time.Second, 0, time.Minute)
+	assert.Equal(t, []string{"scheduled.delay.1s", "scheduled.delay.1m0s", "scheduled.delay.1h0m0s"}, topo.Topics())
+
+	a, fake := newTestAdmin()
+	require.NoError(t, topo.Ensure(a, TopicConfig{Partitions: 3}))
+	assert.Len(t, fake.topics, 3)
+}
+
+func TestNewDelayTopologyFromEnv(t *testing.T) {
+	topo, err := NewDelayTopologyFromEnv()
+	require.NoError(t, err)
+	assert.Empty(t, topo.Tiers)
+
+	t.Setenv("KAFKA_DELAY_TIERS", "1m, 1s")
+	t.Setenv("KAFKA_DELAY_TOPIC_PREFIX", "billing")
+	topo, err = NewDelayTopologyFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing.delay.1s", "billing.delay.1m0s"}, topo.Topics())
+
+	t.Setenv("KAFKA_DELAY_TIERS", "1m,soon")
+	_, err = NewDelayTopologyFromEnv()
+	assert.ErrorContains(t, err, "invalid KAFKA_DELAY_TIERS")
+}
+
+func TestDelayTopology_SendAfter(t *testing.T) {
+	topo := NewDelayTopology("scheduled", time.Second, time.Minute, time.Hour)
+	p, sent := recordingProducer(nil)
+
+	start := time.Now()
+	require.NoError(t, topo.SendAfter(context.Background(), p, 90*time.Minute, "orders", "o-1", []byte("expire"), map[string]string{"tenant": "acme"}))
+	require.Len(t, *sent, 1)
+	out := (*sent)[0]
+	assert.Equal(t, "scheduled.delay.1h0m0s", out.Topic, "largest tier within the delay")
+	assert.Equal(t, sarama.StringEncoder("o-1"), out.Key)
+
+	headers := sentHeaders(out)
+	assert.Equal(t, "orders", headers[HeaderOriginalTopic])
+	assert.Equal(t, "acme", headers["tenant"])
+	deliverAt, err := strconv.ParseInt(headers[HeaderDeliverAt], 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, start.Add(90*time.Minute).UnixMilli(), deliverAt, 1000)
+	notBefore, err := strconv.ParseInt(headers[HeaderRetryNotBefore], 10, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, start.Add(time.Hour).UnixMilli(), notBefore, 1000)
+
+	require.NoError(t, topo.SendAfter(context.Background(), p, 0, "orders", "o-1", []byte("now"), nil))
+	assert.Equal(t, "orders", (*sent)[1].Topic)
+	assert.NotContains(t, sentHeaders((*sent)[1]), HeaderDeliverAt)
+
+	assert.ErrorContains(t, DelayTopology{}.SendAfter(context.Background(), p, time.Minute, "orders", "", nil, nil), "no tiers")
+}
+
+func TestDelayTopology_Forwarder(t *testing.T) {
+	topo := NewDelayTopology("scheduled", time.Second, time.Minute)
+	p, sent := recordingProducer(nil)
+	fwd := topo.Forwarder(p)
+
+	// Due in 30s: moved to the 1s tier, the largest that fits.
+	msg := &sarama.ConsumerMessage{
+		Topic: "scheduled.delay.1m0s",
+		Key:   []byte("o-1"),
+		Value: []byte("expire"),
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte(HeaderOriginalTopic), Value: []byte("orders")},
+			{Key: []byte(HeaderDeliverAt), Value: []byte(strconv.FormatInt(time.Now().Add(30*time.Second).UnixMilli(), 10))},
+			{Key: []byte("tenant"), Value: []byte("acme")},
+		},
+	}
+	require.NoError(t, fwd.HandleMessage(context.Background(), msg))
+	require.Len(t, *sent, 1)
+	assert.Equal(t, "scheduled.delay.1s", (*sent)[0].Topic)
+
+	// Due: delivered to the original topic without scheduling headers.
+	msg.Headers[1].Value = []byte(strconv.FormatInt(time.Now().Add(-time.Millisecond).UnixMilli(), 10))
+	require.NoError(t, fwd.HandleMessage(context.Background(), msg))
+	require.Len(t, *sent, 2)
+	out := (*sent)[1]
+	assert.Equal(t, "orders", out.Topic)
+	assert.Equal(t, sarama.ByteEncoder("expire"), out.Value)
+	headers := sentHeaders(out)
+	assert.Equal(t, "acme", headers["tenant"])
+	assert.NotContains(t, headers, HeaderOriginalTopic)
+	assert.NotContains(t, headers, HeaderDeliverAt)
+	assert.NotContains(t, headers, HeaderRetryNotBefore)
+
+	assert.ErrorContains(t, fwd.HandleMessage(context.Background(), &sarama.ConsumerMessage{Topic: "scheduled.delay.1s"}), "no x-original-topic header")
+}
+
+func TestDelayTopology_ForwarderWaitsForTier(t *testing.T) {
+	topo := NewDelayTopology("scheduled", time.Second)
+	p, sent := recordingProducer(nil)
+	require.NoError(t, topo.SendAfter(context.Background(), p, 50*time.Millisecond, "orders", "", []byte("x"), nil))
+
+	start := time.Now()
+	require.NoError(t, topo.Forwarder(p).HandleMessage(context.Background(), consumed((*sent)[0])))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond, "waits for the requested delay, not the whole tier")
+	require.Len(t, *sent, 2)
+	assert.Equal(t, "orders", (*sent)[1].Topic)
+}
@@ -0,0 +1,178 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/env"
+	"github.com/xdg-go/scram"
+)
+
+// Supported SASL mechanisms.
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+// SecurityConfig holds SASL and TLS settings for talking to secured
+// clusters such as MSK or Confluent Cloud.
+type SecurityConfig struct {
+	// SASLMechanism is one of SASLPlain, SASLScramSHA256 or SASLScramSHA512.
+	// Empty disables SASL.
+	SASLMechanism string
+	Username      string
+	Password      string
+
+	// TLSEnabled turns on TLS. CAFile, CertFile and KeyFile are optional
+	// PEM paths; without CAFile the system roots are used.
+	TLSEnabled            bool
+	CAFile                string
+	CertFile              string
+	KeyFile               string
+	TLSInsecureSkipVerify bool
+}
+
+// securityFromEnv reads SecurityConfig from the environment:
+//
+//	KAFKA_SASL_MECHANISM      PLAIN, SCRAM-SHA-256 (SCRAM-256), SCRAM-SHA-512 (SCRAM-512)
+//	KAFKA_USERNAME            SASL username
+//	KAFKA_PASSWORD            SASL password
+//	KAFKA_TLS_ENABLED         "true" to enable TLS
+//	KAFKA_TLS_CA_FILE         PEM CA bundle
+//	KAFKA_TLS_CERT_FILE       PEM client certificate
+//	KAFKA_TLS_KEY_FILE        PEM client key
+//	KAFKA_TLS_SKIP_VERIFY     "true" to skip server verification (testing only)
+func securityFromEnv() (SecurityConfig, error) {
+	sec := SecurityConfig{
+		Username: os.Getenv("KAFKA_USERNAME"),
+		Password: os.Getenv("KAFKA_PASSWORD"),
+		CAFile:   os.Getenv("KAFKA_TLS_CA_FILE"),
+		CertFile: os.Getenv("KAFKA_TLS_CERT_FILE"),
+		KeyFile:  os.Getenv("KAFKA_TLS_KEY_FILE"),
+	}
+
+	mech, err := normalizeMechanism(os.Getenv("KAFKA_SASL_MECHANISM"))
+	if err != nil {
+		return sec, err
+	}
+	sec.SASLMechanism = mech
+
+	if sec.TLSEnabled, err = env.Bool("KAFKA_TLS_ENABLED"); err != nil {
+		return sec, err
+	}
+	if sec.TLSInsecureSkipVerify, err = env.Bool("KAFKA_TLS_SKIP_VERIFY"); err != nil {
+		return sec, err
+	}
+	return sec, nil
+}
+
+// normalizeMechanism maps accepted spellings to a supported mechanism.
+func normalizeMechanism(m string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(m)) {
+	case "":
+		return "", nil
+	case SASLPlain:
+		return SASLPlain, nil
+	case SASLScramSHA256, "SCRAM-256":
+		return SASLScramSHA256, nil
+	case SASLScramSHA512, "SCRAM-512":
+		return SASLScramSHA512, nil
+	default:
+		return "", fmt.Errorf("unsupported SASL mechanism %q", m)
+	}
+}
+
+// apply configures SASL and TLS on a Sarama config.
+func (s SecurityConfig) apply(sc *sarama.Config) error {
+	if s.SASLMechanism != "" {
+		mech, err := normalizeMechanism(s.SASLMechanism)
+		if err != nil {
+			return err
+		}
+		if s.Username == "" {
+			return fmt.Errorf("SASL mechanism %s requires a username", mech)
+		}
+		sc.Net.SASL.Enable = true
+		sc.Net.SASL.Handshake = true
+		sc.Net.SASL.User = s.Username
+		sc.Net.SASL.Password = s.Password
+
+		switch mech {
+		case SASLPlain:
+			sc.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		case SASLScramSHA256:
+			sc.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+			sc.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{hash: scram.SHA256} }
+		case SASLScramSHA512:
+			sc.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+			sc.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{hash: scram.SHA512} }
+		}
+	}
+
+	if s.TLSEnabled {
+		tlsCfg, err := s.tlsConfig()
+		if err != nil {
+			return err
+		}
+		sc.Net.TLS.Enable = true
+		sc.Net.TLS.Config = tlsCfg
+	}
+	return nil
+}
+
+// tlsConfig builds the TLS configuration from the configured PEM files.
+func (s SecurityConfig) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: s.TLSInsecureSkipVerify,
+	}
+
+	if s.CAFile != "" {
+		pem, err := os.ReadFile(s.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Kafka CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", s.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if s.CertFile != "" || s.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Kafka client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// scramClient implements sarama.SCRAMClient on top of xdg-go/scram.
+type scramClient struct {
+	hash scram.HashGeneratorFcn
+	conv *scram.ClientConversation
+}
+
+func (c *scramClient) Begin(user, password, authzID string) error {
+	client, err := c.hash.NewClient(user, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.conv = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conv.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.conv.Done()
+}
@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertPair writes a self-signed certificate and key to dir.
+func writeTestCertPair(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestNewConfigFromEnv_Security(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "localhost:9092")
+	t.Setenv("KAFKA_SASL_MECHANISM", "scram-512")
+	t.Setenv("KAFKA_USERNAME", "svc")
+	t.Setenv("KAFKA_PASSWORD", "secret")
+	t.Setenv("KAFKA_TLS_ENABLED", "true")
+
+	cfg, err := NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, SASLScramSHA512, cfg.Security.SASLMechanism)
+	assert.Equal(t, "svc", cfg.Security.Username)
+	assert.True(t, cfg.Security.TLSEnabled)
+}
+
+func TestNewConfigFromEnv_InvalidSecurity(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "localhost:9092")
+
+	t.Setenv("KAFKA_SASL_MECHANISM", "GSSAPI")
+	_, err := NewConfigFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("KAFKA_SASL_MECHANISM", "")
+	t.Setenv("KAFKA_TLS_ENABLED", "maybe")
+	_, err = NewConfigFromEnv()
+	assert.Error(t, err)
+}
+
+func TestSecurityConfig_ApplySASL(t *testing.T) {
+	tests := []struct {
+		mechanism string
+		want      sarama.SASLMechanism
+		scram     bool
+	}{
+		{SASLPlain, sarama.SASLTypePlaintext, false},
+		{SASLScramSHA256, sarama.SASLTypeSCRAMSHA256, true},
+		{"SCRAM-512", sarama.SASLTypeSCRAMSHA512, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mechanism, func(t *testing.T) {
+			sc := sarama.NewConfig()
+			err := SecurityConfig{SASLMechanism: tt.mechanism, Username: "u", Password: "p"}.apply(sc)
+			require.NoError(t, err)
+			assert.True(t, sc.Net.SASL.Enable)
+			assert.Equal(t, tt.want, sc.Net.SASL.Mechanism)
+			if tt.scram {
+				require.NotNil(t, sc.Net.SASL.SCRAMClientGeneratorFunc)
+				client := sc.Net.SASL.SCRAMClientGeneratorFunc()
+				require.NoError(t, client.Begin("u", "p", ""))
+				first, err := client.Step("")
+				require.NoError(t, err)
+				assert.Contains(t, first, "n=u")
+			}
+		})
+	}
+}
+
+func TestSecurityConfig_ApplyRequiresUsername(t *testing.T) {
+	err := SecurityConfig{SASLMechanism: SASLPlain}.apply(sarama.NewConfig())
+	assert.Error(t, err)
+}
+
+func TestSecurityConfig_ApplyTLS(t *testing.T) {
+	certFile, keyFile := writeTestCertPair(t, t.TempDir())
+
+	sc := sarama.NewConfig()
+	err := SecurityConfig{TLSEnabled: true, CAFile: certFile, CertFile: certFile, KeyFile: keyFile}.apply(sc)
+	require.NoError(t, err)
+	assert.True(t, sc.Net.TLS.Enable)
+	assert.NotNil(t, sc.Net.TLS.Config.RootCAs)
+	assert.Len(t, sc.Net.TLS.Config.Certificates, 1)
+
+	err = SecurityConfig{TLSEnabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")}.apply(sarama.NewConfig())
+	assert.Error(t, err)
+}
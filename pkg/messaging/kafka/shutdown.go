@@ -0,0 +1,142 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// drainState coordinates Shutdown with the running claims. A nil
+// *drainState never drains, which keeps handlers built without a Consumer
+// working.
+type drainState struct {
+	mu       sync.Mutex
+	stopping chan struct{}
+	idle     chan struct{}
+	stopped  bool
+	active   int
+	cancel   context.CancelFunc
+}
+
+func newDrainState() *drainState {
+	return &drainState{stopping: make(chan struct{}), idle: make(chan struct{})}
+}
+
+// enter registers a claim. It returns false once draining has started, in
+// which case the claim must not consume anything.
+func (d *drainState) enter() bool {
+	if d == nil {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return false
+	}
+	d.active++
+	return true
+}
+
+// exit unregisters a claim entered with enter.
+func (d *drainState) exit() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.stopped && d.active == 0 {
+		close(d.idle)
+	}
+}
+
+// stop starts draining and returns a channel closed once every claim has
+// exited. It is safe to call more than once.
+func (d *drainState) stop() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.stopped {
+		d.stopped = true
+		close(d.stopping)
+		if d.active == 0 {
+			close(d.idle)
+		}
+	}
+	return d.idle
+}
+
+// done returns a channel closed when draining starts (nil, never ready, for
+// a nil drainState).
+func (d *drainState) done() <-chan struct{} {
+	if d == nil {
+		return nil
+	}
+	return d.stopping
+}
+
+// draining reports whether Shutdown has been called.
+func (d *drainState) draining() bool {
+	select {
+	case <-d.done():
+		return true
+	default:
+		return false
+	}
+}
+
+// setCancel records the cancel function of the running Run call.
+func (d *drainState) setCancel(cancel context.CancelFunc) {
+	d.mu.Lock()
+	d.cancel = cancel
+	d.mu.Unlock()
+}
+
+// cancelRun cancels the context of the running Run call, if any.
+func (d *drainState) cancelRun() {
+	d.mu.Lock()
+	cancel := d.cancel
+	d.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// Shutdown stops the consumer gracefully: it stops claiming new messages,
+// waits for in-flight handlers to finish, commits the final offsets and
+// closes the group. Run then returns nil.
+//
+// If ctx expires before the handlers finish, the remaining handlers see
+// their context canceled, the group is closed anyway and ctx's error is
+// returned. Unfinished messages are redelivered after the restart.
+//
+// Example:
+//
+//	go func() { _ = consumer.Run(context.Background()) }()
+//	<-sigterm
+//	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+//	defer cancel()
+//	if err := consumer.Shutdown(ctx); err != nil {
+//	    log.Errorf("kafka shutdown: %v", err)
+//	}
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	var err error
+	select {
+	case <-c.drain.stop():
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	c.drain.cancelRun()
+	if cerr := c.group.Close(); cerr != nil && err == nil {
+		err = fmt.Errorf("failed to close Kafka consumer group: %w", cerr)
+	}
+	return err
+}
+
+// isShutdownErr reports whether err from Consume was caused by Shutdown.
+func (c *Consumer) isShutdownErr(err error) bool {
+	return c.drain.draining() && errors.Is(err, sarama.ErrClosedConsumerGroup)
+}
@@ -0,0 +1,160 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGroup runs a single claim per Consume call, like a one-partition
+// assignment, and records Close.
+type fakeGroup struct {
+	claim *fakeClaim
+	sess  *committingSession
+
+	mu     sync.Mutex
+	closed bool
+	paused map[string][]int32
+	all    bool
+}
+
+func (g *fakeGroup) Consume(ctx context.Context, _ []string, handler sarama.ConsumerGroupHandler) error {
+	g.sess.ctx = ctx
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = handler.ConsumeClaim(g.sess, g.claim)
+	}()
+	<-ctx.Done()
+	<-done
+	return nil
+}
+
+func (g *fakeGroup) Errors() <-chan error { return nil }
+func (g *fakeGroup) Close() error {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+	return nil
+}
+func (g *fakeGroup) Pause(p map[string][]int32) { g.paused = p }
+func (g *fakeGroup) Resume(map[string][]int32)  { g.paused = nil }
+func (g *fakeGroup) PauseAll()                  { g.all = true }
+func (g *fakeGroup) ResumeAll()                 { g.all = false }
+
+func (g *fakeGroup) isClosed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.closed
+}
+
+// newShutdownTestConsumer returns a consumer whose handler signals started
+// and blocks until release is closed.
+func newShutdownTestConsumer() (c *Consumer, g *fakeGroup, started chan *sarama.ConsumerMessage, release chan struct{}) {
+	started = make(chan *sarama.ConsumerMessage, 10)
+	release = make(chan struct{})
+	g = &fakeGroup{
+		claim: &fakeClaim{topic: "topic", messages: make(chan *sarama.ConsumerMessage, 10)},
+		sess:  &committingSession{},
+	}
+	c = &Consumer{
+		group: g,
+		drain: newDrainState(),
+		handler: MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+			started <- msg
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}),
+	}
+	return c, g, started, release
+}
+
+func TestShutdown_DrainsInFlightMessage(t *testing.T) {
+	c, g, started, release := newShutdownTestConsumer()
+	g.claim.messages <- &sarama.ConsumerMessage{Topic: "topic", Offset: 7}
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- c.Run(context.Background()) }()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- c.Shutdown(context.Background()) }()
+
+	select {
+	case <-shutdownErr:
+		t.Fatal("Shutdown returned before the in-flight handler finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Messages arriving after Shutdown are not claimed.
+	g.claim.messages <- &sarama.ConsumerMessage{Topic: "topic", Offset: 8}
+	close(release)
+
+	require.NoError(t, <-shutdownErr)
+	require.NoError(t, <-runErr)
+	assert.True(t, g.isClosed())
+	assert.Equal(t, []int64{7}, g.sess.marked)
+	assert.Equal(t, 1, g.sess.commits)
+	assert.Len(t, started, 0)
+}
+
+func TestShutdown_ContextExpires(t *testing.T) {
+	c, g, started, _ := newShutdownTestConsumer()
+	g.claim.messages <- &sarama.ConsumerMessage{Topic: "topic", Offset: 1}
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- c.Run(context.Background()) }()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.Shutdown(ctx), context.DeadlineExceeded)
+	require.NoError(t, <-runErr)
+	assert.True(t, g.isClosed())
+	assert.Empty(t, g.sess.marked)
+}
+
+func TestShutdown_Concurrent(t *testing.T) {
+	c, g, started, release := newShutdownTestConsumer()
+	c.opts = consumerOptions{concurrency: 4}
+	for i := int64(0); i < 3; i++ {
+		g.claim.messages <- &sarama.ConsumerMessage{Topic: "topic", Offset: i}
+	}
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- c.Run(context.Background()) }()
+	<-started
+
+	close(release)
+	require.NoError(t, c.Shutdown(context.Background()))
+	require.NoError(t, <-runErr)
+	assert.NotEmpty(t, g.sess.marked)
+	assert.Equal(t, 1, g.sess.commits)
+}
+
+func TestDrainState_NilNeverDrains(t *testing.T) {
+	var d *drainState
+	assert.True(t, d.enter())
+	assert.False(t, d.draining())
+	d.exit()
+}
+
+func TestDrainState_StopWithoutClaims(t *testing.T) {
+	d := newDrainState()
+	select {
+	case <-d.stop():
+	default:
+		t.Fatal("idle channel not closed without active claims")
+	}
+	assert.False(t, d.enter())
+	assert.True(t, d.draining())
+}
@@ -0,0 +1,203 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/IBM/sarama"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans created by this package.
+const tracerName = "github.com/ranorsolutions/http-common-go/pkg/messaging/kafka"
+
+// TracingOption configures ProducerTracing and ConsumerTracing.
+type TracingOption func(*tracingConfig)
+
+type tracingConfig struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+// WithTracerProvider sets the tracer provider. Defaults to the global one.
+func WithTracerProvider(tp trace.TracerProvider) TracingOption {
+	return func(c *tracingConfig) { c.provider = tp }
+}
+
+// WithPropagator sets the propagator used for message headers. Defaults to
+// W3C Trace Context, matching the traceparent header of the logger
+// middleware.
+func WithPropagator(p propagation.TextMapPropagator) TracingOption {
+	return func(c *tracingConfig) { c.propagator = p }
+}
+
+func newTracingConfig(opts []TracingOption) tracingConfig {
+	cfg := tracingConfig{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.TraceContext{},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// ProducerTracing returns a producer interceptor that wraps every send in a
+// producer span and injects its context into the message headers. Without
+// an active span in ctx, the span continues the trace of the traceparent
+// taken from the request metadata, so sends made from a Gin handler join
+// the request's trace.
+//
+// Example:
+//
+//	producer.Use(kafka.ProducerTracing())
+func ProducerTracing(opts ...TracingOption) ProducerInterceptor {
+	cfg := newTracingConfig(opts)
+	tracer := cfg.provider.Tracer(tracerName)
+
+	return func(ctx context.Context, msg *sarama.ProducerMessage, next SendFunc) error {
+		carrier := producerCarrier{msg}
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			ctx = cfg.propagator.Extract(ctx, carrier)
+		}
+
+		ctx, span := tracer.Start(ctx, "send "+msg.Topic,
+			trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(messagingAttributes("send", msg.Topic)...),
+		)
+		defer span.End()
+		if msg.Key != nil {
+			if key, err := msg.Key.Encode(); err == nil {
+				span.SetAttributes(attribute.String("messaging.kafka.message.key", string(key)))
+			}
+		}
+
+		cfg.propagator.Inject(ctx, carrier)
+		sc := span.SpanContext()
+		carrier.Set(HeaderTraceID, sc.TraceID().String())
+		carrier.Set(HeaderSpanID, sc.SpanID().String())
+
+		if err := next(ctx, msg); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+		span.SetAttributes(
+			attribute.String("messaging.destination.partition.id", strconv.Itoa(int(msg.Partition))),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		)
+		return nil
+	}
+}
+
+// ConsumerTracing returns a consumer interceptor that handles each message
+// in a consumer span. The span is a child of the producer span found in the
+// message headers, so a request and the asynchronous work it triggers show
+// up as one trace. The request metadata in the handler context is updated
+// to the new span, keeping logs and onward sends correlated.
+//
+// Example:
+//
+//	consumer, err := kafka.NewConsumer(cfg, "billing", topics, handler,
+//	    kafka.WithInterceptors(kafka.ConsumerTracing()))
+func ConsumerTracing(opts ...TracingOption) ConsumerInterceptor {
+	cfg := newTracingConfig(opts)
+	tracer := cfg.provider.Tracer(tracerName)
+
+	return func(ctx context.Context, msg *sarama.ConsumerMessage, next MessageHandlerFunc) error {
+		ctx = cfg.propagator.Extract(ctx, consumerCarrier{msg})
+		ctx, span := tracer.Start(ctx, "process "+msg.Topic,
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(messagingAttributes("process", msg.Topic)...),
+			trace.WithAttributes(
+				attribute.String("messaging.destination.partition.id", strconv.Itoa(int(msg.Partition))),
+				attribute.Int64("messaging.kafka.offset", msg.Offset),
+			),
+		)
+		defer span.End()
+		if len(msg.Key) > 0 {
+			span.SetAttributes(attribute.String("messaging.kafka.message.key", string(msg.Key)))
+		}
+
+		sc := span.SpanContext()
+		md := reqctx.RequestMetadataFromContext(ctx)
+		md.TraceID, md.SpanID = sc.TraceID().String(), sc.SpanID().String()
+		md.TraceParent = "00-" + md.TraceID + "-" + md.SpanID + "-" + sc.TraceFlags().String()
+		ctx = reqctx.WithRequestMetadata(ctx, md)
+
+		if err := next(ctx, msg); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+		return nil
+	}
+}
+
+// messagingAttributes returns the attributes shared by all Kafka spans.
+func messagingAttributes(operation, topic string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.operation.type", operation),
+		attribute.String("messaging.destination.name", topic),
+	}
+}
+
+// producerCarrier adapts producer message headers to a TextMapCarrier.
+type producerCarrier struct {
+	msg *sarama.ProducerMessage
+}
+
+func (c producerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c producerCarrier) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c producerCarrier) Keys() []string {
+	keys := make([]string, len(c.msg.Headers))
+	for i, h := range c.msg.Headers {
+		keys[i] = string(h.Key)
+	}
+	return keys
+}
+
+// consumerCarrier adapts consumed message headers to a read-only
+// TextMapCarrier.
+type consumerCarrier struct {
+	msg *sarama.ConsumerMessage
+}
+
+func (c consumerCarrier) Get(key string) string {
+	return Headers(c.msg)[key]
+}
+
+func (c consumerCarrier) Set(string, string) {}
+
+func (c consumerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		if h != nil {
+			keys = append(keys, string(h.Key))
+		}
+	}
+	return keys
+}
@@ -0,0 +1,102 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestTracerProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	rec := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)), rec
+}
+
+// toConsumerMessage converts a sent message into the one a consumer sees.
+func toConsumerMessage(msg *sarama.ProducerMessage) *sarama.ConsumerMessage {
+	out := &sarama.ConsumerMessage{Topic: msg.Topic, Partition: msg.Partition, Offset: msg.Offset}
+	for i := range msg.Headers {
+		out.Headers = append(out.Headers, &msg.Headers[i])
+	}
+	return out
+}
+
+func TestTracing_ProducerToConsumer(t *testing.T) {
+	tp, rec := newTestTracerProvider()
+
+	var sent *sarama.ProducerMessage
+	p := &Producer{}
+	p.Use(ProducerTracing(WithTracerProvider(tp)))
+	p.Use(func(ctx context.Context, msg *sarama.ProducerMessage, next SendFunc) error {
+		msg.Partition, msg.Offset = 2, 99
+		sent = msg
+		return nil
+	})
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	require.NoError(t, p.SendJSON(ctx, "orders", "o-1", map[string]int{"n": 1}))
+	parent.End()
+
+	var consumerCtx context.Context
+	handler := Chain(MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		consumerCtx = ctx
+		return nil
+	}), ConsumerTracing(WithTracerProvider(tp)))
+	require.NoError(t, handler.HandleMessage(context.Background(), toConsumerMessage(sent)))
+
+	spans := rec.Ended()
+	require.Len(t, spans, 3)
+	send, process := spans[0], spans[2]
+	assert.Equal(t, "send orders", send.Name())
+	assert.Equal(t, trace.SpanKindProducer, send.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), send.Parent().SpanID())
+
+	assert.Equal(t, "process orders", process.Name())
+	assert.Equal(t, trace.SpanKindConsumer, process.SpanKind())
+	assert.Equal(t, send.SpanContext().SpanID(), process.Parent().SpanID())
+	assert.Equal(t, parent.SpanContext().TraceID(), process.SpanContext().TraceID())
+
+	md := reqctx.RequestMetadataFromContext(consumerCtx)
+	assert.Equal(t, process.SpanContext().SpanID().String(), md.SpanID)
+	assert.Equal(t, "00-"+md.TraceID+"-"+md.SpanID+"-01", md.TraceParent)
+	assert.Equal(t, send.SpanContext().SpanID().String(), Headers(toConsumerMessage(sent))[HeaderSpanID])
+}
+
+func TestTracing_ProducerContinuesRequestMetadata(t *testing.T) {
+	tp, rec := newTestTracerProvider()
+	p := &Producer{}
+	p.Use(ProducerTracing(WithTracerProvider(tp)))
+	p.Use(func(ctx context.Context, msg *sarama.ProducerMessage, next SendFunc) error { return nil })
+
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{
+		TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+	require.NoError(t, p.SendJSON(ctx, "orders", "", 1))
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
+
+func TestTracing_RecordsErrors(t *testing.T) {
+	tp, rec := newTestTracerProvider()
+
+	handler := Chain(MessageHandlerFunc(func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		return errors.New("boom")
+	}), ConsumerTracing(WithTracerProvider(tp)))
+	assert.Error(t, handler.HandleMessage(context.Background(), &sarama.ConsumerMessage{Topic: "orders"}))
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.False(t, spans[0].Parent().IsValid())
+}
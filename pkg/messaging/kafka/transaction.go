@@ -0,0 +1,102 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotTransactional is returned by WithinTransaction when the producer
+// was created without ProducerConfig.TransactionalID.
+var ErrNotTransactional = errors.New("kafka producer is not transactional")
+
+// TxProducer publishes messages inside a transaction. Messages sent through
+// it become visible to read-committed consumers only if the transaction
+// commits.
+type TxProducer interface {
+	SendJSON(ctx context.Context, topic string, key string, value any) error
+	SendJSONWithHeaders(ctx context.Context, topic string, key string, value any, headers map[string]string) error
+	SendProto(ctx context.Context, topic string, key string, value proto.Message) error
+	SendAvro(ctx context.Context, topic string, key string, value any) error
+
+	// AddConsumed commits msg's offset for groupID as part of the
+	// transaction, for consume-transform-produce pipelines. The consumer
+	// must then not commit the offset itself (use CommitManual).
+	AddConsumed(msg *sarama.ConsumerMessage, groupID string) error
+}
+
+// txProducer sends through the underlying producer while a transaction is
+// open.
+type txProducer struct {
+	*Producer
+}
+
+func (tx txProducer) AddConsumed(msg *sarama.ConsumerMessage, groupID string) error {
+	if err := tx.producer.AddMessageToTxn(msg, groupID, nil); err != nil {
+		return fmt.Errorf("failed to add offset to transaction: %w", err)
+	}
+	return nil
+}
+
+// WithinTransaction runs fn inside a producer transaction. The transaction
+// commits if fn returns nil and aborts if fn returns an error or panics.
+// Transactions on one Producer are serialized; sends made outside
+// WithinTransaction on a transactional producer fail.
+//
+// If the commit fails with a fatal error the producer must be closed and
+// recreated.
+//
+// Example:
+//
+//	// consume-transform-produce with exactly-once semantics
+//	err := producer.WithinTransaction(ctx, func(tx kafka.TxProducer) error {
+//	    if err := tx.SendJSON(ctx, "invoices", order.ID, invoiceFor(order)); err != nil {
+//	        return err
+//	    }
+//	    return tx.AddConsumed(msg, "billing")
+//	})
+func (p *Producer) WithinTransaction(ctx context.Context, fn func(tx TxProducer) error) (err error) {
+	if !p.producer.IsTransactional() {
+		return ErrNotTransactional
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	p.txMu.Lock()
+	defer p.txMu.Unlock()
+
+	if err := p.producer.BeginTxn(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	finished := false
+	defer func() {
+		if finished {
+			return
+		}
+		if abortErr := p.producer.AbortTxn(); abortErr != nil && err != nil {
+			err = fmt.Errorf("%w (abort failed: %v)", err, abortErr)
+		}
+	}()
+
+	if err := fn(txProducer{p}); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := p.producer.CommitTxn(); err != nil {
+		if p.producer.TxnStatus()&sarama.ProducerTxnFlagAbortableError == 0 {
+			// The error is fatal; there is nothing left to abort.
+			finished = true
+		}
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	finished = true
+	return nil
+}
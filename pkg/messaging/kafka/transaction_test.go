@@ -0,0 +1,134 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// txnRecorder counts transaction calls on top of the Sarama mock.
+type txnRecorder struct {
+	*mocks.SyncProducer
+	begins, commits, aborts int
+	consumed                []int64
+	commitErr               error
+}
+
+func (r *txnRecorder) BeginTxn() error {
+	r.begins++
+	return r.SyncProducer.BeginTxn()
+}
+
+func (r *txnRecorder) CommitTxn() error {
+	r.commits++
+	if r.commitErr != nil {
+		return r.commitErr
+	}
+	return r.SyncProducer.CommitTxn()
+}
+
+func (r *txnRecorder) AbortTxn() error {
+	r.aborts++
+	return r.SyncProducer.AbortTxn()
+}
+
+func (r *txnRecorder) TxnStatus() sarama.ProducerTxnStatusFlag {
+	if r.commitErr != nil {
+		return sarama.ProducerTxnFlagInError | sarama.ProducerTxnFlagAbortableError
+	}
+	return r.SyncProducer.TxnStatus()
+}
+
+func (r *txnRecorder) AddMessageToTxn(msg *sarama.ConsumerMessage, groupID string, metadata *string) error {
+	r.consumed = append(r.consumed, msg.Offset)
+	return nil
+}
+
+func newTxnProducer(t *testing.T) (*Producer, *txnRecorder) {
+	t.Helper()
+	cfg := mocks.NewTestConfig()
+	cfg.Version = sarama.V2_8_0_0
+	require.NoError(t, ProducerConfig{TransactionalID: "billing-0"}.apply(cfg))
+	rec := &txnRecorder{SyncProducer: mocks.NewSyncProducer(t, cfg)}
+	t.Cleanup(func() { _ = rec.Close() })
+	return &Producer{producer: rec}, rec
+}
+
+func TestWithinTransaction_Commits(t *testing.T) {
+	p, rec := newTxnProducer(t)
+	rec.ExpectSendMessageAndSucceed()
+
+	err := p.WithinTransaction(context.Background(), func(tx TxProducer) error {
+		if err := tx.SendJSON(context.Background(), "invoices", "o-1", map[string]int{"total": 10}); err != nil {
+			return err
+		}
+		return tx.AddConsumed(&sarama.ConsumerMessage{Topic: "orders", Offset: 41}, "billing")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, rec.begins)
+	assert.Equal(t, 1, rec.commits)
+	assert.Equal(t, 0, rec.aborts)
+	assert.Equal(t, []int64{41}, rec.consumed)
+}
+
+func TestWithinTransaction_AbortsOnError(t *testing.T) {
+	p, rec := newTxnProducer(t)
+
+	err := p.WithinTransaction(context.Background(), func(tx TxProducer) error {
+		return errors.New("transform failed")
+	})
+	assert.EqualError(t, err, "transform failed")
+	assert.Equal(t, 0, rec.commits)
+	assert.Equal(t, 1, rec.aborts)
+}
+
+func TestWithinTransaction_AbortsOnPanic(t *testing.T) {
+	p, rec := newTxnProducer(t)
+
+	assert.Panics(t, func() {
+		_ = p.WithinTransaction(context.Background(), func(tx TxProducer) error {
+			panic("boom")
+		})
+	})
+	assert.Equal(t, 1, rec.aborts)
+}
+
+func TestWithinTransaction_AbortableCommitError(t *testing.T) {
+	p, rec := newTxnProducer(t)
+	rec.commitErr = sarama.ErrProducerFenced
+
+	err := p.WithinTransaction(context.Background(), func(tx TxProducer) error { return nil })
+	assert.ErrorIs(t, err, sarama.ErrProducerFenced)
+	assert.Equal(t, 1, rec.aborts)
+}
+
+func TestWithinTransaction_NotTransactional(t *testing.T) {
+	mockProducer := mocks.NewSyncProducer(t, nil)
+	defer func() { _ = mockProducer.Close() }()
+	p := &Producer{producer: mockProducer}
+
+	err := p.WithinTransaction(context.Background(), func(tx TxProducer) error { return nil })
+	assert.ErrorIs(t, err, ErrNotTransactional)
+}
+
+func TestProducerConfig_Transactional(t *testing.T) {
+	sc := sarama.NewConfig()
+	require.NoError(t, ProducerConfig{TransactionalID: "billing-0"}.apply(sc))
+	assert.Equal(t, "billing-0", sc.Producer.Transaction.ID)
+	assert.True(t, sc.Producer.Idempotent)
+	assert.Equal(t, 1, sc.Net.MaxOpenRequests)
+
+	assert.Error(t, ProducerConfig{TransactionalID: "billing-0", Acks: "leader"}.apply(sarama.NewConfig()))
+	assert.Error(t, ProducerConfig{TransactionalID: "billing-0", MaxRetries: -1}.apply(sarama.NewConfig()))
+}
+
+func TestConsumerConfig_ReadCommitted(t *testing.T) {
+	sc := sarama.NewConfig()
+	require.NoError(t, ConsumerConfig{ReadCommitted: true}.apply(sc))
+	assert.Equal(t, sarama.ReadCommitted, sc.Consumer.IsolationLevel)
+}
@@ -0,0 +1,88 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKafkaSender records SendBytes calls.
+type fakeKafkaSender struct {
+	topic, key string
+	value      []byte
+	headers    map[string]string
+	closed     bool
+}
+
+func (f *fakeKafkaSender) SendBytes(_ context.Context, topic, key string, value []byte, headers map[string]string) error {
+	f.topic, f.key, f.value, f.headers = topic, key, value, headers
+	return nil
+}
+
+func (f *fakeKafkaSender) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	sender := &fakeKafkaSender{}
+	p := &KafkaPublisher{producer: sender}
+
+	require.NoError(t, PublishJSON(context.Background(), p, "orders", "o-1", 42, nil))
+	assert.Equal(t, "orders", sender.topic)
+	assert.Equal(t, "o-1", sender.key)
+	assert.Equal(t, "42", string(sender.value))
+	assert.Equal(t, ContentTypeJSON, sender.headers[HeaderContentType])
+
+	require.NoError(t, p.Close())
+	assert.True(t, sender.closed)
+}
+
+func TestKafkaPublisher_PublishAfter(t *testing.T) {
+	var sent []*sarama.ProducerMessage
+	producer := &kafka.Producer{}
+	producer.Use(func(_ context.Context, msg *sarama.ProducerMessage, _ kafka.SendFunc) error {
+		sent = append(sent, msg)
+		return nil
+	})
+
+	msg := &Message{Topic: "orders", Key: "o-1", Payload: []byte("expire")}
+	assert.ErrorIs(t, NewKafkaPublisher(producer).PublishAfter(context.Background(), time.Minute, msg), ErrDelayUnsupported)
+
+	p := NewKafkaPublisher(producer, WithDelayTopology(kafka.NewDelayTopology("scheduled", time.Minute)))
+	require.NoError(t, PublishAfter(context.Background(), p, time.Minute, msg))
+	require.Len(t, sent, 1)
+	assert.Equal(t, "scheduled.delay.1m0s", sent[0].Topic)
+}
+
+func TestKafkaHandler(t *testing.T) {
+	var got *Message
+	h := kafkaHandler{HandlerFunc(func(_ context.Context, msg *Message) error {
+		got = msg
+		return nil
+	})}
+
+	err := h.HandleMessage(context.Background(), &sarama.ConsumerMessage{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    42,
+		Key:       []byte("o-1"),
+		Value:     []byte(`{"id":"o-1"}`),
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("tenant"), Value: []byte("acme")},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &Message{
+		ID:      "orders/2/42",
+		Topic:   "orders",
+		Key:     "o-1",
+		Payload: []byte(`{"id":"o-1"}`),
+		Headers: map[string]string{"tenant": "acme"},
+	}, got)
+}
@@ -0,0 +1,157 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/smithy-go"
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+)
+
+// DefaultDataKeyReuse is how long a data key generated by KMS encrypts
+// messages when created from the environment.
+const DefaultDataKeyReuse = 5 * time.Minute
+
+// maxCachedDataKeys bounds the decrypted data keys kept by KMSKeyProvider.
+const maxCachedDataKeys = 1000
+
+// KMSAPI defines the subset of kms.Client methods we use.
+// This makes it mockable in tests.
+type KMSAPI interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// KMSKeyProvider generates and decrypts data keys with an AWS KMS key.
+// Decrypted data keys are cached, so consumers call KMS once per data key
+// rather than once per message.
+type KMSKeyProvider struct {
+	api   KMSAPI
+	keyID string
+	reuse time.Duration
+
+	mu        sync.Mutex
+	current   *kmsDataKey
+	decrypted map[string][]byte
+}
+
+// kmsDataKey is a generated data key and when it was generated.
+type kmsDataKey struct {
+	key, encrypted []byte
+	keyID          string
+	created        time.Time
+}
+
+// NewKMSKeyProvider returns a provider generating data keys with the KMS key
+// keyID (a key ID, ARN or alias). Each data key encrypts messages for reuse;
+// zero generates a key per message, which costs a KMS call per message.
+func NewKMSKeyProvider(api KMSAPI, keyID string, reuse time.Duration) *KMSKeyProvider {
+	return &KMSKeyProvider{api: api, keyID: keyID, reuse: reuse, decrypted: make(map[string][]byte)}
+}
+
+// newKMSKeyProviderFromEnv creates a KMS provider for keyID in AWS_REGION,
+// reusing data keys for MESSAGING_ENCRYPTION_KEY_REUSE (default
+// DefaultDataKeyReuse).
+func newKMSKeyProviderFromEnv(keyID string) (*KMSKeyProvider, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		return nil, fmt.Errorf("AWS_REGION is required")
+	}
+	reuse := DefaultDataKeyReuse
+	if v := os.Getenv("MESSAGING_ENCRYPTION_KEY_REUSE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid MESSAGING_ENCRYPTION_KEY_REUSE %q", v)
+		}
+		reuse = d
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	return NewKMSKeyProvider(kms.NewFromConfig(awsCfg), keyID, reuse), nil
+}
+
+// DataKey implements KeyProvider. The returned key ID is the ARN of the KMS
+// key.
+func (p *KMSKeyProvider) DataKey(ctx context.Context) ([]byte, []byte, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if dk := p.current; dk != nil && time.Since(dk.created) < p.reuse {
+		return dk.key, dk.encrypted, dk.keyID, nil
+	}
+
+	out, err := p.api.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(p.keyID),
+		KeySpec: types.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, nil, "", wrapKMSError("generate data key", err)
+	}
+	dk := &kmsDataKey{key: out.Plaintext, encrypted: out.CiphertextBlob, keyID: aws.ToString(out.KeyId), created: time.Now()}
+	if p.reuse > 0 {
+		p.current = dk
+	}
+	return dk.key, dk.encrypted, dk.keyID, nil
+}
+
+// DecryptKey implements KeyProvider.
+func (p *KMSKeyProvider) DecryptKey(ctx context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	cacheKey := keyID + "\x00" + string(encrypted)
+	p.mu.Lock()
+	key, ok := p.decrypted[cacheKey]
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	input := &kms.DecryptInput{CiphertextBlob: encrypted}
+	if keyID != "" {
+		input.KeyId = aws.String(keyID)
+	}
+	out, err := p.api.Decrypt(ctx, input)
+	if err != nil {
+		return nil, wrapKMSError("decrypt data key", err)
+	}
+
+	p.mu.Lock()
+	if len(p.decrypted) >= maxCachedDataKeys {
+		clear(p.decrypted)
+	}
+	p.decrypted[cacheKey] = out.Plaintext
+	p.mu.Unlock()
+	return out.Plaintext, nil
+}
+
+// kmsTransientCodes are the KMS error codes worth retrying.
+var kmsTransientCodes = map[string]bool{
+	"ThrottlingException":        true,
+	"KMSInternalException":       true,
+	"DependencyTimeoutException": true,
+	"KeyUnavailableException":    true,
+}
+
+// wrapKMSError classifies a failed KMS call of op: throttling and transient
+// failures, including unreachable endpoints, are errcode.Unavailable so
+// that consumers retry the message. Other API errors, such as an invalid
+// ciphertext, are left unclassified.
+func wrapKMSError(op string, err error) error {
+	err = fmt.Errorf("%s failed: %w", op, err)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && !kmsTransientCodes[apiErr.ErrorCode()] {
+		return err
+	}
+	return errcode.Wrap(err, errcode.Unavailable, "")
+}
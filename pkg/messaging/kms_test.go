@@ -0,0 +1,125 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/smithy-go"
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKeyARN = "arn:aws:kms:us-east-1:123456789012:key/test"
+
+// mockKMSClient fakes KMSAPI by "encrypting" data keys with a prefix.
+type mockKMSClient struct {
+	generated, decrypted int
+	err                  error
+}
+
+func (m *mockKMSClient) GenerateDataKey(_ context.Context, input *kms.GenerateDataKeyInput, _ ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if input.KeySpec != types.DataKeySpecAes256 {
+		return nil, errors.New("unexpected key spec")
+	}
+	m.generated++
+	key := make([]byte, 32)
+	key[0] = byte(m.generated)
+	return &kms.GenerateDataKeyOutput{
+		KeyId:          aws.String(testKeyARN),
+		Plaintext:      key,
+		CiphertextBlob: append([]byte("wrapped:"), key...),
+	}, nil
+}
+
+func (m *mockKMSClient) Decrypt(_ context.Context, input *kms.DecryptInput, _ ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.decrypted++
+	return &kms.DecryptOutput{KeyId: input.KeyId, Plaintext: input.CiphertextBlob[len("wrapped:"):]}, nil
+}
+
+func TestKMSKeyProvider_RoundTrip(t *testing.T) {
+	api := &mockKMSClient{}
+	keys := NewKMSKeyProvider(api, "alias/messaging", time.Hour)
+	rec := &recordingPublisher{}
+	pub := NewEncryptingPublisher(rec, keys)
+
+	for range 3 {
+		require.NoError(t, pub.Publish(context.Background(), &Message{Topic: "t", Payload: []byte("secret")}))
+	}
+	assert.Equal(t, 1, api.generated, "data key is reused")
+	assert.Equal(t, testKeyARN, rec.published[0].Headers[HeaderEncryptionKeyID])
+
+	consumer := NewKMSKeyProvider(api, "", 0)
+	handler := NewDecryptingHandler(consumer, HandlerFunc(func(_ context.Context, msg *Message) error {
+		assert.Equal(t, "secret", string(msg.Payload))
+		return nil
+	}))
+	for _, msg := range rec.published {
+		require.NoError(t, handler.Handle(context.Background(), msg))
+	}
+	assert.Equal(t, 1, api.decrypted, "decrypted data key is cached")
+}
+
+func TestKMSKeyProvider_NoReuse(t *testing.T) {
+	api := &mockKMSClient{}
+	keys := NewKMSKeyProvider(api, "alias/messaging", 0)
+	for range 2 {
+		_, _, _, err := keys.DataKey(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, api.generated)
+}
+
+func TestKMSKeyProvider_Errors(t *testing.T) {
+	tests := map[string]struct {
+		err       error
+		retryable bool
+	}{
+		"throttled":   {&smithy.GenericAPIError{Code: "ThrottlingException"}, true},
+		"unreachable": {errors.New("dial tcp: connection refused"), true},
+		"invalid":     {&smithy.GenericAPIError{Code: "InvalidCiphertextException"}, false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			keys := NewKMSKeyProvider(&mockKMSClient{err: tt.err}, "alias/messaging", time.Hour)
+			_, _, _, err := keys.DataKey(context.Background())
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.retryable, errcode.IsRetryable(err))
+
+			_, err = keys.DecryptKey(context.Background(), testKeyARN, []byte("wrapped:x"))
+			assert.ErrorContains(t, err, "decrypt data key failed")
+			assert.Equal(t, tt.retryable, errcode.IsRetryable(err))
+		})
+	}
+
+	keys := NewKMSKeyProvider(&mockKMSClient{err: context.Canceled}, "alias/messaging", 0)
+	_, err := keys.DecryptKey(context.Background(), testKeyARN, nil)
+	assert.Equal(t, errcode.Canceled, errcode.Of(err))
+}
+
+func TestNewKMSKeyProviderFromEnv(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	_, err := newKMSKeyProviderFromEnv("alias/messaging")
+	assert.ErrorContains(t, err, "AWS_REGION is required")
+
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("MESSAGING_ENCRYPTION_KEY_REUSE", "-1s")
+	_, err = newKMSKeyProviderFromEnv("alias/messaging")
+	assert.ErrorContains(t, err, "invalid MESSAGING_ENCRYPTION_KEY_REUSE")
+
+	t.Setenv("MESSAGING_ENCRYPTION_KEY_REUSE", "")
+	keys, err := newKMSKeyProviderFromEnv("alias/messaging")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultDataKeyReuse, keys.reuse)
+}
@@ -0,0 +1,324 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+)
+
+// ErrBusClosed is returned by MemoryBus.Publish after Close.
+var ErrBusClosed = errors.New("message bus closed")
+
+// MemoryBus is an in-process Publisher and Subscriber, for unit tests and
+// single-binary deployments. Every subscription receives every message
+// published to its topics while it is subscribed; messages published to a
+// topic without subscribers are dropped.
+//
+// By default delivery is synchronous: Publish runs the handlers before it
+// returns and returns their errors. WithBuffer makes delivery asynchronous.
+type MemoryBus struct {
+	buffer      int
+	maxAttempts int
+	onError     func(ctx context.Context, msg *Message, err error)
+
+	mu       sync.Mutex
+	subs     map[string][]*memorySubscription
+	faults   []memoryFault
+	timers   map[*time.Timer]struct{}
+	closed   bool
+	closedCh chan struct{}
+}
+
+// MemoryOption configures a MemoryBus.
+type MemoryOption func(*MemoryBus)
+
+// WithBuffer queues up to size messages per subscription and delivers them
+// in order from the goroutine running Subscribe. Publish blocks while a
+// queue is full. Queued messages are dropped when the subscription ends.
+func WithBuffer(size int) MemoryOption {
+	return func(b *MemoryBus) {
+		if size > 0 {
+			b.buffer = size
+		}
+	}
+}
+
+// WithMaxAttempts sets how many times a failed delivery is attempted,
+// including the first (default 1).
+func WithMaxAttempts(n int) MemoryOption {
+	return func(b *MemoryBus) {
+		if n > 0 {
+			b.maxAttempts = n
+		}
+	}
+}
+
+// WithDeliveryErrorHandler is called with messages whose delivery failed
+// after every attempt. Failures are otherwise only visible to Publish in
+// synchronous mode.
+func WithDeliveryErrorHandler(fn func(ctx context.Context, msg *Message, err error)) MemoryOption {
+	return func(b *MemoryBus) {
+		b.onError = fn
+	}
+}
+
+// NewMemoryBus returns an empty bus.
+//
+// Example:
+//
+//	bus := messaging.NewMemoryBus()
+//	go bus.Subscribe(ctx, []string{"orders"}, handler)
+//	err := messaging.PublishJSON(ctx, bus, "orders", "o-1", order, nil)
+func NewMemoryBus(opts ...MemoryOption) *MemoryBus {
+	b := &MemoryBus{
+		maxAttempts: 1,
+		subs:        map[string][]*memorySubscription{},
+		timers:      map[*time.Timer]struct{}{},
+		closedCh:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// memorySubscription is one Subscribe call.
+type memorySubscription struct {
+	handler Handler
+	queue   chan *Message // nil in synchronous mode
+	done    chan struct{} // closed when Subscribe returns
+}
+
+// memoryFault is an injected failure of the next remaining publishes or
+// deliveries of topic ("" for any topic).
+type memoryFault struct {
+	delivery  bool
+	topic     string
+	remaining int
+	err       error
+}
+
+// FailPublish makes the next n Publish calls to topic ("" for any topic)
+// fail with err without delivering the message.
+func (b *MemoryBus) FailPublish(topic string, n int, err error) {
+	b.addFault(memoryFault{topic: topic, remaining: n, err: err})
+}
+
+// FailDelivery makes the next n delivery attempts of messages of topic (""
+// for any topic) fail with err, as if the handler had returned it.
+func (b *MemoryBus) FailDelivery(topic string, n int, err error) {
+	b.addFault(memoryFault{delivery: true, topic: topic, remaining: n, err: err})
+}
+
+func (b *MemoryBus) addFault(f memoryFault) {
+	if f.remaining <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.faults = append(b.faults, f)
+}
+
+// takeFault consumes one injected failure matching topic, if any.
+func (b *MemoryBus) takeFault(delivery bool, topic string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := range b.faults {
+		f := &b.faults[i]
+		if f.delivery != delivery || (f.topic != "" && f.topic != topic) {
+			continue
+		}
+		err := f.err
+		if f.remaining--; f.remaining == 0 {
+			b.faults = slices.Delete(b.faults, i, i+1)
+		}
+		return err
+	}
+	return nil
+}
+
+// Subscribers returns the number of active subscriptions to topic, e.g. to
+// wait for a Subscribe goroutine before publishing in tests.
+func (b *MemoryBus) Subscribers(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs[topic])
+}
+
+// Publish implements Publisher. Correlation IDs found in ctx are added to the
+// headers, and handlers receive their own copy of the message.
+func (b *MemoryBus) Publish(ctx context.Context, msg *Message) error {
+	b.mu.Lock()
+	closed := b.closed
+	subs := slices.Clone(b.subs[msg.Topic])
+	b.mu.Unlock()
+	if closed {
+		return ErrBusClosed
+	}
+	if err := b.takeFault(false, msg.Topic); err != nil {
+		return err
+	}
+
+	m := &Message{
+		ID:      uuid.NewString(),
+		Topic:   msg.Topic,
+		Key:     msg.Key,
+		Payload: slices.Clone(msg.Payload),
+		Headers: correlation.Merge(ctx, msg.Headers),
+	}
+
+	if b.buffer == 0 {
+		var errs []error
+		for _, sub := range subs {
+			if err := b.deliver(ctx, sub.handler, m); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	for _, sub := range subs {
+		select {
+		case sub.queue <- m:
+		case <-sub.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.closedCh:
+			return ErrBusClosed
+		}
+	}
+	return nil
+}
+
+// PublishAfter implements DelayedPublisher. The message is published from a
+// timer once delay elapsed, to the subscriptions active at that time; failed
+// deliveries are reported to the delivery error handler. Close drops the
+// messages still pending.
+func (b *MemoryBus) PublishAfter(ctx context.Context, delay time.Duration, msg *Message) error {
+	m := copyMessage(msg)
+	m.Headers = correlation.Merge(ctx, msg.Headers)
+	pctx := context.WithoutCancel(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrBusClosed
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		b.mu.Lock()
+		delete(b.timers, timer)
+		b.mu.Unlock()
+		_ = b.Publish(pctx, m)
+	})
+	b.timers[timer] = struct{}{}
+	return nil
+}
+
+// deliver passes a copy of m to handler, retrying up to maxAttempts times.
+func (b *MemoryBus) deliver(ctx context.Context, handler Handler, m *Message) error {
+	hctx := correlation.Context(ctx, m.Headers)
+	var err error
+	for range b.maxAttempts {
+		if err = b.takeFault(true, m.Topic); err == nil {
+			err = handler.Handle(hctx, copyMessage(m))
+		}
+		if err == nil {
+			return nil
+		}
+	}
+	if b.onError != nil {
+		b.onError(hctx, copyMessage(m), err)
+	}
+	return err
+}
+
+// Subscribe implements Subscriber. It returns nil when ctx is canceled or the
+// bus is closed.
+func (b *MemoryBus) Subscribe(ctx context.Context, topics []string, handler Handler) error {
+	sub := &memorySubscription{handler: handler, done: make(chan struct{})}
+	if b.buffer > 0 {
+		sub.queue = make(chan *Message, b.buffer)
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	for _, topic := range topics {
+		b.subs[topic] = append(b.subs[topic], sub)
+	}
+	b.mu.Unlock()
+	defer b.unsubscribe(topics, sub)
+
+	for {
+		select {
+		case m := <-sub.queue:
+			_ = b.deliver(ctx, handler, m)
+		case <-ctx.Done():
+			return nil
+		case <-b.closedCh:
+			return nil
+		}
+	}
+}
+
+func (b *MemoryBus) unsubscribe(topics []string, sub *memorySubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	close(sub.done)
+	for _, topic := range topics {
+		b.subs[topic] = slices.DeleteFunc(b.subs[topic], func(s *memorySubscription) bool { return s == sub })
+		if len(b.subs[topic]) == 0 {
+			delete(b.subs, topic)
+		}
+	}
+}
+
+// Close implements Publisher and Subscriber. It ends every subscription and
+// drops delayed messages; later publishes fail with ErrBusClosed.
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.closedCh)
+		for timer := range b.timers {
+			timer.Stop()
+		}
+		clear(b.timers)
+	}
+	return nil
+}
+
+func copyMessage(m *Message) *Message {
+	return &Message{
+		ID:      m.ID,
+		Topic:   m.Topic,
+		Key:     m.Key,
+		Payload: slices.Clone(m.Payload),
+		Headers: maps.Clone(m.Headers),
+	}
+}
+
+// sharedMemoryBus is the process-wide bus returned for the memory backend.
+// Closing it is a no-op, so closing one publisher does not end the
+// subscriptions of others.
+type sharedMemoryBus struct {
+	*MemoryBus
+}
+
+func (sharedMemoryBus) Close() error {
+	return nil
+}
+
+var defaultMemoryBus = sync.OnceValue(func() *MemoryBus {
+	return NewMemoryBus()
+})
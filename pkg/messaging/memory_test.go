@@ -0,0 +1,221 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// subscribe runs bus.Subscribe in the background until the test ends and
+// waits for the subscription to be registered.
+func subscribe(t *testing.T, bus *MemoryBus, topic string, handler Handler) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	before := bus.Subscribers(topic)
+	go func() {
+		defer close(done)
+		assert.NoError(t, bus.Subscribe(ctx, []string{topic}, handler))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	require.Eventually(t, func() bool { return bus.Subscribers(topic) > before }, time.Second, time.Millisecond)
+}
+
+// collector records handled messages.
+type collector struct {
+	mu       sync.Mutex
+	messages []*Message
+	ctxs     []context.Context
+}
+
+func (c *collector) Handle(ctx context.Context, msg *Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, msg)
+	c.ctxs = append(c.ctxs, ctx)
+	return nil
+}
+
+func (c *collector) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.messages)
+}
+
+func TestMemoryBus_SynchronousFanOut(t *testing.T) {
+	bus := NewMemoryBus()
+	a, b := &collector{}, &collector{}
+	subscribe(t, bus, "orders", a)
+	subscribe(t, bus, "orders", b)
+
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1"})
+	require.NoError(t, PublishJSON(ctx, bus, "orders", "o-1", map[string]string{"id": "o-1"}, nil))
+	require.NoError(t, bus.Publish(ctx, &Message{Topic: "payments"}), "no subscribers")
+
+	for _, c := range []*collector{a, b} {
+		require.Equal(t, 1, c.len(), "delivered before Publish returns")
+		msg := c.messages[0]
+		assert.Equal(t, "orders", msg.Topic)
+		assert.Equal(t, "o-1", msg.Key)
+		assert.JSONEq(t, `{"id":"o-1"}`, string(msg.Payload))
+		assert.Equal(t, "req-1", msg.Headers[HeaderRequestID])
+		assert.Equal(t, "req-1", reqctx.RequestMetadataFromContext(c.ctxs[0]).RequestID)
+	}
+
+	a.messages[0].Headers["tenant"] = "changed"
+	assert.NotContains(t, b.messages[0].Headers, "tenant", "handlers get their own copy")
+}
+
+func TestMemoryBus_SynchronousHandlerError(t *testing.T) {
+	var failures []error
+	bus := NewMemoryBus(WithMaxAttempts(2), WithDeliveryErrorHandler(func(_ context.Context, _ *Message, err error) {
+		failures = append(failures, err)
+	}))
+	attempts := 0
+	subscribe(t, bus, "orders", HandlerFunc(func(context.Context, *Message) error {
+		attempts++
+		return errors.New("boom")
+	}))
+
+	err := bus.Publish(context.Background(), &Message{Topic: "orders"})
+	assert.ErrorContains(t, err, "boom")
+	assert.Equal(t, 2, attempts)
+	assert.Len(t, failures, 1)
+}
+
+func TestMemoryBus_Buffered(t *testing.T) {
+	bus := NewMemoryBus(WithBuffer(10))
+	c := &collector{}
+	subscribe(t, bus, "orders", c)
+
+	for _, key := range []string{"1", "2", "3"} {
+		require.NoError(t, bus.Publish(context.Background(), &Message{Topic: "orders", Key: key}))
+	}
+	require.Eventually(t, func() bool { return c.len() == 3 }, time.Second, time.Millisecond)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, key := range []string{"1", "2", "3"} {
+		assert.Equal(t, key, c.messages[i].Key, "delivered in order")
+	}
+}
+
+func TestMemoryBus_BufferedFullQueue(t *testing.T) {
+	bus := NewMemoryBus(WithBuffer(1))
+	release := make(chan struct{})
+	subscribe(t, bus, "orders", HandlerFunc(func(context.Context, *Message) error {
+		<-release
+		return nil
+	}))
+	defer close(release)
+
+	// The first message is being handled, the second fills the queue.
+	require.NoError(t, bus.Publish(context.Background(), &Message{Topic: "orders"}))
+	require.NoError(t, bus.Publish(context.Background(), &Message{Topic: "orders"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, bus.Publish(ctx, &Message{Topic: "orders"}), context.DeadlineExceeded)
+}
+
+func TestMemoryBus_FailureInjection(t *testing.T) {
+	errDown := errors.New("broker down")
+	bus := NewMemoryBus(WithMaxAttempts(3))
+	c := &collector{}
+	subscribe(t, bus, "orders", c)
+
+	bus.FailPublish("orders", 1, errDown)
+	assert.ErrorIs(t, bus.Publish(context.Background(), &Message{Topic: "orders"}), errDown)
+	assert.Equal(t, 0, c.len())
+	require.NoError(t, bus.Publish(context.Background(), &Message{Topic: "orders"}))
+	assert.Equal(t, 1, c.len())
+
+	bus.FailPublish("payments", 1, errDown)
+	require.NoError(t, bus.Publish(context.Background(), &Message{Topic: "orders"}), "other topics are unaffected")
+
+	// Two failed attempts are retried; three exhaust the attempts.
+	bus.FailDelivery("", 2, errDown)
+	require.NoError(t, bus.Publish(context.Background(), &Message{Topic: "orders"}))
+	assert.Equal(t, 3, c.len())
+
+	bus.FailDelivery("orders", 3, errDown)
+	assert.ErrorIs(t, bus.Publish(context.Background(), &Message{Topic: "orders"}), errDown)
+	assert.Equal(t, 3, c.len())
+}
+
+func TestMemoryBus_Close(t *testing.T) {
+	bus := NewMemoryBus()
+	done := make(chan error)
+	go func() {
+		done <- bus.Subscribe(context.Background(), []string{"orders"}, &collector{})
+	}()
+	require.Eventually(t, func() bool { return bus.Subscribers("orders") == 1 }, time.Second, time.Millisecond)
+
+	require.NoError(t, bus.Close())
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe did not return after Close")
+	}
+	assert.ErrorIs(t, bus.Publish(context.Background(), &Message{Topic: "orders"}), ErrBusClosed)
+	assert.NoError(t, bus.Close())
+}
+
+func TestMemoryBus_PublishAfter(t *testing.T) {
+	bus := NewMemoryBus()
+	c := &collector{}
+	subscribe(t, bus, "orders", c)
+
+	ctx, cancel := context.WithCancel(reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1"}))
+	start := time.Now()
+	require.NoError(t, PublishAfter(ctx, bus, 50*time.Millisecond, &Message{Topic: "orders", Payload: []byte("later")}))
+	cancel()
+	assert.Zero(t, c.len(), "delivery is deferred")
+
+	require.Eventually(t, func() bool { return c.len() == 1 }, time.Second, time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, "later", string(c.messages[0].Payload))
+	assert.Equal(t, "req-1", reqctx.RequestMetadataFromContext(c.ctxs[0]).RequestID, "correlation survives the canceled context")
+}
+
+func TestMemoryBus_PublishAfterClose(t *testing.T) {
+	bus := NewMemoryBus()
+	c := &collector{}
+	subscribe(t, bus, "orders", c)
+
+	require.NoError(t, bus.PublishAfter(context.Background(), 20*time.Millisecond, &Message{Topic: "orders"}))
+	require.NoError(t, bus.Close())
+	time.Sleep(50 * time.Millisecond)
+	assert.Zero(t, c.len(), "pending messages are dropped")
+	assert.ErrorIs(t, bus.PublishAfter(context.Background(), time.Millisecond, &Message{Topic: "orders"}), ErrBusClosed)
+}
+
+func TestFromEnv_MemoryBackend(t *testing.T) {
+	t.Setenv("MESSAGING_BACKEND", BackendMemory)
+
+	pub, err := NewPublisherFromEnv()
+	require.NoError(t, err)
+	sub, err := NewSubscriberFromEnv("")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := &collector{}
+	go func() { _ = sub.Subscribe(ctx, []string{"env-orders"}, c) }()
+	require.Eventually(t, func() bool { return defaultMemoryBus().Subscribers("env-orders") == 1 }, time.Second, time.Millisecond)
+
+	require.NoError(t, pub.Publish(ctx, &Message{Topic: "env-orders"}))
+	require.NoError(t, pub.Close(), "closing does not end the shared bus")
+	require.NoError(t, pub.Publish(ctx, &Message{Topic: "env-orders"}))
+	assert.Equal(t, 2, c.len())
+}
@@ -0,0 +1,193 @@
+// Package messaging defines transport-agnostic Publisher and Subscriber
+// interfaces with adapters for the kafka, sns, sqs, pubsub and redisstream
+// packages, so services can pick a transport through configuration. Use the
+// transport packages directly for features the abstraction does not cover,
+// such as Kafka transactions or SNS batch publishing.
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+)
+
+// Header names shared with the transport packages. The correlation headers
+// carry the request metadata of the publishing context.
+const (
+	HeaderContentType = "content-type"
+	HeaderRequestID   = correlation.RequestID
+	HeaderTraceID     = correlation.TraceID
+	HeaderSpanID      = correlation.SpanID
+	HeaderTraceParent = correlation.TraceParent
+	HeaderTraceState  = correlation.TraceState
+
+	// HeaderMessageID is an ID assigned by the application, e.g. an event
+	// ID. Set it to let NewDedupHandler recognize messages published twice.
+	HeaderMessageID = "message-id"
+)
+
+// ContentTypeJSON is set in HeaderContentType by PublishJSON.
+const ContentTypeJSON = "application/json"
+
+// Backends accepted by NewPublisherFromEnv and NewSubscriberFromEnv.
+const (
+	BackendKafka  = "kafka"
+	BackendSNS    = "sns"
+	BackendSQS    = "sqs"
+	BackendMemory = "memory"
+	BackendPubSub = "pubsub"
+	BackendRedis  = "redis"
+)
+
+// ErrUnknownBackend is returned for an unsupported MESSAGING_BACKEND.
+var ErrUnknownBackend = errors.New("unknown messaging backend")
+
+// Message is a transport-agnostic message.
+type Message struct {
+	// ID is the transport's ID of a received message, kept across
+	// redeliveries: "<topic>/<partition>/<offset>" for Kafka, the SQS
+	// message ID (or the SNS one of an envelope), the Pub/Sub message ID,
+	// the Redis entry ID or an ID generated by the MemoryBus. Publishers
+	// ignore it.
+	ID string
+
+	// Topic is the Kafka topic, SNS topic ARN, SQS queue URL, Pub/Sub
+	// topic or Redis stream. For received SQS messages it is the queue URL
+	// (or the topic ARN of an SNS envelope), for Pub/Sub messages the
+	// subscription.
+	Topic string
+
+	// Key orders messages: it is the Kafka message key, the SNS and SQS
+	// FIFO message group and the Pub/Sub ordering key (when ordering is
+	// enabled). Redis streams store it with the entry.
+	Key string
+
+	Payload []byte
+	Headers map[string]string
+}
+
+// Publisher sends messages. Correlation IDs found in ctx are propagated by
+// every implementation.
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+	Close() error
+}
+
+// Handler processes received messages. Returning an error leaves the
+// message to be redelivered as the transport defines.
+type Handler interface {
+	Handle(ctx context.Context, msg *Message) error
+}
+
+// HandlerFunc adapts a function to Handler.
+type HandlerFunc func(ctx context.Context, msg *Message) error
+
+// Handle implements Handler.
+func (f HandlerFunc) Handle(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// Subscriber receives messages.
+type Subscriber interface {
+	// Subscribe passes the messages of topics to handler until ctx is
+	// canceled, then returns nil.
+	Subscribe(ctx context.Context, topics []string, handler Handler) error
+	Close() error
+}
+
+// PublishJSON marshals value as JSON and publishes it to topic, tagged with
+// ContentTypeJSON.
+func PublishJSON(ctx context.Context, p Publisher, topic, key string, value any, headers map[string]string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	withType := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		withType[k] = v
+	}
+	if _, ok := withType[HeaderContentType]; !ok {
+		withType[HeaderContentType] = ContentTypeJSON
+	}
+	return p.Publish(ctx, &Message{Topic: topic, Key: key, Payload: data, Headers: withType})
+}
+
+// DecodeJSON unmarshals the payload of msg into out.
+func DecodeJSON(msg *Message, out any) error {
+	if err := json.Unmarshal(msg.Payload, out); err != nil {
+		return fmt.Errorf("failed to decode JSON message: %w", err)
+	}
+	return nil
+}
+
+// backendFromEnv returns the MESSAGING_BACKEND setting, defaulting to kafka.
+func backendFromEnv() string {
+	if b := strings.ToLower(strings.TrimSpace(os.Getenv("MESSAGING_BACKEND"))); b != "" {
+		return b
+	}
+	return BackendKafka
+}
+
+// NewPublisherFromEnv creates the publisher selected by MESSAGING_BACKEND:
+// "kafka" (default, configured by kafka.NewConfigFromEnv and
+// kafka.NewDelayTopologyFromEnv), "sns" (configured by
+// sns.NewConfigFromEnv), "sqs" (sending to queue URLs, configured by
+// sqs.NewConfigFromEnv), "pubsub" (configured by pubsub.NewConfigFromEnv),
+// "redis" (configured by redisstream.NewConfigFromEnv) or "memory" (the
+// in-process bus shared by every memory publisher and subscriber of the
+// binary).
+//
+// Example:
+//
+//	pub, err := messaging.NewPublisherFromEnv()
+//	if err != nil {
+//	    return err
+//	}
+//	defer pub.Close()
+//	err = messaging.PublishJSON(ctx, pub, topic, order.ID, OrderCreated{ID: order.ID}, nil)
+func NewPublisherFromEnv() (Publisher, error) {
+	switch b := backendFromEnv(); b {
+	case BackendKafka:
+		return newKafkaPublisherFromEnv()
+	case BackendSNS:
+		return newSNSPublisherFromEnv()
+	case BackendSQS:
+		return newSQSPublisherFromEnv()
+	case BackendPubSub:
+		return newPubSubPublisherFromEnv()
+	case BackendRedis:
+		return newRedisPublisherFromEnv()
+	case BackendMemory:
+		return sharedMemoryBus{defaultMemoryBus()}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, b)
+	}
+}
+
+// NewSubscriberFromEnv creates the subscriber selected by MESSAGING_BACKEND:
+// "kafka" (default), consuming as consumer group group, "sns" or "sqs",
+// receiving from SQS queues (configured by sqs.NewConfigFromEnv; topics are
+// then queue URLs and group is unused), "pubsub" (topics are subscription
+// IDs and group is unused), "redis" (topics are streams consumed as
+// consumer group group) or "memory" (group is unused).
+func NewSubscriberFromEnv(group string) (Subscriber, error) {
+	switch b := backendFromEnv(); b {
+	case BackendKafka:
+		return newKafkaSubscriberFromEnv(group)
+	case BackendSNS, BackendSQS:
+		return newSQSSubscriberFromEnv()
+	case BackendPubSub:
+		return newPubSubSubscriberFromEnv()
+	case BackendRedis:
+		return newRedisSubscriberFromEnv(group)
+	case BackendMemory:
+		return sharedMemoryBus{defaultMemoryBus()}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, b)
+	}
+}
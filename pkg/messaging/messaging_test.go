@@ -0,0 +1,94 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPublisher records published messages.
+type recordingPublisher struct {
+	published []*Message
+	err       error
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, msg *Message) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, msg)
+	return nil
+}
+
+func (p *recordingPublisher) Close() error { return nil }
+
+func TestPublishJSON(t *testing.T) {
+	p := &recordingPublisher{}
+	headers := map[string]string{"tenant": "acme"}
+
+	err := PublishJSON(context.Background(), p, "orders", "o-1", map[string]string{"id": "o-1"}, headers)
+	require.NoError(t, err)
+
+	require.Len(t, p.published, 1)
+	msg := p.published[0]
+	assert.Equal(t, "orders", msg.Topic)
+	assert.Equal(t, "o-1", msg.Key)
+	assert.JSONEq(t, `{"id":"o-1"}`, string(msg.Payload))
+	assert.Equal(t, map[string]string{"tenant": "acme", HeaderContentType: ContentTypeJSON}, msg.Headers)
+	assert.NotContains(t, headers, HeaderContentType, "caller headers are not modified")
+
+	var out map[string]string
+	require.NoError(t, DecodeJSON(msg, &out))
+	assert.Equal(t, "o-1", out["id"])
+}
+
+func TestPublishJSON_Errors(t *testing.T) {
+	err := PublishJSON(context.Background(), &recordingPublisher{}, "orders", "", make(chan int), nil)
+	assert.ErrorContains(t, err, "marshal")
+
+	err = PublishJSON(context.Background(), &recordingPublisher{err: errors.New("down")}, "orders", "", 1, nil)
+	assert.ErrorContains(t, err, "down")
+
+	assert.Error(t, DecodeJSON(&Message{Payload: []byte("{")}, &struct{}{}))
+}
+
+func TestFromEnv_UnknownBackend(t *testing.T) {
+	t.Setenv("MESSAGING_BACKEND", "carrier-pigeon")
+
+	_, err := NewPublisherFromEnv()
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+	_, err = NewSubscriberFromEnv("billing")
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+}
+
+func TestFromEnv_SelectsBackend(t *testing.T) {
+	t.Setenv("MESSAGING_BACKEND", "SNS")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	pub, err := NewPublisherFromEnv()
+	require.NoError(t, err)
+	assert.IsType(t, &SNSPublisher{}, pub)
+
+	sub, err := NewSubscriberFromEnv("")
+	require.NoError(t, err)
+	assert.IsType(t, &SQSSubscriber{}, sub)
+
+	t.Setenv("MESSAGING_BACKEND", "sqs")
+	pub, err = NewPublisherFromEnv()
+	require.NoError(t, err)
+	assert.IsType(t, &SQSPublisher{}, pub)
+
+	t.Setenv("MESSAGING_BACKEND", "")
+	t.Setenv("KAFKA_BROKERS", "localhost:9092")
+	sub, err = NewSubscriberFromEnv("billing")
+	require.NoError(t, err)
+	assert.IsType(t, &KafkaSubscriber{}, sub)
+
+	_, err = NewSubscriberFromEnv("")
+	assert.Error(t, err, "kafka requires a consumer group")
+}
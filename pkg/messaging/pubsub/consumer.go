@@ -0,0 +1,88 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+)
+
+// Consumer receives messages from a Pub/Sub subscription and hands them to
+// a MessageHandler.
+type Consumer struct {
+	client  *pubsub.Client
+	sub     *pubsub.Subscriber
+	handler MessageHandler
+}
+
+// NewConsumer creates a consumer for cfg.Subscription using application
+// default credentials.
+//
+// Example:
+//
+//	consumer, err := pubsub.NewConsumer(cfg, pubsub.MessageHandlerFunc(func(ctx context.Context, msg *gpubsub.Message) error {
+//	    var ev OrderCreated
+//	    if err := json.Unmarshal(msg.Data, &ev); err != nil {
+//	        return err
+//	    }
+//	    return svc.OnOrderCreated(ctx, ev)
+//	}))
+//	go consumer.Run(ctx)
+func NewConsumer(cfg *Config, handler MessageHandler) (*Consumer, error) {
+	if cfg.Subscription == "" {
+		return nil, fmt.Errorf("subscription is required")
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newConsumer(client, cfg, handler), nil
+}
+
+func newConsumer(client *pubsub.Client, cfg *Config, handler MessageHandler) *Consumer {
+	sub := client.Subscriber(cfg.Subscription)
+	s := &sub.ReceiveSettings
+	if cfg.MaxOutstandingMessages > 0 {
+		s.MaxOutstandingMessages = cfg.MaxOutstandingMessages
+	}
+	if cfg.MaxOutstandingBytes > 0 {
+		s.MaxOutstandingBytes = cfg.MaxOutstandingBytes
+	}
+	if cfg.MaxExtension > 0 {
+		s.MaxExtension = cfg.MaxExtension
+	}
+	s.MinDurationPerAckExtension = cfg.MinAckExtension
+	s.MaxDurationPerAckExtension = cfg.MaxAckExtension
+	return &Consumer{client: client, sub: sub, handler: handler}
+}
+
+// Run receives and handles messages until ctx is canceled, then returns
+// nil. Messages are handled concurrently within the flow control limits of
+// Config, and their ack deadlines are extended while handlers are running,
+// up to Config.MaxExtension. On subscriptions with message ordering
+// enabled, messages with the same ordering key are handled one at a time.
+func (c *Consumer) Run(ctx context.Context) error {
+	err := c.sub.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+		if c.handler.HandleMessage(correlation.Context(ctx, msg.Attributes), msg) != nil {
+			msg.Nack()
+			return
+		}
+		msg.Ack()
+	})
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("receive failed: %w", err)
+	}
+	return nil
+}
+
+// Close closes the client. Call it after Run returned.
+func (c *Consumer) Close() error {
+	if err := c.client.Close(); err != nil {
+		return fmt.Errorf("failed to close Pub/Sub client: %w", err)
+	}
+	return nil
+}
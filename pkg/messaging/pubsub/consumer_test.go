@@ -0,0 +1,79 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConsumer_RequiresSubscription(t *testing.T) {
+	_, err := NewConsumer(&Config{ProjectID: testProject}, MessageHandlerFunc(func(context.Context, *pubsub.Message) error {
+		return nil
+	}))
+	assert.ErrorContains(t, err, "subscription is required")
+}
+
+func TestConsumer_Run(t *testing.T) {
+	srv, client := newTestClient(t, false)
+	okID := srv.Publish(testTopic, []byte("ok"), map[string]string{AttributeRequestID: "req-1"})
+	failID := srv.Publish(testTopic, []byte("fail"), nil)
+
+	var (
+		mu        sync.Mutex
+		requestID string
+	)
+	c := newConsumer(client, &Config{Subscription: testSub, MaxOutstandingMessages: 5}, MessageHandlerFunc(func(ctx context.Context, msg *pubsub.Message) error {
+		if string(msg.Data) == "fail" {
+			return errors.New("transient")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		requestID = reqctx.RequestMetadataFromContext(ctx).RequestID
+		return nil
+	}))
+	assert.Equal(t, 5, c.sub.ReceiveSettings.MaxOutstandingMessages)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- c.Run(ctx) }()
+
+	// Successful messages are acked, failed ones nacked (deadline 0).
+	require.Eventually(t, func() bool {
+		var acked, nacked bool
+		for _, m := range srv.Messages() {
+			switch m.ID {
+			case okID:
+				acked = m.Acks == 1
+			case failID:
+				for _, mod := range m.Modacks {
+					nacked = nacked || mod.AckDeadline == 0
+				}
+			}
+		}
+		return acked && nacked
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-result)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "req-1", requestID)
+}
+
+func TestConsumer_RunMissingSubscription(t *testing.T) {
+	_, client := newTestClient(t, false)
+	c := newConsumer(client, &Config{Subscription: "projects/test-project/subscriptions/missing"}, MessageHandlerFunc(func(context.Context, *pubsub.Message) error {
+		return nil
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.ErrorContains(t, c.Run(ctx), "receive failed")
+}
@@ -0,0 +1,136 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"cloud.google.com/go/pubsub/v2"
+)
+
+// Producer publishes messages to Pub/Sub topics. Publishers are created per
+// topic on first use and batch concurrent publishes.
+type Producer struct {
+	client   *pubsub.Client
+	topic    string
+	ordering bool
+
+	mu         sync.Mutex
+	publishers map[string]*pubsub.Publisher
+}
+
+// NewProducer creates a producer using application default credentials.
+// cfg.Topic is used when a publish is given no topic.
+func NewProducer(cfg *Config) (*Producer, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newProducer(client, cfg), nil
+}
+
+func newProducer(client *pubsub.Client, cfg *Config) *Producer {
+	return &Producer{
+		client:     client,
+		topic:      cfg.Topic,
+		ordering:   cfg.EnableOrdering,
+		publishers: map[string]*pubsub.Publisher{},
+	}
+}
+
+// publisher returns the cached publisher of topic.
+func (p *Producer) publisher(topic string) *pubsub.Publisher {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pub, ok := p.publishers[topic]
+	if !ok {
+		pub = p.client.Publisher(topic)
+		pub.EnableMessageOrdering = p.ordering
+		p.publishers[topic] = pub
+	}
+	return pub
+}
+
+// OrderingEnabled reports whether publishes may set an ordering key.
+func (p *Producer) OrderingEnabled() bool {
+	return p.ordering
+}
+
+// Publish sends data to topic (the configured topic if empty) and waits for
+// the server to accept it, returning the message ID. orderingKey requires
+// Config.EnableOrdering and may be empty. The request_id, trace_id, span_id,
+// traceparent and tracestate set by the logger middleware are read from ctx
+// and added as attributes unless attrs already defines them.
+//
+// After a failed publish with an ordering key, Pub/Sub pauses the key to
+// keep messages in order; Publish resumes it so the caller can retry.
+func (p *Producer) Publish(ctx context.Context, topic, orderingKey string, data []byte, attrs map[string]string) (string, error) {
+	if topic == "" {
+		topic = p.topic
+	}
+	if topic == "" {
+		return "", fmt.Errorf("topic is required")
+	}
+	if orderingKey != "" && !p.ordering {
+		return "", fmt.Errorf("ordering key %q requires message ordering to be enabled", orderingKey)
+	}
+
+	pub := p.publisher(topic)
+	id, err := pub.Publish(ctx, &pubsub.Message{
+		Data:        data,
+		Attributes:  buildAttributes(ctx, attrs),
+		OrderingKey: orderingKey,
+	}).Get(ctx)
+	if err != nil {
+		if orderingKey != "" {
+			pub.ResumePublish(orderingKey)
+		}
+		return "", fmt.Errorf("publish failed: %w", err)
+	}
+	return id, nil
+}
+
+// PublishJSON marshals value as JSON and publishes it to topic (the
+// configured topic if empty), returning the message ID.
+//
+// Example:
+//
+//	id, err := producer.PublishJSON(ctx, "", order.CustomerID, OrderCreated{ID: order.ID})
+func (p *Producer) PublishJSON(ctx context.Context, topic, orderingKey string, value any) (string, error) {
+	return p.PublishJSONWithAttributes(ctx, topic, orderingKey, value, nil)
+}
+
+// PublishJSONWithAttributes publishes a JSON-encoded message with custom
+// attributes, tagged with ContentTypeJSON unless attrs sets
+// AttributeContentType.
+func (p *Producer) PublishJSONWithAttributes(ctx context.Context, topic, orderingKey string, value any, attrs map[string]string) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if _, ok := attrs[AttributeContentType]; !ok {
+		withType := make(map[string]string, len(attrs)+1)
+		for k, v := range attrs {
+			withType[k] = v
+		}
+		withType[AttributeContentType] = ContentTypeJSON
+		attrs = withType
+	}
+	return p.Publish(ctx, topic, orderingKey, data, attrs)
+}
+
+// Close flushes pending messages, stops the publishers and closes the
+// client.
+func (p *Producer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, pub := range p.publishers {
+		pub.Stop()
+	}
+	p.publishers = map[string]*pubsub.Publisher{}
+	if err := p.client.Close(); err != nil {
+		return fmt.Errorf("failed to close Pub/Sub client: %w", err)
+	}
+	return nil
+}
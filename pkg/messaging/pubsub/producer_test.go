@@ -0,0 +1,64 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProducer_PublishJSON(t *testing.T) {
+	srv, client := newTestClient(t, false)
+	p := newProducer(client, &Config{Topic: testTopic})
+	defer p.Close()
+
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1"})
+	id, err := p.PublishJSON(ctx, "", "", map[string]string{"id": "o-1"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+
+	msgs := srv.Messages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, id, msgs[0].ID)
+	assert.JSONEq(t, `{"id":"o-1"}`, string(msgs[0].Data))
+	assert.Equal(t, map[string]string{
+		AttributeRequestID:   "req-1",
+		AttributeContentType: ContentTypeJSON,
+	}, msgs[0].Attributes)
+}
+
+func TestProducer_OrderingKey(t *testing.T) {
+	srv, client := newTestClient(t, true)
+
+	unordered := newProducer(client, &Config{Topic: testTopic})
+	_, err := unordered.Publish(context.Background(), "", "customer-1", []byte("x"), nil)
+	assert.ErrorContains(t, err, "ordering")
+	assert.False(t, unordered.OrderingEnabled())
+
+	p := newProducer(client, &Config{EnableOrdering: true})
+	defer p.Close()
+	assert.True(t, p.OrderingEnabled())
+	_, err = p.Publish(context.Background(), testTopic, "customer-1", []byte("x"), map[string]string{"tenant": "acme"})
+	require.NoError(t, err)
+
+	msgs := srv.Messages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "customer-1", msgs[0].OrderingKey)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, msgs[0].Attributes)
+}
+
+func TestProducer_Errors(t *testing.T) {
+	_, client := newTestClient(t, false)
+	p := newProducer(client, &Config{})
+
+	_, err := p.Publish(context.Background(), "", "", []byte("x"), nil)
+	assert.ErrorContains(t, err, "topic is required")
+
+	_, err = p.PublishJSON(context.Background(), testTopic, "", make(chan int))
+	assert.ErrorContains(t, err, "marshal")
+
+	_, err = p.Publish(context.Background(), "projects/test-project/topics/missing", "", []byte("x"), nil)
+	assert.ErrorContains(t, err, "publish failed")
+}
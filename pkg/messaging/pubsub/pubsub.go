@@ -0,0 +1,180 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/env"
+	"google.golang.org/api/option"
+)
+
+// Attribute names used to propagate request correlation IDs between
+// services. They match the Kafka header and SQS attribute names.
+const (
+	AttributeRequestID   = correlation.RequestID
+	AttributeTraceID     = correlation.TraceID
+	AttributeSpanID      = correlation.SpanID
+	AttributeTraceParent = correlation.TraceParent
+	AttributeTraceState  = correlation.TraceState
+
+	// AttributeContentType identifies the data encoding.
+	AttributeContentType = "content-type"
+)
+
+// ContentTypeJSON is set in AttributeContentType by PublishJSON.
+const ContentTypeJSON = "application/json"
+
+// Config holds Pub/Sub connection, publisher and subscriber settings.
+type Config struct {
+	ProjectID string
+
+	// Topic is the default topic ID or full name
+	// ("projects/<project>/topics/<topic>") to publish to.
+	Topic string
+
+	// Subscription is the subscription ID or full name to receive from.
+	Subscription string
+
+	// EnableOrdering lets publishers set ordering keys. Messages with the
+	// same key are delivered in order to subscriptions with message
+	// ordering enabled.
+	EnableOrdering bool
+
+	// MaxOutstandingMessages and MaxOutstandingBytes bound the messages a
+	// consumer holds unacknowledged; receiving pauses at either limit
+	// (client defaults, 1000 messages and 1GB, when zero).
+	MaxOutstandingMessages int
+	MaxOutstandingBytes    int
+
+	// MaxExtension bounds how long the ack deadline of a message is
+	// extended while its handler is running (client default, 60m, when
+	// zero). The message is redelivered afterwards.
+	MaxExtension time.Duration
+
+	// MinAckExtension and MaxAckExtension bound each ack deadline
+	// extension, between 10s and 600s. Zero lets the client derive it from
+	// observed processing times.
+	MinAckExtension time.Duration
+	MaxAckExtension time.Duration
+
+	// Endpoint overrides the Pub/Sub endpoint, e.g. a regional endpoint
+	// such as "us-east1-pubsub.googleapis.com:443".
+	Endpoint string
+}
+
+// NewConfigFromEnv builds configuration from environment variables:
+//
+//	GOOGLE_CLOUD_PROJECT             required
+//	PUBSUB_TOPIC                     default topic
+//	PUBSUB_SUBSCRIPTION              subscription
+//	PUBSUB_ENABLE_ORDERING           bool
+//	PUBSUB_MAX_OUTSTANDING_MESSAGES  integer
+//	PUBSUB_MAX_OUTSTANDING_BYTES     integer
+//	PUBSUB_MAX_EXTENSION             duration, e.g. 10m
+//	PUBSUB_MIN_ACK_EXTENSION         duration, e.g. 30s
+//	PUBSUB_MAX_ACK_EXTENSION         duration, e.g. 2m
+//	PUBSUB_ENDPOINT                  endpoint override
+//
+// Credentials are found by the client library (application default
+// credentials, e.g. GOOGLE_APPLICATION_CREDENTIALS), which also connects to
+// the emulator at PUBSUB_EMULATOR_HOST when set.
+func NewConfigFromEnv() (*Config, error) {
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" {
+		return nil, fmt.Errorf("GOOGLE_CLOUD_PROJECT is required")
+	}
+
+	cfg := &Config{
+		ProjectID:    project,
+		Topic:        os.Getenv("PUBSUB_TOPIC"),
+		Subscription: os.Getenv("PUBSUB_SUBSCRIPTION"),
+		Endpoint:     os.Getenv("PUBSUB_ENDPOINT"),
+	}
+	var err error
+	if v := os.Getenv("PUBSUB_ENABLE_ORDERING"); v != "" {
+		if cfg.EnableOrdering, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid PUBSUB_ENABLE_ORDERING: %w", err)
+		}
+	}
+	if cfg.MaxOutstandingMessages, err = env.Int("PUBSUB_MAX_OUTSTANDING_MESSAGES"); err != nil {
+		return nil, err
+	}
+	if cfg.MaxOutstandingBytes, err = env.Int("PUBSUB_MAX_OUTSTANDING_BYTES"); err != nil {
+		return nil, err
+	}
+	if cfg.MaxExtension, err = env.Duration("PUBSUB_MAX_EXTENSION"); err != nil {
+		return nil, err
+	}
+	if cfg.MinAckExtension, err = env.Duration("PUBSUB_MIN_ACK_EXTENSION"); err != nil {
+		return nil, err
+	}
+	if cfg.MaxAckExtension, err = env.Duration("PUBSUB_MAX_ACK_EXTENSION"); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate rejects values the client would ignore or Pub/Sub would refuse.
+func (c *Config) validate() error {
+	if c.MaxOutstandingMessages < 0 || c.MaxOutstandingBytes < 0 || c.MaxExtension < 0 {
+		return fmt.Errorf("invalid Pub/Sub flow control settings: must not be negative")
+	}
+	for _, d := range []time.Duration{c.MinAckExtension, c.MaxAckExtension} {
+		if d != 0 && (d < 10*time.Second || d > 600*time.Second) {
+			return fmt.Errorf("invalid Pub/Sub ack extension %s: must be between 10s and 600s", d)
+		}
+	}
+	if c.MinAckExtension > 0 && c.MaxAckExtension > 0 && c.MinAckExtension > c.MaxAckExtension {
+		return fmt.Errorf("invalid Pub/Sub ack extension: minimum %s exceeds maximum %s", c.MinAckExtension, c.MaxAckExtension)
+	}
+	return nil
+}
+
+// newClient creates a Pub/Sub client using application default credentials,
+// applying the overrides set in cfg.
+func newClient(cfg *Config) (*pubsub.Client, error) {
+	var opts []option.ClientOption
+	if cfg.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(cfg.Endpoint))
+	}
+	client, err := pubsub.NewClient(context.Background(), cfg.ProjectID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pub/Sub client: %w", err)
+	}
+	return client, nil
+}
+
+// MessageHandler defines the signature for handling received messages.
+// The context carries the correlation IDs found in the message attributes.
+// Returning nil acknowledges the message; an error nacks it so Pub/Sub
+// redelivers it, subject to the subscription's retry and dead-letter
+// policies.
+type MessageHandler interface {
+	HandleMessage(ctx context.Context, msg *pubsub.Message) error
+}
+
+// MessageHandlerFunc adapts a function to MessageHandler.
+type MessageHandlerFunc func(ctx context.Context, msg *pubsub.Message) error
+
+// HandleMessage implements MessageHandler.
+func (f MessageHandlerFunc) HandleMessage(ctx context.Context, msg *pubsub.Message) error {
+	return f(ctx, msg)
+}
+
+// buildAttributes merges explicit attributes with correlation IDs from ctx.
+// It returns nil when there are none.
+func buildAttributes(ctx context.Context, attrs map[string]string) map[string]string {
+	merged := correlation.Merge(ctx, attrs)
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
@@ -0,0 +1,115 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/v2"
+	"cloud.google.com/go/pubsub/v2/apiv1/pubsubpb"
+	"cloud.google.com/go/pubsub/v2/pstest"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	testProject = "test-project"
+	testTopic   = "projects/test-project/topics/orders"
+	testSub     = "projects/test-project/subscriptions/billing"
+)
+
+// newTestClient starts an in-process Pub/Sub server with the orders topic
+// and the billing subscription, and returns a client connected to it.
+func newTestClient(t *testing.T, ordering bool) (*pstest.Server, *pubsub.Client) {
+	t.Helper()
+	srv := pstest.NewServer()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	client, err := pubsub.NewClient(context.Background(), testProject,
+		option.WithEndpoint(srv.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	_, err = client.TopicAdminClient.CreateTopic(ctx, &pubsubpb.Topic{Name: testTopic})
+	require.NoError(t, err)
+	_, err = client.SubscriptionAdminClient.CreateSubscription(ctx, &pubsubpb.Subscription{
+		Name:                  testSub,
+		Topic:                 testTopic,
+		AckDeadlineSeconds:    10,
+		EnableMessageOrdering: ordering,
+	})
+	require.NoError(t, err)
+	return srv, client
+}
+
+func TestNewConfigFromEnv(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	_, err := NewConfigFromEnv()
+	assert.ErrorContains(t, err, "GOOGLE_CLOUD_PROJECT")
+
+	t.Setenv("GOOGLE_CLOUD_PROJECT", testProject)
+	t.Setenv("PUBSUB_TOPIC", "orders")
+	t.Setenv("PUBSUB_SUBSCRIPTION", "billing")
+	t.Setenv("PUBSUB_ENABLE_ORDERING", "true")
+	t.Setenv("PUBSUB_MAX_OUTSTANDING_MESSAGES", "50")
+	t.Setenv("PUBSUB_MAX_OUTSTANDING_BYTES", "1048576")
+	t.Setenv("PUBSUB_MAX_EXTENSION", "10m")
+	t.Setenv("PUBSUB_MIN_ACK_EXTENSION", "30s")
+	t.Setenv("PUBSUB_MAX_ACK_EXTENSION", "2m")
+	cfg, err := NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		ProjectID:              testProject,
+		Topic:                  "orders",
+		Subscription:           "billing",
+		EnableOrdering:         true,
+		MaxOutstandingMessages: 50,
+		MaxOutstandingBytes:    1048576,
+		MaxExtension:           10 * time.Minute,
+		MinAckExtension:        30 * time.Second,
+		MaxAckExtension:        2 * time.Minute,
+	}, cfg)
+}
+
+func TestNewConfigFromEnv_Invalid(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", testProject)
+	for key, value := range map[string]string{
+		"PUBSUB_ENABLE_ORDERING":          "maybe",
+		"PUBSUB_MAX_OUTSTANDING_MESSAGES": "many",
+		"PUBSUB_MAX_OUTSTANDING_BYTES":    "-1",
+		"PUBSUB_MAX_EXTENSION":            "forever",
+		"PUBSUB_MIN_ACK_EXTENSION":        "5s",
+		"PUBSUB_MAX_ACK_EXTENSION":        "20m",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			_, err := NewConfigFromEnv()
+			assert.Error(t, err)
+		})
+	}
+
+	t.Setenv("PUBSUB_MIN_ACK_EXTENSION", "60s")
+	t.Setenv("PUBSUB_MAX_ACK_EXTENSION", "30s")
+	_, err := NewConfigFromEnv()
+	assert.ErrorContains(t, err, "exceeds")
+}
+
+func TestBuildAttributes(t *testing.T) {
+	assert.Nil(t, buildAttributes(context.Background(), nil))
+
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1", TraceID: "trace-1"})
+	attrs := buildAttributes(ctx, map[string]string{AttributeTraceID: "explicit", "tenant": "acme"})
+	assert.Equal(t, map[string]string{
+		AttributeRequestID: "req-1",
+		AttributeTraceID:   "explicit",
+		"tenant":           "acme",
+	}, attrs)
+}
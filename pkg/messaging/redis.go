@@ -0,0 +1,149 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/redisstream"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisSender is the part of *redisstream.Producer used by RedisPublisher.
+type redisSender interface {
+	Publish(ctx context.Context, stream, key string, payload []byte, headers map[string]string) (string, error)
+	PublishAfter(ctx context.Context, delay time.Duration, stream, key string, payload []byte, headers map[string]string) (string, error)
+}
+
+// RedisPublisher publishes to Redis streams through a redisstream.Producer.
+// Topics are stream names.
+type RedisPublisher struct {
+	producer redisSender
+	closer   io.Closer // set when created from the environment
+}
+
+// NewRedisPublisher adapts producer to Publisher. Closing it leaves the
+// producer's client open.
+func NewRedisPublisher(producer *redisstream.Producer) *RedisPublisher {
+	return &RedisPublisher{producer: producer}
+}
+
+func newRedisPublisherFromEnv() (Publisher, error) {
+	cfg, err := redisstream.NewConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	client := cfg.Redis.NewClient()
+	return &RedisPublisher{producer: redisstream.NewProducer(client, cfg), closer: client}, nil
+}
+
+// Publish implements Publisher.
+func (p *RedisPublisher) Publish(ctx context.Context, msg *Message) error {
+	_, err := p.producer.Publish(ctx, msg.Topic, msg.Key, msg.Payload, msg.Headers)
+	return err
+}
+
+// PublishAfter implements DelayedPublisher with the scheduled set of the
+// stream, which the stream's consumers move due entries from.
+func (p *RedisPublisher) PublishAfter(ctx context.Context, delay time.Duration, msg *Message) error {
+	_, err := p.producer.PublishAfter(ctx, delay, msg.Topic, msg.Key, msg.Payload, msg.Headers)
+	return err
+}
+
+// Close implements Publisher.
+func (p *RedisPublisher) Close() error {
+	if p.closer != nil {
+		return p.closer.Close()
+	}
+	return nil
+}
+
+// RedisSubscriber consumes Redis streams as a consumer group.
+type RedisSubscriber struct {
+	client  redis.UniversalClient
+	cfg     *redisstream.Config
+	groupID string
+	opts    []redisstream.ConsumerOption
+	closer  io.Closer // set when created from the environment
+}
+
+// NewRedisSubscriber returns a Subscriber consuming as groupID with client,
+// using cfg for every stream; its Stream and Group are replaced by the
+// topics passed to Subscribe and groupID. Closing it leaves client open.
+func NewRedisSubscriber(client redis.UniversalClient, cfg *redisstream.Config, groupID string, opts ...redisstream.ConsumerOption) *RedisSubscriber {
+	return &RedisSubscriber{client: client, cfg: cfg, groupID: groupID, opts: opts}
+}
+
+func newRedisSubscriberFromEnv(group string) (Subscriber, error) {
+	if group == "" {
+		return nil, fmt.Errorf("consumer group is required")
+	}
+	cfg, err := redisstream.NewConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	client := cfg.Redis.NewClient()
+	s := NewRedisSubscriber(client, cfg, group)
+	s.closer = client
+	return s, nil
+}
+
+// Subscribe implements Subscriber, running one consumer per stream. A
+// consumer failing, e.g. because its group cannot be created, stops the
+// others.
+func (s *RedisSubscriber) Subscribe(ctx context.Context, topics []string, handler Handler) error {
+	consumers := make([]*redisstream.Consumer, len(topics))
+	for i, stream := range topics {
+		cfg := *s.cfg
+		cfg.Stream = stream
+		cfg.Group = s.groupID
+		c, err := redisstream.NewConsumer(s.client, &cfg, redisHandler{handler}, s.opts...)
+		if err != nil {
+			return err
+		}
+		consumers[i] = c
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make([]error, len(consumers))
+	for i, c := range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[i] = c.Run(ctx); errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Close implements Subscriber. Consumers stop when the Subscribe context is
+// canceled.
+func (s *RedisSubscriber) Close() error {
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
+
+// redisHandler adapts a Handler to redisstream.MessageHandler.
+type redisHandler struct {
+	handler Handler
+}
+
+func (h redisHandler) HandleMessage(ctx context.Context, msg *redisstream.Message) error {
+	return h.handler.Handle(ctx, &Message{
+		ID:      msg.ID,
+		Topic:   msg.Stream,
+		Key:     msg.Key,
+		Payload: msg.Payload,
+		Headers: msg.Headers,
+	})
+}
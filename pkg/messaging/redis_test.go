@@ -0,0 +1,94 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/redisstream"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisPublisherSubscriber(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	cfg := &redisstream.Config{StartID: "0", Block: 10 * time.Millisecond}
+	pub := NewRedisPublisher(redisstream.NewProducer(client, cfg))
+	require.NoError(t, PublishJSON(context.Background(), pub, "orders", "o-1", map[string]string{"id": "o-1"}, map[string]string{"tenant": "acme"}))
+	require.NoError(t, pub.Close())
+	require.NoError(t, client.Ping(context.Background()).Err(), "client stays open")
+
+	received := make(chan *Message, 1)
+	sub := NewRedisSubscriber(client, cfg, "billing")
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- sub.Subscribe(ctx, []string{"orders"}, HandlerFunc(func(_ context.Context, msg *Message) error {
+			received <- msg
+			return nil
+		}))
+	}()
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "orders", msg.Topic)
+		assert.Equal(t, "o-1", msg.Key)
+		assert.JSONEq(t, `{"id":"o-1"}`, string(msg.Payload))
+		assert.Equal(t, map[string]string{"tenant": "acme", HeaderContentType: ContentTypeJSON}, msg.Headers)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not received")
+	}
+	cancel()
+	require.NoError(t, <-result)
+	require.NoError(t, sub.Close())
+}
+
+func TestRedisPublisher_PublishAfter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	cfg := &redisstream.Config{StartID: "0", Block: 10 * time.Millisecond, ScheduleInterval: 10 * time.Millisecond}
+	pub := NewRedisPublisher(redisstream.NewProducer(client, cfg))
+	require.NoError(t, PublishAfter(context.Background(), pub, 50*time.Millisecond, &Message{Topic: "orders", Key: "o-1", Payload: []byte("later")}))
+
+	received := make(chan *Message, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = NewRedisSubscriber(client, cfg, "billing").Subscribe(ctx, []string{"orders"}, HandlerFunc(func(_ context.Context, msg *Message) error {
+			received <- msg
+			return nil
+		}))
+	}()
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "o-1", msg.Key)
+		assert.Equal(t, "later", string(msg.Payload))
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled message was not received")
+	}
+}
+
+func TestFromEnv_RedisBackend(t *testing.T) {
+	t.Setenv("MESSAGING_BACKEND", BackendRedis)
+
+	_, err := NewSubscriberFromEnv("")
+	assert.ErrorContains(t, err, "consumer group is required")
+
+	sub, err := NewSubscriberFromEnv("billing")
+	require.NoError(t, err)
+	assert.IsType(t, &RedisSubscriber{}, sub)
+	assert.NoError(t, sub.Close())
+
+	pub, err := NewPublisherFromEnv()
+	require.NoError(t, err)
+	assert.IsType(t, &RedisPublisher{}, pub)
+	assert.NoError(t, pub.Close())
+}
@@ -0,0 +1,245 @@
+package redisstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+	"github.com/redis/go-redis/v9"
+)
+
+// readBackoff is the pause after a failed read.
+const readBackoff = time.Second
+
+// ConsumerOption configures a Consumer.
+type ConsumerOption func(*Consumer)
+
+// WithErrorHandler sets a function called with read, claim and acknowledge
+// errors. The consumer keeps running after such errors; by default they are
+// dropped. Handler errors are not reported here.
+func WithErrorHandler(fn func(error)) ConsumerOption {
+	return func(c *Consumer) { c.onError = fn }
+}
+
+// Consumer reads a stream as a member of a consumer group and hands the
+// entries to a MessageHandler.
+type Consumer struct {
+	client    redis.UniversalClient
+	stream    string
+	group     string
+	name      string
+	startID   string
+	batchSize int64
+	block     time.Duration
+	minIdle   time.Duration
+	maxLen    int64
+	schedule  time.Duration
+	handler   MessageHandler
+	onError   func(error)
+}
+
+// NewConsumer returns a consumer of cfg.Stream in cfg.Group using client.
+//
+// Example:
+//
+//	consumer, err := redisstream.NewConsumer(client, cfg, redisstream.MessageHandlerFunc(func(ctx context.Context, msg *redisstream.Message) error {
+//	    var ev OrderCreated
+//	    if err := json.Unmarshal(msg.Payload, &ev); err != nil {
+//	        return err
+//	    }
+//	    return svc.OnOrderCreated(ctx, ev)
+//	}))
+//	go consumer.Run(ctx)
+func NewConsumer(client redis.UniversalClient, cfg *Config, handler MessageHandler, opts ...ConsumerOption) (*Consumer, error) {
+	if cfg.Stream == "" {
+		return nil, fmt.Errorf("stream is required")
+	}
+	if cfg.Group == "" {
+		return nil, fmt.Errorf("consumer group is required")
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	c := &Consumer{
+		client:    client,
+		stream:    cfg.Stream,
+		group:     cfg.Group,
+		name:      cfg.Consumer,
+		startID:   cfg.StartID,
+		batchSize: cfg.BatchSize,
+		block:     cfg.Block,
+		minIdle:   cfg.MinIdle,
+		maxLen:    cfg.MaxLen,
+		schedule:  cfg.ScheduleInterval,
+		handler:   handler,
+	}
+	if c.name == "" {
+		if c.name, _ = os.Hostname(); c.name == "" {
+			c.name = "consumer"
+		}
+	}
+	if c.startID == "" {
+		c.startID = DefaultStartID
+	}
+	if c.batchSize == 0 {
+		c.batchSize = DefaultBatchSize
+	}
+	if c.block == 0 {
+		c.block = DefaultBlock
+	}
+	if c.minIdle == 0 {
+		c.minIdle = DefaultMinIdle
+	}
+	if c.schedule == 0 {
+		c.schedule = DefaultScheduleInterval
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Run creates the consumer group if needed, then reads and handles entries
+// until ctx is canceled, returning nil. Entries are handled in order, one
+// at a time; successful ones are acknowledged after each batch. Every
+// MinIdle/2, entries pending for longer than MinIdle in any consumer of the
+// group are claimed and handled again, so failed entries are retried and
+// the entries of crashed consumers are not lost. Every ScheduleInterval,
+// the due entries scheduled with Producer.PublishAfter are appended to the
+// stream.
+func (c *Consumer) Run(ctx context.Context) error {
+	if err := c.ensureGroup(ctx); err != nil {
+		return err
+	}
+
+	nextClaim, nextPromote := time.Now(), time.Now()
+	for ctx.Err() == nil {
+		if !time.Now().Before(nextPromote) {
+			c.promoteScheduled(ctx)
+			nextPromote = time.Now().Add(c.schedule)
+		}
+		if !time.Now().Before(nextClaim) {
+			c.claimPending(ctx)
+			nextClaim = time.Now().Add(c.minIdle / 2)
+		}
+
+		msgs, err := c.read(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			c.reportError(err)
+			sleep(ctx, readBackoff)
+			continue
+		}
+		c.process(ctx, msgs)
+	}
+	return nil
+}
+
+// ensureGroup creates the consumer group and the stream unless they exist.
+func (c *Consumer) ensureGroup(ctx context.Context) error {
+	err := c.client.XGroupCreateMkStream(ctx, c.stream, c.group, c.startID).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s: %w", c.group, err)
+	}
+	return nil
+}
+
+// read waits up to the block duration for new entries, but no longer than
+// until the next pending or scheduled check.
+func (c *Consumer) read(ctx context.Context) ([]redis.XMessage, error) {
+	block := min(c.block, c.minIdle/2, c.schedule)
+	streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    c.group,
+		Consumer: c.name,
+		Streams:  []string{c.stream, ">"},
+		Count:    c.batchSize,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	var msgs []redis.XMessage
+	for _, s := range streams {
+		msgs = append(msgs, s.Messages...)
+	}
+	return msgs, nil
+}
+
+// claimPending takes over and handles the entries idle for longer than
+// minIdle.
+func (c *Consumer) claimPending(ctx context.Context) {
+	start := "0-0"
+	for ctx.Err() == nil {
+		msgs, next, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   c.stream,
+			Group:    c.group,
+			Consumer: c.name,
+			MinIdle:  c.minIdle,
+			Start:    start,
+			Count:    c.batchSize,
+		}).Result()
+		if err != nil {
+			if ctx.Err() == nil {
+				c.reportError(fmt.Errorf("failed to claim pending entries: %w", err))
+			}
+			return
+		}
+		c.process(ctx, msgs)
+		if next == "0-0" || next == "" {
+			return
+		}
+		start = next
+	}
+}
+
+// process handles entries and acknowledges the successful ones.
+func (c *Consumer) process(ctx context.Context, msgs []redis.XMessage) {
+	if len(msgs) == 0 {
+		return
+	}
+	var handled []string
+	for _, entry := range msgs {
+		if ctx.Err() != nil {
+			break
+		}
+		msg := decodeMessage(c.stream, entry)
+		if c.handler.HandleMessage(correlation.Context(ctx, msg.Headers), msg) == nil {
+			handled = append(handled, entry.ID)
+		}
+	}
+	if len(handled) == 0 {
+		return
+	}
+
+	// Acknowledge even when Run is being canceled, so handled entries are
+	// not delivered again.
+	if err := c.client.XAck(context.WithoutCancel(ctx), c.stream, c.group, handled...).Err(); err != nil {
+		c.reportError(fmt.Errorf("acknowledge failed: %w", err))
+	}
+}
+
+func (c *Consumer) reportError(err error) {
+	if c.onError != nil {
+		c.onError(err)
+	}
+}
+
+// sleep waits for d or until ctx is canceled.
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
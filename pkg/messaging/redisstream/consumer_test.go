@@ -0,0 +1,130 @@
+package redisstream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConsumer_Validation(t *testing.T) {
+	_, client := newTestClient(t)
+	noop := MessageHandlerFunc(func(context.Context, *Message) error { return nil })
+
+	_, err := NewConsumer(client, &Config{Group: "billing"}, noop)
+	assert.ErrorContains(t, err, "stream is required")
+	_, err = NewConsumer(client, &Config{Stream: "orders"}, noop)
+	assert.ErrorContains(t, err, "consumer group is required")
+
+	c, err := NewConsumer(client, &Config{Stream: "orders", Group: "billing"}, noop)
+	require.NoError(t, err)
+	assert.NotEmpty(t, c.name)
+	assert.Equal(t, DefaultStartID, c.startID)
+	assert.Equal(t, int64(DefaultBatchSize), c.batchSize)
+	assert.Equal(t, DefaultBlock, c.block)
+	assert.Equal(t, DefaultMinIdle, c.minIdle)
+	assert.Equal(t, DefaultScheduleInterval, c.schedule)
+}
+
+func TestConsumer_Run(t *testing.T) {
+	_, client := newTestClient(t)
+	cfg := &Config{Stream: "orders", Group: "billing", Consumer: "c1", StartID: "0", MinIdle: 100 * time.Millisecond}
+	p := NewProducer(client, cfg)
+
+	reqCtx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1"})
+	_, err := p.Publish(reqCtx, "", "o-1", []byte("ok"), nil)
+	require.NoError(t, err)
+	_, err = p.Publish(context.Background(), "", "o-2", []byte("flaky"), nil)
+	require.NoError(t, err)
+
+	var (
+		mu         sync.Mutex
+		handled    []string
+		requestIDs []string
+		attempts   int
+	)
+	c, err := NewConsumer(client, cfg, MessageHandlerFunc(func(ctx context.Context, msg *Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if string(msg.Payload) == "flaky" {
+			if attempts++; attempts == 1 {
+				return errors.New("transient")
+			}
+		}
+		handled = append(handled, msg.Key)
+		requestIDs = append(requestIDs, reqctx.RequestMetadataFromContext(ctx).RequestID)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- c.Run(ctx) }()
+
+	// The failed entry stays pending and is claimed after MinIdle.
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-result)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"o-1", "o-2"}, handled)
+	assert.Equal(t, []string{"req-1", ""}, requestIDs)
+	assert.Equal(t, 2, attempts)
+
+	pending, err := client.XPending(context.Background(), "orders", "billing").Result()
+	require.NoError(t, err)
+	assert.Zero(t, pending.Count)
+}
+
+func TestConsumer_ExistingGroup(t *testing.T) {
+	_, client := newTestClient(t)
+	require.NoError(t, client.XGroupCreateMkStream(context.Background(), "orders", "billing", "$").Err())
+
+	c, err := NewConsumer(client, &Config{Stream: "orders", Group: "billing"}, MessageHandlerFunc(func(context.Context, *Message) error {
+		return nil
+	}))
+	require.NoError(t, err)
+	assert.NoError(t, c.ensureGroup(context.Background()))
+}
+
+func TestConsumer_ReportsReadErrors(t *testing.T) {
+	mr, client := newTestClient(t)
+	errs := make(chan error, 10)
+	c, err := NewConsumer(client, &Config{Stream: "orders", Group: "billing", Block: 10 * time.Millisecond},
+		MessageHandlerFunc(func(context.Context, *Message) error { return nil }),
+		WithErrorHandler(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	result := make(chan error, 1)
+	go func() { result <- c.Run(ctx) }()
+
+	// Give Run time to create the group, then break the connection.
+	require.Eventually(t, func() bool { return mr.Exists("orders") }, time.Second, time.Millisecond)
+	mr.Close()
+
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("read error was not reported")
+	}
+	cancel()
+	assert.NoError(t, <-result)
+}
@@ -0,0 +1,98 @@
+package redisstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Producer appends entries to Redis streams.
+type Producer struct {
+	client redis.UniversalClient
+	stream string
+	maxLen int64
+}
+
+// NewProducer returns a producer using client. cfg.Stream is used when a
+// publish is given no stream.
+//
+// Example:
+//
+//	client := cfg.Redis.NewClient()
+//	producer := redisstream.NewProducer(client, cfg)
+//	id, err := producer.PublishJSON(ctx, "", order.ID, OrderCreated{ID: order.ID})
+func NewProducer(client redis.UniversalClient, cfg *Config) *Producer {
+	return &Producer{client: client, stream: cfg.Stream, maxLen: cfg.MaxLen}
+}
+
+// Publish appends an entry to stream (the configured stream if empty) and
+// returns its ID. The request_id, trace_id, span_id, traceparent and
+// tracestate set by the logger middleware are read from ctx and added to
+// the headers unless headers already defines them. The stream is trimmed to
+// about Config.MaxLen entries.
+func (p *Producer) Publish(ctx context.Context, stream, key string, payload []byte, headers map[string]string) (string, error) {
+	if stream == "" {
+		stream = p.stream
+	}
+	if stream == "" {
+		return "", fmt.Errorf("stream is required")
+	}
+
+	id, err := p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: p.maxLen,
+		Approx: p.maxLen > 0,
+		Values: encodeFields(ctx, key, payload, headers),
+	}).Result()
+	if err != nil {
+		return "", fmt.Errorf("publish failed: %w", err)
+	}
+	return id, nil
+}
+
+// PublishJSON marshals value as JSON and publishes it.
+func (p *Producer) PublishJSON(ctx context.Context, stream, key string, value any) (string, error) {
+	return p.PublishJSONWithHeaders(ctx, stream, key, value, nil)
+}
+
+// PublishJSONWithHeaders publishes a JSON-encoded message with custom
+// headers, tagged with ContentTypeJSON unless headers sets
+// HeaderContentType.
+func (p *Producer) PublishJSONWithHeaders(ctx context.Context, stream, key string, value any, headers map[string]string) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if _, ok := headers[HeaderContentType]; !ok {
+		withType := make(map[string]string, len(headers)+1)
+		for k, v := range headers {
+			withType[k] = v
+		}
+		withType[HeaderContentType] = ContentTypeJSON
+		headers = withType
+	}
+	return p.Publish(ctx, stream, key, data, headers)
+}
+
+// TrimOlderThan removes the entries of stream (the configured stream if
+// empty) added more than age ago, and returns how many were removed. Use it
+// for time-based retention instead of, or in addition to, Config.MaxLen.
+// Entries still pending in a consumer group are removed too.
+func (p *Producer) TrimOlderThan(ctx context.Context, stream string, age time.Duration) (int64, error) {
+	if stream == "" {
+		stream = p.stream
+	}
+	if stream == "" {
+		return 0, fmt.Errorf("stream is required")
+	}
+
+	minID := fmt.Sprintf("%d-0", time.Now().Add(-age).UnixMilli())
+	n, err := p.client.XTrimMinID(ctx, stream, minID).Result()
+	if err != nil {
+		return 0, fmt.Errorf("trim failed: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,79 @@
+package redisstream
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProducer_PublishJSON(t *testing.T) {
+	_, client := newTestClient(t)
+	p := NewProducer(client, &Config{Stream: "orders"})
+
+	id, err := p.PublishJSON(context.Background(), "", "o-1", map[string]string{"id": "o-1"})
+	require.NoError(t, err)
+
+	entries, err := client.XRange(context.Background(), "orders", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	msg := decodeMessage("orders", entries[0])
+	assert.Equal(t, id, msg.ID)
+	assert.Equal(t, "o-1", msg.Key)
+	assert.JSONEq(t, `{"id":"o-1"}`, string(msg.Payload))
+	assert.Equal(t, map[string]string{HeaderContentType: ContentTypeJSON}, msg.Headers)
+}
+
+func TestProducer_MaxLen(t *testing.T) {
+	_, client := newTestClient(t)
+	p := NewProducer(client, &Config{MaxLen: 2})
+
+	for i := range 5 {
+		_, err := p.Publish(context.Background(), "orders", "", []byte(fmt.Sprint(i)), nil)
+		require.NoError(t, err)
+	}
+	n, err := client.XLen(context.Background(), "orders").Result()
+	require.NoError(t, err)
+	// Redis trims approximately; miniredis trims exactly.
+	assert.Equal(t, int64(2), n)
+}
+
+func TestProducer_TrimOlderThan(t *testing.T) {
+	_, client := newTestClient(t)
+	p := NewProducer(client, &Config{Stream: "orders"})
+	ctx := context.Background()
+
+	old := fmt.Sprintf("%d-0", time.Now().Add(-2*time.Hour).UnixMilli())
+	require.NoError(t, client.XAdd(ctx, &redis.XAddArgs{Stream: "orders", ID: old, Values: map[string]any{FieldPayload: "old"}}).Err())
+	_, err := p.Publish(ctx, "", "", []byte("new"), nil)
+	require.NoError(t, err)
+
+	removed, err := p.TrimOlderThan(ctx, "", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	entries, err := client.XRange(ctx, "orders", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "new", string(decodeMessage("orders", entries[0]).Payload))
+}
+
+func TestProducer_Errors(t *testing.T) {
+	mr, client := newTestClient(t)
+	p := NewProducer(client, &Config{})
+
+	_, err := p.Publish(context.Background(), "", "", []byte("x"), nil)
+	assert.ErrorContains(t, err, "stream is required")
+	_, err = p.TrimOlderThan(context.Background(), "", time.Hour)
+	assert.ErrorContains(t, err, "stream is required")
+	_, err = p.PublishJSON(context.Background(), "orders", "", make(chan int))
+	assert.ErrorContains(t, err, "marshal")
+
+	mr.Close()
+	_, err = p.Publish(context.Background(), "orders", "", []byte("x"), nil)
+	assert.ErrorContains(t, err, "publish failed")
+}
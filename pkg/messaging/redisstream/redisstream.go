@@ -0,0 +1,202 @@
+// Package redisstream publishes and consumes messages with Redis Streams,
+// for lightweight event pipelines that do not warrant a broker. Consumers
+// use consumer groups, so each entry is handled by one consumer of a group,
+// and entries left pending by crashed consumers are claimed by the others.
+package redisstream
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ranorsolutions/http-common-go/pkg/cache"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/env"
+	"github.com/redis/go-redis/v9"
+)
+
+// Header names used to propagate request correlation IDs between services.
+// They match the Kafka header names.
+const (
+	HeaderRequestID   = correlation.RequestID
+	HeaderTraceID     = correlation.TraceID
+	HeaderSpanID      = correlation.SpanID
+	HeaderTraceParent = correlation.TraceParent
+	HeaderTraceState  = correlation.TraceState
+
+	// HeaderContentType identifies the payload encoding.
+	HeaderContentType = "content-type"
+)
+
+// ContentTypeJSON is set in HeaderContentType by PublishJSON.
+const ContentTypeJSON = "application/json"
+
+// Entry field names. Headers are stored as one field each, prefixed with
+// FieldHeaderPrefix.
+const (
+	FieldPayload      = "payload"
+	FieldKey          = "key"
+	FieldHeaderPrefix = "h:"
+)
+
+// Defaults applied when the corresponding Config field is zero.
+const (
+	DefaultBatchSize = 10
+	DefaultBlock     = 5 * time.Second
+	DefaultMinIdle   = time.Minute
+	DefaultStartID   = "$"
+
+	DefaultScheduleInterval = time.Second
+)
+
+// Config holds stream, producer and consumer settings.
+type Config struct {
+	// Redis holds the connection settings, shared with the cache package.
+	// Use Redis.NewClient() to create the client passed to NewProducer
+	// and NewConsumer, or pass the client of an existing cache.
+	Redis cache.RedisConfig
+
+	// Stream is the default stream to publish to and the stream consumed.
+	Stream string
+
+	// Group is the consumer group. It is created on first use.
+	Group string
+
+	// Consumer names this consumer within the group (the host name when
+	// empty). It must be unique among the running consumers of the group.
+	Consumer string
+
+	// StartID is where a newly created group starts reading: "$" (new
+	// entries only, the default) or "0" (the whole stream).
+	StartID string
+
+	// BatchSize is the number of entries read per call.
+	BatchSize int64
+
+	// Block is how long a read waits for new entries.
+	Block time.Duration
+
+	// MinIdle is how long an entry stays pending, e.g. because its handler
+	// failed or its consumer crashed, before it is claimed and handled
+	// again. Pending entries are checked every MinIdle/2.
+	MinIdle time.Duration
+
+	// MaxLen trims the stream to about this many entries on every publish.
+	// Zero keeps every entry.
+	MaxLen int64
+
+	// ScheduleInterval is how often consumers move the due entries
+	// scheduled with Producer.PublishAfter to the stream.
+	ScheduleInterval time.Duration
+}
+
+// NewConfigFromEnv builds configuration from environment variables:
+//
+//	REDIS_STREAM                    default stream
+//	REDIS_STREAM_GROUP              consumer group
+//	REDIS_STREAM_CONSUMER           consumer name
+//	REDIS_STREAM_START_ID           "$" or "0"
+//	REDIS_STREAM_BATCH_SIZE         integer
+//	REDIS_STREAM_BLOCK              duration, e.g. 5s
+//	REDIS_STREAM_MIN_IDLE           duration, e.g. 1m
+//	REDIS_STREAM_MAX_LEN            integer, approximate stream length
+//	REDIS_STREAM_SCHEDULE_INTERVAL  duration, e.g. 1s
+//
+// The connection is configured by cache.NewRedisConfigFromEnv.
+func NewConfigFromEnv() (*Config, error) {
+	redisCfg, err := cache.NewRedisConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{
+		Redis:    *redisCfg,
+		Stream:   os.Getenv("REDIS_STREAM"),
+		Group:    os.Getenv("REDIS_STREAM_GROUP"),
+		Consumer: os.Getenv("REDIS_STREAM_CONSUMER"),
+		StartID:  os.Getenv("REDIS_STREAM_START_ID"),
+	}
+	if cfg.BatchSize, err = env.Int64("REDIS_STREAM_BATCH_SIZE"); err != nil {
+		return nil, err
+	}
+	if cfg.Block, err = env.Duration("REDIS_STREAM_BLOCK"); err != nil {
+		return nil, err
+	}
+	if cfg.MinIdle, err = env.Duration("REDIS_STREAM_MIN_IDLE"); err != nil {
+		return nil, err
+	}
+	if cfg.MaxLen, err = env.Int64("REDIS_STREAM_MAX_LEN"); err != nil {
+		return nil, err
+	}
+	if cfg.ScheduleInterval, err = env.Duration("REDIS_STREAM_SCHEDULE_INTERVAL"); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate rejects negative settings.
+func (c *Config) validate() error {
+	if c.BatchSize < 0 || c.Block < 0 || c.MinIdle < 0 || c.MaxLen < 0 || c.ScheduleInterval < 0 {
+		return fmt.Errorf("invalid Redis stream settings: must not be negative")
+	}
+	return nil
+}
+
+// Message is a stream entry.
+type Message struct {
+	ID      string
+	Stream  string
+	Key     string
+	Payload []byte
+	Headers map[string]string
+}
+
+// MessageHandler defines the signature for handling stream entries. The
+// context carries the correlation IDs found in the headers. Returning nil
+// acknowledges the entry; an error leaves it pending, to be handled again
+// after Config.MinIdle.
+type MessageHandler interface {
+	HandleMessage(ctx context.Context, msg *Message) error
+}
+
+// MessageHandlerFunc adapts a function to MessageHandler.
+type MessageHandlerFunc func(ctx context.Context, msg *Message) error
+
+// HandleMessage implements MessageHandler.
+func (f MessageHandlerFunc) HandleMessage(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// encodeFields returns the entry fields of a message, adding correlation
+// IDs from ctx to the headers unless already set.
+func encodeFields(ctx context.Context, key string, payload []byte, headers map[string]string) map[string]any {
+	fields := map[string]any{FieldPayload: payload}
+	if key != "" {
+		fields[FieldKey] = key
+	}
+	for k, v := range correlation.Merge(ctx, headers) {
+		fields[FieldHeaderPrefix+k] = v
+	}
+	return fields
+}
+
+// decodeMessage converts a stream entry to a Message.
+func decodeMessage(stream string, entry redis.XMessage) *Message {
+	msg := &Message{ID: entry.ID, Stream: stream, Headers: map[string]string{}}
+	for k, v := range entry.Values {
+		s := fmt.Sprint(v)
+		switch {
+		case k == FieldPayload:
+			msg.Payload = []byte(s)
+		case k == FieldKey:
+			msg.Key = s
+		case strings.HasPrefix(k, FieldHeaderPrefix):
+			msg.Headers[strings.TrimPrefix(k, FieldHeaderPrefix)] = s
+		}
+	}
+	return msg
+}
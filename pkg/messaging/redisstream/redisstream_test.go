@@ -0,0 +1,101 @@
+package redisstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ranorsolutions/http-common-go/pkg/cache"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient returns a client connected to an in-memory Redis server.
+func newTestClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, client
+}
+
+func TestNewConfigFromEnv(t *testing.T) {
+	t.Setenv("REDIS_ADDR", "redis:6379")
+	t.Setenv("REDIS_STREAM", "orders")
+	t.Setenv("REDIS_STREAM_GROUP", "billing")
+	t.Setenv("REDIS_STREAM_CONSUMER", "billing-1")
+	t.Setenv("REDIS_STREAM_START_ID", "0")
+	t.Setenv("REDIS_STREAM_BATCH_SIZE", "50")
+	t.Setenv("REDIS_STREAM_BLOCK", "2s")
+	t.Setenv("REDIS_STREAM_MIN_IDLE", "30s")
+	t.Setenv("REDIS_STREAM_MAX_LEN", "10000")
+	t.Setenv("REDIS_STREAM_SCHEDULE_INTERVAL", "500ms")
+
+	cfg, err := NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		Redis:            cache.RedisConfig{Addr: "redis:6379"},
+		Stream:           "orders",
+		Group:            "billing",
+		Consumer:         "billing-1",
+		StartID:          "0",
+		BatchSize:        50,
+		Block:            2 * time.Second,
+		MinIdle:          30 * time.Second,
+		MaxLen:           10000,
+		ScheduleInterval: 500 * time.Millisecond,
+	}, cfg)
+}
+
+func TestNewConfigFromEnv_Invalid(t *testing.T) {
+	for key, value := range map[string]string{
+		"REDIS_DB":                       "x",
+		"REDIS_STREAM_BATCH_SIZE":        "many",
+		"REDIS_STREAM_BLOCK":             "long",
+		"REDIS_STREAM_MIN_IDLE":          "-1s",
+		"REDIS_STREAM_MAX_LEN":           "-5",
+		"REDIS_STREAM_SCHEDULE_INTERVAL": "soon",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			_, err := NewConfigFromEnv()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1", TraceID: "trace-1"})
+	fields := encodeFields(ctx, "o-1", []byte("hi"), map[string]string{HeaderTraceID: "explicit", "tenant": "acme"})
+
+	values := make(map[string]any, len(fields))
+	for k, v := range fields {
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		values[k] = v
+	}
+	msg := decodeMessage("orders", redis.XMessage{ID: "1-0", Values: values})
+	assert.Equal(t, &Message{
+		ID:      "1-0",
+		Stream:  "orders",
+		Key:     "o-1",
+		Payload: []byte("hi"),
+		Headers: map[string]string{
+			HeaderRequestID: "req-1",
+			HeaderTraceID:   "explicit",
+			"tenant":        "acme",
+		},
+	}, msg)
+
+	md := reqctx.RequestMetadataFromContext(correlation.Context(context.Background(), msg.Headers))
+	assert.Equal(t, "req-1", md.RequestID)
+	assert.Equal(t, "explicit", md.TraceID)
+
+	parent := context.Background()
+	assert.Equal(t, parent, correlation.Context(parent, (&Message{}).Headers))
+}
@@ -0,0 +1,131 @@
+package redisstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// scheduleLease is how long a consumer owns the due entries it is moving to
+// the stream. Entries of a consumer that crashed meanwhile become due again
+// afterwards.
+const scheduleLease = 30 * time.Second
+
+// ScheduledKey returns the sorted set holding the entries scheduled for
+// stream, scored by due time in Unix milliseconds.
+func ScheduledKey(stream string) string {
+	return stream + ":scheduled"
+}
+
+// scheduledEntry is a member of the scheduled set. ID keeps identical
+// messages distinct.
+type scheduledEntry struct {
+	ID      string            `json:"id"`
+	Fields  map[string]string `json:"fields"`
+	Payload []byte            `json:"payload"`
+}
+
+// claimDue returns up to ARGV[2] members of KEYS[1] due at ARGV[1] and
+// re-scores them to ARGV[3], so concurrent consumers do not move them too.
+var claimDue = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, member in ipairs(due) do
+	redis.call('ZADD', KEYS[1], ARGV[3], member)
+end
+return due
+`)
+
+// PublishAfter schedules an entry for stream (the configured stream if
+// empty) to be appended after delay, and returns its schedule ID. Headers
+// are completed with the correlation IDs of ctx as by Publish. Scheduled
+// entries are moved to the stream by its running consumers, every
+// Config.ScheduleInterval, so they are appended late when no consumer runs.
+//
+// Example:
+//
+//	_, err := producer.PublishAfter(ctx, 10*time.Minute, "orders.expire", order.ID, payload, nil)
+func (p *Producer) PublishAfter(ctx context.Context, delay time.Duration, stream, key string, payload []byte, headers map[string]string) (string, error) {
+	if stream == "" {
+		stream = p.stream
+	}
+	if stream == "" {
+		return "", fmt.Errorf("stream is required")
+	}
+
+	entry := scheduledEntry{ID: uuid.NewString(), Fields: map[string]string{}, Payload: payload}
+	for k, v := range encodeFields(ctx, key, nil, headers) {
+		if k != FieldPayload {
+			entry.Fields[k] = v.(string)
+		}
+	}
+	member, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode scheduled entry: %w", err)
+	}
+
+	due := time.Now().Add(delay).UnixMilli()
+	if err := p.client.ZAdd(ctx, ScheduledKey(stream), redis.Z{Score: float64(due), Member: member}).Err(); err != nil {
+		return "", fmt.Errorf("schedule failed: %w", err)
+	}
+	return entry.ID, nil
+}
+
+// promoteScheduled appends the due scheduled entries to the stream. An
+// entry is removed from the scheduled set once appended, so a failure in
+// between appends it again after scheduleLease.
+func (c *Consumer) promoteScheduled(ctx context.Context) {
+	key := ScheduledKey(c.stream)
+	for ctx.Err() == nil {
+		now := time.Now()
+		members, err := claimDue.Run(ctx, c.client, []string{key},
+			now.UnixMilli(), c.batchSize, now.Add(scheduleLease).UnixMilli()).StringSlice()
+		if err != nil {
+			if ctx.Err() == nil {
+				c.reportError(fmt.Errorf("failed to claim scheduled entries: %w", err))
+			}
+			return
+		}
+
+		for _, member := range members {
+			if err := c.promote(ctx, key, member); err != nil {
+				c.reportError(err)
+			}
+		}
+		if int64(len(members)) < c.batchSize {
+			return
+		}
+	}
+}
+
+// promote appends one claimed member to the stream and removes it from the
+// scheduled set. Malformed members are removed.
+func (c *Consumer) promote(ctx context.Context, key, member string) error {
+	var entry scheduledEntry
+	if err := json.Unmarshal([]byte(member), &entry); err != nil {
+		c.client.ZRem(ctx, key, member)
+		return fmt.Errorf("dropped malformed scheduled entry: %w", err)
+	}
+
+	values := make(map[string]any, len(entry.Fields)+1)
+	for k, v := range entry.Fields {
+		values[k] = v
+	}
+	values[FieldPayload] = entry.Payload
+	err := c.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: c.stream,
+		MaxLen: c.maxLen,
+		Approx: c.maxLen > 0,
+		Values: values,
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to append scheduled entry %s: %w", entry.ID, err)
+	}
+	if err := c.client.ZRem(context.WithoutCancel(ctx), key, member).Err(); err != nil {
+		return fmt.Errorf("failed to remove scheduled entry %s: %w", entry.ID, err)
+	}
+	return nil
+}
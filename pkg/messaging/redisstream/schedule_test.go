@@ -0,0 +1,139 @@
+package redisstream
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProducer_PublishAfter(t *testing.T) {
+	_, client := newTestClient(t)
+	p := NewProducer(client, &Config{Stream: "orders"})
+
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1"})
+	start := time.Now()
+	id, err := p.PublishAfter(ctx, time.Minute, "", "o-1", []byte("expire"), map[string]string{"tenant": "acme"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+
+	scheduled, err := client.ZRangeWithScores(context.Background(), ScheduledKey("orders"), 0, -1).Result()
+	require.NoError(t, err)
+	require.Len(t, scheduled, 1)
+	assert.InDelta(t, start.Add(time.Minute).UnixMilli(), scheduled[0].Score, 1000)
+	assert.Contains(t, scheduled[0].Member, `"h:request_id":"req-1"`)
+
+	n, err := client.XLen(context.Background(), "orders").Result()
+	require.NoError(t, err)
+	assert.Zero(t, n, "nothing is appended before the delay")
+
+	_, err = NewProducer(client, &Config{}).PublishAfter(ctx, time.Minute, "", "", nil, nil)
+	assert.ErrorContains(t, err, "stream is required")
+}
+
+func TestConsumer_PromotesScheduledEntries(t *testing.T) {
+	_, client := newTestClient(t)
+	cfg := &Config{Stream: "orders", Group: "billing", Consumer: "c1", ScheduleInterval: 10 * time.Millisecond}
+	p := NewProducer(client, cfg)
+
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1"})
+	_, err := p.PublishAfter(ctx, 100*time.Millisecond, "", "o-1", []byte{0xff, 0x00}, map[string]string{"tenant": "acme"})
+	require.NoError(t, err)
+	_, err = p.PublishAfter(ctx, time.Hour, "", "o-2", []byte("later"), nil)
+	require.NoError(t, err)
+
+	var (
+		mu         sync.Mutex
+		handled    []*Message
+		requestIDs []string
+	)
+	c, err := NewConsumer(client, cfg, MessageHandlerFunc(func(ctx context.Context, msg *Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, msg)
+		requestIDs = append(requestIDs, reqctx.RequestMetadataFromContext(ctx).RequestID)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	start := time.Now()
+	go func() { result <- c.Run(runCtx) }()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	cancel()
+	require.NoError(t, <-result)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "o-1", handled[0].Key)
+	assert.Equal(t, []byte{0xff, 0x00}, handled[0].Payload, "binary payloads survive scheduling")
+	assert.Equal(t, "acme", handled[0].Headers["tenant"])
+	assert.Equal(t, []string{"req-1"}, requestIDs)
+
+	n, err := client.ZCard(context.Background(), ScheduledKey("orders")).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "entries not yet due stay scheduled")
+}
+
+func TestConsumer_PromoteClaimsOnce(t *testing.T) {
+	_, client := newTestClient(t)
+	cfg := &Config{Stream: "orders", Group: "billing"}
+	p := NewProducer(client, cfg)
+	for range 3 {
+		_, err := p.PublishAfter(context.Background(), -time.Second, "", "", []byte("due"), nil)
+		require.NoError(t, err)
+	}
+
+	noop := MessageHandlerFunc(func(context.Context, *Message) error { return nil })
+	c1, err := NewConsumer(client, &Config{Stream: "orders", Group: "billing", BatchSize: 2}, noop)
+	require.NoError(t, err)
+	c2, err := NewConsumer(client, &Config{Stream: "orders", Group: "billing", BatchSize: 2}, noop)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for _, c := range []*Consumer{c1, c2} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.promoteScheduled(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	n, err := client.XLen(context.Background(), "orders").Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	left, err := client.ZCard(context.Background(), ScheduledKey("orders")).Result()
+	require.NoError(t, err)
+	assert.Zero(t, left)
+}
+
+func TestConsumer_PromoteDropsMalformed(t *testing.T) {
+	_, client := newTestClient(t)
+	require.NoError(t, client.ZAdd(context.Background(), ScheduledKey("orders"), redis.Z{Score: 0, Member: "not json"}).Err())
+
+	var reported []error
+	c, err := NewConsumer(client, &Config{Stream: "orders", Group: "billing"},
+		MessageHandlerFunc(func(context.Context, *Message) error { return nil }),
+		WithErrorHandler(func(err error) { reported = append(reported, err) }))
+	require.NoError(t, err)
+	c.promoteScheduled(context.Background())
+
+	require.Len(t, reported, 1)
+	assert.ErrorContains(t, reported[0], "malformed")
+	left, err := client.ZCard(context.Background(), ScheduledKey("orders")).Result()
+	require.NoError(t, err)
+	assert.Zero(t, left)
+}
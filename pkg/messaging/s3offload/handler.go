@@ -0,0 +1,104 @@
+package s3offload
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/sns"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/sqs"
+)
+
+// Handler returns an sqs.MessageHandler that passes pointer messages to next
+// with the offloaded body in place of the pointer, and without
+// AttributePayloadSize. Once next succeeded the object is deleted, unless
+// RetainObjects is set. Other messages are passed unchanged.
+//
+// Messages SNS delivered without raw message delivery are resolved too:
+// the body of the notification envelope is replaced.
+//
+// Example:
+//
+//	consumer, err := sqs.NewConsumer(cfg, store.Handler(handler))
+func (s *Store) Handler(next sqs.MessageHandler) sqs.MessageHandler {
+	return sqs.MessageHandlerFunc(func(ctx context.Context, msg *types.Message) error {
+		resolved, ptr, err := s.resolve(ctx, msg)
+		if err != nil {
+			return err
+		}
+		if ptr == nil {
+			return next.HandleMessage(ctx, msg)
+		}
+
+		if err := next.HandleMessage(ctx, resolved); err != nil {
+			return err
+		}
+		if !s.retain {
+			// The message is handled; delete even when the consumer is
+			// stopping.
+			if err := s.Delete(context.WithoutCancel(ctx), *ptr); err != nil && s.onError != nil {
+				s.onError(err)
+			}
+		}
+		return nil
+	})
+}
+
+// resolve returns a copy of msg with the offloaded body and its pointer, or
+// a nil pointer when msg is not a pointer message.
+func (s *Store) resolve(ctx context.Context, msg *types.Message) (*types.Message, *Pointer, error) {
+	if IsPointer(sqs.Attributes(msg)) {
+		body, ptr, err := s.Fetch(ctx, aws.ToString(msg.Body))
+		if err != nil {
+			return nil, nil, err
+		}
+		resolved := *msg
+		resolved.Body = aws.String(body)
+		resolved.MessageAttributes = make(map[string]types.MessageAttributeValue, len(msg.MessageAttributes))
+		for k, v := range msg.MessageAttributes {
+			if k != AttributePayloadSize && k != legacyAttributePayloadSize {
+				resolved.MessageAttributes[k] = v
+			}
+		}
+		return &resolved, &ptr, nil
+	}
+
+	env, ok := snsEnvelope(msg)
+	if !ok {
+		return nil, nil, nil
+	}
+	body, ptr, err := s.Fetch(ctx, env.Message)
+	if err != nil {
+		return nil, nil, err
+	}
+	env.Message = body
+	delete(env.MessageAttributes, AttributePayloadSize)
+	delete(env.MessageAttributes, legacyAttributePayloadSize)
+	data, err := json.Marshal(env)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved := *msg
+	resolved.Body = aws.String(string(data))
+	return &resolved, &ptr, nil
+}
+
+// snsEnvelope decodes the SNS notification envelope of msg when it carries
+// a pointer message.
+func snsEnvelope(msg *types.Message) (*sns.Message, bool) {
+	body := aws.ToString(msg.Body)
+	if !strings.HasPrefix(body, "{") {
+		return nil, false
+	}
+	var env sns.Message
+	if json.Unmarshal([]byte(body), &env) != nil || env.Type != sns.TypeNotification || env.TopicARN == "" {
+		return nil, false
+	}
+	attrs := make(map[string]string, len(env.MessageAttributes))
+	for k, a := range env.MessageAttributes {
+		attrs[k] = a.Value
+	}
+	return &env, IsPointer(attrs)
+}
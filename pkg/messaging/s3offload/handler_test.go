@@ -0,0 +1,120 @@
+package s3offload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/sns"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pointerMessage offloads body through s and returns the SQS message a raw
+// delivery of it would be.
+func pointerMessage(t *testing.T, s *Store, body string) *types.Message {
+	t.Helper()
+	pointer, attrs, err := s.Offload(context.Background(), body, map[string]string{"tenant": "acme"})
+	require.NoError(t, err)
+	msg := &types.Message{MessageId: aws.String("m-1"), Body: aws.String(pointer), MessageAttributes: map[string]types.MessageAttributeValue{}}
+	for k, v := range attrs {
+		msg.MessageAttributes[k] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
+	}
+	return msg
+}
+
+func TestHandler_RawDelivery(t *testing.T) {
+	api := newFakeS3()
+	s := NewWithAPI(api, &Config{Bucket: "b", AlwaysOffload: true})
+	msg := pointerMessage(t, s, "large body")
+
+	var got *types.Message
+	h := s.Handler(sqs.MessageHandlerFunc(func(_ context.Context, m *types.Message) error {
+		got = m
+		return nil
+	}))
+	require.NoError(t, h.HandleMessage(context.Background(), msg))
+	assert.Equal(t, "large body", aws.ToString(got.Body))
+	assert.Equal(t, map[string]string{"tenant": "acme"}, sqs.Attributes(got))
+	assert.Equal(t, "m-1", aws.ToString(got.MessageId))
+	assert.Zero(t, api.len(), "object is deleted")
+	assert.Contains(t, aws.ToString(msg.Body), "PayloadS3Pointer", "received message is not modified")
+}
+
+func TestHandler_SNSEnvelope(t *testing.T) {
+	api := newFakeS3()
+	s := NewWithAPI(api, &Config{Bucket: "b", AlwaysOffload: true, RetainObjects: true})
+	pointer, attrs, err := s.Offload(context.Background(), "large body", nil)
+	require.NoError(t, err)
+
+	env, err := json.Marshal(sns.Message{
+		Type:     sns.TypeNotification,
+		TopicARN: "arn:aws:sns:us-east-1:123456789012:orders",
+		Message:  pointer,
+		MessageAttributes: map[string]sns.MessageAttribute{
+			AttributePayloadSize: {Type: "Number", Value: attrs[AttributePayloadSize]},
+			"tenant":             {Type: "String", Value: "acme"},
+		},
+	})
+	require.NoError(t, err)
+
+	var got sns.Message
+	h := s.Handler(sqs.MessageHandlerFunc(func(_ context.Context, m *types.Message) error {
+		return json.Unmarshal([]byte(aws.ToString(m.Body)), &got)
+	}))
+	require.NoError(t, h.HandleMessage(context.Background(), &types.Message{Body: aws.String(string(env))}))
+	assert.Equal(t, "large body", got.Message)
+	assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:orders", got.TopicARN)
+	assert.Equal(t, map[string]sns.MessageAttribute{"tenant": {Type: "String", Value: "acme"}}, got.MessageAttributes)
+	assert.Equal(t, 1, api.len(), "object is retained")
+}
+
+func TestHandler_PlainMessages(t *testing.T) {
+	s := NewWithAPI(newFakeS3(), &Config{Bucket: "b"})
+	plain := &types.Message{Body: aws.String(`{"Type":"Notification","TopicArn":"arn","Message":"hi"}`)}
+
+	var got *types.Message
+	h := s.Handler(sqs.MessageHandlerFunc(func(_ context.Context, m *types.Message) error {
+		got = m
+		return nil
+	}))
+	require.NoError(t, h.HandleMessage(context.Background(), plain))
+	assert.Same(t, plain, got)
+}
+
+func TestHandler_Failures(t *testing.T) {
+	api := newFakeS3()
+	var reported []error
+	s := NewWithAPI(api, &Config{Bucket: "b", AlwaysOffload: true}, WithErrorHandler(func(err error) {
+		reported = append(reported, err)
+	}))
+	msg := pointerMessage(t, s, strings.Repeat("x", 10))
+
+	failing := s.Handler(sqs.MessageHandlerFunc(func(context.Context, *types.Message) error {
+		return errors.New("boom")
+	}))
+	assert.ErrorContains(t, failing.HandleMessage(context.Background(), msg), "boom")
+	assert.Equal(t, 1, api.len(), "object is kept for redelivery")
+
+	api.deleteErr = errors.New("denied")
+	ok := s.Handler(sqs.MessageHandlerFunc(func(context.Context, *types.Message) error { return nil }))
+	require.NoError(t, ok.HandleMessage(context.Background(), msg))
+	require.Len(t, reported, 1)
+	assert.ErrorContains(t, reported[0], "denied")
+
+	api.deleteErr = nil
+	require.NoError(t, s.Delete(context.Background(), mustPointer(t, msg)))
+	assert.ErrorContains(t, ok.HandleMessage(context.Background(), msg), "failed to fetch message body")
+}
+
+func mustPointer(t *testing.T, msg *types.Message) Pointer {
+	t.Helper()
+	ptr, err := parsePointer(aws.ToString(msg.Body))
+	require.NoError(t, err)
+	return ptr
+}
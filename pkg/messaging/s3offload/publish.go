@@ -0,0 +1,104 @@
+package s3offload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/sns"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/sqs"
+)
+
+// snsSender is the part of *sns.Client used by SNSPublisher.
+type snsSender interface {
+	PublishStringWithOptions(ctx context.Context, topicARN, message string, opts sns.PublishOptions) (string, error)
+}
+
+// SNSPublisher publishes through an sns.Client, offloading large messages
+// to S3. It implements sns.Publisher.
+type SNSPublisher struct {
+	client snsSender
+	store  *Store
+}
+
+// NewSNSPublisher returns a publisher sending through client and offloading
+// to store.
+func NewSNSPublisher(client *sns.Client, store *Store) *SNSPublisher {
+	return &SNSPublisher{client: client, store: store}
+}
+
+// PublishString publishes message, offloaded when large.
+func (p *SNSPublisher) PublishString(ctx context.Context, topicARN, message string) (string, error) {
+	return p.PublishStringWithOptions(ctx, topicARN, message, sns.PublishOptions{})
+}
+
+// PublishStringWithOptions publishes message with opts, offloaded when
+// large. The attributes in opts stay on the pointer message.
+func (p *SNSPublisher) PublishStringWithOptions(ctx context.Context, topicARN, message string, opts sns.PublishOptions) (string, error) {
+	body, attrs, err := p.store.Offload(ctx, message, opts.Attributes)
+	if err != nil {
+		return "", err
+	}
+	opts.Attributes = attrs
+	return p.client.PublishStringWithOptions(ctx, topicARN, body, opts)
+}
+
+// PublishJSON marshals payload as JSON and publishes it, offloaded when
+// large.
+func (p *SNSPublisher) PublishJSON(ctx context.Context, topicARN string, payload any) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return p.PublishString(ctx, topicARN, string(data))
+}
+
+// sqsSender is the part of *sqs.Producer used by SQSProducer.
+type sqsSender interface {
+	SendString(ctx context.Context, queueURL, body string, attrs map[string]string) (string, error)
+}
+
+// SQSProducer sends through an sqs.Producer, offloading large messages to
+// S3.
+type SQSProducer struct {
+	producer sqsSender
+	store    *Store
+}
+
+// NewSQSProducer returns a producer sending through producer and offloading
+// to store.
+func NewSQSProducer(producer *sqs.Producer, store *Store) *SQSProducer {
+	return &SQSProducer{producer: producer, store: store}
+}
+
+// SendString sends body with attrs, offloaded when large.
+func (p *SQSProducer) SendString(ctx context.Context, queueURL, body string, attrs map[string]string) (string, error) {
+	body, attrs, err := p.store.Offload(ctx, body, attrs)
+	if err != nil {
+		return "", err
+	}
+	return p.producer.SendString(ctx, queueURL, body, attrs)
+}
+
+// SendJSON marshals value as JSON and sends it, offloaded when large.
+func (p *SQSProducer) SendJSON(ctx context.Context, queueURL string, value any) (string, error) {
+	return p.SendJSONWithAttributes(ctx, queueURL, value, nil)
+}
+
+// SendJSONWithAttributes marshals value as JSON and sends it with attrs,
+// tagged with sqs.ContentTypeJSON like sqs.Producer.SendJSONWithAttributes,
+// offloaded when large.
+func (p *SQSProducer) SendJSONWithAttributes(ctx context.Context, queueURL string, value any, attrs map[string]string) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	withType := make(map[string]string, len(attrs)+1)
+	for k, v := range attrs {
+		withType[k] = v
+	}
+	if _, ok := withType[sqs.AttributeContentType]; !ok {
+		withType[sqs.AttributeContentType] = sqs.ContentTypeJSON
+	}
+	return p.SendString(ctx, queueURL, string(data), withType)
+}
@@ -0,0 +1,73 @@
+package s3offload
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/sns"
+	"github.com/ranorsolutions/http-common-go/pkg/messaging/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSNS records published messages.
+type recordingSNS struct {
+	message string
+	opts    sns.PublishOptions
+}
+
+func (r *recordingSNS) PublishStringWithOptions(_ context.Context, _, message string, opts sns.PublishOptions) (string, error) {
+	r.message, r.opts = message, opts
+	return "msg-1", nil
+}
+
+// recordingSQS records sent messages.
+type recordingSQS struct {
+	body  string
+	attrs map[string]string
+}
+
+func (r *recordingSQS) SendString(_ context.Context, _, body string, attrs map[string]string) (string, error) {
+	r.body, r.attrs = body, attrs
+	return "msg-1", nil
+}
+
+func TestSNSPublisher(t *testing.T) {
+	api := newFakeS3()
+	rec := &recordingSNS{}
+	p := &SNSPublisher{client: rec, store: NewWithAPI(api, &Config{Bucket: "b", Threshold: 50})}
+
+	id, err := p.PublishJSON(context.Background(), "arn", map[string]string{"data": strings.Repeat("x", 100)})
+	require.NoError(t, err)
+	assert.Equal(t, "msg-1", id)
+	assert.Contains(t, rec.message, "PayloadS3Pointer")
+	assert.Equal(t, "111", rec.opts.Attributes[AttributePayloadSize])
+	assert.Equal(t, 1, api.len())
+
+	_, err = p.PublishStringWithOptions(context.Background(), "arn", "small", sns.PublishOptions{Subject: "s", GroupID: "g"})
+	require.NoError(t, err)
+	assert.Equal(t, "small", rec.message)
+	assert.Equal(t, sns.PublishOptions{Subject: "s", GroupID: "g"}, rec.opts)
+
+	_, err = p.PublishJSON(context.Background(), "arn", make(chan int))
+	assert.ErrorContains(t, err, "marshal")
+}
+
+func TestSQSProducer(t *testing.T) {
+	api := newFakeS3()
+	rec := &recordingSQS{}
+	p := &SQSProducer{producer: rec, store: NewWithAPI(api, &Config{Bucket: "b", Threshold: 50})}
+
+	_, err := p.SendJSONWithAttributes(context.Background(), "", map[string]string{"data": strings.Repeat("x", 100)}, map[string]string{"tenant": "acme"})
+	require.NoError(t, err)
+	assert.Contains(t, rec.body, "PayloadS3Pointer")
+	assert.Equal(t, "acme", rec.attrs["tenant"])
+	assert.Equal(t, sqs.ContentTypeJSON, rec.attrs[sqs.AttributeContentType])
+	assert.Equal(t, "111", rec.attrs[AttributePayloadSize])
+
+	_, err = p.SendJSON(context.Background(), "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "1", rec.body)
+	assert.NotContains(t, rec.attrs, AttributePayloadSize)
+}
@@ -0,0 +1,326 @@
+// Package s3offload stores SNS and SQS message bodies that exceed the 256 KB
+// message size limit in S3 and sends a pointer to the object instead, like
+// the AWS extended client libraries for Java and Python, with which the
+// pointer format is compatible. Consumers wrap their handler with
+// Store.Handler to receive the original body.
+package s3offload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/awsconfig"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/env"
+)
+
+// AttributePayloadSize is set on pointer messages to the size of the
+// offloaded body. Its presence marks a message as a pointer.
+const AttributePayloadSize = "ExtendedPayloadSize"
+
+// legacyAttributePayloadSize marks pointer messages sent by older versions
+// of the Java extended client.
+const legacyAttributePayloadSize = "SQSLargePayloadSize"
+
+// pointerClass is the class name that starts the JSON pointer document.
+const pointerClass = "software.amazon.payloadoffloading.PayloadS3Pointer"
+
+// MaxMessageSize is the largest message, body and attributes, SNS and SQS
+// accept.
+const MaxMessageSize = 256 * 1024
+
+// DefaultThreshold is the message size above which bodies are offloaded
+// when Config.Threshold is zero. It leaves 1 KB below MaxMessageSize for the
+// correlation attributes added when publishing.
+const DefaultThreshold = MaxMessageSize - 1024
+
+// S3API defines the subset of s3.Client methods we use.
+// This makes it mockable in tests.
+type S3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// Config holds the bucket and S3 connection settings.
+type Config struct {
+	Region string
+
+	// Bucket receives the offloaded bodies. Required.
+	Bucket string
+
+	// KeyPrefix is prepended to object keys, e.g. "messages/".
+	KeyPrefix string
+
+	// Threshold is the message size in bytes, body and attributes, above
+	// which the body is offloaded (DefaultThreshold when zero).
+	Threshold int
+
+	// AlwaysOffload sends every body through S3, whatever its size.
+	AlwaysOffload bool
+
+	// RetainObjects keeps objects after their message was handled. Set it
+	// when the messages of an SNS topic reach several queues, since the
+	// first consumer would otherwise delete the object the others need;
+	// expire the objects with a bucket lifecycle rule instead.
+	RetainObjects bool
+
+	// Endpoint overrides the S3 endpoint, e.g. "http://localhost:4566" for
+	// LocalStack. UsePathStyle is usually needed with it.
+	Endpoint     string
+	UsePathStyle bool
+
+	// Profile selects a profile of the shared config and credentials files
+	// instead of AWS_PROFILE.
+	Profile string
+
+	// AccessKeyID and SecretAccessKey (and optionally SessionToken) replace
+	// the default credential chain with static credentials.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// RoleARN is assumed with the credentials above, e.g. to use a bucket
+	// in another account.
+	RoleARN string
+}
+
+// NewConfigFromEnv builds configuration from environment variables:
+//
+//	AWS_REGION            required
+//	S3_OFFLOAD_BUCKET     required
+//	S3_OFFLOAD_PREFIX     object key prefix
+//	S3_OFFLOAD_THRESHOLD  integer, bytes
+//	S3_OFFLOAD_ALWAYS     boolean, offload every body
+//	S3_OFFLOAD_RETAIN     boolean, keep objects after handling
+//	S3_OFFLOAD_ENDPOINT   endpoint override, addressed path-style
+//	S3_OFFLOAD_ROLE_ARN   role to assume
+//
+// Credentials and profiles are read by the AWS SDK from its usual
+// environment variables and shared files.
+func NewConfigFromEnv() (*Config, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		return nil, fmt.Errorf("AWS_REGION is required")
+	}
+	bucket := os.Getenv("S3_OFFLOAD_BUCKET")
+	if bucket == "" {
+		return nil, fmt.Errorf("S3_OFFLOAD_BUCKET is required")
+	}
+
+	cfg := &Config{
+		Region:    region,
+		Bucket:    bucket,
+		KeyPrefix: os.Getenv("S3_OFFLOAD_PREFIX"),
+		Endpoint:  os.Getenv("S3_OFFLOAD_ENDPOINT"),
+		RoleARN:   os.Getenv("S3_OFFLOAD_ROLE_ARN"),
+	}
+	cfg.UsePathStyle = cfg.Endpoint != ""
+
+	var err error
+	if v := os.Getenv("S3_OFFLOAD_THRESHOLD"); v != "" {
+		if cfg.Threshold, err = strconv.Atoi(v); err != nil || cfg.Threshold < 0 || cfg.Threshold > MaxMessageSize {
+			return nil, fmt.Errorf("invalid S3_OFFLOAD_THRESHOLD %q", v)
+		}
+	}
+	if cfg.AlwaysOffload, err = env.Bool("S3_OFFLOAD_ALWAYS"); err != nil {
+		return nil, err
+	}
+	if cfg.RetainObjects, err = env.Bool("S3_OFFLOAD_RETAIN"); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// StoreOption configures a Store.
+type StoreOption func(*Store)
+
+// WithErrorHandler sets a function called when the object of a handled
+// message cannot be deleted. The message is still deleted from the queue;
+// by default such errors are dropped.
+func WithErrorHandler(fn func(error)) StoreOption {
+	return func(s *Store) { s.onError = fn }
+}
+
+// Store offloads message bodies to an S3 bucket.
+type Store struct {
+	api       S3API
+	bucket    string
+	prefix    string
+	threshold int
+	always    bool
+	retain    bool
+	onError   func(error)
+}
+
+// New creates a store from AWS credentials/config in the environment,
+// applying the overrides set in cfg.
+func New(cfg *Config, opts ...StoreOption) (*Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	awsCfg, err := awsconfig.Load(context.Background(), awsconfig.Options{
+		Region:          cfg.Region,
+		Profile:         cfg.Profile,
+		AccessKeyID:     cfg.AccessKeyID,
+		SecretAccessKey: cfg.SecretAccessKey,
+		SessionToken:    cfg.SessionToken,
+		RoleARN:         cfg.RoleARN,
+	})
+	if err != nil {
+		return nil, err
+	}
+	api := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+	})
+	return NewWithAPI(api, cfg, opts...), nil
+}
+
+// NewWithAPI returns a store using api with the bucket and offload settings
+// of cfg. The connection settings of cfg are ignored.
+func NewWithAPI(api S3API, cfg *Config, opts ...StoreOption) *Store {
+	s := &Store{
+		api:       api,
+		bucket:    cfg.Bucket,
+		prefix:    cfg.KeyPrefix,
+		threshold: DefaultThreshold,
+		always:    cfg.AlwaysOffload,
+		retain:    cfg.RetainObjects,
+	}
+	if cfg.Threshold > 0 {
+		s.threshold = cfg.Threshold
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Pointer locates an offloaded body.
+type Pointer struct {
+	Bucket string `json:"s3BucketName"`
+	Key    string `json:"s3Key"`
+}
+
+// Offload stores body in S3 when the message it forms with attrs is larger
+// than the threshold (or always, with AlwaysOffload), returning the pointer
+// message to send instead and its attributes. Smaller messages are returned
+// unchanged.
+func (s *Store) Offload(ctx context.Context, body string, attrs map[string]string) (string, map[string]string, error) {
+	if !s.always && messageSize(body, attrs) <= s.threshold {
+		return body, attrs, nil
+	}
+	if _, ok := attrs[AttributePayloadSize]; ok {
+		return "", nil, fmt.Errorf("attribute %s is reserved", AttributePayloadSize)
+	}
+
+	ptr := Pointer{Bucket: s.bucket, Key: s.prefix + uuid.NewString()}
+	_, err := s.api.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(ptr.Bucket),
+		Key:           aws.String(ptr.Key),
+		Body:          strings.NewReader(body),
+		ContentLength: aws.Int64(int64(len(body))),
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store message body: %w", err)
+	}
+
+	pointer, err := json.Marshal([]any{pointerClass, ptr})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal pointer: %w", err)
+	}
+	withSize := make(map[string]string, len(attrs)+1)
+	for k, v := range attrs {
+		withSize[k] = v
+	}
+	withSize[AttributePayloadSize] = strconv.Itoa(len(body))
+	return string(pointer), withSize, nil
+}
+
+// Fetch returns the body a pointer message refers to, and the pointer.
+// Pointers outside the bucket and key prefix of the store are rejected, so
+// publishers cannot make consumers read other objects.
+func (s *Store) Fetch(ctx context.Context, pointer string) (string, Pointer, error) {
+	ptr, err := parsePointer(pointer)
+	if err != nil {
+		return "", Pointer{}, err
+	}
+	if err := s.checkPointer(ptr); err != nil {
+		return "", Pointer{}, err
+	}
+	out, err := s.api.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(ptr.Bucket), Key: aws.String(ptr.Key)})
+	if err != nil {
+		return "", ptr, fmt.Errorf("failed to fetch message body s3://%s/%s: %w", ptr.Bucket, ptr.Key, err)
+	}
+	defer out.Body.Close()
+	body, err := io.ReadAll(out.Body)
+	if err != nil {
+		return "", ptr, fmt.Errorf("failed to read message body s3://%s/%s: %w", ptr.Bucket, ptr.Key, err)
+	}
+	return string(body), ptr, nil
+}
+
+// Delete removes an offloaded body. Like Fetch, it rejects pointers outside
+// the bucket and key prefix of the store.
+func (s *Store) Delete(ctx context.Context, ptr Pointer) error {
+	if err := s.checkPointer(ptr); err != nil {
+		return err
+	}
+	_, err := s.api.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(ptr.Bucket), Key: aws.String(ptr.Key)})
+	if err != nil {
+		return fmt.Errorf("failed to delete message body s3://%s/%s: %w", ptr.Bucket, ptr.Key, err)
+	}
+	return nil
+}
+
+// checkPointer reports an error if ptr is outside the bucket and key prefix
+// of the store. Pointer messages are untrusted input: anyone able to publish
+// could otherwise reach every object the consumer role can read or delete.
+func (s *Store) checkPointer(ptr Pointer) error {
+	if ptr.Bucket != s.bucket || !strings.HasPrefix(ptr.Key, s.prefix) {
+		return fmt.Errorf("S3 pointer s3://%s/%s is outside s3://%s/%s", ptr.Bucket, ptr.Key, s.bucket, s.prefix)
+	}
+	return nil
+}
+
+// IsPointer reports whether attrs mark a pointer message.
+func IsPointer(attrs map[string]string) bool {
+	_, ok := attrs[AttributePayloadSize]
+	if !ok {
+		_, ok = attrs[legacyAttributePayloadSize]
+	}
+	return ok
+}
+
+// parsePointer decodes a pointer message body.
+func parsePointer(body string) (Pointer, error) {
+	var doc []json.RawMessage
+	if err := json.Unmarshal([]byte(body), &doc); err != nil || len(doc) != 2 {
+		return Pointer{}, fmt.Errorf("invalid S3 pointer message")
+	}
+	var ptr Pointer
+	if err := json.Unmarshal(doc[1], &ptr); err != nil || ptr.Bucket == "" || ptr.Key == "" {
+		return Pointer{}, fmt.Errorf("invalid S3 pointer message")
+	}
+	return ptr, nil
+}
+
+// messageSize returns the size SNS and SQS count for a message: the body
+// plus the name, type and value of every attribute.
+func messageSize(body string, attrs map[string]string) int {
+	n := len(body)
+	for k, v := range attrs {
+		n += len(k) + len("String") + len(v)
+	}
+	return n
+}
@@ -0,0 +1,197 @@
+package s3offload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 fakes S3API with an in-memory bucket.
+type fakeS3 struct {
+	mu        sync.Mutex
+	objects   map[string]string
+	deleteErr error
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string]string{}}
+}
+
+func (f *fakeS3) PutObject(_ context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[aws.ToString(in.Bucket)+"/"+aws.ToString(in.Key)] = string(data)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObject(_ context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.objects[aws.ToString(in.Bucket)+"/"+aws.ToString(in.Key)]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(data))}, nil
+}
+
+func (f *fakeS3) DeleteObject(_ context.Context, in *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	if f.deleteErr != nil {
+		return nil, f.deleteErr
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, aws.ToString(in.Bucket)+"/"+aws.ToString(in.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (f *fakeS3) len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.objects)
+}
+
+func TestNewConfigFromEnv(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("S3_OFFLOAD_BUCKET", "large-messages")
+	t.Setenv("S3_OFFLOAD_PREFIX", "orders/")
+	t.Setenv("S3_OFFLOAD_THRESHOLD", "1000")
+	t.Setenv("S3_OFFLOAD_ALWAYS", "true")
+	t.Setenv("S3_OFFLOAD_RETAIN", "1")
+	t.Setenv("S3_OFFLOAD_ENDPOINT", "http://localhost:4566")
+
+	cfg, err := NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		Region:        "eu-west-1",
+		Bucket:        "large-messages",
+		KeyPrefix:     "orders/",
+		Threshold:     1000,
+		AlwaysOffload: true,
+		RetainObjects: true,
+		Endpoint:      "http://localhost:4566",
+		UsePathStyle:  true,
+	}, cfg)
+}
+
+func TestNewConfigFromEnv_Invalid(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-1")
+	_, err := NewConfigFromEnv()
+	assert.ErrorContains(t, err, "S3_OFFLOAD_BUCKET is required")
+
+	t.Setenv("S3_OFFLOAD_BUCKET", "large-messages")
+	for key, value := range map[string]string{
+		"S3_OFFLOAD_THRESHOLD": "300000",
+		"S3_OFFLOAD_ALWAYS":    "sometimes",
+		"S3_OFFLOAD_RETAIN":    "forever",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			_, err := NewConfigFromEnv()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	s, err := New(&Config{Region: "us-east-1", Bucket: "b", Endpoint: "http://localhost:4566", UsePathStyle: true, AccessKeyID: "test", SecretAccessKey: "test"})
+	require.NoError(t, err)
+	opts := s.api.(*s3.Client).Options()
+	assert.True(t, opts.UsePathStyle)
+	assert.Equal(t, DefaultThreshold, s.threshold)
+
+	_, err = New(&Config{Region: "us-east-1"})
+	assert.ErrorContains(t, err, "bucket is required")
+}
+
+func TestOffloadFetch(t *testing.T) {
+	api := newFakeS3()
+	s := NewWithAPI(api, &Config{Bucket: "b", KeyPrefix: "msgs/", Threshold: 100})
+	ctx := context.Background()
+
+	body, attrs, err := s.Offload(ctx, "small", map[string]string{"tenant": "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "small", body)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, attrs)
+	assert.Zero(t, api.len())
+
+	large := strings.Repeat("x", 200)
+	pointer, attrs, err := s.Offload(ctx, large, map[string]string{"tenant": "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"tenant": "acme", AttributePayloadSize: "200"}, attrs)
+	assert.True(t, IsPointer(attrs))
+	assert.Contains(t, pointer, `["software.amazon.payloadoffloading.PayloadS3Pointer",{"s3BucketName":"b","s3Key":"msgs/`)
+
+	got, ptr, err := s.Fetch(ctx, pointer)
+	require.NoError(t, err)
+	assert.Equal(t, large, got)
+	assert.Equal(t, "b", ptr.Bucket)
+
+	require.NoError(t, s.Delete(ctx, ptr))
+	_, _, err = s.Fetch(ctx, pointer)
+	var noSuchKey *types.NoSuchKey
+	assert.ErrorAs(t, err, &noSuchKey)
+}
+
+func TestOffload_AttributesCount(t *testing.T) {
+	s := NewWithAPI(newFakeS3(), &Config{Bucket: "b", Threshold: 100})
+	body, _, err := s.Offload(context.Background(), strings.Repeat("x", 90), map[string]string{"tenant": "acme"})
+	require.NoError(t, err)
+	assert.NotEqual(t, strings.Repeat("x", 90), body)
+}
+
+func TestOffload_Always(t *testing.T) {
+	s := NewWithAPI(newFakeS3(), &Config{Bucket: "b", AlwaysOffload: true})
+	_, attrs, err := s.Offload(context.Background(), "tiny", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "4", attrs[AttributePayloadSize])
+
+	_, _, err = s.Offload(context.Background(), "tiny", map[string]string{AttributePayloadSize: "1"})
+	assert.ErrorContains(t, err, "reserved")
+}
+
+func TestFetch_InvalidPointer(t *testing.T) {
+	s := NewWithAPI(newFakeS3(), &Config{Bucket: "b"})
+	for _, body := range []string{"not json", `["class"]`, `["class",{"s3BucketName":"b"}]`} {
+		_, _, err := s.Fetch(context.Background(), body)
+		assert.ErrorContains(t, err, "invalid S3 pointer", body)
+	}
+}
+
+func TestFetchDelete_ForeignPointer(t *testing.T) {
+	api := newFakeS3()
+	api.objects["secrets/config.json"] = "secret"
+	api.objects["b/other/k"] = "other"
+	s := NewWithAPI(api, &Config{Bucket: "b", KeyPrefix: "msgs/"})
+
+	for _, ptr := range []Pointer{{Bucket: "secrets", Key: "msgs/config.json"}, {Bucket: "secrets", Key: "config.json"}, {Bucket: "b", Key: "other/k"}} {
+		body := `["` + pointerClass + `",{"s3BucketName":"` + ptr.Bucket + `","s3Key":"` + ptr.Key + `"}]`
+		_, _, err := s.Fetch(context.Background(), body)
+		assert.ErrorContains(t, err, "is outside s3://b/msgs/", ptr)
+		assert.ErrorContains(t, s.Delete(context.Background(), ptr), "is outside", ptr)
+	}
+	assert.Len(t, api.objects, 2, "foreign objects are left alone")
+}
+
+func TestIsPointer(t *testing.T) {
+	assert.True(t, IsPointer(map[string]string{"SQSLargePayloadSize": "300000"}))
+	assert.False(t, IsPointer(map[string]string{"tenant": "acme"}))
+	assert.False(t, IsPointer(nil))
+}
+
+func TestDelete_Error(t *testing.T) {
+	s := NewWithAPI(&fakeS3{deleteErr: errors.New("denied")}, &Config{Bucket: "b"})
+	assert.ErrorContains(t, s.Delete(context.Background(), Pointer{Bucket: "b", Key: "k"}), "denied")
+}
@@ -0,0 +1,186 @@
+package sns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+)
+
+// MaxBatchSize is the maximum number of messages SNS accepts in a single
+// PublishBatch call.
+const MaxBatchSize = 10
+
+// MaxBatchBytes is the maximum aggregate size of the messages, attributes
+// included, SNS accepts in a single PublishBatch call.
+const MaxBatchBytes = 256 * 1024
+
+// BatchEntryError describes a message of a batch that was not published.
+type BatchEntryError struct {
+	// Index is the position of the message in the payloads passed to the
+	// batch call.
+	Index int
+
+	// Code and Message are the error reported by SNS for the entry, or
+	// describe the failed request when the whole call failed.
+	Code    string
+	Message string
+
+	// SenderFault reports whether the failure was caused by the request
+	// (and retrying it unchanged will fail again).
+	SenderFault bool
+
+	// Err is the error of the request when the whole call failed.
+	Err error
+}
+
+// BatchError is returned by the batch publish helpers when some messages
+// could not be published. The other messages were published. It unwraps to
+// the errors of failed calls, so errors.Is(err, ErrThrottled) works.
+type BatchError struct {
+	Failed []BatchEntryError
+}
+
+func (e *BatchError) Error() string {
+	indexes := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		indexes[i] = strconv.Itoa(f.Index)
+	}
+	return fmt.Sprintf("publish batch failed for %d message(s): %s (first error: %s: %s)",
+		len(e.Failed), strings.Join(indexes, ", "), e.Failed[0].Code, e.Failed[0].Message)
+}
+
+// Unwrap returns the distinct call errors of the failed entries.
+func (e *BatchError) Unwrap() []error {
+	var errs []error
+	for _, f := range e.Failed {
+		if f.Err != nil && (len(errs) == 0 || errs[len(errs)-1] != f.Err) {
+			errs = append(errs, f.Err)
+		}
+	}
+	return errs
+}
+
+// PublishStringBatch publishes messages to an SNS topic using PublishBatch,
+// at most MaxBatchSize messages and MaxBatchBytes per call. Every message
+// carries the correlation IDs found in ctx as attributes. The returned slice holds the message ID of
+// each message, in order, or "" for messages that failed. If any message
+// failed the error is a *BatchError listing them; a failed call does not
+// stop the remaining chunks from being sent.
+func (c *Client) PublishStringBatch(ctx context.Context, topicARN string, messages []string) ([]string, error) {
+	if topicARN == "" {
+		topicARN = c.defaultARN
+	}
+	if topicARN == "" {
+		return nil, fmt.Errorf("topic ARN is required")
+	}
+
+	ids := make([]string, len(messages))
+	var failed []BatchEntryError
+	for start := 0; start < len(messages); {
+		n, chunkFailed := c.publishChunk(ctx, topicARN, messages[start:], start, ids)
+		failed = append(failed, chunkFailed...)
+		start += n
+	}
+
+	if len(failed) > 0 {
+		return ids, &BatchError{Failed: failed}
+	}
+	return ids, nil
+}
+
+// PublishJSONBatch marshals each payload as JSON and publishes them with
+// PublishStringBatch. Nothing is sent if any payload fails to marshal.
+//
+// Example:
+//
+//	ids, err := client.PublishJSONBatch(ctx, "", events)
+//	var batchErr *sns.BatchError
+//	if errors.As(err, &batchErr) {
+//	    for _, f := range batchErr.Failed {
+//	        log.Printf("event %d not published: %s", f.Index, f.Message)
+//	    }
+//	}
+func (c *Client) PublishJSONBatch(ctx context.Context, topicARN string, payloads []any) ([]string, error) {
+	messages := make([]string, len(payloads))
+	for i, payload := range payloads {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JSON at index %d: %w", i, err)
+		}
+		messages[i] = string(data)
+	}
+	return c.PublishStringBatch(ctx, topicARN, messages)
+}
+
+// publishChunk sends the first messages that fit in one PublishBatch call,
+// at least one, records their message IDs in ids and returns how many it
+// sent and the entries that failed. offset is the index of messages[0].
+func (c *Client) publishChunk(ctx context.Context, topicARN string, messages []string, offset int, ids []string) (int, []BatchEntryError) {
+	merged := correlation.Merge(ctx, nil)
+	ctx, span := c.tracing.start(ctx, topicARN, merged)
+	attrs := messageAttributes(merged)
+	attrsSize := 0
+	for k, v := range merged {
+		attrsSize += len(k) + len("String") + len(v)
+	}
+
+	var entries []types.PublishBatchRequestEntry
+	size := 0
+	for i, msg := range messages {
+		entrySize := len(msg) + attrsSize
+		if i == MaxBatchSize || i > 0 && size+entrySize > MaxBatchBytes {
+			break
+		}
+		size += entrySize
+		entries = append(entries, types.PublishBatchRequestEntry{
+			Id:                aws.String(strconv.Itoa(offset + i)),
+			Message:           aws.String(msg),
+			MessageAttributes: attrs,
+		})
+	}
+	messages = messages[:len(entries)]
+
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	out, err := c.snsClient.PublishBatch(ctx, &sns.PublishBatchInput{
+		TopicArn:                   aws.String(topicARN),
+		PublishBatchRequestEntries: entries,
+	})
+	endSpan(span, "", err)
+	if err != nil {
+		err = wrapError("publish batch", err)
+		failed := make([]BatchEntryError, len(messages))
+		for i := range messages {
+			failed[i] = BatchEntryError{Index: offset + i, Code: "RequestFailed", Message: err.Error(), Err: err}
+		}
+		return len(messages), failed
+	}
+
+	for _, s := range out.Successful {
+		if i, err := strconv.Atoi(aws.ToString(s.Id)); err == nil && i >= 0 && i < len(ids) {
+			ids[i] = aws.ToString(s.MessageId)
+		}
+	}
+
+	var failed []BatchEntryError
+	for _, f := range out.Failed {
+		i, err := strconv.Atoi(aws.ToString(f.Id))
+		if err != nil {
+			continue
+		}
+		failed = append(failed, BatchEntryError{
+			Index:       i,
+			Code:        aws.ToString(f.Code),
+			Message:     aws.ToString(f.Message),
+			SenderFault: f.SenderFault,
+		})
+	}
+	return len(messages), failed
+}
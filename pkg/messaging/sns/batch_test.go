@@ -0,0 +1,156 @@
+package sns
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchSNSClient fakes PublishBatch, failing the entries whose ID is in
+// failIDs and every call once callErr is set.
+type batchSNSClient struct {
+	mockSNSClient
+	calls   []*sns.PublishBatchInput
+	failIDs map[string]bool
+	callErr error
+}
+
+func (m *batchSNSClient) PublishBatch(_ context.Context, input *sns.PublishBatchInput, _ ...func(*sns.Options)) (*sns.PublishBatchOutput, error) {
+	m.calls = append(m.calls, input)
+	if m.callErr != nil {
+		return nil, m.callErr
+	}
+
+	out := &sns.PublishBatchOutput{}
+	for _, e := range input.PublishBatchRequestEntries {
+		id := aws.ToString(e.Id)
+		if m.failIDs[id] {
+			out.Failed = append(out.Failed, types.BatchResultErrorEntry{
+				Id:          e.Id,
+				Code:        aws.String("InvalidParameter"),
+				Message:     aws.String("bad message"),
+				SenderFault: true,
+			})
+			continue
+		}
+		out.Successful = append(out.Successful, types.PublishBatchResultEntry{
+			Id:        e.Id,
+			MessageId: aws.String("msg-" + id),
+		})
+	}
+	return out, nil
+}
+
+func TestPublishJSONBatch_Chunks(t *testing.T) {
+	mock := &batchSNSClient{}
+	c := &Client{snsClient: mock, defaultARN: "arn:aws:sns:us-east-1:123456789012:test"}
+
+	payloads := make([]any, 23)
+	for i := range payloads {
+		payloads[i] = map[string]int{"n": i}
+	}
+
+	ids, err := c.PublishJSONBatch(context.Background(), "", payloads)
+	require.NoError(t, err)
+	require.Len(t, ids, 23)
+	for i, id := range ids {
+		assert.Equal(t, "msg-"+strconv.Itoa(i), id)
+	}
+
+	require.Len(t, mock.calls, 3)
+	assert.Len(t, mock.calls[0].PublishBatchRequestEntries, 10)
+	assert.Len(t, mock.calls[1].PublishBatchRequestEntries, 10)
+	assert.Len(t, mock.calls[2].PublishBatchRequestEntries, 3)
+	assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:test", aws.ToString(mock.calls[0].TopicArn))
+	assert.JSONEq(t, `{"n":12}`, aws.ToString(mock.calls[1].PublishBatchRequestEntries[2].Message))
+}
+
+func TestPublishStringBatch_SplitsBySize(t *testing.T) {
+	mock := &batchSNSClient{}
+	c := &Client{snsClient: mock, defaultARN: "arn:aws:sns:us-east-1:123456789012:test"}
+
+	// Three 100 KB messages cannot share a 256 KB batch
+	big := strings.Repeat("x", 100*1024)
+	ids, err := c.PublishStringBatch(context.Background(), "", []string{big, big, big, "small"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"msg-0", "msg-1", "msg-2", "msg-3"}, ids)
+
+	require.Len(t, mock.calls, 2)
+	assert.Len(t, mock.calls[0].PublishBatchRequestEntries, 2)
+	assert.Len(t, mock.calls[1].PublishBatchRequestEntries, 2)
+}
+
+func TestPublishStringBatch_CorrelationAttributes(t *testing.T) {
+	mock := &batchSNSClient{}
+	c := &Client{snsClient: mock, defaultARN: "arn:aws:sns:us-east-1:123456789012:test"}
+
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1"})
+	_, err := c.PublishStringBatch(ctx, "", []string{"a", "b"})
+	require.NoError(t, err)
+
+	for _, e := range mock.calls[0].PublishBatchRequestEntries {
+		assert.Equal(t, "req-1", aws.ToString(e.MessageAttributes[AttributeRequestID].StringValue))
+	}
+}
+
+func TestPublishJSONBatch_PartialFailure(t *testing.T) {
+	mock := &batchSNSClient{failIDs: map[string]bool{"1": true, "11": true}}
+	c := &Client{snsClient: mock}
+
+	payloads := make([]any, 12)
+	for i := range payloads {
+		payloads[i] = i
+	}
+
+	ids, err := c.PublishJSONBatch(context.Background(), "arn:topic", payloads)
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	require.Len(t, batchErr.Failed, 2)
+	assert.Equal(t, 1, batchErr.Failed[0].Index)
+	assert.Equal(t, 11, batchErr.Failed[1].Index)
+	assert.Equal(t, "InvalidParameter", batchErr.Failed[0].Code)
+	assert.True(t, batchErr.Failed[0].SenderFault)
+	assert.Contains(t, err.Error(), "1, 11")
+
+	assert.Equal(t, "msg-0", ids[0])
+	assert.Empty(t, ids[1])
+	assert.Equal(t, "msg-10", ids[10])
+	assert.Empty(t, ids[11])
+}
+
+func TestPublishStringBatch_CallError(t *testing.T) {
+	mock := &batchSNSClient{callErr: errors.New("throttled")}
+	c := &Client{snsClient: mock}
+
+	ids, err := c.PublishStringBatch(context.Background(), "arn:topic", []string{"a", "b"})
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	require.Len(t, batchErr.Failed, 2)
+	assert.Contains(t, batchErr.Failed[1].Message, "throttled")
+	assert.Equal(t, []string{"", ""}, ids)
+}
+
+func TestPublishJSONBatch_MarshalError(t *testing.T) {
+	mock := &batchSNSClient{}
+	c := &Client{snsClient: mock}
+
+	_, err := c.PublishJSONBatch(context.Background(), "arn:topic", []any{1, make(chan int)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "index 1")
+	assert.Empty(t, mock.calls)
+}
+
+func TestPublishStringBatch_MissingARN(t *testing.T) {
+	c := &Client{snsClient: &batchSNSClient{}}
+	_, err := c.PublishStringBatch(context.Background(), "", []string{"a"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package sns
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/smithy-go"
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+)
+
+// Errors matched with errors.Is on the errors returned by Client. Such
+// errors are also *errcode.AppError values (errcode.RateLimited and
+// errcode.PermissionDenied), so errcode.IsRetryable and the messaging
+// helpers classify them without knowing about SNS.
+var (
+	// ErrThrottled is returned when SNS rejected the call because a rate
+	// limit was exceeded, after the configured retries.
+	ErrThrottled = errors.New("sns request throttled")
+
+	// ErrUnauthorized is returned when the credentials are invalid,
+	// expired or not allowed to perform the call. Retrying will not help.
+	ErrUnauthorized = errors.New("sns request not authorized")
+)
+
+// throttlingCodes are the error codes SNS and KMS (for encrypted topics)
+// return when throttling.
+var throttlingCodes = map[string]bool{
+	"Throttling":                 true,
+	"ThrottlingException":        true,
+	"ThrottledException":         true,
+	"RequestThrottled":           true,
+	"TooManyRequestsException":   true,
+	"RequestLimitExceeded":       true,
+	"KMSThrottling":              true,
+	"KMSThrottlingException":     true,
+	"ProvisionedThroughputError": true,
+}
+
+// authCodes are the error codes returned for authentication and
+// authorization failures.
+var authCodes = map[string]bool{
+	"AuthorizationError":          true,
+	"AccessDenied":                true,
+	"AccessDeniedException":       true,
+	"InvalidClientTokenId":        true,
+	"UnrecognizedClientException": true,
+	"ExpiredToken":                true,
+	"ExpiredTokenException":       true,
+	"SignatureDoesNotMatch":       true,
+	"KMSAccessDenied":             true,
+	"KMSAccessDeniedException":    true,
+}
+
+// wrapError annotates a failed call of op with ErrThrottled or
+// ErrUnauthorized when the API error code says so.
+func wrapError(op string, err error) error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return fmt.Errorf("%s failed: %w", op, err)
+	}
+
+	switch code := apiErr.ErrorCode(); {
+	case throttlingCodes[code]:
+		return errcode.Wrap(fmt.Errorf("%s failed: %w: %w", op, ErrThrottled, err), errcode.RateLimited, "")
+	case authCodes[code]:
+		return errcode.Wrap(fmt.Errorf("%s failed: %w: %w", op, ErrUnauthorized, err), errcode.PermissionDenied, "")
+	}
+	return fmt.Errorf("%s failed: %w", op, err)
+}
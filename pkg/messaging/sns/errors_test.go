@@ -0,0 +1,121 @@
+package sns
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/smithy-go"
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func apiError(code string) error {
+	return &smithy.GenericAPIError{Code: code, Message: code + " message"}
+}
+
+func TestPublishString_ThrottledError(t *testing.T) {
+	c := &Client{snsClient: &mockSNSClient{err: apiError("Throttling")}, defaultARN: "arn:topic"}
+
+	_, err := c.PublishString(context.Background(), "", "hi")
+	require.ErrorIs(t, err, ErrThrottled)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+	assert.True(t, errcode.IsRetryable(err))
+	assert.Equal(t, errcode.RateLimited, errcode.Of(err))
+
+	var apiErr smithy.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "Throttling", apiErr.ErrorCode())
+}
+
+func TestPublishString_UnauthorizedError(t *testing.T) {
+	for _, code := range []string{"AuthorizationError", "InvalidClientTokenId", "ExpiredToken"} {
+		c := &Client{snsClient: &mockSNSClient{err: apiError(code)}, defaultARN: "arn:topic"}
+
+		_, err := c.PublishString(context.Background(), "", "hi")
+		assert.ErrorIs(t, err, ErrUnauthorized, code)
+		assert.False(t, errcode.IsRetryable(err), code)
+		assert.Equal(t, errcode.PermissionDenied, errcode.Of(err), code)
+	}
+}
+
+func TestPublishString_OtherErrorsAreUnclassified(t *testing.T) {
+	c := &Client{snsClient: &mockSNSClient{err: apiError("NotFound")}, defaultARN: "arn:topic"}
+
+	_, err := c.PublishString(context.Background(), "", "hi")
+	assert.NotErrorIs(t, err, ErrThrottled)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+	assert.EqualError(t, err, "publish failed: api error NotFound: NotFound message")
+}
+
+// slowSNSClient blocks every call until ctx is done.
+type slowSNSClient struct {
+	mockSNSClient
+}
+
+func (m *slowSNSClient) Publish(ctx context.Context, _ *sns.PublishInput, _ ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestPublishString_Timeout(t *testing.T) {
+	c := &Client{snsClient: &slowSNSClient{}, defaultARN: "arn:topic", timeout: 10 * time.Millisecond}
+
+	start := time.Now()
+	_, err := c.PublishString(context.Background(), "", "hi")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, errcode.Timeout, errcode.Of(err))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestPublishStringBatch_UnwrapsCallErrors(t *testing.T) {
+	c := &Client{snsClient: &batchSNSClient{callErr: apiError("Throttling")}}
+
+	payloads := make([]string, 12)
+	_, err := c.PublishStringBatch(context.Background(), "arn:topic", payloads)
+
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Unwrap(), 2, "one error per failed call")
+	assert.ErrorIs(t, err, ErrThrottled)
+}
+
+func TestNew_RetrySettings(t *testing.T) {
+	c, err := New(&Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "test",
+		SecretAccessKey: "secret",
+		MaxAttempts:     7,
+		MaxBackoff:      2 * time.Second,
+		Timeout:         5 * time.Second,
+	})
+	require.NoError(t, err)
+
+	opts := c.snsClient.(*sns.Client).Options()
+	assert.Equal(t, 7, opts.Retryer.MaxAttempts())
+	assert.Equal(t, 5*time.Second, c.timeout)
+
+	delay, err := opts.Retryer.RetryDelay(10, errors.New("throttled"))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, delay, 2*time.Second)
+}
+
+func TestNewConfigFromEnv_Retry(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("SNS_MAX_ATTEMPTS", "6")
+	t.Setenv("SNS_MAX_BACKOFF", "3s")
+	t.Setenv("SNS_TIMEOUT", "10s")
+
+	cfg, err := NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 6, cfg.MaxAttempts)
+	assert.Equal(t, 3*time.Second, cfg.MaxBackoff)
+	assert.Equal(t, 10*time.Second, cfg.Timeout)
+
+	t.Setenv("SNS_MAX_ATTEMPTS", "0")
+	_, err = NewConfigFromEnv()
+	assert.Error(t, err)
+}
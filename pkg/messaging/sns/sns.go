@@ -5,16 +5,35 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/awsconfig"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/env"
+)
+
+// Attribute names used to propagate request correlation IDs between
+// services. They match the Kafka header and SQS attribute names, so they
+// survive SNS to SQS delivery.
+const (
+	AttributeRequestID   = correlation.RequestID
+	AttributeTraceID     = correlation.TraceID
+	AttributeSpanID      = correlation.SpanID
+	AttributeTraceParent = correlation.TraceParent
+	AttributeTraceState  = correlation.TraceState
 )
 
 // SNSAPI defines the subset of sns.Client methods we use.
 // This makes it mockable in tests.
 type SNSAPI interface {
 	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+	PublishBatch(ctx context.Context, params *sns.PublishBatchInput, optFns ...func(*sns.Options)) (*sns.PublishBatchOutput, error)
+	Subscribe(ctx context.Context, params *sns.SubscribeInput, optFns ...func(*sns.Options)) (*sns.SubscribeOutput, error)
 }
 
 // Publisher defines the interface for publishing SNS messages.
@@ -27,15 +46,59 @@ type Publisher interface {
 type Client struct {
 	snsClient  SNSAPI
 	defaultARN string
+	timeout    time.Duration
+	tracing    *tracing
 }
 
 // Config holds optional configuration for SNS setup.
 type Config struct {
 	Region   string
 	TopicARN string
+
+	// Endpoint overrides the SNS endpoint, e.g. "http://localhost:4566" for
+	// LocalStack.
+	Endpoint string
+
+	// Profile selects a profile of the shared config and credentials files
+	// instead of AWS_PROFILE.
+	Profile string
+
+	// AccessKeyID and SecretAccessKey (and optionally SessionToken) replace
+	// the default credential chain with static credentials.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// RoleARN is assumed with the credentials above, e.g. to publish to a
+	// topic in another account.
+	RoleARN string
+
+	// MaxAttempts is the number of attempts per call, including the first
+	// (SDK default, 3, when zero). Throttled calls are retried with
+	// exponential backoff and jitter.
+	MaxAttempts int
+
+	// MaxBackoff caps the delay between attempts (SDK default, 20s, when
+	// zero).
+	MaxBackoff time.Duration
+
+	// Timeout bounds each call, including its retries, unless ctx has an
+	// earlier deadline. Zero leaves calls bounded by ctx only.
+	Timeout time.Duration
 }
 
-// NewConfigFromEnv builds configuration from environment variables.
+// NewConfigFromEnv builds configuration from environment variables:
+//
+//	AWS_REGION        required
+//	SNS_TOPIC_ARN     default topic
+//	SNS_ENDPOINT      endpoint override
+//	SNS_ROLE_ARN      role to assume
+//	SNS_MAX_ATTEMPTS  integer, attempts per call
+//	SNS_MAX_BACKOFF   duration, e.g. 5s
+//	SNS_TIMEOUT       duration, e.g. 10s
+//
+// Credentials and profiles are read by the AWS SDK from its usual
+// environment variables and shared files.
 func NewConfigFromEnv() (*Config, error) {
 	region := os.Getenv("AWS_REGION")
 	if region == "" {
@@ -43,26 +106,107 @@ func NewConfigFromEnv() (*Config, error) {
 	}
 
 	topic := os.Getenv("SNS_TOPIC_ARN")
-	return &Config{
+	cfg := &Config{
 		Region:   region,
 		TopicARN: topic,
-	}, nil
+		Endpoint: os.Getenv("SNS_ENDPOINT"),
+		RoleARN:  os.Getenv("SNS_ROLE_ARN"),
+	}
+
+	var err error
+	if v := os.Getenv("SNS_MAX_ATTEMPTS"); v != "" {
+		if cfg.MaxAttempts, err = strconv.Atoi(v); err != nil || cfg.MaxAttempts < 1 {
+			return nil, fmt.Errorf("invalid SNS_MAX_ATTEMPTS %q", v)
+		}
+	}
+	if cfg.MaxBackoff, err = env.Duration("SNS_MAX_BACKOFF"); err != nil {
+		return nil, err
+	}
+	if cfg.Timeout, err = env.Duration("SNS_TIMEOUT"); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
 
-// New creates a new SNS client from AWS credentials/config in the environment.
-func New(cfg *Config) (*Client, error) {
-	awsCfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(cfg.Region))
+// New creates a new SNS client from AWS credentials/config in the environment,
+// applying the overrides set in cfg.
+//
+// Example:
+//
+//	// LocalStack
+//	client, err := sns.New(&sns.Config{
+//	    Region:          "us-east-1",
+//	    Endpoint:        "http://localhost:4566",
+//	    AccessKeyID:     "test",
+//	    SecretAccessKey: "test",
+//	})
+func New(cfg *Config, opts ...Option) (*Client, error) {
+	awsCfg, err := awsconfig.Load(context.Background(), awsconfig.Options{
+		Region:          cfg.Region,
+		Profile:         cfg.Profile,
+		AccessKeyID:     cfg.AccessKeyID,
+		SecretAccessKey: cfg.SecretAccessKey,
+		SessionToken:    cfg.SessionToken,
+		RoleARN:         cfg.RoleARN,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+		return nil, err
 	}
-	return &Client{
-		snsClient:  sns.NewFromConfig(awsCfg),
+	c := &Client{
+		snsClient: sns.NewFromConfig(awsCfg, func(o *sns.Options) {
+			if cfg.Endpoint != "" {
+				o.BaseEndpoint = aws.String(cfg.Endpoint)
+			}
+			if cfg.MaxAttempts > 0 {
+				o.RetryMaxAttempts = cfg.MaxAttempts
+			}
+			if cfg.MaxBackoff > 0 {
+				o.Retryer = retry.AddWithMaxBackoffDelay(o.Retryer, cfg.MaxBackoff)
+			}
+		}),
 		defaultARN: cfg.TopicARN,
-	}, nil
+		timeout:    cfg.Timeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
-// PublishString publishes a plain string message to an SNS topic.
+// callContext applies the configured per-call timeout to ctx.
+func (c *Client) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout > 0 {
+		return context.WithTimeout(ctx, c.timeout)
+	}
+	return ctx, func() {}
+}
+
+// PublishOptions sets the optional fields of a published message.
+type PublishOptions struct {
+	// Attributes are sent as string message attributes, in addition to the
+	// correlation IDs found in ctx. SNS allows at most 10 per message.
+	Attributes map[string]string
+
+	// Subject is used by email subscriptions.
+	Subject string
+
+	// GroupID and DeduplicationID are required by FIFO topics (the latter
+	// unless content-based deduplication is enabled).
+	GroupID         string
+	DeduplicationID string
+}
+
+// PublishString publishes a plain string message to an SNS topic. Throttling
+// and authorization failures match ErrThrottled and ErrUnauthorized.
 func (c *Client) PublishString(ctx context.Context, topicARN, message string) (string, error) {
+	return c.PublishStringWithOptions(ctx, topicARN, message, PublishOptions{})
+}
+
+// PublishStringWithOptions publishes a plain string message with the
+// attributes and FIFO settings in opts. The request_id, trace_id, span_id,
+// traceparent and tracestate set by the logger middleware are read from ctx
+// and added as attributes unless opts already defines them.
+func (c *Client) PublishStringWithOptions(ctx context.Context, topicARN, message string, opts PublishOptions) (string, error) {
 	if topicARN == "" {
 		topicARN = c.defaultARN
 	}
@@ -70,14 +214,48 @@ func (c *Client) PublishString(ctx context.Context, topicARN, message string) (s
 		return "", fmt.Errorf("topic ARN is required")
 	}
 
-	out, err := c.snsClient.Publish(ctx, &sns.PublishInput{
-		Message:  aws.String(message),
-		TopicArn: aws.String(topicARN),
-	})
+	attrs := correlation.Merge(ctx, opts.Attributes)
+	ctx, span := c.tracing.start(ctx, topicARN, attrs)
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	input := &sns.PublishInput{
+		Message:           aws.String(message),
+		TopicArn:          aws.String(topicARN),
+		MessageAttributes: messageAttributes(attrs),
+	}
+	if opts.Subject != "" {
+		input.Subject = aws.String(opts.Subject)
+	}
+	if opts.GroupID != "" {
+		input.MessageGroupId = aws.String(opts.GroupID)
+	}
+	if opts.DeduplicationID != "" {
+		input.MessageDeduplicationId = aws.String(opts.DeduplicationID)
+	}
+
+	out, err := c.snsClient.Publish(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("publish failed: %w", err)
+		err = wrapError("publish", err)
+		endSpan(span, "", err)
+		return "", err
+	}
+	id := aws.ToString(out.MessageId)
+	endSpan(span, id, nil)
+	return id, nil
+}
+
+// messageAttributes converts attrs to string message attributes. It returns
+// nil when there are none.
+func messageAttributes(attrs map[string]string) map[string]types.MessageAttributeValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	out := make(map[string]types.MessageAttributeValue, len(attrs))
+	for k, v := range attrs {
+		out[k] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
 	}
-	return aws.ToString(out.MessageId), nil
+	return out
 }
 
 // PublishJSON marshals a struct as JSON and publishes it.
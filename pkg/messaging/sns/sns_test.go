@@ -4,11 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // mockSNSClient fakes SNSAPI for testing.
@@ -25,6 +29,14 @@ func (m *mockSNSClient) Publish(ctx context.Context, input *sns.PublishInput, _
 	return &sns.PublishOutput{MessageId: aws.String("msg-123")}, nil
 }
 
+func (m *mockSNSClient) PublishBatch(context.Context, *sns.PublishBatchInput, ...func(*sns.Options)) (*sns.PublishBatchOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockSNSClient) Subscribe(context.Context, *sns.SubscribeInput, ...func(*sns.Options)) (*sns.SubscribeOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
 func TestPublishString_Success(t *testing.T) {
 	mock := &mockSNSClient{}
 	c := &Client{snsClient: mock, defaultARN: "arn:aws:sns:us-east-1:123456789012:test"}
@@ -86,3 +98,91 @@ func TestNewConfigFromEnv_MissingRegion(t *testing.T) {
 	_, err := NewConfigFromEnv()
 	assert.Error(t, err)
 }
+
+func TestNewConfigFromEnv_Overrides(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("SNS_ENDPOINT", "http://localhost:4566")
+	t.Setenv("SNS_ROLE_ARN", "arn:aws:iam::210987654321:role/publisher")
+
+	cfg, err := NewConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:4566", cfg.Endpoint)
+	assert.Equal(t, "arn:aws:iam::210987654321:role/publisher", cfg.RoleARN)
+}
+
+func TestNew_EndpointAndStaticCredentials(t *testing.T) {
+	c, err := New(&Config{
+		Region:          "us-east-1",
+		Endpoint:        "http://localhost:4566",
+		AccessKeyID:     "test",
+		SecretAccessKey: "secret",
+	})
+	require.NoError(t, err)
+
+	opts := c.snsClient.(*sns.Client).Options()
+	assert.Equal(t, "http://localhost:4566", aws.ToString(opts.BaseEndpoint))
+	creds, err := opts.Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "test", creds.AccessKeyID)
+	assert.Equal(t, "secret", creds.SecretAccessKey)
+}
+
+func TestNew_Profile(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config")
+	require.NoError(t, os.WriteFile(configFile, []byte("[profile localstack]\nregion = eu-central-1\n"), 0o600))
+	credsFile := filepath.Join(dir, "credentials")
+	require.NoError(t, os.WriteFile(credsFile, []byte("[localstack]\naws_access_key_id = profile-key\naws_secret_access_key = profile-secret\n"), 0o600))
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsFile)
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	c, err := New(&Config{Profile: "localstack"})
+	require.NoError(t, err)
+
+	opts := c.snsClient.(*sns.Client).Options()
+	assert.Equal(t, "eu-central-1", opts.Region)
+	creds, err := opts.Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "profile-key", creds.AccessKeyID)
+}
+
+func TestNew_IncompleteStaticCredentials(t *testing.T) {
+	_, err := New(&Config{Region: "us-east-1", AccessKeyID: "test"})
+	assert.Error(t, err)
+}
+
+func TestPublishStringWithOptions(t *testing.T) {
+	mock := &mockSNSClient{}
+	c := &Client{snsClient: mock, defaultARN: "arn:aws:sns:us-east-1:123456789012:orders.fifo"}
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1", TraceID: "trace-1"})
+
+	_, err := c.PublishStringWithOptions(ctx, "", "hello", PublishOptions{
+		Attributes:      map[string]string{"event_type": "order.created", AttributeTraceID: "explicit"},
+		Subject:         "New order",
+		GroupID:         "order-1",
+		DeduplicationID: "dedup-1",
+	})
+	require.NoError(t, err)
+
+	in := mock.lastInput
+	assert.Equal(t, "New order", aws.ToString(in.Subject))
+	assert.Equal(t, "order-1", aws.ToString(in.MessageGroupId))
+	assert.Equal(t, "dedup-1", aws.ToString(in.MessageDeduplicationId))
+	assert.Equal(t, "order.created", aws.ToString(in.MessageAttributes["event_type"].StringValue))
+	assert.Equal(t, "req-1", aws.ToString(in.MessageAttributes[AttributeRequestID].StringValue))
+	assert.Equal(t, "explicit", aws.ToString(in.MessageAttributes[AttributeTraceID].StringValue))
+	assert.Equal(t, "String", aws.ToString(in.MessageAttributes[AttributeRequestID].DataType))
+}
+
+func TestPublishString_NoAttributesWithoutMetadata(t *testing.T) {
+	mock := &mockSNSClient{}
+	c := &Client{snsClient: mock, defaultARN: "arn:topic"}
+
+	_, err := c.PublishString(context.Background(), "", "hi")
+	require.NoError(t, err)
+	assert.Nil(t, mock.lastInput.MessageAttributes)
+	assert.Nil(t, mock.lastInput.MessageGroupId)
+}
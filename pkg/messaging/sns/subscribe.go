@@ -0,0 +1,203 @@
+package sns
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// QueueAPI defines the subset of sqs.Client methods SubscribeQueue uses.
+type QueueAPI interface {
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+	SetQueueAttributes(ctx context.Context, params *sqs.SetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.SetQueueAttributesOutput, error)
+}
+
+// Filter policy scopes.
+const (
+	FilterScopeMessageAttributes = "MessageAttributes"
+	FilterScopeMessageBody       = "MessageBody"
+)
+
+// SubscriptionOptions configures SubscribeQueue.
+type SubscriptionOptions struct {
+	// RawMessageDelivery delivers the published message as the SQS body
+	// instead of wrapping it in the SNS JSON envelope.
+	RawMessageDelivery bool
+
+	// FilterPolicy restricts the messages delivered to the queue, e.g.
+	// {"event_type": ["order.created"]}. Nil delivers every message.
+	FilterPolicy map[string]any
+
+	// FilterPolicyScope is FilterScopeMessageAttributes (the SNS default)
+	// or FilterScopeMessageBody.
+	FilterPolicyScope string
+}
+
+// SubscribeQueue subscribes the SQS queue at queueURL to topicARN (the
+// default topic if empty) and returns the subscription ARN. The queue
+// policy is extended to let the topic send messages to the queue; existing
+// statements are kept. Calling it again with the same arguments is a
+// no-op, so services can run it at startup.
+//
+// Example:
+//
+//	arn, err := client.SubscribeQueue(ctx, sqsClient, "", queueURL, sns.SubscriptionOptions{
+//	    RawMessageDelivery: true,
+//	    FilterPolicy:       map[string]any{"event_type": []string{"order.created"}},
+//	})
+func (c *Client) SubscribeQueue(ctx context.Context, queues QueueAPI, topicARN, queueURL string, opts SubscriptionOptions) (string, error) {
+	if topicARN == "" {
+		topicARN = c.defaultARN
+	}
+	if topicARN == "" {
+		return "", fmt.Errorf("topic ARN is required")
+	}
+	if queueURL == "" {
+		return "", fmt.Errorf("queue URL is required")
+	}
+
+	attrs, err := subscriptionAttributes(opts)
+	if err != nil {
+		return "", err
+	}
+
+	queueARN, err := allowTopic(ctx, queues, queueURL, topicARN)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	out, err := c.snsClient.Subscribe(ctx, &sns.SubscribeInput{
+		TopicArn:              aws.String(topicARN),
+		Protocol:              aws.String("sqs"),
+		Endpoint:              aws.String(queueARN),
+		Attributes:            attrs,
+		ReturnSubscriptionArn: true,
+	})
+	if err != nil {
+		return "", wrapError("subscribe", err)
+	}
+	return aws.ToString(out.SubscriptionArn), nil
+}
+
+// subscriptionAttributes converts opts to SNS subscription attributes.
+func subscriptionAttributes(opts SubscriptionOptions) (map[string]string, error) {
+	attrs := map[string]string{}
+	if opts.RawMessageDelivery {
+		attrs["RawMessageDelivery"] = "true"
+	}
+	if opts.FilterPolicy != nil {
+		policy, err := json.Marshal(opts.FilterPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal filter policy: %w", err)
+		}
+		attrs["FilterPolicy"] = string(policy)
+	}
+	switch opts.FilterPolicyScope {
+	case "":
+	case FilterScopeMessageAttributes, FilterScopeMessageBody:
+		if opts.FilterPolicy == nil {
+			return nil, fmt.Errorf("filter policy scope requires a filter policy")
+		}
+		attrs["FilterPolicyScope"] = opts.FilterPolicyScope
+	default:
+		return nil, fmt.Errorf("invalid filter policy scope %q", opts.FilterPolicyScope)
+	}
+	return attrs, nil
+}
+
+// allowTopic adds a statement to the queue policy that lets topicARN send
+// messages to the queue, unless it is already present, and returns the
+// queue ARN.
+func allowTopic(ctx context.Context, queues QueueAPI, queueURL, topicARN string) (string, error) {
+	out, err := queues.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn, sqstypes.QueueAttributeNamePolicy},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read queue attributes: %w", err)
+	}
+	queueARN := out.Attributes[string(sqstypes.QueueAttributeNameQueueArn)]
+	if queueARN == "" {
+		return "", fmt.Errorf("queue %s has no ARN", queueURL)
+	}
+
+	policy, changed, err := withTopicStatement(out.Attributes[string(sqstypes.QueueAttributeNamePolicy)], queueARN, topicARN)
+	if err != nil {
+		return "", err
+	}
+	if !changed {
+		return queueARN, nil
+	}
+
+	_, err = queues.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(queueURL),
+		Attributes: map[string]string{string(sqstypes.QueueAttributeNamePolicy): policy},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to update queue policy: %w", err)
+	}
+	return queueARN, nil
+}
+
+// withTopicStatement returns policy with a statement allowing topicARN to
+// send to queueARN, and whether the statement had to be added.
+func withTopicStatement(policy, queueARN, topicARN string) (string, bool, error) {
+	doc := map[string]any{}
+	if policy != "" {
+		if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+			return "", false, fmt.Errorf("invalid queue policy: %w", err)
+		}
+	}
+	if _, ok := doc["Version"]; !ok {
+		doc["Version"] = "2012-10-17"
+	}
+
+	// Statement may be a single object or a list.
+	var statements []any
+	switch s := doc["Statement"].(type) {
+	case []any:
+		statements = s
+	case map[string]any:
+		statements = []any{s}
+	}
+
+	sid := topicStatementID(topicARN)
+	for _, s := range statements {
+		if m, ok := s.(map[string]any); ok && m["Sid"] == sid {
+			return policy, false, nil
+		}
+	}
+
+	doc["Statement"] = append(statements, map[string]any{
+		"Sid":       sid,
+		"Effect":    "Allow",
+		"Principal": map[string]any{"Service": "sns.amazonaws.com"},
+		"Action":    "sqs:SendMessage",
+		"Resource":  queueARN,
+		"Condition": map[string]any{
+			"ArnEquals": map[string]any{"aws:SourceArn": topicARN},
+		},
+	})
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to marshal queue policy: %w", err)
+	}
+	return string(out), true, nil
+}
+
+// topicStatementID derives an alphanumeric policy statement ID from a hash
+// of topicARN. Hashing the whole ARN keeps IDs distinct across partitions
+// and for names that only differ in "-" or "_".
+func topicStatementID(topicARN string) string {
+	sum := sha256.Sum256([]byte(topicARN))
+	return "AllowSNS" + hex.EncodeToString(sum[:16])
+}
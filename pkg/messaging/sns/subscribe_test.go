@@ -0,0 +1,148 @@
+package sns
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTopicARN = "arn:aws:sns:us-east-1:123456789012:orders"
+	testQueueARN = "arn:aws:sqs:us-east-1:123456789012:billing"
+	testQueueURL = "https://sqs.us-east-1.amazonaws.com/123456789012/billing"
+)
+
+// subscribeSNSClient records Subscribe calls.
+type subscribeSNSClient struct {
+	mockSNSClient
+	subscribed *sns.SubscribeInput
+}
+
+func (m *subscribeSNSClient) Subscribe(_ context.Context, input *sns.SubscribeInput, _ ...func(*sns.Options)) (*sns.SubscribeOutput, error) {
+	m.subscribed = input
+	return &sns.SubscribeOutput{SubscriptionArn: aws.String(testTopicARN + ":sub-1")}, nil
+}
+
+// fakeQueue stores queue attributes in memory.
+type fakeQueue struct {
+	attrs map[string]string
+	sets  int
+}
+
+func (q *fakeQueue) GetQueueAttributes(context.Context, *sqs.GetQueueAttributesInput, ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	out := map[string]string{}
+	for k, v := range q.attrs {
+		out[k] = v
+	}
+	return &sqs.GetQueueAttributesOutput{Attributes: out}, nil
+}
+
+func (q *fakeQueue) SetQueueAttributes(_ context.Context, input *sqs.SetQueueAttributesInput, _ ...func(*sqs.Options)) (*sqs.SetQueueAttributesOutput, error) {
+	q.sets++
+	for k, v := range input.Attributes {
+		q.attrs[k] = v
+	}
+	return &sqs.SetQueueAttributesOutput{}, nil
+}
+
+func policyStatements(t *testing.T, policy string) []map[string]any {
+	t.Helper()
+	var doc struct {
+		Statement []map[string]any
+	}
+	require.NoError(t, json.Unmarshal([]byte(policy), &doc))
+	return doc.Statement
+}
+
+func TestSubscribeQueue(t *testing.T) {
+	api := &subscribeSNSClient{}
+	queue := &fakeQueue{attrs: map[string]string{"QueueArn": testQueueARN}}
+	c := &Client{snsClient: api, defaultARN: testTopicARN}
+
+	arn, err := c.SubscribeQueue(context.Background(), queue, "", testQueueURL, SubscriptionOptions{
+		RawMessageDelivery: true,
+		FilterPolicy:       map[string]any{"event_type": []string{"order.created"}},
+		FilterPolicyScope:  FilterScopeMessageAttributes,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, testTopicARN+":sub-1", arn)
+
+	in := api.subscribed
+	require.NotNil(t, in)
+	assert.Equal(t, "sqs", aws.ToString(in.Protocol))
+	assert.Equal(t, testQueueARN, aws.ToString(in.Endpoint))
+	assert.Equal(t, testTopicARN, aws.ToString(in.TopicArn))
+	assert.True(t, in.ReturnSubscriptionArn)
+	assert.Equal(t, "true", in.Attributes["RawMessageDelivery"])
+	assert.JSONEq(t, `{"event_type":["order.created"]}`, in.Attributes["FilterPolicy"])
+	assert.Equal(t, FilterScopeMessageAttributes, in.Attributes["FilterPolicyScope"])
+
+	statements := policyStatements(t, queue.attrs["Policy"])
+	require.Len(t, statements, 1)
+	assert.Equal(t, "sqs:SendMessage", statements[0]["Action"])
+	assert.Equal(t, testQueueARN, statements[0]["Resource"])
+	assert.Equal(t, map[string]any{"ArnEquals": map[string]any{"aws:SourceArn": testTopicARN}}, statements[0]["Condition"])
+
+	// Subscribing again leaves the policy alone.
+	_, err = c.SubscribeQueue(context.Background(), queue, "", testQueueURL, SubscriptionOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, queue.sets)
+	assert.Empty(t, api.subscribed.Attributes)
+}
+
+func TestSubscribeQueue_KeepsExistingStatements(t *testing.T) {
+	existing := `{"Version":"2012-10-17","Statement":{"Sid":"Other","Effect":"Allow","Principal":"*","Action":"sqs:ReceiveMessage","Resource":"` + testQueueARN + `"}}`
+	queue := &fakeQueue{attrs: map[string]string{"QueueArn": testQueueARN, "Policy": existing}}
+	c := &Client{snsClient: &subscribeSNSClient{}}
+
+	_, err := c.SubscribeQueue(context.Background(), queue, testTopicARN, testQueueURL, SubscriptionOptions{})
+	require.NoError(t, err)
+
+	statements := policyStatements(t, queue.attrs["Policy"])
+	require.Len(t, statements, 2)
+	assert.Equal(t, "Other", statements[0]["Sid"])
+	assert.Equal(t, topicStatementID(testTopicARN), statements[1]["Sid"])
+}
+
+func TestSubscribeQueue_Errors(t *testing.T) {
+	c := &Client{snsClient: &subscribeSNSClient{}}
+	queue := &fakeQueue{attrs: map[string]string{"QueueArn": testQueueARN}}
+
+	_, err := c.SubscribeQueue(context.Background(), queue, "", testQueueURL, SubscriptionOptions{})
+	assert.Error(t, err, "missing topic")
+
+	_, err = c.SubscribeQueue(context.Background(), queue, testTopicARN, "", SubscriptionOptions{})
+	assert.Error(t, err, "missing queue")
+
+	_, err = c.SubscribeQueue(context.Background(), queue, testTopicARN, testQueueURL, SubscriptionOptions{FilterPolicyScope: "Everything"})
+	assert.ErrorContains(t, err, "filter policy scope")
+
+	_, err = c.SubscribeQueue(context.Background(), queue, testTopicARN, testQueueURL, SubscriptionOptions{FilterPolicyScope: FilterScopeMessageBody})
+	assert.ErrorContains(t, err, "requires a filter policy")
+
+	_, err = c.SubscribeQueue(context.Background(), &fakeQueue{attrs: map[string]string{}}, testTopicARN, testQueueURL, SubscriptionOptions{})
+	assert.ErrorContains(t, err, "no ARN")
+}
+
+func TestTopicStatementID(t *testing.T) {
+	seen := map[string]string{}
+	for _, arn := range []string{
+		testTopicARN,
+		"arn:aws:sns:us-east-1:123456789012:order-s",
+		"arn:aws:sns:us-east-1:123456789012:order_s",
+		"arn:aws-cn:sns:us-east-1:123456789012:orders",
+		"arn:aws-us-gov:sns:us-east-1:123456789012:orders",
+	} {
+		sid := topicStatementID(arn)
+		assert.Regexp(t, `^[A-Za-z0-9]+$`, sid)
+		assert.NotContains(t, seen, sid, "statement ID of %s collides with %s", arn, seen[sid])
+		seen[sid] = arn
+	}
+	assert.Equal(t, topicStatementID(testTopicARN), topicStatementID(testTopicARN))
+}
@@ -0,0 +1,106 @@
+package sns
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans created by this package.
+const tracerName = "github.com/ranorsolutions/http-common-go/pkg/messaging/sns"
+
+// Option configures a Client.
+type Option func(*Client)
+
+// TracingOption configures WithTracing.
+type TracingOption func(*tracing)
+
+// WithTracerProvider sets the tracer provider. Defaults to the global one.
+func WithTracerProvider(tp trace.TracerProvider) TracingOption {
+	return func(t *tracing) { t.provider = tp }
+}
+
+// WithPropagator sets the propagator used for message attributes. Defaults
+// to W3C Trace Context, matching the traceparent attribute of the logger
+// middleware.
+func WithPropagator(p propagation.TextMapPropagator) TracingOption {
+	return func(t *tracing) { t.propagator = p }
+}
+
+// WithTracing wraps every publish in a producer span and injects its
+// context into the message attributes, where sqs.WithConsumerTracing picks it up
+// on the consuming side. Without an active span in ctx, the span continues
+// the trace of the traceparent taken from the request metadata, so
+// publishes made from a Gin handler join the request's trace. A batch call
+// is one span, shared by its messages.
+//
+// Example:
+//
+//	client, err := sns.New(cfg, sns.WithTracing())
+func WithTracing(opts ...TracingOption) Option {
+	t := &tracing{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.TraceContext{},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.tracer = t.provider.Tracer(tracerName)
+	return func(c *Client) { c.tracing = t }
+}
+
+// tracing holds the tracing settings of a Client.
+type tracing struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+	tracer     trace.Tracer
+}
+
+// start begins a producer span for topicARN and injects it into attrs, which
+// hold the attributes about to be sent. Without tracing it returns a no-op
+// span.
+func (t *tracing) start(ctx context.Context, topicARN string, attrs map[string]string) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	carrier := propagation.MapCarrier(attrs)
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = t.propagator.Extract(ctx, carrier)
+	}
+
+	name := topicName(topicARN)
+	ctx, span := t.tracer.Start(ctx, "publish "+name,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "aws_sns"),
+			attribute.String("messaging.operation.type", "send"),
+			attribute.String("messaging.destination.name", name),
+		),
+	)
+	t.propagator.Inject(ctx, carrier)
+	sc := span.SpanContext()
+	attrs[AttributeTraceID] = sc.TraceID().String()
+	attrs[AttributeSpanID] = sc.SpanID().String()
+	return ctx, span
+}
+
+// endSpan records the outcome of a publish on span and ends it.
+func endSpan(span trace.Span, messageID string, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if messageID != "" {
+		span.SetAttributes(attribute.String("messaging.message.id", messageID))
+	}
+	span.End()
+}
+
+// topicName returns the name part of a topic ARN.
+func topicName(topicARN string) string {
+	return topicARN[strings.LastIndex(topicARN, ":")+1:]
+}
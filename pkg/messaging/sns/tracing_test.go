@@ -0,0 +1,99 @@
+package sns
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestTracerProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	rec := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)), rec
+}
+
+const testTopic = "arn:aws:sns:us-east-1:123456789012:orders"
+
+func TestTracing_Publish(t *testing.T) {
+	tp, rec := newTestTracerProvider()
+	mock := &mockSNSClient{}
+	c := &Client{snsClient: mock}
+	WithTracing(WithTracerProvider(tp))(c)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	_, err := c.PublishString(ctx, testTopic, "hi")
+	require.NoError(t, err)
+	parent.End()
+
+	spans := rec.Ended()
+	require.Len(t, spans, 2)
+	publish := spans[0]
+	assert.Equal(t, "publish orders", publish.Name())
+	assert.Equal(t, trace.SpanKindProducer, publish.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), publish.Parent().SpanID())
+
+	sc := publish.SpanContext()
+	attrs := mock.lastInput.MessageAttributes
+	assert.Equal(t, "00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-01", aws.ToString(attrs[AttributeTraceParent].StringValue))
+	assert.Equal(t, sc.TraceID().String(), aws.ToString(attrs[AttributeTraceID].StringValue))
+	assert.Equal(t, sc.SpanID().String(), aws.ToString(attrs[AttributeSpanID].StringValue))
+	assert.Contains(t, publish.Attributes(), attribute.String("messaging.message.id", "msg-123"))
+}
+
+func TestTracing_PublishContinuesRequestMetadata(t *testing.T) {
+	tp, rec := newTestTracerProvider()
+	c := &Client{snsClient: &mockSNSClient{}}
+	WithTracing(WithTracerProvider(tp))(c)
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{
+		RequestID:   "req-1",
+		TraceParent: "00-" + traceID + "-00f067aa0ba902b7-01",
+	})
+	_, err := c.PublishString(ctx, testTopic, "hi")
+	require.NoError(t, err)
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, traceID, spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
+
+func TestTracing_PublishError(t *testing.T) {
+	tp, rec := newTestTracerProvider()
+	c := &Client{snsClient: &mockSNSClient{err: errors.New("throttled")}}
+	WithTracing(WithTracerProvider(tp))(c)
+
+	_, err := c.PublishString(context.Background(), testTopic, "hi")
+	require.Error(t, err)
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestTracing_Batch(t *testing.T) {
+	tp, rec := newTestTracerProvider()
+	mock := &batchSNSClient{}
+	c := &Client{snsClient: mock}
+	_, err := c.PublishStringBatch(context.Background(), testTopic, []string{"a"})
+	require.NoError(t, err)
+	assert.Nil(t, mock.calls[0].PublishBatchRequestEntries[0].MessageAttributes, "untraced batches carry no attributes")
+
+	WithTracing(WithTracerProvider(tp))(c)
+	_, err = c.PublishStringBatch(context.Background(), testTopic, []string{"a", "b"})
+	require.NoError(t, err)
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	for _, e := range mock.calls[1].PublishBatchRequestEntries {
+		assert.Contains(t, aws.ToString(e.MessageAttributes[AttributeTraceParent].StringValue), spans[0].SpanContext().SpanID().String())
+	}
+}
@@ -0,0 +1,366 @@
+package sns
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SNS HTTP(S) delivery message types.
+const (
+	TypeNotification             = "Notification"
+	TypeSubscriptionConfirmation = "SubscriptionConfirmation"
+	TypeUnsubscribeConfirmation  = "UnsubscribeConfirmation"
+)
+
+// maxWebhookBody caps the size of a delivery; SNS messages are at most 256KB.
+const maxWebhookBody = 512 << 10
+
+// DefaultWebhookMaxAge is the default age past which deliveries are
+// rejected. It covers the longest retry window SNS allows for HTTP(S)
+// endpoints.
+const DefaultWebhookMaxAge = time.Hour
+
+// ErrInvalidSignature is returned when a delivery's signature does not
+// verify against its signing certificate.
+var ErrInvalidSignature = errors.New("invalid SNS message signature")
+
+// ErrMessageExpired is returned when a delivery's timestamp is older than
+// the maximum age of the Webhook, e.g. a replayed message.
+var ErrMessageExpired = errors.New("expired SNS message")
+
+// snsHost matches the hosts SNS signing certificates and subscription URLs
+// are served from.
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// MessageAttribute is a message attribute of an HTTP(S) delivery.
+type MessageAttribute struct {
+	Type  string `json:"Type"`
+	Value string `json:"Value"`
+}
+
+// Message is the JSON document SNS posts to HTTP(S) endpoints.
+type Message struct {
+	Type              string                      `json:"Type"`
+	MessageID         string                      `json:"MessageId"`
+	Token             string                      `json:"Token,omitempty"`
+	TopicARN          string                      `json:"TopicArn"`
+	Subject           string                      `json:"Subject,omitempty"`
+	Message           string                      `json:"Message"`
+	Timestamp         string                      `json:"Timestamp"`
+	SignatureVersion  string                      `json:"SignatureVersion"`
+	Signature         string                      `json:"Signature"`
+	SigningCertURL    string                      `json:"SigningCertURL"`
+	SubscribeURL      string                      `json:"SubscribeURL,omitempty"`
+	UnsubscribeURL    string                      `json:"UnsubscribeURL,omitempty"`
+	MessageAttributes map[string]MessageAttribute `json:"MessageAttributes,omitempty"`
+}
+
+// NotificationHandler handles verified Notification deliveries. Returning
+// an error responds with a 500 so SNS retries the delivery according to
+// the subscription's delivery policy.
+type NotificationHandler interface {
+	HandleNotification(ctx context.Context, msg *Message) error
+}
+
+// NotificationHandlerFunc adapts a function to NotificationHandler.
+type NotificationHandlerFunc func(ctx context.Context, msg *Message) error
+
+// HandleNotification implements NotificationHandler.
+func (f NotificationHandlerFunc) HandleNotification(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// WebhookOption configures a Webhook.
+type WebhookOption func(*Webhook)
+
+// WithTopics accepts deliveries from the given topic ARNs. It is required:
+// any AWS account can subscribe an endpoint to its own topic, so a Webhook
+// without topics rejects every delivery.
+func WithTopics(topicARNs ...string) WebhookOption {
+	return func(w *Webhook) {
+		for _, arn := range topicARNs {
+			w.topics[arn] = true
+		}
+	}
+}
+
+// WithAutoConfirm controls whether SubscriptionConfirmation messages are
+// confirmed by visiting their SubscribeURL. Enabled by default.
+func WithAutoConfirm(enabled bool) WebhookOption {
+	return func(w *Webhook) { w.autoConfirm = enabled }
+}
+
+// WithMaxAge sets the age past which deliveries are rejected, so captured
+// messages cannot be replayed later. Defaults to DefaultWebhookMaxAge; 0
+// disables the check.
+func WithMaxAge(d time.Duration) WebhookOption {
+	return func(w *Webhook) { w.maxAge = d }
+}
+
+// WithHTTPClient sets the client used to fetch signing certificates and
+// confirm subscriptions. Defaults to a client with a 10s timeout.
+func WithHTTPClient(client *http.Client) WebhookOption {
+	return func(w *Webhook) { w.client = client }
+}
+
+// Webhook receives SNS HTTP(S) endpoint deliveries.
+type Webhook struct {
+	handler     NotificationHandler
+	client      *http.Client
+	topics      map[string]bool
+	autoConfirm bool
+	maxAge      time.Duration
+
+	// validURL guards the certificate and subscription URLs and now
+	// returns the current time; replaced in tests.
+	validURL func(*url.URL) bool
+	now      func() time.Time
+
+	mu    sync.Mutex
+	certs map[string]*x509.Certificate
+}
+
+// NewWebhook returns a Webhook dispatching notifications to handler.
+//
+// Example:
+//
+//	hook := sns.NewWebhook(sns.NotificationHandlerFunc(func(ctx context.Context, msg *sns.Message) error {
+//	    return svc.OnEvent(ctx, msg.Message)
+//	}), sns.WithTopics(topicARN))
+//	router.POST("/sns", hook.Handler())
+func NewWebhook(handler NotificationHandler, opts ...WebhookOption) *Webhook {
+	w := &Webhook{
+		handler:     handler,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		topics:      map[string]bool{},
+		autoConfirm: true,
+		maxAge:      DefaultWebhookMaxAge,
+		validURL:    isSNSURL,
+		now:         time.Now,
+		certs:       map[string]*x509.Certificate{},
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Handler returns a Gin handler processing deliveries. It responds with
+// 400 to malformed requests, 403 to unverified or expired messages and to
+// topics not set with WithTopics, 500 when confirming or handling fails,
+// and 200 otherwise.
+func (w *Webhook) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var msg Message
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+		if err != nil || json.Unmarshal(body, &msg) != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid SNS message"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		if !w.topics[msg.TopicARN] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "unexpected SNS topic"})
+			return
+		}
+		if err := w.Verify(ctx, &msg); err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "SNS message verification failed"})
+			return
+		}
+
+		switch msg.Type {
+		case TypeNotification:
+			err = w.handler.HandleNotification(ctx, &msg)
+		case TypeSubscriptionConfirmation:
+			if w.autoConfirm {
+				err = w.Confirm(ctx, &msg)
+			}
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to process SNS message"})
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+// Verify checks msg's signature against its signing certificate, which
+// must be served over HTTPS by an SNS endpoint, and that msg is not older
+// than the maximum age. Certificates are cached by URL.
+func (w *Webhook) Verify(ctx context.Context, msg *Message) error {
+	if w.maxAge > 0 {
+		ts, err := time.Parse(time.RFC3339, msg.Timestamp)
+		if err != nil {
+			return fmt.Errorf("%w: invalid timestamp %q", ErrInvalidSignature, msg.Timestamp)
+		}
+		if age := w.now().Sub(ts); age > w.maxAge {
+			return fmt.Errorf("%w: sent %s ago", ErrMessageExpired, age.Round(time.Second))
+		}
+	}
+
+	var hash crypto.Hash
+	switch msg.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("%w: unsupported signature version %q", ErrInvalidSignature, msg.SignatureVersion)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	payload, err := stringToSign(msg)
+	if err != nil {
+		return err
+	}
+	cert, err := w.certificate(ctx, msg.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: signing certificate has no RSA key", ErrInvalidSignature)
+	}
+
+	var digest []byte
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum(payload)
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256(payload)
+		digest = sum[:]
+	}
+	if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// Confirm confirms a subscription by visiting msg.SubscribeURL.
+func (w *Webhook) Confirm(ctx context.Context, msg *Message) error {
+	u, err := url.Parse(msg.SubscribeURL)
+	if err != nil || !w.validURL(u) {
+		return fmt.Errorf("invalid SubscribeURL %q", msg.SubscribeURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("subscription confirmation failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("subscription confirmation failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// certificate returns the parsed certificate at rawURL.
+func (w *Webhook) certificate(ctx context.Context, rawURL string) (*x509.Certificate, error) {
+	w.mu.Lock()
+	cert, ok := w.certs[rawURL]
+	w.mu.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || !w.validURL(u) || !strings.HasSuffix(u.Path, ".pem") {
+		return nil, fmt.Errorf("%w: untrusted SigningCertURL %q", ErrInvalidSignature, rawURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing certificate: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch signing certificate: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing certificate: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: signing certificate is not PEM encoded", ErrInvalidSignature)
+	}
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	w.mu.Lock()
+	w.certs[rawURL] = cert
+	w.mu.Unlock()
+	return cert, nil
+}
+
+// stringToSign builds the canonical string SNS signs for msg.
+func stringToSign(msg *Message) ([]byte, error) {
+	var fields [][2]string
+	switch msg.Type {
+	case TypeNotification:
+		fields = append(fields, [2]string{"Message", msg.Message}, [2]string{"MessageId", msg.MessageID})
+		if msg.Subject != "" {
+			fields = append(fields, [2]string{"Subject", msg.Subject})
+		}
+		fields = append(fields,
+			[2]string{"Timestamp", msg.Timestamp},
+			[2]string{"TopicArn", msg.TopicARN},
+			[2]string{"Type", msg.Type},
+		)
+	case TypeSubscriptionConfirmation, TypeUnsubscribeConfirmation:
+		fields = [][2]string{
+			{"Message", msg.Message},
+			{"MessageId", msg.MessageID},
+			{"SubscribeURL", msg.SubscribeURL},
+			{"Timestamp", msg.Timestamp},
+			{"Token", msg.Token},
+			{"TopicArn", msg.TopicARN},
+			{"Type", msg.Type},
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown message type %q", ErrInvalidSignature, msg.Type)
+	}
+
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(f[0])
+		b.WriteByte('\n')
+		b.WriteString(f[1])
+		b.WriteByte('\n')
+	}
+	return []byte(b.String()), nil
+}
+
+// isSNSURL reports whether u is an HTTPS URL on an SNS endpoint.
+func isSNSURL(u *url.URL) bool {
+	return u.Scheme == "https" && snsHost.MatchString(u.Hostname())
+}
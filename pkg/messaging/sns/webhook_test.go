@@ -0,0 +1,249 @@
+package sns
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snsFixture serves a signing certificate and a SubscribeURL, and signs
+// messages with the matching key.
+type snsFixture struct {
+	key       *rsa.PrivateKey
+	server    *httptest.Server
+	confirmed atomic.Int32
+	certHits  atomic.Int32
+}
+
+func newSNSFixture(t *testing.T) *snsFixture {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	f := &snsFixture{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cert.pem", func(w http.ResponseWriter, _ *http.Request) {
+		f.certHits.Add(1)
+		_, _ = w.Write(certPEM)
+	})
+	mux.HandleFunc("/confirm", func(w http.ResponseWriter, _ *http.Request) {
+		f.confirmed.Add(1)
+	})
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// testNow is the current time of fixture webhooks, shortly after the
+// timestamp of test messages.
+var testNow = time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC)
+
+// webhook returns a Webhook trusting the fixture server and accepting
+// testTopicARN unless opts say otherwise.
+func (f *snsFixture) webhook(handler NotificationHandler, opts ...WebhookOption) *Webhook {
+	w := NewWebhook(handler, append([]WebhookOption{WithTopics(testTopicARN)}, opts...)...)
+	host := f.server.Listener.Addr().String()
+	w.validURL = func(u *url.URL) bool { return u.Host == host }
+	w.now = func() time.Time { return testNow }
+	return w
+}
+
+func (f *snsFixture) sign(t *testing.T, msg *Message) {
+	t.Helper()
+	msg.SignatureVersion = "2"
+	msg.SigningCertURL = f.server.URL + "/cert.pem"
+	payload, err := stringToSign(msg)
+	require.NoError(t, err)
+	sum := sha256.Sum256(payload)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, sum[:])
+	require.NoError(t, err)
+	msg.Signature = base64.StdEncoding.EncodeToString(sig)
+}
+
+func postMessage(t *testing.T, w *Webhook, msg any) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/sns", w.Handler())
+
+	body, err := json.Marshal(msg)
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/sns", bytes.NewReader(body)))
+	return rec
+}
+
+func notification(topic string) *Message {
+	return &Message{
+		Type:      TypeNotification,
+		MessageID: "m-1",
+		TopicARN:  topic,
+		Subject:   "hello",
+		Message:   `{"event":"order.created"}`,
+		Timestamp: "2024-01-01T00:00:00.000Z",
+	}
+}
+
+func TestWebhook_Notification(t *testing.T) {
+	f := newSNSFixture(t)
+	var got []string
+	w := f.webhook(NotificationHandlerFunc(func(_ context.Context, msg *Message) error {
+		got = append(got, msg.Message)
+		return nil
+	}))
+
+	for range 2 {
+		msg := notification(testTopicARN)
+		f.sign(t, msg)
+		rec := postMessage(t, w, msg)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Equal(t, []string{`{"event":"order.created"}`, `{"event":"order.created"}`}, got)
+	assert.Equal(t, int32(1), f.certHits.Load(), "certificate is cached")
+}
+
+func TestWebhook_RejectsTamperedMessage(t *testing.T) {
+	f := newSNSFixture(t)
+	called := false
+	w := f.webhook(NotificationHandlerFunc(func(context.Context, *Message) error {
+		called = true
+		return nil
+	}))
+
+	msg := notification(testTopicARN)
+	f.sign(t, msg)
+	msg.Message = `{"event":"order.deleted"}`
+
+	rec := postMessage(t, w, msg)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.False(t, called)
+	assert.ErrorIs(t, w.Verify(context.Background(), msg), ErrInvalidSignature)
+}
+
+func TestWebhook_RejectsUntrustedCertURL(t *testing.T) {
+	w := NewWebhook(NotificationHandlerFunc(func(context.Context, *Message) error { return nil }))
+	w.now = func() time.Time { return testNow }
+	msg := notification(testTopicARN)
+	msg.SignatureVersion = "1"
+	msg.Signature = base64.StdEncoding.EncodeToString([]byte("sig"))
+
+	for _, certURL := range []string{
+		"https://evil.example.com/cert.pem",
+		"http://sns.us-east-1.amazonaws.com/cert.pem",
+		"https://sns.us-east-1.amazonaws.com.evil.com/cert.pem",
+		"https://sns.us-east-1.amazonaws.com/cert.txt",
+	} {
+		msg.SigningCertURL = certURL
+		assert.ErrorIs(t, w.Verify(context.Background(), msg), ErrInvalidSignature, certURL)
+	}
+}
+
+func TestWebhook_TopicAllowlist(t *testing.T) {
+	f := newSNSFixture(t)
+	w := f.webhook(NotificationHandlerFunc(func(context.Context, *Message) error { return nil }))
+
+	msg := notification("arn:aws:sns:us-east-1:123456789012:other")
+	f.sign(t, msg)
+	assert.Equal(t, http.StatusForbidden, postMessage(t, w, msg).Code)
+}
+
+func TestWebhook_RequiresTopics(t *testing.T) {
+	f := newSNSFixture(t)
+	w := f.webhook(NotificationHandlerFunc(func(context.Context, *Message) error {
+		t.Fatal("handler must not see deliveries without an allowlist")
+		return nil
+	}))
+	w.topics = map[string]bool{}
+
+	msg := notification(testTopicARN)
+	f.sign(t, msg)
+	assert.Equal(t, http.StatusForbidden, postMessage(t, w, msg).Code)
+	assert.Zero(t, f.certHits.Load(), "unexpected topics are rejected before verification")
+}
+
+func TestWebhook_RejectsExpiredMessage(t *testing.T) {
+	f := newSNSFixture(t)
+	called := false
+	w := f.webhook(NotificationHandlerFunc(func(context.Context, *Message) error {
+		called = true
+		return nil
+	}), WithMaxAge(time.Minute))
+
+	msg := notification(testTopicARN)
+	f.sign(t, msg)
+	assert.Equal(t, http.StatusForbidden, postMessage(t, w, msg).Code)
+	assert.False(t, called)
+	assert.ErrorIs(t, w.Verify(context.Background(), msg), ErrMessageExpired)
+
+	w = f.webhook(nil, WithMaxAge(0))
+	assert.NoError(t, w.Verify(context.Background(), msg))
+}
+
+func TestWebhook_HandlerError(t *testing.T) {
+	f := newSNSFixture(t)
+	w := f.webhook(NotificationHandlerFunc(func(context.Context, *Message) error { return errors.New("boom") }))
+
+	msg := notification(testTopicARN)
+	f.sign(t, msg)
+	assert.Equal(t, http.StatusInternalServerError, postMessage(t, w, msg).Code)
+}
+
+func TestWebhook_ConfirmsSubscription(t *testing.T) {
+	f := newSNSFixture(t)
+	w := f.webhook(NotificationHandlerFunc(func(context.Context, *Message) error {
+		t.Fatal("handler must not see confirmations")
+		return nil
+	}))
+
+	msg := &Message{
+		Type:         TypeSubscriptionConfirmation,
+		MessageID:    "m-2",
+		Token:        "token",
+		TopicARN:     testTopicARN,
+		Message:      "You have chosen to subscribe",
+		SubscribeURL: f.server.URL + "/confirm",
+		Timestamp:    "2024-01-01T00:00:00.000Z",
+	}
+	f.sign(t, msg)
+
+	assert.Equal(t, http.StatusOK, postMessage(t, w, msg).Code)
+	assert.Equal(t, int32(1), f.confirmed.Load())
+
+	w = f.webhook(nil, WithAutoConfirm(false))
+	assert.Equal(t, http.StatusOK, postMessage(t, w, msg).Code)
+	assert.Equal(t, int32(1), f.confirmed.Load())
+}
+
+func TestWebhook_InvalidBody(t *testing.T) {
+	w := NewWebhook(nil)
+	assert.Equal(t, http.StatusBadRequest, postMessage(t, w, "not an object").Code)
+}
@@ -0,0 +1,260 @@
+package sqs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+)
+
+// receiveBackoff is the pause after a failed receive call.
+const receiveBackoff = time.Second
+
+// ConsumerOption configures a Consumer.
+type ConsumerOption func(*Consumer)
+
+// WithErrorHandler sets a function called with receive, delete and
+// visibility errors. The consumer keeps running after such errors; by
+// default they are dropped. Handler errors are not reported here.
+func WithErrorHandler(fn func(error)) ConsumerOption {
+	return func(c *Consumer) { c.onError = fn }
+}
+
+// Consumer long-polls an SQS queue and hands messages to a MessageHandler.
+type Consumer struct {
+	api         SQSAPI
+	queueURL    string
+	handler     MessageHandler
+	waitTime    time.Duration
+	maxMessages int
+	visibility  time.Duration
+	onError     func(error)
+	tracing     *tracing
+}
+
+// NewConsumer creates a consumer for cfg.QueueURL from AWS credentials/config
+// in the environment.
+//
+// Example:
+//
+//	consumer, err := sqs.NewConsumer(cfg, sqs.MessageHandlerFunc(func(ctx context.Context, msg *types.Message) error {
+//	    var ev OrderCreated
+//	    if err := json.Unmarshal([]byte(aws.ToString(msg.Body)), &ev); err != nil {
+//	        return err
+//	    }
+//	    return svc.OnOrderCreated(ctx, ev)
+//	}))
+//	go consumer.Run(ctx)
+func NewConsumer(cfg *Config, handler MessageHandler, opts ...ConsumerOption) (*Consumer, error) {
+	if cfg.QueueURL == "" {
+		return nil, fmt.Errorf("queue URL is required")
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	api, err := newAPI(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newConsumer(api, cfg, handler, opts), nil
+}
+
+func newConsumer(api SQSAPI, cfg *Config, handler MessageHandler, opts []ConsumerOption) *Consumer {
+	c := &Consumer{
+		api:         api,
+		queueURL:    cfg.QueueURL,
+		handler:     handler,
+		waitTime:    DefaultWaitTime,
+		maxMessages: DefaultMaxMessages,
+		visibility:  DefaultVisibilityTimeout,
+	}
+	if cfg.WaitTime > 0 {
+		c.waitTime = cfg.WaitTime
+	}
+	if cfg.MaxMessages > 0 {
+		c.maxMessages = cfg.MaxMessages
+	}
+	if cfg.VisibilityTimeout > 0 {
+		c.visibility = cfg.VisibilityTimeout
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run receives and handles messages until ctx is canceled, then returns
+// nil. The messages of a receive call are handled concurrently; their
+// visibility timeout is extended while any of them is still being handled,
+// and the successful ones are deleted together once all handlers returned.
+// Failed messages become visible again when their visibility timeout
+// expires, and are moved to the queue's dead-letter queue by SQS after its
+// maxReceiveCount.
+func (c *Consumer) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		msgs, err := c.receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			c.reportError(err)
+			sleep(ctx, receiveBackoff)
+			continue
+		}
+		if len(msgs) > 0 {
+			c.process(ctx, msgs)
+		}
+	}
+	return nil
+}
+
+// receive long-polls the queue for the next batch of messages.
+func (c *Consumer) receive(ctx context.Context) ([]types.Message, error) {
+	out, err := c.api.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:                    aws.String(c.queueURL),
+		MaxNumberOfMessages:         int32(c.maxMessages),
+		WaitTimeSeconds:             int32(c.waitTime / time.Second),
+		VisibilityTimeout:           int32(c.visibility / time.Second),
+		MessageAttributeNames:       []string{"All"},
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("receive failed: %w", err)
+	}
+	return out.Messages, nil
+}
+
+// process handles a received batch and deletes the messages that succeeded.
+func (c *Consumer) process(ctx context.Context, msgs []types.Message) {
+	stop := c.keepInvisible(ctx, msgs)
+
+	succeeded := make([]bool, len(msgs))
+	var wg sync.WaitGroup
+	for i := range msgs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hctx, span := c.tracing.startProcess(correlation.Context(ctx, Attributes(&msgs[i])), c.queueURL, &msgs[i])
+			err := c.handler.HandleMessage(hctx, &msgs[i])
+			endSpan(span, err)
+			succeeded[i] = err == nil
+		}(i)
+	}
+	wg.Wait()
+	stop()
+
+	// Delete even when Run is being canceled, so handled messages are not
+	// delivered again.
+	c.delete(context.WithoutCancel(ctx), msgs, succeeded)
+}
+
+// keepInvisible extends the visibility timeout of msgs every half timeout
+// until the returned function is called.
+func (c *Consumer) keepInvisible(ctx context.Context, msgs []types.Message) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(c.visibility / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.extendVisibility(ctx, msgs)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// extendVisibility resets the visibility timeout of msgs.
+func (c *Consumer) extendVisibility(ctx context.Context, msgs []types.Message) {
+	entries := make([]types.ChangeMessageVisibilityBatchRequestEntry, len(msgs))
+	for i, msg := range msgs {
+		entries[i] = types.ChangeMessageVisibilityBatchRequestEntry{
+			Id:                aws.String(strconv.Itoa(i)),
+			ReceiptHandle:     msg.ReceiptHandle,
+			VisibilityTimeout: int32(c.visibility / time.Second),
+		}
+	}
+	out, err := c.api.ChangeMessageVisibilityBatch(ctx, &sqs.ChangeMessageVisibilityBatchInput{
+		QueueUrl: aws.String(c.queueURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		c.reportError(fmt.Errorf("failed to extend visibility timeout: %w", err))
+		return
+	}
+	for _, f := range out.Failed {
+		c.reportError(fmt.Errorf("failed to extend visibility timeout of message %s: %s: %s",
+			messageID(msgs, f.Id), aws.ToString(f.Code), aws.ToString(f.Message)))
+	}
+}
+
+// delete removes the messages marked as succeeded from the queue.
+func (c *Consumer) delete(ctx context.Context, msgs []types.Message, succeeded []bool) {
+	var entries []types.DeleteMessageBatchRequestEntry
+	for i, msg := range msgs {
+		if succeeded[i] {
+			entries = append(entries, types.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(i)),
+				ReceiptHandle: msg.ReceiptHandle,
+			})
+		}
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	out, err := c.api.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(c.queueURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		c.reportError(fmt.Errorf("delete failed: %w", err))
+		return
+	}
+	for _, f := range out.Failed {
+		c.reportError(fmt.Errorf("failed to delete message %s: %s: %s",
+			messageID(msgs, f.Id), aws.ToString(f.Code), aws.ToString(f.Message)))
+	}
+}
+
+func (c *Consumer) reportError(err error) {
+	if c.onError != nil {
+		c.onError(err)
+	}
+}
+
+// messageID returns the ID of the message a batch entry ID refers to.
+func messageID(msgs []types.Message, entryID *string) string {
+	i, err := strconv.Atoi(aws.ToString(entryID))
+	if err != nil || i < 0 || i >= len(msgs) {
+		return aws.ToString(entryID)
+	}
+	return aws.ToString(msgs[i].MessageId)
+}
+
+// sleep waits for d or until ctx is canceled.
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
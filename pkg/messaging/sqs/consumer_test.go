@@ -0,0 +1,123 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMessage(id string) types.Message {
+	return types.Message{
+		MessageId:     aws.String(id),
+		ReceiptHandle: aws.String("rh-" + id),
+		Body:          aws.String(`{"id":"` + id + `"}`),
+	}
+}
+
+// runConsumer runs c until wait returns, then stops it.
+func runConsumer(t *testing.T, c *Consumer, wait func() bool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	assert.Eventually(t, wait, 2*time.Second, 5*time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
+
+func TestConsumer_DeletesSucceededMessages(t *testing.T) {
+	api := &fakeSQS{receives: [][]types.Message{{testMessage("a"), testMessage("b"), testMessage("c")}}}
+
+	var mu sync.Mutex
+	var handled []string
+	handler := MessageHandlerFunc(func(ctx context.Context, msg *types.Message) error {
+		mu.Lock()
+		handled = append(handled, aws.ToString(msg.MessageId))
+		mu.Unlock()
+		if aws.ToString(msg.MessageId) == "b" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	c := newConsumer(api, &Config{QueueURL: "https://queue"}, handler, nil)
+
+	runConsumer(t, c, func() bool { return len(api.deletedHandles()) == 2 })
+
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, handled)
+	assert.ElementsMatch(t, []string{"rh-a", "rh-c"}, api.deletedHandles())
+}
+
+func TestConsumer_PropagatesCorrelationIDs(t *testing.T) {
+	msg := testMessage("a")
+	msg.MessageAttributes = map[string]types.MessageAttributeValue{
+		AttributeRequestID: {DataType: aws.String("String"), StringValue: aws.String("req-1")},
+	}
+	api := &fakeSQS{receives: [][]types.Message{{msg}}}
+
+	got := make(chan string, 1)
+	handler := MessageHandlerFunc(func(ctx context.Context, _ *types.Message) error {
+		got <- reqctx.RequestMetadataFromContext(ctx).RequestID
+		return nil
+	})
+	c := newConsumer(api, &Config{QueueURL: "https://queue"}, handler, nil)
+
+	runConsumer(t, c, func() bool { return len(api.deletedHandles()) == 1 })
+	assert.Equal(t, "req-1", <-got)
+}
+
+func TestConsumer_ExtendsVisibilityWhileHandling(t *testing.T) {
+	api := &fakeSQS{receives: [][]types.Message{{testMessage("slow")}}}
+
+	handler := MessageHandlerFunc(func(ctx context.Context, _ *types.Message) error {
+		assert.Eventually(t, func() bool { return len(api.extendedHandles()) >= 2 }, 2*time.Second, 5*time.Millisecond)
+		return nil
+	})
+	c := newConsumer(api, &Config{QueueURL: "https://queue"}, handler, nil)
+	c.visibility = 20 * time.Millisecond
+
+	runConsumer(t, c, func() bool { return len(api.deletedHandles()) == 1 })
+	assert.Contains(t, api.extendedHandles(), "rh-slow")
+}
+
+func TestConsumer_ReportsReceiveErrorsAndContinues(t *testing.T) {
+	api := &fakeSQS{
+		receiveErr: errors.New("throttled"),
+		receives:   [][]types.Message{{testMessage("a")}},
+	}
+
+	var mu sync.Mutex
+	var reported []error
+	c := newConsumer(api, &Config{QueueURL: "https://queue"},
+		MessageHandlerFunc(func(context.Context, *types.Message) error { return nil }),
+		[]ConsumerOption{WithErrorHandler(func(err error) {
+			mu.Lock()
+			reported = append(reported, err)
+			mu.Unlock()
+		})})
+
+	runConsumer(t, c, func() bool { return len(api.deletedHandles()) == 1 })
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, reported, 1)
+	assert.ErrorContains(t, reported[0], "throttled")
+}
+
+func TestNewConsumer_RequiresQueueURL(t *testing.T) {
+	_, err := NewConsumer(&Config{Region: "eu-west-1"}, MessageHandlerFunc(func(context.Context, *types.Message) error { return nil }))
+	assert.Error(t, err)
+}
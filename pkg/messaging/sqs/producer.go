@@ -0,0 +1,132 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// MaxDelay is the longest delivery delay SQS supports.
+const MaxDelay = 15 * time.Minute
+
+// Producer sends messages to SQS queues.
+type Producer struct {
+	api      SQSAPI
+	queueURL string
+	tracing  *tracing
+}
+
+// ProducerOption configures a Producer.
+type ProducerOption func(*Producer)
+
+// NewProducer creates a producer from AWS credentials/config in the
+// environment. cfg.QueueURL is used when a send is given no queue URL.
+func NewProducer(cfg *Config, opts ...ProducerOption) (*Producer, error) {
+	api, err := newAPI(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p := &Producer{api: api, queueURL: cfg.QueueURL}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+// SendJSON marshals value as JSON and sends it to queueURL (the configured
+// queue if empty), returning the message ID. Correlation IDs found in ctx
+// are added as message attributes.
+func (p *Producer) SendJSON(ctx context.Context, queueURL string, value any) (string, error) {
+	return p.SendJSONWithAttributes(ctx, queueURL, value, nil)
+}
+
+// SendJSONWithAttributes sends a JSON-encoded message with custom string
+// attributes. The request_id, trace_id, span_id, traceparent and tracestate
+// set by the logger middleware are read from ctx and added automatically
+// unless attrs already defines them. SQS allows at most 10 attributes per
+// message.
+func (p *Producer) SendJSONWithAttributes(ctx context.Context, queueURL string, value any, attrs map[string]string) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if _, ok := attrs[AttributeContentType]; !ok {
+		withType := make(map[string]string, len(attrs)+1)
+		for k, v := range attrs {
+			withType[k] = v
+		}
+		withType[AttributeContentType] = ContentTypeJSON
+		attrs = withType
+	}
+	return p.SendString(ctx, queueURL, string(data), attrs)
+}
+
+// SendOptions sets the optional fields of a sent message.
+type SendOptions struct {
+	// Attributes are sent as string message attributes, in addition to the
+	// correlation IDs found in ctx.
+	Attributes map[string]string
+
+	// Delay hides the message from consumers for up to MaxDelay. It is
+	// rounded up to whole seconds. FIFO queues only support a queue-level
+	// delay.
+	Delay time.Duration
+
+	// GroupID and DeduplicationID are required by FIFO queues (the latter
+	// unless content-based deduplication is enabled).
+	GroupID         string
+	DeduplicationID string
+}
+
+// SendString sends body as it is to queueURL (the configured queue if
+// empty) with custom string attributes and the correlation IDs found in
+// ctx, returning the message ID.
+func (p *Producer) SendString(ctx context.Context, queueURL, body string, attrs map[string]string) (string, error) {
+	return p.SendStringWithOptions(ctx, queueURL, body, SendOptions{Attributes: attrs})
+}
+
+// SendStringWithOptions sends body with the attributes, delay and FIFO
+// settings in opts.
+func (p *Producer) SendStringWithOptions(ctx context.Context, queueURL, body string, opts SendOptions) (string, error) {
+	if queueURL == "" {
+		queueURL = p.queueURL
+	}
+	if queueURL == "" {
+		return "", fmt.Errorf("queue URL is required")
+	}
+	if opts.Delay < 0 || opts.Delay > MaxDelay {
+		return "", fmt.Errorf("invalid SQS delay %s: must be between 0 and %s", opts.Delay, MaxDelay)
+	}
+
+	attrs := correlation.Merge(ctx, opts.Attributes)
+	ctx, span := p.tracing.startSend(ctx, queueURL, attrs)
+	input := &sqs.SendMessageInput{
+		QueueUrl:          aws.String(queueURL),
+		MessageBody:       aws.String(body),
+		MessageAttributes: messageAttributes(attrs),
+		DelaySeconds:      int32((opts.Delay + time.Second - 1) / time.Second),
+	}
+	if opts.GroupID != "" {
+		input.MessageGroupId = aws.String(opts.GroupID)
+	}
+	if opts.DeduplicationID != "" {
+		input.MessageDeduplicationId = aws.String(opts.DeduplicationID)
+	}
+
+	out, err := p.api.SendMessage(ctx, input)
+	if err != nil {
+		err = fmt.Errorf("send failed: %w", err)
+		endSpan(span, err)
+		return "", err
+	}
+	id := aws.ToString(out.MessageId)
+	span.SetAttributes(attribute.String("messaging.message.id", id))
+	endSpan(span, nil)
+	return id, nil
+}
@@ -0,0 +1,114 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendJSON(t *testing.T) {
+	api := &fakeSQS{}
+	p := &Producer{api: api, queueURL: "https://queue/default"}
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1"})
+
+	id, err := p.SendJSON(ctx, "", map[string]string{"event": "order.created"})
+	require.NoError(t, err)
+	assert.Equal(t, "msg-1", id)
+
+	require.Len(t, api.sent, 1)
+	in := api.sent[0]
+	assert.Equal(t, "https://queue/default", aws.ToString(in.QueueUrl))
+	assert.JSONEq(t, `{"event":"order.created"}`, aws.ToString(in.MessageBody))
+	assert.Equal(t, "req-1", aws.ToString(in.MessageAttributes[AttributeRequestID].StringValue))
+	assert.Equal(t, ContentTypeJSON, aws.ToString(in.MessageAttributes[AttributeContentType].StringValue))
+	assert.Equal(t, "String", aws.ToString(in.MessageAttributes[AttributeContentType].DataType))
+}
+
+func TestSendJSONWithAttributes_Overrides(t *testing.T) {
+	api := &fakeSQS{}
+	p := &Producer{api: api}
+	ctx := reqctx.WithRequestMetadata(context.Background(), reqctx.RequestMetadata{RequestID: "req-1"})
+
+	_, err := p.SendJSONWithAttributes(ctx, "https://queue/other", 1, map[string]string{
+		AttributeRequestID: "explicit",
+		"tenant":           "acme",
+	})
+	require.NoError(t, err)
+
+	attrs := api.sent[0].MessageAttributes
+	assert.Equal(t, "explicit", aws.ToString(attrs[AttributeRequestID].StringValue))
+	assert.Equal(t, "acme", aws.ToString(attrs["tenant"].StringValue))
+	assert.Equal(t, "https://queue/other", aws.ToString(api.sent[0].QueueUrl))
+}
+
+func TestSendJSON_Errors(t *testing.T) {
+	p := &Producer{api: &fakeSQS{}}
+	_, err := p.SendJSON(context.Background(), "", 1)
+	assert.Error(t, err, "missing queue URL")
+
+	_, err = p.SendJSON(context.Background(), "https://queue", make(chan int))
+	assert.ErrorContains(t, err, "marshal")
+
+	p = &Producer{api: &fakeSQS{sendErr: errors.New("denied")}, queueURL: "https://queue"}
+	_, err = p.SendJSON(context.Background(), "", 1)
+	assert.ErrorContains(t, err, "denied")
+}
+
+func TestSendString(t *testing.T) {
+	api := &fakeSQS{}
+	p := &Producer{api: api, queueURL: "https://queue/default"}
+
+	_, err := p.SendString(context.Background(), "", "plain text", map[string]string{"tenant": "acme"})
+	require.NoError(t, err)
+
+	in := api.sent[0]
+	assert.Equal(t, "plain text", aws.ToString(in.MessageBody))
+	assert.Equal(t, "acme", aws.ToString(in.MessageAttributes["tenant"].StringValue))
+	assert.NotContains(t, in.MessageAttributes, AttributeContentType)
+}
+
+func TestSendStringWithOptions(t *testing.T) {
+	api := &fakeSQS{}
+	p := &Producer{api: api, queueURL: "https://queue/orders.fifo"}
+
+	_, err := p.SendStringWithOptions(context.Background(), "", "body", SendOptions{
+		Delay:           1500 * time.Millisecond,
+		GroupID:         "o-1",
+		DeduplicationID: "d-1",
+	})
+	require.NoError(t, err)
+	in := api.sent[0]
+	assert.Equal(t, int32(2), in.DelaySeconds)
+	assert.Equal(t, "o-1", aws.ToString(in.MessageGroupId))
+	assert.Equal(t, "d-1", aws.ToString(in.MessageDeduplicationId))
+
+	_, err = p.SendStringWithOptions(context.Background(), "", "body", SendOptions{Delay: 16 * time.Minute})
+	assert.ErrorContains(t, err, "invalid SQS delay")
+}
+
+func TestNewProducer_EndpointAndStaticCredentials(t *testing.T) {
+	p, err := NewProducer(&Config{
+		Region:          "us-east-1",
+		QueueURL:        "http://localhost:4566/000000000000/orders",
+		Endpoint:        "http://localhost:4566",
+		AccessKeyID:     "test",
+		SecretAccessKey: "secret",
+	})
+	require.NoError(t, err)
+
+	opts := p.api.(*sqs.Client).Options()
+	assert.Equal(t, "http://localhost:4566", aws.ToString(opts.BaseEndpoint))
+	creds, err := opts.Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "test", creds.AccessKeyID)
+
+	_, err = NewProducer(&Config{Region: "us-east-1", SecretAccessKey: "secret"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,202 @@
+package sqs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/awsconfig"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/env"
+)
+
+// Attribute names used to propagate request correlation IDs between
+// services. They match the Kafka header names.
+const (
+	AttributeRequestID   = correlation.RequestID
+	AttributeTraceID     = correlation.TraceID
+	AttributeSpanID      = correlation.SpanID
+	AttributeTraceParent = correlation.TraceParent
+	AttributeTraceState  = correlation.TraceState
+
+	// AttributeContentType identifies the body encoding.
+	AttributeContentType = "content-type"
+)
+
+// ContentTypeJSON is set in AttributeContentType by SendJSON.
+const ContentTypeJSON = "application/json"
+
+// Defaults applied when the corresponding Config field is zero.
+const (
+	DefaultWaitTime          = 20 * time.Second
+	DefaultMaxMessages       = 10
+	DefaultVisibilityTimeout = 30 * time.Second
+)
+
+// SQSAPI defines the subset of sqs.Client methods we use.
+// This makes it mockable in tests.
+type SQSAPI interface {
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error)
+	ChangeMessageVisibilityBatch(ctx context.Context, params *sqs.ChangeMessageVisibilityBatchInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error)
+}
+
+// Config holds SQS connection and consumer settings.
+type Config struct {
+	Region   string
+	QueueURL string
+
+	// WaitTime is the long-polling duration of a receive call, in whole
+	// seconds up to 20s.
+	WaitTime time.Duration
+
+	// MaxMessages is the number of messages received per call, 1-10.
+	MaxMessages int
+
+	// VisibilityTimeout hides received messages from other consumers, in
+	// whole seconds from 1s to 12h. It is extended while handlers are still
+	// running, so it only bounds how long a message stays hidden after a
+	// consumer crashed.
+	VisibilityTimeout time.Duration
+
+	// Endpoint overrides the SQS endpoint, e.g. "http://localhost:4566" for
+	// LocalStack.
+	Endpoint string
+
+	// Profile selects a profile of the shared config and credentials files
+	// instead of AWS_PROFILE.
+	Profile string
+
+	// AccessKeyID and SecretAccessKey (and optionally SessionToken) replace
+	// the default credential chain with static credentials.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// RoleARN is assumed with the credentials above, e.g. to use a queue in
+	// another account.
+	RoleARN string
+}
+
+// NewConfigFromEnv builds configuration from environment variables:
+//
+//	AWS_REGION              required
+//	SQS_QUEUE_URL           default queue
+//	SQS_WAIT_TIME           duration, e.g. 20s
+//	SQS_MAX_MESSAGES        integer, 1-10
+//	SQS_VISIBILITY_TIMEOUT  duration, e.g. 30s
+//	SQS_ENDPOINT            endpoint override
+//	SQS_ROLE_ARN            role to assume
+//
+// Credentials and profiles are read by the AWS SDK from its usual
+// environment variables and shared files.
+func NewConfigFromEnv() (*Config, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		return nil, fmt.Errorf("AWS_REGION is required")
+	}
+
+	cfg := &Config{
+		Region:   region,
+		QueueURL: os.Getenv("SQS_QUEUE_URL"),
+		Endpoint: os.Getenv("SQS_ENDPOINT"),
+		RoleARN:  os.Getenv("SQS_ROLE_ARN"),
+	}
+	var err error
+	if cfg.WaitTime, err = env.Duration("SQS_WAIT_TIME"); err != nil {
+		return nil, err
+	}
+	if cfg.VisibilityTimeout, err = env.Duration("SQS_VISIBILITY_TIMEOUT"); err != nil {
+		return nil, err
+	}
+	if v := os.Getenv("SQS_MAX_MESSAGES"); v != "" {
+		if cfg.MaxMessages, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid SQS_MAX_MESSAGES: %w", err)
+		}
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate rejects values SQS would refuse on every receive call. SQS
+// takes both durations in whole seconds, so fractions are rejected rather
+// than truncated: a visibility timeout cut to 0 would redeliver messages
+// while they are being handled.
+func (c *Config) validate() error {
+	if c.WaitTime < 0 || c.WaitTime > 20*time.Second || c.WaitTime%time.Second != 0 {
+		return fmt.Errorf("invalid SQS wait time %s: must be whole seconds between 0 and 20s", c.WaitTime)
+	}
+	if c.MaxMessages < 0 || c.MaxMessages > 10 {
+		return fmt.Errorf("invalid SQS max messages %d: must be between 1 and 10", c.MaxMessages)
+	}
+	if c.VisibilityTimeout != 0 && (c.VisibilityTimeout < time.Second || c.VisibilityTimeout > 12*time.Hour || c.VisibilityTimeout%time.Second != 0) {
+		return fmt.Errorf("invalid SQS visibility timeout %s: must be whole seconds between 1s and 12h", c.VisibilityTimeout)
+	}
+	return nil
+}
+
+// newAPI creates an SQS client from AWS credentials/config in the
+// environment, applying the overrides set in cfg.
+func newAPI(cfg *Config) (*sqs.Client, error) {
+	awsCfg, err := awsconfig.Load(context.Background(), awsconfig.Options{
+		Region:          cfg.Region,
+		Profile:         cfg.Profile,
+		AccessKeyID:     cfg.AccessKeyID,
+		SecretAccessKey: cfg.SecretAccessKey,
+		SessionToken:    cfg.SessionToken,
+		RoleARN:         cfg.RoleARN,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sqs.NewFromConfig(awsCfg, func(o *sqs.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	}), nil
+}
+
+// MessageHandler defines the signature for handling received messages.
+// The context carries the correlation IDs found in the message attributes;
+// use context.RequestMetadataFromContext or Attributes(msg) to read them.
+// Returning nil deletes the message; an error leaves it on the queue to be
+// received again once its visibility timeout expires.
+type MessageHandler interface {
+	HandleMessage(ctx context.Context, msg *types.Message) error
+}
+
+// MessageHandlerFunc adapts a function to MessageHandler.
+type MessageHandlerFunc func(ctx context.Context, msg *types.Message) error
+
+// HandleMessage implements MessageHandler.
+func (f MessageHandlerFunc) HandleMessage(ctx context.Context, msg *types.Message) error {
+	return f(ctx, msg)
+}
+
+// Attributes returns the string message attributes of msg as a map.
+func Attributes(msg *types.Message) map[string]string {
+	out := make(map[string]string, len(msg.MessageAttributes))
+	for k, v := range msg.MessageAttributes {
+		if v.StringValue != nil {
+			out[k] = aws.ToString(v.StringValue)
+		}
+	}
+	return out
+}
+
+// messageAttributes converts attrs to string message attributes.
+func messageAttributes(attrs map[string]string) map[string]types.MessageAttributeValue {
+	out := make(map[string]types.MessageAttributeValue, len(attrs))
+	for k, v := range attrs {
+		out[k] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
+	}
+	return out
+}
@@ -0,0 +1,170 @@
+package sqs
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/correlation"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSQS fakes SQSAPI. Receive calls pop batches from receives and block
+// until ctx is canceled once it is empty, like an idle long poll.
+type fakeSQS struct {
+	mu         sync.Mutex
+	sent       []*sqs.SendMessageInput
+	sendErr    error
+	receives   [][]types.Message
+	receiveErr error
+	deleted    []string
+	extended   []string
+}
+
+func (f *fakeSQS) SendMessage(_ context.Context, input *sqs.SendMessageInput, _ ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.sendErr != nil {
+		return nil, f.sendErr
+	}
+	f.sent = append(f.sent, input)
+	return &sqs.SendMessageOutput{MessageId: aws.String("msg-" + strconv.Itoa(len(f.sent)))}, nil
+}
+
+func (f *fakeSQS) ReceiveMessage(ctx context.Context, _ *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	f.mu.Lock()
+	if err := f.receiveErr; err != nil {
+		f.receiveErr = nil
+		f.mu.Unlock()
+		return nil, err
+	}
+	if len(f.receives) > 0 {
+		batch := f.receives[0]
+		f.receives = f.receives[1:]
+		f.mu.Unlock()
+		return &sqs.ReceiveMessageOutput{Messages: batch}, nil
+	}
+	f.mu.Unlock()
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f *fakeSQS) DeleteMessageBatch(_ context.Context, input *sqs.DeleteMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range input.Entries {
+		f.deleted = append(f.deleted, aws.ToString(e.ReceiptHandle))
+	}
+	return &sqs.DeleteMessageBatchOutput{}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibilityBatch(_ context.Context, input *sqs.ChangeMessageVisibilityBatchInput, _ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range input.Entries {
+		f.extended = append(f.extended, aws.ToString(e.ReceiptHandle))
+	}
+	return &sqs.ChangeMessageVisibilityBatchOutput{}, nil
+}
+
+func (f *fakeSQS) deletedHandles() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.deleted...)
+}
+
+func (f *fakeSQS) extendedHandles() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.extended...)
+}
+
+func TestNewConfigFromEnv(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("SQS_QUEUE_URL", "https://sqs.eu-west-1.amazonaws.com/123/orders")
+	t.Setenv("SQS_WAIT_TIME", "5s")
+	t.Setenv("SQS_MAX_MESSAGES", "4")
+	t.Setenv("SQS_VISIBILITY_TIMEOUT", "2m")
+	t.Setenv("SQS_ENDPOINT", "http://localhost:4566")
+
+	cfg, err := NewConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-1", cfg.Region)
+	assert.Equal(t, "https://sqs.eu-west-1.amazonaws.com/123/orders", cfg.QueueURL)
+	assert.Equal(t, 5*time.Second, cfg.WaitTime)
+	assert.Equal(t, 4, cfg.MaxMessages)
+	assert.Equal(t, 2*time.Minute, cfg.VisibilityTimeout)
+	assert.Equal(t, "http://localhost:4566", cfg.Endpoint)
+}
+
+func TestNewConfigFromEnv_Invalid(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	_, err := NewConfigFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("AWS_REGION", "eu-west-1")
+	for key, value := range map[string]string{
+		"SQS_WAIT_TIME":          "30s",
+		"SQS_MAX_MESSAGES":       "11",
+		"SQS_VISIBILITY_TIMEOUT": "soon",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			_, err := NewConfigFromEnv()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestConfigValidate_Durations(t *testing.T) {
+	for _, cfg := range []Config{
+		{WaitTime: 1500 * time.Millisecond},
+		{VisibilityTimeout: 500 * time.Millisecond},
+		{VisibilityTimeout: 5 * time.Nanosecond},
+		{VisibilityTimeout: 90500 * time.Millisecond},
+		{VisibilityTimeout: 13 * time.Hour},
+	} {
+		assert.Error(t, cfg.validate(), "%+v", cfg)
+	}
+	for _, cfg := range []Config{{}, {WaitTime: 20 * time.Second, VisibilityTimeout: time.Second}, {VisibilityTimeout: 12 * time.Hour}} {
+		assert.NoError(t, cfg.validate(), "%+v", cfg)
+	}
+}
+
+func TestNewConfigFromEnv_Fractions(t *testing.T) {
+	t.Setenv("AWS_REGION", "eu-west-1")
+	for key, value := range map[string]string{
+		"SQS_WAIT_TIME":          "2.5s",
+		"SQS_VISIBILITY_TIMEOUT": "500ms",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			_, err := NewConfigFromEnv()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAttributesAndContext(t *testing.T) {
+	msg := &types.Message{MessageAttributes: map[string]types.MessageAttributeValue{
+		AttributeRequestID: {DataType: aws.String("String"), StringValue: aws.String("req-1")},
+		AttributeTraceID:   {DataType: aws.String("String"), StringValue: aws.String("trace-1")},
+		"blob":             {DataType: aws.String("Binary"), BinaryValue: []byte{1}},
+	}}
+
+	assert.Equal(t, map[string]string{AttributeRequestID: "req-1", AttributeTraceID: "trace-1"}, Attributes(msg))
+
+	md := reqctx.RequestMetadataFromContext(correlation.Context(context.Background(), Attributes(msg)))
+	assert.Equal(t, "req-1", md.RequestID)
+	assert.Equal(t, "trace-1", md.TraceID)
+
+	parent := context.Background()
+	assert.Equal(t, parent, correlation.Context(parent, Attributes(&types.Message{})))
+}
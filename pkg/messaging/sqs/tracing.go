@@ -0,0 +1,192 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans created by this package.
+const tracerName = "github.com/ranorsolutions/http-common-go/pkg/messaging/sqs"
+
+// TracingOption configures WithProducerTracing and WithConsumerTracing.
+type TracingOption func(*tracing)
+
+// WithTracerProvider sets the tracer provider. Defaults to the global one.
+func WithTracerProvider(tp trace.TracerProvider) TracingOption {
+	return func(t *tracing) { t.provider = tp }
+}
+
+// WithPropagator sets the propagator used for message attributes. Defaults
+// to W3C Trace Context, matching the traceparent attribute of the logger
+// middleware.
+func WithPropagator(p propagation.TextMapPropagator) TracingOption {
+	return func(t *tracing) { t.propagator = p }
+}
+
+// WithProducerTracing wraps every send in a producer span and injects its
+// context into the message attributes. Without an active span in ctx, the
+// span continues the trace of the traceparent taken from the request
+// metadata, so sends made from a Gin handler join the request's trace.
+//
+// Example:
+//
+//	producer, err := sqs.NewProducer(cfg, sqs.WithProducerTracing())
+func WithProducerTracing(opts ...TracingOption) ProducerOption {
+	t := newTracing(opts)
+	return func(p *Producer) { p.tracing = t }
+}
+
+// WithConsumerTracing handles each message in a consumer span linked to the
+// span that sent it, found in the message attributes or, for messages SNS
+// delivered without raw message delivery, in the attributes of the
+// envelope. The span is a child of the sending span unless the handler
+// context already has an active span, so the trace continues across the
+// queue. The request metadata in the handler context is updated to the new
+// span, keeping logs and onward sends correlated.
+//
+// Example:
+//
+//	consumer, err := sqs.NewConsumer(cfg, handler, sqs.WithConsumerTracing())
+func WithConsumerTracing(opts ...TracingOption) ConsumerOption {
+	t := newTracing(opts)
+	return func(c *Consumer) { c.tracing = t }
+}
+
+// tracing holds the tracing settings of a Producer or Consumer.
+type tracing struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+	tracer     trace.Tracer
+}
+
+func newTracing(opts []TracingOption) *tracing {
+	t := &tracing{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.TraceContext{},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.tracer = t.provider.Tracer(tracerName)
+	return t
+}
+
+// startSend begins a producer span for queueURL and injects it into attrs,
+// which hold the attributes about to be sent. Without tracing it returns a
+// no-op span.
+func (t *tracing) startSend(ctx context.Context, queueURL string, attrs map[string]string) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	carrier := propagation.MapCarrier(attrs)
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = t.propagator.Extract(ctx, carrier)
+	}
+
+	name := queueName(queueURL)
+	ctx, span := t.tracer.Start(ctx, "send "+name,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(messagingAttributes("send", name)...),
+	)
+	t.propagator.Inject(ctx, carrier)
+	sc := span.SpanContext()
+	attrs[AttributeTraceID] = sc.TraceID().String()
+	attrs[AttributeSpanID] = sc.SpanID().String()
+	return ctx, span
+}
+
+// startProcess begins a consumer span for msg, received from queueURL.
+// Without tracing it returns a no-op span.
+func (t *tracing) startProcess(ctx context.Context, queueURL string, msg *types.Message) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	name := queueName(queueURL)
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(messagingAttributes("process", name)...),
+		trace.WithAttributes(attribute.String("messaging.message.id", aws.ToString(msg.MessageId))),
+	}
+
+	extracted := t.propagator.Extract(ctx, propagation.MapCarrier(traceAttributes(msg)))
+	if sender := trace.SpanContextFromContext(extracted); sender.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: sender}))
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			ctx = extracted
+		}
+	}
+	ctx, span := t.tracer.Start(ctx, "process "+name, opts...)
+
+	sc := span.SpanContext()
+	md := reqctx.RequestMetadataFromContext(ctx)
+	md.TraceID, md.SpanID = sc.TraceID().String(), sc.SpanID().String()
+	md.TraceParent = "00-" + md.TraceID + "-" + md.SpanID + "-" + sc.TraceFlags().String()
+	return reqctx.WithRequestMetadata(ctx, md), span
+}
+
+// endSpan records the outcome of a send or handler on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// messagingAttributes returns the attributes shared by all SQS spans.
+func messagingAttributes(operation, queue string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("messaging.system", "aws_sqs"),
+		attribute.String("messaging.operation.type", operation),
+		attribute.String("messaging.destination.name", queue),
+	}
+}
+
+// queueName returns the name part of a queue URL.
+func queueName(queueURL string) string {
+	return queueURL[strings.LastIndex(queueURL, "/")+1:]
+}
+
+// snsEnvelope is the part of an SNS notification envelope holding the
+// message attributes.
+type snsEnvelope struct {
+	Type              string
+	MessageAttributes map[string]struct {
+		Type  string
+		Value string
+	}
+}
+
+// traceAttributes returns the attributes of msg carrying its trace context:
+// its message attributes, or those of the SNS envelope in its body.
+func traceAttributes(msg *types.Message) map[string]string {
+	attrs := Attributes(msg)
+	if attrs[AttributeTraceParent] != "" {
+		return attrs
+	}
+	body := aws.ToString(msg.Body)
+	if !strings.HasPrefix(body, "{") {
+		return attrs
+	}
+	var env snsEnvelope
+	if json.Unmarshal([]byte(body), &env) != nil || env.Type != "Notification" {
+		return attrs
+	}
+	out := make(map[string]string, len(env.MessageAttributes))
+	for k, a := range env.MessageAttributes {
+		if a.Type == "String" {
+			out[k] = a.Value
+		}
+	}
+	return out
+}
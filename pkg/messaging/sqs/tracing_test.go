@@ -0,0 +1,127 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testQueue = "https://sqs.us-east-1.amazonaws.com/123456789012/orders"
+
+func newTestTracerProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	rec := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)), rec
+}
+
+// tracedConsumer returns a consumer of testQueue with tracing and the
+// handler contexts it saw.
+func tracedConsumer(tp trace.TracerProvider, handlerErr error) (*Consumer, *[]context.Context) {
+	var ctxs []context.Context
+	handler := MessageHandlerFunc(func(ctx context.Context, _ *types.Message) error {
+		ctxs = append(ctxs, ctx)
+		return handlerErr
+	})
+	c := newConsumer(&fakeSQS{}, &Config{QueueURL: testQueue}, handler, []ConsumerOption{WithConsumerTracing(WithTracerProvider(tp))})
+	return c, &ctxs
+}
+
+func TestTracing_ProducerToConsumer(t *testing.T) {
+	tp, rec := newTestTracerProvider()
+	api := &fakeSQS{}
+	p := &Producer{api: api}
+	WithProducerTracing(WithTracerProvider(tp))(p)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	_, err := p.SendString(ctx, testQueue, "{}", nil)
+	require.NoError(t, err)
+	parent.End()
+
+	c, ctxs := tracedConsumer(tp, nil)
+	msg := types.Message{MessageId: aws.String("m-1"), ReceiptHandle: aws.String("rh-1"), Body: aws.String("{}"), MessageAttributes: api.sent[0].MessageAttributes}
+	c.process(context.Background(), []types.Message{msg})
+
+	spans := rec.Ended()
+	require.Len(t, spans, 3)
+	send, process := spans[0], spans[2]
+	assert.Equal(t, "send orders", send.Name())
+	assert.Equal(t, trace.SpanKindProducer, send.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), send.Parent().SpanID())
+
+	assert.Equal(t, "process orders", process.Name())
+	assert.Equal(t, trace.SpanKindConsumer, process.SpanKind())
+	assert.Equal(t, send.SpanContext().SpanID(), process.Parent().SpanID(), "the trace continues across the queue")
+	require.Len(t, process.Links(), 1)
+	assert.Equal(t, send.SpanContext().SpanID(), process.Links()[0].SpanContext.SpanID())
+
+	require.Len(t, *ctxs, 1)
+	md := reqctx.RequestMetadataFromContext((*ctxs)[0])
+	assert.Equal(t, process.SpanContext().TraceID().String(), md.TraceID)
+	assert.Equal(t, process.SpanContext().SpanID().String(), md.SpanID)
+}
+
+func TestTracing_ConsumerWithActiveSpan(t *testing.T) {
+	tp, rec := newTestTracerProvider()
+	c, _ := tracedConsumer(tp, errors.New("boom"))
+
+	sender := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	msg := testMessage("a")
+	msg.MessageAttributes = map[string]types.MessageAttributeValue{
+		AttributeTraceParent: {DataType: aws.String("String"), StringValue: aws.String(sender)},
+	}
+	ctx, job := tp.Tracer("test").Start(context.Background(), "job")
+	c.process(ctx, []types.Message{msg})
+	job.End()
+
+	spans := rec.Ended()
+	require.Len(t, spans, 2)
+	process := spans[0]
+	assert.Equal(t, job.SpanContext().SpanID(), process.Parent().SpanID(), "the active span stays the parent")
+	require.Len(t, process.Links(), 1)
+	assert.Equal(t, "00f067aa0ba902b7", process.Links()[0].SpanContext.SpanID().String())
+	assert.Equal(t, codes.Error, process.Status().Code)
+}
+
+func TestTracing_ConsumerSNSEnvelope(t *testing.T) {
+	tp, rec := newTestTracerProvider()
+	c, _ := tracedConsumer(tp, nil)
+
+	env, err := json.Marshal(map[string]any{
+		"Type":     "Notification",
+		"TopicArn": "arn:aws:sns:us-east-1:123456789012:orders",
+		"Message":  "{}",
+		"MessageAttributes": map[string]any{
+			AttributeTraceParent: map[string]string{"Type": "String", "Value": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		},
+	})
+	require.NoError(t, err)
+	msg := testMessage("a")
+	msg.Body = aws.String(string(env))
+	c.process(context.Background(), []types.Message{msg})
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
+
+func TestTracing_ConsumerWithoutTraceContext(t *testing.T) {
+	tp, rec := newTestTracerProvider()
+	c, _ := tracedConsumer(tp, nil)
+	c.process(context.Background(), []types.Message{testMessage("a")})
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	assert.False(t, spans[0].Parent().IsValid())
+	assert.Empty(t, spans[0].Links())
+}
@@ -0,0 +1,57 @@
+package context
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestMetadata holds the correlation identifiers of a request, as set by
+// the logger middleware. It is propagated to downstream calls and messages.
+type RequestMetadata struct {
+	RequestID   string
+	TraceID     string
+	SpanID      string
+	TraceParent string
+	TraceState  string
+}
+
+// IsZero reports whether no identifiers are set.
+func (m RequestMetadata) IsZero() bool {
+	return m == RequestMetadata{}
+}
+
+type requestMetadataKey struct{}
+
+// WithRequestMetadata returns a copy of ctx carrying md.
+func WithRequestMetadata(ctx context.Context, md RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataKey{}, md)
+}
+
+// RequestMetadataFromContext returns the request metadata stored in ctx.
+// If none was stored explicitly, it falls back to the values the logger
+// middleware sets on a *gin.Context (either ctx itself or one stored by
+// GinContextToContextMiddleware). A zero value is returned if nothing is found.
+func RequestMetadataFromContext(ctx context.Context) RequestMetadata {
+	if ctx == nil {
+		return RequestMetadata{}
+	}
+	if md, ok := ctx.Value(requestMetadataKey{}).(RequestMetadata); ok {
+		return md
+	}
+
+	gc, ok := ctx.(*gin.Context)
+	if !ok {
+		gc, ok = ctx.Value(ginContextKey).(*gin.Context)
+	}
+	if !ok || gc == nil {
+		return RequestMetadata{}
+	}
+	return RequestMetadata{
+		RequestID:   gc.GetString("request_id"),
+		TraceID:     gc.GetString("trace_id"),
+		SpanID:      gc.GetString("span_id"),
+		TraceParent: gc.GetString("traceparent"),
+		TraceState:  gc.GetString("tracestate"),
+	}
+}
@@ -0,0 +1,44 @@
+package context
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- RequestMetadataFromContext() tests ---
+
+func TestRequestMetadata_RoundTrip(t *testing.T) {
+	md := RequestMetadata{RequestID: "r1", TraceID: "t1", SpanID: "s1"}
+	ctx := WithRequestMetadata(context.Background(), md)
+
+	if got := RequestMetadataFromContext(ctx); got != md {
+		t.Fatalf("expected %+v, got %+v", md, got)
+	}
+}
+
+func TestRequestMetadata_Missing(t *testing.T) {
+	if got := RequestMetadataFromContext(context.Background()); !got.IsZero() {
+		t.Fatalf("expected zero metadata, got %+v", got)
+	}
+	if got := RequestMetadataFromContext(nil); !got.IsZero() {
+		t.Fatalf("expected zero metadata for nil context, got %+v", got)
+	}
+}
+
+func TestRequestMetadata_FromGinContext(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("request_id", "r2")
+	c.Set("traceparent", "00-a-b-01")
+
+	if got := RequestMetadataFromContext(c); got.RequestID != "r2" || got.TraceParent != "00-a-b-01" {
+		t.Fatalf("expected values from gin.Context, got %+v", got)
+	}
+
+	wrapped := context.WithValue(context.Background(), ginContextKey, c)
+	if got := RequestMetadataFromContext(wrapped); got.RequestID != "r2" {
+		t.Fatalf("expected values from stored gin.Context, got %+v", got)
+	}
+}
@@ -0,0 +1,138 @@
+package context
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// TraceFlagSampled is the sampled bit of TraceContext.Flags: the caller
+// may have recorded the trace, so downstream services should too.
+const TraceFlagSampled byte = 0x01
+
+// ErrInvalidTraceParent is returned by ParseTraceParent for values that do
+// not follow the W3C Trace Context traceparent format.
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+// TraceContext is a W3C Trace Context: the trace, the parent span and the
+// trace flags of a traceparent header, plus the vendor tracestate.
+//
+// See https://www.w3.org/TR/trace-context/.
+type TraceContext struct {
+	// TraceID is the 32 lowercase hex digits of the trace.
+	TraceID string
+
+	// SpanID is the 16 lowercase hex digits of the span.
+	SpanID string
+
+	// Flags are the trace flags, see TraceFlagSampled.
+	Flags byte
+
+	// State is the tracestate header, passed on as is.
+	State string
+}
+
+// NewTraceContext returns a TraceContext starting a new sampled trace,
+// with IDs from crypto/rand.
+func NewTraceContext() TraceContext {
+	return TraceContext{TraceID: randomHex(16), SpanID: randomHex(8), Flags: TraceFlagSampled}
+}
+
+// ParseTraceParent parses a traceparent header such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". It rejects
+// malformed values, the invalid version ff, and all-zero IDs. Values of a
+// later version are parsed as version 00, ignoring the fields that version
+// adds, as the specification requires.
+func ParseTraceParent(s string) (TraceContext, error) {
+	s = strings.TrimSpace(s)
+	const size = 55 // 2 + 1 + 32 + 1 + 16 + 1 + 2
+	if len(s) < size || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+	version, traceID, spanID, flags := s[0:2], s[3:35], s[36:52], s[53:55]
+	if !isLowerHex(version) || version == "ff" {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+	if len(s) > size && (version == "00" || s[size] != '-') {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+	if !isLowerHex(traceID) || isZeros(traceID) || !isLowerHex(spanID) || isZeros(spanID) || !isLowerHex(flags) {
+		return TraceContext{}, ErrInvalidTraceParent
+	}
+
+	b, _ := hex.DecodeString(flags)
+	return TraceContext{TraceID: traceID, SpanID: spanID, Flags: b[0]}, nil
+}
+
+// IsValid reports whether tc has a trace and span ID.
+func (tc TraceContext) IsValid() bool {
+	return tc.TraceID != "" && tc.SpanID != ""
+}
+
+// Sampled reports whether the sampled flag is set.
+func (tc TraceContext) Sampled() bool {
+	return tc.Flags&TraceFlagSampled != 0
+}
+
+// String returns tc as a version 00 traceparent header value.
+func (tc TraceContext) String() string {
+	return "00-" + tc.TraceID + "-" + tc.SpanID + "-" + hex.EncodeToString([]byte{tc.Flags})
+}
+
+type traceContextKey struct{}
+
+// WithTraceContext returns a copy of ctx carrying tc.
+func WithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFromContext returns the TraceContext stored in ctx, as set by
+// the logger middleware. If none was stored explicitly, it parses the
+// traceparent of the request metadata of ctx. ok is false if neither is
+// found or valid.
+func TraceContextFromContext(ctx context.Context) (tc TraceContext, ok bool) {
+	if ctx == nil {
+		return TraceContext{}, false
+	}
+	if tc, ok := ctx.Value(traceContextKey{}).(TraceContext); ok {
+		return tc, true
+	}
+
+	md := RequestMetadataFromContext(ctx)
+	tc, err := ParseTraceParent(md.TraceParent)
+	if err != nil {
+		return TraceContext{}, false
+	}
+	tc.State = md.TraceState
+	return tc, true
+}
+
+// randomHex returns n random bytes, hex-encoded. A zero result, which
+// trace and span IDs must not be, is redrawn.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	for {
+		// crypto/rand.Read does not fail on supported platforms
+		_, _ = rand.Read(b)
+		if s := hex.EncodeToString(b); !isZeros(s) {
+			return s
+		}
+	}
+}
+
+// isLowerHex reports whether s only holds lowercase hex digits.
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// isZeros reports whether s only holds '0'.
+func isZeros(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
@@ -0,0 +1,80 @@
+package context
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+const validTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+	tc, err := ParseTraceParent(" " + validTraceParent + " ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tc.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tc.SpanID != "00f067aa0ba902b7" || !tc.Sampled() {
+		t.Errorf("unexpected trace context %+v", tc)
+	}
+	if tc.String() != validTraceParent {
+		t.Errorf("expected %s, got %s", validTraceParent, tc.String())
+	}
+
+	tc, err = ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	if err != nil || tc.Sampled() || tc.Flags != 0 {
+		t.Errorf("expected an unsampled trace, got %+v, %v", tc, err)
+	}
+
+	// Later versions are read as version 00 and may carry more fields
+	tc, err = ParseTraceParent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-09-extra")
+	if err != nil || tc.Flags != 0x09 || tc.String() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-09" {
+		t.Errorf("expected a future version to parse, got %+v, %v", tc, err)
+	}
+}
+
+func TestParseTraceParent_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"0g-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-600f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0x",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01x",
+	} {
+		if _, err := ParseTraceParent(s); !errors.Is(err, ErrInvalidTraceParent) {
+			t.Errorf("ParseTraceParent(%q): expected ErrInvalidTraceParent, got %v", s, err)
+		}
+	}
+}
+
+func TestNewTraceContext(t *testing.T) {
+	a, b := NewTraceContext(), NewTraceContext()
+	if !a.IsValid() || !a.Sampled() || a.TraceID == b.TraceID || a.SpanID == b.SpanID {
+		t.Errorf("expected distinct sampled trace contexts, got %+v and %+v", a, b)
+	}
+	if parsed, err := ParseTraceParent(a.String()); err != nil || parsed != a {
+		t.Errorf("expected the generated traceparent to parse back, got %+v, %v", parsed, err)
+	}
+}
+
+func TestTraceContextFromContext(t *testing.T) {
+	if _, ok := TraceContextFromContext(context.Background()); ok {
+		t.Error("expected no trace context in an empty context")
+	}
+
+	tc := NewTraceContext()
+	if got, ok := TraceContextFromContext(WithTraceContext(context.Background(), tc)); !ok || got != tc {
+		t.Errorf("expected %+v, got %+v", tc, got)
+	}
+
+	ctx := WithRequestMetadata(context.Background(), RequestMetadata{TraceParent: validTraceParent, TraceState: "k=v"})
+	got, ok := TraceContextFromContext(ctx)
+	if !ok || got.SpanID != "00f067aa0ba902b7" || got.State != "k=v" {
+		t.Errorf("expected the trace context parsed from the metadata, got %+v", got)
+	}
+}
@@ -3,7 +3,10 @@
 package cors
 
 import (
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,10 +14,44 @@ import (
 // CORSConfig defines the configuration for CORS headers.
 // If any field is omitted, sensible defaults are used.
 type CORSConfig struct {
-	AllowOrigins     []string
+	// AllowOrigins lists the origins allowed, e.g. "https://example.com".
+	// A "*." host prefix allows any subdomain, e.g. "https://*.example.com"
+	// matches "https://pr-42.preview.example.com" but not
+	// "https://example.com". The request Origin is echoed back when it
+	// matches, since browsers reject a list of origins. "*" allows any
+	// other origin, but is sent as is and without credentials, so that
+	// sites cannot read credentialed responses.
+	AllowOrigins []string
+
+	// AllowOriginPatterns also allows the origins matching one of these
+	// expressions. Anchor them, e.g. `^https://pr-\d+\.example\.com$`.
+	AllowOriginPatterns []*regexp.Regexp
+
+	// AllowOriginFunc decides on the origins that AllowOrigins and
+	// AllowOriginPatterns do not match, e.g. by looking them up in the
+	// registered origins of a tenant. It runs on every such request, so
+	// cache slow lookups.
+	AllowOriginFunc func(origin string, c *gin.Context) bool
+
 	AllowCredentials string
 	AllowHeaders     []string
 	AllowMethods     []string
+
+	// ExposeHeaders lists the response headers, beyond the CORS-safelisted
+	// ones, that client scripts may read, e.g. "X-Request-ID".
+	ExposeHeaders []string
+
+	// MaxAge is how long browsers may cache a preflight response, sent in
+	// whole seconds. Zero omits the header, leaving the browser default of
+	// a few seconds.
+	MaxAge time.Duration
+
+	// AllowPrivateNetwork answers the Private Network Access preflights
+	// Chrome sends before public sites call a private network address, such
+	// as a dashboard calling an internal service. Off by default.
+	//
+	// See https://wicg.github.io/private-network-access/.
+	AllowPrivateNetwork bool
 }
 
 // Default values for headers and methods.
@@ -29,7 +66,7 @@ var (
 
 // CORSMiddleware returns a Gin middleware that applies CORS headers
 // based on the provided configuration. If config is nil, a permissive
-// default is used that allows all origins and credentials.
+// default is used that allows all origins, without credentials.
 //
 // Example:
 //
@@ -38,33 +75,144 @@ var (
 //	    AllowOrigins: []string{"https://example.com"},
 //	}))
 //
+// The request Origin is matched against AllowOrigins and echoed back alone,
+// with "Vary: Origin" so caches keep one response per origin. Requests from
+// other origins get no Access-Control-* headers, so browsers block them.
+//
 // The middleware automatically responds to OPTIONS preflight requests
 // with status 204 and skips the rest of the chain.
 func CORSMiddleware(config *CORSConfig) gin.HandlerFunc {
+	// Use default configuration if none provided.
+	if config == nil {
+		config = &CORSConfig{
+			AllowOrigins: []string{"*"},
+			AllowHeaders: defaultHeaders,
+			AllowMethods: defaultMethods,
+		}
+	}
+	origins := newOriginMatcher(config.AllowOrigins, config.AllowOriginPatterns)
+	origins.dynamic = origins.dynamic || config.AllowOriginFunc != nil
+	allowHeaders := strings.Join(config.AllowHeaders, ",")
+	allowMethods := strings.Join(config.AllowMethods, ",")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ",")
+	maxAge := ""
+	if seconds := int64(config.MaxAge / time.Second); seconds > 0 {
+		maxAge = strconv.FormatInt(seconds, 10)
+	}
+
 	return func(c *gin.Context) {
-		// Use default configuration if none provided.
-		if config == nil {
-			config = &CORSConfig{
-				AllowOrigins:     []string{"*"},
-				AllowHeaders:     defaultHeaders,
-				AllowMethods:     defaultMethods,
-				AllowCredentials: "true",
+		headers := c.Writer.Header()
+		origin := c.Request.Header.Get("Origin")
+
+		allowOrigin, credentials := "", ""
+		if origin != "" && origins.dynamic {
+			headers.Add("Vary", "Origin")
+			if origins.match(origin) ||
+				config.AllowOriginFunc != nil && config.AllowOriginFunc(origin, c) {
+				allowOrigin, credentials = origin, config.AllowCredentials
 			}
 		}
+		if allowOrigin == "" && origins.any {
+			// "*" is never combined with credentials: echoing any origin
+			// with them would let every site read credentialed responses
+			allowOrigin = "*"
+		}
 
-		headers := c.Writer.Header()
-		headers.Set("Access-Control-Allow-Origin", strings.Join(config.AllowOrigins, ","))
-		headers.Set("Access-Control-Allow-Credentials", config.AllowCredentials)
-		headers.Set("Access-Control-Allow-Headers", strings.Join(config.AllowHeaders, ","))
-		// ✅ FIXED BUG: previously used AllowOrigins instead of AllowMethods
-		headers.Set("Access-Control-Allow-Methods", strings.Join(config.AllowMethods, ","))
+		if allowOrigin != "" {
+			headers.Set("Access-Control-Allow-Origin", allowOrigin)
+			if credentials != "" {
+				headers.Set("Access-Control-Allow-Credentials", credentials)
+			}
+			headers.Set("Access-Control-Allow-Headers", allowHeaders)
+			headers.Set("Access-Control-Allow-Methods", allowMethods)
+		}
 
 		// Handle preflight request
 		if c.Request.Method == "OPTIONS" {
+			if allowOrigin != "" && maxAge != "" {
+				headers.Set("Access-Control-Max-Age", maxAge)
+			}
+			if allowOrigin != "" && config.AllowPrivateNetwork &&
+				c.Request.Header.Get("Access-Control-Request-Private-Network") == "true" {
+				headers.Set("Access-Control-Allow-Private-Network", "true")
+			}
 			c.AbortWithStatus(204)
 			return
 		}
+		if allowOrigin != "" && exposeHeaders != "" {
+			headers.Set("Access-Control-Expose-Headers", exposeHeaders)
+		}
 
 		c.Next()
 	}
 }
+
+// originMatcher matches request origins against an allowlist.
+type originMatcher struct {
+	any bool
+	// dynamic is set when the allowed origin depends on the request Origin
+	dynamic  bool
+	origins  map[string]bool
+	wildcard []wildcardOrigin
+	patterns []*regexp.Regexp
+}
+
+// wildcardOrigin is an origin with a "*." host prefix, split around the
+// star.
+type wildcardOrigin struct {
+	prefix, suffix string
+}
+
+// newOriginMatcher compiles allowed and patterns. Origins are compared
+// case-insensitively and without a trailing slash.
+func newOriginMatcher(allowed []string, patterns []*regexp.Regexp) originMatcher {
+	m := originMatcher{origins: make(map[string]bool, len(allowed)), patterns: patterns}
+	for _, o := range allowed {
+		if o == "*" {
+			m.any = true
+			continue
+		}
+		o = normalizeOrigin(o)
+		if i := strings.Index(o, "://*."); i >= 0 {
+			m.wildcard = append(m.wildcard, wildcardOrigin{prefix: o[:i+3], suffix: o[i+4:]})
+			continue
+		}
+		m.origins[o] = true
+	}
+	m.dynamic = len(m.origins) > 0 || len(m.wildcard) > 0 || len(patterns) > 0
+	return m
+}
+
+// match reports whether origin is allowed.
+func (m originMatcher) match(origin string) bool {
+	o := normalizeOrigin(origin)
+	if m.origins[o] {
+		return true
+	}
+	for _, w := range m.wildcard {
+		if w.match(o) {
+			return true
+		}
+	}
+	for _, p := range m.patterns {
+		if p.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether the normalized origin o has a non-empty subdomain
+// in place of the star.
+func (w wildcardOrigin) match(o string) bool {
+	if len(o) <= len(w.prefix)+len(w.suffix) || !strings.HasPrefix(o, w.prefix) || !strings.HasSuffix(o, w.suffix) {
+		return false
+	}
+	sub := o[len(w.prefix) : len(o)-len(w.suffix)]
+	return !strings.ContainsAny(sub, "/:@") && !strings.HasPrefix(sub, ".") && !strings.HasSuffix(sub, ".")
+}
+
+// normalizeOrigin lowercases origin and removes a trailing slash.
+func normalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimSuffix(origin, "/"))
+}
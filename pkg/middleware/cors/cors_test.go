@@ -3,8 +3,10 @@ package cors
 import (
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,6 +14,10 @@ import (
 // --- Helpers ---
 
 func performRequest(method, path string, mw gin.HandlerFunc, config *CORSConfig) *httptest.ResponseRecorder {
+	return performRequestFrom(method, path, "", mw)
+}
+
+func performRequestFrom(method, path, origin string, mw gin.HandlerFunc, header ...string) *httptest.ResponseRecorder {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	router.Use(mw)
@@ -20,6 +26,12 @@ func performRequest(method, path string, mw gin.HandlerFunc, config *CORSConfig)
 	})
 
 	req, _ := http.NewRequest(method, path, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 	return w
@@ -35,8 +47,8 @@ func TestCORSMiddleware_DefaultConfig(t *testing.T) {
 	if got := headers.Get("Access-Control-Allow-Origin"); got != "*" {
 		t.Errorf("expected * origin, got %q", got)
 	}
-	if got := headers.Get("Access-Control-Allow-Credentials"); got != "true" {
-		t.Errorf("expected Allow-Credentials true, got %q", got)
+	if got := headers.Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("expected no Allow-Credentials, got %q", got)
 	}
 
 	// Headers should include known defaults
@@ -68,11 +80,11 @@ func TestCORSMiddleware_CustomConfig(t *testing.T) {
 		AllowCredentials: "false",
 	}
 
-	w := performRequest("GET", "/test", CORSMiddleware(cfg), cfg)
+	w := performRequestFrom("GET", "/test", "https://foo.com", CORSMiddleware(cfg))
 	h := w.Header()
 
-	if got := h.Get("Access-Control-Allow-Origin"); !strings.Contains(got, "example.com") {
-		t.Errorf("expected custom origin list, got %q", got)
+	if got := h.Get("Access-Control-Allow-Origin"); got != "https://foo.com" {
+		t.Errorf("expected the matched origin, got %q", got)
 	}
 	if got := h.Get("Access-Control-Allow-Credentials"); got != "false" {
 		t.Errorf("expected Allow-Credentials false, got %q", got)
@@ -124,3 +136,182 @@ func TestCORSMiddleware_CallsNextForNonOptions(t *testing.T) {
 		t.Errorf("expected 200 OK, got %d", w.Code)
 	}
 }
+
+func TestCORSMiddleware_OriginAllowlist(t *testing.T) {
+	mw := CORSMiddleware(&CORSConfig{
+		AllowOrigins:     []string{"https://example.com/", "https://foo.com"},
+		AllowCredentials: "true",
+	})
+
+	tt := []struct {
+		name   string
+		origin string
+		want   string
+	}{
+		{name: "allowed", origin: "https://example.com", want: "https://example.com"},
+		{name: "case-insensitive", origin: "https://FOO.com", want: "https://FOO.com"},
+		{name: "not allowed", origin: "https://evil.com", want: ""},
+		{name: "other port", origin: "https://example.com:8443", want: ""},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			w := performRequestFrom("GET", "/test", tc.origin, mw)
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tc.want {
+				t.Errorf("expected Allow-Origin %q, got %q", tc.want, got)
+			}
+			if got := w.Header().Get("Vary"); got != "Origin" {
+				t.Errorf("expected Vary: Origin, got %q", got)
+			}
+			if tc.want == "" && w.Header().Get("Access-Control-Allow-Credentials") != "" {
+				t.Errorf("expected no CORS headers for a rejected origin")
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware_WildcardWithCredentials(t *testing.T) {
+	for _, cfg := range []*CORSConfig{nil, {AllowOrigins: []string{"*"}, AllowCredentials: "true"}} {
+		w := performRequestFrom("GET", "/test", "https://evil.example", CORSMiddleware(cfg))
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("expected * rather than the echoed origin, got %q", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("expected no credentials for *, got %q", got)
+		}
+		if got := w.Header().Get("Vary"); got != "" {
+			t.Errorf("expected no Vary for *, got %q", got)
+		}
+	}
+
+	// Listed origins keep their credentials next to "*"
+	cfg := &CORSConfig{AllowOrigins: []string{"*", "https://app.example.com"}, AllowCredentials: "true"}
+	w := performRequestFrom("GET", "/test", "https://app.example.com", CORSMiddleware(cfg))
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("expected the listed origin echoed, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("expected credentials for a listed origin, got %q", got)
+	}
+	w = performRequestFrom("GET", "/test", "https://evil.example", CORSMiddleware(cfg))
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("expected * for other origins, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("expected no credentials for other origins, got %q", got)
+	}
+}
+
+func TestCORSMiddleware_OriginPatterns(t *testing.T) {
+	mw := CORSMiddleware(&CORSConfig{
+		AllowOrigins:        []string{"https://*.example.com", "http://*.localhost:3000"},
+		AllowOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`^https://pr-\d+\.preview\.dev$`)},
+	})
+
+	tt := []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://app.example.com", allowed: true},
+		{origin: "https://pr-42.preview.example.com", allowed: true},
+		{origin: "http://web.localhost:3000", allowed: true},
+		{origin: "https://pr-7.preview.dev", allowed: true},
+		{origin: "https://example.com", allowed: false},
+		{origin: "http://app.example.com", allowed: false},
+		{origin: "https://app.example.com:8443", allowed: false},
+		{origin: "https://evil.com/.example.com", allowed: false},
+		{origin: "https://notexample.com", allowed: false},
+		{origin: "https://pr-x.preview.dev", allowed: false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.origin, func(t *testing.T) {
+			w := performRequestFrom("GET", "/test", tc.origin, mw)
+			got := w.Header().Get("Access-Control-Allow-Origin")
+			if tc.allowed && got != tc.origin {
+				t.Errorf("expected %q allowed, got %q", tc.origin, got)
+			}
+			if !tc.allowed && got != "" {
+				t.Errorf("expected %q rejected, got %q", tc.origin, got)
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware_MaxAgeAndExposeHeaders(t *testing.T) {
+	mw := CORSMiddleware(&CORSConfig{
+		AllowOrigins:  []string{"https://example.com"},
+		ExposeHeaders: []string{"X-Request-ID", "X-Total-Count"},
+		MaxAge:        10 * time.Minute,
+	})
+
+	w := performRequestFrom("OPTIONS", "/test", "https://example.com", mw)
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("expected Max-Age 600 on preflight, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "" {
+		t.Errorf("expected no Expose-Headers on preflight, got %q", got)
+	}
+
+	w = performRequestFrom("GET", "/test", "https://example.com", mw)
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "X-Request-ID,X-Total-Count" {
+		t.Errorf("expected Expose-Headers on the actual response, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Errorf("expected no Max-Age on the actual response, got %q", got)
+	}
+
+	w = performRequestFrom("OPTIONS", "/test", "https://evil.com", mw)
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Errorf("expected no Max-Age for a rejected origin, got %q", got)
+	}
+}
+
+func TestCORSMiddleware_AllowOriginFunc(t *testing.T) {
+	var asked []string
+	mw := CORSMiddleware(&CORSConfig{
+		AllowOrigins: []string{"https://static.example.com"},
+		AllowOriginFunc: func(origin string, c *gin.Context) bool {
+			asked = append(asked, origin)
+			return origin == "https://tenant.example.org" && c.Request.URL.Path == "/test"
+		},
+	})
+
+	w := performRequestFrom("GET", "/test", "https://tenant.example.org", mw)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://tenant.example.org" {
+		t.Errorf("expected the func to allow the origin, got %q", got)
+	}
+	w = performRequestFrom("GET", "/test", "https://other.example.org", mw)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("expected the func to reject the origin, got %q", got)
+	}
+	performRequestFrom("GET", "/test", "https://static.example.com", mw)
+
+	if len(asked) != 2 {
+		t.Errorf("expected the func consulted for unlisted origins only, got %v", asked)
+	}
+}
+
+func TestCORSMiddleware_PrivateNetwork(t *testing.T) {
+	cfg := &CORSConfig{AllowOrigins: []string{"https://dashboard.example.com"}, AllowPrivateNetwork: true}
+	pna := []string{"Access-Control-Request-Private-Network", "true"}
+
+	w := performRequestFrom("OPTIONS", "/test", "https://dashboard.example.com", CORSMiddleware(cfg), pna...)
+	if got := w.Header().Get("Access-Control-Allow-Private-Network"); got != "true" {
+		t.Errorf("expected Allow-Private-Network true, got %q", got)
+	}
+
+	w = performRequestFrom("OPTIONS", "/test", "https://dashboard.example.com", CORSMiddleware(cfg))
+	if got := w.Header().Get("Access-Control-Allow-Private-Network"); got != "" {
+		t.Errorf("expected no header without the request header, got %q", got)
+	}
+
+	w = performRequestFrom("OPTIONS", "/test", "https://evil.com", CORSMiddleware(cfg), pna...)
+	if got := w.Header().Get("Access-Control-Allow-Private-Network"); got != "" {
+		t.Errorf("expected no header for a rejected origin, got %q", got)
+	}
+
+	cfg.AllowPrivateNetwork = false
+	w = performRequestFrom("OPTIONS", "/test", "https://dashboard.example.com", CORSMiddleware(cfg), pna...)
+	if got := w.Header().Get("Access-Control-Allow-Private-Network"); got != "" {
+		t.Errorf("expected the header opt-in, got %q", got)
+	}
+}
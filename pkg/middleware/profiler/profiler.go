@@ -0,0 +1,164 @@
+// Package profiler provides an opt-in Gin middleware for finding
+// allocation-heavy endpoints. Each request runs under runtime/pprof labels,
+// so CPU and heap profiles collected separately (e.g. with net/http/pprof)
+// can be broken down by route, and requests in the slowest percentile get
+// allocation summary fields on their access log.
+//
+// The allocation fields are not attributed to the request: they are deltas
+// of process-wide runtime counters, exact for serial traffic but including
+// the allocations of concurrent requests. No CPU figures are reported. The
+// middleware is intended for debug and staging environments.
+package profiler
+
+import (
+	"context"
+	"runtime/metrics"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranorsolutions/http-common-go/pkg/log/logger"
+)
+
+// Config controls the profiler middleware.
+type Config struct {
+	// Percentile selects which requests get summary fields, e.g. 0.99 for
+	// the slowest 1%. Defaults to 0.99.
+	Percentile float64
+
+	// Window is the number of recent request latencies used to compute the
+	// percentile threshold. Defaults to 1000.
+	Window int
+
+	// MinSamples is the number of requests observed before any are reported.
+	// Until then no threshold is known. Defaults to 100, and is capped at
+	// Window since no more samples are kept.
+	MinSamples int
+}
+
+// Field names attached to the access log. FieldAllocBytes and
+// FieldAllocObjects count every allocation of the process during the
+// request.
+const (
+	FieldAllocBytes   = "alloc_bytes"
+	FieldAllocObjects = "alloc_objects"
+	FieldSlowestPct   = "slowest_pct"
+)
+
+// allocation metrics read before and after each request.
+var sampleNames = []string{
+	"/gc/heap/allocs:bytes",
+	"/gc/heap/allocs:objects",
+}
+
+// Middleware returns a Gin middleware that labels requests for pprof and
+// reports the process-wide allocations made while the slowest requests ran
+// through logger.AddFields. Register it after the logger middleware.
+//
+// Example:
+//
+//	r.Use(log.Middleware())
+//	if gin.Mode() == gin.DebugMode {
+//	    r.Use(profiler.Middleware(profiler.Config{Percentile: 0.95}))
+//	}
+func Middleware(cfg Config) gin.HandlerFunc {
+	if cfg.Percentile <= 0 || cfg.Percentile >= 1 {
+		cfg.Percentile = 0.99
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 1000
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = 100
+	}
+	cfg.MinSamples = min(cfg.MinSamples, cfg.Window)
+	w := newLatencyWindow(cfg.Window, cfg.Percentile)
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		labels := pprof.Labels("http.method", c.Request.Method, "http.route", route)
+
+		before := readAllocs()
+		start := time.Now()
+		pprof.Do(c.Request.Context(), labels, func(ctx context.Context) {
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+		})
+		elapsed := time.Since(start)
+		after := readAllocs()
+
+		threshold, samples := w.observe(elapsed)
+		if samples < cfg.MinSamples || elapsed < threshold {
+			return
+		}
+		logger.AddFields(c, map[string]interface{}{
+			FieldAllocBytes:   after[0] - before[0],
+			FieldAllocObjects: after[1] - before[1],
+			FieldSlowestPct:   cfg.Percentile,
+		})
+	}
+}
+
+// readAllocs returns the cumulative heap allocation counters.
+func readAllocs() [2]uint64 {
+	samples := make([]metrics.Sample, len(sampleNames))
+	for i, name := range sampleNames {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+
+	var out [2]uint64
+	for i, s := range samples {
+		if s.Value.Kind() == metrics.KindUint64 {
+			out[i] = s.Value.Uint64()
+		}
+	}
+	return out
+}
+
+// latencyWindow keeps a ring of recent latencies and a cached percentile
+// threshold, recomputed every recomputeEvery observations.
+type latencyWindow struct {
+	mu         sync.Mutex
+	ring       []time.Duration
+	next       int
+	count      int
+	percentile float64
+	threshold  time.Duration
+	sinceCalc  int
+}
+
+// recomputeEvery bounds how often the window is sorted.
+const recomputeEvery = 50
+
+func newLatencyWindow(size int, percentile float64) *latencyWindow {
+	return &latencyWindow{ring: make([]time.Duration, size), percentile: percentile}
+}
+
+// observe records d and returns the threshold computed before d was added
+// together with the number of observations so far.
+func (w *latencyWindow) observe(d time.Duration) (time.Duration, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	threshold := w.threshold
+	w.ring[w.next] = d
+	w.next = (w.next + 1) % len(w.ring)
+	if w.count < len(w.ring) {
+		w.count++
+	}
+
+	w.sinceCalc++
+	if w.sinceCalc >= recomputeEvery || w.threshold == 0 {
+		w.sinceCalc = 0
+		sorted := append([]time.Duration(nil), w.ring[:w.count]...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		w.threshold = sorted[int(float64(len(sorted)-1)*w.percentile)]
+	}
+	return threshold, w.count
+}
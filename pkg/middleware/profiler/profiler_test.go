@@ -0,0 +1,109 @@
+package profiler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranorsolutions/http-common-go/pkg/log/logger"
+)
+
+// fieldsRecorder captures the log fields left on the Gin context.
+func fieldsRecorder(out *map[string]interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if v, ok := c.Get(logger.FieldsKey); ok {
+			*out = v.(map[string]interface{})
+		}
+	}
+}
+
+func TestMiddleware_ReportsSlowRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var fields map[string]interface{}
+	var route string
+	r := gin.New()
+	r.Use(fieldsRecorder(&fields), Middleware(Config{MinSamples: 1, Percentile: 0.5}))
+	r.GET("/slow", func(c *gin.Context) {
+		route, _ = pprof.Label(c.Request.Context(), "http.route")
+		_ = make([]byte, 1<<20)
+		time.Sleep(5 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if route != "/slow" {
+		t.Errorf("expected pprof route label, got %q", route)
+	}
+	if fields == nil {
+		t.Fatal("expected profile fields on the access log")
+	}
+	if b, _ := fields[FieldAllocBytes].(uint64); b < 1<<20 {
+		t.Errorf("expected at least 1MiB allocated, got %v", fields[FieldAllocBytes])
+	}
+}
+
+func TestMiddleware_SkipsUntilMinSamples(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var fields map[string]interface{}
+	r := gin.New()
+	r.Use(fieldsRecorder(&fields), Middleware(Config{MinSamples: 10}))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if fields != nil {
+		t.Errorf("expected no fields before MinSamples, got %v", fields)
+	}
+}
+
+func TestMiddleware_MinSamplesCappedAtWindow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var fields map[string]interface{}
+	r := gin.New()
+	r.Use(fieldsRecorder(&fields), Middleware(Config{Window: 2, MinSamples: 100, Percentile: 0.5}))
+	r.GET("/fast", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/slow", func(c *gin.Context) {
+		time.Sleep(5 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fast", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if fields == nil {
+		t.Error("expected slow requests to be reported once the window is full")
+	}
+}
+
+func TestLatencyWindow_Threshold(t *testing.T) {
+	w := newLatencyWindow(100, 0.9)
+	for i := 1; i <= 100; i++ {
+		w.observe(time.Duration(i) * time.Millisecond)
+	}
+	// Force a recompute with a full window of 1..100ms.
+	w.sinceCalc = recomputeEvery
+	w.observe(100 * time.Millisecond)
+
+	threshold, n := w.observe(time.Millisecond)
+	if n != 100 {
+		t.Errorf("expected window to be capped at 100, got %d", n)
+	}
+	if threshold < 85*time.Millisecond || threshold > 95*time.Millisecond {
+		t.Errorf("expected p90 near 90ms, got %v", threshold)
+	}
+}
+
+func TestReadAllocs_Monotonic(t *testing.T) {
+	before := readAllocs()
+	_ = make([]byte, 1<<20)
+	after := readAllocs()
+	if after[0] < before[0] || after[1] < before[1] {
+		t.Errorf("expected counters to be monotonic: %v -> %v", before, after)
+	}
+}
@@ -0,0 +1,117 @@
+package recovery
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ranorsolutions/http-common-go/pkg/internal/httpbody"
+	"github.com/ranorsolutions/http-common-go/pkg/log/formatter"
+)
+
+// DumpKey is the log field holding the request dump of a panic.
+const DumpKey = "request_dump"
+
+// DefaultDumpBodyLimit is the number of body bytes dumped unless
+// RequestDumpConfig.MaxBodyBytes is set.
+const DefaultDumpBodyLimit = 1 << 10
+
+// DefaultDumpHeaders are the headers dumped unless RequestDumpConfig.Headers
+// is set. Authorization and Cookie show whether credentials were sent, never
+// their value.
+var DefaultDumpHeaders = []string{"Accept", "Authorization", "Content-Length", "Content-Type", "Cookie", "User-Agent"}
+
+// alwaysRedactedHeaders are masked whatever the Redactor.
+var alwaysRedactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// RequestDumpConfig configures the request dump of RecoveryConfig.DumpRequest.
+type RequestDumpConfig struct {
+	// Headers dumped. Defaults to DefaultDumpHeaders.
+	Headers []string
+
+	// Bytes of the body dumped; longer bodies are cut and flagged with
+	// body_truncated. Defaults to DefaultDumpBodyLimit; a negative value
+	// leaves the body out.
+	MaxBodyBytes int
+
+	// Masks sensitive query and header values, and JSON and form body
+	// values by key, even in a truncated body. Defaults to
+	// formatter.NewRedactor().
+	Redactor *formatter.Redactor
+}
+
+// withDefaults returns cfg with the defaults filled in.
+func (cfg RequestDumpConfig) withDefaults() *RequestDumpConfig {
+	if len(cfg.Headers) == 0 {
+		cfg.Headers = DefaultDumpHeaders
+	}
+	if cfg.MaxBodyBytes == 0 {
+		cfg.MaxBodyBytes = DefaultDumpBodyLimit
+	}
+	if cfg.Redactor == nil {
+		cfg.Redactor = formatter.NewRedactor()
+	}
+	return &cfg
+}
+
+// captureBody reads the first MaxBodyBytes+1 bytes of the request body and
+// puts them back in front of the rest, so the handler sees the body
+// unchanged even if it panics before reading it.
+func (cfg *RequestDumpConfig) captureBody(req *http.Request) []byte {
+	if cfg.MaxBodyBytes < 0 {
+		return nil
+	}
+	head, _ := httpbody.Capture(req, cfg.MaxBodyBytes)
+	return head
+}
+
+// dump returns the redacted method, path, query, headers and body of req.
+func (cfg *RequestDumpConfig) dump(req *http.Request, body []byte) map[string]interface{} {
+	r := cfg.Redactor
+	dump := map[string]interface{}{
+		"method": req.Method,
+		"path":   req.URL.Path,
+	}
+	if req.URL.RawQuery != "" {
+		query, _ := url.ParseQuery(req.URL.RawQuery)
+		dump["query"] = r.Value("", map[string][]string(query))
+	}
+
+	headers := map[string]string{}
+	for _, name := range cfg.Headers {
+		v := req.Header.Get(name)
+		switch {
+		case v == "":
+		case r.IsSensitive(name):
+			headers[http.CanonicalHeaderKey(name)] = r.Value(name, v).(string)
+		case isAlwaysRedacted(name):
+			headers[http.CanonicalHeaderKey(name)] = formatter.DefaultRedactMask
+		default:
+			headers[http.CanonicalHeaderKey(name)] = r.String(v)
+		}
+	}
+	if len(headers) > 0 {
+		dump["headers"] = headers
+	}
+
+	if len(body) > 0 {
+		truncated := len(body) > cfg.MaxBodyBytes
+		if truncated {
+			body = body[:cfg.MaxBodyBytes]
+			dump["body_truncated"] = true
+		}
+		dump["body"] = httpbody.Redact(cfg.Redactor, body, req.Header.Get("Content-Type"), truncated)
+	}
+	return dump
+}
+
+// isAlwaysRedacted reports whether the header name is masked whatever the
+// Redactor.
+func isAlwaysRedacted(name string) bool {
+	for _, h := range alwaysRedactedHeaders {
+		if strings.EqualFold(name, h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package recovery
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranorsolutions/http-common-go/pkg/log/formatter"
+	"github.com/ranorsolutions/http-common-go/pkg/log/logger"
+)
+
+func TestRecovery_DumpRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	l, _ := logger.New("svc", "v1", true)
+	h := &testHook{}
+	l.Entry.Logger.AddHook(h)
+
+	var read string
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("logger", l)
+		c.Next()
+	})
+	r.Use(Middleware(&RecoveryConfig{DumpRequest: &RequestDumpConfig{}}))
+	r.POST("/orders", func(c *gin.Context) {
+		b, _ := io.ReadAll(c.Request.Body)
+		read = string(b)
+		panic("bad order")
+	})
+
+	body := `{"item":"book","password":"hunter2"}`
+	req := httptest.NewRequest(http.MethodPost, "/orders?id=7&token=abc", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Cookie", "session=1")
+	req.Header.Set("X-Internal", "not dumped")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if read != body {
+		t.Errorf("expected the handler to read the full body, got %q", read)
+	}
+	if len(h.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(h.entries))
+	}
+	dump, _ := h.entries[0].Data[DumpKey].(map[string]interface{})
+	if dump["method"] != "POST" || dump["path"] != "/orders" {
+		t.Errorf("unexpected dump %v", dump)
+	}
+	wantQuery := map[string][]string{"id": {"7"}, "token": {formatter.DefaultRedactMask}}
+	if !reflect.DeepEqual(dump["query"], wantQuery) {
+		t.Errorf("expected %v, got %v", wantQuery, dump["query"])
+	}
+	wantHeaders := map[string]string{
+		"Authorization": formatter.DefaultRedactMask,
+		"Cookie":        formatter.DefaultRedactMask,
+		"Content-Type":  "application/json",
+	}
+	if !reflect.DeepEqual(dump["headers"], wantHeaders) {
+		t.Errorf("expected %v, got %v", wantHeaders, dump["headers"])
+	}
+	if dump["body"] != `{"item":"book","password":"[REDACTED]"}` {
+		t.Errorf("unexpected body %v", dump["body"])
+	}
+}
+
+func TestRequestDumpConfig_Dump(t *testing.T) {
+	cfg := RequestDumpConfig{
+		Headers:      []string{"authorization", "X-Tenant"},
+		MaxBodyBytes: 4,
+		Redactor:     &formatter.Redactor{Keys: []string{"ssn"}},
+	}.withDefaults()
+
+	req := httptest.NewRequest(http.MethodPut, "/users/1", strings.NewReader("0123456789"))
+	req.Header.Set("Authorization", "Basic abc")
+	req.Header.Set("X-Tenant", "acme")
+	body := cfg.captureBody(req)
+	rest, _ := io.ReadAll(req.Body)
+	if string(rest) != "0123456789" {
+		t.Errorf("expected the body restored, got %q", rest)
+	}
+
+	dump := cfg.dump(req, body)
+	wantHeaders := map[string]string{"Authorization": formatter.DefaultRedactMask, "X-Tenant": "acme"}
+	if !reflect.DeepEqual(dump["headers"], wantHeaders) {
+		t.Errorf("expected Authorization masked whatever the redactor, got %v", dump["headers"])
+	}
+	if dump["body"] != "0123" || dump["body_truncated"] != true {
+		t.Errorf("expected the body cut at 4 bytes, got %v", dump)
+	}
+
+	cfg.MaxBodyBytes = -1
+	if body := cfg.captureBody(req); body != nil {
+		t.Errorf("expected no body captured, got %q", body)
+	}
+}
+
+func TestRequestDumpConfig_RedactsFormAndTruncatedJSON(t *testing.T) {
+	cfg := RequestDumpConfig{MaxBodyBytes: 30}.withDefaults()
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("user=ana&password=hunter2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	dump := cfg.dump(req, cfg.captureBody(req))
+	if dump["body"] != "password=%5BREDACTED%5D&user=ana" {
+		t.Errorf("expected the form password masked, got %v", dump["body"])
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"password":"hunter2","note":"`+strings.Repeat("x", 40)+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	dump = cfg.dump(req, cfg.captureBody(req))
+	if got, _ := dump["body"].(string); strings.Contains(got, "hunter2") || dump["body_truncated"] != true {
+		t.Errorf("expected the truncated JSON password masked, got %v", dump)
+	}
+}
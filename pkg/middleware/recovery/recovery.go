@@ -3,14 +3,33 @@
 // 500 response to the client. If a logger compatible with this package's
 // Errorf interface is present in the Gin context under the key "logger",
 // it will be used; otherwise the middleware falls back to the standard log.
+// Handler does the same for net/http handlers.
 package recovery
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"runtime/debug"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ranorsolutions/http-common-go/pkg/log/logger"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+)
+
+// ErrorIDKey is the log field and JSON response key of the ID generated for
+// each recovered panic; ErrorIDHeader is the response header carrying it.
+const (
+	ErrorIDKey    = "error_id"
+	ErrorIDHeader = "X-Error-ID"
 )
 
 // loggerIface is the minimal contract we need from a logger.
@@ -20,6 +39,12 @@ type loggerIface interface {
 	Error(msg string, args ...interface{})
 }
 
+// warnLogger is implemented by loggers that can log client aborts at warn
+// level, such as pkg/log/logger.Logger.
+type warnLogger interface {
+	Warn(msg string, args ...interface{})
+}
+
 // RecoveryConfig controls the behavior of the recovery middleware.
 type RecoveryConfig struct {
 	// IncludeStack controls whether a stack trace is captured and logged.
@@ -35,6 +60,65 @@ type RecoveryConfig struct {
 	// OnPanic, if provided, is invoked after the panic is recovered but before the response is sent.
 	// Use this to add metrics or custom tracing.
 	OnPanic func(c *gin.Context, recovered any)
+
+	// Reporter, if provided, receives every recovered panic, e.g. a
+	// SentryReporter forwarding them to alerting.
+	Reporter Reporter
+
+	// ResponseBuilder, if provided, returns the status and JSON body sent
+	// instead of the MaskErrorMessage response, e.g. a response.Response
+	// envelope. errorID uniquely identifies the panic. Set a Content-Type
+	// header on c to send another media type, such as
+	// application/problem+json. Never put recovered in the body.
+	ResponseBuilder func(c *gin.Context, errorID string, recovered any) (status int, body any)
+
+	// Metrics, if provided, counts recovered panics, so panic rates can be
+	// alerted on without parsing logs.
+	Metrics PanicMetrics
+
+	// DumpRequest, if provided, adds the method, path, query, selected
+	// headers and the start of the body of the request to the panic log,
+	// as the DumpKey field, with sensitive values redacted.
+	DumpRequest *RequestDumpConfig
+
+	// Repanic re-raises the panic after it is logged, reported and counted,
+	// instead of sending a 500, so test suites and development runs fail
+	// loudly. RECOVERY_REPANIC=true has the same effect, whatever the
+	// config; keep both off in production.
+	Repanic bool
+}
+
+// PanicMetrics receives a call per recovered panic, client aborts aside.
+// Implementations typically increment a counter labeled by route and panic
+// type (a Prometheus CounterVec, a StatsD counter, ...).
+type PanicMetrics interface {
+	// PanicRecovered is called with the route pattern, e.g. "/users/:id"
+	// (empty for unmatched routes), and the type of the recovered value,
+	// e.g. "runtime.boundsError" or "string".
+	PanicRecovered(route, panicType string)
+}
+
+// Report describes a recovered panic.
+type Report struct {
+	// ErrorID uniquely identifies the panic, as sent to the client.
+	ErrorID string
+
+	// Recovered is the value passed to panic.
+	Recovered any
+
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+
+	// Request is the request being served.
+	Request *http.Request
+
+	// Metadata holds the request and trace IDs set by the logger middleware.
+	Metadata reqctx.RequestMetadata
+}
+
+// Reporter forwards recovered panics to an error tracker.
+type Reporter interface {
+	Report(ctx context.Context, report Report)
 }
 
 // DefaultConfig returns a permissive, production-safe configuration.
@@ -48,15 +132,18 @@ func DefaultConfig() *RecoveryConfig {
 
 // Middleware returns a Gin middleware that recovers from panics,
 // logs, and returns a 500 with a safe message.
+//
+// Panics caused by a client closing the connection (broken pipe or
+// connection reset) are not server errors: they are logged at warn level,
+// not reported, and the request is aborted without writing a response,
+// since the connection is gone.
 func Middleware(cfg *RecoveryConfig) gin.HandlerFunc {
-	if cfg == nil {
-		cfg = DefaultConfig()
-	}
-	if cfg.MaskErrorMessage == "" {
-		cfg.MaskErrorMessage = "internal server error"
-	}
+	rc := newRecoverer(cfg)
+	cfg = rc.cfg
 
 	return func(c *gin.Context) {
+		body := rc.captureBody(c.Request)
+
 		defer func() {
 			if r := recover(); r != nil {
 				// Pick a logger if present
@@ -67,33 +154,32 @@ func Middleware(cfg *RecoveryConfig) gin.HandlerFunc {
 					}
 				}
 
-				// Log panic + optional stack
-				if cfg.IncludeStack {
-					stack := debug.Stack()
-					if lg != nil {
-						lg.Error("panic recovered: %v\n%s", r, string(stack))
-					} else {
-						log.Printf("panic recovered: %v\n%s", r, string(stack))
-					}
-				} else {
-					if lg != nil {
-						lg.Error("panic recovered: %v", r)
-					} else {
-						log.Printf("panic recovered: %v", r)
-					}
+				errorID := rc.recovered(c, c.Request, lg, c.FullPath(), body, r)
+				if errorID == "" {
+					_ = c.Error(r.(error))
+					c.Abort()
+					return
 				}
 
 				// User callback
 				if cfg.OnPanic != nil {
 					cfg.OnPanic(c, r)
 				}
+				if rc.repanic {
+					panic(r)
+				}
 
 				// Mark as recovered and reply
 				c.Header("X-Recovered-From", "panic")
-				if cfg.ResponseJSON {
+				c.Header(ErrorIDHeader, errorID)
+				if cfg.ResponseBuilder != nil {
+					status, body := cfg.ResponseBuilder(c, errorID, r)
+					c.AbortWithStatusJSON(status, body)
+				} else if cfg.ResponseJSON {
 					// Safe JSON payload
 					c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-						"error": cfg.MaskErrorMessage,
+						"error":    cfg.MaskErrorMessage,
+						ErrorIDKey: errorID,
 					})
 				} else {
 					c.AbortWithStatusJSON(http.StatusInternalServerError, cfg.MaskErrorMessage)
@@ -104,3 +190,166 @@ func Middleware(cfg *RecoveryConfig) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// Handler is the net/http version of Middleware, for services on chi or the
+// standard library. Panics are logged through the logger.FromContext of the
+// request, reported, counted by the Go 1.22 route pattern of the request,
+// and answered with the masked message: as JSON if ResponseJSON is set,
+// otherwise as plain text. OnPanic and ResponseBuilder take a Gin context
+// and only apply to Middleware.
+//
+// Example:
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("GET /users/{id}", getUser)
+//	http.ListenAndServe(":8080", recovery.Handler(mux, recovery.DefaultConfig()))
+func Handler(next http.Handler, cfg *RecoveryConfig) http.Handler {
+	rc := newRecoverer(cfg)
+	cfg = rc.cfg
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body := rc.captureBody(req)
+
+		defer func() {
+			if r := recover(); r != nil {
+				errorID := rc.recovered(req.Context(), req, logger.FromContext(req.Context()), req.Pattern, body, r)
+				if errorID == "" {
+					return
+				}
+				if rc.repanic {
+					panic(r)
+				}
+
+				h := w.Header()
+				h.Set("X-Recovered-From", "panic")
+				h.Set(ErrorIDHeader, errorID)
+				if cfg.ResponseJSON {
+					h.Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusInternalServerError)
+					_ = json.NewEncoder(w).Encode(map[string]string{
+						"error":    cfg.MaskErrorMessage,
+						ErrorIDKey: errorID,
+					})
+				} else {
+					http.Error(w, cfg.MaskErrorMessage, http.StatusInternalServerError)
+				}
+			}
+		}()
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// recoverer holds the configuration Middleware and Handler share.
+type recoverer struct {
+	cfg     *RecoveryConfig
+	repanic bool
+	dump    *RequestDumpConfig
+}
+
+// newRecoverer resolves the defaults of cfg and the environment.
+func newRecoverer(cfg *RecoveryConfig) *recoverer {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	if cfg.MaskErrorMessage == "" {
+		cfg.MaskErrorMessage = "internal server error"
+	}
+
+	repanic, _ := strconv.ParseBool(os.Getenv("RECOVERY_REPANIC"))
+	rc := &recoverer{cfg: cfg, repanic: repanic || cfg.Repanic}
+	if cfg.DumpRequest != nil {
+		rc.dump = cfg.DumpRequest.withDefaults()
+	}
+	return rc
+}
+
+// captureBody returns the start of the request body if requests are dumped.
+func (rc *recoverer) captureBody(req *http.Request) []byte {
+	if rc.dump == nil {
+		return nil
+	}
+	return rc.dump.captureBody(req)
+}
+
+// recovered logs, counts and reports the panic r raised while serving req
+// on route, and returns its error ID. Client aborts are only logged, at
+// warn level, and return an empty ID.
+func (rc *recoverer) recovered(ctx context.Context, req *http.Request, lg loggerIface, route string, body []byte, r any) string {
+	cfg := rc.cfg
+	if isBrokenPipe(r) {
+		if wl, ok := lg.(warnLogger); ok {
+			wl.Warn("client connection closed: %v", r)
+		} else {
+			log.Printf("client connection closed: %v", r)
+		}
+		return ""
+	}
+
+	// Log panic + optional stack, tagged with an error ID the client also
+	// gets, so support can find the stack trace
+	errorID := uuid.NewString()
+	md := reqctx.RequestMetadataFromContext(ctx)
+	var stack []byte
+	if cfg.IncludeStack || cfg.Reporter != nil {
+		stack = debug.Stack()
+	}
+	fields := map[string]interface{}{ErrorIDKey: errorID}
+	if rc.dump != nil {
+		fields[DumpKey] = rc.dump.dump(req, body)
+	}
+	logPanic(lg, fields, md, r, stack, cfg.IncludeStack)
+
+	if cfg.Metrics != nil {
+		cfg.Metrics.PanicRecovered(route, panicType(r))
+	}
+	if cfg.Reporter != nil {
+		cfg.Reporter.Report(ctx, Report{
+			ErrorID:   errorID,
+			Recovered: r,
+			Stack:     stack,
+			Request:   req,
+			Metadata:  md,
+		})
+	}
+	return errorID
+}
+
+// logPanic logs recovered with fields, such as the error ID, and stack if
+// includeStack. A *logger.Logger gets the fields next to the request IDs it
+// already carries; the standard log gets them in the message.
+func logPanic(lg loggerIface, fields map[string]interface{}, md reqctx.RequestMetadata, recovered any, stack []byte, includeStack bool) {
+	format, args := "panic recovered: %v", []interface{}{recovered}
+	if includeStack {
+		format, args = format+"\n%s", append(args, string(stack))
+	}
+
+	if l, ok := lg.(*logger.Logger); ok {
+		l.WithFields(fields).Error(format, args...)
+		return
+	}
+	if lg != nil {
+		lg.Error(format, args...)
+		return
+	}
+
+	prefix := fmt.Sprintf("[error_id=%s request_id=%s trace_id=%s", fields[ErrorIDKey], md.RequestID, md.TraceID)
+	if dump, ok := fields[DumpKey]; ok {
+		b, _ := json.Marshal(dump)
+		prefix += " " + DumpKey + "=" + string(b)
+	}
+	log.Printf(prefix+"] "+format, args...)
+}
+
+// isBrokenPipe reports whether recovered is a write error on a connection
+// the client closed.
+func isBrokenPipe(recovered any) bool {
+	err, ok := recovered.(error)
+	return ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET))
+}
+
+// panicType returns the type of recovered without the pointer star, e.g.
+// "runtime.boundsError" for an index out of range.
+func panicType(recovered any) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", recovered), "*")
+}
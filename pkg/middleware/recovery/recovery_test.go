@@ -1,8 +1,15 @@
 package recovery
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -125,6 +132,302 @@ func TestRecovery_UsesInjectedLogger(t *testing.T) {
 	}
 }
 
+// fakeReporter records the reports it receives.
+type fakeReporter struct {
+	reports []Report
+}
+
+func (r *fakeReporter) Report(_ context.Context, report Report) {
+	r.reports = append(r.reports, report)
+}
+
+func TestRecovery_Reporter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reporter := &fakeReporter{}
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("request_id", "req-1")
+		c.Set("trace_id", "trace-1")
+		c.Next()
+	})
+	r.Use(Middleware(&RecoveryConfig{Reporter: reporter}))
+	r.GET("/panic", func(c *gin.Context) {
+		panic("reported")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	r.ServeHTTP(w, req)
+
+	if len(reporter.reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(reporter.reports))
+	}
+	got := reporter.reports[0]
+	if got.Recovered != "reported" || got.Request.URL.Path != "/panic" {
+		t.Errorf("unexpected report %+v", got)
+	}
+	if got.Metadata.RequestID != "req-1" || got.Metadata.TraceID != "trace-1" {
+		t.Errorf("expected request metadata, got %+v", got.Metadata)
+	}
+	if !contains(string(got.Stack), "recovery_test.go") {
+		t.Errorf("expected the panic stack, got %q", got.Stack)
+	}
+}
+
+func TestRecovery_BrokenPipe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	l, _ := logger.New("svc", "v1", true)
+	h := &testHook{}
+	l.Entry.Logger.AddHook(h)
+	reporter := &fakeReporter{}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("logger", l)
+		c.Next()
+	})
+	r.Use(Middleware(&RecoveryConfig{ResponseJSON: true, Reporter: reporter}))
+	r.GET("/download", func(c *gin.Context) {
+		panic(&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/download", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Body.Len() != 0 || w.Header().Get("X-Recovered-From") != "" {
+		t.Errorf("expected no response written, got %q", w.Body.String())
+	}
+	if len(reporter.reports) != 0 {
+		t.Errorf("expected client aborts not reported")
+	}
+	if len(h.entries) != 1 || h.entries[0].Level != logrus.WarnLevel {
+		t.Fatalf("expected a single warn entry, got %v", h.entries)
+	}
+}
+
+func TestIsBrokenPipe(t *testing.T) {
+	tt := []struct {
+		recovered any
+		want      bool
+	}{
+		{recovered: &net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)}, want: true},
+		{recovered: fmt.Errorf("copy: %w", syscall.ECONNRESET), want: true},
+		{recovered: errors.New("broken pipe"), want: false},
+		{recovered: "boom", want: false},
+	}
+	for _, tc := range tt {
+		if got := isBrokenPipe(tc.recovered); got != tc.want {
+			t.Errorf("isBrokenPipe(%v) = %v, want %v", tc.recovered, got, tc.want)
+		}
+	}
+}
+
+func TestRecovery_ResponseBuilder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotID string
+	var gotRecovered any
+	r := gin.New()
+	r.Use(Middleware(&RecoveryConfig{
+		ResponseBuilder: func(c *gin.Context, errorID string, recovered any) (int, any) {
+			gotID, gotRecovered = errorID, recovered
+			c.Header("Content-Type", "application/problem+json")
+			return http.StatusServiceUnavailable, gin.H{"title": "Service Unavailable", "instance": errorID}
+		},
+	}))
+	r.GET("/panic", func(c *gin.Context) {
+		panic("built")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected the builder status, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("expected the builder content type, got %q", got)
+	}
+	if gotRecovered != "built" || gotID == "" || !contains(w.Body.String(), `"instance":"`+gotID+`"`) {
+		t.Errorf("unexpected body %q for error ID %q", w.Body.String(), gotID)
+	}
+}
+
+func TestRecovery_ErrorID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	l, _ := logger.New("svc", "v1", true)
+	h := &testHook{}
+	l.Entry.Logger.AddHook(h)
+	reporter := &fakeReporter{}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("logger", l.WithFields(map[string]interface{}{"request_id": "req-1"}))
+		c.Next()
+	})
+	r.Use(Middleware(&RecoveryConfig{ResponseJSON: true, Reporter: reporter}))
+	r.GET("/panic", func(c *gin.Context) {
+		panic("correlated")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	r.ServeHTTP(w, req)
+
+	errorID := w.Header().Get(ErrorIDHeader)
+	if errorID == "" {
+		t.Fatal("expected an error ID header")
+	}
+	if !contains(w.Body.String(), `"error_id":"`+errorID+`"`) {
+		t.Errorf("expected the error ID in the body, got %q", w.Body.String())
+	}
+	if len(h.entries) != 1 || h.entries[0].Data[ErrorIDKey] != errorID || h.entries[0].Data["request_id"] != "req-1" {
+		t.Errorf("expected the error and request IDs in the log entry, got %v", h.entries)
+	}
+	if len(reporter.reports) != 1 || reporter.reports[0].ErrorID != errorID {
+		t.Errorf("expected the error ID in the report")
+	}
+}
+
+// fakeMetrics records the panics counted.
+type fakeMetrics struct {
+	panics []string
+}
+
+func (m *fakeMetrics) PanicRecovered(route, panicType string) {
+	m.panics = append(m.panics, route+" "+panicType)
+}
+
+func TestRecovery_Metrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	metrics := &fakeMetrics{}
+	r := gin.New()
+	r.Use(Middleware(&RecoveryConfig{Metrics: metrics}))
+	r.GET("/users/:id", func(c *gin.Context) {
+		var ids []int
+		_ = ids[len(c.Param("id"))]
+	})
+	r.GET("/error", func(c *gin.Context) {
+		panic(errors.New("failed"))
+	})
+	r.GET("/download", func(c *gin.Context) {
+		panic(fmt.Errorf("write: %w", syscall.EPIPE))
+	})
+
+	for _, path := range []string{"/users/42", "/error", "/download"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	want := []string{"/users/:id runtime.boundsError", "/error errors.errorString"}
+	if strings.Join(metrics.panics, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, metrics.panics)
+	}
+}
+
+func TestRecovery_Repanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tt := []struct {
+		name string
+		cfg  *RecoveryConfig
+		env  string
+	}{
+		{name: "option", cfg: &RecoveryConfig{Repanic: true}},
+		{name: "environment", cfg: &RecoveryConfig{}, env: "true"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("RECOVERY_REPANIC", tc.env)
+			metrics := &fakeMetrics{}
+			tc.cfg.Metrics = metrics
+
+			r := gin.New()
+			r.Use(Middleware(tc.cfg))
+			r.GET("/panic", func(c *gin.Context) {
+				panic("loud")
+			})
+
+			defer func() {
+				if got := recover(); got != "loud" {
+					t.Errorf("expected the panic re-raised, got %v", got)
+				}
+				if len(metrics.panics) != 1 {
+					t.Errorf("expected the panic counted before re-raising")
+				}
+			}()
+			req, _ := http.NewRequest("GET", "/panic", nil)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+			t.Error("expected ServeHTTP to panic")
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	l, _ := logger.New("svc", "v1", true)
+	h := &testHook{}
+	l.Entry.Logger.AddHook(h)
+	metrics := &fakeMetrics{}
+	reporter := &fakeReporter{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("stdlib")
+	})
+	handler := Handler(mux, &RecoveryConfig{ResponseJSON: true, Metrics: metrics, Reporter: reporter})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	handler.ServeHTTP(w, req.WithContext(logger.NewContext(req.Context(), l)))
+
+	errorID := w.Header().Get(ErrorIDHeader)
+	if w.Code != http.StatusInternalServerError || errorID == "" {
+		t.Fatalf("expected a 500 with an error ID, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected JSON, got %q", got)
+	}
+	if !contains(w.Body.String(), `"error":"internal server error"`) || !contains(w.Body.String(), errorID) {
+		t.Errorf("unexpected body %q", w.Body.String())
+	}
+	if len(h.entries) != 1 || h.entries[0].Data[ErrorIDKey] != errorID {
+		t.Errorf("expected the panic logged through the context logger, got %v", h.entries)
+	}
+	if strings.Join(metrics.panics, ",") != "GET /users/{id} string" {
+		t.Errorf("expected the route pattern counted, got %v", metrics.panics)
+	}
+	if len(reporter.reports) != 1 || reporter.reports[0].Request.URL.Path != "/users/42" {
+		t.Errorf("expected the panic reported")
+	}
+}
+
+func TestHandler_PlainTextAndBrokenPipe(t *testing.T) {
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/download" {
+			panic(fmt.Errorf("write: %w", syscall.EPIPE))
+		}
+		panic("plain")
+	}), &RecoveryConfig{MaskErrorMessage: "oops"})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "oops\n" {
+		t.Errorf("expected the plain masked message, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/download", nil))
+	if w.Body.Len() != 0 || w.Header().Get(ErrorIDHeader) != "" {
+		t.Errorf("expected no response for a client abort, got %q", w.Body.String())
+	}
+}
+
 // contains is a tiny helper to avoid importing strings in multiple spots.
 func contains(s, sub string) bool {
 	return len(s) >= len(sub) && (s == sub || (len(sub) > 0 && indexOf(s, sub) >= 0))
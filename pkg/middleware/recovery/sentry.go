@@ -0,0 +1,53 @@
+package recovery
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// SentryReporter is a Reporter capturing recovered panics as Sentry events,
+// tagged with the request and trace IDs and carrying the request.
+type SentryReporter struct {
+	hub *sentry.Hub
+}
+
+// NewSentryReporter returns a SentryReporter capturing through hub, or
+// through sentry.CurrentHub() if hub is nil.
+//
+// Example:
+//
+//	_ = sentry.Init(sentry.ClientOptions{Dsn: os.Getenv("SENTRY_DSN")})
+//	r.Use(recovery.Middleware(&recovery.RecoveryConfig{
+//		IncludeStack: true,
+//		ResponseJSON: true,
+//		Reporter:     recovery.NewSentryReporter(nil),
+//	}))
+func NewSentryReporter(hub *sentry.Hub) *SentryReporter {
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	return &SentryReporter{hub: hub}
+}
+
+// Report implements Reporter. It runs in the panicking goroutine, so the
+// captured stack trace is the one of the panic.
+func (r *SentryReporter) Report(ctx context.Context, report Report) {
+	hub := r.hub.Clone()
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetLevel(sentry.LevelFatal)
+		if report.Request != nil {
+			scope.SetRequest(report.Request)
+		}
+		for k, v := range map[string]string{
+			"request_id": report.Metadata.RequestID,
+			"trace_id":   report.Metadata.TraceID,
+			"span_id":    report.Metadata.SpanID,
+		} {
+			if v != "" {
+				scope.SetTag(k, v)
+			}
+		}
+	})
+	hub.RecoverWithContext(ctx, report.Recovered)
+}
@@ -0,0 +1,65 @@
+package recovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+)
+
+// recordingTransport is a sentry.Transport keeping the events it is sent.
+type recordingTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *recordingTransport) Configure(sentry.ClientOptions)        {}
+func (t *recordingTransport) Close()                                {}
+func (t *recordingTransport) Flush(time.Duration) bool              { return true }
+func (t *recordingTransport) FlushWithContext(context.Context) bool { return true }
+
+func (t *recordingTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func TestSentryReporter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	transport := &recordingTransport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("request_id", "req-1")
+		c.Next()
+	})
+	r.Use(Middleware(&RecoveryConfig{Reporter: NewSentryReporter(hub)}))
+	r.GET("/panic", func(c *gin.Context) {
+		panic("to sentry")
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if len(transport.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.events))
+	}
+	event := transport.events[0]
+	if event.Message != "to sentry" || event.Level != sentry.LevelFatal {
+		t.Errorf("unexpected event %q at %v", event.Message, event.Level)
+	}
+	if event.Tags["request_id"] != "req-1" {
+		t.Errorf("expected the request_id tag, got %v", event.Tags)
+	}
+	if event.Request == nil || event.Request.URL != "http://example.com/panic" {
+		t.Errorf("expected the request, got %+v", event.Request)
+	}
+}
@@ -0,0 +1,204 @@
+// Package request provides helpers for reading and validating incoming HTTP
+// request bodies, including streaming decoding of large JSON payloads.
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sentinel errors returned by the streaming decoder. Use errors.Is to match.
+var (
+	ErrNotArray      = errors.New("request body is not a JSON array")
+	ErrTooManyItems  = errors.New("request body contains too many items")
+	ErrItemTooLarge  = errors.New("request body item exceeds maximum size")
+	ErrBodyTooLarge  = errors.New("request body exceeds maximum size")
+	ErrTrailingInput = errors.New("unexpected data after JSON array")
+)
+
+// StreamLimits bounds the work done by DecodeJSONArray. Zero values disable
+// the corresponding limit.
+type StreamLimits struct {
+	// MaxItems is the maximum number of array elements accepted.
+	MaxItems int
+
+	// MaxItemBytes is the maximum encoded size of a single element. It is
+	// enforced while reading, so an oversized element is never buffered
+	// whole.
+	MaxItemBytes int
+
+	// MaxBodyBytes is the maximum size of the whole body.
+	MaxBodyBytes int64
+
+	// DisallowUnknownFields rejects elements containing fields that are not
+	// present in the destination type.
+	DisallowUnknownFields bool
+}
+
+// DecodeJSONArray reads a top-level JSON array from r one element at a time,
+// decoding each into a T and passing it to fn together with its index. Only a
+// single element is held in memory at once, so arbitrarily large imports can
+// be validated incrementally. Decoding stops at the first error returned by
+// fn, which is returned unchanged.
+//
+// It returns the number of elements successfully handed to fn.
+//
+// Example:
+//
+//	n, err := request.DecodeJSONArray(r.Body, request.StreamLimits{MaxItems: 10000},
+//	    func(i int, u User) error { return store.Insert(ctx, u) })
+func DecodeJSONArray[T any](r io.Reader, limits StreamLimits, fn func(index int, item T) error) (int, error) {
+	if limits.MaxBodyBytes > 0 {
+		r = &limitedReader{r: r, remaining: limits.MaxBodyBytes}
+	}
+	items := &itemReader{r: r, unlimited: true}
+	if limits.MaxItemBytes > 0 {
+		r = items
+	}
+
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, wrapDecodeErr(err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return 0, ErrNotArray
+	}
+
+	count := 0
+	for {
+		// The decoder buffers what it reads, so bounding the reads per
+		// element bounds the memory an oversized element takes.
+		items.limit(int64(limits.MaxItemBytes) + itemReadSlack)
+		if !dec.More() {
+			break
+		}
+		if limits.MaxItems > 0 && count >= limits.MaxItems {
+			return count, fmt.Errorf("%w: limit is %d", ErrTooManyItems, limits.MaxItems)
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, ErrItemTooLarge) {
+				return count, fmt.Errorf("%w: item %d exceeds %d bytes", ErrItemTooLarge, count, limits.MaxItemBytes)
+			}
+			return count, wrapDecodeErr(err)
+		}
+		if limits.MaxItemBytes > 0 && len(raw) > limits.MaxItemBytes {
+			return count, fmt.Errorf("%w: item %d is %d bytes", ErrItemTooLarge, count, len(raw))
+		}
+
+		var item T
+		if err := unmarshalItem(raw, &item, limits.DisallowUnknownFields); err != nil {
+			return count, fmt.Errorf("invalid item %d: %w", count, err)
+		}
+		if err := fn(count, item); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	// Consume the closing bracket and make sure nothing follows it.
+	items.limit(-1)
+	if _, err := dec.Token(); err != nil {
+		return count, wrapDecodeErr(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return count, ErrTrailingInput
+	}
+	return count, nil
+}
+
+// BindJSONArray streams the body of a Gin request through DecodeJSONArray.
+// When MaxBodyBytes is set, the body is also wrapped in http.MaxBytesReader
+// so the server stops reading oversized uploads.
+func BindJSONArray[T any](c *gin.Context, limits StreamLimits, fn func(index int, item T) error) (int, error) {
+	body := c.Request.Body
+	if limits.MaxBodyBytes > 0 {
+		body = http.MaxBytesReader(c.Writer, body, limits.MaxBodyBytes)
+	}
+	return DecodeJSONArray(body, limits, fn)
+}
+
+func unmarshalItem(raw json.RawMessage, out any, strict bool) error {
+	if !strict {
+		return json.Unmarshal(raw, out)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	return dec.Decode(out)
+}
+
+func wrapDecodeErr(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.Is(err, ErrBodyTooLarge) || errors.As(err, &maxErr) {
+		return ErrBodyTooLarge
+	}
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("invalid JSON: %w", err)
+}
+
+// itemReadSlack is read past MaxItemBytes for the separators and
+// whitespace around an element, and the decoder's read-ahead.
+const itemReadSlack = 512
+
+// itemReader fails with ErrItemTooLarge once more than its limit was read
+// since the last call to limit.
+type itemReader struct {
+	r         io.Reader
+	remaining int64
+	unlimited bool
+}
+
+// limit allows n more bytes to be read, or any number if n is negative.
+func (l *itemReader) limit(n int64) {
+	l.remaining, l.unlimited = n, n < 0
+}
+
+func (l *itemReader) Read(p []byte) (int, error) {
+	if l.unlimited {
+		return l.r.Read(p)
+	}
+	if l.remaining <= 0 {
+		return 0, ErrItemTooLarge
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// limitedReader behaves like io.LimitReader but reports ErrBodyTooLarge
+// instead of a silent EOF once the limit is exceeded.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Probe for more data so bodies of exactly the limit still succeed.
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, ErrBodyTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
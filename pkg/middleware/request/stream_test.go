@@ -0,0 +1,157 @@
+package request
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func collect(t *testing.T, body string, limits StreamLimits) ([]item, error) {
+	t.Helper()
+	var got []item
+	_, err := DecodeJSONArray(strings.NewReader(body), limits, func(_ int, it item) error {
+		got = append(got, it)
+		return nil
+	})
+	return got, err
+}
+
+// --- DecodeJSONArray() tests ---
+
+func TestDecodeJSONArray_Success(t *testing.T) {
+	got, err := collect(t, `[{"id":1,"name":"a"},{"id":2,"name":"b"}]`, StreamLimits{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[1].Name != "b" {
+		t.Errorf("unexpected items: %+v", got)
+	}
+}
+
+func TestDecodeJSONArray_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		limits StreamLimits
+		want   error
+	}{
+		{"not an array", `{"id":1}`, StreamLimits{}, ErrNotArray},
+		{"too many items", `[{"id":1},{"id":2},{"id":3}]`, StreamLimits{MaxItems: 2}, ErrTooManyItems},
+		{"item too large", `[{"id":1,"name":"` + strings.Repeat("x", 64) + `"}]`, StreamLimits{MaxItemBytes: 32}, ErrItemTooLarge},
+		{"body too large", `[{"id":1},{"id":2},{"id":3}]`, StreamLimits{MaxBodyBytes: 12}, ErrBodyTooLarge},
+		{"trailing input", `[{"id":1}] extra`, StreamLimits{}, ErrTrailingInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := collect(t, tt.body, tt.limits)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestDecodeJSONArray_ItemLimitBoundsReads(t *testing.T) {
+	body := `[{"id":1},{"id":2,"name":"` + strings.Repeat("x", 1<<20) + `"}]`
+	r := &countingReader{r: strings.NewReader(body)}
+	n, err := DecodeJSONArray(r, StreamLimits{MaxItemBytes: 64}, func(int, item) error { return nil })
+	if !errors.Is(err, ErrItemTooLarge) || n != 1 {
+		t.Fatalf("expected ErrItemTooLarge after one item, got %v (%d items)", err, n)
+	}
+	if r.n > 4096 {
+		t.Errorf("expected reading to stop near the item limit, read %d bytes", r.n)
+	}
+}
+
+func TestDecodeJSONArray_ManyItemsWithinLimit(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("[")
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(`{"id":1,"name":"abcdefghij"}`)
+	}
+	b.WriteString("]")
+	got, err := collect(t, b.String(), StreamLimits{MaxItemBytes: 32})
+	if err != nil || len(got) != 1000 {
+		t.Fatalf("expected every item to fit, got %v (%d items)", err, len(got))
+	}
+}
+
+func TestDecodeJSONArray_ExactBodyLimit(t *testing.T) {
+	body := `[{"id":1}]`
+	got, err := collect(t, body, StreamLimits{MaxBodyBytes: int64(len(body))})
+	if err != nil || len(got) != 1 {
+		t.Fatalf("expected body at exact limit to succeed, got %v (%d items)", err, len(got))
+	}
+}
+
+func TestDecodeJSONArray_UnknownFields(t *testing.T) {
+	_, err := collect(t, `[{"id":1,"extra":true}]`, StreamLimits{DisallowUnknownFields: true})
+	if err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
+
+func TestDecodeJSONArray_CallbackErrorStops(t *testing.T) {
+	stop := errors.New("stop")
+	n, err := DecodeJSONArray(strings.NewReader(`[1,2,3]`), StreamLimits{}, func(i int, v int) error {
+		if v == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected callback error, got %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 processed item, got %d", n)
+	}
+}
+
+// --- BindJSONArray() tests ---
+
+func TestBindJSONArray_Gin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/import", func(c *gin.Context) {
+		n, err := BindJSONArray(c, StreamLimits{MaxItems: 10}, func(_ int, it item) error { return nil })
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"count": n})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/import", strings.NewReader(`[{"id":1},{"id":2}]`))
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"count":2`) {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,81 @@
+package response
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+)
+
+// CursorPaginatedResponse defines the schema for cursor-paginated API
+// results. Unlike PaginatedResponse it needs no total count, so it suits
+// large or changing datasets where counting and offsets are costly. An
+// empty cursor means there is no page in that direction.
+type CursorPaginatedResponse struct {
+	Results    interface{} `json:"results"`
+	NextCursor string      `json:"next_cursor"`
+	PrevCursor string      `json:"prev_cursor"`
+	Limit      int         `json:"limit"`
+}
+
+// NewCursorPaginatedResponse creates a cursor-paginated JSON response
+// envelope.
+//
+// Example:
+//
+//	next, _ := response.EncodeCursor(userCursor{ID: users[len(users)-1].ID})
+//	c.JSON(http.StatusOK, response.NewCursorPaginatedResponse(http.StatusOK, "ok", limit, next, "", users))
+func NewCursorPaginatedResponse(status int, message string, limit int, next, prev string, results interface{}) *Response {
+	return &Response{
+		Status:  status,
+		Message: message,
+		Content: &CursorPaginatedResponse{
+			Results:    results,
+			NextCursor: next,
+			PrevCursor: prev,
+			Limit:      limit,
+		},
+	}
+}
+
+// EncodeCursor returns the opaque cursor of v, typically a struct holding
+// the sort keys of the last row of a page. v is JSON encoded, so it needs
+// exported or tagged fields.
+//
+// Cursors are URL-safe base64 and not signed: clients can decode and
+// forge them, so treat decoded values as untrusted input.
+func EncodeCursor(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor decodes the cursor made by EncodeCursor into v. Malformed
+// cursors return an errcode.InvalidArgument error, which WriteError turns
+// into a 400.
+//
+// Example:
+//
+//	var cur userCursor
+//	if after := c.Query("cursor"); after != "" {
+//		if err := response.DecodeCursor(after, &cur); err != nil {
+//			response.WriteError(c, err)
+//			return
+//		}
+//	}
+func DecodeCursor(cursor string, v interface{}) error {
+	if cursor == "" {
+		return errcode.Wrap(errors.New("empty cursor"), errcode.InvalidArgument, "invalid cursor")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return errcode.Wrap(err, errcode.InvalidArgument, "invalid cursor")
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return errcode.Wrap(err, errcode.InvalidArgument, "invalid cursor")
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+)
+
+type testCursor struct {
+	ID        int    `json:"id"`
+	CreatedAt string `json:"created_at"`
+}
+
+func TestNewCursorPaginatedResponse(t *testing.T) {
+	r := NewCursorPaginatedResponse(200, "ok", 2, "n", "p", []int{1, 2})
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"status":200,"message":"ok","content":{"results":[1,2],"next_cursor":"n","prev_cursor":"p","limit":2}}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	in := testCursor{ID: 42, CreatedAt: "2024-01-02T03:04:05Z"}
+	cursor, err := EncodeCursor(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(cursor, "+/=") {
+		t.Errorf("cursor %q is not URL-safe", cursor)
+	}
+
+	var out testCursor
+	if err := DecodeCursor(cursor, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	notJSON, _ := EncodeCursor("x")
+	for _, cursor := range []string{"", "not base64!", notJSON} {
+		var out testCursor
+		err := DecodeCursor(cursor, &out)
+		if err == nil {
+			t.Errorf("DecodeCursor(%q): expected an error", cursor)
+			continue
+		}
+		if errcode.HTTPStatus(err) != http.StatusBadRequest {
+			t.Errorf("DecodeCursor(%q): expected a 400 error, got %v", cursor, err)
+		}
+	}
+}
+
+func TestWriteNegotiatedCSVCursorPaginated(t *testing.T) {
+	resp := NewCursorPaginatedResponse(http.StatusOK, "ok", 1, "next", "", []exportRow{{ID: 1, Name: "a"}})
+	rec := writeNegotiated(t, "text/csv", resp)
+	if got := rec.Body.String(); got != "id,name\n1,a\n" {
+		t.Errorf("unexpected body %q", got)
+	}
+}
@@ -0,0 +1,194 @@
+package response
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/etag"
+)
+
+// File describes a file sent by ServeFile or StreamFile.
+type File struct {
+	// Name is the file name offered to the client, e.g. "report.csv".
+	Name string
+
+	// ContentType defaults to the type of the Name extension, else to the
+	// type sniffed from the first 512 bytes of the content.
+	ContentType string
+
+	// ModTime is sent as Last-Modified when set.
+	ModTime time.Time
+
+	// ETag is the entity tag of the content. ServeFile computes a strong
+	// one from the content when empty, which reads it twice; set it from
+	// storage metadata for large files.
+	ETag string
+
+	// Inline asks browsers to display the file rather than download it.
+	Inline bool
+}
+
+// ContentDisposition returns the Content-Disposition header of a download
+// named name: "attachment", or "inline" when inline is set. Names outside
+// printable ASCII are sent both as an ASCII approximation and in the
+// RFC 5987 "filename*" form that browsers prefer.
+func ContentDisposition(name string, inline bool) string {
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+	if name == "" {
+		return disposition
+	}
+
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r >= 0x7f || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, name)
+	disposition += `; filename="` + fallback + `"`
+	if fallback != name {
+		disposition += "; filename*=UTF-8''" + encodeRFC5987(name)
+	}
+	return disposition
+}
+
+// encodeRFC5987 percent-encodes the bytes of s outside the RFC 5987
+// attr-char set.
+func encodeRFC5987(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// FileETag returns a strong entity tag of content, read from its current
+// position to the end, and seeks content back to that position.
+func FileETag(content io.ReadSeeker) (string, error) {
+	start, err := content.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(start, io.SeekStart); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`, nil
+}
+
+// ServeFile sends content as the file f with http.ServeContent, which
+// answers range requests with 206 and conditional requests (If-None-Match,
+// If-Modified-Since, If-Range) with 304 or 412.
+//
+// Example:
+//
+//	func downloadReport(w http.ResponseWriter, r *http.Request) {
+//		report := bytes.NewReader(csvBytes)
+//		_ = response.ServeFile(w, r, response.File{Name: "report.csv", ModTime: generatedAt}, report)
+//	}
+func ServeFile(w http.ResponseWriter, r *http.Request, f File, content io.ReadSeeker) error {
+	if f.ETag == "" {
+		tag, err := FileETag(content)
+		if err != nil {
+			return fmt.Errorf("failed to compute file ETag: %w", err)
+		}
+		f.ETag = tag
+	}
+	h := w.Header()
+	h.Set("Content-Disposition", ContentDisposition(f.Name, f.Inline))
+	h.Set("ETag", f.ETag)
+	h.Set("X-Content-Type-Options", "nosniff")
+	if f.ContentType != "" {
+		h.Set("Content-Type", f.ContentType)
+	}
+	http.ServeContent(w, r, f.Name, f.ModTime, content)
+	return nil
+}
+
+// StreamFile copies content to w as the file f, for content that cannot
+// seek, such as a report generated on the fly or an object storage stream.
+// Range requests are not supported, but a request matching f.ETag through
+// If-None-Match gets a 304 without content being read.
+func StreamFile(w http.ResponseWriter, r *http.Request, f File, content io.Reader) error {
+	h := w.Header()
+	h.Set("Content-Disposition", ContentDisposition(f.Name, f.Inline))
+	if !f.ModTime.IsZero() {
+		h.Set("Last-Modified", f.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if f.ETag != "" {
+		h.Set("ETag", f.ETag)
+		if etag.Matches(r.Header.Get("If-None-Match"), f.ETag) {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(f.Name))
+	}
+	if contentType == "" {
+		br := bufio.NewReaderSize(content, 512)
+		head, _ := br.Peek(512)
+		contentType = http.DetectContentType(head)
+		content = br
+	}
+	h.Set("Content-Type", contentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return nil
+	}
+	_, err := io.Copy(w, content)
+	return err
+}
+
+// Download is the Gin version of ServeFile. Errors are added to c.Errors
+// and answered with a 500.
+func Download(c *gin.Context, f File, content io.ReadSeeker) {
+	if err := ServeFile(c.Writer, c.Request, f, content); err != nil {
+		_ = c.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+// DownloadStream is the Gin version of StreamFile. Errors, which usually
+// happen once the response has started, are added to c.Errors.
+//
+// Example:
+//
+//	r.GET("/exports/:id", func(c *gin.Context) {
+//		obj, err := store.Open(c, c.Param("id"))
+//		if err != nil {
+//			response.WriteError(c, err)
+//			return
+//		}
+//		defer obj.Close()
+//		response.DownloadStream(c, response.File{Name: obj.Name, ETag: obj.ETag}, obj)
+//	})
+func DownloadStream(c *gin.Context, f File, content io.Reader) {
+	if err := StreamFile(c.Writer, c.Request, f, content); err != nil {
+		_ = c.Error(err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,177 @@
+package response
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestContentDisposition(t *testing.T) {
+	cases := []struct {
+		name   string
+		inline bool
+		want   string
+	}{
+		{"", false, "attachment"},
+		{"report.csv", false, `attachment; filename="report.csv"`},
+		{"chart.png", true, `inline; filename="chart.png"`},
+		{`a"b.txt`, false, `attachment; filename="a_b.txt"; filename*=UTF-8''a%22b.txt`},
+		{"résumé 2024.pdf", false, `attachment; filename="r_sum_ 2024.pdf"; filename*=UTF-8''r%C3%A9sum%C3%A9%202024.pdf`},
+	}
+	for _, tc := range cases {
+		if got := ContentDisposition(tc.name, tc.inline); got != tc.want {
+			t.Errorf("ContentDisposition(%q, %v) = %q, want %q", tc.name, tc.inline, got, tc.want)
+		}
+	}
+}
+
+func TestFileETag(t *testing.T) {
+	r := strings.NewReader("hello world")
+	_, _ = r.Seek(6, io.SeekStart)
+	tag, err := FileETag(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := FileETag(strings.NewReader("world"))
+	if tag != other || !strings.HasPrefix(tag, `"`) {
+		t.Errorf("expected the tag of the remaining content, got %q and %q", tag, other)
+	}
+	if rest, _ := io.ReadAll(r); string(rest) != "world" {
+		t.Errorf("expected the reader to be restored, read %q", rest)
+	}
+}
+
+func serveFile(t *testing.T, f File, content string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/download", nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	if err := ServeFile(rec, req, f, strings.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestServeFile(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rec := serveFile(t, File{Name: "report.csv", ModTime: modTime}, "id,name\n1,a\n", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	h := rec.Header()
+	if got := h.Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("unexpected Content-Type %q", got)
+	}
+	if got := h.Get("Content-Disposition"); got != `attachment; filename="report.csv"` {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+	if got := h.Get("Last-Modified"); got != "Tue, 02 Jan 2024 03:04:05 GMT" {
+		t.Errorf("unexpected Last-Modified %q", got)
+	}
+	if h.Get("ETag") == "" || h.Get("Accept-Ranges") != "bytes" {
+		t.Errorf("expected an ETag and range support, got %v", h)
+	}
+	if rec.Body.String() != "id,name\n1,a\n" {
+		t.Errorf("unexpected body %q", rec.Body.String())
+	}
+
+	rec = serveFile(t, File{Name: "report.csv"}, "id,name\n1,a\n", http.Header{"If-None-Match": {h.Get("ETag")}})
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for a matching ETag, got %d", rec.Code)
+	}
+}
+
+func TestServeFileRange(t *testing.T) {
+	rec := serveFile(t, File{Name: "data.bin", ETag: `"v1"`}, "0123456789", http.Header{"Range": {"bytes=2-5"}})
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("expected 206, got %d", rec.Code)
+	}
+	if rec.Body.String() != "2345" || rec.Header().Get("Content-Range") != "bytes 2-5/10" {
+		t.Errorf("unexpected range %q %q", rec.Header().Get("Content-Range"), rec.Body.String())
+	}
+
+	rec = serveFile(t, File{Name: "data.bin", ETag: `"v1"`}, "0123456789", http.Header{"Range": {"bytes=2-5"}, "If-Range": {`"v0"`}})
+	if rec.Code != http.StatusOK || rec.Body.String() != "0123456789" {
+		t.Errorf("expected the full file for a stale If-Range, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServeFileSniffsContentType(t *testing.T) {
+	rec := serveFile(t, File{Name: "download"}, "%PDF-1.7 ...", nil)
+	if got := rec.Header().Get("Content-Type"); got != "application/pdf" {
+		t.Errorf("unexpected Content-Type %q", got)
+	}
+}
+
+func TestStreamFile(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/download", nil)
+	rec := httptest.NewRecorder()
+	err := StreamFile(rec, req, File{Name: "export", ETag: `"v1"`, Inline: true}, io.MultiReader(strings.NewReader("%PDF-"), strings.NewReader("1.7")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := rec.Header()
+	if got := h.Get("Content-Type"); got != "application/pdf" {
+		t.Errorf("unexpected Content-Type %q", got)
+	}
+	if got := h.Get("Content-Disposition"); got != `inline; filename="export"` {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+	if h.Get("ETag") != `"v1"` || h.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("unexpected headers %v", h)
+	}
+	if rec.Body.String() != "%PDF-1.7" {
+		t.Errorf("unexpected body %q", rec.Body.String())
+	}
+}
+
+// unreadable fails the test if read.
+type unreadable struct{ t *testing.T }
+
+func (r unreadable) Read([]byte) (int, error) {
+	r.t.Error("content read for a 304")
+	return 0, io.EOF
+}
+
+func TestStreamFileNotModified(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/download", nil)
+	req.Header.Set("If-None-Match", `W/"v1", "v2"`)
+	rec := httptest.NewRecorder()
+	if err := StreamFile(rec, req, File{Name: "a.csv", ETag: `"v1"`}, unreadable{t}); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", rec.Code)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/file", func(c *gin.Context) {
+		Download(c, File{Name: "a.txt"}, bytes.NewReader([]byte("hello")))
+	})
+	r.GET("/stream", func(c *gin.Context) {
+		DownloadStream(c, File{Name: "a.json"}, strings.NewReader(`{}`))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/file", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello" {
+		t.Errorf("unexpected file response %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	if got := rec.Header().Get("Content-Type"); got != "application/json" || rec.Body.String() != "{}" {
+		t.Errorf("unexpected stream response %q %q", got, rec.Body.String())
+	}
+}
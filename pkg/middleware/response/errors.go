@@ -0,0 +1,122 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+	reqctx "github.com/ranorsolutions/http-common-go/pkg/middleware/context"
+)
+
+// ErrorResponse defines the standard JSON error body.
+type ErrorResponse struct {
+	// Code is the machine-readable errcode code, e.g. "not_found".
+	Code string `json:"code"`
+
+	// Message is a human-readable description safe to show to clients.
+	Message string `json:"message"`
+
+	// Details are additional human-readable hints, see WithDetails.
+	Details []string `json:"details,omitempty"`
+
+	// Fields lists the invalid request fields, see Invalid.
+	Fields []FieldError `json:"fields,omitempty"`
+
+	// RequestID correlates the error with the server logs.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// FieldError describes an invalid request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// FieldErrors is an error listing invalid request fields.
+type FieldErrors []FieldError
+
+// Error implements error.
+func (e FieldErrors) Error() string {
+	if len(e) == 0 {
+		return "invalid fields"
+	}
+	msg := e[0].Field + ": " + e[0].Message
+	if len(e) > 1 {
+		msg += " (and more)"
+	}
+	return msg
+}
+
+// Invalid returns an errcode.InvalidArgument error carrying fields, written
+// by WriteError as the fields of the ErrorResponse.
+//
+// Example:
+//
+//	if req.Email == "" {
+//		response.WriteError(c, response.Invalid("invalid user",
+//			response.FieldError{Field: "email", Code: "required", Message: "email is required"}))
+//		return
+//	}
+func Invalid(message string, fields ...FieldError) error {
+	return errcode.Wrap(FieldErrors(fields), errcode.InvalidArgument, message)
+}
+
+// detailsError adds ErrorResponse details to an error.
+type detailsError struct {
+	err     error
+	details []string
+}
+
+func (e *detailsError) Error() string { return e.err.Error() }
+func (e *detailsError) Unwrap() error { return e.err }
+
+// WithDetails returns err annotated with details, written by WriteError as
+// the details of the ErrorResponse. The code of err is unchanged.
+func WithDetails(err error, details ...string) error {
+	return &detailsError{err: err, details: details}
+}
+
+// NewErrorResponse builds the ErrorResponse of err and its HTTP status,
+// both derived from the errcode code of err (errcode.Internal for errors
+// without one). Messages of server errors are replaced by the status text,
+// so wrapped internal errors never reach clients.
+func NewErrorResponse(err error, requestID string) (int, *ErrorResponse) {
+	code := errcode.Of(err)
+	resp := &ErrorResponse{Code: code.Code, RequestID: requestID}
+
+	var appErr *errcode.AppError
+	if code.Category != errcode.CategoryServer && errors.As(err, &appErr) {
+		resp.Message = appErr.Message
+	}
+	if resp.Message == "" {
+		resp.Message = http.StatusText(code.HTTPStatus)
+	}
+
+	var details *detailsError
+	if errors.As(err, &details) {
+		resp.Details = details.details
+	}
+	var fields FieldErrors
+	if errors.As(err, &fields) {
+		resp.Fields = fields
+	}
+	return code.HTTPStatus, resp
+}
+
+// WriteError aborts c with the ErrorResponse of err, tagged with the
+// request ID set by the logger middleware. err is also added to c.Errors.
+//
+// Example:
+//
+//	user, err := svc.Get(ctx, id)
+//	if err != nil {
+//		response.WriteError(c, err) // e.g. 404 {"code":"not_found","message":"user 42 not found"}
+//		return
+//	}
+func WriteError(c *gin.Context, err error) {
+	status, resp := NewErrorResponse(err, reqctx.RequestMetadataFromContext(c).RequestID)
+	_ = c.Error(err)
+	c.AbortWithStatusJSON(status, resp)
+}
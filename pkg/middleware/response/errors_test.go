@@ -0,0 +1,80 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranorsolutions/http-common-go/pkg/errcode"
+)
+
+func TestNewErrorResponse(t *testing.T) {
+	tt := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{name: "app error", err: errcode.New(errcode.NotFound, "user %d not found", 42), status: 404, code: "not_found", message: "user 42 not found"},
+		{name: "wrapped", err: fmt.Errorf("load: %w", errcode.New(errcode.Conflict, "email taken")), status: 409, code: "conflict", message: "email taken"},
+		{name: "plain error", err: errors.New("pq: connection refused"), status: 500, code: "internal", message: "Internal Server Error"},
+		{name: "server app error", err: errcode.Wrap(errors.New("dial tcp 10.0.0.1"), errcode.Unimplemented, ""), status: 501, code: "unimplemented", message: "Not Implemented"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			status, resp := NewErrorResponse(tc.err, "req-1")
+			if status != tc.status || resp.Code != tc.code || resp.Message != tc.message || resp.RequestID != "req-1" {
+				t.Errorf("unexpected %d %+v", status, resp)
+			}
+		})
+	}
+}
+
+func TestNewErrorResponse_FieldsAndDetails(t *testing.T) {
+	fields := []FieldError{{Field: "email", Code: "required", Message: "email is required"}}
+	err := WithDetails(Invalid("invalid user", fields...), "see https://docs.example.com/users")
+
+	status, resp := NewErrorResponse(err, "")
+	if status != http.StatusBadRequest || resp.Code != "invalid_argument" || resp.Message != "invalid user" {
+		t.Errorf("unexpected %d %+v", status, resp)
+	}
+	if !reflect.DeepEqual(resp.Fields, fields) {
+		t.Errorf("expected %v, got %v", fields, resp.Fields)
+	}
+	if len(resp.Details) != 1 || resp.Details[0] != "see https://docs.example.com/users" {
+		t.Errorf("unexpected details %v", resp.Details)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id", func(c *gin.Context) {
+		c.Set("request_id", "req-42")
+		WriteError(c, errcode.New(errcode.NotFound, "user not found"))
+		if len(c.Errors) != 1 {
+			t.Errorf("expected the error added to c.Errors")
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := map[string]interface{}{"code": "not_found", "message": "user not found", "request_id": "req-42"}
+	if !reflect.DeepEqual(resp, want) {
+		t.Errorf("expected %v, got %v", want, resp)
+	}
+}
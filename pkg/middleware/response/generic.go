@@ -0,0 +1,48 @@
+package response
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Respond writes data in the JSON envelope with status and its lowercase
+// status text as message, e.g. "not found". Prefer the named helpers below,
+// which pick the status for the outcome.
+func Respond[T any](c *gin.Context, status int, data T) {
+	c.JSON(status, NewResponse(status, strings.ToLower(http.StatusText(status)), data))
+}
+
+// Ok writes data in a 200 envelope with message "ok".
+//
+// Example:
+//
+//	r.GET("/users/:id", func(c *gin.Context) {
+//		user, err := svc.Get(c, c.Param("id"))
+//		if err != nil {
+//			response.WriteError(c, err)
+//			return
+//		}
+//		response.Ok(c, user) // {"status":200,"message":"ok","content":{...}}
+//	})
+func Ok[T any](c *gin.Context, data T) {
+	Respond(c, http.StatusOK, data)
+}
+
+// Created writes data, typically the new resource, in a 201 envelope with
+// message "created".
+func Created[T any](c *gin.Context, data T) {
+	Respond(c, http.StatusCreated, data)
+}
+
+// Accepted writes data, typically a job reference, in a 202 envelope with
+// message "accepted", for work completed asynchronously.
+func Accepted[T any](c *gin.Context, data T) {
+	Respond(c, http.StatusAccepted, data)
+}
+
+// NoContent writes a 204 without a body, as the status requires.
+func NoContent(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,49 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveGeneric(t *testing.T, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", handler)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+func TestGenericConstructors(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler gin.HandlerFunc
+		status  int
+		body    string
+	}{
+		{"ok", func(c *gin.Context) { Ok(c, exportRow{ID: 1, Name: "a"}) }, 200,
+			`{"status":200,"message":"ok","content":{"id":1,"name":"a"}}`},
+		{"created", func(c *gin.Context) { Created(c, map[string]int{"id": 2}) }, 201,
+			`{"status":201,"message":"created","content":{"id":2}}`},
+		{"accepted", func(c *gin.Context) { Accepted(c, "job-1") }, 202,
+			`{"status":202,"message":"accepted","content":"job-1"}`},
+		{"respond", func(c *gin.Context) { Respond[*exportRow](c, http.StatusNotFound, nil) }, 404,
+			`{"status":404,"message":"not found","content":null}`},
+		{"no content", NoContent, 204, ``},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serveGeneric(t, tc.handler)
+			if rec.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, rec.Code)
+			}
+			if got := rec.Body.String(); got != tc.body {
+				t.Errorf("expected body %q, got %q", tc.body, got)
+			}
+		})
+	}
+}
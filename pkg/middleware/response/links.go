@@ -0,0 +1,161 @@
+package response
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Link is a hypermedia link of a Response.
+type Link struct {
+	// Href is the absolute URL of the linked resource.
+	Href string `json:"href"`
+
+	// Method is the HTTP method to use, GET when empty.
+	Method string `json:"method,omitempty"`
+}
+
+// WithLink adds the link rel to r and returns r, so calls chain.
+//
+// Example:
+//
+//	resp := response.NewResponse(http.StatusOK, "ok", order).
+//		WithLink("self", response.SelfLink(c.Request)).
+//		WithLink("cancel", response.NewLink(c.Request, http.MethodPost, "cancel"))
+func (r *Response) WithLink(rel string, link Link) *Response {
+	if r.Links == nil {
+		r.Links = map[string]Link{}
+	}
+	r.Links[rel] = link
+	return r
+}
+
+// NewLink returns a link to ref, resolved against the URL of req like an
+// HTML link: "/users/42" replaces the path, "cancel" replaces the last
+// path segment. GET links leave method empty.
+func NewLink(req *http.Request, method, ref string) Link {
+	href := RequestURL(req)
+	if u, err := url.Parse(ref); err == nil {
+		href = href.ResolveReference(u)
+	}
+	if method == http.MethodGet {
+		method = ""
+	}
+	return Link{Href: href.String(), Method: method}
+}
+
+// SelfLink returns the link to the requested URL, query included.
+func SelfLink(req *http.Request) Link {
+	link := Link{Href: RequestURL(req).String()}
+	if req.Method != http.MethodGet {
+		link.Method = req.Method
+	}
+	return link
+}
+
+// QueryLink returns the GET link to the requested URL with the query
+// parameter key set to value, e.g. the next page of a list. An empty value
+// removes key.
+//
+// Example:
+//
+//	if next != "" {
+//		resp.WithLink("next", response.QueryLink(c.Request, "cursor", next))
+//	}
+func QueryLink(req *http.Request, key, value string) Link {
+	u := RequestURL(req)
+	q := u.Query()
+	if value == "" {
+		q.Del(key)
+	} else {
+		q.Set(key, value)
+	}
+	u.RawQuery = q.Encode()
+	return Link{Href: u.String()}
+}
+
+// trustedProxyKey marks requests received from a trusted proxy.
+type trustedProxyKey struct{}
+
+// TrustProxies returns middleware that lets RequestURL honor the
+// X-Forwarded-Proto and X-Forwarded-Host headers of requests whose peer
+// address is in one of proxies. Without it, or for other peers, the
+// headers are ignored, since any client can send them.
+//
+// Example:
+//
+//	r.Use(response.TrustProxies(netip.MustParsePrefix("10.0.0.0/8")))
+func TrustProxies(proxies ...netip.Prefix) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if trustedPeer(c.Request.RemoteAddr, proxies) {
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), trustedProxyKey{}, true))
+		}
+		c.Next()
+	}
+}
+
+// trustedPeer reports whether the address remoteAddr is in proxies.
+func trustedPeer(remoteAddr string, proxies []netip.Prefix) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestURL returns the absolute URL of req as seen by the client. For
+// requests that TrustProxies accepted, the scheme and host come from the
+// X-Forwarded-Proto and X-Forwarded-Host headers when present, so services
+// behind a proxy link to the public address.
+func RequestURL(req *http.Request) *url.URL {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	host := req.Host
+	if trusted, _ := req.Context().Value(trustedProxyKey{}).(bool); trusted {
+		if proto := strings.ToLower(lastHeaderValue(req, "X-Forwarded-Proto")); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if fwd := lastHeaderValue(req, "X-Forwarded-Host"); fwd != "" {
+			host = fwd
+		}
+	}
+	return &url.URL{
+		Scheme:   scheme,
+		Host:     host,
+		Path:     req.URL.Path,
+		RawPath:  req.URL.RawPath,
+		RawQuery: req.URL.RawQuery,
+	}
+}
+
+// lastHeaderValue returns the last entry of a comma-separated header, the
+// one set by the trusted proxy in front of the service. Earlier entries
+// were appended by hops further away, or sent by the client itself.
+func lastHeaderValue(req *http.Request, name string) string {
+	values := req.Header.Values(name)
+	if len(values) == 0 {
+		return ""
+	}
+	v := values[len(values)-1]
+	if i := strings.LastIndexByte(v, ','); i >= 0 {
+		v = v[i+1:]
+	}
+	return strings.TrimSpace(v)
+}
@@ -0,0 +1,105 @@
+package response
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResponseOmitsEmptyLinks(t *testing.T) {
+	b, _ := json.Marshal(NewResponse(200, "ok", nil))
+	if string(b) != `{"status":200,"message":"ok","content":null}` {
+		t.Errorf("unexpected body %s", b)
+	}
+}
+
+func TestWithLink(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://api.example.com/orders/42?expand=items", nil)
+	resp := NewResponse(200, "ok", nil).
+		WithLink("self", SelfLink(req)).
+		WithLink("cancel", NewLink(req, http.MethodPost, "42/cancel")).
+		WithLink("customer", NewLink(req, http.MethodGet, "/customers/7"))
+
+	b, err := json.Marshal(resp.Links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"cancel":{"href":"http://api.example.com/orders/42/cancel","method":"POST"},` +
+		`"customer":{"href":"http://api.example.com/customers/7"},` +
+		`"self":{"href":"http://api.example.com/orders/42?expand=items"}}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestSelfLinkMethod(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/orders/42", nil)
+	if link := SelfLink(req); link.Method != http.MethodPut || link.Href != "http://example.com/orders/42" {
+		t.Errorf("unexpected link %+v", link)
+	}
+}
+
+func TestQueryLink(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/orders?limit=10&cursor=abc", nil)
+	if got := QueryLink(req, "cursor", "def").Href; got != "http://example.com/orders?cursor=def&limit=10" {
+		t.Errorf("unexpected next link %q", got)
+	}
+	if got := QueryLink(req, "cursor", "").Href; got != "http://example.com/orders?limit=10" {
+		t.Errorf("unexpected first link %q", got)
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/a%2Fb?x=1", nil)
+	req.TLS = &tls.ConnectionState{}
+	if got := RequestURL(req).String(); got != "https://example.com/a%2Fb?x=1" {
+		t.Errorf("unexpected URL %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "api.example.com")
+	if got := RequestURL(req).String(); got != "http://example.com/orders" {
+		t.Errorf("expected forwarded headers to be ignored without TrustProxies, got %q", got)
+	}
+}
+
+// trustedURL returns the RequestURL seen by a handler behind TrustProxies
+// for a request from remoteAddr.
+func trustedURL(t *testing.T, remoteAddr string, header http.Header) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(TrustProxies(netip.MustParsePrefix("10.0.0.0/8")))
+	var got string
+	r.GET("/orders", func(c *gin.Context) { got = RequestURL(c.Request).String() })
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header = header
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return got
+}
+
+func TestTrustProxies(t *testing.T) {
+	header := http.Header{
+		"X-Forwarded-Proto": {"HTTPS"},
+		"X-Forwarded-Host":  {"evil.example, api.example.com"},
+	}
+	if got := trustedURL(t, "10.1.2.3:4567", header); got != "https://api.example.com/orders" {
+		t.Errorf("expected the entry of the trusted proxy, got %q", got)
+	}
+	if got := trustedURL(t, "192.0.2.1:4567", header); got != "http://example.com/orders" {
+		t.Errorf("expected headers from an untrusted peer to be ignored, got %q", got)
+	}
+
+	header.Set("X-Forwarded-Proto", "javascript")
+	if got := trustedURL(t, "10.1.2.3:4567", header); got != "http://api.example.com/orders" {
+		t.Errorf("expected an invalid forwarded scheme to be ignored, got %q", got)
+	}
+}
@@ -0,0 +1,362 @@
+package response
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// Media types WriteNegotiated renders, in order of preference when the
+// client accepts several equally.
+const (
+	MIMEJSON = "application/json"
+	MIMEXML  = "application/xml"
+	MIMEYAML = "application/yaml"
+	MIMECSV  = "text/csv"
+)
+
+// Negotiate returns the offer the Accept header prefers, honoring q-values
+// and wildcards such as "text/*", or "" if none is acceptable. An empty
+// Accept header accepts the first offer. Ties go to the earlier offer.
+func Negotiate(accept string, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	type mediaRange struct {
+		typ, sub string
+		q        float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		typ, sub, _ := strings.Cut(mediaType, "/")
+		ranges = append(ranges, mediaRange{typ: typ, sub: sub, q: q})
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ, sub, _ := strings.Cut(offer, "/")
+		// The most specific matching range decides the quality of offer
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			s := -1
+			switch {
+			case r.typ == typ && r.sub == sub:
+				s = 2
+			case r.typ == typ && r.sub == "*":
+				s = 1
+			case r.typ == "*" && r.sub == "*":
+				s = 0
+			}
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// WriteNegotiated writes resp in the format the Accept header of r
+// prefers: JSON, XML, YAML or, when the content is a list or a paginated
+// response, CSV with one row per result. Field names follow the
+// json tags of the content in every format. A 406 is written if no format
+// is acceptable.
+//
+// Example:
+//
+//	func exportUsers(w http.ResponseWriter, r *http.Request) {
+//		_ = response.WriteNegotiated(w, r, response.NewResponse(200, "ok", users))
+//	}
+func WriteNegotiated(w http.ResponseWriter, r *http.Request, resp *Response) error {
+	rows, isList := csvRows(resp.Content)
+	offers := []string{MIMEJSON, MIMEXML, MIMEYAML}
+	if isList {
+		offers = append(offers, MIMECSV)
+	}
+
+	mediaType := Negotiate(r.Header.Get("Accept"), offers...)
+	var body []byte
+	var err error
+	switch mediaType {
+	case MIMEJSON:
+		body, err = json.Marshal(resp)
+		body = append(body, '\n')
+	case MIMEXML:
+		body, err = marshalXML(resp)
+	case MIMEYAML:
+		body, err = marshalYAML(resp)
+	case MIMECSV:
+		body, err = marshalCSV(rows)
+		mediaType += "; charset=utf-8"
+	default:
+		http.Error(w, "not acceptable, supported media types: "+strings.Join(offers, ", "), http.StatusNotAcceptable)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode %s response: %w", mediaType, err)
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(resp.Status)
+	_, err = w.Write(body)
+	return err
+}
+
+// Render is the Gin version of WriteNegotiated. Encoding errors are added
+// to c.Errors and answered with a 500.
+//
+// Example:
+//
+//	r.GET("/reports/users", func(c *gin.Context) {
+//		response.Render(c, response.NewResponse(http.StatusOK, "ok", users))
+//	})
+func Render(c *gin.Context, resp *Response) {
+	if err := WriteNegotiated(c.Writer, c.Request, resp); err != nil {
+		_ = c.Error(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+// toGeneric returns v as decoded JSON, so the other formats use the json
+// tags of v.
+func toGeneric(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	err = d.Decode(&out)
+	return out, err
+}
+
+// marshalYAML encodes resp as YAML.
+func marshalYAML(resp *Response) ([]byte, error) {
+	v, err := toGeneric(resp)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(yamlNumbers(v))
+}
+
+// yamlNumbers converts the json.Number values of v, which YAML would quote,
+// to numbers.
+func yamlNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = yamlNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = yamlNumbers(e)
+		}
+	}
+	return v
+}
+
+// marshalXML encodes resp as a <response> document. Objects become nested
+// elements in key order, and list elements repeated <item> elements.
+func marshalXML(resp *Response) ([]byte, error) {
+	v, err := toGeneric(resp)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(&b)
+	if err := encodeXML(enc, "response", v); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// encodeXML writes v as the element name.
+func encodeXML(enc *xml.Encoder, name string, v interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: xmlName(name)}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := encodeXML(enc, k, v[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if err := encodeXML(enc, "item", e); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// xmlName replaces the characters of key not allowed in XML names by "_".
+func xmlName(key string) string {
+	if key == "" {
+		return "_"
+	}
+	b := []byte(key)
+	for i, c := range b {
+		ok := c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			i > 0 && (c == '-' || c == '.' || c >= '0' && c <= '9')
+		if !ok {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// csvRows returns the results of content as decoded JSON values, and
+// whether content is a list: a slice or array, a PaginatedResponse or a
+// CursorPaginatedResponse.
+func csvRows(content interface{}) ([]json.RawMessage, bool) {
+	switch p := content.(type) {
+	case *PaginatedResponse:
+		content = p.Results
+	case *CursorPaginatedResponse:
+		content = p.Results
+	}
+	b, err := json.Marshal(content)
+	if err != nil || len(b) == 0 || b[0] != '[' {
+		return nil, false
+	}
+	var rows []json.RawMessage
+	if err := json.Unmarshal(b, &rows); err != nil {
+		return nil, false
+	}
+	return rows, true
+}
+
+// marshalCSV encodes rows with a header row. Columns are the keys of the
+// objects in order of first appearance; nested values are written as JSON,
+// and rows that are not objects go to a "value" column.
+func marshalCSV(rows []json.RawMessage) ([]byte, error) {
+	var columns []string
+	seen := map[string]bool{}
+	records := make([]map[string]string, len(rows))
+	for i, raw := range rows {
+		keys, values, err := csvRecord(raw)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+		records[i] = values
+	}
+
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(columns))
+	for _, values := range records {
+		for i, k := range columns {
+			record[i] = values[k]
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
+
+// csvRecord returns the keys, in document order, and the cell values of
+// the JSON row raw.
+func csvRecord(raw json.RawMessage) ([]string, map[string]string, error) {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	if t, err := d.Token(); err != nil || t != json.Delim('{') {
+		return []string{"value"}, map[string]string{"value": csvCell(raw)}, nil
+	}
+
+	var keys []string
+	values := map[string]string{}
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := t.(string)
+		var v json.RawMessage
+		if err := d.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		values[key] = csvCell(v)
+	}
+	return keys, values, nil
+}
+
+// csvCell returns the JSON value raw as a cell: strings unquoted, null
+// empty, and anything else as JSON. Strings that a spreadsheet would run as
+// a formula are prefixed with a single quote.
+func csvCell(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+			return "'" + s
+		}
+		return s
+	}
+	if string(raw) == "null" {
+		return ""
+	}
+	return string(raw)
+}
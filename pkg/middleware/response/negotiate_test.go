@@ -0,0 +1,166 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type exportRow struct {
+	ID    int               `json:"id"`
+	Name  string            `json:"name"`
+	Tags  []string          `json:"tags,omitempty"`
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{MIMEJSON, MIMEXML, MIMECSV}
+	cases := []struct {
+		accept, want string
+	}{
+		{"", MIMEJSON},
+		{"*/*", MIMEJSON},
+		{"text/csv", MIMECSV},
+		{"application/xml;q=0.9, text/csv;q=0.5", MIMEXML},
+		{"text/*, application/json;q=0.1", MIMECSV},
+		{"*/*;q=0.1, application/xml", MIMEXML},
+		{"text/csv;q=0, */*", MIMEJSON},
+		{"image/png", ""},
+	}
+	for _, tc := range cases {
+		if got := Negotiate(tc.accept, offers...); got != tc.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tc.accept, got, tc.want)
+		}
+	}
+}
+
+func writeNegotiated(t *testing.T, accept string, resp *Response) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	if err := WriteNegotiated(rec, req, resp); err != nil {
+		t.Fatalf("WriteNegotiated: %v", err)
+	}
+	return rec
+}
+
+func TestWriteNegotiatedJSONByDefault(t *testing.T) {
+	rec := writeNegotiated(t, "", NewResponse(http.StatusOK, "ok", map[string]string{"a": "b"}))
+	if ct := rec.Header().Get("Content-Type"); ct != MIMEJSON {
+		t.Fatalf("expected JSON, got %q", ct)
+	}
+	if got := rec.Body.String(); got != `{"status":200,"message":"ok","content":{"a":"b"}}`+"\n" {
+		t.Errorf("unexpected body %q", got)
+	}
+	if rec.Header().Get("Vary") != "Accept" {
+		t.Error("expected Vary: Accept")
+	}
+}
+
+func TestWriteNegotiatedXML(t *testing.T) {
+	rows := []exportRow{{ID: 1, Name: "a & b", Tags: []string{"x"}}}
+	rec := writeNegotiated(t, "application/xml", NewResponse(http.StatusOK, "ok", rows))
+	if ct := rec.Header().Get("Content-Type"); ct != MIMEXML {
+		t.Fatalf("expected XML, got %q", ct)
+	}
+	want := `<response><content><item><id>1</id><name>a &amp; b</name><tags><item>x</item></tags></item></content><message>ok</message><status>200</status></response>`
+	if !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("unexpected body %q", rec.Body.String())
+	}
+}
+
+func TestWriteNegotiatedYAML(t *testing.T) {
+	rec := writeNegotiated(t, "application/yaml", NewResponse(http.StatusCreated, "created", exportRow{ID: 7, Name: "nick"}))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d", rec.Code)
+	}
+	want := "content:\n    id: 7\n    name: nick\nmessage: created\nstatus: 201\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("unexpected body %q", got)
+	}
+}
+
+func TestWriteNegotiatedCSV(t *testing.T) {
+	rows := []exportRow{
+		{ID: 1, Name: "alice"},
+		{ID: 2, Name: "bob, jr", Tags: []string{"a", "b"}, Extra: map[string]string{"k": "v"}},
+	}
+	rec := writeNegotiated(t, "text/csv", NewPaginatedResponse(http.StatusOK, 2, "ok", "", "", rows))
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Fatalf("expected CSV, got %q", ct)
+	}
+	want := "id,name,tags,extra\n1,alice,,\n2,\"bob, jr\",\"[\"\"a\"\",\"\"b\"\"]\",\"{\"\"k\"\":\"\"v\"\"}\"\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("unexpected body %q", got)
+	}
+}
+
+func TestWriteNegotiatedCSVScalars(t *testing.T) {
+	rec := writeNegotiated(t, "text/csv", NewResponse(http.StatusOK, "ok", []string{"a", "b"}))
+	if got := rec.Body.String(); got != "value\na\nb\n" {
+		t.Errorf("unexpected body %q", got)
+	}
+}
+
+func TestWriteNegotiatedCSVEscapesFormulas(t *testing.T) {
+	rows := []exportRow{
+		{ID: -1, Name: "=HYPERLINK(\"http://evil.example\")"},
+		{ID: 2, Name: "+1"},
+		{ID: 3, Name: "-2"},
+		{ID: 4, Name: "@SUM(A1)"},
+		{ID: 5, Name: "a=b"},
+	}
+	rec := writeNegotiated(t, "text/csv", NewResponse(http.StatusOK, "ok", rows))
+	want := "id,name\n" +
+		"-1,\"'=HYPERLINK(\"\"http://evil.example\"\")\"\n" +
+		"2,'+1\n" +
+		"3,'-2\n" +
+		"4,'@SUM(A1)\n" +
+		"5,a=b\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("unexpected body %q", got)
+	}
+}
+
+func TestWriteNegotiatedCSVRequiresList(t *testing.T) {
+	rec := writeNegotiated(t, "text/csv", NewResponse(http.StatusOK, "ok", exportRow{ID: 1}))
+	if rec.Code != http.StatusNotAcceptable {
+		t.Errorf("expected 406 for CSV of a single object, got %d", rec.Code)
+	}
+
+	rec = writeNegotiated(t, "text/csv, application/json;q=0.5", NewResponse(http.StatusOK, "ok", exportRow{ID: 1}))
+	if ct := rec.Header().Get("Content-Type"); ct != MIMEJSON {
+		t.Errorf("expected JSON fallback, got %q", ct)
+	}
+}
+
+func TestRender(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/export", func(c *gin.Context) {
+		Render(c, NewResponse(http.StatusOK, "ok", []exportRow{{ID: 1, Name: "a"}}))
+	})
+	r.GET("/broken", func(c *gin.Context) {
+		Render(c, NewResponse(http.StatusOK, "ok", func() {}))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	req.Header.Set("Accept", "text/csv")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if got := rec.Body.String(); got != "id,name\n1,a\n" {
+		t.Errorf("unexpected body %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/broken", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for an unencodable content, got %d", rec.Code)
+	}
+}
@@ -13,13 +13,19 @@ type Response struct {
 	Status  int         `json:"status"`
 	Message string      `json:"message"`
 	Content interface{} `json:"content"`
+
+	// Links are the related resources and actions, keyed by relation such
+	// as "self", "next" or "related". See WithLink.
+	Links map[string]Link `json:"links,omitempty"`
 }
 
-// ResponseLogger wraps gin.ResponseWriter to capture status codes
-// while preserving full compatibility with Gin's writer interface.
+// ResponseLogger wraps gin.ResponseWriter to capture status codes and the
+// response body size while preserving full compatibility with Gin's writer
+// interface.
 type ResponseLogger struct {
 	gin.ResponseWriter
 	statusCode int
+	size       int
 }
 
 // NewWriter wraps a gin.ResponseWriter for logging and status tracking.
@@ -41,6 +47,26 @@ func (w *ResponseLogger) Status() int {
 	return w.statusCode
 }
 
+// Write records the number of bytes written and forwards them.
+func (w *ResponseLogger) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// WriteString records the number of bytes written and forwards them.
+func (w *ResponseLogger) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	w.size += n
+	return n, err
+}
+
+// BytesWritten returns the number of response body bytes written, 0 when
+// no body was written.
+func (w *ResponseLogger) BytesWritten() int {
+	return w.size
+}
+
 // PaginatedResponse defines the schema for paginated API results.
 type PaginatedResponse struct {
 	Count    int         `json:"count"`
@@ -3,8 +3,11 @@ package response
 import (
 	"bytes"
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/gin-gonic/gin"
 )
 
 func TestNewResponse(t *testing.T) {
@@ -48,3 +51,24 @@ func TestWriteJSON(t *testing.T) {
 		t.Error("expected Content-Type: application/json")
 	}
 }
+
+func TestResponseLogger_BytesWritten(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	w := NewWriter(c.Writer)
+
+	if w.BytesWritten() != 0 {
+		t.Errorf("expected 0 bytes before any write, got %d", w.BytesWritten())
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("hello "))
+	w.WriteString("world")
+
+	if w.BytesWritten() != 11 || rec.Body.String() != "hello world" {
+		t.Errorf("expected 11 bytes written, got %d (%q)", w.BytesWritten(), rec.Body.String())
+	}
+	if w.Status() != http.StatusAccepted {
+		t.Errorf("expected status 202, got %d", w.Status())
+	}
+}
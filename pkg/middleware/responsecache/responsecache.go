@@ -0,0 +1,381 @@
+// Package responsecache provides a Gin middleware that caches successful GET
+// responses in a cache.Cache backend and serves conditional requests
+// (If-None-Match) with 304 Not Modified directly from the cache.
+package responsecache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ranorsolutions/http-common-go/pkg/cache"
+	"github.com/ranorsolutions/http-common-go/pkg/internal/etag"
+)
+
+// DefaultMaxBodySize is the largest response body cached when
+// Config.MaxBodySize is not set.
+const DefaultMaxBodySize = 1 << 20
+
+// Config controls the behavior of the response cache middleware.
+type Config struct {
+	// Cache is the backend used to store responses. Required.
+	Cache cache.Cache
+
+	// TTL is the lifetime of cached responses. If <= 0, the cache's
+	// DefaultTTL is used.
+	TTL time.Duration
+
+	// KeyFunc derives the cache key for a request. Defaults to DefaultKey.
+	KeyFunc func(c *gin.Context) string
+
+	// MaxBodySize is the largest response body, in bytes, that is buffered
+	// and cached. Larger responses are streamed to the client uncached.
+	// Defaults to DefaultMaxBodySize.
+	MaxBodySize int
+}
+
+// entry is the cached representation of a response.
+type entry struct {
+	Status int                 `json:"status"`
+	Header map[string][]string `json:"header"`
+	Body   []byte              `json:"body"`
+	ETag   string              `json:"etag"`
+
+	// Vary holds the request headers the response depends on and the
+	// values it was produced for.
+	Vary map[string]string `json:"vary,omitempty"`
+}
+
+// etagEntry is the stored ETag of an entry, with the Vary of the entry and
+// the headers a 304 for it must carry.
+type etagEntry struct {
+	ETag   string              `json:"etag"`
+	Vary   map[string]string   `json:"vary,omitempty"`
+	Header map[string][]string `json:"header,omitempty"`
+}
+
+// cachedHeaders lists the response headers replayed from the cache.
+var cachedHeaders = []string{"Content-Type", "Content-Language", "Cache-Control", "Last-Modified", "Vary"}
+
+// notModifiedHeaders lists the cached headers a 304 repeats from the 200
+// response (RFC 9110, section 15.4.5).
+var notModifiedHeaders = []string{"Cache-Control", "Vary"}
+
+// implicitVary lists the request headers every cached response is assumed
+// to vary on, besides those of its Vary header: negotiated formats, links
+// built from forwarded hosts and cookie-based sessions.
+var implicitVary = []string{"Accept", "Cookie", "X-Forwarded-Host", "X-Forwarded-Proto"}
+
+// varyValues returns the values of the request headers response headers h
+// vary on, and false for "Vary: *", which is never cached.
+func varyValues(req *http.Request, h http.Header) (map[string]string, bool) {
+	names := append([]string{}, implicitVary...)
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name == "*" {
+				return nil, false
+			} else if name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	values := map[string]string{}
+	for _, name := range names {
+		if v := strings.Join(req.Header.Values(name), ","); v != "" {
+			values[http.CanonicalHeaderKey(name)] = v
+		}
+	}
+	return values, true
+}
+
+// varyMatches reports whether req has the header values stored in vary.
+// Implicit headers absent from vary must be absent from req too.
+func varyMatches(req *http.Request, vary map[string]string) bool {
+	for name, v := range vary {
+		if strings.Join(req.Header.Values(name), ",") != v {
+			return false
+		}
+	}
+	for _, name := range implicitVary {
+		if _, ok := vary[name]; !ok && req.Header.Get(name) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// storable reports whether the response headers h allow a shared cache to
+// store the response.
+func storable(h http.Header) bool {
+	if len(h.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	for _, v := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			directive, _, _ = strings.Cut(strings.TrimSpace(directive), "=")
+			switch strings.ToLower(directive) {
+			case "no-store", "private", "no-cache":
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// DefaultKey returns a cache key built from the request method and URI.
+func DefaultKey(c *gin.Context) string {
+	return "respcache:" + c.Request.Method + ":" + c.Request.URL.RequestURI()
+}
+
+// etagKey returns the key under which only the ETag of an entry is stored,
+// so conditional requests never need to load the full body.
+func etagKey(key string) string {
+	return key + ":etag"
+}
+
+// Middleware returns a Gin middleware that caches 200 responses to GET
+// requests, attaches a strong ETag and answers matching If-None-Match
+// requests with 304 without invoking the handler or reading the cached body.
+//
+// A cached response is only served to requests with the same values for the
+// headers it varies on: those of its Vary header, plus Accept, Cookie,
+// X-Forwarded-Host and X-Forwarded-Proto. A key holds one variant at a
+// time. Requests with an Authorization header, and responses with
+// Set-Cookie, "Vary: *" or a Cache-Control of no-store, no-cache or
+// private, are never cached. Bodies larger than Config.MaxBodySize are
+// streamed to the client without being cached.
+//
+// Example:
+//
+//	r.GET("/products", responsecache.Middleware(&responsecache.Config{
+//	    Cache: redisCache,
+//	    TTL:   time.Minute,
+//	}), listProducts)
+func Middleware(cfg *Config) gin.HandlerFunc {
+	keyFunc := cfg.KeyFunc
+	if keyFunc == nil {
+		keyFunc = DefaultKey
+	}
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = cfg.Cache.DefaultTTL()
+	}
+	maxBody := cfg.MaxBodySize
+	if maxBody <= 0 {
+		maxBody = DefaultMaxBodySize
+	}
+
+	return func(c *gin.Context) {
+		// Responses to authenticated requests are user-specific
+		if c.Request.Method != http.MethodGet || c.GetHeader("Authorization") != "" {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		key := keyFunc(c)
+
+		// Conditional request: only the ETag is fetched.
+		if inm := c.GetHeader("If-None-Match"); inm != "" {
+			var tag etagEntry
+			if found, err := cfg.Cache.GetJSON(ctx, etagKey(key), &tag); err == nil && found &&
+				varyMatches(c.Request, tag.Vary) && etag.Matches(inm, tag.ETag) {
+				for h, values := range tag.Header {
+					for _, v := range values {
+						c.Writer.Header().Add(h, v)
+					}
+				}
+				c.Header("ETag", tag.ETag)
+				c.AbortWithStatus(http.StatusNotModified)
+				return
+			}
+		}
+
+		var cached entry
+		if found, err := cfg.Cache.GetJSON(ctx, key, &cached); err == nil && found && varyMatches(c.Request, cached.Vary) {
+			replay(c, &cached)
+			return
+		}
+
+		w := newBufferedWriter(c.Writer, maxBody)
+		func() {
+			// Restored even if a handler panics, so the recovery
+			// middleware writes its 500 to the client
+			defer func() { c.Writer = w.ResponseWriter }()
+			c.Writer = w
+			c.Next()
+		}()
+		if w.streaming {
+			return
+		}
+
+		status := w.Status()
+		body := w.buf.Bytes()
+		vary, ok := varyValues(c.Request, w.Header())
+		if status != http.StatusOK || !ok || !storable(w.Header()) {
+			w.flush()
+			return
+		}
+
+		tag := w.Header().Get("ETag")
+		if tag == "" {
+			tag = ETag(body)
+			w.Header().Set("ETag", tag)
+		}
+
+		stored := &entry{Status: status, Header: map[string][]string{}, Body: body, ETag: tag, Vary: vary}
+		for _, h := range cachedHeaders {
+			if v := w.Header().Values(h); len(v) > 0 {
+				stored.Header[h] = v
+			}
+		}
+		storedTag := &etagEntry{ETag: tag, Vary: vary, Header: map[string][]string{}}
+		for _, h := range notModifiedHeaders {
+			if v := w.Header().Values(h); len(v) > 0 {
+				storedTag.Header[h] = v
+			}
+		}
+		if err := cfg.Cache.SetJSON(ctx, key, stored, ttl); err == nil {
+			_ = cfg.Cache.SetJSON(ctx, etagKey(key), storedTag, ttl)
+		}
+
+		// The handler's headers, Cache-Control and Vary included, are
+		// already on the real writer.
+		if etag.Matches(c.GetHeader("If-None-Match"), tag) {
+			c.Writer.WriteHeader(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			return
+		}
+		w.flush()
+	}
+}
+
+// Invalidate removes a cached response and its ETag.
+func Invalidate(ctx context.Context, store cache.Cache, key string) error {
+	if err := store.Delete(ctx, etagKey(key)); err != nil {
+		return err
+	}
+	return store.Delete(ctx, key)
+}
+
+// ETag returns a strong entity tag for body.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// replay writes a cached entry to the client, honoring If-None-Match.
+func replay(c *gin.Context, e *entry) {
+	for h, values := range e.Header {
+		for _, v := range values {
+			c.Writer.Header().Add(h, v)
+		}
+	}
+	c.Header("ETag", e.ETag)
+	if etag.Matches(c.GetHeader("If-None-Match"), e.ETag) {
+		c.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+	c.Status(e.Status)
+	_, _ = c.Writer.Write(e.Body)
+	c.Abort()
+}
+
+// bufferedWriter holds the response in memory until the middleware decides
+// whether to cache it, so the ETag header can be added before the body.
+// Once the body grows past max bytes it switches to streaming: what was
+// buffered is sent and later writes go straight to the client.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	status    int
+	max       int
+	streaming bool
+}
+
+func newBufferedWriter(w gin.ResponseWriter, max int) *bufferedWriter {
+	return &bufferedWriter{ResponseWriter: w, status: http.StatusOK, max: max}
+}
+
+// WriteHeader records the status code without sending it.
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow is deferred until flush.
+func (w *bufferedWriter) WriteHeaderNow() {
+	if w.streaming {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// Write buffers the body.
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	if w.stream(len(data)) {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.buf.Write(data)
+}
+
+// WriteString buffers the body.
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	if w.stream(len(s)) {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.buf.WriteString(s)
+}
+
+// stream reports whether a write of n bytes must bypass the buffer,
+// flushing the buffer the first time the body outgrows max.
+func (w *bufferedWriter) stream(n int) bool {
+	if !w.streaming && w.buf.Len()+n > w.max {
+		w.flush()
+		w.buf.Reset()
+		w.streaming = true
+	}
+	return w.streaming
+}
+
+// Status returns the recorded status code.
+func (w *bufferedWriter) Status() int {
+	if w.streaming {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+// Size returns the number of buffered bytes.
+func (w *bufferedWriter) Size() int {
+	if w.streaming {
+		return w.ResponseWriter.Size()
+	}
+	return w.buf.Len()
+}
+
+// Written reports whether a body has been buffered.
+func (w *bufferedWriter) Written() bool {
+	if w.streaming {
+		return w.ResponseWriter.Written()
+	}
+	return w.buf.Len() > 0
+}
+
+// flush sends the recorded status and buffered body to the real writer.
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.WriteHeaderNow()
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+}
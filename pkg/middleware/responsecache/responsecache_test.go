@@ -0,0 +1,267 @@
+package responsecache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/ranorsolutions/http-common-go/pkg/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// --- Helpers ---
+
+func newTestRouter(t *testing.T) (*gin.Engine, cache.Cache, *int) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	store := cache.NewRedisCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}), time.Minute)
+	calls := 0
+
+	r := gin.New()
+	r.Use(Middleware(&Config{Cache: store}))
+	r.GET("/items", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"items": []int{1, 2, 3}})
+	})
+	r.GET("/missing", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	})
+	return r, store, &calls
+}
+
+func get(r *gin.Engine, path, inm string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	if inm != "" {
+		req.Header.Set("If-None-Match", inm)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// --- Tests ---
+
+func TestMiddleware_CachesAndSetsETag(t *testing.T) {
+	r, _, calls := newTestRouter(t)
+
+	first := get(r, "/items", "")
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", first.Code)
+	}
+	tag := first.Header().Get("ETag")
+	if tag == "" {
+		t.Fatal("expected ETag header")
+	}
+
+	second := get(r, "/items", "")
+	if second.Code != http.StatusOK || second.Body.String() != first.Body.String() {
+		t.Fatalf("expected cached body, got %d %q", second.Code, second.Body.String())
+	}
+	if second.Header().Get("Content-Type") == "" {
+		t.Error("expected Content-Type to be replayed from cache")
+	}
+	if *calls != 1 {
+		t.Errorf("expected handler to run once, ran %d times", *calls)
+	}
+}
+
+func TestMiddleware_ConditionalRequestReturns304(t *testing.T) {
+	r, _, calls := newTestRouter(t)
+
+	tag := get(r, "/items", "").Header().Get("ETag")
+
+	w := get(r, "/items", tag)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %q", w.Body.String())
+	}
+	if *calls != 1 {
+		t.Errorf("expected handler to run once, ran %d times", *calls)
+	}
+
+	if w := get(r, "/items", `"other"`); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for non-matching ETag, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_NotModifiedRepeatsCacheHeaders(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	r.GET("/fresh", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=60")
+		c.Header("Vary", "Accept-Language")
+		c.String(http.StatusOK, "fresh")
+	})
+
+	tag := get(r, "/fresh", "").Header().Get("ETag")
+	if w := get(r, "/fresh", tag); w.Code != http.StatusNotModified ||
+		w.Header().Get("Cache-Control") != "public, max-age=60" || w.Header().Get("Vary") != "Accept-Language" {
+		t.Errorf("expected a 304 with Cache-Control and Vary, got %d %v", w.Code, w.Header())
+	}
+
+	r.GET("/first", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=60")
+		c.String(http.StatusOK, "fresh")
+	})
+	if w := get(r, "/first", ETag([]byte("fresh"))); w.Code != http.StatusNotModified || w.Header().Get("Cache-Control") == "" {
+		t.Errorf("expected an uncached 304 with Cache-Control, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestMiddleware_StreamsLargeBodies(t *testing.T) {
+	_, store, _ := newTestRouter(t)
+	r := gin.New()
+	r.Use(Middleware(&Config{Cache: store, MaxBodySize: 8}))
+	calls := 0
+	r.GET("/large", func(c *gin.Context) {
+		calls++
+		c.Header("Content-Type", "text/plain")
+		c.Status(http.StatusOK)
+		_, _ = c.Writer.WriteString("0123")
+		_, _ = c.Writer.WriteString("456789")
+		_, _ = c.Writer.WriteString("abc")
+	})
+
+	for i := 0; i < 2; i++ {
+		w := get(r, "/large", "")
+		if w.Code != http.StatusOK || w.Body.String() != "0123456789abc" {
+			t.Fatalf("expected the full body, got %d %q", w.Code, w.Body.String())
+		}
+		if w.Header().Get("ETag") != "" {
+			t.Error("expected no ETag on a streamed response")
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected large responses not to be cached, handler ran %d times", calls)
+	}
+}
+
+func TestMiddleware_SkipsNonOK(t *testing.T) {
+	r, _, calls := newTestRouter(t)
+
+	get(r, "/missing", "")
+	w := get(r, "/missing", "")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	if *calls != 2 {
+		t.Errorf("expected non-200 responses not to be cached, handler ran %d times", *calls)
+	}
+}
+
+func getWith(r *gin.Engine, path string, header ...string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_HonorsVary(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	calls := 0
+	r.GET("/export", func(c *gin.Context) {
+		calls++
+		c.Header("Vary", "Accept-Language")
+		c.String(http.StatusOK, c.GetHeader("Accept")+" "+c.GetHeader("Accept-Language"))
+	})
+
+	if w := getWith(r, "/export", "Accept", "text/csv", "Accept-Language", "fr"); w.Body.String() != "text/csv fr" {
+		t.Fatalf("unexpected body %q", w.Body.String())
+	}
+	if w := getWith(r, "/export", "Accept", "text/csv", "Accept-Language", "fr"); w.Body.String() != "text/csv fr" || calls != 1 {
+		t.Errorf("expected a cached hit, got %q after %d calls", w.Body.String(), calls)
+	}
+	if w := getWith(r, "/export", "Accept", "application/json", "Accept-Language", "fr"); w.Body.String() != "application/json fr" {
+		t.Errorf("expected another Accept not to get the CSV variant, got %q", w.Body.String())
+	}
+	if w := getWith(r, "/export", "Accept", "application/json", "Accept-Language", "de"); w.Body.String() != "application/json de" {
+		t.Errorf("expected the Vary header to be honored, got %q", w.Body.String())
+	}
+	if w := getWith(r, "/export", "Accept", "application/json", "Accept-Language", "de", "X-Forwarded-Host", "evil.example"); calls != 4 {
+		t.Errorf("expected a forwarded host not to get the cached response, got %q after %d calls", w.Body.String(), calls)
+	}
+
+	// Conditional requests check the variant too
+	tag := getWith(r, "/export", "Accept", "application/json", "Accept-Language", "de").Header().Get("ETag")
+	if w := getWith(r, "/export", "If-None-Match", tag, "Accept", "text/csv", "Accept-Language", "de"); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for another variant, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_SkipsPrivateResponses(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	calls := 0
+	r.GET("/private", func(c *gin.Context) {
+		calls++
+		c.Header("Cache-Control", "private, max-age=60")
+		c.String(http.StatusOK, "mine")
+	})
+	r.GET("/session", func(c *gin.Context) {
+		calls++
+		c.SetCookie("session", "s", 60, "/", "", true, true)
+		c.String(http.StatusOK, "hi")
+	})
+	r.GET("/me", func(c *gin.Context) {
+		calls++
+		c.String(http.StatusOK, c.GetHeader("Authorization"))
+	})
+
+	for _, path := range []string{"/private", "/session"} {
+		get(r, path, "")
+		get(r, path, "")
+	}
+	getWith(r, "/me", "Authorization", "Bearer alice")
+	if w := getWith(r, "/me", "Authorization", "Bearer bob"); w.Body.String() != "Bearer bob" {
+		t.Errorf("expected no cached response for another user, got %q", w.Body.String())
+	}
+	if calls != 6 {
+		t.Errorf("expected no response to be cached, handlers ran %d times", calls)
+	}
+}
+
+func TestMiddleware_PanicReachesRecovery(t *testing.T) {
+	_, store, _ := newTestRouter(t)
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+		c.String(http.StatusInternalServerError, "recovered")
+	}))
+	r.Use(Middleware(&Config{Cache: store}))
+	r.GET("/panic", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("boom")
+	})
+
+	w := get(r, "/panic", "")
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "recovered" {
+		t.Errorf("expected the recovery response, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	r, store, calls := newTestRouter(t)
+
+	get(r, "/items", "")
+	if err := Invalidate(context.Background(), store, "respcache:GET:/items"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	get(r, "/items", "")
+	if *calls != 2 {
+		t.Errorf("expected handler to run again after invalidation, ran %d times", *calls)
+	}
+}
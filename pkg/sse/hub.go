@@ -0,0 +1,84 @@
+package sse
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultHubBuffer is the number of events buffered per subscriber unless
+// NewHub is given another size.
+const DefaultHubBuffer = 16
+
+// Hub fans events out to the subscribers of a topic in this process. Use a
+// RedisBridge to reach the subscribers of other instances too.
+type Hub struct {
+	buffer int
+
+	mu     sync.RWMutex
+	topics map[string]map[chan Event]struct{}
+
+	dropped atomic.Int64
+}
+
+// NewHub returns a Hub buffering buffer events per subscriber;
+// DefaultHubBuffer when buffer <= 0.
+func NewHub(buffer int) *Hub {
+	if buffer <= 0 {
+		buffer = DefaultHubBuffer
+	}
+	return &Hub{buffer: buffer, topics: map[string]map[chan Event]struct{}{}}
+}
+
+// Subscribe returns the events published to topic from now on, and the
+// function that unsubscribes and closes the channel. Call it when the
+// client disconnects.
+func (h *Hub) Subscribe(topic string) (<-chan Event, func()) {
+	ch := make(chan Event, h.buffer)
+	h.mu.Lock()
+	if h.topics[topic] == nil {
+		h.topics[topic] = map[chan Event]struct{}{}
+	}
+	h.topics[topic][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.topics[topic], ch)
+			if len(h.topics[topic]) == 0 {
+				delete(h.topics, topic)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends e to the current subscribers of topic without blocking.
+// Subscribers whose buffer is full miss the event, counted by Dropped, so
+// one slow client cannot stall the others; they can catch up by
+// reconnecting with the Last-Event-ID.
+func (h *Hub) Publish(topic string, e Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.topics[topic] {
+		select {
+		case ch <- e:
+		default:
+			h.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribers returns the number of subscribers of topic.
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// Dropped returns the number of events missed by slow subscribers.
+func (h *Hub) Dropped() int64 {
+	return h.dropped.Load()
+}
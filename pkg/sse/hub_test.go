@@ -0,0 +1,52 @@
+package sse
+
+import "testing"
+
+func TestHubPublishSubscribe(t *testing.T) {
+	h := NewHub(1)
+	a, unsubscribeA := h.Subscribe("orders")
+	b, unsubscribeB := h.Subscribe("orders")
+	other, unsubscribeOther := h.Subscribe("users")
+	defer unsubscribeB()
+	defer unsubscribeOther()
+
+	if n := h.Subscribers("orders"); n != 2 {
+		t.Errorf("expected 2 subscribers, got %d", n)
+	}
+
+	h.Publish("orders", Event{Data: "1"})
+	for _, ch := range []<-chan Event{a, b} {
+		if e := <-ch; e.Data != "1" {
+			t.Errorf("unexpected event %+v", e)
+		}
+	}
+	select {
+	case e := <-other:
+		t.Errorf("unexpected event on another topic %+v", e)
+	default:
+	}
+
+	unsubscribeA()
+	unsubscribeA()
+	if _, ok := <-a; ok {
+		t.Error("expected the channel to be closed")
+	}
+	if n := h.Subscribers("orders"); n != 1 {
+		t.Errorf("expected 1 subscriber, got %d", n)
+	}
+}
+
+func TestHubDropsForSlowSubscribers(t *testing.T) {
+	h := NewHub(1)
+	ch, unsubscribe := h.Subscribe("orders")
+	defer unsubscribe()
+
+	h.Publish("orders", Event{Data: "1"})
+	h.Publish("orders", Event{Data: "2"})
+	if got := h.Dropped(); got != 1 {
+		t.Errorf("expected 1 dropped event, got %d", got)
+	}
+	if e := <-ch; e.Data != "1" {
+		t.Errorf("expected the first event, got %+v", e)
+	}
+}
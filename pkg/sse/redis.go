@@ -0,0 +1,96 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisPrefix prefixes the Redis channels of RedisBridge topics
+// unless another prefix is given.
+const DefaultRedisPrefix = "sse:"
+
+// redisEvent is the Redis message of an Event, with Data already encoded.
+type redisEvent struct {
+	ID    string        `json:"id,omitempty"`
+	Event string        `json:"event,omitempty"`
+	Data  string        `json:"data"`
+	Retry time.Duration `json:"retry,omitempty"`
+}
+
+// RedisBridge broadcasts events to the Hub of every instance through Redis
+// pub/sub. Each instance runs the bridge and publishes through it rather
+// than through its Hub; events reach the local subscribers on their way
+// back from Redis. Pub/sub does not persist messages, so events published
+// while an instance is disconnected from Redis are lost to its clients.
+type RedisBridge struct {
+	client redis.UniversalClient
+	hub    *Hub
+	prefix string
+}
+
+// NewRedisBridge returns a bridge delivering to hub the events published on
+// client under prefix, DefaultRedisPrefix when empty.
+func NewRedisBridge(client redis.UniversalClient, hub *Hub, prefix string) *RedisBridge {
+	if prefix == "" {
+		prefix = DefaultRedisPrefix
+	}
+	return &RedisBridge{client: client, hub: hub, prefix: prefix}
+}
+
+// Publish broadcasts e to the subscribers of topic on every instance.
+func (b *RedisBridge) Publish(ctx context.Context, topic string, e Event) error {
+	data, err := e.data()
+	if err != nil {
+		return fmt.Errorf("failed to encode event data: %w", err)
+	}
+	payload, err := json.Marshal(redisEvent{ID: e.ID, Event: e.Event, Data: data, Retry: e.Retry})
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.prefix+topic, payload).Err()
+}
+
+// Run delivers the events published by every instance to the Hub until ctx
+// is canceled, which returns nil. Malformed messages are skipped.
+//
+// Example:
+//
+//	bridge := sse.NewRedisBridge(client, hub, "")
+//	go func() { _ = bridge.Run(ctx) }()
+//	...
+//	_ = bridge.Publish(ctx, "orders", sse.Event{Event: "created", Data: order})
+func (b *RedisBridge) Run(ctx context.Context) error {
+	sub := b.client.PSubscribe(ctx, b.prefix+"*")
+	defer sub.Close()
+	// Waits for the subscription, so events published after Run started
+	// are not missed
+	if _, err := sub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to subscribe to %s*: %w", b.prefix, err)
+	}
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var e redisEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+				continue
+			}
+			topic := strings.TrimPrefix(msg.Channel, b.prefix)
+			b.hub.Publish(topic, Event{ID: e.ID, Event: e.Event, Data: e.Data, Retry: e.Retry})
+		}
+	}
+}
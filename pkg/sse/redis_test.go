@@ -0,0 +1,69 @@
+package sse
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisBridge(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	// Two instances sharing Redis
+	hubA, hubB := NewHub(0), NewHub(0)
+	bridgeA := NewRedisBridge(client, hubA, "")
+	bridgeB := NewRedisBridge(client, hubB, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := make(chan error, 2)
+	go func() { results <- bridgeA.Run(ctx) }()
+	go func() { results <- bridgeB.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		n, err := client.PubSubNumPat(ctx).Result()
+		if err == nil && n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("bridges did not subscribe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	events, unsubscribe := hubB.Subscribe("orders")
+	defer unsubscribe()
+	local, unsubscribeLocal := hubA.Subscribe("orders")
+	defer unsubscribeLocal()
+
+	if err := bridgeA.Publish(ctx, "orders", Event{ID: "1", Event: "created", Data: map[string]string{"id": "o-1"}, Retry: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	want := Event{ID: "1", Event: "created", Data: `{"id":"o-1"}`, Retry: time.Second}
+	for name, ch := range map[string]<-chan Event{"remote": events, "local": local} {
+		select {
+		case e := <-ch:
+			if e != want {
+				t.Errorf("%s: got %+v, want %+v", name, e, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: event was not delivered", name)
+		}
+	}
+
+	if err := bridgeA.Publish(ctx, "orders", Event{Data: func() {}}); err == nil {
+		t.Error("expected an error for unencodable data")
+	}
+
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package sse streams Server-Sent Events to browsers: event framing,
+// heartbeats and client-disconnect detection in Stream, in-process fan-out
+// to many clients in Hub, and a RedisBridge that broadcasts through Redis
+// pub/sub so every instance of a service reaches its own clients.
+package sse
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHeartbeat is the keep-alive interval of Run when none is given,
+// shorter than the idle timeout of common proxies and load balancers.
+const DefaultHeartbeat = 15 * time.Second
+
+// LastEventIDHeader is the header browsers send on reconnect with the ID of
+// the last event they received.
+const LastEventIDHeader = "Last-Event-ID"
+
+// Event is a Server-Sent Event.
+type Event struct {
+	// ID is stored by the browser and sent back in LastEventIDHeader on
+	// reconnect, so the stream can resume. Optional.
+	ID string `json:"id,omitempty"`
+
+	// Event is the event type the client listens to with addEventListener;
+	// "message" when empty.
+	Event string `json:"event,omitempty"`
+
+	// Data is the payload. Strings and byte slices are sent as is, other
+	// values as JSON.
+	Data interface{} `json:"data,omitempty"`
+
+	// Retry tells the browser how long to wait before reconnecting, sent in
+	// milliseconds. Zero keeps the browser default.
+	Retry time.Duration `json:"retry,omitempty"`
+}
+
+// data returns the payload of e as text.
+func (e Event) data() (string, error) {
+	switch d := e.Data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return d, nil
+	case []byte:
+		return string(d), nil
+	}
+	b, err := json.Marshal(e.Data)
+	return string(b), err
+}
+
+// frame returns e in the text/event-stream format. Multi-line data is sent
+// as one "data:" line per line, and line breaks in ID and Event, which
+// would end the field early, are removed.
+func (e Event) frame() ([]byte, error) {
+	data, err := e.data()
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + stripNewlines(e.ID) + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + stripNewlines(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+	data = strings.ReplaceAll(strings.ReplaceAll(data, "\r\n", "\n"), "\r", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return []byte(b.String()), nil
+}
+
+// stripNewlines removes the line breaks of s.
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// Stream writes events to one client. Its methods are safe for concurrent
+// use.
+type Stream struct {
+	w    http.ResponseWriter
+	rc   *http.ResponseController
+	done <-chan struct{}
+
+	mu sync.Mutex
+}
+
+// NewStream starts an event stream on w: it writes the 200 and the
+// text/event-stream headers, and flushes them so the client knows the
+// stream is open. It fails before writing anything if w cannot flush,
+// since events would then sit in a buffer.
+//
+// Example:
+//
+//	r.GET("/events", func(c *gin.Context) {
+//		stream, err := sse.NewStream(c.Writer, c.Request)
+//		if err != nil {
+//			response.WriteError(c, err)
+//			return
+//		}
+//		events, unsubscribe := hub.Subscribe("orders")
+//		defer unsubscribe()
+//		_ = stream.Run(events, 0)
+//	})
+func NewStream(w http.ResponseWriter, r *http.Request) (*Stream, error) {
+	if !canFlush(w) {
+		return nil, errors.New("sse: response writer does not support flushing")
+	}
+	s := &Stream{w: w, rc: http.NewResponseController(w), done: r.Context().Done()}
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	// Disables response buffering in nginx
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := s.rc.Flush(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// canFlush reports whether w, or a writer it wraps, is an http.Flusher.
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		switch v := w.(type) {
+		case http.Flusher:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+// LastEventID returns the ID of the last event the client received before
+// reconnecting, or "" on the first connection.
+func LastEventID(r *http.Request) string {
+	return r.Header.Get(LastEventIDHeader)
+}
+
+// Send writes e and flushes it to the client.
+func (s *Stream) Send(e Event) error {
+	frame, err := e.frame()
+	if err != nil {
+		return err
+	}
+	return s.write(frame)
+}
+
+// Comment writes a comment line, ignored by browsers. Proxies see traffic,
+// which keeps idle streams open.
+func (s *Stream) Comment(text string) error {
+	return s.write([]byte(": " + stripNewlines(text) + "\n\n"))
+}
+
+// write writes and flushes p.
+func (s *Stream) write(p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(p); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// Done is closed when the client disconnects.
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Run sends events until the channel is closed or the client disconnects,
+// which both return nil, or a write fails. A heartbeat comment is sent
+// every heartbeat without events; DefaultHeartbeat when zero, none when
+// negative.
+func (s *Stream) Run(events <-chan Event, heartbeat time.Duration) error {
+	if heartbeat == 0 {
+		heartbeat = DefaultHeartbeat
+	}
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return nil
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := s.Send(e); err != nil {
+				return err
+			}
+		case <-tick:
+			if err := s.Comment("heartbeat"); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventFrame(t *testing.T) {
+	cases := []struct {
+		name  string
+		event Event
+		want  string
+	}{
+		{"string", Event{Data: "hello"}, "data: hello\n\n"},
+		{"all fields", Event{ID: "7", Event: "created", Data: "x", Retry: 3 * time.Second},
+			"id: 7\nevent: created\nretry: 3000\ndata: x\n\n"},
+		{"multi-line", Event{Data: "a\nb\r\nc"}, "data: a\ndata: b\ndata: c\n\n"},
+		{"json", Event{Data: map[string]int{"id": 1}}, "data: {\"id\":1}\n\n"},
+		{"bytes", Event{Data: []byte("raw")}, "data: raw\n\n"},
+		{"no data", Event{Event: "ping"}, "event: ping\ndata: \n\n"},
+		{"injection", Event{ID: "1\ndata: evil", Event: "a\r\nb"}, "id: 1data: evil\nevent: ab\ndata: \n\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.event.frame()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := (Event{Data: func() {}}).frame(); err == nil {
+		t.Error("expected an error for unencodable data")
+	}
+}
+
+func TestNewStream(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s, err := NewStream(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected Content-Type %q", ct)
+	}
+	if !rec.Flushed {
+		t.Error("expected the headers to be flushed")
+	}
+	if err := s.Send(Event{ID: "1", Data: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Comment("hi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Body.String(); got != "id: 1\ndata: a\n\n: hi\n\n" {
+		t.Errorf("unexpected body %q", got)
+	}
+}
+
+// plainWriter is a ResponseWriter that cannot flush.
+type plainWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *plainWriter) Header() http.Header         { return w.header }
+func (w *plainWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *plainWriter) WriteHeader(status int)      { w.status = status }
+
+func TestNewStreamRequiresFlusher(t *testing.T) {
+	w := &plainWriter{header: http.Header{}}
+	if _, err := NewStream(w, httptest.NewRequest(http.MethodGet, "/events", nil)); err == nil {
+		t.Fatal("expected an error")
+	}
+	if w.status != 0 {
+		t.Error("expected nothing to be written")
+	}
+}
+
+func TestLastEventID(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	r.Header.Set(LastEventIDHeader, "42")
+	if got := LastEventID(r); got != "42" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRunStopsWhenEventsClose(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s, err := NewStream(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan Event, 2)
+	events <- Event{Data: "1"}
+	events <- Event{Data: "2"}
+	close(events)
+	if err := s.Run(events, -1); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Body.String(); got != "data: 1\n\ndata: 2\n\n" {
+		t.Errorf("unexpected body %q", got)
+	}
+}
+
+func TestRunHeartbeatAndDisconnect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := NewStream(w, r)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = s.Run(make(chan Event), 10*time.Millisecond)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	buf := make([]byte, 64)
+	n, err := resp.Body.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(buf[:n]), ": heartbeat\n\n") {
+		t.Errorf("expected a heartbeat, got %q", buf[:n])
+	}
+
+	// The handler returns once the client disconnects, which Close waits for
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		srv.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the client disconnected")
+	}
+}